@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// anthropicAPIKey is the key of the Anthropic API.
+var anthropicAPIKey = os.Getenv("ANTHROPIC_API_KEY")
+
+func init() {
+	registerProvider("anthropic", providerFactory{
+		defaultModel: ai.AnthropicDefaultModel,
+		keyEnv:       "ANTHROPIC_API_KEY",
+		hasKey:       func() bool { return anthropicAPIKey != "" },
+		new: func() (ai.Provider, error) {
+			return ai.NewAnthropic(anthropicAPIKey), nil
+		},
+	})
+}
@@ -0,0 +1,10 @@
+package main
+
+import "github.com/ernesto27/stui/pkg/player"
+
+func init() {
+	registerPlayer("applemusic", playerFactory{
+		name: "Music.app",
+		new:  func() (player.Player, error) { return player.AppleMusic{}, nil },
+	})
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// getArtist is replaced in tests.
+var getArtist = fetchArtist
+
+var errNoArtist = errors.New("no MusicBrainz artist found")
+
+// Artist is the MusicBrainz data that grounds the deep dive.
+type Artist struct {
+	ID   string
+	Name string
+	// Type is Person, Group, Orchestra...
+	Type    string
+	Members []ArtistMember
+	Albums  []ArtistAlbum
+}
+
+// ArtistMember is a member of a band and the years they played in it.
+type ArtistMember struct {
+	Name       string
+	Begin      string
+	End        string
+	Attributes []string
+}
+
+// ArtistAlbum is a studio album, the release groups without secondary types
+// such as live or compilation.
+type ArtistAlbum struct {
+	Title string
+	Date  string
+}
+
+// fetchArtist searches the artist on MusicBrainz and looks up its members
+// and studio albums.
+func fetchArtist(ctx context.Context, name string) (*Artist, error) {
+	var search struct {
+		Artists []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"artists"`
+	}
+	query := fmt.Sprintf(`artist:"%s"`, name)
+	if err := musicBrainzGet(ctx, "/artist/?fmt=json&limit=1&query="+url.QueryEscape(query), &search); err != nil {
+		return nil, err
+	}
+	if len(search.Artists) == 0 {
+		return nil, errNoArtist
+	}
+	found := search.Artists[0]
+	a := &Artist{ID: found.ID, Name: found.Name, Type: found.Type}
+
+	var lookup struct {
+		Relations []struct {
+			Type       string   `json:"type"`
+			Direction  string   `json:"direction"`
+			Begin      string   `json:"begin"`
+			End        string   `json:"end"`
+			Attributes []string `json:"attributes"`
+			Artist     struct {
+				Name string `json:"name"`
+			} `json:"artist"`
+		} `json:"relations"`
+	}
+	if err := musicBrainzGet(ctx, "/artist/"+a.ID+"?fmt=json&inc=artist-rels", &lookup); err != nil {
+		return nil, err
+	}
+	for _, r := range lookup.Relations {
+		// The other direction lists the bands the artist was a member of.
+		if r.Type == "member of band" && r.Direction == "backward" {
+			a.Members = append(a.Members, ArtistMember{Name: r.Artist.Name, Begin: r.Begin, End: r.End, Attributes: r.Attributes})
+		}
+	}
+
+	var groups struct {
+		ReleaseGroups []struct {
+			Title            string   `json:"title"`
+			FirstReleaseDate string   `json:"first-release-date"`
+			SecondaryTypes   []string `json:"secondary-types"`
+		} `json:"release-groups"`
+	}
+	if err := musicBrainzGet(ctx, "/release-group?fmt=json&type=album&limit=100&artist="+a.ID, &groups); err != nil {
+		return nil, err
+	}
+	for _, g := range groups.ReleaseGroups {
+		if len(g.SecondaryTypes) == 0 {
+			a.Albums = append(a.Albums, ArtistAlbum{Title: g.Title, Date: g.FirstReleaseDate})
+		}
+	}
+	sort.SliceStable(a.Albums, func(i, j int) bool { return a.Albums[i].Date < a.Albums[j].Date })
+
+	return a, nil
+}
+
+// membersFacts lists the members for a prompt, e.g. "Thom Yorke (1985-,
+// lead vocals, guitar)".
+func (a *Artist) membersFacts() string {
+	var b strings.Builder
+	for _, member := range a.Members {
+		details := []string{yearOf(member.Begin) + "-" + yearOf(member.End)}
+		details = append(details, member.Attributes...)
+		fmt.Fprintf(&b, "- %s (%s)\n", member.Name, strings.Join(details, ", "))
+	}
+	return b.String()
+}
+
+// albumsFacts lists the studio albums for a prompt, e.g. "1997 OK Computer".
+func (a *Artist) albumsFacts() string {
+	var b strings.Builder
+	for _, album := range a.Albums {
+		fmt.Fprintf(&b, "- %s %s\n", yearOf(album.Date), album.Title)
+	}
+	return b.String()
+}
+
+// yearOf is the year of a MusicBrainz date, which can be a year, a month or
+// a day.
+func yearOf(date string) string {
+	year, _, _ := strings.Cut(date, "-")
+	return year
+}
+
+// grounded asks to base the answer on the facts from MusicBrainz, the prompt
+// is left as is without them.
+func grounded(prompt string, facts string) string {
+	if facts == "" {
+		return prompt
+	}
+	return prompt + ". Base it on these facts from MusicBrainz:\n" + facts
+}
+
+// toggleDeepDive switches between the artist of the track and the track.
+// The deep dive stays on screen when the track changes, leaving it goes back
+// to the track playing.
+func (m *model) toggleDeepDive() tea.Cmd {
+	// Podcasts have no artist.
+	if !m.deepDive && m.artist == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	diving := m.deepDive
+	m.deepDive = !diving
+	m.mu.Unlock()
+
+	if diving {
+		info, status := getTrackInfo()
+		if status != PlayerPlaying || (info.artist == "" && !info.isPodcast()) {
+			info = m.diveFrom
+		}
+		// stui artist starts in the deep dive, without a track to go back to.
+		if info == (MusicInfo{}) {
+			m.reset(info)
+			m.loading = false
+			m.status = PlayerIdle
+			return m.waitForPlayback()
+		}
+		return m.refresh(info)
+	}
+
+	m.diveFrom = m.MusicInfo
+	return m.refresh(MusicInfo{artist: m.targetArtist()})
+}
+
+// getArtistInfo is getInfo for the deep dive: the sections are about the
+// artist and the ones with facts from MusicBrainz are grounded by them.
+func (m *model) getArtistInfo(ctx context.Context, info MusicInfo) {
+	name := info.artist
+	// MusicBrainz is the first step, the links the last.
+	m.whileCurrent(ctx, func() { m.steps = 2 })
+
+	artist, err := getArtist(ctx, name)
+	if err != nil && !errors.Is(err, errNoArtist) && ctx.Err() == nil {
+		m.whileCurrent(ctx, func() { m.errMsg = "  musicbrainz: " + err.Error() })
+	}
+	if artist == nil {
+		artist = &Artist{Name: name}
+	}
+	m.stepDone(ctx)
+
+	sections := []Section{{
+		key:    "bio",
+		Title:  "Artist bio",
+		prompt: fmt.Sprintf("Give me a biography of %s", name),
+	}}
+	if artist.Type != "Person" {
+		sections = append(sections, Section{
+			key:    "members",
+			Title:  "Band members",
+			prompt: grounded(fmt.Sprintf("Tell the history of the line-up of %s: who joined and left, when and why", name), artist.membersFacts()),
+		})
+	}
+	sections = append(sections, Section{
+		key:   "discography",
+		Title: "Discography timeline",
+		prompt: grounded(fmt.Sprintf("Write a timeline of the discography of %s, one entry per studio album with its year and how the sound changed", name),
+			artist.albumsFacts()),
+	})
+	sections = append(sections, Section{
+		key:    "trivia",
+		Title:  "Trivia",
+		prompt: fmt.Sprintf("Give me notable trivia about %s: records, anecdotes and lesser known facts", name),
+	})
+	for i := range sections {
+		sections[i].prompt = inLanguage(withLength(sections[i].key, sections[i].prompt))
+	}
+
+	m.whileCurrent(ctx, func() {
+		m.sections = sections
+		m.steps += len(sections)
+	})
+
+	var wg sync.WaitGroup
+	for i, s := range sections {
+		wg.Add(1)
+		index, title, prompt := i, s.Title, s.prompt
+		goSafe(func() { m.fetchSection(ctx, info, index, title, prompt, &wg) })
+	}
+	wg.Wait()
+
+	links := buildLinks(info)
+	m.whileCurrent(ctx, func() {
+		m.links = links
+		m.buildContent()
+	})
+	m.stepDone(ctx)
+}
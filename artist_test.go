@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+func TestFetchArtist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artist/":
+			if q := r.URL.Query().Get("query"); q != `artist:"Radiohead"` {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`{"artists":[{"id":"a74b1b7f","name":"Radiohead","type":"Group"}]}`))
+		case "/artist/a74b1b7f":
+			w.Write([]byte(`{"relations":[
+				{"type":"member of band","direction":"backward","begin":"1985","end":null,"attributes":["lead vocals","guitar"],"artist":{"name":"Thom Yorke"}},
+				{"type":"member of band","direction":"forward","begin":"2009","artist":{"name":"Atoms for Peace"}}
+			]}`))
+		case "/release-group":
+			if artist := r.URL.Query().Get("artist"); artist != "a74b1b7f" {
+				t.Errorf("got artist %q", artist)
+			}
+			w.Write([]byte(`{"release-groups":[
+				{"title":"OK Computer","first-release-date":"1997-05-21","secondary-types":[]},
+				{"title":"I Might Be Wrong","first-release-date":"2001-11-12","secondary-types":["Live"]},
+				{"title":"Pablo Honey","first-release-date":"1993-02-22","secondary-types":[]}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(mb string) { musicBrainzURL = mb }(musicBrainzURL)
+	musicBrainzURL = server.URL
+
+	a, err := fetchArtist(context.Background(), "Radiohead")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.membersFacts(); got != "- Thom Yorke (1985-, lead vocals, guitar)\n" {
+		t.Errorf("got members %q", got)
+	}
+	if got := a.albumsFacts(); got != "- 1993 Pablo Honey\n- 1997 OK Computer\n" {
+		t.Errorf("got albums %q", got)
+	}
+}
+
+func TestDeepDive(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('A'))
+	teatest.WaitFor(t, tm.Output(), contains("Discography"))
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		m.mu.Lock()
+		done := m.steps > 0 && m.stepsDone >= m.steps
+		m.mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	var titles []string
+	for _, s := range m.sections {
+		titles = append(titles, s.Title)
+	}
+	members := m.sections[1].Content
+	info := m.MusicInfo
+	m.mu.Unlock()
+
+	if got := strings.Join(titles, ", "); got != "Artist bio, Band members, Discography timeline, Trivia" {
+		t.Errorf("got sections %s", got)
+	}
+	if !strings.Contains(members, "Base it on these facts from MusicBrainz:\n- Thom Yorke (1985-)") {
+		t.Errorf("the members are not grounded: %q", members)
+	}
+	if info != (MusicInfo{artist: "Radiohead"}) {
+		t.Errorf("got %+v in the deep dive", info)
+	}
+
+	tm.Send(keyRune('A'))
+	teatest.WaitFor(t, tm.Output(), contains("2 Review"))
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+	if fm.deepDive || fm.MusicInfo != testTrack {
+		t.Errorf("got %+v after leaving the deep dive", fm.MusicInfo)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ernesto27/stui/pkg/stui"
+)
+
+// artistSep joins the featured artists of a MusicInfo, as a string keeps it
+// comparable.
+const artistSep = "\x1f"
+
+var (
+	// featRe splits the artist credited like "A feat. B" by the players that
+	// report a single name.
+	featRe = regexp.MustCompile(`(?i)\s+(?:feat\.?|ft\.|featuring)\s+`)
+	// titleFeatRe finds the artists in titles such as "Song (feat. B)" or
+	// "Song [with B]".
+	titleFeatRe = regexp.MustCompile(`(?i)[(\[](?:feat\.?|ft\.|featuring|with)\s+([^)\]]+)[)\]]`)
+	// featListRe splits the featured artists, "B, C & D". The names of the
+	// main artist are not split, "Simon & Garfunkel" is one.
+	featListRe = regexp.MustCompile(`\s*,\s*|\s+&\s+`)
+)
+
+// setArtists sets the artist of info to the first of names and the others,
+// along with the ones credited as featured in them or in the track title,
+// as the featured artists. The track must be set first.
+func (i *MusicInfo) setArtists(names ...string) {
+	var artists []string
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		for _, a := range artists {
+			if strings.EqualFold(a, name) {
+				return
+			}
+		}
+		artists = append(artists, name)
+	}
+	addFeatured := func(names string) {
+		for _, name := range featListRe.Split(names, -1) {
+			add(name)
+		}
+	}
+
+	for _, name := range names {
+		parts := featRe.Split(name, 2)
+		add(parts[0])
+		if len(parts) == 2 {
+			addFeatured(parts[1])
+		}
+	}
+	if match := titleFeatRe.FindStringSubmatch(i.track); match != nil {
+		addFeatured(match[1])
+	}
+
+	i.artist, i.featured = "", ""
+	if len(artists) > 0 {
+		i.artist = artists[0]
+		i.featured = strings.Join(artists[1:], artistSep)
+	}
+}
+
+// artists lists the artist and then the featured ones.
+func (i MusicInfo) artists() []string {
+	if i.featured == "" {
+		return []string{i.artist}
+	}
+	return append([]string{i.artist}, strings.Split(i.featured, artistSep)...)
+}
+
+// artistLine is the artists as they are shown, "A feat. B, C".
+func (i MusicInfo) artistLine() string {
+	if i.featured == "" {
+		return i.artist
+	}
+	return i.artist + " feat. " + strings.Join(i.artists()[1:], ", ")
+}
+
+// stuiTrack is info for the library of package stui.
+func (i MusicInfo) stuiTrack() stui.Track {
+	return stui.Track{Artist: i.artist, Featured: i.artists()[1:], Album: i.album, Track: i.track}
+}
+
+// withArtist returns info with name as its artist and the others featured,
+// info itself when name is not one of its artists.
+func (i MusicInfo) withArtist(name string) MusicInfo {
+	artists := i.artists()
+	for n, a := range artists {
+		if a == name && n > 0 {
+			others := append(append([]string{}, artists[:n]...), artists[n+1:]...)
+			i.artist, i.featured = name, strings.Join(others, artistSep)
+			return i
+		}
+	}
+	return i
+}
+
+// targetArtist is the artist the deep dive and the links are about, the one
+// picked with switchArtist or else the first.
+func (m *model) targetArtist() string {
+	return m.MusicInfo.withArtist(m.pickedArtist).artist
+}
+
+// switchArtist points the deep dive and the links to the next artist of the
+// track.
+func (m *model) switchArtist() {
+	artists := m.artists()
+	if len(artists) < 2 {
+		m.notice = "  " + tr("The track has a single artist")
+		return
+	}
+	next := artists[0]
+	for n, a := range artists {
+		if a == m.targetArtist() {
+			next = artists[(n+1)%len(artists)]
+		}
+	}
+	m.notice = "  " + trf("Deep dive and links: %s", next)
+
+	m.mu.Lock()
+	m.pickedArtist = next
+	rebuild := m.links != nil
+	if rebuild {
+		m.links = buildLinks(m.MusicInfo.withArtist(next))
+		m.buildContent()
+	}
+	m.mu.Unlock()
+	if rebuild {
+		if err := m.renderViewport(); err != nil {
+			m.errMsg = "  " + err.Error()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSetArtists(t *testing.T) {
+	for _, c := range []struct {
+		names []string
+		track string
+		want  []string
+	}{
+		{[]string{"Radiohead"}, "Airbag", []string{"Radiohead"}},
+		{[]string{"Simon & Garfunkel"}, "America", []string{"Simon & Garfunkel"}},
+		{[]string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"}, "Get Lucky", []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"}},
+		{[]string{"Gorillaz feat. De La Soul & Gruff Rhys"}, "Superfast Jellyfish", []string{"Gorillaz", "De La Soul", "Gruff Rhys"}},
+		{[]string{"Kendrick Lamar Ft. SZA"}, "All the Stars", []string{"Kendrick Lamar", "SZA"}},
+		// The featured artists of the title are added once.
+		{[]string{"Calvin Harris", "Dua Lipa"}, "One Kiss (with Dua Lipa)", []string{"Calvin Harris", "Dua Lipa"}},
+		{[]string{"Eminem"}, "Stan [feat. Dido]", []string{"Eminem", "Dido"}},
+		{[]string{""}, "Episode 12", []string{""}},
+	} {
+		info := MusicInfo{track: c.track}
+		info.setArtists(c.names...)
+		if got := info.artists(); strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("setArtists(%q) with track %q = %q, want %q", c.names, c.track, got, c.want)
+		}
+	}
+
+	info := MusicInfo{track: "Get Lucky"}
+	info.setArtists("Daft Punk", "Pharrell Williams", "Nile Rodgers")
+	if got := info.artistLine(); got != "Daft Punk feat. Pharrell Williams, Nile Rodgers" {
+		t.Errorf("got artist line %q", got)
+	}
+	if got := info.withArtist("Nile Rodgers").artists(); strings.Join(got, "|") != "Nile Rodgers|Daft Punk|Pharrell Williams" {
+		t.Errorf("withArtist put the artists as %q", got)
+	}
+	if info.withArtist("Radiohead") != info {
+		t.Error("withArtist changed the track for an artist not in it")
+	}
+}
+
+func TestSwitchArtist(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.MusicInfo = MusicInfo{album: "Random Access Memories", track: "Get Lucky"}
+	m.setArtists("Daft Punk", "Pharrell Williams")
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	if song := m.sections[2].Content; song != "stub answer for: Give me song info of Daft Punk feat. Pharrell Williams Get Lucky" {
+		t.Errorf("got song info %q", song)
+	}
+	if title := m.titleView(); !strings.Contains(title, "Daft Punk feat. Pharrell Williams - Random Access Memories") {
+		t.Errorf("got title %q", title)
+	}
+	if r := m.result(); strings.Join(r.Featured, ",") != "Pharrell Williams" {
+		t.Errorf("got featured %q", r.Featured)
+	}
+
+	m.Update(keyRune('W'))
+	if !strings.Contains(m.links.YouTube, "Pharrell+Williams+Get+Lucky") || m.notice != "  Deep dive and links: Pharrell Williams" {
+		t.Errorf("got link %q and notice %q", m.links.YouTube, m.notice)
+	}
+	if m.targetArtist() != "Pharrell Williams" {
+		t.Errorf("the deep dive is about %q", m.targetArtist())
+	}
+	m.Update(keyRune('W'))
+	if m.targetArtist() != "Daft Punk" {
+		t.Errorf("W did not go back to the first artist, got %q", m.targetArtist())
+	}
+
+	// The pick of another track does not apply.
+	m.MusicInfo = testTrack
+	if m.targetArtist() != "Radiohead" {
+		t.Errorf("got %q for a track with a single artist", m.targetArtist())
+	}
+	m.Update(keyRune('W'))
+	if m.notice != "  The track has a single artist" {
+		t.Errorf("got notice %q", m.notice)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// artworkMode is the protocol used to show the album cover, see -artwork.
+var artworkMode = "auto"
+
+// artworkCols and artworkRows are the size of the cover in terminal cells.
+var (
+	artworkCols = 12
+	artworkRows = 6
+)
+
+var (
+	musicBrainzURL = "https://musicbrainz.org/ws/2"
+	coverArtURL    = "https://coverartarchive.org"
+)
+
+// getArtwork is replaced in tests.
+var getArtwork = fetchCoverArt
+
+var errNoArtwork = errors.New("no cover art found")
+
+// detectArtworkMode resolves "auto" from the environment, terminals without an
+// image protocol get the half-block version. Sixel support can not be
+// detected without querying the terminal, so it has to be set explicitly.
+func detectArtworkMode(mode string) string {
+	if mode != "auto" {
+		return mode
+	}
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty":
+		return "kitty"
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm2"
+	}
+	return "ansi"
+}
+
+// fetchCoverArt looks up the release group of the album on MusicBrainz and
+// downloads its front cover from the Cover Art Archive.
+func fetchCoverArt(ctx context.Context, info MusicInfo) (image.Image, error) {
+	query := fmt.Sprintf(`artist:"%s" AND releasegroup:"%s"`, info.artist, info.album)
+	var r struct {
+		ReleaseGroups []struct {
+			ID string `json:"id"`
+		} `json:"release-groups"`
+	}
+	if err := musicBrainzGet(ctx, "/release-group/?fmt=json&limit=1&query="+url.QueryEscape(query), &r); err != nil {
+		return nil, err
+	}
+	if len(r.ReleaseGroups) == 0 {
+		return nil, errNoArtwork
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, coverArtURL+"/release-group/"+r.ReleaseGroups[0].ID+"/front-250", nil)
+	if err != nil {
+		return nil, err
+	}
+	cover, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer cover.Body.Close()
+	if cover.StatusCode == http.StatusNotFound {
+		return nil, errNoArtwork
+	}
+	if cover.StatusCode != http.StatusOK {
+		return nil, newStatusError(cover.StatusCode, "cover art archive: status code %d", cover.StatusCode)
+	}
+
+	img, _, err := image.Decode(cover.Body)
+	return img, err
+}
+
+// fetchTrackArtwork loads the cover of the current album into the model. A
+// missing cover is not an error.
+func (m *model) fetchTrackArtwork(ctx context.Context, info MusicInfo) {
+	var img image.Image
+	err := m.withRetry(ctx, func() (err error) {
+		img, err = getArtwork(ctx, info)
+		return err
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil && !errors.Is(err, errNoArtwork) {
+		m.errMsg = "  artwork: " + err.Error()
+	}
+	m.artwork = img
+	m.changed = true
+}
+
+// kittyImageID cycles through the ids of transmitted images so the cover of
+// the previous track is not replaced while it is still on screen.
+var kittyImageID = 0
+
+// artworkCmd shows a newly fetched cover. Kitty only needs the image once,
+// it is then drawn in the layout by unicode placeholders. iTerm2 and sixel
+// images can not be part of the layout, so they are printed above it.
+func (m *model) artworkCmd() tea.Cmd {
+	m.mu.Lock()
+	img := m.artwork
+	m.mu.Unlock()
+	if img == nil || m.artworkShown {
+		return nil
+	}
+	m.artworkShown = true
+	m.applyCoverTheme(img)
+
+	switch artworkMode {
+	case "kitty":
+		kittyImageID = kittyImageID%255 + 1
+		m.kittyID = kittyImageID
+		seq, err := kittyTransmit(img, m.kittyID, artworkCols, artworkRows)
+		if err != nil {
+			return nil
+		}
+		return tea.Printf("%s", seq)
+	case "iterm2":
+		seq, err := iterm2Image(img, artworkCols, artworkRows)
+		if err != nil {
+			return nil
+		}
+		return tea.Printf("%s", seq)
+	case "sixel":
+		return tea.Printf("%s", sixelImage(resizeImage(img, artworkCols*10, artworkRows*20)))
+	case "ansi":
+		m.artworkANSI = halfBlockImage(img, artworkCols, artworkRows)
+	}
+	return nil
+}
+
+// artworkView is the cover drawn in the layout, next to the title.
+func (m *model) artworkView() string {
+	switch {
+	case artworkMode == "kitty" && m.kittyID != 0:
+		return kittyPlaceholder(m.kittyID, artworkCols, artworkRows)
+	case artworkMode == "ansi":
+		return m.artworkANSI
+	}
+	return ""
+}
+
+// halfBlockImage draws img with "▀" characters, the foreground color is the
+// upper pixel and the background the lower one, so every cell holds two
+// pixels. lipgloss degrades the colors for terminals without true color.
+func halfBlockImage(img image.Image, cols int, rows int) string {
+	return halfBlock(lipgloss.DefaultRenderer(), img, cols, rows)
+}
+
+// halfBlock is halfBlockImage with the colors of the renderer r.
+func halfBlock(r *lipgloss.Renderer, img image.Image, cols int, rows int) string {
+	small := resizeImage(img, cols, rows*2)
+
+	hex := func(x, y int) lipgloss.Color {
+		c := small.RGBAAt(x, y)
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+	}
+
+	lines := make([]string, rows)
+	for row := range lines {
+		var b strings.Builder
+		for x := 0; x < cols; x++ {
+			b.WriteString(r.NewStyle().Foreground(hex(x, row*2)).Background(hex(x, row*2+1)).Render("▀"))
+		}
+		lines[row] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var b bytes.Buffer
+	err := png.Encode(&b, img)
+	return b.Bytes(), err
+}
+
+// kittyTransmit sends the image with a virtual placement of cols x rows cells,
+// which is drawn wherever kittyPlaceholder is printed. q=2 keeps the
+// terminal from answering, the answer would be read as key presses.
+func kittyTransmit(img image.Image, id int, cols int, rows int) (string, error) {
+	data, err := encodePNG(img)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	var b strings.Builder
+	const chunkSize = 4096
+	for i := 0; i < len(payload); i += chunkSize {
+		end := i + chunkSize
+		more := 1
+		if end >= len(payload) {
+			end = len(payload)
+			more = 0
+		}
+
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=T,U=1,f=100,t=d,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", id, cols, rows, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	return b.String(), nil
+}
+
+// kittyDiacritics encode the row and column of a placeholder cell, from the
+// rowcolumn-diacritics table of the kitty graphics protocol.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F,
+}
+
+const kittyPlaceholderRune = '\U0010EEEE'
+
+// kittyPlaceholder prints the cells of a virtual placement. The image id is
+// passed as the foreground color and only the first cell of every row needs
+// the diacritics, the following cells continue the row.
+func kittyPlaceholder(id int, cols int, rows int) string {
+	if rows > len(kittyDiacritics) {
+		rows = len(kittyDiacritics)
+	}
+
+	lines := make([]string, rows)
+	for row := range lines {
+		cells := string(kittyPlaceholderRune) + string(kittyDiacritics[row]) + string(kittyDiacritics[0]) +
+			strings.Repeat(string(kittyPlaceholderRune), cols-1)
+		lines[row] = fmt.Sprintf("\x1b[38;5;%dm%s\x1b[39m", id, cells)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// iterm2Image is the inline image escape of iTerm2, also understood by
+// WezTerm.
+func iterm2Image(img image.Image, cols int, rows int) (string, error) {
+	data, err := encodePNG(img)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		cols, rows, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// resizeImage scales img to width x height with nearest neighbour sampling,
+// good enough for a thumbnail.
+func resizeImage(img image.Image, width int, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := img.Bounds()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return dst
+}
+
+// sixelImage encodes img as sixel with a 6x6x6 color cube palette.
+func sixelImage(img *image.RGBA) string {
+	b := img.Bounds()
+	index := func(x, y int) int {
+		c := img.RGBAAt(x, y)
+		return int(c.R)*6/256*36 + int(c.G)*6/256*6 + int(c.B)*6/256
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "\x1bPq\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, i/36*100/5, i/6%6*100/5, i%6*100/5)
+	}
+
+	for band := b.Min.Y; band < b.Max.Y; band += 6 {
+		// Every color used in the band is drawn in its own pass over the row.
+		used := map[int]bool{}
+		for y := band; y < band+6 && y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				used[index(x, y)] = true
+			}
+		}
+
+		first := true
+		for color := 0; color < 216; color++ {
+			if !used[color] {
+				continue
+			}
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&out, "#%d", color)
+
+			var prev byte
+			run := 0
+			flush := func() {
+				if run > 3 {
+					fmt.Fprintf(&out, "!%d%c", run, prev)
+				} else {
+					out.WriteString(strings.Repeat(string(prev), run))
+				}
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for bit := 0; bit < 6 && band+bit < b.Max.Y; bit++ {
+					if index(x, band+bit) == color {
+						bits |= 1 << bit
+					}
+				}
+				c := '?' + bits
+				if c != prev && run > 0 {
+					flush()
+					run = 0
+				}
+				prev = c
+				run++
+			}
+			flush()
+		}
+		out.WriteByte('-')
+	}
+
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// headerView places the cover, when it is drawn in the layout, left of the
+// title and badge.
+func (m *model) headerView(header string) string {
+	cover := m.artworkView()
+	if cover == "" {
+		return header
+	}
+	// The title starts with a blank line, the cover lines up with the title
+	// text.
+	block := strings.TrimRight(header, "\n")
+	return lipgloss.JoinHorizontal(lipgloss.Top, strings.Repeat(" ", padding), "\n"+cover, block) + "\n\n"
+}
+
+// headerExtraRows is how many rows the cover adds to a header of titleRows
+// rows.
+func (m *model) headerExtraRows(titleRows int) int {
+	if m.artworkView() == "" {
+		return 0
+	}
+	if extra := 1 + artworkRows - titleRows; extra > 0 {
+		return extra
+	}
+	return 0
+}
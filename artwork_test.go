@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFetchCoverArt(t *testing.T) {
+	var cover bytes.Buffer
+	if err := png.Encode(&cover, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release-group/":
+			if q := r.URL.Query().Get("query"); q != `artist:"Radiohead" AND releasegroup:"OK Computer"` {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`{"release-groups":[{"id":"b1392450"}]}`))
+		case "/release-group/b1392450/front-250":
+			w.Write(cover.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(mb, caa string) { musicBrainzURL, coverArtURL = mb, caa }(musicBrainzURL, coverArtURL)
+	musicBrainzURL, coverArtURL = server.URL, server.URL
+
+	img, err := fetchCoverArt(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 2 {
+		t.Errorf("got a %v image", img.Bounds())
+	}
+}
+
+func TestKittyPlaceholder(t *testing.T) {
+	got := kittyPlaceholder(7, 4, 3)
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d rows, want 3", len(lines))
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w != 4 {
+			t.Errorf("row %d is %d cells wide, want 4", i, w)
+		}
+		if !strings.HasPrefix(line, "\x1b[38;5;7m") {
+			t.Errorf("row %d does not set the image id: %q", i, line)
+		}
+		if !strings.ContainsRune(line, kittyDiacritics[i]) {
+			t.Errorf("row %d does not encode its row", i)
+		}
+	}
+}
+
+func TestKittyTransmitChunks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	// Noise does not compress, so the PNG needs several chunks.
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+
+	seq, err := kittyTransmit(img, 3, 12, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(seq, "\x1b_Ga=T,U=1,f=100,t=d,q=2,i=3,c=12,r=6,m=1;") {
+		t.Errorf("unexpected first chunk %.60q", seq)
+	}
+	if !strings.Contains(seq, "\x1b_Gm=0;") {
+		t.Error("last chunk is not marked")
+	}
+}
+
+func TestSixelImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+
+	got := sixelImage(img)
+	if !strings.HasPrefix(got, "\x1bPq\"1;1;8;7") || !strings.HasSuffix(got, "-\x1b\\") {
+		t.Errorf("unexpected sixel framing %q", got)
+	}
+	// Red is color 180 of the cube, the first band is full and the second
+	// has only its top row.
+	if !strings.Contains(got, "#180!8~-#180!8@-") {
+		t.Errorf("unexpected sixel data %q", got[len(got)-40:])
+	}
+}
+
+func TestDetectArtworkMode(t *testing.T) {
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("TERM_PROGRAM", "iTerm.app")
+	if got := detectArtworkMode("auto"); got != "iterm2" {
+		t.Errorf("got %q, want iterm2", got)
+	}
+
+	t.Setenv("TERM_PROGRAM", "")
+	if got := detectArtworkMode("auto"); got != "ansi" {
+		t.Errorf("got %q, want ansi", got)
+	}
+	if got := detectArtworkMode("sixel"); got != "sixel" {
+		t.Errorf("got %q, want sixel", got)
+	}
+}
+
+func TestHalfBlockImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 25), uint8(y * 25), 0, 255})
+		}
+	}
+
+	got := halfBlockImage(img, 5, 3)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d rows, want 3", len(lines))
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w != 5 {
+			t.Errorf("row %d is %d cells wide, want 5", i, w)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// askView is the one line prompt of a question about the track. The answer
+// is added as a section until the next track or refresh. The same box edits
+// the prompt of a section.
+type askView struct {
+	input textinput.Model
+	// edit is the section whose prompt is edited, -1 for a question.
+	edit int
+}
+
+func (m *model) promptInput(prompt string) textinput.Model {
+	input := textinput.New()
+	input.Prompt = prompt
+	input.Width = m.width - padding*2 - 4
+	// Only key messages reach the box, a blinking cursor would not blink.
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.Focus()
+	return input
+}
+
+func (m *model) openAsk() {
+	input := m.promptInput("? ")
+	input.Placeholder = tr("Ask about this track")
+	input.CharLimit = 300
+	m.ask = &askView{input: input, edit: -1}
+}
+
+// openPromptEdit edits the prompt of the selected section, enter asks it
+// again. The sections of the online sources and plugins have no prompt.
+func (m *model) openPromptEdit() {
+	if !m.hasContent() || m.fetchCtx == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	index := m.tab
+	if !m.sectionDone(index) {
+		return
+	}
+	if s := m.sections[index]; s.prompt == "" {
+		m.notice = "  " + trf("%s has no prompt, it does not come from the AI provider", s.Title)
+		return
+	}
+	input := m.promptInput("> ")
+	input.SetValue(m.sections[index].prompt)
+	m.ask = &askView{input: input, edit: index}
+}
+
+func (m *model) updateAsk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.stopFetch()
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.ask = nil
+		return m, nil
+	case tea.KeyEnter:
+		question, edit := strings.TrimSpace(m.ask.input.Value()), m.ask.edit
+		if question == "" {
+			return m, nil
+		}
+		m.ask = nil
+		if edit >= 0 {
+			return m, m.regenerateSection(edit, question)
+		}
+		return m, m.askSection(question)
+	}
+
+	var cmd tea.Cmd
+	m.ask.input, cmd = m.ask.input.Update(msg)
+	return m, cmd
+}
+
+// askSection adds a section answering question with the track as context
+// and selects it. It is fetched like the other sections, so the answer
+// streams in and is cached.
+func (m *model) askSection(question string) tea.Cmd {
+	if !m.hasContent() || m.fetchCtx == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prompt := chatPrompt(m.MusicInfo, nil, question)
+	m.sections = append(m.sections, Section{key: "ask", Title: question, prompt: prompt})
+	index := len(m.sections) - 1
+	// Chosen, so the tab stays while the answer is empty.
+	m.tab, m.tabChosen = index, true
+	m.steps++
+	m.changed = true
+	wasLoading := m.loading
+	m.loading = true
+
+	ctx, info := m.fetchCtx, m.MusicInfo
+	var wg sync.WaitGroup
+	wg.Add(1)
+	m.goFetch(func() { m.fetchSection(ctx, info, index, question, prompt, &wg) })
+
+	// The running tick loop ends the loading already.
+	if wasLoading {
+		return nil
+	}
+	return tea.Batch(tickCmd(), m.spinner.Tick)
+}
+
+// regenerateSection asks the section at index again with the edited prompt,
+// without the cache.
+func (m *model) regenerateSection(index int, prompt string) tea.Cmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.sectionDone(index) {
+		return nil
+	}
+	return m.refetchSection(index, prompt, true)
+}
+
+// askBoxView replaces the help line while typing the question.
+func (m *model) askBoxView() string {
+	return "\n  " + m.ask.input.View() + "\n"
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAsk(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	sections := len(m.sections)
+
+	m.Update(keyRune('i'))
+	if m.ask == nil {
+		t.Fatal("i did not open the ask box")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.ask != nil {
+		t.Fatal("esc did not close the ask box")
+	}
+
+	m.Update(keyRune('i'))
+	for _, r := range "who plays the bass" {
+		if r == ' ' {
+			m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+		} else {
+			m.Update(keyRune(r))
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "? who plays the bass") {
+		t.Errorf("the ask box is not shown:\n%s", view)
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ask != nil || cmd == nil {
+		t.Fatal("enter did not ask the question")
+	}
+	m.Update(tickMsg{})
+	if m.tab != sections {
+		t.Errorf("the tab moved to %d before the answer came", m.tab)
+	}
+	waitFetched(t, m)
+
+	if len(m.sections) != sections+1 || m.tab != sections {
+		t.Fatalf("got %d sections on tab %d, want the answer added and selected", len(m.sections), m.tab)
+	}
+	s := m.sections[sections]
+	if s.key != "ask" || s.Title != "who plays the bass" {
+		t.Errorf("the answer section is %q %q", s.key, s.Title)
+	}
+	if !strings.Contains(s.Content, "stub answer for: ") || !strings.Contains(s.Content, `the song "Airbag" by Radiohead`) ||
+		!strings.Contains(s.Content, "who plays the bass") {
+		t.Errorf("the answer does not have the track and the question:\n%s", s.Content)
+	}
+}
+
+func TestRegenerateSection(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	c := &countingCompleter{}
+	completer = c
+	m.tab = 1
+	prompt := m.sections[1].prompt
+
+	// The answer is cached, r asks past the cache.
+	m.Update(keyRune('r'))
+	waitFetched(t, m)
+	if got, want := m.sections[1].Content, "counted answer for: "+prompt; got != want || c.calls.Load() != 1 {
+		t.Fatalf("got %q after %d requests, want %q", got, c.calls.Load(), want)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	if m.ask == nil || m.ask.edit != 1 || m.ask.input.Value() != prompt {
+		t.Fatal("ctrl+e did not open the prompt of the section")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	for _, r := range "shorter review" {
+		if r == ' ' {
+			m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+		} else {
+			m.Update(keyRune(r))
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	waitFetched(t, m)
+	if s := m.sections[1]; s.prompt != "shorter review" || s.Content != "counted answer for: shorter review" {
+		t.Errorf("got prompt %q and answer %q", s.prompt, s.Content)
+	}
+
+	// The online sources have no prompt to edit.
+	m.sections = append(m.sections, Section{key: "wikipedia", Title: "Wikipedia", Content: "From Wikipedia"})
+	m.tab = len(m.sections) - 1
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	if m.ask != nil || !strings.Contains(m.notice, "Wikipedia has no prompt") {
+		t.Errorf("got notice %q", m.notice)
+	}
+}
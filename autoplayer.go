@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+func init() {
+	registerPlayer("auto", playerFactory{
+		name: "a player",
+		new:  func() (player.Player, error) { return newAutoPlayer() },
+	})
+}
+
+// autoSourceKeys are the players read by the auto player, the Spotify app
+// first. spotify-web is left out, the spotify player already follows the
+// other devices once logged in.
+func autoSourceKeys() []string {
+	sourceKeys := []string{"spotify"}
+	for _, key := range playerKeys() {
+		if key != "spotify" && key != "spotify-web" && key != "auto" {
+			sourceKeys = append(sourceKeys, key)
+		}
+	}
+	return sourceKeys
+}
+
+// newAutoPlayer reads the players of autoSourceKeys that could be opened.
+func newAutoPlayer() (*player.Auto, error) {
+	var sources []player.Source
+	var errs []error
+	for _, key := range autoSourceKeys() {
+		f := players[key]
+		source, err := f.new()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		sources = append(sources, player.Source{Name: f.name, Player: source})
+	}
+	if len(sources) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return player.NewAuto(sources), nil
+}
+
+// switchPlayer reads the track from the next source of the auto player, the
+// settings are read again as they belong to the player.
+func (m *model) switchPlayer() tea.Cmd {
+	if cycleSource == nil {
+		m.notice = "  " + tr("Use -player auto to switch between the players")
+		return nil
+	}
+	m.notice = "  " + trf("Reading the track from %s", cycleSource())
+	return tea.Batch(func() tea.Msg {
+		info, status := getTrackInfo()
+		return playerMsg{info: info, status: status}
+	}, settingsCmd())
+}
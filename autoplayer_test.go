@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+// fakePlayer plays testTrack.
+type fakePlayer struct{}
+
+func (fakePlayer) Track() (player.Track, PlayerStatus) {
+	return player.Track{Artists: []string{testTrack.artist}, Album: testTrack.album, Title: testTrack.track}, PlayerPlaying
+}
+func (fakePlayer) Control(player.Action)              {}
+func (fakePlayer) Position() (time.Duration, error)   { return 0, nil }
+func (fakePlayer) Duration() (time.Duration, error)   { return 0, nil }
+func (fakePlayer) Settings() (player.Settings, error) { return player.Settings{}, nil }
+
+func TestSwitchPlayer(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func() {
+		currentSource = func() string { return playerName }
+		cycleSource = nil
+	}()
+
+	if cmd := m.switchPlayer(); cmd != nil || !strings.Contains(m.notice, "-player auto") {
+		t.Errorf("got notice %q with a single player", m.notice)
+	}
+
+	p := player.NewAuto([]player.Source{{Name: "Spotify", Player: fakePlayer{}}, {Name: "MPD", Player: fakePlayer{}}})
+	currentSource, cycleSource = p.Source, p.Cycle
+	m.Update(keyRune('D'))
+	if m.notice != "  Reading the track from MPD" {
+		t.Errorf("got notice %q", m.notice)
+	}
+	if bar := m.statusBarView(); !strings.Contains(bar, "MPD") {
+		t.Errorf("the status bar does not name the player: %q", bar)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// azureEndpoint is the URL of an Azure OpenAI resource, such as
+// https://my-resource.openai.azure.com, azureDeployment the deployment of
+// the chat model in it and azureEmbeddingDeployment the one of the
+// embeddings model. Without a deployment the model name is used, as Azure
+// names them after the model by default.
+var (
+	azureEndpoint            string
+	azureDeployment          string
+	azureEmbeddingDeployment string
+	azureAPIVersion          = ai.AzureDefaultAPIVersion
+	azureAPIKey              = os.Getenv("AZURE_OPENAI_API_KEY")
+)
+
+func init() {
+	registerProvider("azure", providerFactory{
+		defaultModel:   "gpt-35-turbo",
+		embeddingModel: openai.AdaEmbeddingV2.String(),
+		keyEnv:         "AZURE_OPENAI_API_KEY",
+		hasKey:         func() bool { return azureAPIKey != "" },
+		new: func() (ai.Provider, error) {
+			if azureEndpoint == "" {
+				return nil, errors.New("the azure provider needs azure_endpoint, such as https://my-resource.openai.azure.com")
+			}
+			return ai.NewAzure(ai.AzureConfig{
+				Endpoint:   azureEndpoint,
+				APIKey:     azureAPIKey,
+				APIVersion: azureAPIVersion,
+				Deployment: azureModelDeployment,
+			}), nil
+		},
+	})
+}
+
+// azureModelDeployment is the deployment of a model, the embeddings model
+// has its own.
+func azureModelDeployment(model string) string {
+	deployment := azureDeployment
+	if model == embeddingModel {
+		deployment = azureEmbeddingDeployment
+	}
+	if deployment == "" {
+		return model
+	}
+	return deployment
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+func TestAzureComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/music/chat/completions" || r.URL.Query().Get("api-version") != ai.AzureDefaultAPIVersion {
+			t.Errorf("got request %s", r.URL)
+		}
+		if r.Header.Get("api-key") != "key" {
+			t.Errorf("missing api key header")
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	defer func(endpoint, deployment, key string) {
+		azureEndpoint, azureDeployment, azureAPIKey = endpoint, deployment, key
+	}(azureEndpoint, azureDeployment, azureAPIKey)
+	azureEndpoint, azureAPIKey = "", "key"
+	if _, _, err := newProvider("azure"); err == nil {
+		t.Error("the azure provider is created without an endpoint")
+	}
+
+	azureEndpoint, azureDeployment = server.URL, "music"
+	p, _, err := newProvider("azure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := p.Complete(context.Background(), "gpt-4o", "hi")
+	if err != nil || content != "Hello" {
+		t.Errorf("got %q, %v", content, err)
+	}
+}
+
+func TestAzureEmbed(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("api-key") != "key" || r.URL.Query().Get("api-version") != ai.AzureDefaultAPIVersion {
+			t.Errorf("got request %s without the key or the version", r.URL)
+		}
+		fmt.Fprint(w, `{"data":[{"embedding":[0.5,1],"index":0}],"usage":{"prompt_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	defer func(endpoint, deployment, embedding, key, model string) {
+		azureEndpoint, azureDeployment, azureEmbeddingDeployment, azureAPIKey, embeddingModel = endpoint, deployment, embedding, key, model
+	}(azureEndpoint, azureDeployment, azureEmbeddingDeployment, azureAPIKey, embeddingModel)
+	azureEndpoint, azureDeployment, azureAPIKey = server.URL, "music", "key"
+
+	for _, model := range []string{"text-embedding-ada-002", "text-embedding-3-small"} {
+		embeddingModel, azureEmbeddingDeployment = model, ""
+		if model == "text-embedding-ada-002" {
+			azureEmbeddingDeployment = "vectors"
+		}
+		p, _, err := newProvider("azure")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors, err := p.(ai.EmbedProvider).Embed(context.Background(), model, []string{"moody"})
+		if err != nil || len(vectors) != 1 || len(vectors[0]) != 2 {
+			t.Errorf("got %v, %v for %s", vectors, err, model)
+		}
+	}
+	want := []string{"/openai/deployments/vectors/embeddings", "/openai/deployments/text-embedding-3-small/embeddings"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("got requests %v, want %v", paths, want)
+	}
+}
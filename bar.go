@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// barOutput is a line of stui bar, the JSON of a Waybar custom module with
+// return-type json. Class and Alt are playing, paused or stopped.
+type barOutput struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+	Alt     string `json:"alt"`
+}
+
+// barTeaser is the teaser of a track, fetched in the background.
+type barTeaser struct {
+	info   MusicInfo
+	teaser string
+}
+
+// bar follows the player for stui bar, it prints a line when the track, its
+// state or teaser change.
+type bar struct {
+	m    *model
+	tmpl *template.Template
+	w    io.Writer
+	// info is the last track played, it stays while the player is paused.
+	info    MusicInfo
+	class   string
+	teaser  string
+	teasers chan barTeaser
+	printed *barOutput
+}
+
+func newBar(m *model, tmpl *template.Template, w io.Writer) *bar {
+	return &bar{m: m, tmpl: tmpl, w: w, class: "stopped", teasers: make(chan barTeaser, 1)}
+}
+
+// runBar prints the lines of stui bar until the process is stopped, the
+// player is read every pollInterval.
+func runBar(m *model, tmpl *template.Template, w io.Writer) error {
+	b := newBar(m, tmpl, w)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		b.poll()
+		if err := b.print(); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case t := <-b.teasers:
+			b.teaserFetched(t)
+			if err := b.print(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// poll reads the player and fetches the teaser of a new track.
+func (b *bar) poll() {
+	info, status := getTrackInfo()
+	if status == PlayerUnavailable {
+		b.info, b.class, b.teaser = MusicInfo{}, "stopped", ""
+		return
+	}
+	if status != PlayerPlaying || (info.artist == "" && !info.isPodcast()) {
+		if b.info != (MusicInfo{}) {
+			b.class = "paused"
+		}
+		return
+	}
+
+	b.class = "playing"
+	if info == b.info {
+		return
+	}
+	b.info, b.teaser = info, ""
+	goSafe(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if teaser := b.m.teaser(ctx, info); teaser != "" {
+			b.teasers <- barTeaser{info: info, teaser: teaser}
+		}
+	})
+}
+
+// teaserFetched keeps the teaser when it is of the track shown.
+func (b *bar) teaserFetched(t barTeaser) {
+	if t.info == b.info {
+		b.teaser = t.teaser
+	}
+}
+
+// output is the module for the track shown. Waybar reads the text as Pango
+// markup, so the names are escaped.
+func (b *bar) output() (barOutput, error) {
+	out := barOutput{Class: b.class, Alt: b.class}
+	if b.info == (MusicInfo{}) {
+		return out, nil
+	}
+
+	info := b.info
+	var text strings.Builder
+	if err := b.tmpl.Execute(&text, statusLine{
+		Artist: html.EscapeString(info.artistLine()),
+		Album:  html.EscapeString(info.album),
+		Track:  html.EscapeString(info.track),
+		Player: html.EscapeString(playerName),
+	}); err != nil {
+		return out, err
+	}
+	out.Text = strings.Join(strings.Fields(text.String()), " ")
+
+	tooltip := []string{info.track, info.artistLine()}
+	if info.isPodcast() {
+		tooltip = []string{info.track, info.album}
+	} else if info.album != "" {
+		tooltip[1] += " – " + info.album
+	}
+	if b.teaser != "" {
+		tooltip = append(tooltip, "", b.teaser)
+	}
+	out.Tooltip = html.EscapeString(strings.TrimSpace(strings.Join(tooltip, "\n")))
+	return out, nil
+}
+
+// print writes the module as a line of JSON unless it is the one printed
+// last.
+func (b *bar) print() error {
+	out, err := b.output()
+	if err != nil {
+		return err
+	}
+	if b.printed != nil && *b.printed == out {
+		return nil
+	}
+	b.printed = &out
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	_, err = b.w.Write(append(data, '\n'))
+	return err
+}
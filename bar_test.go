@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBar(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	tmpl, err := parseStatusFormat("{{.Artist}} – {{.Track}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	b := newBar(m, tmpl, &out)
+	lines := func() []barOutput {
+		var got []barOutput
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var o barOutput
+			if err := json.Unmarshal([]byte(line), &o); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			got = append(got, o)
+		}
+		out.Reset()
+		return got
+	}
+
+	b.poll()
+	b.print()
+	got := lines()
+	if len(got) != 1 || got[0].Text != "Radiohead – Airbag" || got[0].Class != "playing" || got[0].Tooltip != "Airbag\nRadiohead – OK Computer" {
+		t.Fatalf("got %+v", got)
+	}
+
+	select {
+	case teaser := <-b.teasers:
+		b.teaserFetched(teaser)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no teaser")
+	}
+	b.poll()
+	b.print()
+	b.print()
+	if got := lines(); len(got) != 1 || !strings.HasSuffix(got[0].Tooltip, "\n\nstub answer for: In one short sentence, tease the song &#34;Airbag&#34; by Radiohead for someone who just started playing it. Answer only with the sentence.") {
+		t.Errorf("got %+v, want one line with the teaser", got)
+	}
+
+	// Paused keeps the track, the names are escaped for Pango.
+	getTrackInfo = func() (MusicInfo, PlayerStatus) { return MusicInfo{}, PlayerIdle }
+	b.poll()
+	b.print()
+	if got := lines(); len(got) != 1 || got[0].Class != "paused" || got[0].Alt != "paused" || got[0].Text != "Radiohead – Airbag" {
+		t.Errorf("got %+v paused", got)
+	}
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		return MusicInfo{artist: "Simon & Garfunkel", album: "Bookends", track: "America"}, PlayerPlaying
+	}
+	b.poll()
+	b.print()
+	if got := lines(); len(got) != 1 || got[0].Text != "Simon &amp; Garfunkel – America" || strings.Contains(got[0].Tooltip, "stub") {
+		t.Errorf("got %+v for the next track", got)
+	}
+	<-b.teasers
+
+	getTrackInfo = func() (MusicInfo, PlayerStatus) { return MusicInfo{}, PlayerUnavailable }
+	b.poll()
+	b.print()
+	if got := lines(); len(got) != 1 || got[0] != (barOutput{Class: "stopped", Alt: "stopped"}) {
+		t.Errorf("got %+v without the player", got)
+	}
+}
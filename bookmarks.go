@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+// bookmarkNote is the prompt of the note of a bookmark.
+type bookmarkNote struct {
+	info  MusicInfo
+	input string
+}
+
+// bookmarksView is the state of the bookmarks screen.
+type bookmarksView struct {
+	bookmarks []storage.Bookmark
+	filter    string
+	cursor    int
+}
+
+// matches returns the bookmarks whose track or note match the filter.
+func (v bookmarksView) matches() []storage.Bookmark {
+	var bookmarks []storage.Bookmark
+	for _, b := range v.bookmarks {
+		if fuzzyMatch(v.filter, b.Artist+" "+b.Album+" "+b.Track) || strings.Contains(strings.ToLower(b.Note), strings.ToLower(v.filter)) {
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	return bookmarks
+}
+
+// openBookmarkNote asks for the note of the track, the note of a track
+// already bookmarked is there to edit.
+func (m *model) openBookmarkNote() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b, _, err := history.FindBookmark(ctx, m.artist, m.album, m.track)
+	if err != nil {
+		return err
+	}
+	m.bookmarkNote = &bookmarkNote{info: m.MusicInfo, input: b.Note}
+	return nil
+}
+
+func (m *model) updateBookmarkNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := m.bookmarkNote
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.bookmarkNote = nil
+	case tea.KeyEnter:
+		m.bookmarkNote = nil
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := history.SaveBookmark(ctx, n.info.artist, n.info.album, n.info.track, strings.TrimSpace(n.input), time.Now()); err != nil {
+			m.errMsg = "  bookmarks: " + err.Error()
+		} else {
+			m.notice = "  " + trf("Bookmarked %s", n.info.track)
+		}
+	case tea.KeyBackspace:
+		if r := []rune(n.input); len(r) > 0 {
+			n.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		n.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m *model) bookmarkNoteView() string {
+	n := m.bookmarkNote
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+trf("Bookmark: %s - %s", n.info.artist, n.info.track)) + "\n\n")
+	b.WriteString(pad + helpStyle(tr("Note, e.g. where the sample is:")) + "\n\n")
+	b.WriteString(pad + "> " + n.input + "█\n\n")
+	b.WriteString(pad + helpStyle(tr("enter: Save • esc: Cancel")))
+	return b.String()
+}
+
+// openBookmarksView lists the bookmarks in the bookmarks screen.
+func (m *model) openBookmarksView() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bookmarks, err := history.Bookmarks(ctx)
+	if err != nil {
+		return err
+	}
+	m.bookmarksScreen = &bookmarksView{bookmarks: bookmarks}
+	return nil
+}
+
+func (m *model) updateBookmarks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.bookmarksScreen
+	matches := v.matches()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.bookmarksScreen = nil
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case tea.KeyDown:
+		if v.cursor < len(matches)-1 {
+			v.cursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(v.filter); len(r) > 0 {
+			v.filter = string(r[:len(r)-1])
+			v.cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		v.filter += string(msg.Runes)
+		v.cursor = 0
+	case tea.KeyCtrlD:
+		if v.cursor < len(matches) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := history.DeleteBookmark(ctx, matches[v.cursor].ID); err != nil {
+				m.errMsg = "  bookmarks: " + err.Error()
+				return m, nil
+			}
+			for i, b := range v.bookmarks {
+				if b.ID == matches[v.cursor].ID {
+					v.bookmarks = append(v.bookmarks[:i], v.bookmarks[i+1:]...)
+					break
+				}
+			}
+			if v.cursor > 0 && v.cursor >= len(matches)-1 {
+				v.cursor--
+			}
+		}
+	case tea.KeyCtrlE:
+		if len(matches) == 0 {
+			return m, nil
+		}
+		m.bookmarksScreen = nil
+		path, err := exportBookmarks(matches)
+		if err != nil {
+			m.errMsg = "  export: " + err.Error()
+		} else {
+			m.notice = "  " + trf("Saved to %s", path)
+		}
+	case tea.KeyEnter:
+		if v.cursor < len(matches) {
+			b := matches[v.cursor]
+			m.bookmarksScreen = nil
+			cmd, err := m.showHistoryTrack(storage.Track{ID: b.TrackID, Artist: b.Artist, Album: b.Album, Track: b.Track})
+			if err != nil {
+				m.errMsg = "  history: " + err.Error()
+			}
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// bookmarksMarkdown lists the bookmarks with their notes.
+func bookmarksMarkdown(bookmarks []storage.Bookmark) string {
+	var b strings.Builder
+	b.WriteString("# Bookmarks\n")
+	for _, bm := range bookmarks {
+		fmt.Fprintf(&b, "\n## %s - %s - %s\n\n*%s*\n", bm.Artist, bm.Album, bm.Track, bm.UpdatedAt.Local().Format("2006-01-02 15:04"))
+		if bm.Note != "" {
+			b.WriteString("\n" + bm.Note + "\n")
+		}
+	}
+	return b.String()
+}
+
+// exportBookmarks saves the bookmarks to exportDir in the export format and
+// returns the path of the file.
+func exportBookmarks(bookmarks []storage.Bookmark) (string, error) {
+	data := []byte(bookmarksMarkdown(bookmarks))
+	name := "bookmarks.md"
+	if exportFormat == "html" {
+		var err error
+		if data, err = markdownToHTML("Bookmarks", data); err != nil {
+			return "", err
+		}
+		name = "bookmarks.html"
+	}
+
+	dir := expandHome(exportDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (m *model) bookmarksScreenView() string {
+	v := m.bookmarksScreen
+	pad := strings.Repeat(" ", padding)
+	matches := v.matches()
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Bookmarks")) + "\n\n")
+	b.WriteString(pad + "> " + v.filter + "█\n\n")
+
+	// Every bookmark takes two lines, the track and the note.
+	rows := (m.height - 8) / 2
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+
+	if len(matches) == 0 {
+		b.WriteString(pad + helpStyle(tr("No bookmarks found")) + "\n")
+	}
+	for i := start; i < len(matches) && i < start+rows; i++ {
+		bm := matches[i]
+		line := fmt.Sprintf("%s - %s - %s", bm.Artist, bm.Album, bm.Track)
+		when := helpStyle(" " + bm.UpdatedAt.Local().Format("2006-01-02 15:04"))
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + when + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + when + "\n")
+		}
+		b.WriteString(pad + "    " + helpStyle(bm.Note) + "\n")
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("type to filter • ↑/↓: Select • enter: Open • ctrl+d: Delete • ctrl+e: Export • esc: Back")))
+	return b.String()
+}
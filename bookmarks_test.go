@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestBookmarks(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+	dir := exportDir
+	exportDir = t.TempDir()
+	defer func() { exportDir = dir }()
+
+	typeText := func(s string) {
+		for _, r := range s {
+			if r == ' ' {
+				m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+			} else {
+				m.Update(keyRune(r))
+			}
+		}
+	}
+
+	m.Update(keyRune('s'))
+	if m.bookmarkNote == nil {
+		t.Fatal("s did not open the note prompt")
+	}
+	typeText("sample the intro")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.bookmarkNote != nil || !strings.Contains(m.notice, "Bookmarked Airbag") {
+		t.Fatalf("got notice %q, error %q", m.notice, m.errMsg)
+	}
+
+	// Bookmarking again edits the note.
+	m.Update(keyRune('s'))
+	if m.bookmarkNote == nil || m.bookmarkNote.input != "sample the intro" {
+		t.Fatalf("got note prompt %+v", m.bookmarkNote)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	m.Update(keyRune('S'))
+	if m.bookmarksScreen == nil {
+		t.Fatal("S did not open the bookmarks")
+	}
+	typeText("intro")
+	if view := m.View(); !strings.Contains(view, "Radiohead - OK Computer - Airbag") || !strings.Contains(view, "sample the intro") {
+		t.Fatalf("filtered view:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	data, err := os.ReadFile(filepath.Join(exportDir, "bookmarks.md"))
+	if err != nil {
+		t.Fatalf("%v, error %q", err, m.errMsg)
+	}
+	if !strings.Contains(string(data), "## Radiohead - OK Computer - Airbag") || !strings.Contains(string(data), "sample the intro") {
+		t.Errorf("got export:\n%s", data)
+	}
+
+	m.Update(keyRune('S'))
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if len(m.bookmarksScreen.bookmarks) != 0 || !strings.Contains(m.View(), "No bookmarks found") {
+		t.Errorf("the bookmark was not deleted:\n%s", m.View())
+	}
+	if bookmarks, err := db.Bookmarks(context.Background()); err != nil || len(bookmarks) != 0 {
+		t.Errorf("got %+v, %v", bookmarks, err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// Budget limits the provider requests, zero values are no limit. The cost
+// limits count the models with a known price, see modelPrices.
+type Budget struct {
+	SessionTokens int     `toml:"session_tokens"`
+	SessionCost   float64 `toml:"session_cost"`
+	DailyTokens   int     `toml:"daily_tokens"`
+	DailyCost     float64 `toml:"daily_cost"`
+}
+
+// budget is the budget table of the config file.
+var budget Budget
+
+var errBudgetReached = errors.New("budget reached")
+
+// checkBudget refuses a request when its prompt alone would go over a limit,
+// the answers already cached are still shown.
+func (m *model) checkBudget(model string, prompt string) error {
+	next := requestUsage(model, ai.EstimateTokens(prompt), 0, true)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := overLimit("session", m.usage, next, budget.SessionTokens, budget.SessionCost)
+	if err == nil {
+		err = overLimit("daily", m.todayUsage(), next, budget.DailyTokens, budget.DailyCost)
+	}
+	if err != nil {
+		m.errMsg = "  " + err.Error()
+	}
+	return err
+}
+
+func overLimit(name string, used tokenUsage, next tokenUsage, tokens int, cost float64) error {
+	if tokens > 0 && used.promptTokens+used.completionTokens+next.promptTokens > tokens {
+		return fmt.Errorf("%w: the %s limit of %s tokens, only cached answers are shown", errBudgetReached, name, formatTokens(tokens))
+	}
+	if cost > 0 && used.cost+next.cost > cost {
+		return fmt.Errorf("%w: the %s limit of %s, only cached answers are shown", errBudgetReached, name, formatCost(cost))
+	}
+	return nil
+}
+
+// todayUsage returns the usage of the day, starting over after midnight.
+// The caller must hold m.mu.
+func (m *model) todayUsage() tokenUsage {
+	if day := time.Now().Format(time.DateOnly); day != m.usageDay {
+		m.usageDay = day
+		m.today = tokenUsage{}
+	}
+	return m.today
+}
+
+// loadTodayUsage reads the requests of the day from the history, so the
+// daily limit holds across restarts.
+func (m *model) loadTodayUsage() error {
+	if history == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	u, err := history.UsageSince(ctx, midnight)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.todayUsage()
+	m.today = tokenUsage{requests: u.Requests, promptTokens: u.PromptTokens, completionTokens: u.CompletionTokens, cost: u.Cost}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	previous := budget
+	t.Cleanup(func() { budget = previous })
+	ctx := context.Background()
+
+	budget = Budget{SessionCost: 0.01, DailyTokens: 1000}
+	if _, err := m.complete(ctx, "gpt-4o", "Who produced OK Computer?"); err != nil {
+		t.Fatal(err)
+	}
+
+	m.usage = tokenUsage{requests: 3, cost: 0.01}
+	_, err := m.complete(ctx, "gpt-4o", "Who produced OK Computer?")
+	if !errors.Is(err, errBudgetReached) || !strings.Contains(err.Error(), "session limit of $0.0100") {
+		t.Errorf("got %v over the session cost", err)
+	}
+	if !strings.Contains(m.errMsg, "only cached answers are shown") {
+		t.Errorf("got notice %q", m.errMsg)
+	}
+
+	// The prompt alone would go over the daily tokens.
+	m.usage = tokenUsage{}
+	m.today = tokenUsage{promptTokens: 900, completionTokens: 90}
+	_, err = m.complete(ctx, "gpt-4o", "Who produced OK Computer and where was it recorded?")
+	if !errors.Is(err, errBudgetReached) || !strings.Contains(err.Error(), "daily limit of 1.0k tokens") {
+		t.Errorf("got %v over the daily tokens", err)
+	}
+
+	// A new day starts over.
+	m.usageDay = "2023-09-01"
+	if _, err := m.complete(ctx, "gpt-4o", "Who produced OK Computer?"); err != nil {
+		t.Errorf("got %v on a new day", err)
+	}
+}
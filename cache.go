@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheTTL is how long answers are reused, zero disables the cache.
+var cacheTTL = 7 * 24 * time.Hour
+
+// cacheDir holds the cached answers, empty disables the cache.
+var cacheDir = defaultCacheDir()
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stui", "answers")
+}
+
+type cacheEntry struct {
+	Content string    `json:"content"`
+	Created time.Time `json:"created"`
+}
+
+// cacheScope is what a cached answer is about. The answers about the album
+// are shared by its tracks, a new track on it does not ask for them again.
+type cacheScope int
+
+const (
+	albumScope cacheScope = iota
+	trackScope
+)
+
+// trackSections are the AI sections about the track, the others are about
+// its album or artist.
+var trackSections = []string{"song", "ask", "episode"}
+
+// sectionScope is the scope of the answer of the section key.
+func sectionScope(key string) cacheScope {
+	if containsString(trackSections, key) {
+		return trackScope
+	}
+	return albumScope
+}
+
+// cacheKey identifies an answer, the provider and model are part of it as
+// they give different answers to the same prompt. The track is only part of
+// it in trackScope.
+func cacheKey(info MusicInfo, scope cacheScope, model string, prompt string) string {
+	track := ""
+	if scope == trackScope {
+		track = info.track
+	}
+	h := sha256.New()
+	for _, s := range []string{provider, model, info.artist, info.album, track, prompt} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cacheEnabled() bool {
+	return cacheDir != "" && cacheTTL > 0
+}
+
+// readCache returns the answer stored for key unless it is older than
+// cacheTTL. Offline the old answers are better than none.
+func readCache(key string) (string, bool) {
+	e, ok := readCacheEntry(key)
+	if !ok || (time.Since(e.Created) > cacheTTL && !offline) {
+		return "", false
+	}
+	return e.Content, true
+}
+
+// readCacheEntry returns the entry stored for key, however old.
+func readCacheEntry(key string) (cacheEntry, bool) {
+	if !cacheEnabled() {
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, key+".json"))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Content == "" {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// writeCache stores an answer. The cache is best effort, a failure only
+// means the next lookup asks the provider again.
+func writeCache(key string, content string) {
+	if !cacheEnabled() {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{Content: content, Created: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return
+	}
+
+	// Write through a temporary file so concurrent runs never read half an
+	// entry.
+	tmp, err := os.CreateTemp(cacheDir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(cacheDir, key+".json")); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// cachedAnswer looks up an answer in the cache, skipped when the refresh of
+// ctx asked to bypass it.
+func cachedAnswer(ctx context.Context, key string) (string, bool) {
+	if skip, _ := ctx.Value(skipCacheKey{}).(bool); skip {
+		debugLog("cache", "key", key, "result", "skipped")
+		return "", false
+	}
+	content, ok := readCache(key)
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+	debugLog("cache", "key", key, "result", result)
+	return content, ok
+}
+
+type skipCacheKey struct{}
+
+// withoutCache makes cachedAnswer miss for the requests of ctx, they ask the
+// providers again.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
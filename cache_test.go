@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingCompleter struct {
+	calls atomic.Int32
+}
+
+func (c *countingCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	c.calls.Add(1)
+	return "counted answer for: " + prompt, nil
+}
+
+func TestAnswerCache(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	c := &countingCompleter{}
+	completer = c
+
+	// Unparsable summaries are not cached, leave it out of the count.
+	enabledSections = map[string]bool{"album": true, "review": true, "song": true, "bio": true}
+	defer func() { enabledSections = nil }()
+
+	m.getInfo(context.Background())
+	first := c.calls.Load()
+	if first == 0 {
+		t.Fatal("the provider was not called")
+	}
+
+	m.getInfo(context.Background())
+	if got := c.calls.Load(); got != first {
+		t.Errorf("cached run made %d calls", got-first)
+	}
+	if m.sections[0].Content != "counted answer for: Give me album info of Radiohead OK Computer, leave out the tracklist and credits" {
+		t.Errorf("got %q from the cache", m.sections[0].Content)
+	}
+
+	m.skipCache = true
+	m.getInfo(context.Background())
+	if got := c.calls.Load(); got != 2*first {
+		t.Errorf("forced run made %d calls, want %d", got-first, first)
+	}
+
+	defer func(ttl time.Duration) { cacheTTL = ttl }(cacheTTL)
+	cacheTTL = time.Nanosecond
+	m.skipCache = false
+	m.getInfo(context.Background())
+	if got := c.calls.Load(); got != 3*first {
+		t.Errorf("expired run made %d calls, want %d", got-2*first, first)
+	}
+}
+
+func TestAlbumCacheSharedByTracks(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	c := &countingCompleter{}
+	completer = c
+
+	enabledSections = map[string]bool{"album": true, "review": true, "song": true}
+	defer func() { enabledSections = nil }()
+
+	m.getInfo(context.Background())
+	if got := c.calls.Load(); got != 3 {
+		t.Fatalf("made %d calls, want 3", got)
+	}
+
+	// Only the song info of another track of the album is asked.
+	m.MusicInfo.track = "Paranoid Android"
+	m.getInfo(context.Background())
+	if got := c.calls.Load(); got != 4 {
+		t.Errorf("the next track made %d calls, want 1", got-3)
+	}
+	if m.sections[0].Content != "counted answer for: Give me album info of Radiohead OK Computer, leave out the tracklist and credits" {
+		t.Errorf("got %q from the cache", m.sections[0].Content)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// catalogLimit is how many albums and how many tracks a catalog search
+// lists.
+const catalogLimit = 10
+
+// searchSpotifyCatalog and playSpotifyResult are replaced in tests.
+var (
+	searchSpotifyCatalog = fetchSpotifyCatalog
+	playSpotifyResult    = startSpotifyResult
+)
+
+// catalogResult is an album or a track found in the Spotify catalog.
+type catalogResult struct {
+	info MusicInfo
+	uri  string
+	year string
+}
+
+func (r catalogResult) isAlbum() bool {
+	return r.info.track == ""
+}
+
+// title is the album or the track of the result.
+func (r catalogResult) title() string {
+	if r.isAlbum() {
+		return r.info.album
+	}
+	return r.info.track
+}
+
+type catalogMsg struct {
+	query   string
+	results []catalogResult
+	err     error
+}
+
+// catalogView is the Spotify catalog search, enter on the query searches and
+// enter on a result plays it.
+type catalogView struct {
+	query string
+	// searched is the query of the results.
+	searched string
+	loading  bool
+	err      string
+	results  []catalogResult
+	cursor   int
+}
+
+func fetchSpotifyCatalog(ctx context.Context, query string) ([]catalogResult, error) {
+	type artists []struct {
+		Name string `json:"name"`
+	}
+	var page struct {
+		Albums struct {
+			Items []struct {
+				URI         string  `json:"uri"`
+				Name        string  `json:"name"`
+				ReleaseDate string  `json:"release_date"`
+				Artists     artists `json:"artists"`
+			} `json:"items"`
+		} `json:"albums"`
+		Tracks struct {
+			Items []struct {
+				URI     string  `json:"uri"`
+				Name    string  `json:"name"`
+				Artists artists `json:"artists"`
+				Album   struct {
+					Name        string `json:"name"`
+					ReleaseDate string `json:"release_date"`
+				} `json:"album"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	path := "/search?type=album,track&limit=" + strconv.Itoa(catalogLimit) + "&q=" + url.QueryEscape(query)
+	if err := newSpotifyWebPlayer(spotifyClientID).get(ctx, path, &page); err != nil {
+		return nil, err
+	}
+
+	var results []catalogResult
+	for _, a := range page.Albums.Items {
+		if len(a.Artists) == 0 {
+			continue
+		}
+		info := MusicInfo{artist: a.Artists[0].Name, album: cleanAlbumName(a.Name, albumNoise)}
+		results = append(results, catalogResult{info: info, uri: a.URI, year: releaseYear(a.ReleaseDate)})
+	}
+	for _, t := range page.Tracks.Items {
+		if len(t.Artists) == 0 {
+			continue
+		}
+		info := MusicInfo{artist: t.Artists[0].Name, album: cleanAlbumName(t.Album.Name, albumNoise), track: t.Name}
+		results = append(results, catalogResult{info: info, uri: t.URI, year: releaseYear(t.Album.ReleaseDate)})
+	}
+	return results, nil
+}
+
+// releaseYear is the year of a Spotify release date, which is a year, a
+// month or a day.
+func releaseYear(date string) string {
+	year, _, _ := strings.Cut(date, "-")
+	return year
+}
+
+// startSpotifyResult plays the album, or the track, on the active device.
+func startSpotifyResult(ctx context.Context, r catalogResult) error {
+	body := map[string]any{"uris": []string{r.uri}}
+	if r.isAlbum() {
+		body = map[string]any{"context_uri": r.uri}
+	}
+	return newSpotifyWebPlayer(spotifyClientID).send(ctx, http.MethodPut, "/me/player/play", body, nil)
+}
+
+// openCatalog shows the catalog search.
+func (m *model) openCatalog() {
+	if spotifyClientID == "" || !spotifyLoggedIn() {
+		m.errMsg = "  spotify: the catalog search needs spotify_client_id in the config file and stui -spotify-login"
+		return
+	}
+	m.catalog = &catalogView{}
+}
+
+func (m *model) catalogLoaded(msg catalogMsg) {
+	v := m.catalog
+	if v == nil || msg.query != v.searched {
+		return
+	}
+	v.loading = false
+	if msg.err != nil {
+		v.err = msg.err.Error()
+		return
+	}
+	v.results = msg.results
+}
+
+func (m *model) updateCatalog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.catalog
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.catalog = nil
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case tea.KeyDown:
+		if v.cursor < len(v.results)-1 {
+			v.cursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(v.query); len(r) > 0 {
+			v.query = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		v.query += string(msg.Runes)
+	case tea.KeyEnter:
+		// A changed query is searched, otherwise the result plays.
+		query := strings.TrimSpace(v.query)
+		if query != v.searched {
+			if query == "" {
+				return m, nil
+			}
+			v.searched, v.results, v.cursor, v.loading, v.err = query, nil, 0, true, ""
+			return m, func() tea.Msg {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				results, err := searchSpotifyCatalog(ctx, query)
+				return catalogMsg{query: query, results: results, err: err}
+			}
+		}
+		if v.loading || v.cursor >= len(v.results) {
+			return m, nil
+		}
+		r := v.results[v.cursor]
+		m.notice = "  " + trf("Playing %s", r.title())
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return libraryPlayMsg{info: r.info, err: playSpotifyResult(ctx, r)}
+		}
+	case tea.KeyTab:
+		// The info without playing it, like stui query.
+		if v.loading || v.cursor >= len(v.results) || strings.TrimSpace(v.query) != v.searched {
+			return m, nil
+		}
+		m.catalog = nil
+		m.query = true
+		return m, m.reload(v.results[v.cursor].info, false)
+	}
+	return m, nil
+}
+
+func (m *model) catalogScreenView() string {
+	v := m.catalog
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Search Spotify")) + "\n\n")
+	b.WriteString(pad + "> " + v.query + "█\n\n")
+
+	switch {
+	case v.loading:
+		b.WriteString(pad + helpStyle(tr("Loading...")) + "\n")
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	case v.searched != "" && len(v.results) == 0:
+		b.WriteString(pad + helpStyle(tr("Nothing found")) + "\n")
+	}
+
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+	for i := start; i < len(v.results) && i < start+rows; i++ {
+		r := v.results[i]
+		kind := tr("Track")
+		if r.isAlbum() {
+			kind = tr("Album")
+		}
+		hint := " " + kind
+		if r.year != "" {
+			hint += ", " + r.year
+		}
+		if !r.isAlbum() {
+			hint += ", " + r.info.album
+		}
+		line := r.info.artist + " - " + r.title()
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + helpStyle(hint) + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + helpStyle(hint) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("enter: Search or Play • tab: Info • ↑/↓: Select • esc: Back")))
+	return b.String()
+}
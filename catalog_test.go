@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFetchSpotifyCatalog(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var played map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			if q := r.URL.Query(); q.Get("q") != "massive attack" || q.Get("type") != "album,track" {
+				t.Errorf("got query %v", q)
+			}
+			w.Write([]byte(`{
+				"albums":{"items":[{"uri":"spotify:album:1","name":"Mezzanine (Remastered)","release_date":"1998-04-20","artists":[{"name":"Massive Attack"}]}]},
+				"tracks":{"items":[{"uri":"spotify:track:2","name":"Teardrop","artists":[{"name":"Massive Attack"}],"album":{"name":"Mezzanine","release_date":"1998"}},
+					{"uri":"spotify:track:3","name":"Nobody","artists":[]}]}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/me/player/play":
+			json.NewDecoder(r.Body).Decode(&played)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api string) { spotifyAPIURL = api }(spotifyAPIURL)
+	spotifyAPIURL = server.URL
+
+	results, err := fetchSpotifyCatalog(context.Background(), "massive attack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []catalogResult{
+		{info: MusicInfo{artist: "Massive Attack", album: "Mezzanine"}, uri: "spotify:album:1", year: "1998"},
+		{info: MusicInfo{artist: "Massive Attack", album: "Mezzanine", track: "Teardrop"}, uri: "spotify:track:2", year: "1998"},
+	}
+	if len(results) != len(want) || results[0] != want[0] || results[1] != want[1] {
+		t.Fatalf("got %+v", results)
+	}
+
+	if err := startSpotifyResult(context.Background(), results[0]); err != nil {
+		t.Fatal(err)
+	}
+	if played["context_uri"] != "spotify:album:1" {
+		t.Errorf("played %v", played)
+	}
+	if err := startSpotifyResult(context.Background(), results[1]); err != nil {
+		t.Fatal(err)
+	}
+	if uris, _ := played["uris"].([]any); len(uris) != 1 || uris[0] != "spotify:track:2" {
+		t.Errorf("played %v", played)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+	m := setupTest(t, PlayerPlaying)
+	defer func(id string) { spotifyClientID = id }(spotifyClientID)
+	spotifyClientID = "client"
+
+	mezzanine := MusicInfo{artist: "Massive Attack", album: "Mezzanine"}
+	teardrop := MusicInfo{artist: "Massive Attack", album: "Mezzanine", track: "Teardrop"}
+	var searched string
+	var played catalogResult
+	searchSpotifyCatalog = func(ctx context.Context, query string) ([]catalogResult, error) {
+		searched = query
+		return []catalogResult{{info: mezzanine, uri: "spotify:album:1", year: "1998"}, {info: teardrop, uri: "spotify:track:2"}}, nil
+	}
+	playSpotifyResult = func(ctx context.Context, r catalogResult) error {
+		played = r
+		return nil
+	}
+	defer func() {
+		searchSpotifyCatalog = fetchSpotifyCatalog
+		playSpotifyResult = startSpotifyResult
+	}()
+
+	m.loading = false
+	m.Update(keyRune('C'))
+	if m.catalog == nil {
+		t.Fatal("C did not open the catalog search")
+	}
+	for _, r := range "mezzanine" {
+		m.Update(keyRune(r))
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter did not search")
+	}
+	m.Update(cmd())
+	// The audio features of the album would use the Web API.
+	spotifyClientID = ""
+	if view := m.View(); searched != "mezzanine" || !strings.Contains(view, "Massive Attack - Mezzanine Album, 1998") {
+		t.Errorf("searched %q, got\n%s", searched, view)
+	}
+
+	// Tab shows the info of the album without playing it.
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.catalog != nil || m.MusicInfo != mezzanine || !m.query || played.uri != "" {
+		t.Fatalf("got track %+v, query %v, played %+v", m.MusicInfo, m.query, played)
+	}
+	if m.isNewTrack(testTrack, PlayerPlaying) {
+		t.Error("the album is replaced by the track playing")
+	}
+	waitFetched(t, m)
+
+	// Enter plays the track and the player is followed again.
+	m.loading = false
+	spotifyClientID = "client"
+	m.Update(keyRune('C'))
+	for _, r := range "teardrop" {
+		m.Update(keyRune(r))
+	}
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+	spotifyClientID = ""
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+	if played.uri != "spotify:track:2" || m.catalog != nil || m.MusicInfo != teardrop || m.query {
+		t.Errorf("played %+v, got track %+v and query %v", played, m.MusicInfo, m.query)
+	}
+	waitFetched(t, m)
+}
+
+// waitFetched waits for the info of the track, the provider is gone after
+// the test.
+func waitFetched(t *testing.T, m *model) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		m.mu.Lock()
+		done := m.steps > 0 && m.stepsDone >= m.steps
+		m.mu.Unlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the info of the track was not fetched")
+		}
+	}
+}
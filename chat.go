@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chatContextTurns is how many previous questions are sent along with a new
+// one.
+const chatContextTurns = 10
+
+// chatTurn is a question and its answer, or the error that came instead.
+type chatTurn struct {
+	question string
+	answer   string
+	err      string
+}
+
+// chatView is the open chat about a track. The turns are kept in
+// model.chats so the conversation is still there when it is opened again.
+type chatView struct {
+	info  MusicInfo
+	input string
+	// waiting is set while the answer to the last question is on its way.
+	waiting bool
+	// scroll is how many lines the transcript is scrolled up from the end.
+	scroll int
+	// rendered is the transcript rendered for renderedWidth.
+	rendered      string
+	renderedWidth int
+}
+
+type chatAnswerMsg struct {
+	info   MusicInfo
+	answer string
+	err    error
+}
+
+func (m *model) openChat() {
+	m.chat = &chatView{info: m.MusicInfo}
+}
+
+// chatPrompt asks question with the track and the previous turns as context.
+func chatPrompt(info MusicInfo, turns []chatTurn, question string) string {
+	var b strings.Builder
+	switch {
+	case info.isPodcast():
+		fmt.Fprintf(&b, "You are a podcast expert talking with a listener about the episode %q", info.track)
+		if info.album != "" {
+			fmt.Fprintf(&b, " of the podcast %q", info.album)
+		}
+	case info.track == "":
+		// The artist deep dive.
+		fmt.Fprintf(&b, "You are a music expert talking with a fan about the artist %s", info.artist)
+	default:
+		fmt.Fprintf(&b, "You are a music expert talking with a listener about the song %q by %s", info.track, info.artist)
+		if info.album != "" {
+			fmt.Fprintf(&b, " from the album %q", info.album)
+		}
+	}
+	b.WriteString(".\n")
+
+	if len(turns) > chatContextTurns {
+		turns = turns[len(turns)-chatContextTurns:]
+	}
+	if len(turns) > 0 {
+		b.WriteString("\nThe conversation so far:\n")
+		for _, t := range turns {
+			if t.err == "" {
+				fmt.Fprintf(&b, "Listener: %s\nYou: %s\n", t.question, t.answer)
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "\nListener: %s\nAnswer the last question of the listener.", question)
+	return inLanguage(b.String())
+}
+
+// askChat sends the typed question, the answer comes back as a
+// chatAnswerMsg.
+func (m *model) askChat() tea.Cmd {
+	c := m.chat
+	question := strings.TrimSpace(c.input)
+	if question == "" || c.waiting {
+		return nil
+	}
+
+	info := c.info
+	prompt := chatPrompt(info, m.chats[info], question)
+	m.chats[info] = append(m.chats[info], chatTurn{question: question})
+	c.input = ""
+	c.waiting = true
+	c.scroll = 0
+	c.rendered = ""
+
+	return func() tea.Msg {
+		answer, err := m.complete(context.Background(), chatModel, prompt)
+		return chatAnswerMsg{info: info, answer: answer, err: err}
+	}
+}
+
+// answerChat fills in the last turn of the conversation msg belongs to.
+func (m *model) answerChat(msg chatAnswerMsg) {
+	turns := m.chats[msg.info]
+	if len(turns) == 0 {
+		return
+	}
+	last := &turns[len(turns)-1]
+	if msg.err != nil {
+		last.err = provider + " api: " + msg.err.Error()
+	} else {
+		last.answer = msg.answer
+	}
+
+	if m.chat != nil && m.chat.info == msg.info {
+		m.chat.waiting = false
+		m.chat.rendered = ""
+	}
+}
+
+func (m *model) updateChat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.chat
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.chat = nil
+	case tea.KeyEnter:
+		return m, m.askChat()
+	case tea.KeyBackspace:
+		if r := []rune(c.input); len(r) > 0 {
+			c.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		c.input += string(msg.Runes)
+	case tea.KeyUp:
+		c.scroll++
+	case tea.KeyDown:
+		if c.scroll > 0 {
+			c.scroll--
+		}
+	case tea.KeyPgUp:
+		c.scroll += m.chatRows()
+	case tea.KeyPgDown:
+		c.scroll -= m.chatRows()
+		if c.scroll < 0 {
+			c.scroll = 0
+		}
+	}
+	return m, nil
+}
+
+// chatRows is the height of the transcript.
+func (m *model) chatRows() int {
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// chatTranscript renders the conversation as markdown.
+func (m *model) chatTranscript() string {
+	c := m.chat
+	width := m.viewportWidth() - viewportFrame
+	if c.rendered != "" && c.renderedWidth == width {
+		return c.rendered
+	}
+
+	var b strings.Builder
+	for _, t := range m.chats[c.info] {
+		fmt.Fprintf(&b, "**> %s**\n\n", t.question)
+		switch {
+		case t.err != "":
+			fmt.Fprintf(&b, "> ⚠ %s\n\n", t.err)
+		case t.answer == "":
+			b.WriteString("*" + tr("Thinking...") + "*\n\n")
+		default:
+			b.WriteString(t.answer + "\n\n")
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("*" + tr("Ask anything about the track, e.g. who produced it or the story behind it.") + "*")
+	}
+
+	rendered, err := renderContent(b.String(), width)
+	if err != nil {
+		rendered = b.String()
+	}
+	c.rendered, c.renderedWidth = strings.TrimRight(rendered, "\n"), width
+	return c.rendered
+}
+
+func (m *model) chatScreenView() string {
+	c := m.chat
+	pad := strings.Repeat(" ", padding)
+
+	// Podcasts have the show instead of an artist.
+	name := c.info.artist
+	if c.info.isPodcast() {
+		name = c.info.album
+	}
+
+	var b strings.Builder
+	title := trf("Chat: %s - %s", name, c.info.track)
+	if c.info.track == "" {
+		title = trf("Chat: %s", name)
+	}
+	b.WriteString(styleTitle(pad+title) + "\n\n")
+
+	lines := strings.Split(m.chatTranscript(), "\n")
+	rows := m.chatRows()
+	// Scrolling stops at the top of the transcript.
+	end := len(lines) - c.scroll
+	if end < rows {
+		end = rows
+		if end > len(lines) {
+			end = len(lines)
+		}
+		c.scroll = len(lines) - end
+	}
+	start := end - rows
+	if start < 0 {
+		start = 0
+	}
+	b.WriteString(strings.Join(lines[start:end], "\n") + "\n\n")
+
+	b.WriteString(pad + "> " + c.input + "█\n\n")
+	b.WriteString(pad + helpStyle(tr("enter: Send • ↑/↓/pgup/pgdown: Scroll • esc: Back")))
+	return b.String()
+}
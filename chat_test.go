@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestChat(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	ask := func(question string) {
+		t.Helper()
+		for _, r := range question {
+			if r == ' ' {
+				m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+			} else {
+				m.Update(keyRune(r))
+			}
+		}
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if cmd == nil {
+			t.Fatal("no question sent")
+		}
+		m.Update(cmd())
+	}
+
+	m.Update(keyRune('c'))
+	if m.chat == nil {
+		t.Fatal("chat is not open")
+	}
+	ask("who produced it")
+
+	turns := m.chats[m.MusicInfo]
+	if len(turns) != 1 || turns[0].question != "who produced it" {
+		t.Fatalf("got turns %+v", turns)
+	}
+	if !strings.Contains(turns[0].answer, `about the song "Airbag" by Radiohead`) {
+		t.Errorf("got answer %q", turns[0].answer)
+	}
+	if view := m.View(); !strings.Contains(view, "who produced it") {
+		t.Errorf("question not in view:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.chat != nil {
+		t.Fatal("chat is still open")
+	}
+
+	// The conversation is kept and sent with the next question.
+	m.Update(keyRune('c'))
+	ask("and the video")
+	turns = m.chats[m.MusicInfo]
+	if len(turns) != 2 {
+		t.Fatalf("got %d turns", len(turns))
+	}
+	if !strings.Contains(turns[1].answer, "Listener: who produced it\nYou: stub answer for:") {
+		t.Errorf("history not in prompt %q", turns[1].answer)
+	}
+}
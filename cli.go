@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subcommand is a command of stui, given as the first arguments. Without one
+// stui shows the info of the playing track in the terminal.
+type subcommand struct {
+	name  string
+	usage string
+}
+
+var subcommands = []subcommand{
+	{"print", "Print the info of the playing track as markdown and exit, -output text or json for the other formats"},
+	{"serve", "Publish the info of the playing track over HTTP on -addr"},
+	{"mcp", "Run a Model Context Protocol server on stdio"},
+	{"artist", "Deep dive into the named artist, or the one playing"},
+	{"query", `Show the info of an album that is not playing: stui query "Artist" "Album" ["Track"]`},
+	{"history", "List the last tracks of the history, up to -limit"},
+	{"bar", "Print the playing track as the JSON of a Waybar custom module on every change"},
+	{"status", `Print the playing track as one line for a status bar: stui status -format "{{.Artist}} – {{.Track}}"`},
+	{"config init", "Set up the AI provider, the language, the theme and Spotify, and write them to -config; the defaults without a terminal"},
+	{"version", "Print version information and exit"},
+}
+
+// printHistoryLimit is how many tracks stui history lists.
+var printHistoryLimit = 20
+
+// parseSubcommand splits the subcommand off args, "" when stui is run without
+// one. The flags follow the subcommand.
+func parseSubcommand(args []string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args, nil
+	}
+	for _, c := range subcommands {
+		words := strings.Fields(c.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == c.name {
+			return c.name, args[len(words):], nil
+		}
+	}
+	if args[0] == "config" {
+		return "", nil, errors.New(`Unknown config command, use "stui config init"`)
+	}
+	var names []string
+	for _, c := range subcommands {
+		names = append(names, c.name)
+	}
+	return "", nil, fmt.Errorf("Unknown command %q, use %s", args[0], strings.Join(names, ", "))
+}
+
+// versionRequested reports whether stui version or -version is run, before
+// the flags are parsed as they need the config.
+func versionRequested(command string, args []string) bool {
+	if command == "version" {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "version" {
+			on, err := strconv.ParseBool(value)
+			return !hasValue || err == nil && on
+		}
+	}
+	return false
+}
+
+// usage prints the subcommands and the flags, it is flag.Usage.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: stui [command] [flags]\n\nCommands:\n")
+	for _, c := range subcommands {
+		fmt.Fprintf(out, "  %-12s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintf(out, "\nWithout a command stui shows the info of the playing track.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// printHistory lists the last limit tracks of the history, most recent first.
+func printHistory(w io.Writer, limit int) error {
+	db, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tracks, err := db.Recent(context.Background(), limit)
+	if err != nil {
+		return err
+	}
+	if len(tracks) == 0 {
+		fmt.Fprintln(w, "The history is empty")
+		return nil
+	}
+	for _, t := range tracks {
+		title := t.Artist + " - " + t.Track
+		if t.Track == "" {
+			title = t.Artist + " - " + t.Album
+		} else if t.Album != "" {
+			title += " (" + t.Album + ")"
+		}
+		plays := "1 play"
+		if t.Plays != 1 {
+			plays = fmt.Sprintf("%d plays", t.Plays)
+		}
+		fmt.Fprintf(w, "%s  %s, %s\n", t.LastSeen.Local().Format("2006-01-02 15:04"), title, plays)
+	}
+	return nil
+}
+
+// parseQuery returns the album or track of the arguments of stui query.
+func parseQuery(args []string) (MusicInfo, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return MusicInfo{}, errors.New(`stui query needs the artist and the album, and optionally the track: stui query "Artist" "Album" ["Track"]`)
+	}
+	info := MusicInfo{album: strings.TrimSpace(args[1])}
+	if len(args) == 3 {
+		info.track = strings.TrimSpace(args[2])
+	}
+	info.setArtists(args[0])
+	if info.artist == "" || info.album == "" {
+		return MusicInfo{}, errors.New("stui query: the artist and the album can not be empty")
+	}
+	return info, nil
+}
+
+// configTemplate is what stui config init writes: the main settings with
+// their defaults, commented out. The README lists every setting.
+const configTemplate = `# stui settings, the command line flags override them.
+# Every setting is described in https://github.com/ernesto27/stui#config
+
+# player = %q
+# provider = %q
+# model = "" # the default model of the provider
+# language = "" # write the AI sections in this language instead of English
+# locale = %q
+# theme = %q
+# layout = %q
+# artwork = %q
+# history = %t
+# cache_ttl = %q
+# watch_interval = %q
+`
+
+// initConfig writes the config template to path, it does not replace an
+// existing file.
+func initConfig(path string) error {
+	if path == "" {
+		return errors.New("config init: no config path, pass -config")
+	}
+	path = expandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("config init: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("config init: %s already exists", path)
+	} else if err != nil {
+		return fmt.Errorf("config init: %w", err)
+	}
+	_, err = fmt.Fprintf(f, configTemplate, playerKey, provider, locale, themeName, layout, artworkMode,
+		historyEnabled, cacheTTL.Round(time.Second).String(), pollInterval.String())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("config init: %w", err)
+	}
+	return nil
+}
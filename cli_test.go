@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestParseSubcommand(t *testing.T) {
+	for _, tt := range []struct {
+		args    []string
+		command string
+		rest    []string
+	}{
+		{nil, "", nil},
+		{[]string{"-model", "gpt-4o"}, "", []string{"-model", "gpt-4o"}},
+		{[]string{"print", "-render"}, "print", []string{"-render"}},
+		{[]string{"config", "init", "-config", "c.toml"}, "config init", []string{"-config", "c.toml"}},
+		{[]string{"artist", "Björk"}, "artist", []string{"Björk"}},
+		{[]string{"status", "-format", "{{.Track}}"}, "status", []string{"-format", "{{.Track}}"}},
+	} {
+		command, rest, err := parseSubcommand(tt.args)
+		if err != nil || command != tt.command || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("parseSubcommand(%q) = %q, %q, %v", tt.args, command, rest, err)
+		}
+	}
+
+	for _, args := range [][]string{{"bogus"}, {"config"}, {"config", "edit"}} {
+		if _, _, err := parseSubcommand(args); err == nil {
+			t.Errorf("parseSubcommand(%q) accepts an unknown command", args)
+		}
+	}
+}
+
+func TestVersionWithBrokenConfig(t *testing.T) {
+	if args := os.Getenv("STUI_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"stui"}, strings.Fields(args)...)
+		main()
+		return
+	}
+
+	broken := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(broken, []byte("provider = \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range []string{
+		"-config " + broken + " -version",
+		"version -config " + broken,
+		"-config " + filepath.Join(t.TempDir(), "missing.toml") + " --version=true",
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestVersionWithBrokenConfig$")
+		cmd.Env = append(os.Environ(), "STUI_TEST_ARGS="+args)
+		out, err := cmd.CombinedOutput()
+		if err != nil || !strings.HasPrefix(string(out), "stui "+version+" (commit ") {
+			t.Errorf("stui %s printed %q: %v", args, out, err)
+		}
+	}
+
+	if versionRequested("", []string{"-version=false"}) || versionRequested("", []string{"--", "-version"}) {
+		t.Error("the version is printed without being asked for")
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stui", "config.toml")
+	if err := initConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `# provider = "openai"`) {
+		t.Errorf("got\n%s", data)
+	}
+	// The settings read back once uncommented.
+	var cfg Config
+	uncommented := regexp.MustCompile(`(?m)^# (\w+ =)`).ReplaceAllString(string(data), "$1")
+	if _, err := toml.Decode(uncommented, &cfg); err != nil {
+		t.Fatalf("%v in\n%s", err, uncommented)
+	}
+	if cfg.Provider != "openai" || cfg.CacheTTL == nil || cfg.CacheTTL.Duration != cacheTTL {
+		t.Errorf("got %+v", cfg)
+	}
+
+	if err := initConfig(path); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got %v for an existing file", err)
+	}
+}
+
+func TestPrintHistory(t *testing.T) {
+	defer func(p string) { historyPath = p }(historyPath)
+	historyPath = filepath.Join(t.TempDir(), "history.db")
+
+	var buf bytes.Buffer
+	if err := printHistory(&buf, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "The history is empty\n" {
+		t.Errorf("got %q", got)
+	}
+
+	db, err := storage.Open(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 21, 3, 0, 0, time.Local)
+	for _, play := range []time.Time{at, at.Add(time.Minute)} {
+		if _, err := db.RecordPlay(context.Background(), "Radiohead", "OK Computer", "Airbag", play); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.RecordPlay(context.Background(), "Björk", "Homogenic", "", at.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	buf.Reset()
+	if err := printHistory(&buf, 10); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-05-01 21:04  Radiohead - Airbag (OK Computer), 2 plays\n" +
+		"2024-05-01 20:03  Björk - Homogenic, 1 play\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	info, err := parseQuery([]string{"Björk", " Homogenic ", "Jóga"})
+	if err != nil || info != (MusicInfo{artist: "Björk", album: "Homogenic", track: "Jóga"}) {
+		t.Errorf("got %+v, %v", info, err)
+	}
+	for _, args := range [][]string{{"Björk"}, {"Björk", ""}, {"a", "b", "c", "d"}} {
+		if _, err := parseQuery(args); err == nil {
+			t.Errorf("parseQuery(%q) accepts it", args)
+		}
+	}
+}
+
+func TestQueryIgnoresPlayer(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.query = true
+	m.loading = false
+
+	if m.isNewTrack(MusicInfo{artist: "Portishead", album: "Dummy", track: "Roads"}, PlayerPlaying) {
+		t.Error("a query is replaced by the track playing")
+	}
+	if m.startPlayback() != nil {
+		t.Error("a query follows the playback")
+	}
+}
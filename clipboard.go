@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copyToClipboard puts text on the system clipboard, replaced in tests. The
+// command it returns, if any, finishes the copy through the program.
+var copyToClipboard = writeClipboard
+
+// clipboardCommands are tried in order, the first one that is installed is
+// used.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	default:
+		var cmds [][]string
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			cmds = append(cmds, []string{"wl-copy"})
+		}
+		return append(cmds,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"},
+		)
+	}
+}
+
+// writeClipboard copies text with the platform clipboard tool and falls back
+// to the OSC 52 escape sequence, which also works over ssh, when none works.
+// Bubble Tea owns the terminal, the sequence is printed by the program
+// between its frames.
+func writeClipboard(text string) (tea.Cmd, error) {
+	for _, args := range clipboardCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil, nil
+		}
+	}
+
+	return tea.Println(osc52(text)), nil
+}
+
+// osc52 is the sequence asking the terminal to set the clipboard. tmux only
+// forwards it wrapped in a passthrough sequence.
+func osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// copyText copies text and tells what was copied.
+func (m *model) copyText(what string, text string) tea.Cmd {
+	cmd, err := copyToClipboard(text)
+	if err != nil {
+		m.errMsg = "  copy: " + err.Error()
+		return nil
+	}
+	m.notice = "  " + trf("Copied %s to the clipboard", what)
+	return cmd
+}
+
+// currentTabText returns the markdown of the selected tab, or the lyrics.
+func (m *model) currentTabText() string {
+	t, _ := m.currentTab()
+	if !t.lyrics {
+		return t.content
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lyrics == nil {
+		return ""
+	}
+	return m.lyrics.Plain
+}
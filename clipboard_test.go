@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	if got, want := osc52("Airbag"), "\x1b]52;c;QWlyYmFn\a"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if got, want := osc52("Airbag"), "\x1bPtmux;\x1b\x1b]52;c;QWlyYmFn\a\x1b\\"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCopyKeys(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	var copied []string
+	copyToClipboard = func(text string) (tea.Cmd, error) {
+		copied = append(copied, text)
+		return nil, nil
+	}
+	defer func() { copyToClipboard = writeClipboard }()
+
+	m.Update(keyRune('y'))
+	m.Update(keyRune('Y'))
+	m.Update(keyRune('o'))
+	m.Update(keyRune('y'))
+
+	if len(copied) != 3 {
+		t.Fatalf("copied %d times, want 3", len(copied))
+	}
+	if !strings.HasPrefix(copied[0], "## Album info") || strings.Contains(copied[0], "## Album review") {
+		t.Errorf("tab copy is %q", copied[0])
+	}
+	if !strings.HasPrefix(copied[1], "# Radiohead - OK Computer - Airbag") || !strings.Contains(copied[1], "## Album review") {
+		t.Errorf("document copy is %q", copied[1])
+	}
+	if copied[2] != m.links.YouTube {
+		t.Errorf("link copy is %q", copied[2])
+	}
+	if !strings.Contains(m.notice, "link") {
+		t.Errorf("notice is %q", m.notice)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "strings"
+
+// sameAlbum reports whether a and b are the same album, ignoring case.
+func sameAlbum(a, b MusicInfo) bool {
+	return strings.EqualFold(a.artist, b.artist) && strings.EqualFold(a.album, b.album)
+}
+
+// togglePin pins the album shown, or unpins the pinned one. While an album
+// is pinned the other albums that play get a comparison with it.
+func (m *model) togglePin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compare.album != "" {
+		m.notice = "  " + trf("Unpinned %s", m.compare.album)
+		m.compare = MusicInfo{}
+		return
+	}
+	if m.deepDive || m.isPodcast() || m.album == "" {
+		return
+	}
+	m.compare = MusicInfo{artist: m.artist, album: m.album}
+	m.notice = "  " + trf("Pinned %s, the next albums are compared with it", m.album)
+}
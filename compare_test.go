@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPinAlbum(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+
+	m.togglePin()
+	if m.compare != (MusicInfo{artist: "Radiohead", album: "OK Computer"}) {
+		t.Fatalf("got pinned %+v", m.compare)
+	}
+
+	hasComparison := func() (string, bool) {
+		for _, s := range m.sections {
+			if s.key == "comparison" {
+				return s.Content, true
+			}
+		}
+		return "", false
+	}
+
+	// The pinned album is not compared with itself.
+	m.reset(testTrack)
+	m.getInfo(context.Background())
+	if _, ok := hasComparison(); ok {
+		t.Error("the pinned album got a comparison")
+	}
+
+	m.reset(MusicInfo{artist: "Radiohead", album: "Kid A", track: "Idioteque"})
+	m.getInfo(context.Background())
+	content, ok := hasComparison()
+	if !ok {
+		t.Fatal("no comparison with the pinned album")
+	}
+	if !strings.Contains(content, "Compare the album Kid A by Radiohead with the album OK Computer by Radiohead side by side") ||
+		!strings.Contains(content, "columns Aspect, Kid A and OK Computer") {
+		t.Errorf("got comparison %q", content)
+	}
+
+	m.togglePin()
+	if m.compare != (MusicInfo{}) || !strings.Contains(m.notice, "Unpinned OK Computer") {
+		t.Errorf("got pinned %+v and notice %q", m.compare, m.notice)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var bandsintownURL = "https://rest.bandsintown.com"
+
+// bandsintownAppID enables the concerts section, concertsCountry keeps only
+// the shows in that country, as Bandsintown names it, e.g. "Argentina".
+var (
+	bandsintownAppID = os.Getenv("BANDSINTOWN_APP_ID")
+	concertsCountry  string
+)
+
+// maxConcerts is how many upcoming shows are listed.
+const maxConcerts = 15
+
+var errNoConcerts = errors.New("no upcoming concerts")
+
+// getConcerts is replaced in tests.
+var getConcerts = fetchConcerts
+
+type Concert struct {
+	Datetime string `json:"datetime"`
+	URL      string `json:"url"`
+	Venue    struct {
+		Name    string `json:"name"`
+		City    string `json:"city"`
+		Region  string `json:"region"`
+		Country string `json:"country"`
+	} `json:"venue"`
+}
+
+// fetchConcerts lists the upcoming shows of the artist.
+func fetchConcerts(ctx context.Context, info MusicInfo) ([]Concert, error) {
+	u := fmt.Sprintf("%s/artists/%s/events?date=upcoming&app_id=%s", bandsintownURL, url.PathEscape(info.artist), url.QueryEscape(bandsintownAppID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNoConcerts
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, "bandsintown: status code %d", resp.StatusCode)
+	}
+
+	// Unknown artists get an object with an error instead of a list.
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	var concerts []Concert
+	if err := json.Unmarshal(body, &concerts); err != nil {
+		return nil, errNoConcerts
+	}
+	return concerts, nil
+}
+
+// concertsSection renders the upcoming shows, in concertsCountry when it is
+// set.
+func concertsSection(ctx context.Context, info MusicInfo) (string, error) {
+	concerts, err := getConcerts(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	shown := 0
+	for _, c := range concerts {
+		if concertsCountry != "" && !strings.EqualFold(c.Venue.Country, concertsCountry) {
+			continue
+		}
+		if shown == maxConcerts {
+			break
+		}
+		if shown == 0 {
+			b.WriteString("| Date | City | Venue |\n| --- | --- | --- |\n")
+		}
+		shown++
+
+		venue := tableCell(c.Venue.Name)
+		if c.URL != "" {
+			venue = "[" + venue + "](" + c.URL + ")"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", concertDate(c.Datetime), tableCell(concertPlace(c)), venue)
+	}
+
+	if shown == 0 {
+		return "", errNoConcerts
+	}
+	b.WriteString("\n*Source: Bandsintown*")
+	return b.String(), nil
+}
+
+// concertDate formats the local time of a show, values that do not parse
+// are shown as they are.
+func concertDate(s string) string {
+	t, err := time.Parse("2006-01-02T15:04:05", s)
+	if err != nil {
+		return s
+	}
+	return t.Format("Mon Jan 2 2006")
+}
+
+func concertPlace(c Concert) string {
+	var parts []string
+	for _, p := range []string{c.Venue.City, c.Venue.Region, c.Venue.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
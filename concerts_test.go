@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConcertsSection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artists/Radiohead/events" || r.URL.Query().Get("app_id") != "stui" {
+			t.Errorf("got %s", r.URL)
+		}
+		w.Write([]byte(`[
+			{"datetime":"2025-06-01T20:00:00","url":"https://www.bandsintown.com/e/1",
+				"venue":{"name":"Estadio River Plate","city":"Buenos Aires","country":"Argentina"}},
+			{"datetime":"2025-06-10T21:00:00","url":"https://www.bandsintown.com/e/2",
+				"venue":{"name":"Madison Square Garden","city":"New York","region":"NY","country":"United States"}}
+		]`))
+	}))
+	defer server.Close()
+
+	defer func(u, id, country string) { bandsintownURL, bandsintownAppID, concertsCountry = u, id, country }(bandsintownURL, bandsintownAppID, concertsCountry)
+	bandsintownURL, bandsintownAppID = server.URL, "stui"
+
+	got, err := concertsSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Sun Jun 1 2025 | Buenos Aires, Argentina | [Estadio River Plate](https://www.bandsintown.com/e/1) |",
+		"| Tue Jun 10 2025 | New York, NY, United States | [Madison Square Garden](https://www.bandsintown.com/e/2) |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("section does not include %q:\n%s", want, got)
+		}
+	}
+
+	concertsCountry = "argentina"
+	got, err = concertsSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "New York") || !strings.Contains(got, "Buenos Aires") {
+		t.Errorf("the country filter was not applied:\n%s", got)
+	}
+
+	concertsCountry = "Japan"
+	if _, err := concertsSection(context.Background(), testTrack); err != errNoConcerts {
+		t.Errorf("got %v, want errNoConcerts", err)
+	}
+}
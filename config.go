@@ -0,0 +1,693 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Config holds the settings read from the config file. Zero values keep the
+// built-in defaults and command line flags override the file.
+type Config struct {
+	Player                   string                   `toml:"player"`
+	Artwork                  string                   `toml:"artwork"`
+	Layout                   string                   `toml:"layout"`
+	ArtworkWidth             int                      `toml:"artwork_width"`
+	ArtworkTheme             bool                     `toml:"artwork_theme"`
+	SpotifyClientID          string                   `toml:"spotify_client_id"`
+	MPDHost                  string                   `toml:"mpd_host"`
+	MPDPort                  int                      `toml:"mpd_port"`
+	MPDPassword              string                   `toml:"mpd_password"`
+	Provider                 string                   `toml:"provider"`
+	Model                    string                   `toml:"model"`
+	OllamaURL                string                   `toml:"ollama_url"`
+	OpenAIBaseURL            string                   `toml:"openai_base_url"`
+	OpenRouterAPIKey         string                   `toml:"openrouter_api_key"`
+	AnthropicAPIKey          string                   `toml:"anthropic_api_key"`
+	GoogleAPIKey             string                   `toml:"google_api_key"`
+	AzureEndpoint            string                   `toml:"azure_endpoint"`
+	AzureDeployment          string                   `toml:"azure_deployment"`
+	AzureEmbeddingDeployment string                   `toml:"azure_embedding_deployment"`
+	AzureAPIVersion          string                   `toml:"azure_api_version"`
+	AzureAPIKey              string                   `toml:"azure_api_key"`
+	FallbackModel            string                   `toml:"fallback_model"`
+	EmbeddingModel           string                   `toml:"embedding_model"`
+	Language                 string                   `toml:"language"`
+	Length                   lengthSetting            `toml:"length"`
+	Lengths                  map[string]lengthSetting `toml:"lengths"`
+	TranslateTo              string                   `toml:"translate_to"`
+	TokenFile                string                   `toml:"token_file"`
+	DiscogsToken             string                   `toml:"discogs_token"`
+	GeniusToken              string                   `toml:"genius_token"`
+	LastfmAPIKey             string                   `toml:"lastfm_api_key"`
+	LastfmUser               string                   `toml:"lastfm_user"`
+	LastfmSecret             string                   `toml:"lastfm_secret"`
+	Scrobble                 bool                     `toml:"scrobble"`
+	ListenBrainzToken        string                   `toml:"listenbrainz_token"`
+	BandsintownAppID         string                   `toml:"bandsintown_app_id"`
+	PrefetchNext             bool                     `toml:"prefetch_next"`
+	PrefetchLead             duration                 `toml:"prefetch_lead"`
+	Reddit                   bool                     `toml:"reddit"`
+	RedditSummary            bool                     `toml:"reddit_summary"`
+	News                     bool                     `toml:"news"`
+	Scene                    bool                     `toml:"scene"`
+	NewsSummary              bool                     `toml:"news_summary"`
+	NewsFeeds                []string                 `toml:"news_feeds"`
+	ConcertsCountry          string                   `toml:"concerts_country"`
+	MaxWidth                 *int                     `toml:"max_width"`
+	Padding                  *int                     `toml:"padding"`
+	Concurrency              int                      `toml:"concurrency"`
+	Sections                 []string                 `toml:"sections"`
+	SkipSections             []string                 `toml:"skip_sections"`
+	Strip                    []string                 `toml:"strip"`
+	Verify                   bool                     `toml:"verify"`
+	Watch                    bool                     `toml:"watch"`
+	Notify                   bool                     `toml:"notify"`
+	NotifyTeaser             *bool                    `toml:"notify_teaser"`
+	Debounce                 duration                 `toml:"debounce"`
+	WatchInterval            duration                 `toml:"watch_interval"`
+	Retries                  *int                     `toml:"retries"`
+	RetryDelay               duration                 `toml:"retry_delay"`
+	CacheTTL                 *duration                `toml:"cache_ttl"`
+	AITimeout                *duration                `toml:"ai_timeout"`
+	History                  *bool                    `toml:"history"`
+	HistoryPath              string                   `toml:"history_path"`
+	JournalDir               string                   `toml:"journal_dir"`
+	JournalSummary           bool                     `toml:"journal_summary"`
+	VaultDir                 string                   `toml:"vault_dir"`
+	Proxy                    string                   `toml:"proxy"`
+	CAFile                   string                   `toml:"ca_file"`
+	DebugFile                string                   `toml:"debug_file"`
+	Offline                  bool                     `toml:"offline"`
+	VaultAuto                bool                     `toml:"vault_auto"`
+	VaultFrontmatter         string                   `toml:"vault_frontmatter"`
+	Mouse                    *bool                    `toml:"mouse"`
+	Pager                    string                   `toml:"pager"`
+	Resume                   *bool                    `toml:"resume"`
+	ExportDir                string                   `toml:"export_dir"`
+	ExportFormat             string                   `toml:"export_format"`
+	ShareFormat              string                   `toml:"share_format"`
+	StatusFormat             string                   `toml:"status_format"`
+	StatusCache              duration                 `toml:"status_cache"`
+	ServeAddr                string                   `toml:"serve_addr"`
+	Keys                     map[string][]string      `toml:"keys"`
+	Theme                    string                   `toml:"theme"`
+	Locale                   string                   `toml:"locale"`
+	Colors                   Colors                   `toml:"colors"`
+	Prices                   map[string]Price         `toml:"prices"`
+	Budget                   Budget                   `toml:"budget"`
+	Postprocess              Postprocess              `toml:"postprocess"`
+	Prompts                  []Prompt                 `toml:"prompts"`
+	Plugins                  []Plugin                 `toml:"plugins"`
+	Hooks                    []Hook                   `toml:"hooks"`
+	Webhooks                 []Webhook                `toml:"webhooks"`
+}
+
+// Prompt replaces the prompt of a built-in section when Key matches one, or
+// adds a new section otherwise. Prompt is a text/template with the .Artist,
+// .Album and .Track fields.
+type Prompt struct {
+	Key    string `toml:"key"`
+	Title  string `toml:"title"`
+	Prompt string `toml:"prompt"`
+}
+
+// promptData is passed to the prompt templates.
+type promptData struct {
+	Artist string
+	Album  string
+	Track  string
+}
+
+type promptTemplate struct {
+	key      string
+	title    string
+	template *template.Template
+}
+
+// promptTemplates are the compiled prompts from the config file.
+var promptTemplates []promptTemplate
+
+func (p promptTemplate) render(info MusicInfo) (string, error) {
+	var b strings.Builder
+	err := p.template.Execute(&b, promptData{Artist: info.artist, Album: info.album, Track: info.track})
+	return b.String(), err
+}
+
+type Colors struct {
+	Title         string `toml:"title"`
+	Warning       string `toml:"warning"`
+	Badge         string `toml:"badge"`
+	Help          string `toml:"help"`
+	Border        string `toml:"border"`
+	ProgressStart string `toml:"progress_start"`
+	ProgressEnd   string `toml:"progress_end"`
+	Glamour       string `toml:"glamour"`
+}
+
+// duration reads values such as "5s" or "1m30s" from the config file.
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+// sectionKeys are the names accepted by the sections setting.
+var sectionKeys = []string{"album", "review", "comparison", "song", "bio", "tracklist", "features", "stories", "wikipedia", "reception", "discogs", "lastfm", "similar", "listenbrainz", "concerts", "reddit", "news", "scene", "summary", "lyrics"}
+
+// enabledSections is the set of sections to fetch, nil fetches all of them.
+// sectionOrder is the sections setting, the sections are shown in its order.
+// skippedSections are left out even when nothing else is set.
+var (
+	enabledSections map[string]bool
+	sectionOrder    []string
+	skippedSections map[string]bool
+)
+
+func sectionEnabled(key string) bool {
+	return (enabledSections == nil || enabledSections[key]) && !skippedSections[key]
+}
+
+// sectionRank is the position of key in the sections setting, the sections
+// missing from it keep their built-in order after the listed ones.
+func sectionRank(key string) int {
+	for i, k := range sectionOrder {
+		if k == key {
+			return i
+		}
+	}
+	return len(sectionOrder)
+}
+
+// tokenFile is a file holding the OpenAI token, used when OPENAI_TOKEN is not
+// set.
+var tokenFile string
+
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "stui", "config.toml")
+}
+
+// configPathFromArgs finds the -config flag before the flags are parsed, as
+// the file provides the defaults of the other flags.
+func configPathFromArgs(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+
+	return defaultConfigPath(), false
+}
+
+// loadConfig reads the config file at path. A missing file is only an error
+// when the path was given explicitly.
+func loadConfig(path string, explicit bool) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	_, err := toml.DecodeFile(expandHome(path), &cfg)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	keys := append([]string{}, sectionKeys...)
+	for _, p := range cfg.Prompts {
+		if p.Key == "" || p.Prompt == "" {
+			return cfg, fmt.Errorf("config %s: prompts need a key and a prompt", path)
+		}
+		if _, ok := apiSources[p.Key]; ok || p.Key == "summary" || p.Key == "lyrics" {
+			return cfg, fmt.Errorf("config %s: the %s prompt can not be replaced", path, p.Key)
+		}
+		if !containsString(keys, p.Key) && p.Title == "" {
+			return cfg, fmt.Errorf("config %s: prompt %q adds a section and needs a title", path, p.Key)
+		}
+		keys = append(keys, p.Key)
+	}
+	for _, p := range cfg.Plugins {
+		if p.Key == "" || p.Title == "" || len(p.Command) == 0 || p.Command[0] == "" {
+			return cfg, fmt.Errorf("config %s: plugins need a key, a title and a command", path)
+		}
+		if containsString(keys, p.Key) {
+			return cfg, fmt.Errorf("config %s: plugin %q has the key of another section", path, p.Key)
+		}
+		keys = append(keys, p.Key)
+	}
+
+	for _, h := range cfg.Hooks {
+		if !containsString(hookEvents, h.Event) {
+			return cfg, fmt.Errorf("config %s: unknown hook event %q, use %s", path, h.Event, strings.Join(hookEvents, ", "))
+		}
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return cfg, fmt.Errorf("config %s: the %s hook needs a command", path, h.Event)
+		}
+	}
+
+	for _, feed := range cfg.NewsFeeds {
+		if u, err := url.Parse(feed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, fmt.Errorf("config %s: news feed %q is not an http or https URL", path, feed)
+		}
+	}
+
+	for _, w := range cfg.Webhooks {
+		if w.URL == "" {
+			return cfg, fmt.Errorf("config %s: webhooks need a url", path)
+		}
+		if w.Format != "" && !containsString(webhookFormats, w.Format) {
+			return cfg, fmt.Errorf("config %s: unknown webhook format %q, use %s", path, w.Format, strings.Join(webhookFormats, ", "))
+		}
+	}
+
+	keyMap := defaultKeyMap()
+	if err := keyMap.rebind(cfg.Keys); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	if _, err := compilePrompts(cfg.Prompts); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	if _, err := compileReplacements(cfg.Postprocess.Replace); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	if cfg.VaultFrontmatter != "" {
+		if _, err := template.New("frontmatter").Funcs(vaultFuncs).Parse(cfg.VaultFrontmatter); err != nil {
+			return cfg, fmt.Errorf("config %s: vault_frontmatter: %w", path, err)
+		}
+	}
+
+	if _, _, err := parseLength(string(cfg.Length)); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	aiKeys := append([]string{}, lengthKeys...)
+	for _, p := range cfg.Prompts {
+		aiKeys = append(aiKeys, p.Key)
+	}
+	for key, length := range cfg.Lengths {
+		if !containsString(aiKeys, key) {
+			return cfg, fmt.Errorf("config %s: the %s section is not written by the AI provider, lengths apply to %s and the prompts", path, key, strings.Join(lengthKeys, ", "))
+		}
+		if _, _, err := parseLength(string(length)); err != nil {
+			return cfg, fmt.Errorf("config %s: lengths %s: %w", path, key, err)
+		}
+	}
+
+	for _, key := range append(append([]string{}, cfg.Sections...), cfg.SkipSections...) {
+		if !containsString(keys, key) {
+			return cfg, fmt.Errorf("config %s: unknown section %q, valid sections are %s", path, key, strings.Join(keys, ", "))
+		}
+	}
+
+	return cfg, nil
+}
+
+func compilePrompts(prompts []Prompt) ([]promptTemplate, error) {
+	var templates []promptTemplate
+	for _, p := range prompts {
+		t, err := template.New(p.Key).Option("missingkey=error").Parse(p.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("prompt %q: %w", p.Key, err)
+		}
+
+		pt := promptTemplate{key: p.Key, title: p.Title, template: t}
+		// Catch references to unknown fields now instead of on every request.
+		if _, err := pt.render(MusicInfo{}); err != nil {
+			return nil, fmt.Errorf("prompt %q: %w", p.Key, err)
+		}
+		templates = append(templates, pt)
+	}
+
+	return templates, nil
+}
+
+// apply sets the package level settings from the config, the flags defined
+// afterwards use them as their defaults.
+func (c Config) apply() {
+	if c.Artwork != "" {
+		artworkMode = c.Artwork
+	}
+	if c.Layout != "" {
+		layout = c.Layout
+	}
+	if c.ArtworkWidth != 0 {
+		artworkCols = c.ArtworkWidth
+	}
+	if c.ArtworkTheme {
+		artworkTheme = true
+	}
+	if c.Player != "" {
+		playerKey = c.Player
+	}
+	if c.SpotifyClientID != "" {
+		spotifyClientID = c.SpotifyClientID
+	}
+	if c.MPDHost != "" {
+		mpdHost = c.MPDHost
+	}
+	if c.MPDPort != 0 {
+		mpdPort = c.MPDPort
+	}
+	if c.MPDPassword != "" {
+		mpdPassword = c.MPDPassword
+	}
+	if c.Provider != "" {
+		provider = c.Provider
+	}
+	if c.Model != "" {
+		chatModel = c.Model
+	}
+	if c.OllamaURL != "" {
+		ollamaURL = c.OllamaURL
+	}
+	if c.OpenAIBaseURL != "" {
+		openaiBaseURL = c.OpenAIBaseURL
+	}
+	if c.OpenRouterAPIKey != "" && openrouterAPIKey == "" {
+		openrouterAPIKey = c.OpenRouterAPIKey
+	}
+	if c.AnthropicAPIKey != "" && anthropicAPIKey == "" {
+		anthropicAPIKey = c.AnthropicAPIKey
+	}
+	if c.GoogleAPIKey != "" && geminiAPIKey == "" {
+		geminiAPIKey = c.GoogleAPIKey
+	}
+	if c.AzureEndpoint != "" {
+		azureEndpoint = c.AzureEndpoint
+	}
+	if c.AzureDeployment != "" {
+		azureDeployment = c.AzureDeployment
+	}
+	if c.AzureEmbeddingDeployment != "" {
+		azureEmbeddingDeployment = c.AzureEmbeddingDeployment
+	}
+	if c.AzureAPIVersion != "" {
+		azureAPIVersion = c.AzureAPIVersion
+	}
+	if c.AzureAPIKey != "" && azureAPIKey == "" {
+		azureAPIKey = c.AzureAPIKey
+	}
+	if c.FallbackModel != "" {
+		fallbackModel = c.FallbackModel
+	}
+	if c.EmbeddingModel != "" {
+		embeddingModel = c.EmbeddingModel
+	}
+	if c.TokenFile != "" {
+		tokenFile = expandHome(c.TokenFile)
+	}
+	if c.DiscogsToken != "" && discogsToken == "" {
+		discogsToken = c.DiscogsToken
+	}
+	if c.GeniusToken != "" && geniusToken == "" {
+		geniusToken = c.GeniusToken
+	}
+	if c.LastfmAPIKey != "" && lastfmAPIKey == "" {
+		lastfmAPIKey = c.LastfmAPIKey
+	}
+	if c.LastfmUser != "" {
+		lastfmUser = c.LastfmUser
+	}
+	if c.LastfmSecret != "" && lastfmSecret == "" {
+		lastfmSecret = c.LastfmSecret
+	}
+	if c.Scrobble {
+		lastfmScrobble = true
+	}
+	if c.PrefetchNext {
+		prefetchNext = true
+	}
+	if c.PrefetchLead.Duration != 0 {
+		prefetchLead = c.PrefetchLead.Duration
+	}
+	if c.Reddit {
+		redditEnabled = true
+	}
+	if c.RedditSummary {
+		redditSummary = true
+	}
+	if c.News {
+		newsEnabled = true
+	}
+	if c.Scene {
+		sceneEnabled = true
+	}
+	if c.NewsSummary {
+		newsSummary = true
+	}
+	if len(c.NewsFeeds) > 0 {
+		newsFeeds = c.NewsFeeds
+	}
+	if c.ListenBrainzToken != "" && listenBrainzToken == "" {
+		listenBrainzToken = c.ListenBrainzToken
+	}
+	if c.BandsintownAppID != "" && bandsintownAppID == "" {
+		bandsintownAppID = c.BandsintownAppID
+	}
+	if c.ConcertsCountry != "" {
+		concertsCountry = c.ConcertsCountry
+	}
+	if c.MaxWidth != nil {
+		maxWidth = *c.MaxWidth
+	}
+	if c.Padding != nil {
+		padding = *c.Padding
+	}
+	if c.Concurrency != 0 {
+		concurrency = c.Concurrency
+	}
+	if c.Debounce.Duration != 0 {
+		debounce = c.Debounce.Duration
+	}
+	if c.History != nil {
+		historyEnabled = *c.History
+	}
+	if c.HistoryPath != "" {
+		historyPath = c.HistoryPath
+	}
+	if c.JournalDir != "" {
+		journalDir = c.JournalDir
+	}
+	if c.JournalSummary {
+		journalSummary = true
+	}
+	if c.VaultDir != "" {
+		vaultDir = c.VaultDir
+	}
+	if c.Proxy != "" {
+		proxyURL = c.Proxy
+	}
+	if c.CAFile != "" {
+		caFile = c.CAFile
+	}
+	if c.DebugFile != "" {
+		debugFile = c.DebugFile
+	}
+	if c.Offline {
+		offline = true
+	}
+	if c.VaultAuto {
+		vaultAuto = true
+	}
+	if c.VaultFrontmatter != "" {
+		vaultFrontmatter = c.VaultFrontmatter
+	}
+	if c.Mouse != nil {
+		mouseEnabled = *c.Mouse
+	}
+	if c.Pager != "" {
+		pager = c.Pager
+	}
+	if c.Resume != nil {
+		resumeEnabled = *c.Resume
+	}
+	if c.NotifyTeaser != nil {
+		notifyTeaser = *c.NotifyTeaser
+	}
+	if c.ExportDir != "" {
+		exportDir = c.ExportDir
+	}
+	if c.ExportFormat != "" {
+		exportFormat = c.ExportFormat
+	}
+	if c.ShareFormat != "" {
+		shareFormat = c.ShareFormat
+	}
+	if c.StatusFormat != "" {
+		statusFormat = c.StatusFormat
+	}
+	if c.StatusCache.Duration != 0 {
+		statusCacheTTL = c.StatusCache.Duration
+	}
+	if c.ServeAddr != "" {
+		serveAddr = c.ServeAddr
+	}
+	if c.Retries != nil {
+		maxRetries = *c.Retries
+	}
+	if c.RetryDelay.Duration != 0 {
+		retryDelay = c.RetryDelay.Duration
+	}
+	if c.CacheTTL != nil {
+		cacheTTL = c.CacheTTL.Duration
+	}
+	if c.AITimeout != nil {
+		aiTimeout = c.AITimeout.Duration
+	}
+	if c.WatchInterval.Duration != 0 {
+		pollInterval = c.WatchInterval.Duration
+	}
+	if len(c.Sections) > 0 {
+		enabledSections = map[string]bool{}
+		for _, key := range c.Sections {
+			enabledSections[key] = true
+		}
+		sectionOrder = c.Sections
+	}
+	if len(c.SkipSections) > 0 {
+		skippedSections = map[string]bool{}
+		for _, key := range c.SkipSections {
+			skippedSections[key] = true
+		}
+	}
+	albumNoise = append(albumNoise, c.Strip...)
+	// The prompts were validated by loadConfig.
+	promptTemplates, _ = compilePrompts(c.Prompts)
+	plugins = c.Plugins
+	hooks = c.Hooks
+	webhooks = c.Webhooks
+	for _, p := range plugins {
+		apiSources[p.Key] = p.source()
+	}
+	verifyClaims = c.Verify
+	if c.Language != "" {
+		language = c.Language
+	}
+	if c.Length != "" {
+		answerLength = string(c.Length)
+	}
+	if len(c.Lengths) > 0 {
+		sectionLengths = map[string]string{}
+		for key, length := range c.Lengths {
+			sectionLengths[key] = string(length)
+		}
+	}
+	if c.TranslateTo != "" {
+		translateTo = c.TranslateTo
+	}
+	// The keys were validated by loadConfig.
+	keys.rebind(c.Keys)
+
+	if c.Theme != "" {
+		themeName = c.Theme
+	}
+	if c.Locale != "" {
+		locale = c.Locale
+	}
+	// The colors are applied with the theme, once the flags are parsed.
+	customColors = c.Colors
+	for model, price := range c.Prices {
+		modelPrices[model] = price
+	}
+	budget = c.Budget
+	postprocess = c.Postprocess
+	// The replacements were validated by loadConfig.
+	replacements, _ = compileReplacements(c.Postprocess.Replace)
+}
+
+func applyColors(c Colors) {
+	if c.Title != "" {
+		styleTitle = lipgloss.NewStyle().Foreground(lipgloss.Color(c.Title)).MarginTop(1).Bold(true).Render
+	}
+	if c.Warning != "" {
+		styleWarning = lipgloss.NewStyle().Foreground(lipgloss.Color(c.Warning)).Render
+	}
+	if c.Badge != "" {
+		styleBadge = lipgloss.NewStyle().Foreground(lipgloss.Color(c.Badge)).Bold(true).Render
+	}
+	if c.Help != "" {
+		helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(c.Help)).Render
+	}
+	if c.Border != "" {
+		borderColor = lipgloss.Color(c.Border)
+	}
+	if c.ProgressStart != "" {
+		progressColors[0] = c.ProgressStart
+	}
+	if c.ProgressEnd != "" {
+		progressColors[1] = c.ProgressEnd
+	}
+	if c.Glamour != "" {
+		glamourStyle = c.Glamour
+	}
+}
+
+// openaiToken returns OPENAI_TOKEN or, when it is not set, the content of the
+// configured token file.
+func openaiToken() (string, error) {
+	if token := os.Getenv("OPENAI_TOKEN"); token != "" || tokenFile == "" {
+		return token, nil
+	}
+
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"image"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// artworkTheme takes the title, border and progress colors from the album
+// cover, on top of the theme.
+var artworkTheme bool
+
+// coverSamples is how many pixels per side of the cover are quantized.
+const coverSamples = 64
+
+// coverPalette returns up to n colors of img, the most present and vivid
+// first. Each channel is quantized to 16 levels and the pixels of a level
+// are averaged. Greys are left out, so a black and white cover has none.
+func coverPalette(img image.Image, n int) []colorful.Color {
+	type bin struct {
+		count   int
+		r, g, b float64
+	}
+	bins := map[[3]uint32]*bin{}
+
+	bounds := img.Bounds()
+	stepX, stepY := bounds.Dx()/coverSamples, bounds.Dy()/coverSamples
+	if stepX < 1 {
+		stepX = 1
+	}
+	if stepY < 1 {
+		stepY = 1
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			key := [3]uint32{r >> 12, g >> 12, b >> 12}
+			c := bins[key]
+			if c == nil {
+				c = &bin{}
+				bins[key] = c
+			}
+			c.count++
+			c.r += float64(r) / 0xffff
+			c.g += float64(g) / 0xffff
+			c.b += float64(b) / 0xffff
+		}
+	}
+
+	type scored struct {
+		color colorful.Color
+		score float64
+	}
+	var candidates []scored
+	for _, c := range bins {
+		color := colorful.Color{R: c.r / float64(c.count), G: c.g / float64(c.count), B: c.b / float64(c.count)}
+		_, s, l := color.Hsl()
+		if s < 0.2 || l < 0.08 || l > 0.95 {
+			continue
+		}
+		candidates = append(candidates, scored{color, float64(c.count) * (0.3 + s)})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var palette []colorful.Color
+	for _, c := range candidates {
+		distinct := true
+		for _, p := range palette {
+			distinct = distinct && c.color.DistanceLab(p) > 0.2
+		}
+		if distinct {
+			palette = append(palette, c.color)
+		}
+		if len(palette) == n {
+			break
+		}
+	}
+	return palette
+}
+
+// readable moves the lightness of c into the range that stands out on a dark
+// or a light background.
+func readable(c colorful.Color, dark bool) colorful.Color {
+	h, s, l := c.Hsl()
+	if dark && l < 0.6 {
+		l = 0.6
+	} else if !dark && l > 0.4 {
+		l = 0.4
+	}
+	return colorful.Hsl(h, s, l).Clamped()
+}
+
+// coverColors are the colors of the cover for the title, the border and the
+// progress gradient. No colors when the cover has none.
+func coverColors(img image.Image, dark bool) Colors {
+	palette := coverPalette(img, 3)
+	if len(palette) == 0 {
+		return Colors{}
+	}
+	for i := range palette {
+		palette[i] = readable(palette[i], dark)
+	}
+	second, third := palette[0], palette[0]
+	if len(palette) > 1 {
+		second, third = palette[1], palette[1]
+	}
+	if len(palette) > 2 {
+		third = palette[2]
+	}
+	return Colors{
+		Title:         palette[0].Hex(),
+		Border:        second.Hex(),
+		ProgressStart: palette[0].Hex(),
+		ProgressEnd:   third.Hex(),
+	}
+}
+
+// darkBackground reports whether the theme is for a dark terminal.
+func darkBackground() bool {
+	if themeName == "auto" {
+		return lipgloss.HasDarkBackground()
+	}
+	return themes[themeName].Glamour != "light"
+}
+
+// applyCoverTheme colors the screen after the cover, or back after the theme
+// for an album without one. The colors of the config file still win.
+func (m *model) applyCoverTheme(img image.Image) {
+	if !artworkTheme || (img == nil && !m.coverThemed) {
+		return
+	}
+	// The theme would also replace the glamour style of -glamour-style.
+	style := glamourStyle
+	err := applyTheme(themeName)
+	glamourStyle = style
+	if err != nil {
+		return
+	}
+	m.coverThemed = false
+	if img != nil {
+		if c := coverColors(img, darkBackground()); c != (Colors{}) {
+			applyColors(c)
+			applyColors(customColors)
+			m.coverThemed = true
+		}
+	}
+	m.restyle()
+	if m.hasContent() {
+		if err := m.renderViewport(); err != nil {
+			m.errMsg = "  theme: " + err.Error()
+		}
+	}
+}
+
+// restyle rebuilds the parts of the screen that keep their colors once
+// created.
+func (m *model) restyle() {
+	width := m.progress.Width
+	m.progress = progress.New(progress.WithScaledGradient(progressColors[0], progressColors[1]))
+	m.progress.Width = width
+	m.spinner.Style = lipgloss.NewStyle().Foreground(lipgloss.Color(progressColors[0]))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// testCover is mostly red with a blue band and a grey border.
+func testCover() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{200, 30, 30, 255}
+			switch {
+			case x < 10:
+				c = color.RGBA{128, 128, 128, 255}
+			case y >= 70:
+				c = color.RGBA{20, 40, 200, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCoverPalette(t *testing.T) {
+	palette := coverPalette(testCover(), 3)
+	if len(palette) != 2 {
+		t.Fatalf("got %d colors, want red and blue without the grey", len(palette))
+	}
+	if h, _, _ := palette[0].Hsl(); h > 10 && h < 350 {
+		t.Errorf("got hue %.0f first, want red", h)
+	}
+	if h, _, _ := palette[1].Hsl(); h < 200 || h > 250 {
+		t.Errorf("got hue %.0f second, want blue", h)
+	}
+
+	grey := image.NewGray(image.Rect(0, 0, 10, 10))
+	if palette := coverPalette(grey, 3); len(palette) != 0 {
+		t.Errorf("got %d colors of a grey cover", len(palette))
+	}
+}
+
+func TestCoverColorsReadable(t *testing.T) {
+	for _, dark := range []bool{true, false} {
+		c := coverColors(testCover(), dark)
+		for _, hex := range []string{c.Title, c.Border, c.ProgressStart, c.ProgressEnd} {
+			color, err := colorful.Hex(hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, l := color.Hsl(); (dark && l < 0.59) || (!dark && l > 0.41) {
+				t.Errorf("%s has lightness %.2f on a dark background %v", hex, l, dark)
+			}
+		}
+	}
+}
+
+func TestApplyCoverTheme(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(enabled bool, theme string) {
+		artworkTheme, themeName = enabled, theme
+		applyTheme(themeName)
+	}(artworkTheme, themeName)
+	artworkTheme, themeName = true, "dark"
+	applyTheme(themeName)
+
+	m.applyCoverTheme(testCover())
+	want := coverColors(testCover(), true)
+	if !m.coverThemed || string(borderColor) != want.Border || progressColors != [2]string{want.ProgressStart, want.ProgressEnd} {
+		t.Errorf("got border %s and progress %v, want %+v", borderColor, progressColors, want)
+	}
+
+	// The next album has no cover.
+	m.applyCoverTheme(nil)
+	if dark := themes["dark"]; m.coverThemed || string(borderColor) != dark.Border || progressColors[0] != dark.ProgressStart {
+		t.Errorf("got border %s and progress %v after the theme", borderColor, progressColors)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// program is the Bubble Tea program, nil in non-interactive mode. It holds
+// the terminal while programRunning, which only the main goroutine uses.
+var (
+	program        *tea.Program
+	programRunning bool
+)
+
+var crash struct {
+	sync.Mutex
+	report string
+}
+
+// goSafe runs fn in a new goroutine. A panic stops the program and restores
+// the terminal instead of leaving it in raw mode.
+func goSafe(fn func()) {
+	go func() {
+		defer recoverGoroutine()
+		fn()
+	}()
+}
+
+func recoverGoroutine() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	recordCrash(r)
+	if program == nil {
+		exitCrash()
+	}
+	program.Kill()
+}
+
+func recoverMain() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	recordCrash(r)
+	if programRunning {
+		_ = program.ReleaseTerminal()
+	}
+	exitCrash()
+}
+
+func recordCrash(r any) {
+	crash.Lock()
+	defer crash.Unlock()
+
+	// Keep the first panic, the rest are usually a consequence of it.
+	if crash.report == "" {
+		crash.report = fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack())
+	}
+}
+
+func crashed() bool {
+	crash.Lock()
+	defer crash.Unlock()
+	return crash.report != ""
+}
+
+// exitCrash prints a short error, writes the full report to a log file and
+// exits.
+func exitCrash() {
+	crash.Lock()
+	report := crash.report
+	crash.Unlock()
+
+	summary := strings.SplitN(report, "\n", 2)[0]
+	fmt.Fprintln(os.Stderr, "Sorry, stui crashed:", strings.TrimPrefix(summary, "panic: "))
+
+	path := filepath.Join(os.TempDir(), "stui-crash.log")
+	if err := os.WriteFile(path, []byte(report), 0o600); err == nil {
+		fmt.Fprintln(os.Stderr, "The full report was written to", path)
+	}
+	os.Exit(1)
+}
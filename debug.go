@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// debugMode writes what stui does to debugFile, one line of key=value pairs
+// per event: the HTTP requests, the answers of the AI provider, the cache
+// lookups and the player polls.
+var (
+	debugMode bool
+	debugFile = filepath.Join(os.TempDir(), "stui-debug.log")
+)
+
+// startDebugLog sends the log to debugFile and logs the requests of
+// http.DefaultTransport and the reads of the player.
+func startDebugLog() error {
+	if _, err := tea.LogToFile(expandHome(debugFile), ""); err != nil {
+		return fmt.Errorf("debug file: %w", err)
+	}
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
+	http.DefaultTransport = debugTransport{next: http.DefaultTransport}
+
+	read := getTrackInfo
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		start := time.Now()
+		info, status := read()
+		debugLog("player", "status", statusNames[status], "artist", info.artist, "album", info.album, "track", info.track, "duration", time.Since(start))
+		return info, status
+	}
+	return nil
+}
+
+// debugLog logs event with the key and value pairs of kv.
+func debugLog(event string, kv ...any) {
+	if !debugMode {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("event=" + event)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%s", kv[i], debugValue(kv[i+1]))
+	}
+	log.Print(b.String())
+}
+
+// debugValue quotes the values that would not read as one token.
+func debugValue(v any) string {
+	var s string
+	switch v := v.(type) {
+	case time.Duration:
+		return v.Round(time.Millisecond).String()
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// debugTransport logs the requests and how long they took. The query is left
+// out, some APIs take the key in it.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	if err != nil {
+		debugLog("http", "method", req.Method, "url", target, "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+	debugLog("http", "method", req.Method, "url", target, "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, nil
+}
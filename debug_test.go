@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	defer func(d bool) { debugMode = d }(debugMode)
+
+	debugLog("cache", "result", "hit")
+	if buf.Len() != 0 {
+		t.Fatalf("logged without -debug: %q", buf.String())
+	}
+
+	debugMode = true
+	debugLog("ai", "model", "gpt-4o-mini", "duration", 1234567*time.Microsecond, "error", errors.New("rate limit"), "track", "")
+	if got, want := buf.String(), `event=ai model=gpt-4o-mini duration=1.235s error="rate limit" track=""`; !strings.Contains(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: debugTransport{next: http.DefaultTransport}}
+	resp, err := client.Get(server.URL + "/ws/2/release?key=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	got := buf.String()
+	if !strings.Contains(got, "event=http method=GET url="+server.URL+"/ws/2/release status=418") || strings.Contains(got, "secret") {
+		t.Errorf("got %q", got)
+	}
+}
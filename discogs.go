@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var discogsURL = "https://api.discogs.com"
+
+// discogsToken is a Discogs personal access token, the section is only
+// fetched when there is one.
+var discogsToken = os.Getenv("DISCOGS_TOKEN")
+
+// discogsEditions is how many pressings of the album are listed.
+const discogsEditions = 10
+
+var errNoDiscogsRelease = errors.New("no Discogs release found")
+
+// DiscogsMaster is the Discogs master release of an album, which groups all
+// of its pressings.
+type DiscogsMaster struct {
+	ID       int              `json:"id"`
+	Title    string           `json:"title"`
+	Year     string           `json:"year"`
+	Genres   []string         `json:"genre"`
+	Styles   []string         `json:"style"`
+	URI      string           `json:"uri"`
+	Editions []DiscogsEdition `json:"-"`
+}
+
+type DiscogsEdition struct {
+	ID       int    `json:"id"`
+	Format   string `json:"format"`
+	Label    string `json:"label"`
+	CatNo    string `json:"catno"`
+	Country  string `json:"country"`
+	Released string `json:"released"`
+}
+
+// getDiscogsMaster is replaced in tests.
+var getDiscogsMaster = fetchDiscogsMaster
+
+func discogsGet(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discogsURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	req.Header.Set("Authorization", "Discogs token="+discogsToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "discogs: status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchDiscogsMaster searches the master release of the album and lists its
+// earliest pressings.
+func fetchDiscogsMaster(ctx context.Context, info MusicInfo) (*DiscogsMaster, error) {
+	q := url.Values{}
+	q.Set("type", "master")
+	q.Set("artist", info.artist)
+	q.Set("release_title", info.album)
+	q.Set("per_page", "1")
+
+	var search struct {
+		Results []DiscogsMaster `json:"results"`
+	}
+	if err := discogsGet(ctx, "/database/search?"+q.Encode(), &search); err != nil {
+		return nil, err
+	}
+	if len(search.Results) == 0 {
+		return nil, errNoDiscogsRelease
+	}
+	master := search.Results[0]
+
+	var versions struct {
+		Versions []DiscogsEdition `json:"versions"`
+	}
+	path := fmt.Sprintf("/masters/%d/versions?sort=released&sort_order=asc&per_page=%d", master.ID, discogsEditions)
+	if err := discogsGet(ctx, path, &versions); err != nil {
+		return nil, err
+	}
+	master.Editions = versions.Versions
+	return &master, nil
+}
+
+// discogsSection renders the Discogs master release of the album.
+func discogsSection(ctx context.Context, info MusicInfo) (string, error) {
+	master, err := getDiscogsMaster(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return master.markdown(), nil
+}
+
+// markdown renders the genres, the pressings and the marketplace links.
+func (d *DiscogsMaster) markdown() string {
+	var b strings.Builder
+
+	var about []string
+	if d.Year != "" && d.Year != "0" {
+		about = append(about, "**Year:** "+d.Year)
+	}
+	if len(d.Genres) > 0 {
+		about = append(about, "**Genres:** "+strings.Join(d.Genres, ", "))
+	}
+	if len(d.Styles) > 0 {
+		about = append(about, "**Styles:** "+strings.Join(d.Styles, ", "))
+	}
+	if len(about) > 0 {
+		b.WriteString(strings.Join(about, "\n\n") + "\n")
+	}
+
+	if len(d.Editions) > 0 {
+		b.WriteString("\n### Editions\n\n| Released | Country | Label | Cat# | Format |\n| --- | --- | --- | --- | --- |\n")
+		for _, e := range d.Editions {
+			label := "[" + tableCell(e.Label) + "](https://www.discogs.com/release/" + strconv.Itoa(e.ID) + ")"
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", e.Released, tableCell(e.Country), label, tableCell(e.CatNo), tableCell(e.Format))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n[Buy on the Discogs marketplace](https://www.discogs.com/sell/list?master_id=%d) • ", d.ID)
+	fmt.Fprintf(&b, "[All versions](https://www.discogs.com%s)", d.URI)
+	return b.String()
+}
+
+// tableCell escapes the column separator of a markdown table.
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
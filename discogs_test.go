@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchDiscogsMaster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Discogs token=secret" {
+			t.Errorf("got authorization %q", got)
+		}
+		switch r.URL.Path {
+		case "/database/search":
+			q := r.URL.Query()
+			if q.Get("artist") != "Radiohead" || q.Get("release_title") != "OK Computer" || q.Get("type") != "master" {
+				t.Errorf("got query %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"results":[{"id":21491,"title":"Radiohead - OK Computer","year":"1997",
+				"genre":["Electronic","Rock"],"style":["Alternative Rock"],"uri":"/master/21491-Radiohead-OK-Computer"}]}`))
+		case "/masters/21491/versions":
+			w.Write([]byte(`{"versions":[{"id":83182,"format":"LP, Album","label":"Parlophone","catno":"NODATA 02","country":"UK","released":"1997"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u, token string) { discogsURL, discogsToken = u, token }(discogsURL, discogsToken)
+	discogsURL, discogsToken = server.URL, "secret"
+
+	master, err := fetchDiscogsMaster(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := master.markdown()
+	for _, want := range []string{
+		"**Genres:** Electronic, Rock",
+		"**Styles:** Alternative Rock",
+		"| 1997 | UK | [Parlophone](https://www.discogs.com/release/83182) | NODATA 02 | LP, Album |",
+		"https://www.discogs.com/sell/list?master_id=21491",
+		"https://www.discogs.com/master/21491-Radiohead-OK-Computer",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown does not include %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDiscogsSectionNeedsToken(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	getDiscogsMaster = func(ctx context.Context, info MusicInfo) (*DiscogsMaster, error) {
+		return nil, errNoDiscogsRelease
+	}
+	defer func() { getDiscogsMaster = fetchDiscogsMaster }()
+
+	m.getInfo(context.Background())
+	for _, s := range m.sections {
+		if s.key == "discogs" {
+			t.Fatal("the section is fetched without a token")
+		}
+	}
+
+	discogsToken = "secret"
+	m.getInfo(context.Background())
+	last := m.sections[len(m.sections)-1]
+	if last.key != "discogs" || last.Content != "*Discogs does not know this album.*" {
+		t.Errorf("got %+v", last)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/internal/storage"
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// embeddingModel makes the vectors of the write-ups for the search by
+// meaning, it defaults to the one of the provider.
+var embeddingModel string
+
+const (
+	// embedBatch is how many write-ups are sent in one request.
+	embedBatch = 16
+	// embedIndexLimit is how many albums one search embeds at most, the
+	// others are embedded by the next searches.
+	embedIndexLimit = 200
+	// embedTextLimit is how many characters of a write-up are embedded,
+	// the models take a few thousand tokens.
+	embedTextLimit = 6000
+	// similarLimit is how many albums the search by meaning lists.
+	similarLimit = 20
+)
+
+// similarMsg is the result of a search by meaning of the history.
+type similarMsg struct {
+	query   string
+	similar []storage.Similar
+	err     error
+}
+
+// embed returns the vectors of texts, retrying transient errors.
+func (m *model) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e, ok := completer.(ai.EmbedProvider)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider has no embeddings, use openai, azure, gemini or ollama", provider)
+	}
+	if offline {
+		return nil, errOffline
+	}
+	if metadataOnly() != nil {
+		return nil, errMetadataOnly
+	}
+	ctx = m.countUsage(ctx, -1)
+
+	var vectors [][]float32
+	start := time.Now()
+	err := m.withRetry(ctx, func() (err error) {
+		if err := acquireAISlot(ctx); err != nil {
+			return err
+		}
+		defer releaseAISlot()
+		vectors, err = e.Embed(ctx, embeddingModel, texts)
+		return err
+	})
+	checkKey(err)
+	debugLog("embed", "model", embeddingModel, "texts", len(texts), "duration", time.Since(start), "error", err)
+	return vectors, err
+}
+
+// writeUp is the text of the stored sections of t that is embedded.
+func writeUp(t storage.Track, sections []storage.Section) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s\n", t.Artist, t.Album)
+	for _, s := range sections {
+		b.WriteString("\n" + s.Title + "\n" + s.Content + "\n")
+	}
+	text := []rune(b.String())
+	if len(text) > embedTextLimit {
+		text = text[:embedTextLimit]
+	}
+	return string(text)
+}
+
+// embedHistory embeds the write-ups of the albums of the history that have
+// no embedding of the model yet.
+func (m *model) embedHistory(ctx context.Context) error {
+	pending, err := history.PendingEmbeddings(ctx, embeddingModel, embedIndexLimit)
+	if err != nil {
+		return err
+	}
+	for len(pending) > 0 {
+		batch := pending
+		if len(batch) > embedBatch {
+			batch = batch[:embedBatch]
+		}
+		pending = pending[len(batch):]
+
+		texts := make([]string, len(batch))
+		for i, t := range batch {
+			sections, err := history.Sections(ctx, t.ID)
+			if err != nil {
+				return err
+			}
+			texts[i] = writeUp(t, sections)
+		}
+		vectors, err := m.embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, t := range batch {
+			if err := history.SaveEmbedding(ctx, t.Artist, t.Album, embeddingModel, vectors[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchSimilar lists the albums of the history whose write-ups are the
+// closest to query, embedding the new write-ups first. The result comes back
+// as a similarMsg.
+func (m *model) searchSimilar(query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := m.embedHistory(ctx); err != nil {
+			return similarMsg{query: query, err: err}
+		}
+		vectors, err := m.embed(ctx, []string{query})
+		if err != nil {
+			return similarMsg{query: query, err: err}
+		}
+		similar, err := history.SimilarAlbums(ctx, embeddingModel, vectors[0], similarLimit)
+		return similarMsg{query: query, similar: similar, err: err}
+	}
+}
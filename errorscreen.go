@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errorMsg is an error that left the content unusable, such as markdown that
+// failed to render. Update shows it on the error screen instead of panicking
+// with the terminal in raw mode.
+type errorMsg struct{ err error }
+
+// errorCmd sends err to Update, for the code running outside of it.
+func errorCmd(err error) tea.Cmd {
+	return func() tea.Msg { return errorMsg{err: err} }
+}
+
+// fail shows the error screen, the first error is kept when several follow.
+func (m *model) fail(err error) {
+	debugLog("error", "error", err)
+	if m.failure == nil {
+		m.failure = err
+	}
+}
+
+// updateFailure retries rendering the content, or goes back to the content
+// shown before the error.
+func (m *model) updateFailure(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC || key.Matches(msg, keys.Quit):
+		return m, tea.Quit
+	case msg.Type == tea.KeyEsc:
+		m.failure = nil
+	case key.Matches(msg, keys.RetrySection):
+		m.failure = nil
+		if m.hasContent() {
+			if err := m.renderViewport(); err != nil {
+				m.fail(err)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *model) failureView() string {
+	pad := strings.Repeat(" ", padding)
+	return styleTitle(pad+tr("Something went wrong")) + "\n\n" +
+		pad + styleWarning(m.failure.Error()) + "\n\n" +
+		pad + helpStyle(trf("%s: Retry • esc: Back • %s: Quit", keys.RetrySection.Help().Key, keys.Quit.Help().Key))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestErrorScreen(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(style string) { glamourStyle = style }(glamourStyle)
+	glamourStyle = filepath.Join(t.TempDir(), "missing.json")
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	if m.failure == nil {
+		t.Fatal("a failed render did not show the error screen")
+	}
+	if view := m.View(); !strings.Contains(view, "Something went wrong") || !strings.Contains(view, "missing.json") {
+		t.Errorf("got view:\n%s", view)
+	}
+
+	// The keys of the content do nothing while the error is shown.
+	if _, cmd := m.Update(keyRune('O')); cmd != nil || m.reader != nil {
+		t.Error("O opened the reading mode behind the error screen")
+	}
+	m.Update(keyRune('r'))
+	if m.failure == nil {
+		t.Error("the retry cleared the error while the style is still missing")
+	}
+
+	glamourStyle = "ascii"
+	m.Update(keyRune('r'))
+	if m.failure != nil || m.viewport.Width != m.viewportWidth() {
+		t.Errorf("the retry did not render the content: %v", m.failure)
+	}
+
+	// Code outside Update sends its errors as an errorMsg.
+	glamourStyle = filepath.Join(t.TempDir(), "missing.json")
+	m.renderedFor = renderKey{}
+	cmd := m.switchTab(1)
+	if cmd == nil {
+		t.Fatal("switchTab returned no error")
+	}
+	m.Update(cmd())
+	if m.failure == nil {
+		t.Error("the errorMsg did not show the error screen")
+	}
+	if m.Update(tea.KeyMsg{Type: tea.KeyEsc}); m.failure != nil {
+		t.Error("esc did not go back to the content")
+	}
+}
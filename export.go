@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// exportDir is where the e key saves the content, exportFormat is markdown or
+// html.
+var (
+	exportDir    = "~/stui"
+	exportFormat = "markdown"
+)
+
+var exportPage = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+{{.Body}}
+</body>
+</html>
+`))
+
+// exportFileName names the export after the track, without characters that
+// are not allowed in file names.
+func exportFileName(info MusicInfo, format string) string {
+	var parts []string
+	for _, s := range []string{info.artist, info.album, info.track} {
+		s = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+				return '_'
+			}
+			return r
+		}, strings.TrimSpace(s))
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+
+	ext := ".md"
+	if format == "html" {
+		ext = ".html"
+	}
+	return strings.Join(parts, " - ") + ext
+}
+
+// export saves the content to exportDir and returns the path of the file.
+func (m *model) export() (string, error) {
+	data := []byte(m.markdown())
+	if exportFormat == "html" {
+		var err error
+		data, err = markdownToHTML(fmt.Sprintf("%s - %s - %s", m.artistLine(), m.album, m.track), data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dir := expandHome(exportDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, exportFileName(m.MusicInfo, exportFormat))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func markdownToHTML(title string, markdown []byte) ([]byte, error) {
+	body, err := markdownBody(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	err = exportPage.Execute(&page, struct {
+		Title string
+		Body  template.HTML
+	}{title, template.HTML(body)})
+	return page.Bytes(), err
+}
+
+// markdownBody converts markdown to HTML, raw HTML in the answers is left
+// out.
+func markdownBody(markdown []byte) (string, error) {
+	var body bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.Linkify, extension.Table))
+	if err := md.Convert(markdown, &body); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
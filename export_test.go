@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+
+	dir, format := exportDir, exportFormat
+	defer func() { exportDir, exportFormat = dir, format }()
+	exportDir = filepath.Join(t.TempDir(), "exports")
+
+	for _, tt := range []struct {
+		format string
+		file   string
+		want   []string
+	}{
+		{"markdown", "Radiohead - OK Computer - Airbag.md", []string{"## Album review", "stub answer for:"}},
+		{"html", "Radiohead - OK Computer - Airbag.html", []string{"<title>Radiohead - OK Computer - Airbag</title>", "<h2>Album review</h2>", `<a href="https://www.youtube.com/results?search_query=Radiohead+Airbag">`}},
+	} {
+		exportFormat = tt.format
+		path, err := m.export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(path) != tt.file {
+			t.Errorf("exported to %s, want %s", path, tt.file)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s export does not include %q:\n%s", tt.format, want, data)
+			}
+		}
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	got := exportFileName(MusicInfo{artist: "AC/DC", album: "Who Made Who", track: "D.T."}, "markdown")
+	if want := "AC_DC - Who Made Who - D.T..md"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got = exportFileName(MusicInfo{artist: "Radiohead", album: "Kid A"}, "html")
+	if want := "Radiohead - Kid A.html"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var errNoSpotifyTrack = errors.New("the track is not on Spotify")
+
+// getAudioFeatures is replaced in tests.
+var getAudioFeatures = fetchAudioFeatures
+
+// AudioFeatures are the Spotify analysis of a track.
+type AudioFeatures struct {
+	Tempo         float64 `json:"tempo"`
+	Key           int     `json:"key"`
+	Mode          int     `json:"mode"`
+	TimeSignature int     `json:"time_signature"`
+	Energy        float64 `json:"energy"`
+	Danceability  float64 `json:"danceability"`
+	Valence       float64 `json:"valence"`
+	Loudness      float64 `json:"loudness"`
+}
+
+// audioFeaturesEnabled reports whether the Web API can be used, the
+// features need a login but not the spotify player.
+func audioFeaturesEnabled() bool {
+	return spotifyClientID != "" && spotifyLoggedIn()
+}
+
+// get decodes the answer of a Web API path.
+func (p *spotifyWebPlayer) get(ctx context.Context, path string, v any) error {
+	resp, err := p.doContext(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchAudioFeatures searches the track on Spotify and reads its features.
+func fetchAudioFeatures(ctx context.Context, info MusicInfo) (*AudioFeatures, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+
+	query := fmt.Sprintf("track:%s artist:%s", info.track, info.artist)
+	var search struct {
+		Tracks struct {
+			Items []struct {
+				ID string `json:"id"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	if err := api.get(ctx, "/search?type=track&limit=1&q="+url.QueryEscape(query), &search); err != nil {
+		return nil, err
+	}
+	if len(search.Tracks.Items) == 0 {
+		return nil, errNoSpotifyTrack
+	}
+
+	var features AudioFeatures
+	if err := api.get(ctx, "/audio-features/"+search.Tracks.Items[0].ID, &features); err != nil {
+		return nil, err
+	}
+	return &features, nil
+}
+
+var pitchClasses = []string{"C", "C♯/D♭", "D", "D♯/E♭", "E", "F", "F♯/G♭", "G", "G♯/A♭", "A", "A♯/B♭", "B"}
+
+// musicalKey names the key of the track, Spotify uses -1 when it found none.
+func (f *AudioFeatures) musicalKey() string {
+	if f.Key < 0 || f.Key >= len(pitchClasses) {
+		return "unknown"
+	}
+	if f.Mode == 1 {
+		return pitchClasses[f.Key] + " major"
+	}
+	return pitchClasses[f.Key] + " minor"
+}
+
+func (f *AudioFeatures) markdown() string {
+	var b strings.Builder
+	b.WriteString("| Feature | Value |\n| --- | --- |\n")
+	fmt.Fprintf(&b, "| Tempo | %.0f BPM |\n", f.Tempo)
+	fmt.Fprintf(&b, "| Key | %s |\n", f.musicalKey())
+	if f.TimeSignature > 0 {
+		fmt.Fprintf(&b, "| Time signature | %d/4 |\n", f.TimeSignature)
+	}
+	fmt.Fprintf(&b, "| Energy | %.0f%% |\n", f.Energy*100)
+	fmt.Fprintf(&b, "| Danceability | %.0f%% |\n", f.Danceability*100)
+	fmt.Fprintf(&b, "| Valence | %.0f%% |\n", f.Valence*100)
+	fmt.Fprintf(&b, "| Loudness | %.1f dB |\n", f.Loudness)
+	b.WriteString("\n*Source: Spotify*")
+	return b.String()
+}
+
+// audioFeaturesSection renders the features of the current track.
+func audioFeaturesSection(ctx context.Context, info MusicInfo) (string, error) {
+	features, err := getAudioFeatures(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return features.markdown(), nil
+}
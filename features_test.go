@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudioFeatures(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("got authorization %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/search":
+			if q := r.URL.Query().Get("q"); q != "track:Airbag artist:Radiohead" {
+				t.Errorf("got query %q", q)
+			}
+			fmt.Fprint(w, `{"tracks":{"items":[{"id":"6xP3A5"}]}}`)
+		case "/audio-features/6xP3A5":
+			fmt.Fprint(w, `{"tempo":84.9,"key":9,"mode":1,"time_signature":4,"energy":0.74,"danceability":0.32,"valence":0.4,"loudness":-8.5}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api, id string) { spotifyAPIURL, spotifyClientID = api, id }(spotifyAPIURL, spotifyClientID)
+	spotifyAPIURL, spotifyClientID = server.URL, "client"
+
+	if audioFeaturesEnabled() {
+		t.Error("the features are enabled without a login")
+	}
+	if err := (&spotifyToken{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+	if !audioFeaturesEnabled() {
+		t.Error("the features are not enabled after the login")
+	}
+
+	got, err := audioFeaturesSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| Tempo | 85 BPM |", "| Key | A major |", "| Energy | 74% |", "| Danceability | 32% |", "| Loudness | -8.5 dB |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("section does not include %q:\n%s", want, got)
+		}
+	}
+}
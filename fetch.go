@@ -0,0 +1,623 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/ernesto27/stui/pkg/stui"
+	"github.com/sashabaranov/go-openai"
+)
+
+// conciseSuffix is appended to a prompt when retrying a section that did not
+// fit in the model context.
+const conciseSuffix = " Limit the answer to 300 words."
+
+func isContextLengthError(err error) bool {
+	var anthropicErr *ai.AnthropicError
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.Type == "invalid_request_error" && strings.Contains(anthropicErr.Message, "prompt is too long")
+	}
+
+	var geminiErr *ai.GeminiError
+	if errors.As(err, &geminiErr) {
+		return geminiErr.Status == "INVALID_ARGUMENT" && strings.Contains(geminiErr.Message, "exceeds the maximum number of tokens")
+	}
+
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	code, _ := apiErr.Code.(string)
+	return code == "context_length_exceeded"
+}
+
+// completeSection sends the section prompt, streaming the answer into the
+// section when the provider supports it.
+func (m *model) completeSection(ctx context.Context, index int, model string, query string) (content string, err error) {
+	ctx, done := m.sectionRequest(m.countUsage(ctx, index), index)
+	defer func() { err = done(err) }()
+	sc, ok := completer.(ai.StreamProvider)
+	if !ok {
+		return m.complete(ctx, model, query)
+	}
+	if offline {
+		return "", errOffline
+	}
+	if metadataOnly() != nil {
+		return "", errMetadataOnly
+	}
+	if err := m.checkBudget(model, query); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	defer func() {
+		debugLog("ai", "model", model, "section", index, "stream", true, "duration", time.Since(start), "error", err)
+	}()
+	err = m.withRetry(ctx, func() (err error) {
+		if err := acquireAISlot(ctx); err != nil {
+			return err
+		}
+		defer releaseAISlot()
+		// A retry starts the answer over.
+		m.setSectionContent(ctx, index, "")
+		return withAITimeout(ctx, func(ctx context.Context) (err error) {
+			content, err = sc.Stream(ctx, model, query, func(token string) {
+				// The document is built once the section is done.
+				m.whileCurrent(ctx, func() {
+					m.sections[index].Content += token
+					m.sections[index].streaming = true
+					m.changed = true
+				})
+			})
+			return err
+		})
+	})
+	checkKey(err)
+	return content, err
+}
+
+// complete asks the provider, retrying transient errors.
+func (m *model) complete(ctx context.Context, model string, query string) (string, error) {
+	return m.completeWith(ctx, model, query, func(ctx context.Context) (string, error) {
+		return completer.Complete(ctx, model, query)
+	})
+}
+
+// completeWith is complete with call sending the request of query.
+func (m *model) completeWith(ctx context.Context, model string, query string, call func(ctx context.Context) (string, error)) (content string, err error) {
+	// The requests of a section are already counted.
+	if ctx.Value(usageKey{}) == nil {
+		ctx = m.countUsage(ctx, -1)
+	}
+	if offline {
+		return "", errOffline
+	}
+	if metadataOnly() != nil {
+		return "", errMetadataOnly
+	}
+	if err := m.checkBudget(model, query); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	err = m.withRetry(ctx, func() (err error) {
+		if err := acquireAISlot(ctx); err != nil {
+			return err
+		}
+		defer releaseAISlot()
+		return withAITimeout(ctx, func(ctx context.Context) (err error) {
+			content, err = call(ctx)
+			return err
+		})
+	})
+	checkKey(err)
+	debugLog("ai", "model", model, "duration", time.Since(start), "error", err)
+	return content, err
+}
+
+func (m *model) setSectionContent(ctx context.Context, index int, content string) {
+	m.whileCurrent(ctx, func() {
+		m.sections[index].Content = content
+		m.sections[index].streaming = false
+		m.buildContent()
+		m.changed = true
+	})
+}
+
+// whileCurrent runs fn holding m.mu, unless ctx was canceled because a newer
+// fetch replaced the one it belongs to.
+func (m *model) whileCurrent(ctx context.Context, fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ctx.Err() == nil {
+		fn()
+	}
+}
+
+// startFetch cancels the running fetch and starts getInfo for the current
+// track.
+func (m *model) startFetch() {
+	debugLog("fetch", "artist", m.artist, "album", m.album, "track", m.track)
+	ctx := m.newFetch()
+	m.goFetch(func() { m.getInfoWithHooks(ctx) })
+}
+
+// goFetch runs fn in the background as part of the fetch, fetches counts it
+// until it returns.
+func (m *model) goFetch(fn func()) {
+	m.fetches.Add(1)
+	goSafe(func() {
+		defer m.fetches.Done()
+		fn()
+	})
+}
+
+// newFetch cancels the running fetch and returns the context of the next
+// one.
+func (m *model) newFetch() context.Context {
+	m.stopFetch()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.fetchCtx = ctx
+	return ctx
+}
+
+// stopFetch cancels the requests in flight, their results are dropped.
+func (m *model) stopFetch() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// fetchSection fetches the section at index. Each request writes only its
+// own slot so the sections keep their declared order.
+func (m *model) fetchSection(ctx context.Context, info MusicInfo, index int, title string, query string, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer m.stepDone(ctx)
+
+	scope := albumScope
+	m.mu.Lock()
+	if index < len(m.sections) {
+		scope = sectionScope(m.sections[index].key)
+	}
+	m.mu.Unlock()
+
+	key := cacheKey(info, scope, chatModel, query)
+	if offline {
+		m.offlineSection(ctx, info, index, key)
+		return
+	}
+	if content, ok := cachedAnswer(ctx, key); ok {
+		m.whileCurrent(ctx, func() { m.cacheHits++ })
+		m.setSectionContent(ctx, index, content)
+		return
+	}
+	m.whileCurrent(ctx, func() { m.cacheMisses++ })
+
+	content, err := m.completeSection(ctx, index, chatModel, query)
+	if isContextLengthError(err) {
+		model := chatModel
+		if fallbackModel != "" {
+			model = fallbackModel
+		}
+
+		content, err = m.completeSection(ctx, index, model, query+conciseSuffix)
+		if isContextLengthError(err) {
+			err = fmt.Errorf("%s is too long for the model context, try a model with a larger context window (-fallback-model)", strings.ToLower(title))
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		m.whileCurrent(ctx, func() {
+			m.sections[index].Content = ""
+			m.sections[index].streaming = false
+			m.sections[index].Error = provider + " api: " + err.Error()
+			m.buildContent()
+			m.changed = true
+		})
+		return
+	}
+
+	// The key of the section does not change while the fetch is current.
+	var sectionKey string
+	m.whileCurrent(ctx, func() { sectionKey = m.sections[index].key })
+	content = trimAnswer(sectionKey, postProcessAnswer(content, 3))
+	m.setSectionContent(ctx, index, content)
+	writeCache(key, content)
+}
+
+// retrySection fetches the selected section again: a failed one as it was
+// asked, one with an answer without the cache, to get a new answer.
+func (m *model) retrySection() tea.Cmd {
+	if !m.hasContent() || m.fetchCtx == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	index := m.tab
+	if !m.sectionDone(index) {
+		return nil
+	}
+	s := m.sections[index]
+	return m.refetchSection(index, s.prompt, s.Error == "")
+}
+
+// sectionDone reports whether the section at index has its answer or its
+// error. The caller must hold m.mu.
+func (m *model) sectionDone(index int) bool {
+	if index >= len(m.sections) {
+		return false
+	}
+	s := m.sections[index]
+	return s.Error != "" || (s.Content != "" && !s.streaming)
+}
+
+// refetchSection fetches the section at index again with prompt, the cache
+// is skipped when uncached. The caller must hold m.mu.
+func (m *model) refetchSection(index int, prompt string, uncached bool) tea.Cmd {
+	s := &m.sections[index]
+	s.Error, s.Content, s.prompt = "", "", prompt
+	m.buildContent()
+	// Chosen, so the tab stays while the answer is empty.
+	m.tabChosen = true
+	m.steps++
+	m.changed = true
+	wasLoading := m.loading
+	m.loading = true
+
+	ctx, info, title := m.fetchCtx, m.MusicInfo, s.Title
+	if uncached {
+		ctx = withoutCache(ctx)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if source, ok := apiSources[s.key]; ok {
+		m.goFetch(func() { m.fetchAPISection(ctx, info, index, source, &wg) })
+	} else {
+		m.goFetch(func() { m.fetchSection(ctx, info, index, title, prompt, &wg) })
+	}
+
+	// The running tick loop ends the loading already.
+	if wasLoading {
+		return nil
+	}
+	return tea.Batch(tickCmd(), m.spinner.Tick)
+}
+
+// getSummary asks for the album rating, genre and mood. The summary is
+// optional, so any error or unexpected answer just leaves it empty.
+func (m *model) getSummary(ctx context.Context, info MusicInfo) {
+	prompt := fmt.Sprintf("Rate the album %s by %s from 1 to 10 and give its primary genre and a one-word mood. "+
+		"Answer only with the format rating|genre|mood, for example: 8|Alternative rock|Melancholic", info.album, info.artist)
+
+	key := cacheKey(info, albumScope, chatModel, prompt)
+	content, cached := cachedAnswer(ctx, key)
+	if !cached {
+		var err error
+		content, err = m.complete(ctx, chatModel, prompt)
+		if err != nil {
+			return
+		}
+	}
+
+	summary, ok := parseSummary(content)
+	if !ok {
+		return
+	}
+	if !cached {
+		writeCache(key, content)
+	}
+
+	m.whileCurrent(ctx, func() { m.summary = summary })
+}
+
+func parseSummary(s string) (*Summary, bool) {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+	parts := strings.Split(line, "|")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	rating, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(parts[0]), "/10"))
+	if err != nil || rating < 1 || rating > 10 {
+		return nil, false
+	}
+
+	genre := strings.TrimSpace(parts[1])
+	mood := strings.TrimSpace(parts[2])
+	if genre == "" || mood == "" {
+		return nil, false
+	}
+
+	return &Summary{Rating: rating, Genre: genre, Mood: mood}, true
+}
+
+func (m *model) getInfo(ctx context.Context) {
+	// The track is read once, a refresh changes it while the requests of
+	// the previous one return.
+	m.mu.Lock()
+	info, skipCache, deepDive, compare := m.MusicInfo, m.skipCache, m.deepDive, m.compare
+	m.mu.Unlock()
+	if skipCache {
+		ctx = withoutCache(ctx)
+	}
+	if deepDive {
+		m.getArtistInfo(ctx, info)
+		return
+	}
+	if info.isPodcast() {
+		m.getPodcastInfo(ctx, info)
+		return
+	}
+
+	type search struct {
+		key    string
+		prompt string
+		title  string
+	}
+
+	var all []search
+	for _, s := range stui.Prompts(info.stuiTrack(), compare.stuiTrack()) {
+		all = append(all, search{key: s.Key, prompt: s.Prompt, title: s.Title})
+	}
+
+	for _, p := range promptTemplates {
+		prompt, err := p.render(info)
+		if err != nil {
+			m.whileCurrent(ctx, func() { m.errMsg = "  prompt " + p.key + ": " + err.Error() })
+			continue
+		}
+
+		replaced := false
+		for i := range all {
+			if all[i].key == p.key {
+				all[i].prompt = prompt
+				if p.title != "" {
+					all[i].title = p.title
+				}
+				replaced = true
+			}
+		}
+		// Built-in keys missing from all do not apply, e.g. song info without a
+		// track.
+		if !replaced && !containsString(sectionKeys, p.key) {
+			all = append(all, search{key: p.key, prompt: prompt, title: p.title})
+		}
+	}
+
+	for i := range all {
+		all[i].prompt = inLanguage(withLength(all[i].key, all[i].prompt))
+	}
+
+	// The tracklist and credits come from MusicBrainz instead of the AI
+	// provider, which makes them up. Like the lyrics, they and the other
+	// music databases follow the AI sections.
+	if info.album != "" {
+		all = append(all, search{key: "tracklist", title: "Tracklist and credits"})
+	}
+	if info.track != "" && audioFeaturesEnabled() {
+		all = append(all, search{key: "features", title: "Audio features"})
+	}
+	if info.track != "" && geniusToken != "" {
+		all = append(all, search{key: "stories", title: "Song stories"})
+	}
+	all = append(all, search{key: "wikipedia", title: "Wikipedia"})
+	if info.album != "" {
+		all = append(all, search{key: "reception", title: "Critic and user scores"})
+		if discogsToken != "" {
+			all = append(all, search{key: "discogs", title: "Discogs editions"})
+		}
+	}
+	if lastfmAPIKey != "" {
+		all = append(all, search{key: "lastfm", title: "Last.fm stats"})
+		all = append(all, search{key: "similar", title: "Similar artists"})
+	}
+	if listenBrainzToken != "" {
+		all = append(all, search{key: "listenbrainz", title: "ListenBrainz stats"})
+	}
+	if bandsintownAppID != "" {
+		all = append(all, search{key: "concerts", title: "Upcoming concerts"})
+	}
+	if redditEnabled {
+		all = append(all, search{key: "reddit", title: "Reddit discussions"})
+	}
+	if newsEnabled {
+		all = append(all, search{key: "news", title: "Artist news"})
+	}
+	if sceneEnabled && info.album != "" {
+		all = append(all, search{key: "scene", title: "Genre and era"})
+	}
+	for _, p := range plugins {
+		all = append(all, search{key: p.Key, title: p.Title})
+	}
+
+	// Metadata only keeps the sections of the online sources and plugins.
+	aiOn := metadataOnly() == nil
+	var searches []search
+	for _, s := range all {
+		if _, ok := apiSources[s.key]; sectionEnabled(s.key) && (ok || aiOn) {
+			searches = append(searches, s)
+		}
+	}
+	sort.SliceStable(searches, func(i, j int) bool {
+		return sectionRank(searches[i].key) < sectionRank(searches[j].key)
+	})
+
+	// The artwork is not cached, prefetching it or fetching it offline is no
+	// use. The lyrics are cached, a prefetch fetches them for the cache.
+	fetchArtwork := (artworkMode != "off" || artworkTheme) && info.album != "" && !m.prefetch && !offline
+	fetchLyrics := info.track != "" && sectionEnabled("lyrics") && !(m.prefetch && offline)
+
+	// Every request is a step of the progress, the links and the history
+	// are the last one.
+	steps := len(searches) + 1
+	// The claims are checked with the provider.
+	verify := verifyClaims && !offline && aiOn
+	fetchSummary := sectionEnabled("summary") && aiOn
+	for _, extra := range []bool{verify, fetchArtwork, fetchLyrics, fetchSummary} {
+		if extra {
+			steps++
+		}
+	}
+
+	sections := make([]Section, len(searches))
+	for i, search := range searches {
+		sections[i].Title = search.title
+		sections[i].key = search.key
+		sections[i].prompt = search.prompt
+	}
+
+	m.whileCurrent(ctx, func() {
+		m.sections = sections
+		m.steps = steps
+		m.stepsDone = 0
+	})
+
+	// The requests to the AI provider wait for one of its slots, see
+	// acquireAISlot.
+	var wg sync.WaitGroup
+	for i, search := range searches {
+		wg.Add(1)
+		index, title, prompt := i, search.title, search.prompt
+		if source, ok := apiSources[search.key]; ok {
+			goSafe(func() { m.fetchAPISection(ctx, info, index, source, &wg) })
+			continue
+		}
+		goSafe(func() { m.fetchSection(ctx, info, index, title, prompt, &wg) })
+	}
+
+	if fetchArtwork {
+		wg.Add(1)
+		goSafe(func() {
+			defer wg.Done()
+			defer m.stepDone(ctx)
+			m.fetchTrackArtwork(ctx, info)
+		})
+	}
+
+	if fetchLyrics {
+		wg.Add(1)
+		goSafe(func() {
+			defer wg.Done()
+			defer m.stepDone(ctx)
+			m.fetchTrackLyrics(ctx, info)
+		})
+	}
+
+	if fetchSummary {
+		wg.Add(1)
+		goSafe(func() {
+			defer wg.Done()
+			defer m.stepDone(ctx)
+			m.getSummary(ctx, info)
+		})
+	}
+	wg.Wait()
+
+	if verify {
+		m.verifySections(ctx, info)
+		m.stepDone(ctx)
+	}
+
+	m.whileCurrent(ctx, func() {
+		m.links = buildLinks(info.withArtist(m.pickedArtist))
+		m.buildContent()
+	})
+
+	// Offline nothing new is worth saving.
+	if ctx.Err() == nil && !m.prefetch && !m.query && !offline {
+		m.saveHistory(info)
+		// The summary of the entry is not worth keeping the loading going.
+		m.goFetch(func() { m.writeJournal(ctx, info) })
+		if vaultAuto && vaultDir != "" && m.watch && info.album != "" && info.track != "" {
+			if _, err := m.writeVault(); err != nil {
+				m.mu.Lock()
+				m.errMsg = "  vault: " + err.Error()
+				m.mu.Unlock()
+			}
+		}
+	}
+	m.stepDone(ctx)
+}
+
+const lowConfidenceMarker = "⚠ "
+
+// verifySections sends the fetched sections back to the model and marks the
+// lines it is not confident about.
+func (m *model) verifySections(ctx context.Context, info MusicInfo) {
+	m.mu.Lock()
+	sections := append([]Section{}, m.sections...)
+	m.mu.Unlock()
+
+	prompt := "Below are numbered sections about the music of " + info.artist + ". " +
+		"List the lines that contain claims you are not confident are accurate (names, dates, credits, track titles). " +
+		"Answer only with the exact lines copied verbatim, one per line, prefixed by the section number like \"2: <line>\". " +
+		"If every claim is reliable answer NONE.\n\n"
+	for i, s := range sections {
+		if s.Content == "" {
+			continue
+		}
+		prompt += fmt.Sprintf("Section %d: %s\n%s\n\n", i+1, s.Title, s.Content)
+	}
+
+	answer, err := m.complete(ctx, chatModel, prompt)
+	m.whileCurrent(ctx, func() {
+		if err != nil {
+			m.errMsg = "  " + provider + " api: verify: " + err.Error()
+			return
+		}
+		m.sections = annotateSections(m.sections, answer)
+	})
+}
+
+var flaggedLineRe = regexp.MustCompile(`^\s*(?:section\s*)?(\d+)\s*[:.)-]\s*(.+)$`)
+var listPrefixRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+\.)?\s*`)
+
+// annotateSections prefixes with lowConfidenceMarker the section lines listed
+// in answer, which uses the "<section number>: <line>" format.
+func annotateSections(sections []Section, answer string) []Section {
+	for _, flagged := range strings.Split(answer, "\n") {
+		match := flaggedLineRe.FindStringSubmatch(strings.TrimSpace(flagged))
+		if match == nil {
+			continue
+		}
+
+		index, err := strconv.Atoi(match[1])
+		if err != nil || index < 1 || index > len(sections) {
+			continue
+		}
+
+		claim := strings.TrimSpace(listPrefixRe.ReplaceAllString(match[2], ""))
+		if claim == "" {
+			continue
+		}
+
+		lines := strings.Split(sections[index-1].Content, "\n")
+		for i, line := range lines {
+			if strings.Contains(line, lowConfidenceMarker) || !strings.Contains(line, claim) {
+				continue
+			}
+
+			prefix := listPrefixRe.FindString(line)
+			lines[i] = prefix + lowConfidenceMarker + line[len(prefix):]
+			break
+		}
+		sections[index-1].Content = strings.Join(lines, "\n")
+	}
+
+	return sections
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *Summary
+	}{
+		{"8|Alternative rock|Melancholic", &Summary{8, "Alternative rock", "Melancholic"}},
+		{" 9/10 | Jazz | Smoky \nextra text", &Summary{9, "Jazz", "Smoky"}},
+		{"11|Pop|Happy", nil},
+		{"8|Pop", nil},
+		{"I'm not sure about this album", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseSummary(tt.in)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("parseSummary(%q) = %+v, want failure", tt.in, got)
+			}
+			continue
+		}
+		if !ok || *got != *tt.want {
+			t.Errorf("parseSummary(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAnnotateSections(t *testing.T) {
+	sections := []Section{
+		{Title: "Album info", Content: "Released in 1997\n- Producer: Nigel Godrich\n- Engineer: Someone Else"},
+		{Title: "Album review", Content: "A landmark album."},
+	}
+
+	got := annotateSections(sections, "1: - Engineer: Someone Else\n3: out of range\nNONE")
+
+	want := "Released in 1997\n- Producer: Nigel Godrich\n- ⚠ Engineer: Someone Else"
+	if got[0].Content != want {
+		t.Errorf("got %q, want %q", got[0].Content, want)
+	}
+	if got[1].Content != "A landmark album." {
+		t.Errorf("unflagged section changed: %q", got[1].Content)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// geminiAPIKey is the key of the Gemini API.
+var geminiAPIKey = os.Getenv("GOOGLE_API_KEY")
+
+func init() {
+	registerProvider("gemini", providerFactory{
+		defaultModel:   ai.GeminiDefaultModel,
+		embeddingModel: ai.GeminiDefaultEmbeddingModel,
+		keyEnv:         "GOOGLE_API_KEY",
+		hasKey:         func() bool { return geminiAPIKey != "" },
+		new: func() (ai.Provider, error) {
+			return ai.NewGemini(geminiAPIKey), nil
+		},
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+var geniusURL = "https://api.genius.com"
+
+// geniusToken is a Genius API client access token, the song stories are only
+// fetched when there is one.
+var geniusToken = os.Getenv("GENIUS_ACCESS_TOKEN")
+
+// geniusAnnotations is how many annotated lines of the song are listed.
+const geniusAnnotations = 5
+
+var errNoGenius = errors.New("no Genius song found")
+
+// getGeniusSong is replaced in tests.
+var getGeniusSong = fetchGeniusSong
+
+// GeniusSong is the background of a song written by the Genius community.
+type GeniusSong struct {
+	ID          int
+	Title       string
+	URL         string
+	Description string
+	Annotations []GeniusAnnotation
+}
+
+// GeniusAnnotation explains a fragment of the lyrics.
+type GeniusAnnotation struct {
+	Fragment string
+	Body     string
+	Votes    int
+}
+
+func geniusGet(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, geniusURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+geniusToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "genius: status code %d", resp.StatusCode)
+	}
+	// The answers are wrapped in a meta and a response object.
+	var r struct {
+		Response json.RawMessage `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Response, v)
+}
+
+// fetchGeniusSong searches the song and reads its description and the most
+// voted annotations of its lyrics.
+func fetchGeniusSong(ctx context.Context, info MusicInfo) (*GeniusSong, error) {
+	var search struct {
+		Hits []struct {
+			Type   string `json:"type"`
+			Result struct {
+				ID            int    `json:"id"`
+				Title         string `json:"title"`
+				URL           string `json:"url"`
+				PrimaryArtist struct {
+					Name string `json:"name"`
+				} `json:"primary_artist"`
+			} `json:"result"`
+		} `json:"hits"`
+	}
+	if err := geniusGet(ctx, "/search?q="+url.QueryEscape(info.artist+" "+info.track), &search); err != nil {
+		return nil, err
+	}
+
+	// The search matches the lyrics too, keep only the songs of the artist.
+	var song *GeniusSong
+	for _, h := range search.Hits {
+		if h.Type == "song" && strings.EqualFold(h.Result.PrimaryArtist.Name, info.artist) {
+			song = &GeniusSong{ID: h.Result.ID, Title: h.Result.Title, URL: h.Result.URL}
+			break
+		}
+	}
+	if song == nil {
+		return nil, errNoGenius
+	}
+
+	var details struct {
+		Song struct {
+			Description struct {
+				Plain string `json:"plain"`
+			} `json:"description"`
+		} `json:"song"`
+	}
+	if err := geniusGet(ctx, fmt.Sprintf("/songs/%d?text_format=plain", song.ID), &details); err != nil {
+		return nil, err
+	}
+	// Songs without a description have a question mark.
+	if d := strings.TrimSpace(details.Song.Description.Plain); d != "?" {
+		song.Description = d
+	}
+
+	var referents struct {
+		Referents []struct {
+			Fragment    string `json:"fragment"`
+			Annotations []struct {
+				Body struct {
+					Plain string `json:"plain"`
+				} `json:"body"`
+				Votes int `json:"votes_total"`
+			} `json:"annotations"`
+		} `json:"referents"`
+	}
+	if err := geniusGet(ctx, fmt.Sprintf("/referents?song_id=%d&text_format=plain&per_page=50", song.ID), &referents); err != nil {
+		return nil, err
+	}
+	for _, r := range referents.Referents {
+		// A fragment can have several annotations, the first is the verified
+		// or the most voted one.
+		if len(r.Annotations) == 0 || strings.TrimSpace(r.Annotations[0].Body.Plain) == "" {
+			continue
+		}
+		a := r.Annotations[0]
+		song.Annotations = append(song.Annotations, GeniusAnnotation{Fragment: strings.TrimSpace(r.Fragment), Body: strings.TrimSpace(a.Body.Plain), Votes: a.Votes})
+	}
+	sort.SliceStable(song.Annotations, func(i, j int) bool {
+		return song.Annotations[i].Votes > song.Annotations[j].Votes
+	})
+	if len(song.Annotations) > geniusAnnotations {
+		song.Annotations = song.Annotations[:geniusAnnotations]
+	}
+
+	if song.Description == "" && len(song.Annotations) == 0 {
+		return nil, errNoGenius
+	}
+	return song, nil
+}
+
+// storiesSection renders the description of the song and its annotations.
+func storiesSection(ctx context.Context, info MusicInfo) (string, error) {
+	song, err := getGeniusSong(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return song.markdown(), nil
+}
+
+func (s *GeniusSong) markdown() string {
+	var b strings.Builder
+	if s.Description != "" {
+		b.WriteString(s.Description + "\n\n")
+	}
+	if len(s.Annotations) > 0 {
+		b.WriteString("### Annotations\n\n")
+	}
+	for _, a := range s.Annotations {
+		// The fragments span several lines of the lyrics.
+		fmt.Fprintf(&b, "> %s\n\n%s\n\n", strings.ReplaceAll(a.Fragment, "\n", "\n> "), a.Body)
+	}
+	fmt.Fprintf(&b, "*Source: [Genius](%s)*", s.URL)
+	return b.String()
+}
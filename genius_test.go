@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchGeniusSong(t *testing.T) {
+	description := `"?"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("got authorization %q", got)
+		}
+		switch r.URL.Path {
+		case "/search":
+			if q := r.URL.Query().Get("q"); !strings.HasSuffix(q, " Airbag") {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`{"meta":{"status":200},"response":{"hits":[
+				{"type":"song","result":{"id":1,"title":"Airbag (Cover)","url":"https://genius.com/cover","primary_artist":{"name":"Someone Else"}}},
+				{"type":"song","result":{"id":2,"title":"Airbag","url":"https://genius.com/Radiohead-airbag-lyrics","primary_artist":{"name":"Radiohead"}}}]}}`))
+		case "/songs/2":
+			w.Write([]byte(`{"response":{"song":{"description":{"plain":` + description + `}}}}`))
+		case "/referents":
+			if r.URL.Query().Get("song_id") != "2" {
+				t.Errorf("got song_id %q", r.URL.Query().Get("song_id"))
+			}
+			w.Write([]byte(`{"response":{"referents":[
+				{"fragment":"In the next world war","annotations":[{"body":{"plain":"A nod to the Cold War."},"votes_total":3}]},
+				{"fragment":"In an interstellar burst\nI am back to save the universe","annotations":[{"body":{"plain":"Yorke survived a car crash."},"votes_total":12}]},
+				{"fragment":"No annotation","annotations":[]}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u, token string) { geniusURL, geniusToken = u, token }(geniusURL, geniusToken)
+	geniusURL, geniusToken = server.URL, "secret"
+
+	song, err := fetchGeniusSong(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Description != "" {
+		t.Errorf("got description %q for a song without one", song.Description)
+	}
+	if len(song.Annotations) != 2 || song.Annotations[0].Votes != 12 {
+		t.Fatalf("got annotations %+v, want the most voted first", song.Annotations)
+	}
+
+	got := song.markdown()
+	for _, want := range []string{
+		"> In an interstellar burst\n> I am back to save the universe\n\nYorke survived a car crash.",
+		"*Source: [Genius](https://genius.com/Radiohead-airbag-lyrics)*",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("section does not include %q:\n%s", want, got)
+		}
+	}
+
+	description = `"Airbag opens OK Computer."`
+	if song, err = fetchGeniusSong(context.Background(), testTrack); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(song.markdown(), "Airbag opens OK Computer.\n\n### Annotations") {
+		t.Errorf("got %q", song.markdown())
+	}
+
+	_, err = fetchGeniusSong(context.Background(), MusicInfo{artist: "Nobody", track: "Airbag"})
+	if !errors.Is(err, errNoGenius) {
+		t.Errorf("got %v for an unknown artist, want errNoGenius", err)
+	}
+}
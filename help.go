@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpGroupView lists the keys and descriptions of a category, the keys
+// padded to the widest.
+func helpGroupView(g keyGroup) string {
+	width := 0
+	for _, b := range g.bindings {
+		if w := lipgloss.Width(b.Help().Key); w > width {
+			width = w
+		}
+	}
+
+	lines := []string{styleBadge(tr(g.title))}
+	for _, b := range g.bindings {
+		if len(b.Keys()) == 0 {
+			continue
+		}
+		k := b.Help().Key
+		lines = append(lines, k+strings.Repeat(" ", width-lipgloss.Width(k))+"  "+helpStyle(tr(b.Help().Desc)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// helpOverlayView shows every key binding by category, the categories are
+// laid out in as many columns as fit the terminal.
+func (m *model) helpOverlayView() string {
+	pad := strings.Repeat(" ", padding)
+	width := m.width
+	if width == 0 {
+		width = defaultWidth
+	}
+	width -= padding * 2
+
+	var rows []string
+	var row []string
+	rowWidth := 0
+	for _, g := range keys.groups() {
+		block := helpGroupView(g)
+		w := lipgloss.Width(block)
+		if len(row) > 0 && rowWidth+4+w > width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		if len(row) > 0 {
+			block = lipgloss.NewStyle().PaddingLeft(4).Render(block)
+			w += 4
+		}
+		row = append(row, block)
+		rowWidth += w
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+
+	body := lipgloss.NewStyle().PaddingLeft(padding).Render(strings.Join(rows, "\n\n"))
+	return styleTitle(pad+tr("Keys")) + "\n\n" + body + "\n\n" +
+		pad + helpStyle(fmt.Sprintf("%s/esc: %s", keys.Help.Help().Key, tr("Back")))
+}
+
+func (m *model) updateHelpOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case msg.Type == tea.KeyEsc, msg.String() == "q", key.Matches(msg, keys.Help):
+		m.showHelp = false
+	}
+	return m, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+// history is the listening history database, nil when it is disabled.
+var history *storage.DB
+
+// historyEnabled and historyPath are the history settings, an empty path
+// uses storage.DefaultPath.
+var (
+	historyEnabled = true
+	historyPath    string
+)
+
+func openHistory() (*storage.DB, error) {
+	path := historyPath
+	if path == "" {
+		var err error
+		if path, err = storage.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return storage.Open(expandHome(path))
+}
+
+// saveHistory records the play of the current track along with the sections
+// that were generated for it.
+func (m *model) saveHistory(info MusicInfo) {
+	if history == nil {
+		return
+	}
+
+	m.mu.Lock()
+	var sections []storage.Section
+	for _, s := range m.sections {
+		if s.Content != "" {
+			sections = append(sections, storage.Section{Title: s.Title, Content: s.Content})
+		}
+	}
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id, err := history.RecordPlay(ctx, info.artist, info.album, info.track, time.Now())
+	if err == nil && len(sections) > 0 {
+		err = history.SaveSections(ctx, id, chatModel, sections)
+	}
+	if err != nil {
+		m.mu.Lock()
+		m.errMsg = "  history: " + err.Error()
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestSaveHistory(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+
+	m.getInfo(context.Background())
+
+	recent, err := db.Recent(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].Artist != "Radiohead" || recent[0].Track != "Airbag" {
+		t.Fatalf("got %+v", recent)
+	}
+
+	sections, err := db.Sections(context.Background(), recent[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 7 || sections[0].Title != "Album info" {
+		t.Errorf("got sections %+v", sections)
+	}
+}
+
+func TestHistoryScreen(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.width, m.height = 100, 40
+	m.loading = false
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+
+	ctx := context.Background()
+	now := time.Now()
+	id, err := db.RecordPlay(ctx, "Radiohead", "Kid A", "Idioteque", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveSections(ctx, id, "test", []storage.Section{{Title: "Album review", Content: "A cold record."}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RecordPlay(ctx, "Portishead", "Dummy", "Roads", now); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(keyRune('h'))
+	if m.historyScreen == nil {
+		t.Fatal("history screen is not open")
+	}
+	for _, r := range "kid" {
+		m.Update(keyRune(r))
+	}
+	if view := m.View(); !strings.Contains(view, "Idioteque") || strings.Contains(view, "Roads") {
+		t.Fatalf("filtered view:\n%s", view)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.historyScreen != nil {
+		t.Fatal("history screen is still open")
+	}
+	if m.track != "Idioteque" || len(m.sections) != 1 || !strings.Contains(m.content, "A cold record.") {
+		t.Errorf("got track %q, sections %+v", m.track, m.sections)
+	}
+	if cmd == nil {
+		t.Fatal("lyrics are not fetched")
+	}
+	cmd()
+	if m.lyrics == nil {
+		t.Error("lyrics are not loaded")
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"", "Radiohead", true},
+		{"rdhd", "Radiohead", true},
+		{"kid a", "Radiohead Kid A Idioteque", true},
+		{"KIDA", "Radiohead Kid A Idioteque", true},
+		{"dahr", "Radiohead", false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
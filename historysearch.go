@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+// historySearchLimit is how many sections the search of the history lists.
+const historySearchLimit = 50
+
+// historySearchView is the search of the sections stored in the history, by
+// their words or, with meaning set, by the embeddings of the albums.
+type historySearchView struct {
+	query   string
+	matches []storage.Match
+	err     string
+	cursor  int
+
+	meaning bool
+	// searching is set while the albums are embedded and compared, similar
+	// are the albums found for searched.
+	searching bool
+	searched  string
+	similar   []storage.Similar
+}
+
+// results is how many matches or albums are listed.
+func (v *historySearchView) results() int {
+	if v.meaning {
+		return len(v.similar)
+	}
+	return len(v.matches)
+}
+
+func (m *model) openHistorySearch() {
+	m.historySearch = &historySearchView{}
+}
+
+// searchHistory lists the sections matching the query as it is typed, the
+// index makes it fast enough for every key.
+func (m *model) searchHistory() {
+	v := m.historySearch
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v.cursor, v.err = 0, ""
+	matches, err := history.Search(ctx, v.query, historySearchLimit)
+	if err != nil {
+		v.matches, v.err = nil, err.Error()
+		return
+	}
+	v.matches = matches
+}
+
+func (m *model) updateHistorySearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.historySearch
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.historySearch = nil
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case tea.KeyDown:
+		if v.cursor < v.results()-1 {
+			v.cursor++
+		}
+	case tea.KeyTab:
+		v.meaning = !v.meaning
+		v.cursor, v.err = 0, ""
+		if !v.meaning {
+			m.searchHistory()
+		}
+	case tea.KeyBackspace:
+		if r := []rune(v.query); len(r) > 0 {
+			v.query = string(r[:len(r)-1])
+			if !v.meaning {
+				m.searchHistory()
+			}
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		v.query += string(msg.Runes)
+		if !v.meaning {
+			m.searchHistory()
+		}
+	case tea.KeyEnter:
+		if v.meaning {
+			return m, m.enterSimilar()
+		}
+		if v.cursor < len(v.matches) {
+			m.historySearch = nil
+			cmd, err := m.showHistoryMatch(v.matches[v.cursor], v.query)
+			if err != nil {
+				m.errMsg = "  history: " + err.Error()
+			}
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// enterSimilar searches the albums by the meaning of a new query, or opens
+// the album under the cursor.
+func (m *model) enterSimilar() tea.Cmd {
+	v := m.historySearch
+	query := strings.TrimSpace(v.query)
+	switch {
+	case v.searching || query == "":
+		return nil
+	case query != v.searched:
+		v.searching, v.err = true, ""
+		return m.searchSimilar(query)
+	case v.cursor < len(v.similar):
+		m.historySearch = nil
+		cmd, err := m.showHistoryTrack(v.similar[v.cursor].Track)
+		if err != nil {
+			m.errMsg = "  history: " + err.Error()
+		}
+		return cmd
+	}
+	return nil
+}
+
+func (m *model) similarLoaded(msg similarMsg) {
+	v := m.historySearch
+	if v == nil || !v.searching {
+		return
+	}
+	v.searching, v.searched, v.cursor = false, msg.query, 0
+	v.similar, v.err = msg.similar, ""
+	if msg.err != nil {
+		v.searched, v.err = "", msg.err.Error()
+	}
+}
+
+// showHistoryMatch shows the stored sections of the track of match on the
+// tab of the section, scrolled to the first word of query.
+func (m *model) showHistoryMatch(match storage.Match, query string) (tea.Cmd, error) {
+	cmd, err := m.showHistoryTrack(match.Track)
+	if err != nil {
+		return cmd, err
+	}
+	m.mu.Lock()
+	for i, s := range m.sections {
+		if s.Title == match.Section {
+			m.tab, m.tabChosen = i, true
+			break
+		}
+	}
+	m.mu.Unlock()
+	if err := m.renderViewport(); err != nil {
+		return cmd, err
+	}
+	if words := strings.Fields(query); len(words) > 0 {
+		m.search = searchState{query: words[0], line: -1}
+		m.nextMatch(1)
+	}
+	return cmd, nil
+}
+
+// historySnippet is the snippet of a match in one line, with the words found
+// highlighted.
+func historySnippet(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	var b strings.Builder
+	for {
+		before, rest, ok := strings.Cut(snippet, storage.MatchStart)
+		b.WriteString(helpStyle(before))
+		if !ok {
+			return b.String()
+		}
+		word, after, _ := strings.Cut(rest, storage.MatchEnd)
+		b.WriteString(styleMatch(word))
+		snippet = after
+	}
+}
+
+func (m *model) historySearchScreenView() string {
+	v := m.historySearch
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	modes := []string{helpStyle(tr("Words")), helpStyle(tr("Meaning"))}
+	if v.meaning {
+		modes[1] = styleActiveTab(tr("Meaning"))
+	} else {
+		modes[0] = styleActiveTab(tr("Words"))
+	}
+	b.WriteString(styleTitle(pad+tr("Search the history")) + "  " + strings.Join(modes, "  ") + "\n\n")
+	b.WriteString(pad + "> " + v.query + "█\n\n")
+	if v.meaning {
+		b.WriteString(m.similarView(pad))
+		b.WriteString("\n" + pad + helpStyle(tr("type a mood or a style • enter: Search, Open • ↑/↓: Select • tab: Words • esc: Back")))
+		return b.String()
+	}
+
+	// Every match takes two lines, the track and the snippet.
+	rows := (m.height - 8) / 2
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+
+	switch {
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	case strings.TrimSpace(v.query) != "" && len(v.matches) == 0:
+		b.WriteString(pad + helpStyle(tr("Nothing found")) + "\n")
+	}
+	for i := start; i < len(v.matches) && i < start+rows; i++ {
+		match := v.matches[i]
+		t := match.Track
+		line := fmt.Sprintf("%s - %s - %s", t.Artist, t.Album, t.Track)
+		section := helpStyle(" · " + match.Section)
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + section + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + section + "\n")
+		}
+		snippet := pad + "    " + historySnippet(match.Snippet)
+		if m.width > 0 {
+			snippet = lipgloss.NewStyle().MaxWidth(m.width).Render(snippet)
+		}
+		b.WriteString(snippet + "\n")
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("type to search • ↑/↓: Select • enter: Open • tab: Meaning • esc: Back")))
+	return b.String()
+}
+
+// similarView lists the albums found by meaning, the closest first.
+func (m *model) similarView(pad string) string {
+	v := m.historySearch
+	var b strings.Builder
+	switch {
+	case v.searching:
+		b.WriteString(pad + helpStyle(tr("Loading...")) + "\n")
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	case v.searched != "" && len(v.similar) == 0:
+		b.WriteString(pad + helpStyle(tr("No write-ups in the history yet")) + "\n")
+	}
+
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+	for i := start; i < len(v.similar) && i < start+rows; i++ {
+		s := v.similar[i]
+		line := s.Track.Artist + " - " + s.Track.Album
+		score := helpStyle(fmt.Sprintf(" %.0f%%", s.Score*100))
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + score + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + score + "\n")
+		}
+	}
+	return b.String()
+}
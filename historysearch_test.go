@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestHistorySearch(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+	ctx := context.Background()
+	id, err := db.RecordPlay(ctx, "Can", "Tago Mago", "Halleluhwah", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := []storage.Section{{Title: "Album info", Content: "Released in 1971."}, {Title: "Album review", Content: "A landmark of krautrock."}}
+	if err := db.SaveSections(ctx, id, "gpt-4o", sections); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(keyRune('F'))
+	if m.historySearch == nil {
+		t.Fatal("F did not open the search")
+	}
+	for _, r := range "kraut" {
+		m.Update(keyRune(r))
+	}
+	view := m.View()
+	for _, want := range []string{"Can - Tago Mago - Halleluhwah", "Album review", "A landmark of", "krautrock"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("the search does not show %q:\n%s", want, view)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.Update(keyRune('x'))
+	if view := m.View(); !strings.Contains(view, "Nothing found") {
+		t.Errorf("got a match for krauxx:\n%s", view)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.Update(keyRune('t'))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.historySearch != nil || m.artist != "Can" {
+		t.Fatalf("enter did not open the track, got %q", m.artist)
+	}
+	if m.tab != 1 || m.sections[m.tab].Title != "Album review" || m.search.query != "kraut" {
+		t.Errorf("got tab %d and search %q, want the review with the words", m.tab, m.search.query)
+	}
+}
+
+// embedCompleter embeds a text as how many times it says night and
+// krautrock.
+type embedCompleter struct {
+	stubCompleter
+	texts *[]string
+}
+
+func (c embedCompleter) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	*c.texts = append(*c.texts, texts...)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		text = strings.ToLower(text)
+		vectors[i] = []float32{float32(strings.Count(text, "night")), float32(strings.Count(text, "krautrock"))}
+	}
+	return vectors, nil
+}
+
+func TestHistorySearchByMeaning(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	completer = embedCompleter{texts: &texts}
+	defer func(model string) { embeddingModel = model }(embeddingModel)
+	embeddingModel = "stub-embed"
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+	ctx := context.Background()
+	for _, album := range []struct{ artist, album, review string }{
+		{"Burial", "Untrue", "Night bus music for the late night."},
+		{"Can", "Tago Mago", "A krautrock landmark."},
+	} {
+		id, err := db.RecordPlay(ctx, album.artist, album.album, "Track", time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveSections(ctx, id, "gpt-4o", []storage.Section{{Title: "Album review", Content: album.review}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.Update(keyRune('F'))
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "night" {
+		m.Update(keyRune(r))
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter did not search by meaning")
+	}
+	m.Update(cmd())
+	if len(texts) != 3 || !strings.HasPrefix(texts[0], "Can - Tago Mago\n") || texts[2] != "night" {
+		t.Errorf("embedded %q, want the two write-ups and the query", texts)
+	}
+	view := m.View()
+	if burial, can := strings.Index(view, "Burial - Untrue 100%"), strings.Index(view, "Can - Tago Mago 0%"); burial < 0 || can < burial {
+		t.Errorf("the albums are not ranked by meaning:\n%s", view)
+	}
+
+	// The next search only embeds the query.
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m.Update(keyRune('!'))
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+	if len(texts) != 4 {
+		t.Errorf("embedded %q again", texts[3:])
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.historySearch != nil || m.artist != "Burial" {
+		t.Errorf("enter did not open the album, got %q", m.artist)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+// historyLimit is how many tracks the history screen lists.
+const historyLimit = 500
+
+// historyView is the state of the history screen.
+type historyView struct {
+	tracks []storage.Track
+	// usage is the total of all the recorded requests.
+	usage  storage.Usage
+	filter string
+	cursor int
+}
+
+// matches returns the tracks matching the filter, most recent first.
+func (h historyView) matches() []storage.Track {
+	var tracks []storage.Track
+	for _, t := range h.tracks {
+		if fuzzyMatch(h.filter, t.Artist+" "+t.Album+" "+t.Track) {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks
+}
+
+// fuzzyMatch reports whether the letters of pattern appear in s in order,
+// ignoring case and spaces in the pattern.
+func fuzzyMatch(pattern string, s string) bool {
+	target := []rune(strings.ToLower(s))
+	i := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i == len(target) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// openHistoryView lists the stored tracks in the history screen.
+func (m *model) openHistoryView() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tracks, err := history.Recent(ctx, historyLimit)
+	if err != nil {
+		return err
+	}
+	usage, err := history.UsageTotals(ctx)
+	if err != nil {
+		return err
+	}
+	m.historyScreen = &historyView{tracks: tracks, usage: usage}
+	return nil
+}
+
+func (m *model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := m.historyScreen
+	matches := h.matches()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.historyScreen = nil
+	case tea.KeyUp:
+		if h.cursor > 0 {
+			h.cursor--
+		}
+	case tea.KeyDown:
+		if h.cursor < len(matches)-1 {
+			h.cursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(h.filter); len(r) > 0 {
+			h.filter = string(r[:len(r)-1])
+			h.cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		h.filter += string(msg.Runes)
+		h.cursor = 0
+	case tea.KeyEnter:
+		if h.cursor < len(matches) {
+			m.historyScreen = nil
+			cmd, err := m.showHistoryTrack(matches[h.cursor])
+			if err != nil {
+				m.errMsg = "  history: " + err.Error()
+			}
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// historyLyricsMsg tells that the lyrics of a track reopened from the history
+// arrived.
+type historyLyricsMsg struct{}
+
+// showHistoryTrack shows the sections stored for t without asking the
+// provider again. The lyrics are not stored, the returned command fetches
+// them.
+func (m *model) showHistoryTrack(t storage.Track) (tea.Cmd, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stored, err := history.Sections(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) > 0 {
+		if err := history.RecordRead(ctx, t.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	info := MusicInfo{artist: t.Artist, album: t.Album, track: t.Track}
+	m.reset(info)
+	m.loading = false
+
+	sections := make([]Section, len(stored))
+	for i, s := range stored {
+		sections[i] = Section{Title: s.Title, Content: s.Content}
+	}
+
+	m.mu.Lock()
+	m.sections = sections
+	m.links = buildLinks(info)
+	m.buildContent()
+	m.mu.Unlock()
+
+	var cmd tea.Cmd
+	if m.track != "" && !m.isPodcast() && sectionEnabled("lyrics") {
+		ctx := m.newFetch()
+		cmd = func() tea.Msg {
+			m.fetchTrackLyrics(ctx, info)
+			return historyLyricsMsg{}
+		}
+	}
+	return cmd, m.renderViewport()
+}
+
+func (m *model) historyScreenView() string {
+	h := m.historyScreen
+	pad := strings.Repeat(" ", padding)
+	matches := h.matches()
+
+	var b strings.Builder
+	title := styleTitle(pad + tr("History"))
+	if h.usage.Requests > 0 {
+		total := tokenUsage{promptTokens: h.usage.PromptTokens, completionTokens: h.usage.CompletionTokens, cost: h.usage.Cost}
+		title += helpStyle("  " + trf("%d requests", h.usage.Requests) + " • " + total.view())
+	}
+	b.WriteString(title + "\n\n")
+	b.WriteString(pad + "> " + h.filter + "█\n\n")
+
+	// Keep the cursor in view on short terminals.
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if h.cursor >= rows {
+		start = h.cursor - rows + 1
+	}
+
+	if len(matches) == 0 {
+		b.WriteString(pad + helpStyle(tr("No tracks found")) + "\n")
+	}
+	for i := start; i < len(matches) && i < start+rows; i++ {
+		t := matches[i]
+		line := fmt.Sprintf("%s - %s - %s", t.Artist, t.Album, t.Track)
+		when := helpStyle(" " + t.LastSeen.Local().Format("2006-01-02 15:04"))
+		if i == h.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + when + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + when + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("type to filter • ↑/↓: Select • enter: Open • esc: Back")))
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// hookTimeout is how long a hook may run when its config does not set
+// timeout.
+const hookTimeout = 10 * time.Second
+
+// hookEvents are the events a hook can run on.
+var hookEvents = []string{"track_changed", "content_ready", "error"}
+
+// Hook runs a command on an event: track_changed when stui starts on a new
+// track, content_ready once its sections are fetched and error for every
+// section that failed. The command gets the event as JSON on stdin.
+type Hook struct {
+	Event   string   `toml:"event"`
+	Command []string `toml:"command"`
+	Timeout duration `toml:"timeout"`
+}
+
+// hooks are the hooks of the config file.
+var hooks []Hook
+
+// hookEvent is what a hook reads on stdin, the track with what -output json
+// prints of it so far, and the section that failed for an error.
+type hookEvent struct {
+	Event string `json:"event"`
+	Result
+	Section string `json:"section,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runHooks starts the hooks of the event, they run in the background and
+// their failures are only logged for -debug.
+func runHooks(e hookEvent) {
+	for _, h := range hooks {
+		if h.Event != e.Event {
+			continue
+		}
+		h := h
+		goSafe(func() {
+			if err := h.run(e); err != nil {
+				debugLog("hook", "event", e.Event, "command", h.Command[0], "error", err)
+			}
+		})
+	}
+}
+
+func (h Hook) run(e hookEvent) error {
+	timeout := h.Timeout.Duration
+	if timeout <= 0 {
+		timeout = hookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	env := append(trackEnv(MusicInfo{artist: e.Artist, album: e.Album, track: e.Track}), "STUI_EVENT="+e.Event)
+	if e.Error != "" {
+		env = append(env, "STUI_SECTION="+e.Section, "STUI_ERROR="+e.Error)
+	}
+	_, err = runCommand(ctx, h.Command, env, input)
+	return err
+}
+
+// getInfoWithHooks is getInfo for the track followed by the player, with the
+// hooks of its events.
+func (m *model) getInfoWithHooks(ctx context.Context) {
+	if len(hooks) == 0 {
+		m.getInfo(ctx)
+		return
+	}
+
+	r := m.result()
+	info := MusicInfo{artist: r.Artist, album: r.Album, track: r.Track}
+	m.mu.Lock()
+	changed := info != m.hooked
+	m.hooked = info
+	m.mu.Unlock()
+	if changed {
+		runHooks(hookEvent{Event: "track_changed", Result: Result{Artist: r.Artist, Album: r.Album, Track: r.Track, Model: r.Model, Sections: []Section{}}})
+	}
+
+	m.getInfo(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	r = m.result()
+	for _, s := range r.Sections {
+		if s.Error != "" {
+			runHooks(hookEvent{Event: "error", Result: r, Section: s.Title, Error: s.Error})
+		}
+	}
+	runHooks(hookEvent{Event: "content_ready", Result: r})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test hooks are shell scripts")
+	}
+	m := setupTest(t, PlayerPlaying)
+	dir := t.TempDir()
+	defer func() { hooks = nil }()
+	// Every hook writes its event, even the failing one.
+	record := func(name, exit string) []string {
+		return []string{"sh", "-c", `cat > "$0/$STUI_EVENT-$1.json"; exit $2`, dir, name, exit}
+	}
+	hooks = []Hook{
+		{Event: "track_changed", Command: record("a", "0")},
+		{Event: "track_changed", Command: record("b", "1")},
+		{Event: "content_ready", Command: record("a", "0")},
+		{Event: "error", Command: []string{"sh", "-c", `echo "$STUI_SECTION: $STUI_ERROR" > "$0/error.txt"`, dir}},
+	}
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		return nil, os.ErrPermission
+	}
+
+	read := func(name string) []byte {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil && len(data) > 0 {
+				return data
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("the hook did not write %s", name)
+			}
+		}
+	}
+
+	ctx := m.newFetch()
+	m.getInfoWithHooks(ctx)
+	// The same track again is no change. The hooks run in the background,
+	// the first one is removed once it wrote its event.
+	read("track_changed-a.json")
+	os.Remove(filepath.Join(dir, "track_changed-a.json"))
+	m.getInfoWithHooks(ctx)
+
+	var e hookEvent
+	if err := json.Unmarshal(read("content_ready-a.json"), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Event != "content_ready" || e.Artist != "Radiohead" || e.Track != "Airbag" || len(e.Sections) == 0 {
+		t.Errorf("got content_ready %+v", e)
+	}
+	if err := json.Unmarshal(read("track_changed-b.json"), &e); err != nil || e.Event != "track_changed" || e.Album != "OK Computer" {
+		t.Errorf("got track_changed %+v, %v", e, err)
+	}
+	if got := string(read("error.txt")); !strings.HasPrefix(got, "Tracklist and credits: ") {
+		t.Errorf("got error %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "track_changed-a.json")); err == nil {
+		t.Error("track_changed ran again for the same track")
+	}
+}
+
+func TestLoadConfigHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"[[hooks]]\nevent = \"track_changed\"\ncommand = [\"notify-send\"]\n": "",
+		"[[hooks]]\nevent = \"paused\"\ncommand = [\"notify-send\"]\n":        "unknown hook event",
+		"[[hooks]]\nevent = \"error\"\n":                                      "needs a command",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// locale is the language of the interface, auto reads it from LC_ALL,
+// LC_MESSAGES or LANG. It does not change the language of the AI answers,
+// see language.
+var locale = "auto"
+
+// translation maps the English interface strings to the ones of the locale
+// in use, nil keeps them in English.
+var translation map[string]string
+
+// translations are the bundled interface translations by language code. The
+// keys are the English strings, format strings keep their verbs.
+var translations = map[string]map[string]string{
+	"es": {
+		"Navigate":                        "Navegar",
+		"Page":                            "Página",
+		"Half page":                       "Media página",
+		"Top/Bottom":                      "Inicio/Final",
+		"Tabs":                            "Pestañas",
+		"Lyrics":                          "Letra",
+		"Translate lyrics":                "Traducir letra",
+		"Lyrics/Translate":                "Letra/Traducir",
+		"Up next/Playlists":               "A continuación/Playlists",
+		"Translating the lyrics to %s":    "Traduciendo la letra a %s",
+		"History":                         "Historial",
+		"Open link":                       "Abrir enlace",
+		"Similar artists":                 "Artistas similares",
+		"Artist deep dive":                "Artista a fondo",
+		"Pin album":                       "Fijar álbum",
+		"Bookmark/Bookmarks":              "Marcar/Marcadores",
+		"Bookmarked %s":                   "%s marcada",
+		"Bookmark: %s - %s":               "Marcador: %s - %s",
+		"Note, e.g. where the sample is:": "Nota, p. ej. dónde está el sample:",
+		"enter: Save • esc: Cancel":       "enter: Guardar • esc: Cancelar",
+		"Bookmarks":                       "Marcadores",
+		"No bookmarks found":              "No se encontraron marcadores",
+		"type to filter • ↑/↓: Select • enter: Open • ctrl+d: Delete • ctrl+e: Export • esc: Back": "escribí para filtrar • ↑/↓: Elegir • enter: Abrir • ctrl+d: Borrar • ctrl+e: Exportar • esc: Volver",
+		"Quiz":          "Quiz",
+		"Up next":       "A continuación",
+		"Playlists":     "Playlists",
+		"Nothing found": "No se encontró nada",
+		"%d tracks":     "%d temas",
+		"Playing %s":    "Reproduciendo %s",
+		"type to filter • ↑/↓: Select • enter: Play • esc: Back": "escribí para filtrar • ↑/↓: Elegir • enter: Reproducir • esc: Volver",
+		"Rest of the album":    "Resto del álbum",
+		"Spotify queue":        "Cola de Spotify",
+		"Prefetching %s":       "Precargando %s",
+		"Loading the queue...": "Cargando la cola...",
+		"↑/↓: Select • enter: Prefetch • esc: Back": "↑/↓: Elegir • enter: Precargar • esc: Volver",
+		"Quiz: %s":                                        "Quiz: %s",
+		"all time %d/%d":                                  "en total %d/%d",
+		"Writing the quiz...":                             "Escribiendo el quiz...",
+		"esc: Back":                                       "esc: Volver",
+		"Something went wrong":                            "Algo salió mal",
+		"%s: Retry • esc: Back • %s: Quit":                "%s: Reintentar • esc: Volver • %s: Salir",
+		"r: New quiz • esc: Back":                         "r: Nuevo quiz • esc: Volver",
+		"You got %d of %d":                                "Acertaste %d de %d",
+		"Question %d of %d • score %d":                    "Pregunta %d de %d • puntos %d",
+		"enter: Next question • esc: Back":                "enter: Siguiente pregunta • esc: Volver",
+		"1-4: Answer • esc: Back":                         "1-4: Responder • esc: Volver",
+		"pinned: %s - %s":                                 "fijado: %s - %s",
+		"Pinned %s, the next albums are compared with it": "%s fijado, los próximos álbumes se comparan con él",
+		"Unpinned %s":                                     "%s ya no está fijado",
+		"Chat":                                            "Chat",
+		"Export":                                          "Exportar",
+		"Export/Vault":                                    "Exportar/Notas",
+		"Copy tab/all":                                    "Copiar pestaña/todo",
+		"Search":                                          "Buscar",
+		"Raw/Rendered":                                    "Texto/Formato",
+		"Play/Next/Prev":                                  "Reproducir/Siguiente/Anterior",
+		"Volume/Shuffle/Repeat":                           "Volumen/Aleatorio/Repetir",
+		"volume %d%%":                                     "volumen %d%%",
+		"shuffle on":                                      "aleatorio activado",
+		"shuffle off":                                     "aleatorio desactivado",
+		"repeat off":                                      "sin repetir",
+		"repeat all":                                      "repetir todo",
+		"repeat track":                                    "repetir tema",
+		"Refresh":                                         "Actualizar",
+		"Refresh uncached":                                "Actualizar sin caché",
+		"Regenerate section":                              "Regenerar sección",
+		"Cancel sections":                                 "Cancelar secciones",
+		"Cancel":                                          "Cancelar",
+		"still working… (%ds)":                            "sigue trabajando… (%ds)",
+		"Canceled: %s":                                    "Cancelado: %s",
+		"Quit":                                            "Salir",
+		"Clear search":                                    "Borrar búsqueda",
+		"Next/Previous match":                             "Coincidencia siguiente/anterior",
+		"model: %s":                                       "modelo: %s",
+		"watching":                                        "siguiendo",
+		"playing":                                         "reproduciendo",
+		"paused":                                          "en pausa",
+		"unavailable":                                     "no disponible",
+		"updated %s":                                      "actualizado %s",
+		"cache off":                                       "caché desactivada",
+		"cache %d/%d":                                     "caché %d/%d",
+		"%d retries":                                      "%d reintentos",
+		"lines %d-%d of %d":                               "líneas %d-%d de %d",
+		"tokens: %s in, %s out":                           "tokens: %s de entrada, %s de salida",
+		"%d requests":                                     "%d solicitudes",
+		"Pattern not found: %s":                           "No se encontró: %s",
+		"Nothing is playing right now.":                   "No se está reproduciendo nada.",
+		"Waiting for playback…":                           "Esperando la reproducción…",
+		"Seems that %s is not installed or is not open :(":       "Parece que %s no está instalado o no está abierto :(",
+		"Seems that %s is not open, %s to follow other devices.": "Parece que %s no está abierto, %s para seguir otros dispositivos.",
+		"Press %s to retry connecting to %s":                     "Presioná %s para volver a conectar con %s",
+		"Loading...":                                             "Cargando...",
+		"Reading mode":                                           "Modo lectura",
+		"No genre or era known for this album.":                  "No se conocen el género ni la época de este álbum.",
+		"Scene context":                                          "La escena",
+		"No recent news about this artist.":                      "No hay noticias recientes de este artista.",
+		"The news in brief":                                      "Las noticias en breve",
+		"Switch artist":                                          "Cambiar de artista",
+		"The track has a single artist":                          "El tema tiene un solo artista",
+		"Deep dive and links: %s":                                "Artista a fondo y links: %s",
+		"Switch player":                                          "Cambiar de reproductor",
+		"Use -player auto to switch between the players":         "Usá -player auto para cambiar entre los reproductores",
+		"Reading the track from %s":                              "Leyendo el tema de %s",
+		"Metadata only: %s refused the API key, check %s and start stui again": "Solo metadatos: %s rechazó la clave de la API, revisá %s y volvé a abrir stui",
+		"Metadata only: set %s to get the AI sections":                         "Solo metadatos: configurá %s para ver las secciones de la IA",
+		"Markdown style":     "Estilo del markdown",
+		"Markdown style: %s": "Estilo del markdown: %s",
+		"Words":              "Palabras",
+		"Meaning":            "Significado",
+		"type a mood or a style • enter: Search, Open • ↑/↓: Select • tab: Words • esc: Back": "escribí un clima o un estilo • enter: Buscar, Abrir • ↑/↓: Elegir • tab: Palabras • esc: Volver",
+		"No write-ups in the history yet": "Todavía no hay textos en el historial",
+		"Search history":                  "Buscar en el historial",
+		"Search the history":              "Buscar en el historial",
+		"type to search • ↑/↓: Select • enter: Open • tab: Meaning • esc: Back": "escribí para buscar • ↑/↓: Elegir • enter: Abrir • tab: Significado • esc: Volver",
+		"Listen next":    "Escuchar después",
+		"After %s by %s": "Después de %s de %s",
+		"↑/↓: Select • space: Mark • enter: Queue on Spotify • r: Suggest again • esc: Back": "↑/↓: Elegir • space: Marcar • enter: Encolar en Spotify • r: Sugerir otra vez • esc: Volver",
+		"Queueing %d suggestions":     "Encolando %d sugerencias",
+		"Queued %d tracks on Spotify": "%d temas encolados en Spotify",
+		"not found: %s":               "no encontrados: %s",
+		"Edit prompt":                 "Editar prompt",
+		"%s has no prompt, it does not come from the AI provider": "%s no tiene prompt, no viene del proveedor de IA",
+		"Share card":                        "Compartir tarjeta",
+		"Writing the card":                  "Creando la tarjeta",
+		"Now playing":                       "Sonando ahora",
+		"Listening stats":                   "Estadísticas de escucha",
+		"This week":                         "Esta semana",
+		"This month":                        "Este mes",
+		"All time":                          "Todo",
+		"%d plays in %d listening sessions": "%d reproducciones en %d sesiones de escucha",
+		"Top artists":                       "Artistas más escuchados",
+		"Top albums":                        "Álbumes más escuchados",
+		"Top tracks":                        "Temas más escuchados",
+		"Most reread":                       "Más releídos",
+		"Nothing yet":                       "Nada todavía",
+		"tab: Period • esc: Back":           "tab: Período • esc: Volver",
+		"Ask about this":                    "Preguntá sobre esto",
+		"Ask about this track":              "Preguntá algo sobre este tema",
+		"Album tracks":                      "Temas del álbum",
+		"↑/↓: Select • enter: Play • esc: Back":    "↑/↓: Elegir • enter: Reproducir • esc: Volver",
+		"The plugin has nothing about this track.": "El plugin no tiene nada sobre este tema.",
+		"offline":              "sin conexión",
+		"Offline, saved on %s": "Sin conexión, guardado el %s",
+		"Search Spotify":       "Buscar en Spotify",
+		"Album":                "Álbum",
+		"Track":                "Tema",
+		"enter: Search or Play • tab: Info • ↑/↓: Select • esc: Back": "enter: Buscar o reproducir • tab: Info • ↑/↓: Elegir • esc: Volver",
+		"Navigation":        "Navegación",
+		"Playback":          "Reproducción",
+		"AI":                "IA",
+		"General":           "General",
+		"Keys":              "Teclas",
+		"Back":              "Volver",
+		"All keys":          "Todas las teclas",
+		"Up":                "Arriba",
+		"Down":              "Abajo",
+		"Page up":           "Página arriba",
+		"Page down":         "Página abajo",
+		"Half page up":      "Media página arriba",
+		"Half page down":    "Media página abajo",
+		"Top":               "Principio",
+		"Bottom":            "Final",
+		"Next tab":          "Pestaña siguiente",
+		"Previous tab":      "Pestaña anterior",
+		"Next match":        "Coincidencia siguiente",
+		"Previous match":    "Coincidencia anterior",
+		"Help":              "Ayuda",
+		"Copy tab":          "Copiar pestaña",
+		"Copy all":          "Copiar todo",
+		"Notes vault":       "Bóveda de notas",
+		"Bookmark":          "Guardar",
+		"Play/Pause":        "Reproducir/Pausar",
+		"Next track":        "Siguiente tema",
+		"Previous track":    "Tema anterior",
+		"Volume up":         "Subir volumen",
+		"Volume down":       "Bajar volumen",
+		"Shuffle":           "Aleatorio",
+		"Repeat":            "Repetir",
+		"Commands":          "Comandos",
+		"Go to %s":          "Ir a %s",
+		"Theme: %s":         "Tema: %s",
+		"No commands found": "No se encontraron comandos",
+		"type to filter • ↑/↓: Select • enter: Run • esc: Back": "escribí para filtrar • ↑/↓: Elegir • enter: Ejecutar • esc: Volver",
+		"Length":                                "Duración",
+		"Year":                                  "Año",
+		"Label":                                 "Sello",
+		"Focus/Sections":                        "Foco/Secciones",
+		"Writing...":                            "Escribiendo...",
+		"Loading lyrics...":                     "Cargando la letra...",
+		"Thinking...":                           "Pensando...",
+		"Press %s to retry this section.":       "Presioná %s para reintentar esta sección.",
+		"Copied %s to the clipboard":            "Se copió %s al portapapeles",
+		"the tab":                               "la pestaña",
+		"the document":                          "el documento",
+		"the link":                              "el enlace",
+		"Opened %s":                             "Se abrió %s",
+		"Saved to %s":                           "Guardado en %s",
+		"Asking about %s":                       "Preguntando por %s",
+		"MusicBrainz does not know this album.": "MusicBrainz no conoce este álbum.",
+		"Spotify does not know this track.":     "Spotify no conoce este tema.",
+		"Wikipedia has no article about this album or artist.": "Wikipedia no tiene un artículo sobre este álbum o artista.",
+		"No critic or user scores found for this album.":       "No se encontraron puntajes de la crítica ni de usuarios para este álbum.",
+		"Discogs does not know this album.":                    "Discogs no conoce este álbum.",
+		"Genius does not know this song.":                      "Genius no conoce esta canción.",
+		"Reddit has no threads about this album or song.":      "Reddit no tiene hilos sobre este álbum o canción.",
+		"What Reddit thinks":                                   "Qué opina Reddit",
+		"Last.fm does not know this artist.":                   "Last.fm no conoce a este artista.",
+		"No upcoming concerts.":                                "No hay próximos conciertos.",
+		"Last.fm knows no similar artists.":                    "Last.fm no conoce artistas similares.",
+		"ListenBrainz does not know this artist.":              "ListenBrainz no conoce a este artista.",
+		"Links":           "Enlaces",
+		"Chat: %s - %s":   "Chat: %s - %s",
+		"Chat: %s":        "Chat: %s",
+		"No tracks found": "No se encontraron temas",
+		"Ask anything about the track, e.g. who produced it or the story behind it.":      "Preguntá lo que quieras sobre el tema, por ejemplo quién lo produjo o la historia detrás.",
+		"type to filter • ↑/↓: Select • enter: Open • esc: Back":                          "escribí para filtrar • ↑/↓: Elegir • enter: Abrir • esc: Volver",
+		"↑/↓: Select • enter/o/1-9: Open • y: Copy • esc: Back":                           "↑/↓: Elegir • enter/o/1-9: Abrir • y: Copiar • esc: Volver",
+		"↑/↓: Select • enter/1-9: Ask about the artist • p: Spotify playlist • esc: Back": "↑/↓: Elegir • enter/1-9: Preguntar por el artista • p: Playlist de Spotify • esc: Volver",
+		"Saving the playlist %s":                            "Guardando la playlist %s",
+		"Saved %d tracks to the playlist %s":                "%d temas guardados en la playlist %s",
+		"enter: Send • ↑/↓/pgup/pgdown: Scroll • esc: Back": "enter: Enviar • ↑/↓/pgup/pgdown: Desplazar • esc: Volver",
+	},
+	"de": {
+		"Navigate":                        "Navigieren",
+		"Page":                            "Seite",
+		"Half page":                       "Halbe Seite",
+		"Top/Bottom":                      "Anfang/Ende",
+		"Tabs":                            "Tabs",
+		"Lyrics":                          "Liedtext",
+		"Translate lyrics":                "Liedtext übersetzen",
+		"Lyrics/Translate":                "Liedtext/Übersetzen",
+		"Up next/Playlists":               "Als Nächstes/Playlists",
+		"Translating the lyrics to %s":    "Liedtext wird übersetzt: %s",
+		"History":                         "Verlauf",
+		"Open link":                       "Link öffnen",
+		"Similar artists":                 "Ähnliche Künstler",
+		"Artist deep dive":                "Künstler im Detail",
+		"Pin album":                       "Album anheften",
+		"Bookmark/Bookmarks":              "Merken/Lesezeichen",
+		"Bookmarked %s":                   "%s gemerkt",
+		"Bookmark: %s - %s":               "Lesezeichen: %s - %s",
+		"Note, e.g. where the sample is:": "Notiz, z. B. wo das Sample ist:",
+		"enter: Save • esc: Cancel":       "enter: Speichern • esc: Abbrechen",
+		"Bookmarks":                       "Lesezeichen",
+		"No bookmarks found":              "Keine Lesezeichen gefunden",
+		"type to filter • ↑/↓: Select • enter: Open • ctrl+d: Delete • ctrl+e: Export • esc: Back": "tippen zum Filtern • ↑/↓: Auswählen • enter: Öffnen • ctrl+d: Löschen • ctrl+e: Exportieren • esc: Zurück",
+		"Quiz":          "Quiz",
+		"Up next":       "Als Nächstes",
+		"Playlists":     "Playlists",
+		"Nothing found": "Nichts gefunden",
+		"%d tracks":     "%d Titel",
+		"Playing %s":    "%s wird abgespielt",
+		"type to filter • ↑/↓: Select • enter: Play • esc: Back": "tippen zum Filtern • ↑/↓: Auswählen • enter: Abspielen • esc: Zurück",
+		"Rest of the album":    "Rest des Albums",
+		"Spotify queue":        "Spotify-Warteschlange",
+		"Prefetching %s":       "%s wird vorgeladen",
+		"Loading the queue...": "Warteschlange wird geladen...",
+		"↑/↓: Select • enter: Prefetch • esc: Back": "↑/↓: Auswählen • enter: Vorladen • esc: Zurück",
+		"Quiz: %s":                                        "Quiz: %s",
+		"all time %d/%d":                                  "insgesamt %d/%d",
+		"Writing the quiz...":                             "Das Quiz wird geschrieben...",
+		"esc: Back":                                       "esc: Zurück",
+		"Something went wrong":                            "Etwas ist schiefgelaufen",
+		"%s: Retry • esc: Back • %s: Quit":                "%s: Erneut versuchen • esc: Zurück • %s: Beenden",
+		"r: New quiz • esc: Back":                         "r: Neues Quiz • esc: Zurück",
+		"You got %d of %d":                                "%d von %d richtig",
+		"Question %d of %d • score %d":                    "Frage %d von %d • Punkte %d",
+		"enter: Next question • esc: Back":                "enter: Nächste Frage • esc: Zurück",
+		"1-4: Answer • esc: Back":                         "1-4: Antworten • esc: Zurück",
+		"pinned: %s - %s":                                 "angeheftet: %s - %s",
+		"Pinned %s, the next albums are compared with it": "%s angeheftet, die nächsten Alben werden damit verglichen",
+		"Unpinned %s":                                     "%s nicht mehr angeheftet",
+		"Chat":                                            "Chat",
+		"Export":                                          "Exportieren",
+		"Export/Vault":                                    "Exportieren/Notizen",
+		"Copy tab/all":                                    "Tab/Alles kopieren",
+		"Search":                                          "Suchen",
+		"Raw/Rendered":                                    "Roh/Formatiert",
+		"Play/Next/Prev":                                  "Abspielen/Weiter/Zurück",
+		"Volume/Shuffle/Repeat":                           "Lautstärke/Zufall/Wiederholen",
+		"volume %d%%":                                     "Lautstärke %d%%",
+		"shuffle on":                                      "Zufall an",
+		"shuffle off":                                     "Zufall aus",
+		"repeat off":                                      "Wiederholen aus",
+		"repeat all":                                      "alle wiederholen",
+		"repeat track":                                    "Titel wiederholen",
+		"Refresh":                                         "Aktualisieren",
+		"Refresh uncached":                                "Ohne Cache aktualisieren",
+		"Regenerate section":                              "Abschnitt neu erstellen",
+		"Cancel sections":                                 "Abschnitte abbrechen",
+		"Cancel":                                          "Abbrechen",
+		"still working… (%ds)":                            "arbeitet noch… (%ds)",
+		"Canceled: %s":                                    "Abgebrochen: %s",
+		"Quit":                                            "Beenden",
+		"Clear search":                                    "Suche löschen",
+		"Next/Previous match":                             "Nächster/Vorheriger Treffer",
+		"model: %s":                                       "Modell: %s",
+		"watching":                                        "folgt",
+		"playing":                                         "spielt",
+		"paused":                                          "pausiert",
+		"unavailable":                                     "nicht verfügbar",
+		"updated %s":                                      "aktualisiert %s",
+		"cache off":                                       "Cache aus",
+		"cache %d/%d":                                     "Cache %d/%d",
+		"%d retries":                                      "%d Wiederholungen",
+		"lines %d-%d of %d":                               "Zeilen %d-%d von %d",
+		"tokens: %s in, %s out":                           "Tokens: %s ein, %s aus",
+		"%d requests":                                     "%d Anfragen",
+		"Pattern not found: %s":                           "Nicht gefunden: %s",
+		"Nothing is playing right now.":                   "Gerade läuft nichts.",
+		"Waiting for playback…":                           "Warte auf die Wiedergabe…",
+		"Seems that %s is not installed or is not open :(":       "%s scheint nicht installiert oder nicht geöffnet zu sein :(",
+		"Seems that %s is not open, %s to follow other devices.": "%s scheint nicht geöffnet zu sein, %s, um anderen Geräten zu folgen.",
+		"Press %s to retry connecting to %s":                     "Drücke %s, um erneut mit %s zu verbinden",
+		"Loading...":                                             "Wird geladen...",
+		"Reading mode":                                           "Lesemodus",
+		"No genre or era known for this album.":                  "Für dieses Album sind weder Genre noch Ära bekannt.",
+		"Scene context":                                          "Die Szene",
+		"No recent news about this artist.":                      "Keine aktuellen Nachrichten zu diesem Künstler.",
+		"The news in brief":                                      "Die Nachrichten in Kürze",
+		"Switch artist":                                          "Künstler wechseln",
+		"The track has a single artist":                          "Der Titel hat nur einen Künstler",
+		"Deep dive and links: %s":                                "Künstler im Detail und Links: %s",
+		"Switch player":                                          "Player wechseln",
+		"Use -player auto to switch between the players":         "Mit -player auto zwischen den Playern wechseln",
+		"Reading the track from %s":                              "Titel wird von %s gelesen",
+		"Metadata only: %s refused the API key, check %s and start stui again": "Nur Metadaten: %s hat den API-Schlüssel abgelehnt, prüfe %s und starte stui neu",
+		"Metadata only: set %s to get the AI sections":                         "Nur Metadaten: setze %s, um die KI-Abschnitte zu erhalten",
+		"Markdown style":     "Markdown-Stil",
+		"Markdown style: %s": "Markdown-Stil: %s",
+		"Words":              "Wörter",
+		"Meaning":            "Bedeutung",
+		"type a mood or a style • enter: Search, Open • ↑/↓: Select • tab: Words • esc: Back": "Stimmung oder Stil tippen • enter: Suchen, Öffnen • ↑/↓: Auswählen • tab: Wörter • esc: Zurück",
+		"No write-ups in the history yet": "Noch keine Texte im Verlauf",
+		"Search history":                  "Verlauf durchsuchen",
+		"Search the history":              "Verlauf durchsuchen",
+		"type to search • ↑/↓: Select • enter: Open • tab: Meaning • esc: Back": "tippen zum Suchen • ↑/↓: Auswählen • enter: Öffnen • tab: Bedeutung • esc: Zurück",
+		"Listen next":    "Als Nächstes hören",
+		"After %s by %s": "Nach %s von %s",
+		"↑/↓: Select • space: Mark • enter: Queue on Spotify • r: Suggest again • esc: Back": "↑/↓: Auswählen • space: Markieren • enter: In Spotify einreihen • r: Neu vorschlagen • esc: Zurück",
+		"Queueing %d suggestions":     "%d Vorschläge werden eingereiht",
+		"Queued %d tracks on Spotify": "%d Titel in Spotify eingereiht",
+		"not found: %s":               "nicht gefunden: %s",
+		"Edit prompt":                 "Prompt bearbeiten",
+		"%s has no prompt, it does not come from the AI provider": "%s hat keinen Prompt, es kommt nicht vom KI-Anbieter",
+		"Share card":                        "Karte teilen",
+		"Writing the card":                  "Karte wird erstellt",
+		"Now playing":                       "Läuft gerade",
+		"Listening stats":                   "Hörstatistik",
+		"This week":                         "Diese Woche",
+		"This month":                        "Dieser Monat",
+		"All time":                          "Insgesamt",
+		"%d plays in %d listening sessions": "%d Wiedergaben in %d Hörsitzungen",
+		"Top artists":                       "Meistgehörte Künstler",
+		"Top albums":                        "Meistgehörte Alben",
+		"Top tracks":                        "Meistgehörte Titel",
+		"Most reread":                       "Am häufigsten wieder gelesen",
+		"Nothing yet":                       "Noch nichts",
+		"tab: Period • esc: Back":           "tab: Zeitraum • esc: Zurück",
+		"Ask about this":                    "Frag dazu",
+		"Ask about this track":              "Frag etwas zu diesem Titel",
+		"Album tracks":                      "Albumtitel",
+		"↑/↓: Select • enter: Play • esc: Back":    "↑/↓: Auswählen • Enter: Abspielen • Esc: Zurück",
+		"The plugin has nothing about this track.": "Das Plugin hat nichts zu diesem Titel.",
+		"offline":              "offline",
+		"Offline, saved on %s": "Offline, gespeichert am %s",
+		"Search Spotify":       "Spotify durchsuchen",
+		"Album":                "Album",
+		"Track":                "Titel",
+		"enter: Search or Play • tab: Info • ↑/↓: Select • esc: Back": "Enter: Suchen oder Abspielen • Tab: Infos • ↑/↓: Auswählen • Esc: Zurück",
+		"Navigation":        "Navigation",
+		"Playback":          "Wiedergabe",
+		"AI":                "KI",
+		"General":           "Allgemein",
+		"Keys":              "Tasten",
+		"Back":              "Zurück",
+		"All keys":          "Alle Tasten",
+		"Up":                "Hoch",
+		"Down":              "Runter",
+		"Page up":           "Seite hoch",
+		"Page down":         "Seite runter",
+		"Half page up":      "Halbe Seite hoch",
+		"Half page down":    "Halbe Seite runter",
+		"Top":               "Anfang",
+		"Bottom":            "Ende",
+		"Next tab":          "Nächster Tab",
+		"Previous tab":      "Vorheriger Tab",
+		"Next match":        "Nächster Treffer",
+		"Previous match":    "Vorheriger Treffer",
+		"Help":              "Hilfe",
+		"Copy tab":          "Tab kopieren",
+		"Copy all":          "Alles kopieren",
+		"Notes vault":       "Notizsammlung",
+		"Bookmark":          "Merken",
+		"Play/Pause":        "Abspielen/Pause",
+		"Next track":        "Nächster Titel",
+		"Previous track":    "Vorheriger Titel",
+		"Volume up":         "Lauter",
+		"Volume down":       "Leiser",
+		"Shuffle":           "Zufallswiedergabe",
+		"Repeat":            "Wiederholen",
+		"Commands":          "Befehle",
+		"Go to %s":          "Zu %s",
+		"Theme: %s":         "Thema: %s",
+		"No commands found": "Keine Befehle gefunden",
+		"type to filter • ↑/↓: Select • enter: Run • esc: Back": "tippen zum Filtern • ↑/↓: Auswählen • enter: Ausführen • esc: Zurück",
+		"Length":                                "Länge",
+		"Year":                                  "Jahr",
+		"Label":                                 "Label",
+		"Focus/Sections":                        "Fokus/Abschnitte",
+		"Writing...":                            "Schreibt...",
+		"Loading lyrics...":                     "Liedtext wird geladen...",
+		"Thinking...":                           "Denkt nach...",
+		"Press %s to retry this section.":       "Drücke %s, um diesen Abschnitt zu wiederholen.",
+		"Copied %s to the clipboard":            "%s in die Zwischenablage kopiert",
+		"the tab":                               "Tab",
+		"the document":                          "Dokument",
+		"the link":                              "Link",
+		"Opened %s":                             "%s geöffnet",
+		"Saved to %s":                           "Gespeichert in %s",
+		"Asking about %s":                       "Frage nach %s",
+		"MusicBrainz does not know this album.": "MusicBrainz kennt dieses Album nicht.",
+		"Spotify does not know this track.":     "Spotify kennt diesen Titel nicht.",
+		"Wikipedia has no article about this album or artist.": "Wikipedia hat keinen Artikel über dieses Album oder diesen Künstler.",
+		"No critic or user scores found for this album.":       "Keine Kritiker- oder Nutzerwertungen für dieses Album gefunden.",
+		"Discogs does not know this album.":                    "Discogs kennt dieses Album nicht.",
+		"Genius does not know this song.":                      "Genius kennt diesen Song nicht.",
+		"Reddit has no threads about this album or song.":      "Reddit hat keine Threads zu diesem Album oder Song.",
+		"What Reddit thinks":                                   "Was Reddit denkt",
+		"Last.fm does not know this artist.":                   "Last.fm kennt diesen Künstler nicht.",
+		"No upcoming concerts.":                                "Keine anstehenden Konzerte.",
+		"Last.fm knows no similar artists.":                    "Last.fm kennt keine ähnlichen Künstler.",
+		"ListenBrainz does not know this artist.":              "ListenBrainz kennt diesen Künstler nicht.",
+		"Links":           "Links",
+		"Chat: %s - %s":   "Chat: %s - %s",
+		"Chat: %s":        "Chat: %s",
+		"No tracks found": "Keine Titel gefunden",
+		"Ask anything about the track, e.g. who produced it or the story behind it.":      "Frag alles über den Titel, z. B. wer ihn produziert hat oder die Geschichte dahinter.",
+		"type to filter • ↑/↓: Select • enter: Open • esc: Back":                          "tippen zum Filtern • ↑/↓: Auswählen • enter: Öffnen • esc: Zurück",
+		"↑/↓: Select • enter/o/1-9: Open • y: Copy • esc: Back":                           "↑/↓: Auswählen • enter/o/1-9: Öffnen • y: Kopieren • esc: Zurück",
+		"↑/↓: Select • enter/1-9: Ask about the artist • p: Spotify playlist • esc: Back": "↑/↓: Auswählen • enter/1-9: Nach dem Künstler fragen • p: Spotify-Playlist • esc: Zurück",
+		"Saving the playlist %s":                            "Playlist %s wird gespeichert",
+		"Saved %d tracks to the playlist %s":                "%d Titel in der Playlist %s gespeichert",
+		"enter: Send • ↑/↓/pgup/pgdown: Scroll • esc: Back": "enter: Senden • ↑/↓/pgup/pgdown: Scrollen • esc: Zurück",
+	},
+}
+
+func localeNames() []string {
+	names := []string{"auto", "en"}
+	for name := range translations {
+		names = append(names, name)
+	}
+	sort.Strings(names[2:])
+	return names
+}
+
+// useLocale switches the interface to name, a language code such as "es"
+// or a POSIX locale such as "es_AR.UTF-8".
+func useLocale(name string) error {
+	auto := name == "auto"
+	if auto {
+		name = ""
+		for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if name = os.Getenv(env); name != "" {
+				break
+			}
+		}
+	}
+
+	code := strings.ToLower(name)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+
+	t, ok := translations[code]
+	switch {
+	case ok:
+		translation = t
+	case auto || code == "en" || code == "c" || code == "posix":
+		// Locales without a translation keep the interface in English.
+		translation = nil
+	default:
+		return fmt.Errorf("unknown locale %q, use %s", name, strings.Join(localeNames(), ", "))
+	}
+
+	// The locales without a translation still have their Wikipedia.
+	linkLang = "en"
+	if isLanguageCode(code) {
+		linkLang = code
+	}
+	return nil
+}
+
+// isLanguageCode reports whether code is an ISO 639 code, two or three
+// letters. The C locale is not one.
+func isLanguageCode(code string) bool {
+	if len(code) < 2 || len(code) > 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// tr returns the translation of s, or s when the locale has none.
+func tr(s string) string {
+	if t, ok := translation[s]; ok {
+		return t
+	}
+	return s
+}
+
+// trf formats the translation of format.
+func trf(format string, a ...any) string {
+	return fmt.Sprintf(tr(format), a...)
+}
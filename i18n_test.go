@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestUseLocale(t *testing.T) {
+	defer func() { translation, linkLang = nil, "en" }()
+
+	if err := useLocale("es_AR.UTF-8"); err != nil {
+		t.Fatal(err)
+	}
+	if got := trf("Saved to %s", "/tmp/x.md"); got != "Guardado en /tmp/x.md" {
+		t.Errorf("got %q", got)
+	}
+	if got := tr("not translated"); got != "not translated" {
+		t.Errorf("got %q", got)
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	if err := useLocale("auto"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr("Quit"); got != "Quit" {
+		t.Errorf("untranslated locales should stay in English, got %q", got)
+	}
+	if linkLang != "fr" {
+		t.Errorf("the links of a French locale are in %q", linkLang)
+	}
+	if err := useLocale("C"); err != nil || linkLang != "en" {
+		t.Errorf("the C locale got %v and links in %q", err, linkLang)
+	}
+
+	if err := useLocale("fr"); err == nil {
+		t.Error("unknown locale should fail")
+	}
+}
+
+func TestTranslationsComplete(t *testing.T) {
+	for code, have := range translations {
+		for other, otherStrings := range translations {
+			for s := range otherStrings {
+				if _, ok := have[s]; !ok {
+					t.Errorf("%s translates %q but %s does not", other, s, code)
+				}
+			}
+		}
+	}
+}
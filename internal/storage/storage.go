@@ -0,0 +1,673 @@
+// Package storage keeps the listening history of stui in a SQLite database:
+// every track seen and the sections generated for it.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order, the index of the last one applied is
+// stored in the user_version pragma. Never edit a released migration, add a
+// new one instead.
+var migrations = []string{
+	`CREATE TABLE tracks (
+		id         INTEGER PRIMARY KEY,
+		artist     TEXT NOT NULL,
+		album      TEXT NOT NULL,
+		track      TEXT NOT NULL,
+		first_seen TIMESTAMP NOT NULL,
+		last_seen  TIMESTAMP NOT NULL,
+		plays      INTEGER NOT NULL DEFAULT 1,
+		UNIQUE (artist, album, track)
+	);
+	CREATE TABLE sections (
+		track_id   INTEGER NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		title      TEXT NOT NULL,
+		content    TEXT NOT NULL,
+		model      TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (track_id, position)
+	);
+	CREATE INDEX tracks_last_seen ON tracks (last_seen);`,
+	`CREATE TABLE usage (
+		id                INTEGER PRIMARY KEY,
+		artist            TEXT NOT NULL,
+		album             TEXT NOT NULL,
+		track             TEXT NOT NULL,
+		model             TEXT NOT NULL,
+		prompt_tokens     INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		cost              REAL NOT NULL,
+		at                TIMESTAMP NOT NULL
+	);`,
+	`CREATE TABLE quiz_answers (
+		id       INTEGER PRIMARY KEY,
+		artist   TEXT NOT NULL,
+		album    TEXT NOT NULL,
+		question TEXT NOT NULL,
+		correct  INTEGER NOT NULL,
+		at       TIMESTAMP NOT NULL
+	);`,
+	`CREATE TABLE bookmarks (
+		id         INTEGER PRIMARY KEY,
+		artist     TEXT NOT NULL,
+		album      TEXT NOT NULL,
+		track      TEXT NOT NULL,
+		note       TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		UNIQUE (artist, album, track)
+	);`,
+	// The plays before it only have their last time.
+	`CREATE TABLE plays (
+		track_id INTEGER NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+		at       TIMESTAMP NOT NULL
+	);
+	CREATE INDEX plays_at ON plays (at);
+	INSERT INTO plays (track_id, at) SELECT id, last_seen FROM tracks;
+	CREATE TABLE reads (
+		track_id INTEGER NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+		at       TIMESTAMP NOT NULL
+	);`,
+	// The index keeps its own copy of the sections, the rowids of sections
+	// can change with a VACUUM.
+	`CREATE VIRTUAL TABLE sections_fts USING fts5 (
+		title, content, track_id UNINDEXED, position UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+	CREATE TRIGGER sections_fts_insert AFTER INSERT ON sections BEGIN
+		INSERT INTO sections_fts (title, content, track_id, position) VALUES (new.title, new.content, new.track_id, new.position);
+	END;
+	CREATE TRIGGER sections_fts_delete AFTER DELETE ON sections BEGIN
+		DELETE FROM sections_fts WHERE track_id = old.track_id AND position = old.position;
+	END;
+	CREATE TRIGGER sections_fts_update AFTER UPDATE ON sections BEGIN
+		DELETE FROM sections_fts WHERE track_id = old.track_id AND position = old.position;
+		INSERT INTO sections_fts (title, content, track_id, position) VALUES (new.title, new.content, new.track_id, new.position);
+	END;
+	INSERT INTO sections_fts (title, content, track_id, position) SELECT title, content, track_id, position FROM sections;`,
+	`CREATE TABLE embeddings (
+		artist     TEXT NOT NULL,
+		album      TEXT NOT NULL,
+		model      TEXT NOT NULL,
+		vector     BLOB NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (artist, album, model)
+	);`,
+}
+
+// DB is the history database.
+type DB struct {
+	db *sql.DB
+}
+
+// Track is a track seen by stui.
+type Track struct {
+	ID        int64
+	Artist    string
+	Album     string
+	Track     string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Plays     int
+}
+
+// Usage is the total of the provider requests.
+type Usage struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	// Cost is the estimate in USD, requests to models without a known price
+	// count as free.
+	Cost float64
+}
+
+// Bookmark is a track flagged by the user along with their note.
+type Bookmark struct {
+	ID     int64
+	Artist string
+	Album  string
+	Track  string
+	Note   string
+	// TrackID is the track in the history, 0 when it is not there.
+	TrackID   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Section is a generated section of a track.
+type Section struct {
+	Title   string
+	Content string
+}
+
+// DefaultPath is the database in the XDG data directory.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "stui", "history.db"), nil
+}
+
+// Open opens the database at path, creating it and applying the pending
+// migrations.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	// Several goroutines write at the end of a fetch, wait for the lock
+	// instead of failing with SQLITE_BUSY.
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DB{db: db}
+	if err := d.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("history %s: %w", path, err)
+	}
+	return d, nil
+}
+
+func (d *DB) migrate() error {
+	var version int
+	if err := d.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database version %d is newer than this stui", version)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := d.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not take parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// RecordPlay adds a play of the track at the given time and returns its id.
+func (d *DB) RecordPlay(ctx context.Context, artist, album, track string, at time.Time) (int64, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tracks (artist, album, track, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (artist, album, track) DO UPDATE SET last_seen = excluded.last_seen, plays = plays + 1
+		RETURNING id`,
+		artist, album, track, at.UTC(), at.UTC()).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO plays (track_id, at) VALUES (?, ?)", id, at.UTC()); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// RecordRead adds a reopening of the stored sections of a track.
+func (d *DB) RecordRead(ctx context.Context, trackID int64, at time.Time) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO reads (track_id, at) VALUES (?, ?)", trackID, at.UTC())
+	return err
+}
+
+// SaveSections replaces the generated sections of a track.
+func (d *DB) SaveSections(ctx context.Context, trackID int64, model string, sections []Section) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM sections WHERE track_id = ?", trackID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for i, s := range sections {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO sections (track_id, position, title, content, model, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			trackID, i, s.Title, s.Content, model, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Recent returns the last seen tracks, most recent first.
+func (d *DB) Recent(ctx context.Context, limit int) ([]Track, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT id, artist, album, track, first_seen, last_seen, plays
+		FROM tracks ORDER BY last_seen DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracks []Track
+	for rows.Next() {
+		var t Track
+		if err := rows.Scan(&t.ID, &t.Artist, &t.Album, &t.Track, &t.FirstSeen, &t.LastSeen, &t.Plays); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
+}
+
+// Sections returns the generated sections of a track in their order.
+func (d *DB) Sections(ctx context.Context, trackID int64) ([]Section, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT title, content FROM sections WHERE track_id = ? ORDER BY position", trackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []Section
+	for rows.Next() {
+		var s Section
+		if err := rows.Scan(&s.Title, &s.Content); err != nil {
+			return nil, err
+		}
+		sections = append(sections, s)
+	}
+	return sections, rows.Err()
+}
+
+// SavedSection returns the content of the section with the title of a track
+// and when it was generated, ok is false when it was never saved.
+func (d *DB) SavedSection(ctx context.Context, artist, album, track, title string) (content string, updated time.Time, ok bool, err error) {
+	err = d.db.QueryRowContext(ctx, `
+		SELECT s.content, s.updated_at FROM sections s JOIN tracks t ON t.id = s.track_id
+		WHERE t.artist = ? AND t.album = ? AND t.track = ? AND s.title = ?`,
+		artist, album, track, title).Scan(&content, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, false, nil
+	}
+	return content, updated, err == nil, err
+}
+
+// RecordUsage adds a provider request made for the track.
+func (d *DB) RecordUsage(ctx context.Context, artist, album, track, model string, promptTokens, completionTokens int, cost float64, at time.Time) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO usage (artist, album, track, model, prompt_tokens, completion_tokens, cost, at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		artist, album, track, model, promptTokens, completionTokens, cost, at.UTC())
+	return err
+}
+
+// UsageTotals returns the total of all the recorded requests.
+func (d *DB) UsageTotals(ctx context.Context) (Usage, error) {
+	return d.UsageSince(ctx, time.Time{})
+}
+
+// UsageSince returns the total of the requests made at since or later.
+func (d *DB) UsageSince(ctx context.Context, since time.Time) (Usage, error) {
+	var u Usage
+	err := d.db.QueryRowContext(ctx, `
+		SELECT count(*), coalesce(sum(prompt_tokens), 0), coalesce(sum(completion_tokens), 0), coalesce(sum(cost), 0)
+		FROM usage WHERE at >= ?`, since.UTC()).Scan(&u.Requests, &u.PromptTokens, &u.CompletionTokens, &u.Cost)
+	return u, err
+}
+
+// RecordQuizAnswer adds an answer to a quiz question about the album.
+func (d *DB) RecordQuizAnswer(ctx context.Context, artist, album, question string, correct bool, at time.Time) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO quiz_answers (artist, album, question, correct, at) VALUES (?, ?, ?, ?, ?)",
+		artist, album, question, correct, at.UTC())
+	return err
+}
+
+// QuizScore returns how many quiz questions were answered right out of all
+// the answered ones.
+func (d *DB) QuizScore(ctx context.Context) (correct, total int, err error) {
+	err = d.db.QueryRowContext(ctx, "SELECT coalesce(sum(correct), 0), count(*) FROM quiz_answers").Scan(&correct, &total)
+	return correct, total, err
+}
+
+// SaveBookmark bookmarks the track with note, replacing the note of a track
+// already bookmarked.
+func (d *DB) SaveBookmark(ctx context.Context, artist, album, track, note string, at time.Time) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO bookmarks (artist, album, track, note, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (artist, album, track) DO UPDATE SET note = excluded.note, updated_at = excluded.updated_at`,
+		artist, album, track, note, at.UTC(), at.UTC())
+	return err
+}
+
+// FindBookmark returns the bookmark of the track, ok is false when it is not
+// bookmarked.
+func (d *DB) FindBookmark(ctx context.Context, artist, album, track string) (b Bookmark, ok bool, err error) {
+	bookmarks, err := d.bookmarks(ctx, "WHERE b.artist = ? AND b.album = ? AND b.track = ?", artist, album, track)
+	if err != nil || len(bookmarks) == 0 {
+		return Bookmark{}, false, err
+	}
+	return bookmarks[0], true, nil
+}
+
+// Bookmarks returns all the bookmarks, the last updated first.
+func (d *DB) Bookmarks(ctx context.Context) ([]Bookmark, error) {
+	return d.bookmarks(ctx, "")
+}
+
+func (d *DB) bookmarks(ctx context.Context, where string, args ...any) ([]Bookmark, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT b.id, b.artist, b.album, b.track, b.note, coalesce(t.id, 0), b.created_at, b.updated_at
+		FROM bookmarks b LEFT JOIN tracks t USING (artist, album, track) `+where+`
+		ORDER BY b.updated_at DESC, b.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarks []Bookmark
+	for rows.Next() {
+		var b Bookmark
+		if err := rows.Scan(&b.ID, &b.Artist, &b.Album, &b.Track, &b.Note, &b.TrackID, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// DeleteBookmark removes a bookmark.
+func (d *DB) DeleteBookmark(ctx context.Context, id int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM bookmarks WHERE id = ?", id)
+	return err
+}
+
+// SessionGap is the pause between two plays that starts a new listening
+// session.
+const SessionGap = 30 * time.Minute
+
+// Stats are the plays of a period.
+type Stats struct {
+	Plays    int
+	Sessions int
+	// Artists, Albums and Tracks are the most played first, the albums have
+	// no track and the artists neither album.
+	Artists []Count
+	Albums  []Count
+	Tracks  []Count
+	// Reads are the tracks whose sections were reopened the most.
+	Reads []Count
+}
+
+// Count is how many times an artist, album or track was played or read.
+type Count struct {
+	Artist string
+	Album  string
+	Track  string
+	Count  int
+}
+
+// StatsSince returns the stats of the plays at since or later, with up to
+// limit artists, albums, tracks and reads.
+func (d *DB) StatsSince(ctx context.Context, since time.Time, limit int) (Stats, error) {
+	var s Stats
+	rows, err := d.db.QueryContext(ctx, "SELECT at FROM plays WHERE at >= ? ORDER BY at", since.UTC())
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+	var last time.Time
+	for rows.Next() {
+		var at time.Time
+		if err := rows.Scan(&at); err != nil {
+			return s, err
+		}
+		if s.Plays == 0 || at.Sub(last) > SessionGap {
+			s.Sessions++
+		}
+		s.Plays++
+		last = at
+	}
+	if err := rows.Err(); err != nil {
+		return s, err
+	}
+
+	for _, top := range []struct {
+		counts  *[]Count
+		table   string
+		columns string
+	}{
+		{&s.Artists, "plays", "t.artist, '', ''"},
+		{&s.Albums, "plays", "t.artist, t.album, ''"},
+		{&s.Tracks, "plays", "t.artist, t.album, t.track"},
+		{&s.Reads, "reads", "t.artist, t.album, t.track"},
+	} {
+		if *top.counts, err = d.counts(ctx, top.table, top.columns, since, limit); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// counts groups the rows of table, plays or reads, at since or later by the
+// columns of the track.
+func (d *DB) counts(ctx context.Context, table, columns string, since time.Time, limit int) ([]Count, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+columns+`, count(*) AS n FROM `+table+` p JOIN tracks t ON t.id = p.track_id
+		WHERE p.at >= ? GROUP BY `+columns+` ORDER BY n DESC, max(p.at) DESC LIMIT ?`, since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []Count
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.Artist, &c.Album, &c.Track, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// MatchStart and MatchEnd surround the words found in the snippet of a
+// Match.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// Match is a stored section that contains the words searched.
+type Match struct {
+	Track Track
+	// Section is the title of the section, Snippet the part of its content
+	// around the words.
+	Section string
+	Snippet string
+}
+
+// Search returns the stored sections that contain all the words of query,
+// the best matches first. The last word matches as a prefix, as it may still
+// be typed.
+func (d *DB) Search(ctx context.Context, query string, limit int) ([]Match, error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	// Quoted, the words are not read as the operators of the FTS syntax.
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	words[len(words)-1] += "*"
+
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT t.id, t.artist, t.album, t.track, t.first_seen, t.last_seen, t.plays,
+			sections_fts.title, snippet(sections_fts, 1, ?, ?, '…', 16)
+		FROM sections_fts JOIN tracks t ON t.id = sections_fts.track_id
+		WHERE sections_fts MATCH ? ORDER BY rank LIMIT ?`,
+		MatchStart, MatchEnd, strings.Join(words, " "), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var m Match
+		t := &m.Track
+		if err := rows.Scan(&t.ID, &t.Artist, &t.Album, &t.Track, &t.FirstSeen, &t.LastSeen, &t.Plays, &m.Section, &m.Snippet); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// Similar is an album of the history and how close its write-up is to a
+// query, from -1 to 1.
+type Similar struct {
+	// Track is the last track of the album played.
+	Track Track
+	Score float64
+}
+
+// PendingEmbeddings returns a track of every album whose sections have no
+// embedding of model yet, or changed since, up to limit. The track is the
+// one with the newest sections.
+func (d *DB) PendingEmbeddings(ctx context.Context, model string, limit int) ([]Track, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		WITH saved AS (SELECT track_id, max(updated_at) AS updated_at FROM sections GROUP BY track_id)
+		SELECT t.id, t.artist, t.album, t.track, t.first_seen, t.last_seen, t.plays
+		FROM tracks t JOIN saved s ON s.track_id = t.id
+		WHERE t.album != '' AND NOT EXISTS (
+			SELECT 1 FROM embeddings e
+			WHERE e.artist = t.artist AND e.album = t.album AND e.model = ? AND e.updated_at >= s.updated_at
+		)
+		ORDER BY s.updated_at DESC, t.id DESC`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracks []Track
+	seen := map[[2]string]bool{}
+	for rows.Next() && len(tracks) < limit {
+		var t Track
+		if err := rows.Scan(&t.ID, &t.Artist, &t.Album, &t.Track, &t.FirstSeen, &t.LastSeen, &t.Plays); err != nil {
+			return nil, err
+		}
+		if album := [2]string{t.Artist, t.Album}; !seen[album] {
+			seen[album] = true
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks, rows.Err()
+}
+
+// SaveEmbedding replaces the embedding of the write-up of an album made by
+// model.
+func (d *DB) SaveEmbedding(ctx context.Context, artist, album, model string, vector []float32) error {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO embeddings (artist, album, model, vector, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (artist, album, model) DO UPDATE SET vector = excluded.vector, updated_at = excluded.updated_at`,
+		artist, album, model, blob, time.Now().UTC())
+	return err
+}
+
+// SimilarAlbums returns the albums whose embedding of model is the closest
+// to vector by cosine similarity, up to limit.
+func (d *DB) SimilarAlbums(ctx context.Context, model string, vector []float32, limit int) ([]Similar, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT e.vector, t.id, t.artist, t.album, t.track, t.first_seen, t.last_seen, t.plays
+		FROM embeddings e JOIN tracks t ON t.id = (
+			SELECT id FROM tracks WHERE artist = e.artist AND album = e.album ORDER BY last_seen DESC, id DESC LIMIT 1
+		)
+		WHERE e.model = ?`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var similar []Similar
+	for rows.Next() {
+		var blob []byte
+		var s Similar
+		t := &s.Track
+		if err := rows.Scan(&blob, &t.ID, &t.Artist, &t.Album, &t.Track, &t.FirstSeen, &t.LastSeen, &t.Plays); err != nil {
+			return nil, err
+		}
+		s.Score = cosine(vector, blob)
+		similar = append(similar, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].Score > similar[j].Score })
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+// cosine is the cosine similarity of v and the vector stored in blob, 0 when
+// their lengths differ.
+func cosine(v []float32, blob []byte) float64 {
+	if len(blob) != 4*len(v) {
+		return 0
+	}
+	var dot, a, b float64
+	for i, x := range v {
+		y := float64(math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:])))
+		dot += float64(x) * y
+		a += float64(x) * float64(x)
+		b += y * y
+	}
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(a*b)
+}
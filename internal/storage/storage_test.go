@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	id, err := db.RecordPlay(ctx, "Radiohead", "OK Computer", "Airbag", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RecordPlay(ctx, "Björk", "Homogenic", "Jóga", start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	again, err := db.RecordPlay(ctx, "Radiohead", "OK Computer", "Airbag", start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != id {
+		t.Errorf("a second play created track %d, want %d", again, id)
+	}
+
+	if err := db.SaveSections(ctx, id, "gpt-3.5-turbo", []Section{{"Album review", "old"}}); err != nil {
+		t.Fatal(err)
+	}
+	sections := []Section{{"Album info", "Released in 1997"}, {"Album review", "A landmark"}}
+	if err := db.SaveSections(ctx, id, "gpt-3.5-turbo", sections); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// Reopening must not apply the migrations again.
+	db, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	recent, err := db.Recent(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].Track != "Airbag" || recent[0].Plays != 2 || !recent[0].FirstSeen.Equal(start) {
+		t.Errorf("got recent %+v", recent)
+	}
+
+	got, err := db.Sections(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != sections[0] || got[1] != sections[1] {
+		t.Errorf("got sections %+v", got)
+	}
+
+	content, updated, ok, err := db.SavedSection(ctx, "Radiohead", "OK Computer", "Airbag", "Album review")
+	if err != nil || !ok || content != "A landmark" || time.Since(updated) > time.Minute {
+		t.Errorf("got saved section %q of %v, %v, %v", content, updated, ok, err)
+	}
+	if _, _, ok, err := db.SavedSection(ctx, "Björk", "Homogenic", "Jóga", "Album review"); ok || err != nil {
+		t.Errorf("got a saved section of a track without sections: %v", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	u, err := db.UsageTotals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != (Usage{}) {
+		t.Errorf("got %+v without requests", u)
+	}
+
+	at := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	if err := db.RecordUsage(ctx, "Radiohead", "OK Computer", "Airbag", "gpt-4o", 1000, 500, 0.0075, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordUsage(ctx, "Björk", "Homogenic", "Jóga", "llama3", 200, 100, 0, at); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err = db.UsageTotals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != (Usage{Requests: 2, PromptTokens: 1200, CompletionTokens: 600, Cost: 0.0075}) {
+		t.Errorf("got %+v", u)
+	}
+
+	if err := db.RecordUsage(ctx, "Radiohead", "OK Computer", "Airbag", "gpt-4o", 10, 5, 0.0001, at.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	u, err = db.UsageSince(ctx, at.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Requests != 1 || u.PromptTokens != 10 {
+		t.Errorf("got %+v since the next day", u)
+	}
+}
+
+func TestQuizScore(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	at := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	for _, correct := range []bool{true, false, true} {
+		if err := db.RecordQuizAnswer(ctx, "Radiohead", "OK Computer", "Who produced the album?", correct, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	correct, total, err := db.QuizScore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if correct != 2 || total != 3 {
+		t.Errorf("got %d of %d", correct, total)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	at := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	id, err := db.RecordPlay(ctx, "Radiohead", "OK Computer", "Airbag", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveBookmark(ctx, "Radiohead", "OK Computer", "Airbag", "drum break at 0:40", at); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveBookmark(ctx, "Björk", "Homogenic", "Jóga", "", at.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again replaces the note and moves the bookmark to the top.
+	if err := db.SaveBookmark(ctx, "Radiohead", "OK Computer", "Airbag", "strings in the bridge", at.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := db.Bookmarks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("got %+v", bookmarks)
+	}
+	b := bookmarks[0]
+	if b.Track != "Airbag" || b.Note != "strings in the bridge" || b.TrackID != id || !b.CreatedAt.Equal(at) || !b.UpdatedAt.Equal(at.Add(2*time.Minute)) {
+		t.Errorf("got first bookmark %+v", b)
+	}
+	if bookmarks[1].Track != "Jóga" || bookmarks[1].TrackID != 0 {
+		t.Errorf("got second bookmark %+v", bookmarks[1])
+	}
+
+	found, ok, err := db.FindBookmark(ctx, "Björk", "Homogenic", "Jóga")
+	if err != nil || !ok || found.ID != bookmarks[1].ID {
+		t.Errorf("got %+v, %v, %v", found, ok, err)
+	}
+
+	if err := db.DeleteBookmark(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := db.FindBookmark(ctx, "Radiohead", "OK Computer", "Airbag"); err != nil || ok {
+		t.Errorf("the deleted bookmark is still there: %v, %v", ok, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	start := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	plays := []struct {
+		artist, album, track string
+		at                   time.Duration
+	}{
+		// Before the period.
+		{"Björk", "Homogenic", "Jóga", -48 * time.Hour},
+		{"Radiohead", "OK Computer", "Airbag", 0},
+		{"Radiohead", "OK Computer", "Paranoid Android", 5 * time.Minute},
+		{"Björk", "Homogenic", "Jóga", 10 * time.Minute},
+		// A second session.
+		{"Radiohead", "Kid A", "Idioteque", 2 * time.Hour},
+		{"Radiohead", "OK Computer", "Airbag", 2*time.Hour + 5*time.Minute},
+	}
+	var airbag int64
+	for _, p := range plays {
+		id, err := db.RecordPlay(ctx, p.artist, p.album, p.track, start.Add(p.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.track == "Airbag" {
+			airbag = id
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.RecordRead(ctx, airbag, start.Add(3*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := db.StatsSince(ctx, start, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Plays != 5 || s.Sessions != 2 {
+		t.Errorf("got %d plays in %d sessions, want 5 in 2", s.Plays, s.Sessions)
+	}
+	if len(s.Artists) != 2 || s.Artists[0] != (Count{Artist: "Radiohead", Count: 4}) || s.Artists[1] != (Count{Artist: "Björk", Count: 1}) {
+		t.Errorf("got artists %+v", s.Artists)
+	}
+	if len(s.Albums) != 2 || s.Albums[0] != (Count{Artist: "Radiohead", Album: "OK Computer", Count: 3}) {
+		t.Errorf("got albums %+v", s.Albums)
+	}
+	if len(s.Tracks) != 2 || s.Tracks[0] != (Count{Artist: "Radiohead", Album: "OK Computer", Track: "Airbag", Count: 2}) {
+		t.Errorf("got tracks %+v", s.Tracks)
+	}
+	if len(s.Reads) != 1 || s.Reads[0].Track != "Airbag" || s.Reads[0].Count != 2 {
+		t.Errorf("got reads %+v", s.Reads)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	at := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	can, err := db.RecordPlay(ctx, "Can", "Tago Mago", "Halleluhwah", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bjork, err := db.RecordPlay(ctx, "Björk", "Homogenic", "Jóga", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveSections(ctx, can, "gpt-4o", []Section{{"Album review", "A krautrock landmark of motorik grooves."}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveSections(ctx, bjork, "gpt-4o", []Section{{"Album review", "Strings and beats, a nod to krautrock."}, {"Song info", "Written for Iceland."}}); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := db.Search(ctx, "KRAUT", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %+v", matches)
+	}
+	if got := matches[0].Snippet; matches[0].Track.Artist != "Can" || got != "A "+MatchStart+"krautrock"+MatchEnd+" landmark of motorik grooves." {
+		t.Errorf("got the first match %+v", matches[0])
+	}
+
+	// Every word must be in the same section.
+	if matches, err := db.Search(ctx, "krautrock iceland", 10); err != nil || len(matches) != 0 {
+		t.Errorf("got %+v, %v for words of two sections", matches, err)
+	}
+	if matches, err := db.Search(ctx, `written "iceland`, 10); err != nil || len(matches) != 1 || matches[0].Section != "Song info" {
+		t.Errorf("got %+v, %v", matches, err)
+	}
+
+	// Saving the sections again replaces them in the index.
+	if err := db.SaveSections(ctx, can, "gpt-4o", []Section{{"Album review", "Jazz and funk."}}); err != nil {
+		t.Fatal(err)
+	}
+	if matches, err := db.Search(ctx, "krautrock", 10); err != nil || len(matches) != 1 || matches[0].Track.Artist != "Björk" {
+		t.Errorf("got %+v, %v after replacing the sections", matches, err)
+	}
+}
+
+func TestEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	at := time.Date(2023, 9, 1, 20, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i, info := range [][3]string{
+		{"Burial", "Untrue", "Archangel"},
+		{"Burial", "Untrue", "Ghost Hardware"},
+		{"Can", "Tago Mago", "Halleluhwah"},
+		{"Radiohead", "", "Creep"},
+	} {
+		id, err := db.RecordPlay(ctx, info[0], info[1], info[2], at.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveSections(ctx, id, "gpt-4o", []Section{{"Album review", info[1]}}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// One track per album, the one saved last, and none without an album.
+	pending, err := db.PendingEmbeddings(ctx, "small", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != ids[2] || pending[1].ID != ids[1] {
+		t.Fatalf("got pending %+v", pending)
+	}
+	if err := db.SaveEmbedding(ctx, "Burial", "Untrue", "small", []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveEmbedding(ctx, "Can", "Tago Mago", "small", []float32{0.6, 0.8}); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := db.PendingEmbeddings(ctx, "small", 10); err != nil || len(pending) != 0 {
+		t.Errorf("got pending %+v, %v after embedding", pending, err)
+	}
+	if pending, err := db.PendingEmbeddings(ctx, "large", 1); err != nil || len(pending) != 1 {
+		t.Errorf("got pending %+v, %v for another model", pending, err)
+	}
+
+	similar, err := db.SimilarAlbums(ctx, "small", []float32{0, 2}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 2 || similar[0].Track.Album != "Tago Mago" || similar[0].Score < 0.79 || similar[0].Score > 0.81 ||
+		similar[1].Track.ID != ids[1] || similar[1].Score != 0 {
+		t.Errorf("got similar %+v", similar)
+	}
+
+	// New sections of the album are embedded again.
+	if err := db.SaveSections(ctx, ids[0], "gpt-4o", []Section{{"Album review", "Night bus garage"}}); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := db.PendingEmbeddings(ctx, "small", 10); err != nil || len(pending) != 1 || pending[0].ID != ids[0] {
+		t.Errorf("got pending %+v, %v after new sections", pending, err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// journalDir is where a markdown file per day lists the tracks played, the
+// journal is off when it is empty. journalSummary adds a one line summary of
+// the track from the AI provider to the entries.
+var (
+	journalDir     string
+	journalSummary bool
+)
+
+func journalSummaryPrompt(info MusicInfo) string {
+	if info.isPodcast() {
+		return inLanguage(fmt.Sprintf("In one sentence, say what the episode %q of the podcast %q is about. Answer only with the sentence.", info.track, info.album))
+	}
+	return inLanguage(fmt.Sprintf("In one sentence, describe the song %q by %s from the album %q. Answer only with the sentence.", info.track, info.artist, info.album))
+}
+
+// journalEntry is the markdown list item of a track played at t.
+func journalEntry(info MusicInfo, t time.Time, summary string) string {
+	entry := fmt.Sprintf("- **%s** %s – %s", t.Format("15:04"), info.artistLine(), info.track)
+	if info.isPodcast() {
+		entry = fmt.Sprintf("- **%s** 🎙 %s – %s", t.Format("15:04"), info.album, info.track)
+	} else if info.album != "" {
+		entry += " · *" + info.album + "*"
+	}
+	if summary != "" {
+		entry += "\n  " + summary
+	}
+	return entry + "\n"
+}
+
+// appendJournal adds entry to the file of the day of t, a new file starts
+// with the date as heading.
+func appendJournal(t time.Time, entry string) error {
+	dir := expandHome(journalDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, t.Format("2006-01-02")+".md")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		entry = "# " + t.Format("2006-01-02") + "\n\n" + entry
+	}
+	if _, err := f.WriteString(entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeJournal logs the track once its info is loaded. Refreshing the same
+// track does not log it again.
+func (m *model) writeJournal(ctx context.Context, info MusicInfo) {
+	if journalDir == "" || info.track == "" {
+		return
+	}
+	m.mu.Lock()
+	if m.journaled == info {
+		m.mu.Unlock()
+		return
+	}
+	m.journaled = info
+	m.mu.Unlock()
+
+	played := time.Now()
+	var summary string
+	if journalSummary {
+		prompt := journalSummaryPrompt(info)
+		key := cacheKey(info, trackScope, chatModel, prompt)
+		answer, ok := cachedAnswer(ctx, key)
+		if !ok {
+			var err error
+			if answer, err = m.complete(ctx, chatModel, prompt); err == nil {
+				writeCache(key, answer)
+			}
+		}
+		summary, _, _ = strings.Cut(strings.TrimSpace(answer), "\n")
+	}
+
+	if err := appendJournal(played, journalEntry(info, played, summary)); err != nil {
+		m.mu.Lock()
+		m.errMsg = "  journal: " + err.Error()
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalEntry(t *testing.T) {
+	played := time.Date(2024, 5, 21, 9, 5, 0, 0, time.UTC)
+	if got, want := journalEntry(testTrack, played, ""), "- **09:05** Radiohead – Airbag · *OK Computer*\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	episode := MusicInfo{album: "Song Exploder", track: "Radiohead - Airbag"}
+	if got, want := journalEntry(episode, played, "How the song was made."), "- **09:05** 🎙 Song Exploder – Radiohead - Airbag\n  How the song was made.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(dir string, summary bool) { journalDir, journalSummary = dir, summary }(journalDir, journalSummary)
+	journalDir, journalSummary = t.TempDir(), true
+
+	m.writeJournal(context.Background(), testTrack)
+	// Refreshing the track does not log it twice.
+	m.writeJournal(context.Background(), testTrack)
+	next := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	m.writeJournal(context.Background(), next)
+
+	data, err := os.ReadFile(filepath.Join(journalDir, time.Now().Format("2006-01-02")+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := string(data)
+	if !strings.HasPrefix(journal, "# "+time.Now().Format("2006-01-02")+"\n\n- **") {
+		t.Errorf("journal does not start with the date:\n%s", journal)
+	}
+	if strings.Count(journal, "Airbag ·") != 1 || !strings.Contains(journal, "Paranoid Android ·") {
+		t.Errorf("journal has the wrong tracks:\n%s", journal)
+	}
+	if !strings.Contains(journal, "\n  stub answer for: In one sentence, describe the song \"Airbag\"") {
+		t.Errorf("journal has no summary:\n%s", journal)
+	}
+}
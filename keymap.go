@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// keyMap holds the key bindings of the main view. Tabs are always selected
+// with the number keys and the history, bookmarks, up next, playlist, link
+// and artist pickers and search prompt use fixed keys.
+type keyMap struct {
+	Quit            key.Binding
+	Refresh         key.Binding
+	RefreshUncached key.Binding
+	RetrySection    key.Binding
+	CancelSection   key.Binding
+	EditPrompt      key.Binding
+	PlayPause       key.Binding
+	Next            key.Binding
+	Previous        key.Binding
+	VolumeUp        key.Binding
+	VolumeDown      key.Binding
+	Shuffle         key.Binding
+	Repeat          key.Binding
+	SwitchPlayer    key.Binding
+	NextTab         key.Binding
+	PrevTab         key.Binding
+	Tabs            key.Binding
+	Lyrics          key.Binding
+	Translate       key.Binding
+	Queue           key.Binding
+	Library         key.Binding
+	Catalog         key.Binding
+	Tracks          key.Binding
+	ListenNext      key.Binding
+	History         key.Binding
+	Stats           key.Binding
+	SearchHistory   key.Binding
+	Bookmark        key.Binding
+	Bookmarks       key.Binding
+	OpenLink        key.Binding
+	SimilarArtists  key.Binding
+	Chat            key.Binding
+	Ask             key.Binding
+	DeepDive        key.Binding
+	SwitchArtist    key.Binding
+	PinAlbum        key.Binding
+	Quiz            key.Binding
+	Export          key.Binding
+	Share           key.Binding
+	Vault           key.Binding
+	Palette         key.Binding
+	Help            key.Binding
+	Copy            key.Binding
+	CopyAll         key.Binding
+	Search          key.Binding
+	NextMatch       key.Binding
+	PrevMatch       key.Binding
+	ClearSearch     key.Binding
+	Raw             key.Binding
+	Read            key.Binding
+	MarkdownStyle   key.Binding
+	Up              key.Binding
+	Down            key.Binding
+	PageUp          key.Binding
+	PageDown        key.Binding
+	HalfPageUp      key.Binding
+	HalfPageDown    key.Binding
+	Top             key.Binding
+	Bottom          key.Binding
+}
+
+// keys are the bindings in use, the keys section of the config file changes
+// them.
+var keys = defaultKeyMap()
+
+func newBinding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keysHelp(keys), desc))
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Quit:            newBinding("Quit", "q", "ctrl+c"),
+		Refresh:         newBinding("Refresh", "ctrl+r"),
+		RefreshUncached: newBinding("Refresh uncached", "R"),
+		RetrySection:    newBinding("Regenerate section", "r"),
+		CancelSection:   newBinding("Cancel sections", "X"),
+		EditPrompt:      newBinding("Edit prompt", "ctrl+e"),
+		PlayPause:       newBinding("Play/Pause", " "),
+		Next:            newBinding("Next track", "n"),
+		Previous:        newBinding("Previous track", "p"),
+		VolumeUp:        newBinding("Volume up", "+", "="),
+		VolumeDown:      newBinding("Volume down", "-"),
+		Shuffle:         newBinding("Shuffle", "z"),
+		Repeat:          newBinding("Repeat", "x"),
+		SwitchPlayer:    newBinding("Switch player", "D"),
+		NextTab:         newBinding("Next tab", "tab"),
+		PrevTab:         newBinding("Previous tab", "shift+tab"),
+		Tabs:            newBinding("Tabs", "1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		Lyrics:          newBinding("Lyrics", "l"),
+		Translate:       newBinding("Translate lyrics", "t"),
+		Queue:           newBinding("Up next", "U"),
+		Library:         newBinding("Playlists", "L"),
+		Catalog:         newBinding("Search Spotify", "C"),
+		Tracks:          newBinding("Album tracks", "T"),
+		ListenNext:      newBinding("Listen next", "w"),
+		History:         newBinding("History", "h"),
+		Stats:           newBinding("Listening stats", "H"),
+		SearchHistory:   newBinding("Search history", "F"),
+		Bookmark:        newBinding("Bookmark", "s"),
+		Bookmarks:       newBinding("Bookmarks", "S"),
+		OpenLink:        newBinding("Open link", "o"),
+		SimilarArtists:  newBinding("Similar artists", "a"),
+		Chat:            newBinding("Chat", "c"),
+		Ask:             newBinding("Ask about this", "i"),
+		DeepDive:        newBinding("Artist deep dive", "A"),
+		SwitchArtist:    newBinding("Switch artist", "W"),
+		PinAlbum:        newBinding("Pin album", "P"),
+		Quiz:            newBinding("Quiz", "Q"),
+		Export:          newBinding("Export", "e"),
+		Share:           newBinding("Share card", "E"),
+		Vault:           newBinding("Notes vault", "V"),
+		Palette:         newBinding("Commands", "ctrl+p"),
+		Help:            newBinding("Help", "?"),
+		Copy:            newBinding("Copy tab", "y"),
+		CopyAll:         newBinding("Copy all", "Y"),
+		Search:          newBinding("Search", "/"),
+		NextMatch:       newBinding("Next match", "n"),
+		PrevMatch:       newBinding("Previous match", "N"),
+		ClearSearch:     newBinding("Clear search", "esc"),
+		Raw:             newBinding("Raw/Rendered", "m"),
+		Read:            newBinding("Reading mode", "O"),
+		MarkdownStyle:   newBinding("Markdown style", "M"),
+		Up:              newBinding("Up", "up", "k"),
+		Down:            newBinding("Down", "down", "j"),
+		PageUp:          newBinding("Page up", "pgup", "b"),
+		PageDown:        newBinding("Page down", "pgdown", "f"),
+		HalfPageUp:      newBinding("Half page up", "u", "ctrl+u"),
+		HalfPageDown:    newBinding("Half page down", "d", "ctrl+d"),
+		Top:             newBinding("Top", "g", "home"),
+		Bottom:          newBinding("Bottom", "G", "end"),
+	}
+}
+
+// named returns the bindings that can be changed in the config file.
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":             &k.Quit,
+		"refresh":          &k.Refresh,
+		"refresh_uncached": &k.RefreshUncached,
+		"retry_section":    &k.RetrySection,
+		"cancel_section":   &k.CancelSection,
+		"edit_prompt":      &k.EditPrompt,
+		"play_pause":       &k.PlayPause,
+		"next":             &k.Next,
+		"previous":         &k.Previous,
+		"volume_up":        &k.VolumeUp,
+		"volume_down":      &k.VolumeDown,
+		"shuffle":          &k.Shuffle,
+		"repeat":           &k.Repeat,
+		"switch_player":    &k.SwitchPlayer,
+		"next_tab":         &k.NextTab,
+		"prev_tab":         &k.PrevTab,
+		"lyrics":           &k.Lyrics,
+		"translate":        &k.Translate,
+		"queue":            &k.Queue,
+		"library":          &k.Library,
+		"catalog":          &k.Catalog,
+		"tracks":           &k.Tracks,
+		"listen_next":      &k.ListenNext,
+		"history":          &k.History,
+		"stats":            &k.Stats,
+		"search_history":   &k.SearchHistory,
+		"bookmark":         &k.Bookmark,
+		"bookmarks":        &k.Bookmarks,
+		"open_link":        &k.OpenLink,
+		"similar_artists":  &k.SimilarArtists,
+		"chat":             &k.Chat,
+		"ask":              &k.Ask,
+		"deep_dive":        &k.DeepDive,
+		"switch_artist":    &k.SwitchArtist,
+		"pin_album":        &k.PinAlbum,
+		"quiz":             &k.Quiz,
+		"export":           &k.Export,
+		"share":            &k.Share,
+		"vault":            &k.Vault,
+		"palette":          &k.Palette,
+		"help":             &k.Help,
+		"copy":             &k.Copy,
+		"copy_all":         &k.CopyAll,
+		"search":           &k.Search,
+		"next_match":       &k.NextMatch,
+		"prev_match":       &k.PrevMatch,
+		"clear_search":     &k.ClearSearch,
+		"raw":              &k.Raw,
+		"read":             &k.Read,
+		"markdown_style":   &k.MarkdownStyle,
+		"up":               &k.Up,
+		"down":             &k.Down,
+		"page_up":          &k.PageUp,
+		"page_down":        &k.PageDown,
+		"half_page_up":     &k.HalfPageUp,
+		"half_page_down":   &k.HalfPageDown,
+		"top":              &k.Top,
+		"bottom":           &k.Bottom,
+	}
+}
+
+func keyActions() []string {
+	var names []string
+	for name := range (&keyMap{}).named() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyGroup is a category of the help overlay.
+type keyGroup struct {
+	title    string
+	bindings []*key.Binding
+}
+
+// groups sorts every binding into the categories of the help overlay.
+func (k *keyMap) groups() []keyGroup {
+	return []keyGroup{
+		{"Navigation", []*key.Binding{&k.Up, &k.Down, &k.PageUp, &k.PageDown, &k.HalfPageUp, &k.HalfPageDown, &k.Top, &k.Bottom,
+			&k.NextTab, &k.PrevTab, &k.Tabs, &k.Lyrics, &k.Search, &k.NextMatch, &k.PrevMatch, &k.ClearSearch, &k.Raw,
+			&k.MarkdownStyle, &k.Read}},
+		{"Playback", []*key.Binding{&k.PlayPause, &k.Next, &k.Previous, &k.VolumeUp, &k.VolumeDown, &k.Shuffle, &k.Repeat, &k.SwitchPlayer,
+			&k.Tracks, &k.ListenNext, &k.Queue, &k.Library, &k.Catalog}},
+		{"AI", []*key.Binding{&k.Chat, &k.Ask, &k.Translate, &k.SimilarArtists, &k.DeepDive, &k.SwitchArtist, &k.PinAlbum, &k.Quiz, &k.RetrySection, &k.CancelSection,
+			&k.EditPrompt, &k.Refresh, &k.RefreshUncached}},
+		{"Export", []*key.Binding{&k.Export, &k.Share, &k.Vault, &k.Copy, &k.CopyAll, &k.OpenLink, &k.Bookmark, &k.Bookmarks, &k.History, &k.Stats, &k.SearchHistory}},
+		{"General", []*key.Binding{&k.Palette, &k.Help, &k.Quit}},
+	}
+}
+
+// rebind replaces the keys of the named bindings.
+func (k *keyMap) rebind(bindings map[string][]string) error {
+	named := k.named()
+	for name, bindingKeys := range bindings {
+		b, ok := named[name]
+		if !ok {
+			return fmt.Errorf("unknown key action %q, use %s", name, strings.Join(keyActions(), ", "))
+		}
+		if len(bindingKeys) == 0 {
+			return fmt.Errorf("key action %q needs at least one key", name)
+		}
+		b.SetKeys(bindingKeys...)
+		b.SetHelp(keysHelp(bindingKeys), b.Help().Desc)
+	}
+	return nil
+}
+
+// viewport returns the scrolling bindings for the viewport.
+func (k keyMap) viewport() viewport.KeyMap {
+	return viewport.KeyMap{
+		Up:           k.Up,
+		Down:         k.Down,
+		PageUp:       k.PageUp,
+		PageDown:     k.PageDown,
+		HalfPageUp:   k.HalfPageUp,
+		HalfPageDown: k.HalfPageDown,
+	}
+}
+
+var keyNames = map[string]string{" ": "space", "up": "↑", "down": "↓"}
+
+// keysHelp is how keys are written in the help.
+func keysHelp(keys []string) string {
+	if len(keys) == 9 && keys[0] == "1" && keys[8] == "9" {
+		return "1-9"
+	}
+
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		if name, ok := keyNames[k]; ok {
+			k = name
+		}
+		names[i] = k
+	}
+	return strings.Join(names, "/")
+}
+
+// helpItem formats bindings sharing a description, such as up and down.
+func helpItem(desc string, bindings ...key.Binding) string {
+	var keys []string
+	for _, b := range bindings {
+		keys = append(keys, b.Help().Key)
+	}
+	return strings.Join(keys, "/") + ": " + tr(desc)
+}
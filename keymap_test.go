@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestRebindKeys(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { keys = defaultKeyMap() }()
+	if err := keys.rebind(map[string][]string{"raw": {"v"}, "refresh": {"f5", "ctrl+r"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(keyRune('m'))
+	if m.showRaw {
+		t.Error("m still toggles the raw view")
+	}
+	m.Update(keyRune('v'))
+	if !m.showRaw {
+		t.Error("v does not toggle the raw view")
+	}
+
+	if help := m.helpView(); !strings.Contains(help, "space/n/p: Play/Next/Prev") {
+		t.Errorf("help does not include the playback keys: %s", help)
+	}
+	m.Update(keyRune('?'))
+	help := ansiPattern.ReplaceAllString(m.View(), "")
+	for _, want := range []string{"v          Raw/Rendered", "f5/ctrl+r  Refresh", "n      Next track"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help overlay does not include %q:\n%s", want, help)
+		}
+	}
+	m.Update(keyRune('?'))
+	if m.showHelp {
+		t.Error("? does not close the help overlay")
+	}
+
+	// Every binding is in a category of the help overlay.
+	grouped := map[*key.Binding]bool{}
+	for _, g := range keys.groups() {
+		for _, b := range g.bindings {
+			grouped[b] = true
+		}
+	}
+	for name, b := range keys.named() {
+		if !grouped[b] {
+			t.Errorf("%s is not in the help overlay", name)
+		}
+	}
+
+	err := keys.rebind(map[string][]string{"dance": {"x"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown key action "dance"`) {
+		t.Errorf("got error %v", err)
+	}
+}
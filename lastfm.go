@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var lastfmURL = "https://ws.audioscrobbler.com/2.0/"
+
+// lastfmAPIKey enables the Last.fm section, lastfmUser adds the scrobble
+// counts of that account.
+var (
+	lastfmAPIKey = os.Getenv("LASTFM_API_KEY")
+	lastfmUser   string
+)
+
+var errNoLastfm = errors.New("not found on Last.fm")
+
+// Last.fm error codes, it answers most errors with a 200 status.
+const (
+	lastfmNotFound          = 6
+	lastfmUnauthorizedToken = 14
+	lastfmRateLimit         = 29
+)
+
+// getLastfmStats is replaced in tests.
+var getLastfmStats = fetchLastfmStats
+
+// LastfmStats are the Last.fm counts of the track, the album and the artist.
+type LastfmStats struct {
+	Rows []LastfmCounts
+	Tags []string
+	URL  string
+}
+
+type LastfmCounts struct {
+	Name          string `json:"-"`
+	Listeners     string `json:"listeners"`
+	Playcount     string `json:"playcount"`
+	UserPlaycount string `json:"userplaycount"`
+}
+
+type lastfmTags struct {
+	Tag []struct {
+		Name string `json:"name"`
+	} `json:"tag"`
+}
+
+// lastfmGet calls an API method and decodes its answer into v.
+func lastfmGet(ctx context.Context, method string, params url.Values, v any) error {
+	params.Set("method", method)
+	params.Set("api_key", lastfmAPIKey)
+	params.Set("format", "json")
+	params.Set("autocorrect", "1")
+	if lastfmUser != "" {
+		params.Set("username", lastfmUser)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lastfmURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return lastfmDo(req, v)
+}
+
+// lastfmDo sends an API request and decodes its answer into v.
+func lastfmDo(req *http.Request, v any) error {
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(resp.StatusCode, "last.fm: status code %d", resp.StatusCode)
+		}
+		return err
+	}
+
+	var apiErr struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &apiErr)
+	switch {
+	case apiErr.Error == lastfmUnauthorizedToken:
+		return errLastfmUnauthorized
+	case apiErr.Error == lastfmNotFound:
+		return errNoLastfm
+	case apiErr.Error == lastfmRateLimit:
+		return newStatusError(http.StatusTooManyRequests, "last.fm: %s", apiErr.Message)
+	case apiErr.Error != 0:
+		return fmt.Errorf("last.fm: %s", apiErr.Message)
+	case resp.StatusCode != http.StatusOK:
+		return newStatusError(resp.StatusCode, "last.fm: status code %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// fetchLastfmStats reads the counts of the track, the album and the artist.
+// The tags are the ones of the most specific of them.
+func fetchLastfmStats(ctx context.Context, info MusicInfo) (*LastfmStats, error) {
+	stats := &LastfmStats{}
+
+	if info.track != "" {
+		var r struct {
+			Track struct {
+				LastfmCounts
+				URL     string     `json:"url"`
+				TopTags lastfmTags `json:"toptags"`
+			} `json:"track"`
+		}
+		err := lastfmGet(ctx, "track.getInfo", url.Values{"artist": {info.artist}, "track": {info.track}}, &r)
+		if err != nil && !errors.Is(err, errNoLastfm) {
+			return nil, err
+		}
+		if err == nil {
+			stats.add("Track", r.Track.LastfmCounts, r.Track.TopTags, r.Track.URL)
+		}
+	}
+
+	if info.album != "" {
+		var r struct {
+			Album struct {
+				LastfmCounts
+				URL  string     `json:"url"`
+				Tags lastfmTags `json:"tags"`
+			} `json:"album"`
+		}
+		err := lastfmGet(ctx, "album.getInfo", url.Values{"artist": {info.artist}, "album": {info.album}}, &r)
+		if err != nil && !errors.Is(err, errNoLastfm) {
+			return nil, err
+		}
+		if err == nil {
+			stats.add("Album", r.Album.LastfmCounts, r.Album.Tags, r.Album.URL)
+		}
+	}
+
+	var r struct {
+		Artist struct {
+			URL   string       `json:"url"`
+			Stats LastfmCounts `json:"stats"`
+			Tags  lastfmTags   `json:"tags"`
+		} `json:"artist"`
+	}
+	err := lastfmGet(ctx, "artist.getInfo", url.Values{"artist": {info.artist}}, &r)
+	if err != nil && !errors.Is(err, errNoLastfm) {
+		return nil, err
+	}
+	if err == nil {
+		stats.add("Artist", r.Artist.Stats, r.Artist.Tags, r.Artist.URL)
+	}
+
+	if len(stats.Rows) == 0 {
+		return nil, errNoLastfm
+	}
+	return stats, nil
+}
+
+func (s *LastfmStats) add(name string, counts LastfmCounts, tags lastfmTags, url string) {
+	counts.Name = name
+	s.Rows = append(s.Rows, counts)
+	if len(s.Tags) == 0 {
+		for _, t := range tags.Tag {
+			s.Tags = append(s.Tags, t.Name)
+		}
+	}
+	if s.URL == "" {
+		s.URL = url
+	}
+}
+
+// lastfmSection renders the Last.fm counts of the current track.
+func lastfmSection(ctx context.Context, info MusicInfo) (string, error) {
+	stats, err := getLastfmStats(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return stats.markdown(), nil
+}
+
+// markdown renders the counts as a table, with a column for the scrobbles
+// of the user when there is one.
+func (s *LastfmStats) markdown() string {
+	var b strings.Builder
+
+	if lastfmUser != "" {
+		fmt.Fprintf(&b, "| | Listeners | Plays | Plays by %s |\n| --- | --- | --- | --- |\n", tableCell(lastfmUser))
+	} else {
+		b.WriteString("| | Listeners | Plays |\n| --- | --- | --- |\n")
+	}
+	for _, row := range s.Rows {
+		fmt.Fprintf(&b, "| %s | %s | %s |", row.Name, formatCount(row.Listeners), formatCount(row.Playcount))
+		if lastfmUser != "" {
+			user := row.UserPlaycount
+			if user == "" {
+				user = "0"
+			}
+			fmt.Fprintf(&b, " %s |", formatCount(user))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(&b, "\n**Tags:** %s\n", strings.Join(s.Tags, ", "))
+	}
+	if s.URL != "" {
+		fmt.Fprintf(&b, "\n*Source: [Last.fm](%s)*", s.URL)
+	}
+	return b.String()
+}
+
+// formatCount adds thousands separators to a number, anything else is
+// returned as is.
+func formatCount(s string) string {
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return s
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchLastfmStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("api_key") != "key" || q.Get("username") != "thom" {
+			t.Errorf("got query %q", r.URL.RawQuery)
+		}
+		switch q.Get("method") {
+		case "track.getInfo":
+			w.Write([]byte(`{"track":{"name":"Airbag","listeners":"1234567","playcount":"9876543","userplaycount":"42",
+				"url":"https://www.last.fm/music/Radiohead/_/Airbag","toptags":{"tag":[{"name":"alternative"},{"name":"rock"}]}}}`))
+		case "album.getInfo":
+			w.Write([]byte(`{"error":6,"message":"Album not found"}`))
+		case "artist.getInfo":
+			w.Write([]byte(`{"artist":{"stats":{"listeners":"7000000","playcount":"900000000"},"tags":{"tag":[{"name":"british"}]}}}`))
+		}
+	}))
+	defer server.Close()
+
+	defer func(u, key, user string) { lastfmURL, lastfmAPIKey, lastfmUser = u, key, user }(lastfmURL, lastfmAPIKey, lastfmUser)
+	lastfmURL, lastfmAPIKey, lastfmUser = server.URL, "key", "thom"
+
+	stats, err := fetchLastfmStats(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := stats.markdown()
+	for _, want := range []string{
+		"| | Listeners | Plays | Plays by thom |",
+		"| Track | 1,234,567 | 9,876,543 | 42 |",
+		"| Artist | 7,000,000 | 900,000,000 | 0 |",
+		"**Tags:** alternative, rock",
+		"https://www.last.fm/music/Radiohead/_/Airbag",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown does not include %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "| Album |") {
+		t.Errorf("unknown album has a row:\n%s", got)
+	}
+}
+
+func TestLastfmRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":29,"message":"Rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	defer func(u string) { lastfmURL = u }(lastfmURL)
+	lastfmURL = server.URL
+
+	_, err := fetchLastfmStats(context.Background(), testTrack)
+	if !retryable(err) {
+		t.Errorf("%v is not retried", err)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	for in, want := range map[string]string{"0": "0", "999": "999", "1000": "1,000", "123456": "123,456", "n/a": "n/a"} {
+		if got := formatCount(in); got != want {
+			t.Errorf("formatCount(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
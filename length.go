@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// answerLength is how long the AI sections are: short, medium, long or a
+// number of characters. Empty leaves it to the model.
+var answerLength string
+
+// sectionLengths are the lengths of single sections by key, they win over
+// answerLength.
+var sectionLengths map[string]string
+
+// lengthKeys are the sections written by the AI provider, the ones a length
+// applies to besides the custom prompts.
+var lengthKeys = []string{"album", "review", "comparison", "song", "bio", "members", "discography", "trivia", "show", "episode", "hosts"}
+
+// lengthPreset is what a named length asks for. The answers are cut at chars,
+// a little past the words asked for, as the models do not count well.
+type lengthPreset struct {
+	instruction string
+	chars       int
+}
+
+var lengthPresets = map[string]lengthPreset{
+	"short":  {instruction: "Keep the answer short, under 120 words.", chars: 1000},
+	"medium": {instruction: "Keep the answer to about 300 words.", chars: 2400},
+	"long":   {instruction: "Write an in-depth answer of up to 800 words.", chars: 6400},
+}
+
+// lengthSetting reads a length of the config file, a name or a number.
+type lengthSetting string
+
+func (l *lengthSetting) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*l = lengthSetting(v)
+	case int64:
+		*l = lengthSetting(strconv.FormatInt(v, 10))
+	default:
+		return fmt.Errorf("unknown length %v, use short, medium, long or a number of characters", v)
+	}
+	return nil
+}
+
+// parseLength reads a length setting. It returns the instruction added to the
+// prompt and the characters the answer is cut at, zero for no length.
+func parseLength(length string) (string, int, error) {
+	if length == "" {
+		return "", 0, nil
+	}
+	if p, ok := lengthPresets[length]; ok {
+		return p.instruction, p.chars, nil
+	}
+	chars, err := strconv.Atoi(length)
+	if err != nil || chars <= 0 {
+		return "", 0, fmt.Errorf("unknown length %q, use short, medium, long or a number of characters", length)
+	}
+	return fmt.Sprintf("Keep the answer under %d characters.", chars), chars, nil
+}
+
+// sectionLength is the length setting of the section with key.
+func sectionLength(key string) string {
+	if l, ok := sectionLengths[key]; ok {
+		return l
+	}
+	return answerLength
+}
+
+// withLength asks for the answer to prompt in the length of the section.
+// The settings were checked at start, a bad one adds nothing.
+func withLength(key, prompt string) string {
+	instruction, _, _ := parseLength(sectionLength(key))
+	if instruction == "" {
+		return prompt
+	}
+	return prompt + " " + instruction
+}
+
+// trimAnswer cuts an answer of the section with key that came back longer
+// than its length, at the last paragraph, sentence or word that fits.
+func trimAnswer(key, content string) string {
+	_, chars, _ := parseLength(sectionLength(key))
+	if chars == 0 || utf8.RuneCountInString(content) <= chars {
+		return content
+	}
+
+	// The ellipsis counts too.
+	n := chars - 2
+	if n < 1 {
+		n = chars
+	}
+	cut := string([]rune(content)[:n])
+	// A boundary in the first half would drop most of the answer, the next
+	// kind is tried then.
+	for _, sep := range []string{"\n\n", ". ", ".\n", " "} {
+		if i := strings.LastIndex(cut, sep); i > len(cut)/2 {
+			return strings.TrimSpace(cut[:i+len(strings.TrimRight(sep, " \n"))]) + " …"
+		}
+	}
+	return strings.TrimSpace(cut) + "…"
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLength(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	answerLength, sectionLengths = "short", map[string]string{"review": "60"}
+	defer func() { answerLength, sectionLengths = "", nil }()
+
+	m.getInfo(context.Background())
+
+	if album := m.sections[0].Content; !strings.HasSuffix(album, " Keep the answer short, under 120 words.") {
+		t.Errorf("the album info is not asked short: %q", album)
+	}
+	review := m.sections[1].Content
+	if utf8.RuneCountInString(review) > 60 || !strings.HasSuffix(review, "…") {
+		t.Errorf("the review is not cut at 60 characters: %q", review)
+	}
+	if tracklist := m.sections[4]; strings.Contains(tracklist.Content, "Keep the answer") {
+		t.Errorf("the tracklist does not come from the AI provider: %q", tracklist.Content)
+	}
+}
+
+func TestTrimAnswer(t *testing.T) {
+	sectionLengths = map[string]string{"bio": "40"}
+	defer func() { sectionLengths = nil }()
+
+	for content, want := range map[string]string{
+		"A short bio.": "A short bio.",
+		"They formed in Abingdon. They signed to Parlophone in 1991.": "They formed in Abingdon. …",
+		"First paragraph of the bio.\n\nSecond one here.":             "First paragraph of the bio. …",
+		"Abingdon Oxfordshire Parlophone Abbey Road Studios":          "Abingdon Oxfordshire Parlophone Abbey …",
+		"Abingdon-Oxfordshire-Parlophone-Abbey-Road-Studios":          "Abingdon-Oxfordshire-Parlophone-Abbey-…",
+	} {
+		got := trimAnswer("bio", content)
+		if got != want {
+			t.Errorf("trimAnswer(%q) = %q, want %q", content, got, want)
+		}
+		if utf8.RuneCountInString(got) > 40 {
+			t.Errorf("trimAnswer(%q) is longer than 40 characters", content)
+		}
+	}
+	if got := trimAnswer("review", "Not cut without a length."); got != "Not cut without a length." {
+		t.Errorf("the review was cut: %q", got)
+	}
+}
+
+func TestLoadConfigLengths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"length = \"short\"\n[lengths]\nreview = \"long\"\nbio = 600\n":                                   "",
+		"[[prompts]]\nkey = \"samples\"\ntitle = \"Samples\"\nprompt = \"x\"\n[lengths]\nsamples = 300\n": "",
+		"length = \"tiny\"\n":          "unknown length \"tiny\"",
+		"[lengths]\nbio = -5\n":        "lengths bio: unknown length",
+		"[lengths]\ntracklist = 500\n": "not written by the AI provider",
+		"[lengths]\nreview = 1.5\n":    "unknown length 1.5",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
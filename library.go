@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// getSpotifyPlaylists, getSpotifyPlaylistTracks and playSpotifyTrack are
+// replaced in tests.
+var (
+	getSpotifyPlaylists      = fetchSpotifyPlaylists
+	getSpotifyPlaylistTracks = fetchSpotifyPlaylistTracks
+	playSpotifyTrack         = startSpotifyTrack
+)
+
+// spotifyPlaylist is a playlist of the account.
+type spotifyPlaylist struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	URI    string `json:"uri"`
+	Tracks struct {
+		Total int `json:"total"`
+	} `json:"tracks"`
+}
+
+// libraryTrack is a track of a playlist.
+type libraryTrack struct {
+	info MusicInfo
+	uri  string
+}
+
+type libraryMsg struct {
+	playlists []spotifyPlaylist
+	err       error
+}
+
+type libraryTracksMsg struct {
+	playlist spotifyPlaylist
+	tracks   []libraryTrack
+	err      error
+}
+
+type libraryPlayMsg struct {
+	info MusicInfo
+	err  error
+}
+
+// libraryView is the playlist browser, it lists the playlists and then the
+// tracks of the open one.
+type libraryView struct {
+	loading   bool
+	err       string
+	playlists []spotifyPlaylist
+	// playlist is the open playlist, nil while choosing one.
+	playlist *spotifyPlaylist
+	tracks   []libraryTrack
+	filter   string
+	cursor   int
+	// backFilter and backCursor are the ones of the playlists, kept while a
+	// playlist is open.
+	backFilter string
+	backCursor int
+}
+
+func fetchSpotifyPlaylists(ctx context.Context) ([]spotifyPlaylist, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+	var playlists []spotifyPlaylist
+	path := "/me/playlists?limit=50"
+	for path != "" {
+		var page struct {
+			Items []spotifyPlaylist `json:"items"`
+			Next  string            `json:"next"`
+		}
+		if err := api.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+		playlists = append(playlists, page.Items...)
+		path = strings.TrimPrefix(page.Next, spotifyAPIURL)
+	}
+	return playlists, nil
+}
+
+func fetchSpotifyPlaylistTracks(ctx context.Context, id string) ([]libraryTrack, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+	var tracks []libraryTrack
+	path := "/playlists/" + url.PathEscape(id) + "/tracks?limit=100"
+	for path != "" {
+		var page struct {
+			Items []struct {
+				IsLocal bool `json:"is_local"`
+				Track   *struct {
+					Type    string `json:"type"`
+					URI     string `json:"uri"`
+					Name    string `json:"name"`
+					Artists []struct {
+						Name string `json:"name"`
+					} `json:"artists"`
+					Album struct {
+						Name string `json:"name"`
+					} `json:"album"`
+				} `json:"track"`
+			} `json:"items"`
+			Next string `json:"next"`
+		}
+		if err := api.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			// Local files and removed tracks can not be played from the API.
+			t := item.Track
+			if item.IsLocal || t == nil || t.Type != "track" || len(t.Artists) == 0 {
+				continue
+			}
+			info := MusicInfo{artist: t.Artists[0].Name, album: cleanAlbumName(t.Album.Name, albumNoise), track: t.Name}
+			tracks = append(tracks, libraryTrack{info: info, uri: t.URI})
+		}
+		path = strings.TrimPrefix(page.Next, spotifyAPIURL)
+	}
+	return tracks, nil
+}
+
+// startSpotifyTrack plays the playlist from the track on the active device,
+// so the rest of the playlist follows.
+func startSpotifyTrack(ctx context.Context, playlist spotifyPlaylist, track libraryTrack) error {
+	body := map[string]any{"context_uri": playlist.URI, "offset": map[string]string{"uri": track.uri}}
+	return newSpotifyWebPlayer(spotifyClientID).send(ctx, http.MethodPut, "/me/player/play", body, nil)
+}
+
+// names are the lines of the list, the playlists or the tracks of the open
+// one.
+func (v *libraryView) names() []string {
+	var names []string
+	if v.playlist == nil {
+		for _, p := range v.playlists {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	for _, t := range v.tracks {
+		names = append(names, t.info.artist+" - "+t.info.track)
+	}
+	return names
+}
+
+// matches returns the indexes of the names that match the filter.
+func (v *libraryView) matches() []int {
+	var matches []int
+	for i, name := range v.names() {
+		if fuzzyMatch(v.filter, name) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// selected returns the index of the playlist or track under the cursor, or
+// -1.
+func (v *libraryView) selected() int {
+	if matches := v.matches(); v.cursor < len(matches) {
+		return matches[v.cursor]
+	}
+	return -1
+}
+
+// openLibrary shows the playlist browser, the playlists come back as a
+// libraryMsg.
+func (m *model) openLibrary() tea.Cmd {
+	if spotifyClientID == "" || !spotifyLoggedIn() {
+		m.errMsg = "  spotify: the playlists need spotify_client_id in the config file and stui -spotify-login"
+		return nil
+	}
+	m.library = &libraryView{loading: true}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		playlists, err := getSpotifyPlaylists(ctx)
+		return libraryMsg{playlists: playlists, err: err}
+	}
+}
+
+func (m *model) libraryLoaded(msg libraryMsg) {
+	v := m.library
+	if v == nil {
+		return
+	}
+	v.loading = false
+	if msg.err != nil {
+		v.err = msg.err.Error()
+		return
+	}
+	v.playlists = msg.playlists
+}
+
+func (m *model) libraryTracksLoaded(msg libraryTracksMsg) {
+	v := m.library
+	if v == nil || v.playlist == nil || v.playlist.ID != msg.playlist.ID {
+		return
+	}
+	v.loading = false
+	if msg.err != nil {
+		v.err = msg.err.Error()
+		return
+	}
+	v.tracks = msg.tracks
+}
+
+// libraryPlayed shows the info of the track once it plays, from a playlist,
+// the catalog search or the tracklist of the album. The player is followed again after stui query.
+func (m *model) libraryPlayed(msg libraryPlayMsg) tea.Cmd {
+	if msg.err != nil {
+		m.errMsg = "  spotify: " + msg.err.Error()
+		switch statusCode(msg.err) {
+		case http.StatusNotFound:
+			m.errMsg += ", open Spotify on a device first"
+		case http.StatusForbidden:
+			m.errMsg += ", playing from stui needs Spotify Premium"
+		}
+		return nil
+	}
+	m.library, m.catalog, m.tracks, m.query = nil, nil, nil, false
+	return m.reload(msg.info, false)
+}
+
+func (m *model) updateLibrary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.library
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		if v.playlist == nil {
+			m.library = nil
+			return m, nil
+		}
+		v.playlist, v.tracks, v.err, v.loading = nil, nil, "", false
+		v.filter, v.cursor = v.backFilter, v.backCursor
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case tea.KeyDown:
+		if v.cursor < len(v.matches())-1 {
+			v.cursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(v.filter); len(r) > 0 {
+			v.filter = string(r[:len(r)-1])
+			v.cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		v.filter += string(msg.Runes)
+		v.cursor = 0
+	case tea.KeyEnter:
+		i := v.selected()
+		if i < 0 || v.loading {
+			return m, nil
+		}
+		if v.playlist == nil {
+			playlist := v.playlists[i]
+			v.playlist, v.backFilter, v.backCursor = &playlist, v.filter, v.cursor
+			v.filter, v.cursor, v.loading, v.err = "", 0, true, ""
+			return m, func() tea.Msg {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				tracks, err := getSpotifyPlaylistTracks(ctx, playlist.ID)
+				return libraryTracksMsg{playlist: playlist, tracks: tracks, err: err}
+			}
+		}
+
+		playlist, track := *v.playlist, v.tracks[i]
+		m.notice = "  " + trf("Playing %s", track.info.track)
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return libraryPlayMsg{info: track.info, err: playSpotifyTrack(ctx, playlist, track)}
+		}
+	}
+	return m, nil
+}
+
+func (m *model) libraryScreenView() string {
+	v := m.library
+	pad := strings.Repeat(" ", padding)
+
+	title := tr("Playlists")
+	if v.playlist != nil {
+		title = v.playlist.Name
+	}
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+title) + "\n\n")
+	b.WriteString(pad + "> " + v.filter + "█\n\n")
+
+	matches := v.matches()
+	switch {
+	case v.loading:
+		b.WriteString(pad + helpStyle(tr("Loading...")) + "\n")
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	case len(matches) == 0:
+		b.WriteString(pad + helpStyle(tr("Nothing found")) + "\n")
+	}
+
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+	names := v.names()
+	for i := start; i < len(matches) && i < start+rows; i++ {
+		var count string
+		if v.playlist == nil {
+			count = helpStyle(" " + trf("%d tracks", v.playlists[matches[i]].Tracks.Total))
+		}
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+names[matches[i]]) + count + "\n")
+		} else {
+			b.WriteString(pad + "  " + names[matches[i]] + count + "\n")
+		}
+	}
+
+	help := tr("type to filter • ↑/↓: Select • enter: Open • esc: Back")
+	if v.playlist != nil {
+		help = tr("type to filter • ↑/↓: Select • enter: Play • esc: Back")
+	}
+	b.WriteString("\n" + pad + helpStyle(help))
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFetchSpotifyPlaylistTracks(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var played map[string]any
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/playlists/road/tracks" && r.URL.Query().Get("offset") == "":
+			fmt.Fprintf(w, `{"items":[
+				{"track":{"type":"track","uri":"spotify:track:1","name":"Airbag","artists":[{"name":"Radiohead"}],"album":{"name":"OK Computer (Remastered)"}}},
+				{"is_local":true,"track":{"type":"track","uri":"spotify:local:x","name":"Demo","artists":[{"name":"Me"}]}},
+				{"track":null}],"next":"%s/playlists/road/tracks?offset=100"}`, server.URL)
+		case r.URL.Path == "/playlists/road/tracks":
+			fmt.Fprint(w, `{"items":[{"track":{"type":"episode","uri":"spotify:episode:1","name":"Talk"}},
+				{"track":{"type":"track","uri":"spotify:track:2","name":"Teardrop","artists":[{"name":"Massive Attack"}],"album":{"name":"Mezzanine"}}}],"next":null}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/me/player/play":
+			json.NewDecoder(r.Body).Decode(&played)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api string) { spotifyAPIURL = api }(spotifyAPIURL)
+	spotifyAPIURL = server.URL
+
+	tracks, err := fetchSpotifyPlaylistTracks(context.Background(), "road")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 2 || tracks[0].info != (MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Airbag"}) || tracks[1].uri != "spotify:track:2" {
+		t.Fatalf("got %+v", tracks)
+	}
+
+	playlist := spotifyPlaylist{ID: "road", URI: "spotify:playlist:road"}
+	if err := startSpotifyTrack(context.Background(), playlist, tracks[1]); err != nil {
+		t.Fatal(err)
+	}
+	offset, _ := played["offset"].(map[string]any)
+	if played["context_uri"] != "spotify:playlist:road" || offset["uri"] != "spotify:track:2" {
+		t.Errorf("played %v", played)
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+	m := setupTest(t, PlayerPlaying)
+	defer func(id string) { spotifyClientID = id }(spotifyClientID)
+	spotifyClientID = "client"
+
+	teardrop := MusicInfo{artist: "Massive Attack", album: "Mezzanine", track: "Teardrop"}
+	var played libraryTrack
+	getSpotifyPlaylists = func(ctx context.Context) ([]spotifyPlaylist, error) {
+		return []spotifyPlaylist{{ID: "gym", Name: "Gym"}, {ID: "road", Name: "Road trip", URI: "spotify:playlist:road"}}, nil
+	}
+	getSpotifyPlaylistTracks = func(ctx context.Context, id string) ([]libraryTrack, error) {
+		return []libraryTrack{{info: testTrack, uri: "spotify:track:1"}, {info: teardrop, uri: "spotify:track:2"}}, nil
+	}
+	playSpotifyTrack = func(ctx context.Context, playlist spotifyPlaylist, track libraryTrack) error {
+		played = track
+		return nil
+	}
+	defer func() {
+		getSpotifyPlaylists = fetchSpotifyPlaylists
+		getSpotifyPlaylistTracks = fetchSpotifyPlaylistTracks
+		playSpotifyTrack = startSpotifyTrack
+	}()
+
+	m.loading = false
+	_, cmd := m.Update(keyRune('L'))
+	if m.library == nil || cmd == nil {
+		t.Fatal("L did not open the playlists")
+	}
+	m.Update(cmd())
+	// The audio features of the track played would use the Web API.
+	spotifyClientID = ""
+
+	// Filter down to the road trip and open it.
+	m.Update(keyRune('r'))
+	m.Update(keyRune('o'))
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.library.playlist == nil || m.library.playlist.ID != "road" || cmd == nil {
+		t.Fatalf("got playlist %+v", m.library.playlist)
+	}
+	m.Update(cmd())
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+	// Wait for the info of the track, the provider is gone after the test.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		m.mu.Lock()
+		done := m.steps > 0 && m.stepsDone >= m.steps
+		m.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the info of the track was not fetched")
+		}
+	}
+	if played.uri != "spotify:track:2" {
+		t.Errorf("played %+v", played)
+	}
+	if m.library != nil || m.MusicInfo != teardrop {
+		t.Errorf("got track %+v, want the info of the track played", m.MusicInfo)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openURL opens a link in the default browser, replaced in tests.
+var openURL = openBrowser
+
+var urlPattern = regexp.MustCompile(`https?://[^\s)\]>"]+`)
+
+// linkLang is the language of the search links, the one of the locale. It
+// picks the Wikipedia of the links and the language of the Google results.
+var linkLang = "en"
+
+// buildLinks returns the search links for info.
+func buildLinks(info MusicInfo) *Links {
+	return &Links{
+		YouTube:      searchLink("https://www.youtube.com/results", "search_query", []string{info.artist, info.track}),
+		GoogleImages: searchLink("https://www.google.com/search", "q", []string{info.artist, info.album}, "tbm", "isch", "hl", linkLang),
+		Wikipedia:    searchLink("https://"+linkLang+".wikipedia.org/w/index.php", "search", []string{info.artist, info.album}),
+	}
+}
+
+// searchLink is the URL of a search for words on base, key is the query
+// parameter and extra has more of them as name and value pairs. The words
+// are escaped, not dropped, so names in any script find what they should.
+func searchLink(base, key string, words []string, extra ...string) string {
+	var terms []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			terms = append(terms, w)
+		}
+	}
+	params := url.QueryEscape(key) + "=" + url.QueryEscape(strings.Join(terms, " "))
+	for i := 0; i+1 < len(extra); i += 2 {
+		params += "&" + url.QueryEscape(extra[i]) + "=" + url.QueryEscape(extra[i+1])
+	}
+	return base + "?" + params
+}
+
+// namedLink is a link of the link picker.
+type namedLink struct {
+	label string
+	url   string
+}
+
+// linkPicker is the state of the link selection screen.
+type linkPicker struct {
+	links  []namedLink
+	cursor int
+}
+
+// namedLinks returns the search links followed by the links found in the
+// answers, without duplicates. The caller must hold m.mu.
+func (m *model) namedLinks() []namedLink {
+	var links []namedLink
+	if m.links != nil {
+		links = append(links,
+			namedLink{"YouTube", m.links.YouTube},
+			namedLink{"Google Images", m.links.GoogleImages},
+			namedLink{"Wikipedia", m.links.Wikipedia},
+		)
+	}
+
+	seen := map[string]bool{}
+	for _, l := range links {
+		seen[l.url] = true
+	}
+	for _, s := range m.sections {
+		for _, url := range urlPattern.FindAllString(s.Content, -1) {
+			url = strings.TrimRight(url, ".,;:")
+			if !seen[url] {
+				seen[url] = true
+				links = append(links, namedLink{s.Title, url})
+			}
+		}
+	}
+	return links
+}
+
+// linksTabContent is the markdown of the links tab, numbered like the picker.
+func linksTabContent(links []namedLink) string {
+	var b strings.Builder
+	b.WriteString("## Links\n")
+	for i, l := range links {
+		fmt.Fprintf(&b, "%d. %s: %s\n", i+1, l.label, l.url)
+	}
+	return b.String()
+}
+
+func (m *model) openLinkPicker() {
+	m.mu.Lock()
+	links := m.namedLinks()
+	m.mu.Unlock()
+
+	if len(links) > 0 {
+		m.linkPicker = &linkPicker{links: links}
+	}
+}
+
+func (m *model) updateLinkPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.linkPicker
+
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.linkPicker = nil
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.links)-1 {
+			p.cursor++
+		}
+	case "enter", "o":
+		m.openLink(p.links[p.cursor])
+	case "y":
+		m.linkPicker = nil
+		return m, m.copyText(tr("the link"), p.links[p.cursor].url)
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if i := int(key[0] - '1'); i < len(p.links) {
+			p.cursor = i
+			m.openLink(p.links[i])
+		}
+	}
+	return m, nil
+}
+
+func (m *model) openLink(l namedLink) {
+	m.linkPicker = nil
+	if err := openURL(l.url); err != nil {
+		m.errMsg = "  open: " + err.Error()
+		return
+	}
+	m.notice = "  " + trf("Opened %s", l.url)
+}
+
+func (m *model) linkPickerView() string {
+	p := m.linkPicker
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Links")) + "\n\n")
+	for i, l := range p.links {
+		line := fmt.Sprintf("%d %s: %s", i+1, l.label, l.url)
+		if i == p.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("↑/↓: Select • enter/o/1-9: Open • y: Copy • esc: Back")))
+	return b.String()
+}
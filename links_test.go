@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLinkPicker(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.sections[0].Content = "Credits at https://www.discogs.com/release/1 and https://www.discogs.com/release/1."
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	var opened []string
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { openURL = openBrowser }()
+
+	m.Update(keyRune('o'))
+	if m.linkPicker == nil {
+		t.Fatal("link picker is not open")
+	}
+	if got := len(m.linkPicker.links); got != 5 {
+		t.Fatalf("got %d links, want 5: %+v", got, m.linkPicker.links)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.linkPicker != nil {
+		t.Error("link picker is still open")
+	}
+
+	m.Update(keyRune('o'))
+	m.Update(keyRune('4'))
+	if len(opened) != 2 || opened[0] != m.links.GoogleImages || opened[1] != "https://www.discogs.com/release/1" {
+		t.Errorf("opened %v", opened)
+	}
+}
+
+func TestBuildLinks(t *testing.T) {
+	defer func() { linkLang = "en" }()
+
+	links := buildLinks(MusicInfo{artist: "Björk", album: "Homogenic", track: "Jóga"})
+	if want := "https://www.youtube.com/results?search_query=Bj%C3%B6rk+J%C3%B3ga"; links.YouTube != want {
+		t.Errorf("got YouTube link %q, want %q", links.YouTube, want)
+	}
+
+	linkLang = "ja"
+	links = buildLinks(MusicInfo{artist: "宇多田ヒカル", album: "First Love"})
+	if want := "https://www.google.com/search?q=%E5%AE%87%E5%A4%9A%E7%94%B0%E3%83%92%E3%82%AB%E3%83%AB+First+Love&tbm=isch&hl=ja"; links.GoogleImages != want {
+		t.Errorf("got Google Images link %q, want %q", links.GoogleImages, want)
+	}
+	if want := "https://ja.wikipedia.org/w/index.php?search=%E5%AE%87%E5%A4%9A%E7%94%B0%E3%83%92%E3%82%AB%E3%83%AB+First+Love"; links.Wikipedia != want {
+		t.Errorf("got Wikipedia link %q, want %q", links.Wikipedia, want)
+	}
+
+	// Escaped, the names can not end the link early in the markdown.
+	if got := searchLink("https://example.com/s", "q", []string{"Кино", "", "Группа крови (1988) & more"}); got != "https://example.com/s?q=%D0%9A%D0%B8%D0%BD%D0%BE+%D0%93%D1%80%D1%83%D0%BF%D0%BF%D0%B0+%D0%BA%D1%80%D0%BE%D0%B2%D0%B8+%281988%29+%26+more" {
+		t.Errorf("got %q", got)
+	}
+}
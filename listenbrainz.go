@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	listenBrainzURL     = "https://api.listenbrainz.org"
+	listenBrainzLabsURL = "https://labs.api.listenbrainz.org"
+)
+
+// listenBrainzToken is the ListenBrainz user token, with one stui submits
+// the listens of the tracks it shows and adds the ListenBrainz section.
+var listenBrainzToken = os.Getenv("LISTENBRAINZ_TOKEN")
+
+// listenMinimum is how long a track has to be shown to count as a listen.
+const listenMinimum = 30 * time.Second
+
+// similarArtistsAlgorithm is the ListenBrainz dataset of similar artists.
+const similarArtistsAlgorithm = "session_based_days_7500_session_300_contribution_5_threshold_10_limit_100_filter_True_skip_30"
+
+var errNoListenBrainz = errors.New("not found on ListenBrainz")
+
+// getListenBrainzStats and submitListen are replaced in tests.
+var (
+	getListenBrainzStats = fetchListenBrainzStats
+	submitListen         = postListen
+)
+
+// listen is the track being played, it is submitted once stui moves on.
+type listen struct {
+	info  MusicInfo
+	since time.Time
+}
+
+// listenTo submits info as playing now, and the previous track as a listen
+// when it was shown long enough. A refresh of the same track is not a new
+// listen.
+func (m *model) listenTo(info MusicInfo) {
+	if (listenBrainzToken == "" && !scrobbling()) || m.query || (m.listen != nil && m.listen.info == info) {
+		return
+	}
+
+	if previous := m.endListen(); previous != nil {
+		submitListens(previous, false)
+	}
+	// Listens need an artist and a track, which the deep dive has not.
+	if info.isPodcast() || info.track == "" {
+		return
+	}
+	current := &listen{info: info, since: time.Now()}
+	m.listen = current
+	submitListens(current, true)
+}
+
+// submitListens sends l to ListenBrainz and Last.fm in the background.
+func submitListens(l *listen, playingNow bool) {
+	if listenBrainzToken != "" {
+		goSafe(func() { submitListen(context.Background(), l, playingNow) })
+	}
+	if scrobbling() {
+		goSafe(func() { scrobble(context.Background(), l, playingNow) })
+	}
+}
+
+// endListen returns the current track when it was played long enough to be
+// submitted as a listen.
+func (m *model) endListen() *listen {
+	l := m.listen
+	m.listen = nil
+	if l == nil || time.Since(l.since) < listenMinimum {
+		return nil
+	}
+	return l
+}
+
+// listenBrainzRequest sends an authenticated API request.
+func listenBrainzRequest(ctx context.Context, method string, u string, body any, v any) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	req.Header.Set("Authorization", "Token "+listenBrainzToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound:
+		return errNoListenBrainz
+	case resp.StatusCode != http.StatusOK:
+		return newStatusError(resp.StatusCode, "listenbrainz: status code %d", resp.StatusCode)
+	case v == nil:
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// postListen submits a listen, or the playing now status of the track.
+// Submissions are best effort, stui is not a reliable scrobbler as it only
+// sees the tracks it shows.
+func postListen(ctx context.Context, l *listen, playingNow bool) error {
+	metadata := map[string]any{
+		"artist_name": l.info.artist,
+		"track_name":  l.info.track,
+		"additional_info": map[string]string{
+			"submission_client":         "stui",
+			"submission_client_version": version,
+		},
+	}
+	if l.info.album != "" {
+		metadata["release_name"] = l.info.album
+	}
+
+	item := map[string]any{"track_metadata": metadata}
+	listenType := "playing_now"
+	if !playingNow {
+		listenType = "single"
+		item["listened_at"] = l.since.Unix()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	body := map[string]any{"listen_type": listenType, "payload": []any{item}}
+	return listenBrainzRequest(ctx, http.MethodPost, listenBrainzURL+"/1/submit-listens", body, nil)
+}
+
+// ListenBrainzStats are the listens of the artist on ListenBrainz.
+type ListenBrainzStats struct {
+	ArtistMBID  string
+	User        string
+	Listens     int
+	Listeners   int
+	UserListens int
+	Similar     []string
+}
+
+// listenBrainzUser is the name of the token owner, looked up once.
+var listenBrainzUser struct {
+	sync.Mutex
+	name string
+}
+
+func currentListenBrainzUser(ctx context.Context) (string, error) {
+	listenBrainzUser.Lock()
+	defer listenBrainzUser.Unlock()
+	if listenBrainzUser.name != "" {
+		return listenBrainzUser.name, nil
+	}
+
+	var r struct {
+		Valid    bool   `json:"valid"`
+		UserName string `json:"user_name"`
+	}
+	if err := listenBrainzRequest(ctx, http.MethodGet, listenBrainzURL+"/1/validate-token", nil, &r); err != nil {
+		return "", err
+	}
+	if !r.Valid {
+		return "", errors.New("listenbrainz: invalid token")
+	}
+	listenBrainzUser.name = r.UserName
+	return r.UserName, nil
+}
+
+// fetchListenBrainzStats looks up the artist on MusicBrainz, as ListenBrainz
+// identifies artists by their MBID, then reads its listens, those of the
+// user and the similar artists.
+func fetchListenBrainzStats(ctx context.Context, info MusicInfo) (*ListenBrainzStats, error) {
+	var artists struct {
+		Artists []struct {
+			ID string `json:"id"`
+		} `json:"artists"`
+	}
+	query := fmt.Sprintf(`artist:"%s"`, info.artist)
+	if err := musicBrainzGet(ctx, "/artist/?fmt=json&limit=1&query="+url.QueryEscape(query), &artists); err != nil {
+		return nil, err
+	}
+	if len(artists.Artists) == 0 {
+		return nil, errNoListenBrainz
+	}
+	stats := &ListenBrainzStats{ArtistMBID: artists.Artists[0].ID}
+
+	var listeners struct {
+		Payload struct {
+			TotalListenCount int `json:"total_listen_count"`
+			TotalUserCount   int `json:"total_user_count"`
+		} `json:"payload"`
+	}
+	err := listenBrainzRequest(ctx, http.MethodGet, listenBrainzURL+"/1/stats/artist/"+stats.ArtistMBID+"/listeners?range=all_time", nil, &listeners)
+	if err != nil && !errors.Is(err, errNoListenBrainz) {
+		return nil, err
+	}
+	stats.Listens, stats.Listeners = listeners.Payload.TotalListenCount, listeners.Payload.TotalUserCount
+
+	user, err := currentListenBrainzUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.User = user
+	var top struct {
+		Payload struct {
+			Artists []struct {
+				ArtistMBID  string `json:"artist_mbid"`
+				ListenCount int    `json:"listen_count"`
+			} `json:"artists"`
+		} `json:"payload"`
+	}
+	err = listenBrainzRequest(ctx, http.MethodGet, listenBrainzURL+"/1/stats/user/"+url.PathEscape(user)+"/artists?range=all_time&count=1000", nil, &top)
+	if err != nil && !errors.Is(err, errNoListenBrainz) {
+		return nil, err
+	}
+	for _, a := range top.Payload.Artists {
+		if a.ArtistMBID == stats.ArtistMBID {
+			stats.UserListens = a.ListenCount
+		}
+	}
+
+	var similar []struct {
+		Name string `json:"name"`
+	}
+	u := listenBrainzLabsURL + "/similar-artists/json?algorithm=" + similarArtistsAlgorithm + "&artist_mbids=" + stats.ArtistMBID
+	err = listenBrainzRequest(ctx, http.MethodGet, u, nil, &similar)
+	if err != nil && !errors.Is(err, errNoListenBrainz) {
+		return nil, err
+	}
+	for i, a := range similar {
+		if i == 10 {
+			break
+		}
+		stats.Similar = append(stats.Similar, a.Name)
+	}
+
+	return stats, nil
+}
+
+// listenBrainzSection renders the ListenBrainz stats of the artist.
+func listenBrainzSection(ctx context.Context, info MusicInfo) (string, error) {
+	stats, err := getListenBrainzStats(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return stats.markdown(), nil
+}
+
+func (s *ListenBrainzStats) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Listens:** %s by %s listeners\n", formatCount(fmt.Sprint(s.Listens)), formatCount(fmt.Sprint(s.Listeners)))
+	fmt.Fprintf(&b, "\n**Listens by %s:** %s\n", s.User, formatCount(fmt.Sprint(s.UserListens)))
+	if len(s.Similar) > 0 {
+		fmt.Fprintf(&b, "\n**Similar artists:** %s\n", strings.Join(s.Similar, ", "))
+	}
+	fmt.Fprintf(&b, "\n*Source: [ListenBrainz](https://listenbrainz.org/artist/%s/)*", s.ArtistMBID)
+	return b.String()
+}
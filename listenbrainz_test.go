@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetchListenBrainzStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/1/") && r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("%s: got authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/artist/":
+			w.Write([]byte(`{"artists":[{"id":"a74b1b7f"}]}`))
+		case "/1/stats/artist/a74b1b7f/listeners":
+			w.Write([]byte(`{"payload":{"total_listen_count":5400000,"total_user_count":21000}}`))
+		case "/1/validate-token":
+			w.Write([]byte(`{"valid":true,"user_name":"thom"}`))
+		case "/1/stats/user/thom/artists":
+			w.Write([]byte(`{"payload":{"artists":[{"artist_mbid":"other","listen_count":3},{"artist_mbid":"a74b1b7f","listen_count":1234}]}}`))
+		case "/similar-artists/json":
+			w.Write([]byte(`[{"name":"Portishead"},{"name":"Björk"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(mb, lb, labs, token string) {
+		musicBrainzURL, listenBrainzURL, listenBrainzLabsURL, listenBrainzToken = mb, lb, labs, token
+		listenBrainzUser.name = ""
+	}(musicBrainzURL, listenBrainzURL, listenBrainzLabsURL, listenBrainzToken)
+	musicBrainzURL, listenBrainzURL, listenBrainzLabsURL, listenBrainzToken = server.URL, server.URL, server.URL, "secret"
+
+	stats, err := fetchListenBrainzStats(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := stats.markdown()
+	for _, want := range []string{
+		"**Listens:** 5,400,000 by 21,000 listeners",
+		"**Listens by thom:** 1,234",
+		"**Similar artists:** Portishead, Björk",
+		"https://listenbrainz.org/artist/a74b1b7f/",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown does not include %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPostListen(t *testing.T) {
+	var body struct {
+		ListenType string `json:"listen_type"`
+		Payload    []struct {
+			ListenedAt    int64 `json:"listened_at"`
+			TrackMetadata struct {
+				ArtistName  string `json:"artist_name"`
+				TrackName   string `json:"track_name"`
+				ReleaseName string `json:"release_name"`
+			} `json:"track_metadata"`
+		} `json:"payload"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/1/submit-listens" {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	defer func(u string) { listenBrainzURL = u }(listenBrainzURL)
+	listenBrainzURL = server.URL
+
+	since := time.Unix(1700000000, 0)
+	if err := postListen(context.Background(), &listen{info: testTrack, since: since}, false); err != nil {
+		t.Fatal(err)
+	}
+	if body.ListenType != "single" || len(body.Payload) != 1 || body.Payload[0].ListenedAt != since.Unix() {
+		t.Fatalf("got %+v", body)
+	}
+	if meta := body.Payload[0].TrackMetadata; meta.ArtistName != "Radiohead" || meta.TrackName != "Airbag" || meta.ReleaseName != "OK Computer" {
+		t.Errorf("got %+v", meta)
+	}
+}
+
+func TestListenTo(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	listenBrainzToken = "secret"
+
+	var mu sync.Mutex
+	var submitted []string
+	var wg sync.WaitGroup
+	submitListen = func(ctx context.Context, l *listen, playingNow bool) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		kind := "listen"
+		if playingNow {
+			kind = "playing now"
+		}
+		submitted = append(submitted, kind+" "+l.info.track)
+		return nil
+	}
+	defer func() { submitListen = postListen }()
+
+	wg.Add(1)
+	m.listenTo(testTrack)
+	// A refresh does not count again.
+	m.listenTo(testTrack)
+	// Skipped before listenMinimum.
+	wg.Add(1)
+	m.listenTo(MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"})
+	m.listen.since = time.Now().Add(-time.Minute)
+	wg.Add(2)
+	m.listenTo(MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Subterranean Homesick Alien"})
+	wg.Wait()
+
+	want := []string{"playing now Airbag", "playing now Paranoid Android", "listen Paranoid Android", "playing now Subterranean Homesick Alien"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(submitted) != len(want) {
+		t.Fatalf("got %v, want %v", submitted, want)
+	}
+	for _, s := range want {
+		if !containsString(submitted, s) {
+			t.Errorf("%q was not submitted: %v", s, submitted)
+		}
+	}
+}
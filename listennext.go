@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// listenNextCount is how many suggestions the model is asked for.
+const listenNextCount = 8
+
+// listenNextHistory is how many tracks of the history go in the prompt.
+const listenNextHistory = 15
+
+var errNoSuggestions = errors.New("the model suggested nothing, try again with r")
+
+// queueSuggestions is replaced in tests.
+var queueSuggestions = spotifyQueueSuggestions
+
+// listenNextFunction is the structured answer of the suggestions.
+var listenNextFunction = ai.Function{
+	Name:        "suggest_next",
+	Description: "Suggest tracks and albums to listen to next",
+	Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"suggestions": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"kind": {"type": "string", "enum": ["track", "album"]},
+					"artist": {"type": "string"},
+					"title": {"type": "string", "description": "Title of the track or the album"},
+					"why": {"type": "string", "description": "One short sentence on why it follows"}
+				},
+				"required": ["kind", "artist", "title", "why"]
+			}
+		}
+	},
+	"required": ["suggestions"]
+}`),
+}
+
+// suggestion is a track or album to listen to next.
+type suggestion struct {
+	Kind   string `json:"kind"`
+	Artist string `json:"artist"`
+	Title  string `json:"title"`
+	Why    string `json:"why"`
+}
+
+func (s suggestion) album() bool {
+	return s.Kind == "album"
+}
+
+type listenNextMsg struct {
+	info        MusicInfo
+	suggestions []suggestion
+	err         error
+}
+
+type suggestionsQueuedMsg struct {
+	queued  int
+	missing []string
+	err     error
+}
+
+// listenNextView lists the suggestions, space selects them to queue.
+type listenNextView struct {
+	info        MusicInfo
+	loading     bool
+	err         string
+	suggestions []suggestion
+	cursor      int
+	selected    map[int]bool
+}
+
+// listenNextPrompt asks what follows the track of info for a listener who
+// played recent.
+func listenNextPrompt(info MusicInfo, recent []MusicInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Suggest %d tracks or albums to listen to right after the song %q by %s", listenNextCount, info.track, info.artist)
+	if info.album != "" {
+		fmt.Fprintf(&b, " from the album %q", info.album)
+	}
+	b.WriteString(". Mix tracks and albums, other artists too, and leave out what was played recently.")
+	if len(recent) > 0 {
+		b.WriteString("\n\nPlayed recently:\n")
+		for _, r := range recent {
+			fmt.Fprintf(&b, "- %s - %s\n", r.artist, r.track)
+		}
+	}
+	return inLanguage(b.String())
+}
+
+// recentTracks are the last tracks of the history besides info, none without
+// the history.
+func recentTracks(ctx context.Context, info MusicInfo) []MusicInfo {
+	if history == nil {
+		return nil
+	}
+	tracks, err := history.Recent(ctx, listenNextHistory+1)
+	if err != nil {
+		return nil
+	}
+	var recent []MusicInfo
+	for _, t := range tracks {
+		if r := (MusicInfo{artist: t.Artist, album: t.Album, track: t.Track}); r != info && len(recent) < listenNextHistory {
+			recent = append(recent, r)
+		}
+	}
+	return recent
+}
+
+// openListenNext opens the suggestions screen and asks for them.
+func (m *model) openListenNext() tea.Cmd {
+	m.listenNext = &listenNextView{info: m.MusicInfo}
+	return m.suggestNext()
+}
+
+// suggestNext asks the model for the suggestions, they come back as a
+// listenNextMsg.
+func (m *model) suggestNext() tea.Cmd {
+	v := m.listenNext
+	v.loading, v.err, v.suggestions, v.cursor, v.selected = true, "", nil, 0, map[int]bool{}
+	info := v.info
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		var answer struct {
+			Suggestions []suggestion `json:"suggestions"`
+		}
+		prompt := listenNextPrompt(info, recentTracks(ctx, info))
+		if err := m.completeJSON(ctx, chatModel, prompt, listenNextFunction, &answer); err != nil {
+			return listenNextMsg{info: info, err: err}
+		}
+		var suggestions []suggestion
+		for _, s := range answer.Suggestions {
+			if s.Artist != "" && s.Title != "" {
+				suggestions = append(suggestions, s)
+			}
+		}
+		if len(suggestions) == 0 {
+			return listenNextMsg{info: info, err: errNoSuggestions}
+		}
+		return listenNextMsg{info: info, suggestions: suggestions}
+	}
+}
+
+func (m *model) listenNextLoaded(msg listenNextMsg) {
+	v := m.listenNext
+	if v == nil || v.info != msg.info {
+		return
+	}
+	v.loading = false
+	if msg.err != nil {
+		v.err = msg.err.Error()
+		return
+	}
+	v.suggestions = msg.suggestions
+}
+
+func (m *model) updateListenNext(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.listenNext
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.listenNext = nil
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "down", "j":
+		if v.cursor < len(v.suggestions)-1 {
+			v.cursor++
+		}
+	case " ":
+		if v.cursor < len(v.suggestions) {
+			v.selected[v.cursor] = !v.selected[v.cursor]
+		}
+	case "r":
+		if !v.loading {
+			return m, m.suggestNext()
+		}
+	case "enter":
+		if v.loading || v.cursor >= len(v.suggestions) {
+			return m, nil
+		}
+		return m, m.queueListenNext()
+	}
+	return m, nil
+}
+
+// queueListenNext adds the selected suggestions, or the one under the
+// cursor, to the Spotify queue. The result comes back as a
+// suggestionsQueuedMsg.
+func (m *model) queueListenNext() tea.Cmd {
+	v := m.listenNext
+	if spotifyClientID == "" || !spotifyLoggedIn() {
+		m.errMsg = "  spotify: queueing needs spotify_client_id in the config file and stui -spotify-login"
+		return nil
+	}
+
+	var chosen []suggestion
+	for i, s := range v.suggestions {
+		if v.selected[i] {
+			chosen = append(chosen, s)
+		}
+	}
+	if len(chosen) == 0 {
+		chosen = []suggestion{v.suggestions[v.cursor]}
+	}
+	m.listenNext = nil
+	m.notice = "  " + trf("Queueing %d suggestions", len(chosen))
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		queued, missing, err := queueSuggestions(ctx, chosen)
+		return suggestionsQueuedMsg{queued: queued, missing: missing, err: err}
+	}
+}
+
+func (m *model) suggestionsQueued(msg suggestionsQueuedMsg) {
+	if msg.err != nil {
+		m.notice = ""
+		m.errMsg = "  spotify: " + msg.err.Error()
+		return
+	}
+	m.notice = "  " + trf("Queued %d tracks on Spotify", msg.queued)
+	if len(msg.missing) > 0 {
+		m.notice += ", " + trf("not found: %s", strings.Join(msg.missing, ", "))
+	}
+}
+
+// spotifyQueueSuggestions finds the suggestions on Spotify and adds them to
+// the queue of the active device, all the tracks of an album. It returns
+// how many tracks were queued and the suggestions Spotify does not have.
+func spotifyQueueSuggestions(ctx context.Context, suggestions []suggestion) (int, []string, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+
+	queued := 0
+	var missing []string
+	for _, s := range suggestions {
+		var uris []string
+		if s.album() {
+			album, err := spotifyAlbumTracks(ctx, MusicInfo{artist: s.Artist, album: s.Title})
+			if err != nil && !errors.Is(err, errNoAlbumTracks) {
+				return queued, missing, err
+			}
+			for _, t := range album.tracks {
+				uris = append(uris, t.uri)
+			}
+		} else {
+			uri, err := spotifyTrackURI(ctx, api, s.Artist, s.Title)
+			if err != nil {
+				return queued, missing, err
+			}
+			if uri != "" {
+				uris = append(uris, uri)
+			}
+		}
+		if len(uris) == 0 {
+			missing = append(missing, s.Title)
+			continue
+		}
+
+		// The queue takes one track per request, in order.
+		for _, uri := range uris {
+			resp, err := api.doContext(ctx, http.MethodPost, "/me/player/queue?uri="+url.QueryEscape(uri))
+			if err != nil {
+				return queued, missing, err
+			}
+			resp.Body.Close()
+			queued++
+		}
+	}
+	return queued, missing, nil
+}
+
+// spotifyTrackURI returns the uri of the track of the artist, empty when it
+// is not on Spotify.
+func spotifyTrackURI(ctx context.Context, api *spotifyWebPlayer, artist, track string) (string, error) {
+	var search struct {
+		Tracks struct {
+			Items []struct {
+				URI string `json:"uri"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	query := "track:" + track + " artist:" + artist
+	if err := api.get(ctx, "/search?type=track&limit=1&q="+url.QueryEscape(query), &search); err != nil {
+		return "", err
+	}
+	if len(search.Tracks.Items) == 0 {
+		return "", nil
+	}
+	return search.Tracks.Items[0].URI, nil
+}
+
+func (m *model) listenNextScreenView() string {
+	v := m.listenNext
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Listen next")) + "\n\n")
+	switch {
+	case v.loading:
+		b.WriteString(pad + helpStyle(tr("Loading...")) + "\n")
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	default:
+		b.WriteString(pad + helpStyle(trf("After %s by %s", v.info.track, v.info.artist)) + "\n\n")
+	}
+
+	// Every suggestion takes two lines, the title and why.
+	rows := (m.height - 8) / 2
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+	for i := start; i < len(v.suggestions) && i < start+rows; i++ {
+		s := v.suggestions[i]
+		check := "[ ] "
+		if v.selected[i] {
+			check = "[x] "
+		}
+		kind := "♪ "
+		if s.album() {
+			kind = "◉ "
+		}
+		line := check + kind + s.Title + " · " + s.Artist
+		if i == v.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + "\n")
+		}
+		b.WriteString(pad + "      " + helpStyle(s.Why) + "\n")
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("↑/↓: Select • space: Mark • enter: Queue on Spotify • r: Suggest again • esc: Back")))
+	return b.String()
+}
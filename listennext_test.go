@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+type listenNextCompleter struct {
+	prompts *[]string
+}
+
+func (c listenNextCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	*c.prompts = append(*c.prompts, prompt)
+	if !strings.Contains(prompt, "JSON schema") {
+		return "stub answer for: " + prompt, nil
+	}
+	return `{"suggestions":[` +
+		`{"kind":"track","artist":"Portishead","title":"Roads","why":"Same dark mood"},` +
+		`{"kind":"album","artist":"Massive Attack","title":"Mezzanine","why":"Released a year later"},` +
+		`{"kind":"track","artist":"","title":"Nameless","why":"No artist"}]}`, nil
+}
+
+func TestListenNext(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	defer func(id string) { spotifyClientID = id }(spotifyClientID)
+	spotifyClientID = "client"
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+	for _, info := range []MusicInfo{{artist: "Björk", album: "Homogenic", track: "Jóga"}, testTrack} {
+		if _, err := db.RecordPlay(context.Background(), info.artist, info.album, info.track, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var prompts []string
+	completer = listenNextCompleter{prompts: &prompts}
+	var queued []suggestion
+	queueSuggestions = func(ctx context.Context, suggestions []suggestion) (int, []string, error) {
+		queued = suggestions
+		return 11, nil, nil
+	}
+	defer func() { queueSuggestions = spotifyQueueSuggestions }()
+
+	_, cmd := m.Update(keyRune('w'))
+	if m.listenNext == nil || cmd == nil {
+		t.Fatal("w did not open the suggestions")
+	}
+	m.Update(cmd())
+	prompt := prompts[len(prompts)-1]
+	if !strings.Contains(prompt, `after the song "Airbag" by Radiohead`) || !strings.Contains(prompt, "- Björk - Jóga\n") || strings.Contains(prompt, "- Radiohead - Airbag") {
+		t.Errorf("the prompt does not have the track and the history:\n%s", prompt)
+	}
+	view := m.View()
+	for _, want := range []string{"♪ Roads · Portishead", "◉ Mezzanine · Massive Attack", "Released a year later"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("the suggestions do not include %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Nameless") {
+		t.Error("the suggestion without an artist is listed")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.listenNext != nil || cmd == nil {
+		t.Fatal("enter did not queue the suggestions")
+	}
+	m.Update(cmd())
+	if len(queued) != 2 || queued[0].Title != "Roads" || queued[1].Title != "Mezzanine" {
+		t.Errorf("queued %+v", queued)
+	}
+	if m.notice != "  Queued 11 tracks on Spotify" {
+		t.Errorf("got notice %q, error %q", m.notice, m.errMsg)
+	}
+}
+
+func TestSpotifyQueueSuggestions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var queue []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch q := r.URL.Query().Get("q"); {
+		case r.URL.Path == "/search" && q == "track:Roads artist:Portishead":
+			fmt.Fprint(w, `{"tracks":{"items":[{"uri":"spotify:track:roads"}]}}`)
+		case r.URL.Path == "/search" && q == "album:Mezzanine artist:Massive Attack":
+			fmt.Fprint(w, `{"albums":{"items":[{"id":"mezz","uri":"spotify:album:mezz"}]}}`)
+		case r.URL.Path == "/search":
+			fmt.Fprint(w, `{"tracks":{"items":[]},"albums":{"items":[]}}`)
+		case r.URL.Path == "/albums/mezz/tracks":
+			fmt.Fprint(w, `{"items":[{"name":"Angel","uri":"spotify:track:angel","track_number":1,"disc_number":1},{"name":"Risingson","uri":"spotify:track:risingson","track_number":2,"disc_number":1}]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/me/player/queue":
+			queue = append(queue, r.URL.Query().Get("uri"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(api, id string) { spotifyAPIURL, spotifyClientID = api, id }(spotifyAPIURL, spotifyClientID)
+	spotifyAPIURL, spotifyClientID = server.URL, "client"
+
+	queued, missing, err := spotifyQueueSuggestions(context.Background(), []suggestion{
+		{Kind: "track", Artist: "Portishead", Title: "Roads"},
+		{Kind: "track", Artist: "Nobody", Title: "Nothing"},
+		{Kind: "album", Artist: "Massive Attack", Title: "Mezzanine"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"spotify:track:roads", "spotify:track:angel", "spotify:track:risingson"}; queued != 3 || !reflect.DeepEqual(queue, want) {
+		t.Errorf("queued %d tracks %v, want %v", queued, queue, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"Nothing"}) {
+		t.Errorf("got missing %v", missing)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lyricsURL is the lrclib.net endpoint that looks up a single track.
+var lyricsURL = "https://lrclib.net/api/get"
+
+// errNoLyrics is returned when lrclib does not know the track.
+var errNoLyrics = errors.New("no lyrics found")
+
+// getLyrics and getPlaybackPosition are replaced in tests.
+var getLyrics = fetchLyrics
+var getPlaybackPosition = spotifyPosition
+
+// positionInterval is how often the playback position is read while synced
+// lyrics are shown.
+const positionInterval = 500 * time.Millisecond
+
+// Lyrics of a track, Synced holds the LRC version when lrclib has one.
+type Lyrics struct {
+	Plain        string `json:"plainLyrics"`
+	Synced       string `json:"syncedLyrics"`
+	Instrumental bool   `json:"instrumental"`
+}
+
+func fetchLyrics(ctx context.Context, info MusicInfo) (*Lyrics, error) {
+	q := url.Values{}
+	q.Set("artist_name", info.artist)
+	q.Set("track_name", info.track)
+	if info.album != "" {
+		q.Set("album_name", info.album)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lyricsURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// lrclib asks clients to identify themselves.
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNoLyrics
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, "status code: %d", resp.StatusCode)
+	}
+
+	var l Lyrics
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return nil, err
+	}
+	if l.Plain == "" && !l.Instrumental {
+		return nil, errNoLyrics
+	}
+	return &l, nil
+}
+
+// fetchTrackLyrics loads the lyrics of the current track into the model.
+// A track without lyrics is not an error.
+func (m *model) fetchTrackLyrics(ctx context.Context, info MusicInfo) {
+	var lyrics *Lyrics
+	var err error
+	if offline {
+		lyrics, err = cachedLyrics(info)
+	} else if cached, ok := freshLyrics(info); ok {
+		// E.g. prefetched before the track started.
+		lyrics = cached
+	} else {
+		err = m.withRetry(ctx, func() (err error) {
+			lyrics, err = getLyrics(ctx, info)
+			return err
+		})
+		if err == nil {
+			cacheLyrics(info, lyrics)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil && !errors.Is(err, errNoLyrics) {
+		m.errMsg = "  lyrics: " + err.Error()
+	}
+	m.lyrics = lyrics
+	m.syncedLyrics = nil
+	if lyrics != nil {
+		m.syncedLyrics = parseLRC(lyrics.Synced)
+	}
+	m.lyricsLine = -1
+	m.lyricsDone = true
+	m.changed = true
+}
+
+// lyricLine is a line of synced lyrics and the time it starts.
+type lyricLine struct {
+	at   time.Duration
+	text string
+}
+
+var lrcTimeRe = regexp.MustCompile(`^\[(\d+):(\d+(?:\.\d+)?)\]`)
+
+// parseLRC reads the lines of an LRC file, a line can start with several
+// timestamps when it is repeated. Lines without a timestamp, such as the
+// [ar:...] tags, are skipped.
+func parseLRC(s string) []lyricLine {
+	var lines []lyricLine
+	for _, raw := range strings.Split(s, "\n") {
+		raw = strings.TrimSpace(raw)
+
+		var times []time.Duration
+		for {
+			match := lrcTimeRe.FindStringSubmatch(raw)
+			if match == nil {
+				break
+			}
+			min, _ := strconv.Atoi(match[1])
+			sec, _ := strconv.ParseFloat(match[2], 64)
+			times = append(times, time.Duration(min)*time.Minute+time.Duration(sec*float64(time.Second)))
+			raw = raw[len(match[0]):]
+		}
+
+		text := strings.TrimSpace(raw)
+		for _, at := range times {
+			lines = append(lines, lyricLine{at: at, text: text})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].at < lines[j].at })
+	return lines
+}
+
+// currentLyricLine returns the index of the line being sung at pos, or -1
+// before the first line.
+func currentLyricLine(lines []lyricLine, pos time.Duration) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].at > pos }) - 1
+}
+
+type positionMsg struct {
+	seq      int
+	position time.Duration
+	err      error
+}
+
+// positionCmd reads the playback position after positionInterval, seq stops
+// older polls when the lyrics view is toggled.
+func positionCmd(seq int) tea.Cmd {
+	read := getPlaybackPosition
+	return tea.Tick(positionInterval, func(time.Time) tea.Msg {
+		pos, err := read()
+		return positionMsg{seq: seq, position: pos, err: err}
+	})
+}
+
+// renderLyricsView renders the lyrics for the current width along with the
+// line offset of the highlighted line.
+func (m *model) renderLyricsView() (string, int) {
+	m.mu.Lock()
+	lyrics, synced, line, done := m.lyrics, m.syncedLyrics, m.lyricsLine, m.lyricsDone
+	m.mu.Unlock()
+
+	var translation []string
+	if m.showTranslation {
+		translation = m.translation
+	}
+	return renderLyrics(m.track, lyrics, synced, translation, line, done, m.viewportWidth()-viewportFrame)
+}
+
+// renderLyrics formats the lyrics view, wrapped at width. Synced lyrics are
+// used when there are any, with the current line highlighted. The lines of
+// translation, when there is one, are shown next to the original ones.
+func renderLyrics(track string, lyrics *Lyrics, synced []lyricLine, translation []string, current int, done bool, width int) (string, int) {
+	style := lipgloss.NewStyle().Width(width).Padding(0, 1)
+	title := style.Render(lipgloss.NewStyle().Bold(true).Render(track)) + "\n\n"
+	column := lipgloss.NewStyle().Width(width/2).Padding(0, 1)
+	renderLine := func(i int, text string) string {
+		if translation == nil {
+			return style.Render(text)
+		}
+		var translated string
+		if i < len(translation) {
+			translated = translation[i]
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Top, column.Render(text), column.Render(helpStyle(translated)))
+	}
+
+	switch {
+	case !done:
+		return title + style.Render(tr("Loading lyrics...")), 0
+	case lyrics == nil:
+		return title + style.Render("No lyrics found for this track."), 0
+	case lyrics.Instrumental:
+		return title + style.Render("This track is instrumental."), 0
+	case len(synced) == 0 && translation == nil:
+		return title + style.Render(lyrics.Plain), 0
+	case len(synced) == 0:
+		var b strings.Builder
+		b.WriteString(title)
+		for i, text := range strings.Split(lyrics.Plain, "\n") {
+			b.WriteString(renderLine(i, text) + "\n")
+		}
+		return b.String(), 0
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	offset := 0
+	for i, line := range synced {
+		text := line.text
+		if text == "" {
+			text = "♪"
+		}
+		if i == current {
+			offset = strings.Count(b.String(), "\n")
+			text = styleBadge(text)
+		}
+		b.WriteString(renderLine(i, text) + "\n")
+	}
+	return b.String(), offset
+}
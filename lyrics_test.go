@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchLyrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("artist_name") != "Radiohead" || q.Get("track_name") != "Airbag" || q.Get("album_name") != "OK Computer" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		if q.Get("track_name") == "Airbag" {
+			fmt.Fprint(w, `{"id":1,"plainLyrics":"In the next world war","syncedLyrics":"[00:12.34] In the next world war","instrumental":false}`)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u string) { lyricsURL = u }(lyricsURL)
+	lyricsURL = server.URL
+
+	lyrics, err := fetchLyrics(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyrics.Plain != "In the next world war" || lyrics.Synced == "" {
+		t.Errorf("got %+v", lyrics)
+	}
+}
+
+func TestFetchLyricsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":404,"name":"TrackNotFound","message":"Failed to find specified track"}`)
+	}))
+	defer server.Close()
+
+	defer func(u string) { lyricsURL = u }(lyricsURL)
+	lyricsURL = server.URL
+
+	if _, err := fetchLyrics(context.Background(), testTrack); !errors.Is(err, errNoLyrics) {
+		t.Errorf("got %v, want errNoLyrics", err)
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	lines := parseLRC("[ar:Radiohead]\n[00:12.34] In the next world war\n[00:20.00][01:05.50] Chorus\n[00:30.00]\nno timestamp")
+
+	want := []lyricLine{
+		{12*time.Second + 340*time.Millisecond, "In the next world war"},
+		{20 * time.Second, "Chorus"},
+		{30 * time.Second, ""},
+		{65*time.Second + 500*time.Millisecond, "Chorus"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d is %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+
+	for _, tt := range []struct {
+		pos  time.Duration
+		want int
+	}{
+		{0, -1},
+		{12*time.Second + 340*time.Millisecond, 0},
+		{25 * time.Second, 1},
+		{10 * time.Minute, 3},
+	} {
+		if got := currentLyricLine(lines, tt.pos); got != tt.want {
+			t.Errorf("currentLyricLine(%v) = %d, want %d", tt.pos, got, tt.want)
+		}
+	}
+}
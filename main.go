@@ -2,332 +2,2278 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
+	"image"
+	"io"
 	"os"
 	"regexp"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/ernesto27/spotifyclient"
-	"github.com/sashabaranov/go-openai"
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/ernesto27/stui/pkg/player"
+	"github.com/ernesto27/stui/pkg/stui"
 )
 
 var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
 var styleTitle = lipgloss.NewStyle().Foreground(lipgloss.Color("#b8ffcb")).MarginTop(1).Bold(true).Render
 var styleWarning = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff7cc8")).Render
+var styleBadge = lipgloss.NewStyle().Foreground(lipgloss.Color("#FDFF8C")).Bold(true).Render
+var borderColor = lipgloss.Color("62")
+var progressColors = [2]string{"#FF7CCB", "#FDFF8C"}
 
-const (
+// Layout settings, see the -padding and -max-width flags.
+var (
 	padding  = 2
 	maxWidth = 80
 )
 
-var openaiClient *openai.Client
+// minWidth is the narrowest progress bar or viewport content we lay out,
+// tiny terminals get clipped instead of negative widths.
+const minWidth = 1
+
+// defaultWidth is used when the terminal width is unknown and maxWidth does
+// not limit it.
+const defaultWidth = 80
+
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// userAgent names stui to the metadata APIs, MusicBrainz and Wikipedia ask
+// the apps for a way to contact them.
+func userAgent() string {
+	return "stui/" + version + " (https://github.com/ernesto27/stui)"
+}
+
+// completer is the provider selected with -provider.
+var completer ai.Provider
+
+// provider is the name of the registered AI backend, see registerProvider.
+var provider = "openai"
+
+// chatModel is the model used for every request, empty uses the default of
+// the provider.
+var chatModel string
+
+// getTrackInfo reads the currently playing track, it is a variable so tests
+// can replace the Spotify desktop app.
+var getTrackInfo = getSpotifyTrackInfo
+
+// fallbackModel is used to retry a section whose prompt exceeded the context
+// length of the default model.
+var fallbackModel string
+
+// verifyClaims enables a second pass that flags low confidence claims.
+var verifyClaims bool
+
+// language is the language the AI answers are written in, the model picks
+// English when it is empty.
+var language string
+
+// inLanguage asks for the answer to prompt in language.
+func inLanguage(prompt string) string {
+	return stui.InLanguage(prompt, language)
+}
+
+// debounce is how long a newly detected track must keep playing before its
+// info is fetched in watch mode.
+var debounce = 5 * time.Second
+
+// pollInterval is how often the player is checked for track changes in watch
+// mode.
+var pollInterval = 5 * time.Second
+
+// concurrency is the maximum number of requests in flight to the AI provider.
+var concurrency = 3
+
+// defaultAlbumNoise lists the edition qualifiers stripped from album names
+// before they are used in prompts and links.
+var defaultAlbumNoise = []string{
+	"deluxe",
+	"super deluxe",
+	"remaster",
+	"remastered",
+	"anniversary",
+	"expanded",
+	"edition",
+	"bonus track",
+	"bonus tracks",
+	"box set",
+	"reissue",
+}
+
+var albumNoise = defaultAlbumNoise
 
 type MusicInfo struct {
 	artist string
 	album  string
 	track  string
+	// featured are the other artists of the track, see setArtists.
+	featured string
+}
+
+type Section struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Error is why the section could not be fetched.
+	Error  string `json:"error,omitempty"`
+	key    string
+	prompt string
+	// streaming is set while the answer is still arriving.
+	streaming bool
+	// usage counts the requests of the section.
+	usage tokenUsage
+	// started is when the AI request in flight started, cancelRequest
+	// cancels it.
+	started       time.Time
+	cancelRequest context.CancelFunc
+}
+
+type Links struct {
+	YouTube      string `json:"youtube"`
+	GoogleImages string `json:"googleImages"`
+	Wikipedia    string `json:"wikipedia"`
+}
+
+type Result struct {
+	Artist   string    `json:"artist"`
+	Featured []string  `json:"featured,omitempty"`
+	Album    string    `json:"album"`
+	Track    string    `json:"track"`
+	Model    string    `json:"model"`
+	Sections []Section `json:"sections"`
+	Summary  *Summary  `json:"summary,omitempty"`
+	Links    Links     `json:"links"`
+	Lyrics   string    `json:"lyrics,omitempty"`
+}
+
+// Summary is the one line rating/genre/mood overview of an album.
+type Summary struct {
+	Rating int    `json:"rating"`
+	Genre  string `json:"genre"`
+	Mood   string `json:"mood"`
 }
 
 type model struct {
 	viewport viewport.Model
 	progress progress.Model
-	loading  bool
+	// spinner marks the sections still loading in progressView.
+	spinner spinner.Model
+	loading bool
 	MusicInfo
-	errMsg  string
+	errMsg string
+	// failure is shown on the error screen, see errorMsg.
+	failure error
+	// notice tells the result of an action such as an export.
+	notice  string
+	status  PlayerStatus
 	content string
-	percent float64
+	showRaw bool
+	// skipCache asks the providers again instead of reading cached answers,
+	// it is set by a forced refresh.
+	skipCache bool
+	// tab is the selected page, tabOffsets keeps the scroll position of
+	// every tab. raw and rendered are the markdown of the tab and its
+	// rendered version, renderedFor is what rendered was made from so an
+	// unchanged tab is not rendered again. tabChosen is set once a tab is
+	// selected, until then the first finished section is shown.
+	tab         int
+	tabOffsets  map[int]int
+	raw         string
+	rendered    string
+	renderedFor renderKey
+	tabChosen   bool
+	// sidebarFocus is set when tab moved the focus to the sidebar of the
+	// split layout, the navigation keys then select the sections. release
+	// is the album on MusicBrainz shown in the sidebar.
+	sidebarFocus bool
+	release      *Release
+	// artwork is the album cover, artworkShown is set once it was sent to
+	// the terminal and kittyID is its image id with the kitty protocol.
+	artwork      image.Image
+	artworkShown bool
+	// coverThemed is set while the colors come from the cover, see
+	// artworkTheme.
+	coverThemed bool
+	kittyID     int
+	artworkANSI string
+	// lyrics of the current track, lyricsDone is set once the lookup
+	// finished.
+	lyrics     *Lyrics
+	lyricsDone bool
+	// syncedLyrics are the timed lines of the lyrics, lyricsLine is the one
+	// at the last read playback position and lyricsSeq stops older position
+	// polls.
+	syncedLyrics []lyricLine
+	lyricsLine   int
+	lyricsSeq    int
+	// translation are the lines of the lyrics in translationLanguage, shown
+	// next to them while showTranslation is set.
+	translation     []string
+	showTranslation bool
+	translating     bool
+	// playbackPosition and playbackDuration are the progress of the track
+	// read from the player once loading is done, playbackSeq stops the polls
+	// of the previous track.
+	playbackPosition time.Duration
+	playbackDuration time.Duration
+	playbackSeq      int
+	// settings are the volume, shuffle and repeat of the player, nil until
+	// it answered. They are kept across tracks.
+	settings *player.Settings
+	// playerState is what the player answered last about the track shown,
+	// playing until a control or a poll tells otherwise.
+	playerState PlayerStatus
+	// cacheHits and cacheMisses count the sections of the track read from
+	// the cache or asked to the provider, refreshed is when loading ended.
+	cacheHits   int
+	cacheMisses int
+	refreshed   time.Time
+	// historyScreen is the open history browser, nil when it is closed.
+	historyScreen *historyView
+	// stats is the open listening stats screen, nil when it is closed.
+	stats *statsView
+	// historySearch is the open search of the history, nil when it is
+	// closed.
+	historySearch *historySearchView
+	// bookmarkNote is the open prompt of the note of a bookmark and
+	// bookmarksScreen the open bookmarks browser, nil when they are closed.
+	bookmarkNote    *bookmarkNote
+	bookmarksScreen *bookmarksView
+	// showHelp shows the help overlay with every key.
+	showHelp bool
+	// palette is the open command palette, nil when it is closed.
+	palette *commandPalette
+	// linkPicker is the open link selection screen, nil when it is closed.
+	linkPicker *linkPicker
+	// artistPicker selects a similar artist to ask about, nil when it is
+	// closed.
+	artistPicker *artistPicker
+	// reader is the internal reading mode, nil when it is closed.
+	reader *readerView
+	// compare is the album pinned with the P key or set with -compare, the
+	// albums that play are compared with it.
+	compare MusicInfo
+	// deepDive shows the artist instead of the track, diveFrom is the track
+	// it was entered from.
+	deepDive bool
+	diveFrom MusicInfo
+	// pickedArtist is the artist of the track the deep dive and the links
+	// are about, switched with W. A refresh keeps it, another track goes
+	// back to its first artist.
+	pickedArtist string
+	// query is the album given to stui query or opened from the catalog
+	// search, which does not follow the player and is neither scrobbled nor
+	// kept in the history.
+	query bool
+	// chat is the open chat about a track, nil when it is closed. chats
+	// keeps the conversations of the session by track.
+	chat  *chatView
+	chats map[MusicInfo][]chatTurn
+	// quiz is the open quiz about the artist and album, nil when it is
+	// closed.
+	quiz *quizView
+	// queue is the open up next screen, nil when it is closed. prefetched is
+	// the last track fetched ahead of time, nextLookedUp the last one whose
+	// queue was read to prefetch the next, see prefetchNextCmd.
+	queue        *queueView
+	prefetched   MusicInfo
+	nextLookedUp MusicInfo
+	// library is the open playlist browser, nil when it is closed.
+	library *libraryView
+	// catalog is the open Spotify catalog search, nil when it is closed.
+	catalog *catalogView
+	// tracks is the open tracklist of the album, nil when it is closed.
+	tracks *tracksView
+	// listenNext is the open screen of the suggestions to listen next.
+	listenNext *listenNextView
+	// ask is the box of a question about the track while typing it.
+	ask *askView
+	// prefetch models only fill the cache for a track that plays later.
+	prefetch bool
+	// search is the / search of the viewport, n and N move between matches
+	// instead of tracks while a query is set.
+	search searchState
+	// watch polls the player for track changes, notify sends a desktop
+	// notification when the polling finds a new track.
+	watch  bool
+	notify bool
+	// pendingTrack is a detected track change waiting for the debounce
+	// period, pendingSeq invalidates older settle checks.
+	pendingTrack MusicInfo
+	pendingSeq   int
+	sections     []Section
+	summary      *Summary
+	links        *Links
+	// steps is the number of requests of getInfo, loading ends once all of
+	// them are done.
+	steps     int
+	stepsDone int
+	// retries counts the requests tried again after a transient error.
+	retries int
+	// usage is the tokens of the session, it is kept across tracks. today
+	// is the usage of usageDay, for the daily budget.
+	usage    tokenUsage
+	today    tokenUsage
+	usageDay string
+	// changed is set when streamed content arrives and the viewport must be
+	// rendered again.
+	changed bool
 	mu      *sync.Mutex
-	height  int
+	// cancel stops the requests of the running getInfo, fetchCtx is their
+	// context, used to retry a section. fetches counts the goroutines of
+	// the fetches still running, see goFetch.
+	cancel   context.CancelFunc
+	fetchCtx context.Context
+	fetches  sync.WaitGroup
+	// listen is the track submitted to ListenBrainz when stui moves on.
+	listen *listen
+	// journaled is the last track written to the journal, hooked the last
+	// one the track_changed hooks ran for.
+	journaled MusicInfo
+	hooked    MusicInfo
+	height    int
+	width     int
 }
 
 func main() {
+	// The flags follow the command, stui artist takes the artist name before
+	// or after them.
+	command, args, err := parseSubcommand(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	// The version is for the bug reports, a broken config must not hide it.
+	if versionRequested(command, args) {
+		fmt.Printf("stui %s (commit %s, built %s) %s %s/%s\n", version, commit, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		return
+	}
+
+	configPath, explicit := configPathFromArgs(args)
+	// stui config init creates the file given with -config.
+	cfg, err := loadConfig(configPath, explicit && command != "config init")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	cfg.apply()
+
+	flag.String("config", configPath, "Path to the config file")
 	var artistParam string
 	flag.StringVar(&artistParam, "artist", "", "Artist name")
 	var albumParam string
 	flag.StringVar(&albumParam, "album", "", "Album name")
+	var stripParam string
+	flag.StringVar(&stripParam, "strip", "", "Comma separated extra album qualifiers to strip (e.g. \"mono,stereo mix\")")
+	flag.StringVar(&playerKey, "player", playerKey, "Player to read the track from: "+strings.Join(playerKeys(), ", "))
+	flag.StringVar(&provider, "provider", provider, "AI provider: "+strings.Join(providerNames(), ", "))
+	flag.StringVar(&ollamaURL, "ollama-url", ollamaURL, "Base URL of the Ollama server")
+	flag.StringVar(&openaiBaseURL, "openai-base-url", openaiBaseURL, "Base URL of a server with the OpenAI API for the openai provider, such as LM Studio")
+	flag.StringVar(&azureEndpoint, "azure-endpoint", azureEndpoint, "URL of the Azure OpenAI resource, such as https://my-resource.openai.azure.com")
+	flag.StringVar(&azureDeployment, "azure-deployment", azureDeployment, "Azure OpenAI deployment of the model, defaults to the model name")
+	flag.StringVar(&azureEmbeddingDeployment, "azure-embedding-deployment", azureEmbeddingDeployment, "Azure OpenAI deployment of the embeddings model, defaults to the model name")
+	flag.StringVar(&azureAPIVersion, "azure-api-version", azureAPIVersion, "Azure OpenAI API version")
+	flag.StringVar(&chatModel, "model", chatModel, "Model name (e.g. gpt-4o, gpt-4-turbo, claude-3-5-sonnet-latest, llama3), defaults to the provider default")
+	flag.StringVar(&embeddingModel, "embedding-model", embeddingModel, "Embeddings model of the search by meaning of the history, defaults to the provider default")
+	flag.StringVar(&fallbackModel, "fallback-model", fallbackModel, "Model used to retry sections that exceed the context length (e.g. gpt-3.5-turbo-16k)")
+	flag.IntVar(&concurrency, "concurrency", concurrency, "Maximum number of requests in flight to the AI provider, across sections, refreshes and prefetches")
+	flag.BoolVar(&verifyClaims, "verify", verifyClaims, "Ask the model to flag claims it is not confident about")
+	flag.StringVar(&language, "language", language, "Language of the AI answers (e.g. Spanish, German, Japanese), defaults to English")
+	flag.StringVar(&answerLength, "length", answerLength, "Length of the AI sections: short, medium, long or a number of characters, defaults to the model's")
+	flag.StringVar(&translateTo, "translate-to", translateTo, "Language the lyrics are translated to with t, defaults to -language or English")
+	flag.IntVar(&padding, "padding", padding, "Horizontal padding around the content")
+	flag.IntVar(&maxWidth, "max-width", maxWidth, "Maximum width of the progress bar and content, 0 uses the whole terminal")
+	var compareParam string
+	flag.StringVar(&compareParam, "compare", "", "Compare the album with another one, formatted as \"Artist - Album\"")
+	flag.DurationVar(&debounce, "debounce", debounce, "How long a new track must keep playing before fetching its info in watch mode")
+	flag.DurationVar(&pollInterval, "watch-interval", pollInterval, "How often to check the player for track changes in watch mode")
+	var watchParam bool
+	flag.BoolVar(&watchParam, "watch", cfg.Watch, "Refresh automatically when the playing track changes")
+	var notifyParam bool
+	flag.BoolVar(&notifyParam, "notify", cfg.Notify, "Send a desktop notification when the track changes (requires -watch or stui serve)")
+	flag.BoolVar(&notifyTeaser, "notify-teaser", notifyTeaser, "Add a one line AI teaser of the track to the notifications")
+	var outputParam string
+	flag.StringVar(&outputParam, "output", "tui", "Output format: tui, markdown, text (rendered markdown) or json")
+	var jsonParam bool
+	flag.BoolVar(&jsonParam, "json", false, "Print the result as JSON and exit")
+	var noTUIParam bool
+	flag.BoolVar(&noTUIParam, "no-tui", false, "Print the result as markdown and exit")
+	var renderParam bool
+	flag.BoolVar(&renderParam, "render", false, "Render the markdown for the terminal when used with -no-tui")
+	var compactParam bool
+	flag.BoolVar(&compactParam, "compact", false, "Print compact JSON when used with -json")
+	flag.StringVar(&layout, "layout", layout, "Layout: tabs, or split for a sidebar with the track details and the sections")
+	flag.StringVar(&artworkMode, "artwork", artworkMode, "How to show the album cover: auto, kitty, iterm2, sixel, ansi or off")
+	flag.IntVar(&artworkCols, "artwork-width", artworkCols, "Width of the album cover in terminal columns")
+	flag.BoolVar(&artworkTheme, "artwork-theme", artworkTheme, "Color the title, border and progress bar after the album cover")
+	flag.DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "How long answers are cached on disk, 0 disables the cache")
+	flag.BoolVar(&offline, "offline", offline, "Ask no AI provider nor online source, show the cached sections however old and the ones of the history")
+	flag.BoolVar(&debugMode, "debug", debugMode, "Log the requests, timings, token counts, cache lookups and player polls to -debug-file")
+	flag.StringVar(&debugFile, "debug-file", debugFile, "File of the -debug log")
+	flag.StringVar(&proxyURL, "proxy", proxyURL, "Proxy URL of the outbound requests, HTTP_PROXY and HTTPS_PROXY are used without it")
+	flag.StringVar(&caFile, "ca-file", caFile, "PEM bundle of extra CA certificates to trust, such as the one of a corporate proxy")
+	flag.StringVar(&exportDir, "export-dir", exportDir, "Directory where the e key saves the content")
+	flag.StringVar(&journalDir, "journal-dir", journalDir, "Directory of a daily markdown journal of the tracks played")
+	flag.BoolVar(&journalSummary, "journal-summary", journalSummary, "Add a one line AI summary of the track to the journal entries")
+	flag.StringVar(&vaultDir, "vault-dir", vaultDir, "Folder of a notes vault, such as Obsidian, where the V key writes a note per album and track")
+	flag.BoolVar(&vaultAuto, "vault-auto", vaultAuto, "Write the notes of every track to the vault in watch mode")
+	flag.StringVar(&exportFormat, "export-format", exportFormat, "Format of the exported content: markdown or html")
+	flag.StringVar(&shareFormat, "share-format", shareFormat, "Format of the now playing card of the E key: svg or ansi")
+	flag.BoolVar(&historyEnabled, "history", historyEnabled, "Keep the listening history and generated info in a local database")
+	flag.BoolVar(&mouseEnabled, "mouse", mouseEnabled, "Scroll with the mouse wheel and click links, hold shift to select text")
+	flag.StringVar(&pager, "pager", pager, "Command the document is read in with O, internal for the reading mode of stui")
+	flag.BoolVar(&resumeEnabled, "resume", resumeEnabled, "Show the sections of the last session again when its track still plays")
+	flag.StringVar(&locale, "locale", locale, "Language of the interface: "+strings.Join(localeNames(), ", ")+", auto reads it from LANG")
+	flag.StringVar(&themeName, "theme", themeName, "Color theme: "+strings.Join(themeNames(), ", "))
+	flag.StringVar(&glamourStyle, "glamour-style", glamourStyle, "Markdown style: "+strings.Join(glamourStyleNames, ", ")+" or the path of a glamour JSON style, M cycles through them")
+	flag.IntVar(&maxRetries, "retries", maxRetries, "How many times a request that failed with a transient error is tried again")
+	flag.DurationVar(&retryDelay, "retry-delay", retryDelay, "Wait before the first retry, it doubles with every attempt")
+	flag.DurationVar(&aiTimeout, "ai-timeout", aiTimeout, "How long a request to the AI provider may take, 0 waits for as long as it takes")
+	var spotifyLoginParam bool
+	flag.BoolVar(&spotifyLoginParam, "spotify-login", false, "Log in to the Spotify Web API in the browser and exit (requires spotify_client_id)")
+	var lastfmLoginParam bool
+	flag.BoolVar(&lastfmLoginParam, "lastfm-login", false, "Log in to Last.fm in the browser for scrobbling and exit (requires lastfm_api_key and lastfm_secret)")
+	flag.BoolVar(&lastfmScrobble, "scrobble", lastfmScrobble, "Scrobble the tracks shown to Last.fm (requires -lastfm-login)")
+	flag.BoolVar(&prefetchNext, "prefetch-next", prefetchNext, "In watch mode, fetch the info of the next track in the queue before it starts")
+	flag.BoolVar(&redditEnabled, "reddit", redditEnabled, "Add a section with the Reddit threads about the album or song")
+	flag.BoolVar(&redditSummary, "reddit-summary", redditSummary, "Have the AI provider sum up the top comments of the Reddit threads")
+	flag.BoolVar(&sceneEnabled, "scene", sceneEnabled, "Add a section with the genres and era of the album and the scene around them")
+	flag.BoolVar(&newsEnabled, "news", newsEnabled, "Add a section with the latest news about the artist")
+	flag.BoolVar(&newsSummary, "news-summary", newsSummary, "Have the AI provider sum up the news about the artist")
+	flag.Bool("version", false, "Print version information and exit")
+	flag.StringVar(&serveAddr, "addr", serveAddr, "Address of the HTTP API of stui serve")
+	flag.IntVar(&printHistoryLimit, "limit", printHistoryLimit, "Tracks listed by stui history")
+	flag.StringVar(&statusFormat, "format", statusFormat, "Template of the line of stui status and the text of stui bar, with .Artist, .Album, .Track and .Player")
+	flag.DurationVar(&statusCacheTTL, "status-cache", statusCacheTTL, "How long stui status reuses the track it read last, 0 reads the player every time")
+	flag.Usage = usage
 
-	flag.Parse()
-
-	musicInfo := MusicInfo{}
+	serveParam := command == "serve"
+	mcpParam := command == "mcp"
+	deepDiveParam := command == "artist"
+	queryParam := command == "query"
+	var deepDiveArtist string
+	if deepDiveParam && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		deepDiveArtist, args = args[0], args[1:]
+	}
+	// stui query takes the artist, album and track before or after the flags.
+	var queryArgs []string
+	for queryParam && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		queryArgs, args = append(queryArgs, args[0]), args[1:]
+	}
+	flag.CommandLine.Parse(args)
+	var queryInfo MusicInfo
+	if deepDiveParam && deepDiveArtist == "" {
+		deepDiveArtist = strings.Join(flag.Args(), " ")
+	} else if queryParam {
+		if queryInfo, err = parseQuery(append(queryArgs, flag.Args()...)); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		watchParam = false
+	} else if !deepDiveParam && flag.NArg() > 0 {
+		fmt.Printf("Unexpected argument %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+	if command == "print" && outputParam == "tui" {
+		outputParam = "markdown"
+	}
 
-	if artistParam != "" && albumParam != "" {
-		musicInfo.artist = artistParam
-		musicInfo.album = albumParam
-	} else {
-		musicInfo = getSpotifyTrackInfo()
+	switch outputParam {
+	case "tui":
+	case "json":
+		jsonParam = true
+	case "markdown":
+		noTUIParam = true
+	case "text":
+		noTUIParam, renderParam = true, true
+	default:
+		fmt.Printf("Unknown output %q, use tui, markdown, text or json\n", outputParam)
+		os.Exit(1)
 	}
 
-	model, err := newModel(musicInfo.artist, musicInfo.track, musicInfo.album)
-	if err != nil {
-		fmt.Println("Could not initialize Bubble Tea model:", err)
+	if err := configureHTTP(); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	if musicInfo.artist == "" {
-		fmt.Println("Seems that you are listining to a podcast or something else...")
+	switch command {
+	case "config init":
+		if isTerminal(os.Stdin) {
+			if err := runSetup(configPath, os.Stdin, os.Stdout); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := initConfig(configPath); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Wrote", expandHome(configPath))
+		return
+	case "history":
+		if err := printHistory(os.Stdout, printHistoryLimit); err != nil {
+			fmt.Println("history:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The first launch asks for the settings instead of opening without the
+	// AI sections.
+	if command == "" && outputParam == "tui" && !offline && needsSetup(configPath, explicit) {
+		if err := runSetup(configPath, os.Stdin, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if spotifyLoginParam {
+		if spotifyClientID == "" {
+			fmt.Println("-spotify-login needs spotify_client_id in the config file")
+			os.Exit(1)
+		}
+		if err := spotifyLogin(spotifyClientID); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Logged in to Spotify")
+		return
+	}
+	if lastfmLoginParam {
+		if lastfmAPIKey == "" || lastfmSecret == "" {
+			fmt.Println("-lastfm-login needs lastfm_api_key and lastfm_secret in the config file")
+			os.Exit(1)
+		}
+		if err := lastfmLogin(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if lastfmScrobble {
+		session, err := loadLastfmSession()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		lastfmSessionKey = session.Key
+	}
+
+	if _, _, err := parseLength(answerLength); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	openaiClient = openai.NewClient(os.Getenv("OPENAI_TOKEN"))
+	if err := useLocale(locale); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	model.mu = &sync.Mutex{}
-	go model.getInfo()
+	// The theme sets glamourStyle, the flag wins over it.
+	style := glamourStyle
+	if err := applyTheme(themeName); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if flagSet("glamour-style") {
+		glamourStyle = style
+	}
+	if err := checkGlamourStyle(glamourStyle); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if !containsString(glamourStyleNames, glamourStyle) {
+		glamourStylePath = glamourStyle
+	}
 
-	if _, err := tea.NewProgram(model).Run(); err != nil {
-		fmt.Println("Bummer, there's been an error:", err)
+	if err := usePlayer(playerKey); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
-}
+	if debugMode {
+		if err := startDebugLog(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if command == "status" {
+		tmpl, err := parseStatusFormat(statusFormat)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := printStatus(os.Stdout, tmpl); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	artworkMode = detectArtworkMode(artworkMode)
+	if !containsString([]string{"kitty", "iterm2", "sixel", "ansi", "off"}, artworkMode) {
+		fmt.Printf("Unknown artwork mode %q, use auto, kitty, iterm2, sixel, ansi or off\n", artworkMode)
+		os.Exit(1)
+	}
+	if jsonParam || noTUIParam {
+		artworkMode = "off"
+	}
+	if layout != "tabs" && layout != "split" {
+		fmt.Printf("Unknown layout %q, use tabs or split\n", layout)
+		os.Exit(1)
+	}
+	if exportFormat != "markdown" && exportFormat != "html" {
+		fmt.Printf("Unknown export format %q, use markdown or html\n", exportFormat)
+		os.Exit(1)
+	}
+	if shareFormat != "svg" && shareFormat != "ansi" {
+		fmt.Printf("Unknown share format %q, use svg or ansi\n", shareFormat)
+		os.Exit(1)
+	}
+	if artworkCols < 2 {
+		artworkCols = 2
+	}
+	// Terminal cells are about twice as tall as they are wide.
+	artworkRows = artworkCols / 2
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var compareAlbum MusicInfo
+	if compareParam != "" {
+		var err error
+		compareAlbum, err = parseCompare(compareParam)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if padding < 0 {
+		padding = 0
+	}
+	if maxWidth < 0 {
+		maxWidth = 0
+	}
 
-func newModel(artist, track, album string) (*model, error) {
-	prog := progress.New(progress.WithScaledGradient("#FF7CCB", "#FDFF8C"))
+	for _, term := range strings.Split(stripParam, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			albumNoise = append(albumNoise, term)
+		}
+	}
 
-	return &model{
-		progress: prog,
-		loading:  true,
-		MusicInfo: MusicInfo{
-			artist: artist,
-			album:  album,
-			track:  track,
-		},
-	}, nil
-}
+	musicInfo := MusicInfo{}
+	status := PlayerPlaying
+
+	if queryParam {
+		musicInfo = queryInfo
+	} else if artistParam != "" && albumParam != "" {
+		musicInfo.artist = artistParam
+		musicInfo.album = albumParam
+	} else if deepDiveArtist == "" {
+		musicInfo, status = getTrackInfo()
+	}
+	// The deep dive is about the artist alone, of the track playing when no
+	// name is given.
+	if deepDiveParam {
+		if deepDiveArtist != "" {
+			musicInfo.artist = deepDiveArtist
+		}
+		musicInfo = MusicInfo{artist: musicInfo.artist}
+	}
+	// Without an artist or an episode there is nothing to look up, the idle
+	// screen waits for the next track.
+	if status == PlayerPlaying && musicInfo.artist == "" && !musicInfo.isPodcast() {
+		status = PlayerIdle
+	}
+
+	if status != PlayerPlaying && (jsonParam || noTUIParam) {
+		fmt.Println(statusMessage(status))
+		os.Exit(1)
+	}
 
-func getSpotifyTrackInfo() MusicInfo {
-	metadata, err := spotifyclient.GetCurrentTrack()
+	model, err := newModel(musicInfo.artist, musicInfo.track, musicInfo.album)
 	if err != nil {
-		fmt.Println("Seems that you don't have the spotify app desktop installed  or is not open :(")
+		fmt.Println("Could not initialize Bubble Tea model:", err)
 		os.Exit(1)
 	}
+	model.featured = musicInfo.featured
 
-	artistName := metadata.ArtistName[0]
-	trackName := metadata.TrackName
-	albumName := strings.ReplaceAll(strings.ToLower(metadata.AlbumName), "deluxe", "")
-	albumName = strings.ReplaceAll(albumName, "expanded edition - remastered", "")
-	albumName = strings.ReplaceAll(strings.ToLower(albumName), strings.ToLower("Bonus Tracks Edition"), "")
+	if status != PlayerPlaying {
+		model.loading = false
+		model.status = status
+	}
 
-	return MusicInfo{
-		artist: artistName,
-		album:  albumName,
-		track:  trackName,
+	if historyEnabled {
+		db, err := openHistory()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		history = db
+		defer history.Close()
+		if err := model.loadTodayUsage(); err != nil {
+			fmt.Println("history:", err)
+			os.Exit(1)
+		}
 	}
-}
 
-func (m model) Init() tea.Cmd {
-	return tickCmd()
-}
+	p, defaultModel, err := newProvider(provider)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	completer = p
+	if chatModel == "" {
+		chatModel = defaultModel
+	}
+	if embeddingModel == "" {
+		embeddingModel = providers[provider].embeddingModel
+	}
+	if f := providers[provider]; f.hasKey != nil && !f.hasKey() && !offline {
+		setMetadataOnly(&aiOff{keyEnv: f.keyEnv})
+	}
+	debugLog("start", "version", version, "provider", provider, "model", chatModel, "player", playerKey, "cache", cacheDir)
 
-func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
-		case "ctrl+r":
-			m.loading = true
-			m.percent = 0.0
-			m.content = ""
+	model.mu = &sync.Mutex{}
+	if pollInterval < time.Second {
+		pollInterval = time.Second
+	}
+	model.watch = watchParam
+	model.notify = notifyParam
+	model.deepDive = deepDiveParam
+	model.query = queryParam
+	model.compare = compareAlbum
 
-			musicInfo := getSpotifyTrackInfo()
-			m.MusicInfo = musicInfo
-			go m.getInfo()
+	// Panics are handled by recoverMain so the crash report is printed after
+	// the output, or after the terminal has been restored.
+	defer recoverMain()
 
-			return m, tickCmd()
+	if mcpParam {
+		// Stdout carries the protocol.
+		if err := serveMCP(model, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-		default:
-			var cmd tea.Cmd
-			m.viewport, cmd = m.viewport.Update(msg)
-			return m, cmd
+	if command == "bar" {
+		tmpl, err := parseStatusFormat(statusFormat)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
-	case tea.WindowSizeMsg:
-		m.height = msg.Height
-		m.progress.Width = msg.Width - padding*2 - 4
-		if m.progress.Width > maxWidth {
-			m.progress.Width = maxWidth
+		// Stdout carries the lines of the bar.
+		if err := runBar(model, tmpl, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-		return m, nil
-
-	case tickMsg:
-		m.mu.Lock()
-		m.percent += 0.01
-		m.mu.Unlock()
-
-		if m.percent >= 1.0 {
-			m.loading = false
+		return
+	}
 
-			vp, err := NewViewport(*m)
-			if err != nil {
-				panic(err)
-			}
-			m.viewport = vp
+	if serveParam {
+		if err := serve(model, serveAddr); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
-			return m, nil
+	if jsonParam {
+		if err := printJSON(os.Stdout, model, compactParam); err != nil {
+			fmt.Println("Could not print JSON:", err)
+			os.Exit(1)
 		}
-		return m, tickCmd()
-	default:
-		return m, tea.ClearScreen
+		return
 	}
-}
 
-func (m *model) View() string {
-	title := styleTitle(fmt.Sprintf("  %c %s - %s - %s", '♪', m.artist, m.album, m.track)) + "\n\n"
-	if m.loading {
-		pad := strings.Repeat(" ", padding)
-		return "  " + title +
-			pad + m.progress.ViewAs(m.percent) + "\n\n" +
-			pad + helpStyle("Press ctrl-c to quit")
+	if noTUIParam {
+		if err := printMarkdown(os.Stdout, model, renderParam); err != nil {
+			fmt.Println("Could not print markdown:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	errMsg := ""
-	if m.errMsg != "" {
-		errMsg = styleWarning(m.errMsg) + "\n\n"
+	options := []tea.ProgramOption{tea.WithoutCatchPanics()}
+	if mouseEnabled {
+		options = append(options, tea.WithMouseCellMotion())
 	}
+	// The goroutines of the fetch read program when they panic, it is set
+	// before they start.
+	program = tea.NewProgram(model, options...)
 
-	return title + errMsg + m.viewport.View() + m.helpView()
-}
+	if model.status == PlayerPlaying {
+		model.listenTo(model.MusicInfo)
+		s, err := loadSession()
+		if err != nil {
+			// A broken session is replaced on exit.
+			debugLog("session", "error", err)
+		}
+		if resumeEnabled && !model.query && s.resumes(model) {
+			model.resume(s)
+		} else {
+			model.startFetch()
+		}
+	}
 
-func (e model) helpView() string {
-	return helpStyle("\n  ↑/↓: Navigate • ctrl-r Refresh • ctrl-c: Quit \n")
+	programRunning = true
+	_, err = program.Run()
+	programRunning = false
+	// Drop the requests still in flight, e.g. after ctrl+c in the history.
+	model.stopFetch()
+	if resumeEnabled && !crashed() {
+		if err := model.saveSession(); err != nil {
+			fmt.Println("session:", err)
+		}
+	}
+	if l := model.endListen(); l != nil {
+		if listenBrainzToken != "" {
+			submitListen(context.Background(), l, false)
+		}
+		if scrobbling() {
+			scrobble(context.Background(), l, false)
+		}
+	}
+	if err != nil {
+		if crashed() {
+			exitCrash()
+		}
+		fmt.Println("Bummer, there's been an error:", err)
+		os.Exit(1)
+	}
 }
 
-type tickMsg time.Time
-
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
-		return tickMsg(t)
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
 	})
+	return set
 }
 
-func (m *model) DoOpenAIRequest(title string, query string, wg *sync.WaitGroup, lenSearches int) {
-	defer wg.Done()
-
-	resp, err := openaiClient.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model:       openai.GPT3Dot5Turbo,
-			Temperature: 0,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: query,
-				},
-			},
+func newModel(artist, track, album string) (*model, error) {
+	prog := progress.New(progress.WithScaledGradient(progressColors[0], progressColors[1]))
+
+	return &model{
+		progress:   prog,
+		spinner:    spinner.New(spinner.WithSpinner(spinner.MiniDot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color(progressColors[0])))),
+		loading:    true,
+		lyricsLine: -1,
+		tabOffsets: map[int]int{},
+		chats:      map[MusicInfo][]chatTurn{},
+		search:     searchState{line: -1},
+		MusicInfo: MusicInfo{
+			artist: artist,
+			album:  album,
+			track:  track,
 		},
-	)
+	}, nil
+}
 
-	if err != nil {
-		m.errMsg = "  openai api: " + err.Error()
-		m.percent += 1.0
-		return
-	}
+// PlayerStatus describes what the player is doing.
+type PlayerStatus = player.Status
 
-	c := title + "\n"
-	c += resp.Choices[0].Message.Content + "\n"
+const (
+	PlayerPlaying     = player.Playing
+	PlayerIdle        = player.Idle
+	PlayerUnavailable = player.Unavailable
+)
 
-	m.mu.Lock()
-	m.percent += float64(100/lenSearches) / 100
-	m.content += c
-	m.mu.Unlock()
+// statusMessage is shown instead of the track while nothing plays.
+func statusMessage(s PlayerStatus) string {
+	switch s {
+	case PlayerIdle:
+		return tr("Nothing is playing right now.")
+	case PlayerUnavailable:
+		if spotifyClientID != "" && !spotifyLoggedIn() {
+			return trf("Seems that %s is not open, %s to follow other devices.", playerName, errSpotifyLogin.Error())
+		}
+		return trf("Seems that %s is not installed or is not open :(", playerName)
+	}
+	return ""
 }
 
-func (m *model) getInfo() {
-	type search struct {
-		prompt string
-		title  string
+// cleanAlbumName removes edition tags such as "(Remastered 2011)",
+// "[Anniversary Edition]" or " - Deluxe" from an album name. A tag is
+// stripped when it contains any of the noise terms, matched case-insensitively.
+func cleanAlbumName(name string, noise []string) string {
+	if len(noise) == 0 {
+		return strings.TrimSpace(name)
 	}
 
-	searches := []search{
-		{
-			prompt: fmt.Sprintf("Give me album info, tracklist and credits of %s %s", m.artist, m.album),
-			title:  "## Album info and credits",
-		},
-		{
-			prompt: fmt.Sprintf("Give me album review of %s %s", m.artist, m.album),
-			title:  "## Album review",
-		},
+	terms := make([]string, len(noise))
+	for i, n := range noise {
+		terms[i] = regexp.QuoteMeta(n)
 	}
+	t := strings.Join(terms, "|")
 
-	if m.track != "" {
-		searches = append(searches, search{
-			prompt: fmt.Sprintf("Give me song info of %s %s", m.artist, m.track),
-			title:  "## Song info",
-		})
-
-		searches = append(searches, search{
-			prompt: fmt.Sprintf("Give me a biography of %s", m.artist),
-			title:  "## Artist bio",
-		})
-	}
+	reg := regexp.MustCompile(`(?i)\s*[\(\[][^\)\]]*\b(?:` + t + `)\b[^\)\]]*[\)\]]` +
+		`|\s+-\s+[^-]*\b(?:` + t + `)\b.*$` +
+		`|\s+\b(?:` + t + `)\s*$`)
 
-	var wg sync.WaitGroup
+	return strings.TrimSpace(reg.ReplaceAllString(name, ""))
+}
 
-	for _, search := range searches {
-		wg.Add(1)
-		go m.DoOpenAIRequest(search.title, search.prompt, &wg, len(searches))
+// parseCompare parses the -compare value, "Artist - Album".
+func parseCompare(s string) (MusicInfo, error) {
+	artist, album, found := strings.Cut(s, " - ")
+	artist = strings.TrimSpace(artist)
+	album = strings.TrimSpace(album)
+	if !found || artist == "" || album == "" {
+		return MusicInfo{}, fmt.Errorf("invalid -compare value %q, expected \"Artist - Album\"", s)
 	}
-	wg.Wait()
 
-	bandNameQuery := strings.ReplaceAll(m.artist, " ", "+")
-	songNameQuery := strings.ReplaceAll(m.track, " ", "+")
-	albumNameQuery := strings.ReplaceAll(m.album, " ", "+")
+	return MusicInfo{artist: artist, album: album}, nil
+}
 
-	reg, err := regexp.Compile("[^a-zA-Z0-9]+")
-	if err != nil {
-		log.Fatal(err)
+func (m *model) Init() tea.Cmd {
+	var cmds []tea.Cmd
+	if m.status == PlayerPlaying {
+		cmds = append(cmds, tickCmd(), m.spinner.Tick)
 	}
+	if m.watch {
+		cmds = append(cmds, pollTrackCmd())
+	} else if m.status != PlayerPlaying {
+		cmds = append(cmds, idlePollCmd())
+	}
+	return tea.Batch(cmds...)
+}
 
-	bandNameQuery = reg.ReplaceAllString(bandNameQuery, "+")
-	albumNameQuery = reg.ReplaceAllString(albumNameQuery, "+")
-	songNameQuery = reg.ReplaceAllString(songNameQuery, "+")
+// refresh resets the model and starts fetching info for musicInfo.
+func (m *model) refresh(musicInfo MusicInfo) tea.Cmd {
+	return m.reload(musicInfo, false)
+}
 
-	youtubeURL := fmt.Sprintf("https://www.youtube.com/results?search_query=%s+%s", bandNameQuery, songNameQuery)
-	googleImagesURL := fmt.Sprintf("\nhttps://www.google.com/search?q=%s+%s&tbm=isch", bandNameQuery, albumNameQuery)
-	wikipediaURL := fmt.Sprintf("\nhttps://www.google.com/search?q=wikipedia+%s+%s", bandNameQuery, albumNameQuery)
+// trackChanged is refresh for a track found by polling the player.
+func (m *model) trackChanged(musicInfo MusicInfo) tea.Cmd {
+	// stui artist waiting for playback dives into the artist that plays.
+	if m.deepDive {
+		musicInfo = MusicInfo{artist: musicInfo.artist}
+	}
+	cmd := m.refresh(musicInfo)
+	if m.notify || len(webhooks) > 0 {
+		m.notifyTrackChange(m.fetchCtx, musicInfo)
+	}
+	return cmd
+}
 
+// reload is refresh, optionally asking the providers again for answers that
+// are in the cache.
+func (m *model) reload(musicInfo MusicInfo, skipCache bool) tea.Cmd {
+	m.reset(musicInfo)
 	m.mu.Lock()
-	m.content += `
-## Links 
-` + youtubeURL + "\n" + googleImagesURL + "\n" + wikipediaURL
+	m.skipCache = skipCache
 	m.mu.Unlock()
+	m.listenTo(musicInfo)
+	m.startFetch()
 
+	return tea.Batch(tickCmd(), m.spinner.Tick)
 }
 
-func NewViewport(m model) (viewport.Model, error) {
-	const width = 120
+// reset cancels the running fetch and clears what was shown for the previous
+// track.
+func (m *model) reset(musicInfo MusicInfo) {
+	m.stopFetch()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	height := m.height - 5
-	if m.errMsg != "" {
-		height = 15
+	m.skipCache = false
+	m.notice = ""
+	m.search = searchState{line: -1}
+	m.status = PlayerPlaying
+	m.loading = true
+	m.steps = 0
+	m.stepsDone = 0
+	m.retries = 0
+	m.content = ""
+	m.raw = ""
+	m.rendered = ""
+	m.renderedFor = renderKey{}
+	m.release = nil
+	m.tabOffsets = map[int]int{}
+	m.viewport = viewport.Model{}
+	m.sections = nil
+	m.summary = nil
+	m.links = nil
+	m.artwork = nil
+	m.artworkShown = false
+	m.kittyID = 0
+	m.artworkANSI = ""
+	m.lyrics = nil
+	m.syncedLyrics = nil
+	m.lyricsLine = -1
+	m.lyricsDone = false
+	m.translation = nil
+	m.showTranslation = false
+	m.translating = false
+	m.playbackPosition, m.playbackDuration = 0, 0
+	m.playbackSeq++
+	m.cacheHits, m.cacheMisses = 0, 0
+	if musicInfo != m.MusicInfo {
+		m.pickedArtist = ""
 	}
+	m.MusicInfo = musicInfo
+}
 
-	vp := viewport.New(width, height)
-	vp.Style = lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
-		PaddingRight(2)
-
-	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(width),
-	)
-	if err != nil {
-		return viewport.Model{}, err
-	}
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m.fitViewport()
+	return next, cmd
+}
 
-	str, err := renderer.Render(m.content)
-	if err != nil {
-		return viewport.Model{}, err
+func (m *model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.failure != nil {
+			return m.updateFailure(msg)
+		}
+		if m.showHelp {
+			return m.updateHelpOverlay(msg)
+		}
+		if m.palette != nil {
+			return m.updatePalette(msg)
+		}
+		if m.historyScreen != nil {
+			return m.updateHistory(msg)
+		}
+		if m.stats != nil {
+			return m.updateStats(msg)
+		}
+		if m.historySearch != nil {
+			return m.updateHistorySearch(msg)
+		}
+		if m.bookmarkNote != nil {
+			return m.updateBookmarkNote(msg)
+		}
+		if m.bookmarksScreen != nil {
+			return m.updateBookmarks(msg)
+		}
+		if m.linkPicker != nil {
+			return m.updateLinkPicker(msg)
+		}
+		if m.artistPicker != nil {
+			return m.updateArtistPicker(msg)
+		}
+		if m.reader != nil {
+			return m.updateReader(msg)
+		}
+		if m.chat != nil {
+			return m.updateChat(msg)
+		}
+		if m.quiz != nil {
+			return m.updateQuiz(msg)
+		}
+		if m.queue != nil {
+			return m.updateQueue(msg)
+		}
+		if m.library != nil {
+			return m.updateLibrary(msg)
+		}
+		if m.catalog != nil {
+			return m.updateCatalog(msg)
+		}
+		if m.tracks != nil {
+			return m.updateTracks(msg)
+		}
+		if m.listenNext != nil {
+			return m.updateListenNext(msg)
+		}
+		if m.ask != nil {
+			return m.updateAsk(msg)
+		}
+		if m.search.typing {
+			return m.updateSearchInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, keys.Quit):
+			m.stopFetch()
+			return m, tea.Quit
+		case key.Matches(msg, keys.Palette):
+			m.openPalette()
+			return m, nil
+		case key.Matches(msg, keys.Help):
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, keys.History):
+			if history == nil || m.loading {
+				return m, nil
+			}
+			if err := m.openHistoryView(); err != nil {
+				m.errMsg = "  history: " + err.Error()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Bookmark):
+			if !m.hasContent() || m.track == "" {
+				return m, nil
+			}
+			if history == nil {
+				m.errMsg = "  bookmarks: the history is disabled, enable it with -history"
+				return m, nil
+			}
+			if err := m.openBookmarkNote(); err != nil {
+				m.errMsg = "  bookmarks: " + err.Error()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Bookmarks):
+			if history == nil || m.loading {
+				return m, nil
+			}
+			if err := m.openBookmarksView(); err != nil {
+				m.errMsg = "  bookmarks: " + err.Error()
+			}
+			return m, nil
+		case key.Matches(msg, keys.CancelSection):
+			m.cancelSections()
+			return m, nil
+		case key.Matches(msg, keys.Refresh, keys.RefreshUncached):
+			// The deep dive and stui query do not follow the player.
+			if m.deepDive || m.query {
+				return m, m.reload(m.MusicInfo, key.Matches(msg, keys.RefreshUncached))
+			}
+			musicInfo, status := getTrackInfo()
+			if status != PlayerPlaying {
+				wasPlaying := m.status == PlayerPlaying
+				m.loading = false
+				m.status = status
+				if wasPlaying {
+					return m, m.waitForPlayback()
+				}
+				return m, nil
+			}
+
+			return m, m.reload(musicInfo, key.Matches(msg, keys.RefreshUncached))
+
+		case key.Matches(msg, keys.PlayPause):
+			return m, playerCmd(player.PlayPause)
+		case key.Matches(msg, keys.Search):
+			if m.hasContent() {
+				m.startSearch()
+			}
+			return m, nil
+		case m.search.query != "" && key.Matches(msg, keys.ClearSearch):
+			m.clearSearch()
+			return m, nil
+		case m.search.query != "" && key.Matches(msg, keys.PrevMatch):
+			m.nextMatch(-1)
+			return m, nil
+		case m.search.query != "" && key.Matches(msg, keys.NextMatch):
+			m.nextMatch(1)
+			return m, nil
+		case key.Matches(msg, keys.Next):
+			return m, playerCmd(player.Next)
+		case key.Matches(msg, keys.Previous):
+			return m, playerCmd(player.Previous)
+		case key.Matches(msg, keys.VolumeUp):
+			return m, m.adjustPlayer(player.VolumeUp)
+		case key.Matches(msg, keys.VolumeDown):
+			return m, m.adjustPlayer(player.VolumeDown)
+		case key.Matches(msg, keys.Shuffle):
+			return m, m.adjustPlayer(player.Shuffle)
+		case key.Matches(msg, keys.Repeat):
+			return m, m.adjustPlayer(player.Repeat)
+		case key.Matches(msg, keys.SwitchPlayer):
+			return m, m.switchPlayer()
+
+		// In the split layout tab moves the focus between the sidebar and
+		// the content.
+		case m.split() && key.Matches(msg, keys.NextTab, keys.PrevTab):
+			if m.hasContent() {
+				m.sidebarFocus = !m.sidebarFocus
+			}
+			return m, nil
+		case m.split() && m.sidebarFocus && key.Matches(msg, keys.Up, keys.Down):
+			if !m.hasContent() {
+				return m, nil
+			}
+			index := m.tab + 1
+			if key.Matches(msg, keys.Up) {
+				index = m.tab - 1
+			}
+			return m, m.switchTab(index)
+
+		case key.Matches(msg, keys.NextTab, keys.PrevTab, keys.Tabs, keys.Lyrics):
+			if !m.hasContent() || m.tabCount() == 0 {
+				return m, nil
+			}
+
+			index := m.tab
+			switch {
+			case key.Matches(msg, keys.NextTab):
+				index = (m.tab + 1) % m.tabCount()
+			case key.Matches(msg, keys.PrevTab):
+				index = (m.tab + m.tabCount() - 1) % m.tabCount()
+			case key.Matches(msg, keys.Lyrics):
+				index = m.lyricsTabIndex()
+			default:
+				index = int(msg.String()[0] - '1')
+			}
+			return m, m.switchTab(index)
+
+		case key.Matches(msg, keys.Translate):
+			return m, m.toggleTranslation()
+		case key.Matches(msg, keys.OpenLink):
+			if m.hasContent() {
+				m.openLinkPicker()
+			}
+			return m, nil
+		case key.Matches(msg, keys.SimilarArtists):
+			if m.hasContent() {
+				m.openArtistPicker()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Chat):
+			if m.hasContent() {
+				m.openChat()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Ask):
+			if m.hasContent() && !m.loading {
+				m.openAsk()
+			}
+			return m, nil
+		case key.Matches(msg, keys.DeepDive):
+			return m, m.toggleDeepDive()
+		case key.Matches(msg, keys.SwitchArtist):
+			if !m.deepDive && !m.isPodcast() {
+				m.switchArtist()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Quiz):
+			if m.hasContent() && !m.isPodcast() {
+				return m, m.openQuiz()
+			}
+			return m, nil
+		case key.Matches(msg, keys.PinAlbum):
+			m.togglePin()
+			return m, nil
+		case key.Matches(msg, keys.Library):
+			if m.loading {
+				return m, nil
+			}
+			return m, m.openLibrary()
+		case key.Matches(msg, keys.Stats):
+			if history == nil || m.loading {
+				return m, nil
+			}
+			if err := m.openStats(); err != nil {
+				m.errMsg = "  history: " + err.Error()
+			}
+			return m, nil
+		case key.Matches(msg, keys.SearchHistory):
+			if history == nil || m.loading {
+				return m, nil
+			}
+			m.openHistorySearch()
+			return m, nil
+		case key.Matches(msg, keys.Catalog):
+			if m.loading {
+				return m, nil
+			}
+			m.openCatalog()
+			return m, nil
+		case key.Matches(msg, keys.Tracks):
+			if m.hasContent() && !m.isPodcast() && m.album != "" {
+				return m, m.openTracks()
+			}
+			return m, nil
+		case key.Matches(msg, keys.ListenNext):
+			if m.hasContent() && !m.isPodcast() && m.track != "" {
+				return m, m.openListenNext()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Queue):
+			if m.hasContent() && !m.isPodcast() {
+				return m, m.openQueue()
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Copy, keys.CopyAll):
+			if !m.hasContent() {
+				return m, nil
+			}
+			if key.Matches(msg, keys.CopyAll) {
+				return m, m.copyText(tr("the document"), m.markdown())
+			}
+			if text := m.currentTabText(); text != "" {
+				return m, m.copyText(tr("the tab"), text)
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.RetrySection):
+			return m, m.retrySection()
+		case key.Matches(msg, keys.EditPrompt):
+			m.openPromptEdit()
+			return m, nil
+
+		case key.Matches(msg, keys.Export):
+			if !m.hasContent() || m.loading {
+				return m, nil
+			}
+
+			path, err := m.export()
+			if err != nil {
+				m.errMsg = "  export: " + err.Error()
+			} else {
+				m.notice = "  " + trf("Saved to %s", path)
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Share):
+			if !m.hasContent() || m.loading || m.track == "" {
+				return m, nil
+			}
+			return m, m.share()
+
+		case key.Matches(msg, keys.Vault):
+			if !m.hasContent() || m.loading || m.deepDive {
+				return m, nil
+			}
+			if vaultDir == "" {
+				m.errMsg = "  vault: set vault_dir in the config file or use -vault-dir"
+				return m, nil
+			}
+
+			path, err := m.writeVault()
+			if err != nil {
+				m.errMsg = "  vault: " + err.Error()
+			} else {
+				m.notice = "  " + trf("Saved to %s", path)
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Top):
+			if m.hasContent() {
+				m.viewport.GotoTop()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Bottom):
+			if m.hasContent() {
+				m.viewport.GotoBottom()
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Read):
+			return m, m.read()
+
+		case key.Matches(msg, keys.Raw):
+			if !m.hasContent() || m.onLyricsTab() {
+				return m, nil
+			}
+
+			m.showRaw = !m.showRaw
+			percent := m.viewport.ScrollPercent()
+			m.viewport.SetContent(m.viewportContent())
+
+			// Raw and rendered content have different line counts, so keep the
+			// relative position instead of the absolute offset.
+			maxOffset := m.viewport.TotalLineCount() - m.viewport.Height
+			if maxOffset < 0 {
+				maxOffset = 0
+			}
+			m.viewport.SetYOffset(int(percent * float64(maxOffset)))
+			return m, nil
+
+		case key.Matches(msg, keys.MarkdownStyle):
+			m.cycleGlamourStyle()
+			return m, nil
+
+		default:
+			if !m.hasContent() {
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
+	case tea.WindowSizeMsg:
+		heightChanged := msg.Height != m.height
+		m.height = msg.Height
+		m.width = msg.Width
+		m.progress.Width = clampWidth(msg.Width - padding*2 - 4)
+		if m.reader != nil {
+			if text, err := m.readingText(); err == nil {
+				m.resizeReader(text)
+			}
+		}
+
+		if !m.hasContent() {
+			return m, nil
+		}
+
+		// Ignore 1 column jitter, re-rendering the markdown is not free.
+		widthDiff := m.viewportWidth() - m.viewport.Width
+		if heightChanged || widthDiff > 1 || widthDiff < -1 {
+			if err := m.renderViewport(); err != nil {
+				m.fail(err)
+			}
+		}
+		return m, nil
+
+	case positionMsg:
+		if msg.seq != m.lyricsSeq || !m.onLyricsTab() {
+			return m, nil
+		}
+
+		// Keep the last line when the position can not be read, the player
+		// may be restarting. fetchTrackLyrics resets the line.
+		m.mu.Lock()
+		line := currentLyricLine(m.syncedLyrics, msg.position)
+		moved := msg.err == nil && line != m.lyricsLine && m.hasContent()
+		if moved {
+			m.lyricsLine = line
+		}
+		m.mu.Unlock()
+
+		if moved {
+			rendered, offset := m.renderLyricsView()
+			m.rendered = rendered
+			m.viewport.SetContent(m.rendered)
+			m.viewport.SetYOffset(offset - m.viewport.Height/2)
+		}
+		return m, positionCmd(m.lyricsSeq)
+
+	case trackPollMsg:
+		m.playerState = msg.status
+		if !m.isNewTrack(msg.info, msg.status) {
+			m.pendingTrack = MusicInfo{}
+			m.pendingSeq++
+			return m, pollTrackCmd()
+		}
+
+		if debounce <= 0 {
+			return m, tea.Batch(m.trackChanged(msg.info), pollTrackCmd())
+		}
+
+		if msg.info != m.pendingTrack {
+			m.pendingTrack = msg.info
+			m.pendingSeq++
+			return m, tea.Batch(settleTrackCmd(m.pendingSeq), pollTrackCmd())
+		}
+		return m, pollTrackCmd()
+
+	case playerMsg:
+		m.playerState = msg.status
+		// Pausing keeps the info of the paused track on screen.
+		if msg.status != PlayerPlaying || (msg.info.artist == "" && !msg.info.isPodcast()) ||
+			(m.status == PlayerPlaying && msg.info == m.MusicInfo) {
+			return m, nil
+		}
+
+		return m, m.refresh(msg.info)
+
+	case idlePollMsg:
+		// The loop ends once a track plays, watch mode polls on its own.
+		if m.status == PlayerPlaying || m.watch {
+			return m, nil
+		}
+		if msg.status != PlayerPlaying || (msg.info.artist == "" && !msg.info.isPodcast()) {
+			m.status = msg.status
+			return m, idlePollCmd()
+		}
+
+		return m, m.trackChanged(msg.info)
+
+	case trackSettleMsg:
+		if msg.seq != m.pendingSeq || !m.isNewTrack(msg.info, msg.status) {
+			return m, nil
+		}
+
+		// The track changed again while waiting, restart the debounce period.
+		if msg.info != m.pendingTrack {
+			m.pendingTrack = msg.info
+			m.pendingSeq++
+			return m, settleTrackCmd(m.pendingSeq)
+		}
+
+		m.pendingTrack = MusicInfo{}
+		return m, m.trackChanged(msg.info)
+
+	case chatAnswerMsg:
+		m.answerChat(msg)
+		return m, nil
+
+	case quizMsg:
+		m.startQuiz(msg)
+		return m, nil
+
+	case playlistMsg:
+		m.playlistSaved(msg)
+		return m, nil
+
+	case shareMsg:
+		m.shared(msg)
+		return m, nil
+
+	case listenNextMsg:
+		m.listenNextLoaded(msg)
+		return m, nil
+
+	case suggestionsQueuedMsg:
+		m.suggestionsQueued(msg)
+		return m, nil
+
+	case similarMsg:
+		m.similarLoaded(msg)
+		return m, nil
+
+	case translationMsg:
+		m.lyricsTranslated(msg)
+		return m, nil
+
+	case queueMsg:
+		m.queueLoaded(msg)
+		return m, nil
+
+	case albumTracksMsg:
+		m.tracksLoaded(msg)
+		return m, nil
+
+	case playbackMsg:
+		return m, m.playbackUpdated(msg)
+	case errorMsg:
+		m.fail(msg.err)
+		return m, nil
+	case pagerDoneMsg:
+		if msg.err != nil {
+			m.errMsg = "  pager: " + msg.err.Error()
+		}
+		return m, nil
+	case nextTrackMsg:
+		m.nextTrackLoaded(msg)
+		return m, nil
+	case settingsMsg:
+		m.settingsUpdated(msg)
+		return m, nil
+
+	case libraryMsg:
+		m.libraryLoaded(msg)
+		return m, nil
+	case libraryTracksMsg:
+		m.libraryTracksLoaded(msg)
+		return m, nil
+	case catalogMsg:
+		m.catalogLoaded(msg)
+		return m, nil
+	case libraryPlayMsg:
+		return m, m.libraryPlayed(msg)
+
+	case historyLyricsMsg:
+		if err := m.renderViewport(); err != nil {
+			m.fail(err)
+		}
+		return m, nil
+
+	case releaseMsg:
+		if msg.info == m.MusicInfo {
+			m.mu.Lock()
+			m.release = msg.release
+			m.mu.Unlock()
+		}
+		return m, nil
+	case spinner.TickMsg:
+		// The spinner stops with the loading, reload starts it again.
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tickMsg:
+		m.mu.Lock()
+		changed := m.changed
+		m.changed = false
+		done := m.steps > 0 && m.stepsDone >= m.steps
+		if done {
+			m.loading = false
+		}
+		m.mu.Unlock()
+
+		// Before rendering, the cover changes the height of the header.
+		artwork := m.artworkCmd()
+
+		if !done && changed {
+			m.followFinishedSection()
+			if err := m.renderViewport(); err != nil {
+				m.fail(err)
+			}
+		}
+
+		if done {
+			m.refreshed = time.Now()
+			// An album without a cover goes back to the colors of the theme.
+			m.mu.Lock()
+			cover := m.artwork
+			m.mu.Unlock()
+			if cover == nil {
+				m.applyCoverTheme(nil)
+			}
+
+			if err := m.renderViewport(); err != nil {
+				m.fail(err)
+			}
+
+			return m, tea.Batch(artwork, m.startPlayback(), settingsCmd(), m.releaseCmd())
+		}
+		return m, tea.Batch(tickCmd(), artwork)
+	default:
+		return m, nil
+	}
+}
+
+func (m *model) View() string {
+	if m.failure != nil {
+		return m.failureView()
+	}
+	if m.showHelp {
+		return m.helpOverlayView()
+	}
+	if m.palette != nil {
+		return m.paletteView()
+	}
+	if m.historyScreen != nil {
+		return m.historyScreenView()
+	}
+	if m.stats != nil {
+		return m.statsScreenView()
+	}
+	if m.historySearch != nil {
+		return m.historySearchScreenView()
+	}
+	if m.bookmarkNote != nil {
+		return m.bookmarkNoteView()
+	}
+	if m.bookmarksScreen != nil {
+		return m.bookmarksScreenView()
+	}
+	if m.linkPicker != nil {
+		return m.linkPickerView()
+	}
+	if m.artistPicker != nil {
+		return m.artistPickerView()
+	}
+	if m.reader != nil {
+		return m.readerScreenView()
+	}
+	if m.chat != nil {
+		return m.chatScreenView()
+	}
+	if m.quiz != nil {
+		return m.quizScreenView()
+	}
+	if m.queue != nil {
+		return m.queueScreenView()
+	}
+	if m.library != nil {
+		return m.libraryScreenView()
+	}
+	if m.catalog != nil {
+		return m.catalogScreenView()
+	}
+	if m.tracks != nil {
+		return m.tracksScreenView()
+	}
+	if m.listenNext != nil {
+		return m.listenNextScreenView()
+	}
+
+	if m.status != PlayerPlaying {
+		pad := strings.Repeat(" ", padding)
+		return "\n" + pad + styleWarning(statusMessage(m.status)) + "\n\n" +
+			pad + m.statusHelpView()
+	}
+
+	if m.loading && !m.hasContent() {
+		pad := strings.Repeat(" ", padding)
+		return "  " + m.titleView() +
+			pad + m.progressView() + "\n\n" +
+			pad + m.loadingHelpView() + "\n\n" +
+			m.statusBarView()
+	}
+
+	top, bottom := m.chromeViews()
+	if m.split() {
+		content := m.viewport.View()
+		return top + lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(lipgloss.Height(content)), content) + bottom
+	}
+	return top + m.viewport.View() + bottom
+}
+
+// progressView lists the sections with a spinner while they load, a check
+// once done or a warning when they failed, followed by how many requests
+// are done. The items wrap to the width of the viewport.
+func (m *model) progressView() string {
+	m.mu.Lock()
+	steps, done, retries := m.steps, m.stepsDone, m.retries
+	var items []string
+	for _, s := range m.sections {
+		label := sectionLabel(s)
+		switch {
+		case s.Error != "":
+			items = append(items, styleWarning("⚠ "+label))
+		case s.Content != "" && !s.streaming:
+			items = append(items, helpStyle("✓ "+label))
+		default:
+			item := m.spinner.View() + " " + label
+			if waiting := waitingView(s); waiting != "" {
+				item += " " + helpStyle(waiting+" • "+helpItem("Cancel", keys.CancelSection))
+			}
+			items = append(items, item)
+		}
+	}
+	m.mu.Unlock()
+
+	status := fmt.Sprintf("%d/%d", done, steps)
+	if retries > 0 {
+		status += " • " + trf("%d retries", retries)
+	}
+	items = append(items, helpStyle(status))
+
+	width := m.viewportWidth()
+	lines := []string{items[0]}
+	for _, item := range items[1:] {
+		last := &lines[len(lines)-1]
+		if lipgloss.Width(*last)+2+lipgloss.Width(item) > width {
+			lines = append(lines, item)
+			continue
+		}
+		*last += "  " + item
+	}
+	return strings.Join(lines, "\n"+strings.Repeat(" ", padding))
+}
+
+// stepDone counts a finished request of getInfo.
+func (m *model) stepDone(ctx context.Context) {
+	m.whileCurrent(ctx, func() {
+		m.stepsDone++
+		m.changed = true
+	})
+}
+
+func (m *model) titleView() string {
+	if m.deepDive {
+		return styleTitle(fmt.Sprintf("  %c %s", '✦', m.artist)) + "\n\n"
+	}
+	if m.isPodcast() {
+		return styleTitle(fmt.Sprintf("  %c %s - %s", '🎙', m.album, m.track)) + m.playbackTimeView() + "\n\n"
+	}
+	return styleTitle(fmt.Sprintf("  %c %s - %s - %s", '♪', m.artistLine(), m.album, m.track)) + m.playbackTimeView() + "\n\n"
+}
+
+// chromeViews returns what is shown above and below the viewport.
+func (m *model) chromeViews() (top string, bottom string) {
+	pad := strings.Repeat(" ", padding)
+
+	// Streamed content is shown while the rest is still loading, then the
+	// bar follows the playback.
+	progress := ""
+	if m.loading {
+		progress = "\n" + pad + m.progressView()
+	} else if playback := m.playbackView(); playback != "" {
+		progress = "\n" + pad + playback
+	}
+
+	// The fetch writes the errors and the summary while the view is built,
+	// fitViewport builds it on every Update.
+	m.mu.Lock()
+	shownErr, summary := m.errMsg, m.summary
+	m.mu.Unlock()
+
+	errMsg := ""
+	if shownErr != "" {
+		errMsg = styleWarning(shownErr) + "\n\n"
+	}
+	if m.notice != "" {
+		errMsg += helpStyle(m.notice) + "\n\n"
+	}
+	if off := metadataOnly(); off != nil {
+		errMsg = styleWarning(off.banner()) + "\n\n" + errMsg
+	}
+
+	badge := ""
+	if summary != nil {
+		badge = "  " + styleBadge(fmt.Sprintf("★ %d/10 • %s • %s", summary.Rating, summary.Genre, summary.Mood)) + "\n\n"
+	}
+
+	help := m.helpView()
+	if m.search.typing || m.search.query != "" {
+		help = m.searchView()
+	}
+	if m.ask != nil {
+		help = m.askBoxView()
+	}
+
+	// The sidebar of the split layout has the cover and the sections.
+	if m.split() {
+		top = m.titleView() + badge + errMsg
+	} else {
+		top = m.headerView(m.titleView()+badge) + errMsg + m.tabBarView()
+	}
+	statusBar := "\n" + m.statusBarView()
+	bottom = m.scrollView() + progress + help + statusBar
+	return top, bottom
+}
+
+// viewportHeight fills the terminal rows left by the rest of the view.
+func (m *model) viewportHeight() int {
+	top, bottom := m.chromeViews()
+	height := m.height - strings.Count(top, "\n") - strings.Count(bottom, "\n")
+	if height < 1 {
+		height = 1
+	}
+	return height
+}
+
+// fitViewport resizes the viewport after the rows around it changed, for
+// example when a notice was added.
+func (m *model) fitViewport() {
+	if !m.hasContent() {
+		return
+	}
+	if height := m.viewportHeight(); height != m.viewport.Height {
+		m.viewport.Height = height
+		m.viewport.SetYOffset(m.viewport.YOffset)
+	}
+}
+
+// hasContent reports whether the viewport has something to show, which can
+// happen before loading finishes when the answers are streamed.
+func (m *model) hasContent() bool {
+	return m.status == PlayerPlaying && m.rendered != ""
+}
+
+// tabsHelp names what tab does, it moves the focus in the split layout.
+func (e *model) tabsHelp() string {
+	if e.split() {
+		return "Focus/Sections"
+	}
+	return "Tabs"
+}
+
+func (e *model) helpView() string {
+	// The help overlay lists the other keys.
+	items := []string{
+		helpItem("Navigate", keys.Up, keys.Down),
+		helpItem(e.tabsHelp(), keys.NextTab, keys.Tabs),
+		helpItem("Lyrics", keys.Lyrics),
+		helpItem("Play/Next/Prev", keys.PlayPause, keys.Next, keys.Previous),
+		helpItem("Commands", keys.Palette),
+		helpItem("All keys", keys.Help),
+		helpItem("Quit", keys.Quit),
+	}
+	return helpStyle("\n" + wrapHelp(items, e.width) + "\n")
+}
+
+// wrapHelp joins the help items in lines that fit in width, which is not
+// limited when 0.
+func wrapHelp(items []string, width int) string {
+	var lines []string
+	line := "  "
+	for i, item := range items {
+		if i == 0 {
+			line += item
+			continue
+		}
+		if width > 0 && lipgloss.Width(line+" • "+item) > width {
+			lines = append(lines, line)
+			line = "  " + item
+			continue
+		}
+		line += " • " + item
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// scrollView tells which lines of the tab are shown.
+func (m *model) scrollView() string {
+	total := m.viewport.TotalLineCount()
+	if total == 0 {
+		return "\n"
+	}
+
+	first := m.viewport.YOffset + 1
+	last := m.viewport.YOffset + m.viewport.VisibleLineCount()
+	view := fmt.Sprintf("\n  %s • %d%%", trf("lines %d-%d of %d", first, last, total), int(m.viewport.ScrollPercent()*100))
+	// The requests of the section shown, the fetch adds to them.
+	if u := m.tabUsage(); u.requests > 0 {
+		view += " • " + u.view()
+	}
+	return helpStyle(view)
+}
+
+// tabUsage is the usage of the section of the selected tab, read under m.mu
+// without building the tabs.
+func (m *model) tabUsage() tokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tab < len(m.sections) {
+		return m.sections[m.tab].usage
+	}
+	return tokenUsage{}
+}
+
+// usageView is the running total of the session, empty before the first
+// request.
+func (m *model) usageView() string {
+	m.mu.Lock()
+	u := m.usage
+	m.mu.Unlock()
+	if u.requests == 0 {
+		return ""
+	}
+	return u.view()
+}
+
+// watchView tells that the track is followed automatically.
+func (e *model) watchView() string {
+	if !e.watch {
+		return ""
+	}
+	return " • " + tr("watching")
+}
+
+func (e *model) statusHelpView() string {
+	if e.status == PlayerUnavailable {
+		return helpStyle(trf("Press %s to retry connecting to %s", keys.Refresh.Help().Key, playerName) + " • " + helpItem("Quit", keys.Quit) + e.watchView())
+	}
+	return helpStyle(tr("Waiting for playback…") + " • " + helpItem("Refresh", keys.Refresh) + " • " + helpItem("Quit", keys.Quit) + e.watchView())
+}
+
+func (e *model) loadingHelpView() string {
+	return helpStyle(helpItem("Refresh", keys.Refresh) + " • " + helpItem("Quit", keys.Quit))
+}
+
+type tickMsg time.Time
+
+// tickInterval is short enough for streamed answers to look live.
+const tickInterval = 200 * time.Millisecond
+
+func tickCmd() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func printJSON(w io.Writer, m *model, compact bool) error {
+	m.getInfo(context.Background())
+	m.printErrors()
+
+	enc := json.NewEncoder(w)
+	// Answers and links are not embedded in HTML, keep & and <> readable.
+	enc.SetEscapeHTML(false)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(m.result())
+}
+
+// printErrors writes the errors of the fetch to stderr.
+func (m *model) printErrors() {
+	if off := metadataOnly(); off != nil {
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(off.banner()))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errMsg != "" {
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(m.errMsg))
+	}
+	for _, s := range m.sections {
+		if s.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", s.Title, s.Error)
+		}
+	}
+}
+
+// printMarkdown fetches everything and writes the markdown document to w,
+// rendered with glamour when render is set.
+func printMarkdown(w io.Writer, m *model, render bool) error {
+	m.getInfo(context.Background())
+	m.printErrors()
+
+	doc := m.markdown()
+	if render {
+		var err error
+		doc, err = renderContent(doc, clampWidth(defaultWidth))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, strings.TrimRight(doc, "\n"))
+	return err
+}
+
+// markdown returns the content with the track as heading and the lyrics.
+func (m *model) markdown() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	heading := fmt.Sprintf("# %s - %s - %s\n\n", m.artistLine(), m.album, m.track)
+	if m.deepDive {
+		heading = "# " + m.artist + "\n\n"
+	}
+	doc := heading + m.content
+	if m.lyrics != nil && m.lyrics.Plain != "" {
+		doc += "\n\n## Lyrics\n" + strings.ReplaceAll(m.lyrics.Plain, "\n", "  \n")
+	}
+	return doc
+}
+
+func (m *model) result() Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := Result{
+		Artist:   m.artist,
+		Album:    m.album,
+		Track:    m.track,
+		Model:    chatModel,
+		Sections: []Section{},
+	}
+	if m.featured != "" {
+		r.Featured = m.artists()[1:]
+	}
+	for _, s := range m.sections {
+		if s.Content != "" || s.Error != "" {
+			r.Sections = append(r.Sections, s)
+		}
+	}
+	if m.links != nil {
+		r.Links = *m.links
+	}
+	if m.lyrics != nil {
+		r.Lyrics = m.lyrics.Plain
+	}
+	if m.summary != nil {
+		summary := *m.summary
+		r.Summary = &summary
+	}
+	return r
+}
+
+// buildContent assembles the markdown document from the sections, in declared
+// order, and the links. The caller must hold m.mu.
+func (m *model) buildContent() {
+	c := ""
+	for _, s := range m.sections {
+		if s.Content == "" {
+			continue
+		}
+		c += "## " + s.Title + "\n"
+		c += s.Content + "\n"
+	}
+
+	if m.links != nil {
+		c += `
+## Links 
+` + m.links.YouTube + "\n\n" + m.links.GoogleImages + "\n\n" + m.links.Wikipedia
+	}
+
+	m.content = c
+}
+
+type trackPollMsg struct {
+	info   MusicInfo
+	status PlayerStatus
+}
+
+// pollTrackCmd reads the current track after pollInterval.
+func pollTrackCmd() tea.Cmd {
+	// The player is read with the function set when the poll was made, the
+	// function may be replaced until it fires.
+	read := getTrackInfo
+	return tea.Tick(pollInterval, func(t time.Time) tea.Msg {
+		info, status := read()
+		return trackPollMsg{info: info, status: status}
+	})
+}
+
+// idleInterval is how often the player is checked while nothing plays.
+const idleInterval = 2 * time.Second
+
+type idlePollMsg struct {
+	info   MusicInfo
+	status PlayerStatus
+}
+
+// idlePollCmd reads the current track after idleInterval, the idle screen
+// waits with it for playback to start.
+func idlePollCmd() tea.Cmd {
+	read := getTrackInfo
+	return tea.Tick(idleInterval, func(t time.Time) tea.Msg {
+		info, status := read()
+		return idlePollMsg{info: info, status: status}
+	})
+}
+
+// waitForPlayback starts polling for a track after playback stopped.
+func (m *model) waitForPlayback() tea.Cmd {
+	if m.watch {
+		return nil
+	}
+	return idlePollCmd()
+}
+
+type trackSettleMsg struct {
+	seq    int
+	info   MusicInfo
+	status PlayerStatus
+}
+
+// settleTrackCmd reads the current track again once the debounce period is
+// over.
+func settleTrackCmd(seq int) tea.Cmd {
+	read := getTrackInfo
+	return tea.Tick(debounce, func(t time.Time) tea.Msg {
+		info, status := read()
+		return trackSettleMsg{seq: seq, info: info, status: status}
+	})
+}
+
+// isNewTrack reports whether a polled track should replace the current one.
+func (m *model) isNewTrack(info MusicInfo, status PlayerStatus) bool {
+	return status == PlayerPlaying && !m.loading && !m.deepDive && !m.query && (info.artist != "" || info.isPodcast()) &&
+		(m.status != PlayerPlaying || info != m.MusicInfo)
+}
+
+// viewportFrame is the horizontal space taken by the viewport border and
+// padding.
+const viewportFrame = 4
+
+// viewportWidth is the terminal width minus padding, with the content area
+// clamped by clampWidth.
+func (m *model) viewportWidth() int {
+	if m.width == 0 {
+		return clampWidth(defaultWidth) + viewportFrame
+	}
+
+	return clampWidth(m.width-padding*2-viewportFrame-m.sidebarCols()) + viewportFrame
+}
+
+// clampWidth limits width to the [minWidth, maxWidth] range, a maxWidth of 0
+// does not limit it.
+func clampWidth(width int) int {
+	if maxWidth > 0 && width > maxWidth {
+		width = maxWidth
+	}
+	if width < minWidth {
+		width = minWidth
+	}
+	return width
+}
+
+// renderViewport renders the selected tab for the current terminal size and
+// rebuilds the viewport, keeping the scroll position.
+func (m *model) renderViewport() error {
+	offset := m.viewport.YOffset
+
+	t, index := m.currentTab()
+	m.tab = index
+	if t.lyrics {
+		m.raw = ""
+		m.rendered, _ = m.renderLyricsView()
+		m.renderedFor = renderKey{}
+	} else if key := (renderKey{t.content, m.viewportWidth() - viewportFrame, glamourStyle}); key != m.renderedFor {
+		// While loading, only the tab whose section changed is rendered
+		// again.
+		rendered, err := renderContent(t.content, key.width)
+		if err != nil {
+			return err
+		}
+		m.raw = t.content
+		m.rendered = rendered
+		m.renderedFor = key
+	}
+
+	m.viewport = NewViewport(m)
+	m.viewport.SetYOffset(offset)
+	return nil
+}
+
+func NewViewport(m *model) viewport.Model {
+	vp := viewport.New(m.viewportWidth(), m.viewportHeight())
+	vp.KeyMap = keys.viewport()
+	vp.Style = lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		PaddingRight(2)
+
+	vp.SetContent(m.viewportContent())
+	return vp
+}
+
+// viewportContent returns the raw markdown of the tab or its rendered version
+// depending on the current toggle. The lyrics tab has no raw version.
+func (m *model) viewportContent() string {
+	return highlightMatches(m.unhighlightedContent(), m.search.query, m.search.line)
+}
+
+// unhighlightedContent is viewportContent without the search highlights.
+func (m *model) unhighlightedContent() string {
+	if m.showRaw && m.raw != "" {
+		return m.raw
+	}
+	return m.rendered
+}
+
+// renderKey is the markdown of a tab along with the wrap width and the
+// style it was rendered with.
+type renderKey struct {
+	content string
+	width   int
+	style   string
+}
+
+// markdownRenderer caches the glamour renderer, which is rebuilt only when
+// the word wrap width changes.
+var markdownRenderer struct {
+	sync.Mutex
+	renderer *glamour.TermRenderer
+	width    int
+	style    string
+}
+
+func renderContent(content string, width int) (string, error) {
+	markdownRenderer.Lock()
+	defer markdownRenderer.Unlock()
+
+	if markdownRenderer.renderer == nil || markdownRenderer.width != width || markdownRenderer.style != glamourStyle {
+		style := glamour.WithAutoStyle()
+		if glamourStyle != "auto" {
+			style = glamour.WithStylePath(expandHome(glamourStyle))
+		}
+		renderer, err := glamour.NewTermRenderer(style, glamour.WithWordWrap(width))
+		if err != nil {
+			return "", err
+		}
+
+		markdownRenderer.renderer = renderer
+		markdownRenderer.width = width
+		markdownRenderer.style = glamourStyle
 	}
 
-	vp.SetContent(str)
-	return vp, nil
+	return markdownRenderer.renderer.Render(content)
 }
@@ -0,0 +1,790 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+type stubCompleter struct{}
+
+func (stubCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	return "stub answer for: " + prompt, nil
+}
+
+var testTrack = MusicInfo{
+	artist: "Radiohead",
+	album:  "OK Computer",
+	track:  "Airbag",
+}
+
+func setupTest(t *testing.T, status PlayerStatus) *model {
+	t.Helper()
+
+	completer = stubCompleter{}
+	getLyrics = func(ctx context.Context, info MusicInfo) (*Lyrics, error) {
+		return &Lyrics{Plain: "In the next world war\nIn a jackknifed juggernaut"}, nil
+	}
+	getPlaybackPosition = func() (time.Duration, error) {
+		return 0, nil
+	}
+	getTrackDuration = func() (time.Duration, error) {
+		return 0, nil
+	}
+	getPlayerSettings = func() (player.Settings, error) {
+		return player.Settings{}, errors.New("no settings")
+	}
+	getArtwork = func(ctx context.Context, info MusicInfo) (image.Image, error) {
+		return image.NewRGBA(image.Rect(0, 0, 4, 4)), nil
+	}
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		if status != PlayerPlaying {
+			return MusicInfo{}, status
+		}
+		return testTrack, PlayerPlaying
+	}
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		return &Release{ID: "b1392450", Title: info.album, Date: "1997-05-21"}, nil
+	}
+	getWikipedia = func(ctx context.Context, info MusicInfo) ([]WikipediaPage, error) {
+		return []WikipediaPage{{Label: "Artist", Title: info.artist, Extract: "An English rock band."}}, nil
+	}
+	getArtist = func(ctx context.Context, name string) (*Artist, error) {
+		return &Artist{Name: name, Type: "Group", Members: []ArtistMember{{Name: "Thom Yorke", Begin: "1985"}}}, nil
+	}
+	getReception = func(ctx context.Context, info MusicInfo) (*Reception, error) {
+		return &Reception{Scores: []ReviewScore{{Source: "Metacritic", Score: "85/100"}}}, nil
+	}
+	controlPlayer = func(player.Action) {}
+	token, lastfmKey, lbToken, appID, genius := discogsToken, lastfmAPIKey, listenBrainzToken, bandsintownAppID, geniusToken
+	discogsToken, lastfmAPIKey, listenBrainzToken, bandsintownAppID, geniusToken = "", "", "", "", ""
+	dir := cacheDir
+	cacheDir = ""
+
+	m, err := newModel(testTrack.artist, testTrack.track, testTrack.album)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu = &sync.Mutex{}
+	t.Cleanup(func() {
+		// The fetch still reads the variables.
+		waitFetch(m)
+		cacheDir = dir
+		controlPlayer = controlSpotify
+		completer = nil
+		getTrackInfo = getSpotifyTrackInfo
+		getLyrics = fetchLyrics
+		getPlaybackPosition = spotifyPosition
+		getTrackDuration = spotifyDuration
+		getPlayerSettings = spotifySettings
+		getArtwork = fetchCoverArt
+		getRelease = fetchRelease
+		getWikipedia = fetchWikipedia
+		getArtist = fetchArtist
+		getReception = fetchReception
+		discogsToken, lastfmAPIKey, listenBrainzToken, bandsintownAppID, geniusToken = token, lastfmKey, lbToken, appID, genius
+	})
+	return m
+}
+
+// waitFetch cancels the fetch of m and waits for its goroutines, before a
+// test restores the variables they read.
+func waitFetch(m *model) {
+	m.stopFetch()
+	m.fetches.Wait()
+}
+
+// waitFor waits until cond, called holding m.mu, is true.
+func waitFor(t *testing.T, m *model, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		ok := cond()
+		m.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the fetch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// isLoading reads the loading of m while the program updates it.
+func isLoading(m *model) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loading
+}
+
+func contains(s string) func([]byte) bool {
+	return func(b []byte) bool {
+		return bytes.Contains(b, []byte(s))
+	}
+}
+
+// waitReady waits until loading has finished. Content is shown while loading
+// too, so the output alone is not enough.
+func waitReady(t *testing.T, tm *teatest.TestModel, m *model) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(b []byte) bool {
+		return !isLoading(m) && bytes.Contains(b, []byte("Navigate"))
+	}, teatest.WithDuration(5*time.Second))
+}
+
+func keyRune(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestLoadingToReady(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.loading {
+		t.Error("model is still loading")
+	}
+	titles := []string{"Album info", "Album review", "Song info", "Artist bio", "Tracklist and credits", "Wikipedia", "Critic and user scores"}
+	if len(fm.sections) != len(titles) {
+		t.Fatalf("got %d sections, want %d", len(fm.sections), len(titles))
+	}
+	for i, title := range titles {
+		if fm.sections[i].Title != title {
+			t.Errorf("section %d is %q, want %q", i, fm.sections[i].Title, title)
+		}
+	}
+	if fm.links == nil {
+		t.Error("links were not set")
+	}
+	if !strings.Contains(fm.content, "stub answer for:") {
+		t.Errorf("content does not include the completions: %q", fm.content)
+	}
+}
+
+func TestLoadingIgnoresNavigation(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	teatest.WaitFor(t, tm.Output(), contains("ctrl+r: Refresh"))
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+	tm.Send(keyRune('m'))
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlC})
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if !fm.loading {
+		t.Error("model should still be loading")
+	}
+	if fm.showRaw {
+		t.Error("raw toggle should be ignored while loading")
+	}
+}
+
+func TestRawToggle(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('m'))
+	teatest.WaitFor(t, tm.Output(), contains("## Album info"))
+
+	tm.Send(keyRune('m'))
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.showRaw {
+		t.Error("second toggle should go back to the rendered view")
+	}
+}
+
+func TestSpotifyUnavailableRetry(t *testing.T) {
+	m := setupTest(t, PlayerUnavailable)
+	m.loading = false
+	m.status = PlayerUnavailable
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	teatest.WaitFor(t, tm.Output(), contains("retry connecting to Spotify"))
+
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		return testTrack, PlayerPlaying
+	}
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlR})
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.status != PlayerPlaying {
+		t.Errorf("got status %d, want playing", fm.status)
+	}
+	if fm.MusicInfo != testTrack {
+		t.Errorf("got %+v, want %+v", fm.MusicInfo, testTrack)
+	}
+}
+
+func TestRefreshWhilePlayingStops(t *testing.T) {
+	m := setupTest(t, PlayerIdle)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlR})
+	teatest.WaitFor(t, tm.Output(), contains("Nothing is playing right now"))
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.status != PlayerIdle {
+		t.Errorf("got status %d, want idle", fm.status)
+	}
+}
+
+func TestIdleScreenWaitsForPlayback(t *testing.T) {
+	m := setupTest(t, PlayerIdle)
+	m.loading = false
+	m.status = PlayerIdle
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	teatest.WaitFor(t, tm.Output(), contains("Waiting for playback"))
+
+	tm.Send(idlePollMsg{status: PlayerIdle})
+	tm.Send(idlePollMsg{info: testTrack, status: PlayerPlaying})
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.status != PlayerPlaying {
+		t.Errorf("got status %d, want playing", fm.status)
+	}
+	if fm.MusicInfo != testTrack {
+		t.Errorf("got %+v, want %+v", fm.MusicInfo, testTrack)
+	}
+}
+
+func TestTrackPollRefreshes(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	defer func(d time.Duration) { debounce = d }(debounce)
+	debounce = 0
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	next := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	tm.Send(trackPollMsg{info: next, status: PlayerPlaying})
+	teatest.WaitFor(t, tm.Output(), contains("Paranoid Android"))
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.MusicInfo != next {
+		t.Errorf("got %+v, want %+v", fm.MusicInfo, next)
+	}
+}
+
+func TestTrackPollDebounce(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	defer func(d time.Duration) { debounce = d }(debounce)
+	debounce = 200 * time.Millisecond
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	skipped := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Subterranean Homesick Alien"}
+	next := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		return next, PlayerPlaying
+	}
+
+	// The settle check sees a different track than the polled one, so the
+	// skipped track is never fetched.
+	tm.Send(trackPollMsg{info: skipped, status: PlayerPlaying})
+	teatest.WaitFor(t, tm.Output(), contains("Paranoid Android"), teatest.WithDuration(3*time.Second))
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.MusicInfo != next {
+		t.Errorf("got %+v, want %+v", fm.MusicInfo, next)
+	}
+}
+
+func TestPromptTemplates(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	templates, err := compilePrompts([]Prompt{
+		{Key: "review", Prompt: "Reseña del álbum {{.Album}} de {{.Artist}}"},
+		{Key: "samples", Title: "Samples", Prompt: "Which samples are used in {{.Track}}?"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	promptTemplates = templates
+	defer func() { promptTemplates = nil }()
+
+	m.getInfo(context.Background())
+
+	if got := m.sections[1].Content; got != "stub answer for: Reseña del álbum OK Computer de Radiohead" {
+		t.Errorf("review was not replaced: %q", got)
+	}
+	added := m.sections[4]
+	if added.Title != "Samples" || added.Content != "stub answer for: Which samples are used in Airbag?" {
+		t.Errorf("custom section was not added: %+v", added)
+	}
+
+	if _, err := compilePrompts([]Prompt{{Key: "bad", Prompt: "{{.Label}}"}}); err == nil {
+		t.Error("unknown template field should fail")
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	language = "Spanish"
+	defer func() { language = "" }()
+
+	m.getInfo(context.Background())
+
+	for _, s := range m.sections[:4] {
+		if !strings.HasSuffix(s.Content, "\n\nWrite the answer in Spanish.") {
+			t.Errorf("%s is not asked in Spanish: %q", s.Title, s.Content)
+		}
+	}
+	if tracklist := m.sections[4]; strings.Contains(tracklist.Content, "Spanish") {
+		t.Errorf("the tracklist does not come from the AI provider: %q", tracklist.Content)
+	}
+}
+
+func TestSectionOrder(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func() { enabledSections, sectionOrder, skippedSections = nil, nil, nil }()
+
+	skippedSections = map[string]bool{"tracklist": true}
+	m.getInfo(context.Background())
+	var titles []string
+	for _, s := range m.sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Album info, Album review, Song info, Artist bio, Wikipedia, Critic and user scores" {
+		t.Errorf("skipping the tracklist got %s", got)
+	}
+
+	sectionOrder = []string{"review", "wikipedia", "album", "bio"}
+	enabledSections = map[string]bool{"review": true, "wikipedia": true, "album": true, "bio": true}
+	m.getInfo(context.Background())
+	titles = nil
+	for _, s := range m.sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Album review, Wikipedia, Album info, Artist bio" {
+		t.Errorf("got sections %s", got)
+	}
+}
+
+func TestLyricsToggle(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('l'))
+	teatest.WaitFor(t, tm.Output(), contains("jackknifed juggernaut"))
+
+	tm.Send(keyRune('m'))
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if !fm.onLyricsTab() {
+		t.Error("lyrics tab should be active")
+	}
+	if fm.showRaw {
+		t.Error("raw toggle should be ignored in the lyrics view")
+	}
+}
+
+func TestSyncedLyricsFollowPosition(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	getLyrics = func(ctx context.Context, info MusicInfo) (*Lyrics, error) {
+		return &Lyrics{
+			Plain:  "In the next world war\nIn a jackknifed juggernaut",
+			Synced: "[00:10.00] In the next world war\n[00:14.50] In a jackknifed juggernaut",
+		}, nil
+	}
+	getPlaybackPosition = func() (time.Duration, error) {
+		return 15 * time.Second, nil
+	}
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('l'))
+	teatest.WaitFor(t, tm.Output(), func([]byte) bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.lyricsLine == 1
+	}, teatest.WithDuration(3*time.Second))
+
+	tm.Send(keyRune('q'))
+	tm.FinalModel(t, teatest.WithFinalTimeout(time.Second))
+}
+
+func TestTabs(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('2'))
+	teatest.WaitFor(t, tm.Output(), contains("Give me album review"))
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyTab})
+	tm.Send(tea.KeyMsg{Type: tea.KeyShiftTab})
+	tm.Send(tea.KeyMsg{Type: tea.KeyShiftTab})
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.tab != 0 {
+		t.Errorf("got tab %d, want 0", fm.tab)
+	}
+	if !strings.Contains(fm.raw, "## Album info") {
+		t.Errorf("first tab shows %q", fm.raw)
+	}
+}
+
+func TestPlaybackControls(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.startFetch()
+
+	defer func(d time.Duration) { playerDelay = d }(playerDelay)
+	playerDelay = 0
+
+	next := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	var mu sync.Mutex
+	var actions []player.Action
+	controlPlayer = func(action player.Action) {
+		mu.Lock()
+		defer mu.Unlock()
+		actions = append(actions, action)
+	}
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		return next, PlayerPlaying
+	}
+	tm.Send(keyRune(' '))
+	tm.Send(keyRune('n'))
+	teatest.WaitFor(t, tm.Output(), contains("Paranoid Android"))
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if fm.MusicInfo != next {
+		t.Errorf("got %+v, want %+v", fm.MusicInfo, next)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// The commands run concurrently, so the order is not fixed.
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+	if len(actions) != 2 || actions[0] != player.PlayPause || actions[1] != player.Next {
+		t.Errorf("got actions %v", actions)
+	}
+}
+
+func TestPrintMarkdown(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	var b bytes.Buffer
+	if err := printMarkdown(&b, m, false); err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	for _, want := range []string{"# Radiohead - OK Computer - Airbag", "## Album review", "stub answer for:", "## Links", "## Lyrics", "In the next world war  \n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not include %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	var b bytes.Buffer
+	if err := printJSON(&b, m, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var r Result
+	if err := json.Unmarshal(b.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Artist != "Radiohead" || r.Track != "Airbag" || len(r.Sections) != 7 || r.Sections[1].Title != "Album review" {
+		t.Errorf("got %+v", r)
+	}
+	if r.Links.YouTube == "" || r.Lyrics == "" {
+		t.Errorf("links or lyrics are missing: %+v", r)
+	}
+	if !strings.Contains(b.String(), "&tbm=isch") {
+		t.Errorf("links are escaped: %s", b.String())
+	}
+}
+
+func TestScrollKeys(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.width, m.height = 100, 20
+	m.getInfo(context.Background())
+	m.loading = false
+	m.sections[0].Content = strings.Repeat("OK Computer was recorded in 1996.\n\n", 40)
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(keyRune('G'))
+	if !m.viewport.AtBottom() {
+		t.Error("G does not scroll to the bottom")
+	}
+	m.Update(keyRune('u'))
+	if m.viewport.AtBottom() {
+		t.Error("u does not scroll up")
+	}
+	m.Update(keyRune('g'))
+	if !m.viewport.AtTop() {
+		t.Error("g does not scroll to the top")
+	}
+
+	want := fmt.Sprintf("lines 1-%d of %d • 0%%", m.viewport.Height, m.viewport.TotalLineCount())
+	if !strings.Contains(m.View(), want) {
+		t.Errorf("view does not include %q", want)
+	}
+}
+
+func TestLayoutFillsTerminal(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.sections[0].Content = strings.Repeat("OK Computer was recorded in 1996.\n\n", 40)
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range [][2]int{{120, 40}, {100, 30}, {50, 20}, {200, 60}} {
+		m.Update(tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+		if rows := strings.Count(m.View(), "\n") + 1; rows != size[1] {
+			t.Errorf("%dx%d: view has %d rows", size[0], size[1], rows)
+		}
+	}
+
+	m.notice = "  Copied the tab to the clipboard"
+	m.Update(tickMsg{})
+	if rows := strings.Count(m.View(), "\n") + 1; rows != 60 {
+		t.Errorf("view with a notice has %d rows", rows)
+	}
+}
+
+func TestProgressCountsRequests(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+
+	// Seven sections, the summary, the artwork, the lyrics and the links.
+	if m.steps != 11 || m.stepsDone != 11 {
+		t.Errorf("got %d/%d steps, want 11/11", m.stepsDone, m.steps)
+	}
+	if !strings.Contains(m.progressView(), "11/11") {
+		t.Errorf("progress view is %q", m.progressView())
+	}
+
+	m.Update(tickMsg{})
+	if m.loading {
+		t.Error("loading did not end once every request was done")
+	}
+}
+
+func TestSectionStatus(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+
+	m.sections[1].Content = ""
+	m.sections[2].Content, m.sections[2].streaming = "Partial", true
+	m.sections[3].Error = "openai api: rate limited"
+	view := m.progressView()
+	for _, want := range []string{
+		"✓ " + sectionLabel(m.sections[0]),
+		m.spinner.View() + " " + sectionLabel(m.sections[1]),
+		m.spinner.View() + " " + sectionLabel(m.sections[2]),
+		"⚠ " + sectionLabel(m.sections[3]),
+		"11/11",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("progress view does not include %q:\n%s", want, view)
+		}
+	}
+
+	// The items wrap to the width of the terminal.
+	m.Update(tea.WindowSizeMsg{Width: 50, Height: 40})
+	for _, line := range strings.Split(m.progressView(), "\n") {
+		if w := lipgloss.Width(line); w > 50 {
+			t.Errorf("line %q is %d wide", line, w)
+		}
+	}
+}
+
+func TestProgressiveSections(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+
+	// The first section is still loading, the second one is done.
+	m.loading, m.steps = true, m.stepsDone+1
+	m.sections[0].Content = ""
+	m.sections[1].Content = "A cold record."
+	m.changed = true
+	m.Update(tickMsg{})
+	if m.tab != 1 || !strings.Contains(m.raw, "A cold record.") {
+		t.Errorf("got tab %d showing %q, want the finished section", m.tab, m.raw)
+	}
+
+	// A chosen tab is kept, a streamed answer says it is not done.
+	m.Update(keyRune('1'))
+	m.sections[0].Content, m.sections[0].streaming = "OK Computer was", true
+	m.changed = true
+	m.Update(tickMsg{})
+	if m.tab != 0 || !strings.Contains(m.raw, "OK Computer was\n\n*Writing...*") {
+		t.Errorf("got tab %d showing %q", m.tab, m.raw)
+	}
+}
+
+// blockingCompleter blocks the requests about OK Computer until they are
+// canceled.
+type blockingCompleter struct {
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func (c blockingCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	if !strings.Contains(prompt, "OK Computer") {
+		return "answer for: " + prompt, nil
+	}
+	c.started <- struct{}{}
+	<-ctx.Done()
+	c.canceled <- struct{}{}
+	return "", ctx.Err()
+}
+
+func TestRefreshCancelsRequests(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	c := blockingCompleter{started: make(chan struct{}, 10), canceled: make(chan struct{}, 10)}
+	completer = c
+	getArtwork = func(ctx context.Context, info MusicInfo) (image.Image, error) { return nil, errNoArtwork }
+
+	m.startFetch()
+	<-c.started
+
+	m.reload(MusicInfo{artist: "Radiohead", album: "Kid A", track: "Idioteque"}, false)
+	select {
+	case <-c.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the requests of the previous track were not canceled")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		done := m.steps > 0 && m.stepsDone >= m.steps
+		m.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the new track did not finish loading")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if strings.Contains(m.content, "OK Computer") || !strings.Contains(m.content, "Kid A") {
+		t.Errorf("content mixes the tracks: %q", m.content)
+	}
+	if m.errMsg != "" {
+		t.Errorf("canceled requests set an error: %q", m.errMsg)
+	}
+}
+
+// failingCompleter fails the album review until fail is cleared.
+type failingCompleter struct {
+	mu   *sync.Mutex
+	fail *bool
+}
+
+func (c failingCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if *c.fail && strings.Contains(prompt, "album review") {
+		return "", &ai.AnthropicError{StatusCode: 400, Message: "bad request"}
+	}
+	return "answer for: " + prompt, nil
+}
+
+func TestSectionErrorRetry(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	fail := true
+	c := failingCompleter{mu: &sync.Mutex{}, fail: &fail}
+	completer = c
+
+	m.startFetch()
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	if !strings.Contains(m.sections[1].Error, "bad request") {
+		t.Fatalf("got section error %q", m.sections[1].Error)
+	}
+	tm.Send(keyRune('2'))
+	teatest.WaitFor(t, tm.Output(), contains("retry this section."), teatest.WithDuration(5*time.Second))
+
+	c.mu.Lock()
+	fail = false
+	c.mu.Unlock()
+	tm.Send(keyRune('r'))
+	teatest.WaitFor(t, tm.Output(), func([]byte) bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return !m.loading && m.sections[1].Content != ""
+	}, teatest.WithDuration(5*time.Second))
+
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+	if fm.sections[1].Error != "" || fm.errMsg != "" {
+		t.Errorf("got section error %q and error %q", fm.sections[1].Error, fm.errMsg)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mcpProtocolVersions are the MCP revisions stui speaks, the first one is
+// offered to clients asking for another.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "now_playing",
+		Description: "The track playing right now in the music player: artist, album and title, or whether nothing plays.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	},
+	{
+		Name: "track_info",
+		Description: "What the playing track is about: album info, review, song info, artist bio, credits, lyrics and links as markdown. " +
+			"The first call for a track takes a while, the info is fetched then.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"section": map[string]any{
+					"type":        "string",
+					"description": "Only this section, by title or key, e.g. \"Album review\" or \"bio\"",
+				},
+			},
+		},
+	},
+}
+
+// mcpServer is stui mcp, a Model Context Protocol server on stdio.
+type mcpServer struct {
+	m *model
+	// fetched is the track the model has the info of.
+	fetched *MusicInfo
+}
+
+// serveMCP answers the newline delimited JSON-RPC messages of r on w until
+// r ends.
+func serveMCP(m *model, r io.Reader, w io.Writer) error {
+	s := &mcpServer{m: m}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rerr := s.handle(req)
+		// Notifications get no answer.
+		if len(req.ID) == 0 {
+			continue
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *mcpServer) handle(req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		protocol := mcpProtocolVersions[0]
+		if containsString(mcpProtocolVersions, params.ProtocolVersion) {
+			protocol = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": protocol,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "stui", "version": version},
+		}, nil
+	case "ping", "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string `json:"name"`
+			Arguments struct {
+				Section string `json:"section"`
+			} `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+
+		var text string
+		var err error
+		switch params.Name {
+		case "now_playing":
+			text = s.nowPlaying()
+		case "track_info":
+			text, err = s.trackInfo(params.Arguments.Section)
+		default:
+			return nil, &rpcError{rpcInvalidParams, "unknown tool " + params.Name}
+		}
+
+		// Tool failures are results the client model can read.
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "unknown method " + req.Method}
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// playingTrack reads the player, ok is false with the reason when there is no
+// track to look up.
+func playingTrack() (info MusicInfo, reason string, ok bool) {
+	info, status := getTrackInfo()
+	if status != PlayerPlaying {
+		return info, statusMessage(status), false
+	}
+	if info.artist == "" && !info.isPodcast() {
+		return info, statusMessage(PlayerIdle), false
+	}
+	return info, "", true
+}
+
+func (s *mcpServer) nowPlaying() string {
+	info, reason, ok := playingTrack()
+	if !ok {
+		return reason
+	}
+	if info.isPodcast() {
+		return fmt.Sprintf("Podcast: %s\nEpisode: %s", info.album, info.track)
+	}
+	return fmt.Sprintf("Artist: %s\nAlbum: %s\nTrack: %s", info.artist, info.album, info.track)
+}
+
+func (s *mcpServer) trackInfo(section string) (string, error) {
+	info, reason, ok := playingTrack()
+	if !ok {
+		return reason, nil
+	}
+
+	m := s.m
+	if s.fetched == nil || *s.fetched != info {
+		m.reset(info)
+		m.getInfo(context.Background())
+		s.fetched = &info
+	}
+
+	if section == "" {
+		return m.markdown(), nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var titles []string
+	for _, sec := range m.sections {
+		if strings.EqualFold(sec.Title, section) || sec.key == section {
+			if sec.Error != "" {
+				return "", fmt.Errorf("%s: %s", sec.Title, sec.Error)
+			}
+			return "## " + sec.Title + "\n\n" + sec.Content, nil
+		}
+		titles = append(titles, sec.Title)
+	}
+	if strings.EqualFold(section, "lyrics") && m.lyrics != nil {
+		return "## Lyrics\n\n" + m.lyrics.Plain, nil
+	}
+	return "", fmt.Errorf("no section %q, the track has %s", section, strings.Join(titles, ", "))
+}
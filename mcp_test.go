@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServeMCP(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"now_playing","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"track_info","arguments":{"section":"Album review"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"track_info","arguments":{"section":"samples"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := serveMCP(m, strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type response struct {
+		ID     int `json:"id"`
+		Result struct {
+			ProtocolVersion string    `json:"protocolVersion"`
+			Tools           []mcpTool `json:"tools"`
+			Content         []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	var responses []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, r)
+	}
+	if len(responses) != 7 {
+		t.Fatalf("got %d responses, the notification should get none", len(responses))
+	}
+
+	if got := responses[0].Result.ProtocolVersion; got != "2024-11-05" {
+		t.Errorf("got protocol %q", got)
+	}
+	if got := responses[1].Result.Tools; len(got) != 2 || got[0].Name != "now_playing" {
+		t.Errorf("got tools %+v", got)
+	}
+	if got := responses[2].Result.Content[0].Text; got != "Artist: Radiohead\nAlbum: OK Computer\nTrack: Airbag" {
+		t.Errorf("got now playing %q", got)
+	}
+	if got := responses[3].Result.Content[0].Text; got != "## Album review\n\nstub answer for: Give me album review of Radiohead OK Computer" {
+		t.Errorf("got review %q", got)
+	}
+	if r := responses[4].Result; !r.IsError || !strings.Contains(r.Content[0].Text, `no section "samples"`) {
+		t.Errorf("got %+v for an unknown section", r)
+	}
+	if r := responses[5]; r.Error == nil || r.Error.Code != rpcMethodNotFound {
+		t.Errorf("got %+v for an unknown method", r)
+	}
+	if r := responses[6]; r.Error == nil || r.Error.Code != rpcParseError {
+		t.Errorf("got %+v for invalid JSON", r)
+	}
+}
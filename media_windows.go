@@ -0,0 +1,10 @@
+package main
+
+import "github.com/ernesto27/stui/pkg/player"
+
+func init() {
+	registerPlayer("windows", playerFactory{
+		name: "a Windows media player",
+		new:  func() (player.Player, error) { return player.NewWindowsMedia(), nil },
+	})
+}
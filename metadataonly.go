@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// aiOff is why stui runs metadata only: the provider has no API key or
+// refused it. The AI sections are left out then, the online sources, the
+// lyrics and the links still show.
+type aiOff struct {
+	// keyEnv is the variable of the key, refused is set when the provider
+	// answered that the key is not valid.
+	keyEnv  string
+	refused bool
+}
+
+var errMetadataOnly = errors.New("the AI provider has no valid API key")
+
+// metadataOnlyReason is set once, at start or by the first refused request,
+// from the goroutines of the fetches.
+var (
+	metadataOnlyMu     sync.Mutex
+	metadataOnlyReason *aiOff
+)
+
+// metadataOnly returns why the AI sections are off, nil while they are on.
+func metadataOnly() *aiOff {
+	metadataOnlyMu.Lock()
+	defer metadataOnlyMu.Unlock()
+	return metadataOnlyReason
+}
+
+func setMetadataOnly(off *aiOff) {
+	metadataOnlyMu.Lock()
+	defer metadataOnlyMu.Unlock()
+	metadataOnlyReason = off
+}
+
+// checkKey turns metadata only on when err is the provider refusing the API
+// key, the next requests would fail the same.
+func checkKey(err error) {
+	if providers[provider].keyEnv == "" || !keyRefused(err) {
+		return
+	}
+	debugLog("ai", "provider", provider, "key", "refused", "error", err)
+	setMetadataOnly(&aiOff{keyEnv: providers[provider].keyEnv, refused: true})
+}
+
+// keyRefused reports whether err is an answer of the provider to a missing or
+// invalid API key. Gemini tells it with a bad request. A forbidden request
+// is not, it may be a model or a region the key has no access to.
+func keyRefused(err error) bool {
+	switch statusCode(err) {
+	case http.StatusUnauthorized:
+		return true
+	case http.StatusBadRequest:
+		var geminiErr *ai.GeminiError
+		return errors.As(err, &geminiErr) && strings.Contains(geminiErr.Message, "API key not valid")
+	}
+	return false
+}
+
+// banner explains over the content how to get the AI sections.
+func (o *aiOff) banner() string {
+	if o.refused {
+		return "  " + trf("Metadata only: %s refused the API key, check %s and start stui again", provider, o.keyEnv)
+	}
+	return "  " + trf("Metadata only: set %s to get the AI sections", o.keyEnv)
+}
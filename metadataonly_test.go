@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// refusingCompleter answers like OpenAI to an invalid API key.
+type refusingCompleter struct{}
+
+func (refusingCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	return "", &openai.APIError{HTTPStatusCode: 401, Message: "Incorrect API key provided"}
+}
+
+func TestMetadataOnly(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	completer = offlineCompleter{t}
+	setMetadataOnly(&aiOff{keyEnv: "OPENAI_TOKEN"})
+	defer setMetadataOnly(nil)
+
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+
+	var titles []string
+	for _, s := range m.sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Tracklist and credits, Wikipedia, Critic and user scores" {
+		t.Errorf("got sections %s", got)
+	}
+	if m.summary != nil || m.lyrics == nil || m.links == nil {
+		t.Errorf("got summary %v, lyrics %v and links %v", m.summary, m.lyrics, m.links)
+	}
+	if view := m.View(); !strings.Contains(view, "Metadata only: set OPENAI_TOKEN to get the AI sections") {
+		t.Errorf("the banner is missing:\n%s", view)
+	}
+}
+
+func TestRefusedKey(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	completer = refusingCompleter{}
+	defer setMetadataOnly(nil)
+
+	m.getInfo(context.Background())
+
+	off := metadataOnly()
+	if off == nil || !off.refused || off.keyEnv != "OPENAI_TOKEN" {
+		t.Fatalf("got %+v after the key was refused", off)
+	}
+	if banner := off.banner(); banner != "  Metadata only: openai refused the API key, check OPENAI_TOKEN and start stui again" {
+		t.Errorf("got banner %q", banner)
+	}
+	if _, err := m.complete(context.Background(), chatModel, "Give me album info"); err != errMetadataOnly {
+		t.Errorf("the provider was asked again: %v", err)
+	}
+
+	for err, want := range map[error]bool{
+		&openai.APIError{HTTPStatusCode: 401}:                              true,
+		&openai.APIError{HTTPStatusCode: 403}:                              false,
+		&ai.AnthropicError{StatusCode: 401, Type: "authentication_error"}:  true,
+		&ai.GeminiError{StatusCode: 400, Message: "API key not valid."}:    true,
+		&ai.GeminiError{StatusCode: 400, Message: "Invalid JSON payload."}: false,
+		&ai.AnthropicError{StatusCode: 429, Type: "rate_limit_error"}:      false,
+	} {
+		if got := keyRefused(err); got != want {
+			t.Errorf("keyRefused(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mouseEnabled captures the mouse for wheel scrolling and link clicks,
+// selecting text then needs shift in most terminals.
+var mouseEnabled = true
+
+func (m *model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.showHelp || m.palette != nil || m.historyScreen != nil || m.linkPicker != nil || !m.hasContent() {
+		return m, nil
+	}
+
+	if msg.Type == tea.MouseLeft {
+		if url := m.urlAt(msg.X, msg.Y); url != "" {
+			m.openLink(namedLink{url: url})
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.reader != nil {
+		m.reader.viewport, cmd = m.reader.viewport.Update(msg)
+		return m, cmd
+	}
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// urlAt returns the link shown at the x, y cell of the terminal, if any.
+func (m *model) urlAt(x int, y int) string {
+	top, _ := m.chromeViews()
+	// The border takes the first row and column of the viewport.
+	row := y - strings.Count(top, "\n") - 1
+	col := x - m.sidebarCols() - 1
+	if row < 0 || row >= m.viewport.VisibleLineCount() || col < 0 {
+		return ""
+	}
+
+	lines := strings.Split(m.unhighlightedContent(), "\n")
+	if m.viewport.YOffset+row >= len(lines) {
+		return ""
+	}
+	line := ansiPattern.ReplaceAllString(lines[m.viewport.YOffset+row], "")
+	for _, span := range urlPattern.FindAllStringIndex(line, -1) {
+		start := lipgloss.Width(line[:span[0]])
+		end := start + lipgloss.Width(line[span[0]:span[1]])
+		if col >= start && col < end {
+			return strings.TrimRight(line[span[0]:span[1]], ".,;:")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMouse(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.sections[0].Content = strings.Repeat("OK Computer was recorded in 1996.\n\n", 40)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(tea.MouseMsg{Type: tea.MouseWheelDown})
+	if m.viewport.YOffset == 0 {
+		t.Error("the wheel does not scroll")
+	}
+
+	var opened []string
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { openURL = openBrowser }()
+
+	m.Update(keyRune('9'))
+	top, _ := m.chromeViews()
+	lines := strings.Split(m.unhighlightedContent(), "\n")
+	for row, line := range lines {
+		plain := ansiPattern.ReplaceAllString(line, "")
+		if col := strings.Index(plain, "https://www.youtube.com"); col >= 0 {
+			m.Update(tea.MouseMsg{Type: tea.MouseLeft, X: col + 5, Y: strings.Count(top, "\n") + 1 + row})
+			break
+		}
+	}
+	m.Update(tea.MouseMsg{Type: tea.MouseLeft, X: 0, Y: 0})
+
+	if len(opened) != 1 || opened[0] != m.links.YouTube {
+		t.Errorf("opened %v", opened)
+	}
+}
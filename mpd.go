@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+// MPD connection settings, see the mpd_* config settings.
+var (
+	mpdHost     = "localhost"
+	mpdPort     = 6600
+	mpdPassword string
+)
+
+func init() {
+	registerPlayer("mpd", playerFactory{
+		name: "MPD",
+		new: func() (player.Player, error) {
+			return player.NewMPD(net.JoinHostPort(mpdHost, strconv.Itoa(mpdPort)), mpdPassword), nil
+		},
+	})
+}
@@ -0,0 +1,10 @@
+package main
+
+import "github.com/ernesto27/stui/pkg/player"
+
+func init() {
+	registerPlayer("mpris", playerFactory{
+		name: "an MPRIS player",
+		new:  func() (player.Player, error) { return player.NewMPRIS() },
+	})
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// getRelease is replaced in tests.
+var getRelease = fetchRelease
+
+var errNoRelease = errors.New("no MusicBrainz release found")
+
+// Release is the MusicBrainz data of an album used by the tracklist section.
+type Release struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Date         string `json:"date"`
+	Country      string `json:"country"`
+	ReleaseGroup struct {
+		FirstReleaseDate string `json:"first-release-date"`
+	} `json:"release-group"`
+	LabelInfo []struct {
+		CatalogNumber string `json:"catalog-number"`
+		Label         *struct {
+			Name string `json:"name"`
+		} `json:"label"`
+	} `json:"label-info"`
+	Media []struct {
+		Format string         `json:"format"`
+		Tracks []ReleaseTrack `json:"tracks"`
+	} `json:"media"`
+	Relations []Relation `json:"relations"`
+}
+
+type ReleaseTrack struct {
+	Number    string `json:"number"`
+	Title     string `json:"title"`
+	Length    int    `json:"length"`
+	Recording struct {
+		Relations []Relation `json:"relations"`
+	} `json:"recording"`
+}
+
+// Relation links a release or recording to an artist, such as its producer
+// or a guest musician, or a recording to the work it performs, whose
+// relations name the writers.
+type Relation struct {
+	Type       string   `json:"type"`
+	Attributes []string `json:"attributes"`
+	Artist     *struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Work *struct {
+		Relations []Relation `json:"relations"`
+	} `json:"work"`
+}
+
+// musicBrainzGet decodes the JSON answer of a MusicBrainz API path.
+func musicBrainzGet(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, musicBrainzURL+path, nil)
+	if err != nil {
+		return err
+	}
+	// MusicBrainz rejects requests without a meaningful user agent.
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "musicbrainz: status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchRelease searches the album on MusicBrainz and looks up the tracks and
+// credits of the best match.
+func fetchRelease(ctx context.Context, info MusicInfo) (*Release, error) {
+	query := fmt.Sprintf(`artist:"%s" AND release:"%s"`, info.artist, info.album)
+	var search struct {
+		Releases []struct {
+			ID string `json:"id"`
+		} `json:"releases"`
+	}
+	if err := musicBrainzGet(ctx, "/release/?fmt=json&limit=1&query="+url.QueryEscape(query), &search); err != nil {
+		return nil, err
+	}
+	if len(search.Releases) == 0 {
+		return nil, errNoRelease
+	}
+
+	var r Release
+	inc := "recordings+labels+release-groups+artist-rels+recording-level-rels+work-rels+work-level-rels"
+	if err := musicBrainzGet(ctx, "/release/"+search.Releases[0].ID+"?fmt=json&inc="+inc, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// tracklistSection renders the MusicBrainz release of the album.
+func tracklistSection(ctx context.Context, info MusicInfo) (string, error) {
+	release, err := getRelease(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return release.markdown(), nil
+}
+
+// markdown renders the release date, the tracklist and the personnel.
+func (r *Release) markdown() string {
+	var b strings.Builder
+
+	var released []string
+	if r.Date != "" {
+		released = append(released, r.Date)
+	}
+	if r.Country != "" {
+		released = append(released, r.Country)
+	}
+	for _, l := range r.LabelInfo {
+		if l.Label == nil {
+			continue
+		}
+		label := l.Label.Name
+		if l.CatalogNumber != "" {
+			label += " (" + l.CatalogNumber + ")"
+		}
+		released = append(released, label)
+	}
+	if len(released) > 0 {
+		fmt.Fprintf(&b, "**Released:** %s\n", strings.Join(released, " · "))
+	}
+	if first := r.ReleaseGroup.FirstReleaseDate; first != "" && first != r.Date {
+		fmt.Fprintf(&b, "\n**First released:** %s\n", first)
+	}
+
+	album := newCredits()
+	album.add(r.Relations)
+	var tracks []trackCredits
+	for i, medium := range r.Media {
+		if len(r.Media) > 1 {
+			title := fmt.Sprintf("Disc %d", i+1)
+			if medium.Format != "" {
+				title += " (" + medium.Format + ")"
+			}
+			fmt.Fprintf(&b, "\n### %s\n", title)
+		}
+
+		b.WriteString("\n| # | Title | Length |\n| --- | --- | --- |\n")
+		for _, t := range medium.Tracks {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", t.Number, tableCell(t.Title), trackLength(t.Length))
+			c := newCredits()
+			c.add(t.Recording.Relations)
+			tracks = append(tracks, trackCredits{number: t.Number, title: t.Title, credits: c})
+		}
+	}
+
+	// What every track credits is credited once, for the album.
+	if len(tracks) > 1 {
+		first := tracks[0].credits
+		for _, name := range append([]string(nil), first.names...) {
+			for _, role := range append([]string(nil), first.roles[name]...) {
+				shared := true
+				for _, t := range tracks[1:] {
+					shared = shared && t.credits.has(name, role)
+				}
+				if shared {
+					album.addRole(name, role)
+					for _, t := range tracks {
+						t.credits.remove(name, role)
+					}
+				}
+			}
+		}
+	} else if len(tracks) == 1 {
+		album.merge(tracks[0].credits)
+		tracks = nil
+	}
+
+	if len(album.names) > 0 {
+		b.WriteString("\n### Album credits\n\n| Artist | Roles |\n| --- | --- |\n")
+		for _, name := range album.names {
+			fmt.Fprintf(&b, "| %s | %s |\n", tableCell(name), tableCell(strings.Join(album.roles[name], ", ")))
+		}
+	}
+	var credited []trackCredits
+	for _, t := range tracks {
+		if len(t.credits.names) > 0 {
+			credited = append(credited, t)
+		}
+	}
+	if len(credited) > 0 {
+		b.WriteString("\n### Track credits\n\n| # | Title | Credits |\n| --- | --- | --- |\n")
+		for _, t := range credited {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", t.number, tableCell(t.title), tableCell(t.credits.String()))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n*Source: [MusicBrainz](https://musicbrainz.org/release/%s)*", r.ID)
+	return b.String()
+}
+
+// trackLength formats a length in milliseconds as m:ss.
+func trackLength(ms int) string {
+	if ms <= 0 {
+		return ""
+	}
+	d := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// credits collects the roles of each artist in the order they first appear.
+type credits struct {
+	names []string
+	roles map[string][]string
+}
+
+func newCredits() *credits {
+	return &credits{roles: map[string][]string{}}
+}
+
+func (c *credits) add(relations []Relation) {
+	for _, rel := range relations {
+		// The writers are credited on the work the recording performs.
+		if rel.Work != nil {
+			c.add(rel.Work.Relations)
+			continue
+		}
+		if rel.Artist == nil {
+			continue
+		}
+
+		// Instrument and vocal credits name the instrument in the attributes.
+		roles := []string{rel.Type}
+		if (rel.Type == "instrument" || rel.Type == "vocal") && len(rel.Attributes) > 0 {
+			roles = rel.Attributes
+		}
+		for _, role := range roles {
+			c.addRole(rel.Artist.Name, role)
+		}
+	}
+}
+
+func (c *credits) addRole(name, role string) {
+	if _, ok := c.roles[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	if !containsString(c.roles[name], role) {
+		c.roles[name] = append(c.roles[name], role)
+	}
+}
+
+func (c *credits) has(name, role string) bool {
+	return containsString(c.roles[name], role)
+}
+
+// remove drops the role of name, and name once it has no role left.
+func (c *credits) remove(name, role string) {
+	var roles []string
+	for _, r := range c.roles[name] {
+		if r != role {
+			roles = append(roles, r)
+		}
+	}
+	if len(roles) > 0 {
+		c.roles[name] = roles
+		return
+	}
+	delete(c.roles, name)
+	for i, n := range c.names {
+		if n == name {
+			c.names = append(c.names[:i:i], c.names[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *credits) merge(other *credits) {
+	for _, name := range other.names {
+		for _, role := range other.roles[name] {
+			c.addRole(name, role)
+		}
+	}
+}
+
+// String lists the artists with their roles on one line.
+func (c *credits) String() string {
+	parts := make([]string, len(c.names))
+	for i, name := range c.names {
+		parts[i] = name + " (" + strings.Join(c.roles[name], ", ") + ")"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// trackCredits are the credits of a track of the release.
+type trackCredits struct {
+	number  string
+	title   string
+	credits *credits
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testRelease = `{
+	"id": "b1392450",
+	"title": "OK Computer",
+	"date": "1997-06-16",
+	"country": "GB",
+	"release-group": {"first-release-date": "1997-05-21"},
+	"label-info": [{"catalog-number": "NODATA 02", "label": {"name": "Parlophone"}}],
+	"relations": [{"type": "producer", "artist": {"name": "Nigel Godrich"}}],
+	"media": [{"format": "CD", "tracks": [
+		{"number": "1", "title": "Airbag", "length": 284000, "recording": {"relations": [
+			{"type": "instrument", "attributes": ["guitar"], "artist": {"name": "Jonny Greenwood"}},
+			{"type": "engineer", "artist": {"name": "Nigel Godrich"}},
+			{"type": "performance", "work": {"relations": [{"type": "composer", "artist": {"name": "Thom Yorke"}}]}}
+		]}},
+		{"number": "2", "title": "Paranoid Android", "length": 383000, "recording": {"relations": [
+			{"type": "instrument", "attributes": ["guitar", "keyboard"], "artist": {"name": "Jonny Greenwood"}}
+		]}}
+	]}]
+}`
+
+func TestFetchRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release/":
+			if q := r.URL.Query().Get("query"); q != `artist:"Radiohead" AND release:"OK Computer"` {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`{"releases":[{"id":"b1392450"}]}`))
+		case "/release/b1392450":
+			if inc := r.URL.Query().Get("inc"); !strings.Contains(inc, "recording-level-rels") {
+				t.Errorf("got inc %q", inc)
+			}
+			w.Write([]byte(testRelease))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(mb string) { musicBrainzURL = mb }(musicBrainzURL)
+	musicBrainzURL = server.URL
+
+	r, err := fetchRelease(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := r.markdown()
+	for _, want := range []string{
+		"**Released:** 1997-06-16 · GB · Parlophone (NODATA 02)",
+		"**First released:** 1997-05-21",
+		"| 1 | Airbag | 4:44 |",
+		"| 2 | Paranoid Android | 6:23 |",
+		"| Nigel Godrich | producer |",
+		"| Jonny Greenwood | guitar |",
+		"| 1 | Airbag | Nigel Godrich (engineer); Thom Yorke (composer) |",
+		"| 2 | Paranoid Android | Jonny Greenwood (keyboard) |",
+		"https://musicbrainz.org/release/b1392450",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown does not include %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFetchReleaseNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"releases":[]}`))
+	}))
+	defer server.Close()
+
+	defer func(mb string) { musicBrainzURL = mb }(musicBrainzURL)
+	musicBrainzURL = server.URL
+
+	if _, err := fetchRelease(context.Background(), testTrack); err != errNoRelease {
+		t.Errorf("got %v, want errNoRelease", err)
+	}
+}
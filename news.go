@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var newsURL = "https://news.google.com/rss/search"
+
+// newsEnabled adds the news section, newsSummary has the AI provider sum up
+// the headlines. newsFeeds are RSS or Atom feeds read instead of the Google
+// News search, their items that mention the artist are listed.
+var (
+	newsEnabled bool
+	newsSummary bool
+	newsFeeds   []string
+)
+
+const (
+	// newsItems is how many headlines the section lists.
+	newsItems = 8
+	// newsMaxAge leaves out the old items of the feeds.
+	newsMaxAge = 180 * 24 * time.Hour
+)
+
+var errNoNews = errors.New("no news found")
+
+// getNews is replaced in tests.
+var getNews = fetchNews
+
+// NewsItem is a headline about the artist.
+type NewsItem struct {
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	Source    string    `json:"source"`
+	Summary   string    `json:"summary"`
+	Published time.Time `json:"published"`
+}
+
+// newsFeed is an RSS or an Atom feed, whichever the root element is the
+// other fields stay empty.
+type newsFeed struct {
+	Title   string `xml:"title"`
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+			Source      string `xml:"source"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+	} `xml:"entry"`
+}
+
+// newsDateLayouts are the dates of RSS, which feeds write loosely, and Atom.
+var newsDateLayouts = []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC3339}
+
+func parseNewsDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range newsDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// plainText drops the markup of a feed description.
+func plainText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagRe.ReplaceAllString(s, " "))), " ")
+}
+
+// items returns the items of the feed, those that do not name their source
+// get the title of the feed.
+func (f *newsFeed) items() []NewsItem {
+	source := strings.TrimSpace(f.Channel.Title + f.Title)
+
+	var items []NewsItem
+	for _, i := range f.Channel.Items {
+		item := NewsItem{Title: strings.TrimSpace(i.Title), Link: strings.TrimSpace(i.Link), Source: strings.TrimSpace(i.Source),
+			Summary: plainText(i.Description), Published: parseNewsDate(i.PubDate)}
+		if item.Source == "" {
+			item.Source = source
+		}
+		// Google News ends the titles with the source.
+		item.Title = strings.TrimSuffix(item.Title, " - "+item.Source)
+		items = append(items, item)
+	}
+	for _, e := range f.Entries {
+		item := NewsItem{Title: strings.TrimSpace(e.Title), Source: source, Summary: plainText(e.Summary), Published: parseNewsDate(e.Published)}
+		if item.Published.IsZero() {
+			item.Published = parseNewsDate(e.Updated)
+		}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = l.Href
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func newsGet(ctx context.Context, feedURL string) (*newsFeed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, "news: status code %d", resp.StatusCode)
+	}
+	var f newsFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return nil, fmt.Errorf("news: %w", err)
+	}
+	return &f, nil
+}
+
+// mentions reports whether the item is about the artist.
+func (i NewsItem) mentions(artist string) bool {
+	artist = strings.ToLower(artist)
+	return strings.Contains(strings.ToLower(i.Title), artist) || strings.Contains(strings.ToLower(i.Summary), artist)
+}
+
+// fetchNews returns the latest news about the artist, the newest first, from
+// the feeds or else from the Google News search. A feed that can not be read
+// is skipped while the others have news.
+func fetchNews(ctx context.Context, info MusicInfo) ([]NewsItem, error) {
+	var items []NewsItem
+	var errs []error
+	if len(newsFeeds) == 0 {
+		params := url.Values{"q": {fmt.Sprintf("%q", info.artist)}, "hl": {linkLang}}
+		f, err := newsGet(ctx, newsURL+"?"+params.Encode())
+		if err != nil {
+			return nil, err
+		}
+		items = f.items()
+	}
+	for _, feed := range newsFeeds {
+		f, err := newsGet(ctx, feed)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range f.items() {
+			if item.mentions(info.artist) {
+				items = append(items, item)
+			}
+		}
+	}
+
+	var recent []NewsItem
+	for _, item := range items {
+		if item.Title != "" && (item.Published.IsZero() || time.Since(item.Published) < newsMaxAge) {
+			recent = append(recent, item)
+		}
+	}
+	if len(recent) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(recent) == 0 {
+		return nil, errNoNews
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].Published.After(recent[j].Published) })
+	if len(recent) > newsItems {
+		recent = recent[:newsItems]
+	}
+	return recent, nil
+}
+
+// newsSection lists the headlines with their sources and dates, the summary
+// sums up the same headlines.
+func newsSection(ctx context.Context, info MusicInfo) (string, summaryPrompt, error) {
+	items, err := getNews(ctx, info)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	for i, item := range items {
+		fmt.Fprintf(&b, "%d. [%s](%s)", i+1, strings.NewReplacer("[", "(", "]", ")").Replace(item.Title), item.Link)
+		if item.Source != "" {
+			b.WriteString(" · " + item.Source)
+		}
+		if !item.Published.IsZero() {
+			b.WriteString(" · " + item.Published.Format("Jan 2 2006"))
+		}
+		b.WriteString("\n")
+	}
+	if len(newsFeeds) == 0 {
+		b.WriteString("\n*Source: Google News*")
+	} else {
+		b.WriteString("\n*Source: your news feeds*")
+	}
+	if !newsSummary {
+		return b.String(), nil, nil
+	}
+	return b.String(), func(context.Context) (string, error) { return newsSummaryPrompt(info, items), nil }, nil
+}
+
+// newsSummaryPrompt asks to sum up the headlines.
+func newsSummaryPrompt(info MusicInfo, items []NewsItem) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString("\n- ")
+		if !item.Published.IsZero() {
+			b.WriteString(item.Published.Format("2006-01-02") + ": ")
+		}
+		b.WriteString(item.Title)
+		if item.Summary != "" && item.Summary != item.Title {
+			b.WriteString(". " + item.Summary)
+		}
+	}
+	return inLanguage(fmt.Sprintf("Sum up in a short paragraph the recent news about %s, such as reissues, tours and new releases, with their dates. "+
+		"Leave out what is not about the artist. These are the latest headlines:\n%s", info.artist, b.String()))
+}
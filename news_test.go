@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchNews(t *testing.T) {
+	recent := time.Now().Add(-48 * time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.UserAgent(), "stui/") {
+			t.Errorf("got user agent %q", r.UserAgent())
+		}
+		switch r.URL.Path {
+		case "/rss/search":
+			if q := r.URL.Query().Get("q"); q != `"Radiohead"` {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Google News</title>` +
+				`<item><title>Radiohead announce OK Computer [reissue] - Pitchfork</title><link>https://example.com/reissue</link>` +
+				`<pubDate>` + recent.Format(time.RFC1123) + `</pubDate><source url="https://pitchfork.com">Pitchfork</source></item>` +
+				`<item><title>Radiohead play Glastonbury</title><link>https://example.com/1997</link><pubDate>Sat, 28 Jun 1997 21:00:00 GMT</pubDate></item>` +
+				`</channel></rss>`))
+		case "/atom":
+			w.Write([]byte(`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Music News</title>` +
+				`<entry><title>Tour dates</title><link rel="alternate" href="https://example.com/tour"/><updated>` + recent.Format(time.RFC3339) + `</updated>` +
+				`<summary type="html">&lt;p&gt;Radiohead &amp;amp; friends go on tour&lt;/p&gt;</summary></entry>` +
+				`<entry><title>Another band</title><link href="https://example.com/other"/><updated>` + recent.Format(time.RFC3339) + `</updated></entry>` +
+				`</feed>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u string) { newsURL = u }(newsURL)
+	newsURL = server.URL + "/rss/search"
+
+	got, _, err := newsSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. [Radiohead announce OK Computer (reissue)](https://example.com/reissue) · Pitchfork · " + recent.Format("Jan 2 2006") + "\n\n*Source: Google News*"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	defer func() { newsFeeds = nil }()
+	newsFeeds = []string{server.URL + "/atom", server.URL + "/missing"}
+	items, err := fetchNews(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Title != "Tour dates" || items[0].Link != "https://example.com/tour" ||
+		items[0].Source != "Music News" || items[0].Summary != "Radiohead & friends go on tour" {
+		t.Errorf("got items %+v", items)
+	}
+
+	newsFeeds = []string{server.URL + "/missing"}
+	if _, err := fetchNews(context.Background(), testTrack); statusCode(err) != http.StatusNotFound {
+		t.Errorf("got %v with no feed read", err)
+	}
+	newsFeeds = []string{server.URL + "/atom"}
+	if _, err := fetchNews(context.Background(), MusicInfo{artist: "Björk"}); !errors.Is(err, errNoNews) {
+		t.Errorf("got %v without news of the artist", err)
+	}
+}
+
+func TestNewsSummary(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	newsEnabled, newsSummary = true, true
+	reads := 0
+	getNews = func(ctx context.Context, info MusicInfo) ([]NewsItem, error) {
+		reads++
+		return []NewsItem{{Title: "OK Computer reissue", Link: "https://example.com/reissue", Source: "Pitchfork", Published: time.Date(2017, 5, 2, 0, 0, 0, 0, time.UTC)}}, nil
+	}
+	defer func() {
+		newsEnabled, newsSummary = false, false
+		getNews = fetchNews
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+
+	var news Section
+	for _, s := range m.sections {
+		if s.key == "news" {
+			news = s
+		}
+	}
+	for _, want := range []string{"[OK Computer reissue](https://example.com/reissue) · Pitchfork · May 2 2017", "### The news in brief", "stub answer for: Sum up", "- 2017-05-02: OK Computer reissue"} {
+		if !strings.Contains(news.Content, want) {
+			t.Errorf("section does not include %q:\n%s", want, news.Content)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("read the news %d times, the summary uses the headlines of the section", reads)
+	}
+}
+
+func TestLoadConfigNewsFeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"news_feeds = [\"https://pitchfork.com/feed/feed-news/rss\"]\n": "",
+		"news_feeds = [\"pitchfork.com/rss\"]\n":                        "news feed \"pitchfork.com/rss\" is not an http or https URL",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gen2brain/beeep"
+)
+
+// notifyTeaser adds a one line AI teaser to the track change notifications.
+var notifyTeaser = true
+
+// sendNotification is replaced in tests.
+var sendNotification = func(title string, message string) error {
+	return beeep.Notify(title, message, "")
+}
+
+func teaserPrompt(info MusicInfo) string {
+	if info.isPodcast() {
+		return inLanguage(fmt.Sprintf("In one short sentence, tease the episode %q of the podcast %q for someone who just started it. Answer only with the sentence.", info.track, info.album))
+	}
+	return inLanguage(fmt.Sprintf("In one short sentence, tease the song %q by %s for someone who just started playing it. Answer only with the sentence.", info.track, info.artistLine()))
+}
+
+// teaser is the first line of the teaser of the track of info, cached. It is
+// empty for an album or when the provider fails.
+func (m *model) teaser(ctx context.Context, info MusicInfo) string {
+	if info.track == "" {
+		return ""
+	}
+	prompt := teaserPrompt(info)
+	key := cacheKey(info, trackScope, chatModel, prompt)
+	teaser, ok := cachedAnswer(ctx, key)
+	if !ok {
+		var err error
+		if teaser, err = m.complete(ctx, chatModel, prompt); err == nil {
+			writeCache(key, teaser)
+		}
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(teaser), "\n")
+	return line
+}
+
+// notifyTrackChange sends a desktop notification and posts the webhooks for
+// a track found by the watch or serve polling. Nothing is sent when ctx, the
+// fetch of the track, is canceled by a newer one first.
+func (m *model) notifyTrackChange(ctx context.Context, info MusicInfo) {
+	title := "♪ " + info.artistLine() + " – " + info.track
+	body := info.album
+	if info.isPodcast() {
+		title = "🎙 " + info.album + " – " + info.track
+		body = ""
+	}
+	if info.track == "" {
+		title = "♪ " + info.artist + " – " + info.album
+	}
+
+	m.goFetch(func() {
+		var summary string
+		if (m.notify && notifyTeaser) || len(webhooks) > 0 {
+			summary = m.teaser(ctx, info)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if m.notify {
+			if summary != "" && notifyTeaser {
+				body = summary
+			}
+			// Notification failures are not worth interrupting the UI for.
+			_ = sendNotification(title, body)
+		}
+		postWebhooks(trackAnnouncement{info: info, title: title, summary: summary})
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotifyTrackChange(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.notify = true
+
+	sent := make(chan [2]string, 1)
+	sendNotification = func(title string, message string) error {
+		sent <- [2]string{title, message}
+		return nil
+	}
+	defer func(send func(string, string) error) { sendNotification = send }(sendNotification)
+
+	m.notifyTrackChange(context.Background(), testTrack)
+	select {
+	case got := <-sent:
+		if got[0] != "♪ Radiohead – Airbag" {
+			t.Errorf("got title %q", got[0])
+		}
+		if want := `stub answer for: In one short sentence, tease the song "Airbag" by Radiohead for someone who just started playing it. Answer only with the sentence.`; got[1] != want {
+			t.Errorf("got message %q", got[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification")
+	}
+
+	// A newer track cancels the notification.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.notifyTrackChange(ctx, testTrack)
+	select {
+	case got := <-sent:
+		t.Errorf("got notification %q for a canceled track", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// offline skips the AI providers and the online sources: the sections come
+// from the cache, however old, and from the history.
+var offline bool
+
+var errOffline = errors.New("stui is offline")
+
+// offlineTransport refuses the requests that leave the machine, the servers
+// on it stay reachable. The AI providers are not asked at all, even a local
+// Ollama, complete refuses the requests before they are sent.
+type offlineTransport struct {
+	next http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isLocalHost(req.URL.Hostname()) {
+		return nil, errOffline
+	}
+	return t.next.RoundTrip(req)
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+// offlineSection fills the section at index from the cache, or else from
+// the sections saved in the history, and marks what is out of date.
+func (m *model) offlineSection(ctx context.Context, info MusicInfo, index int, key string) {
+	m.mu.Lock()
+	title := m.sections[index].Title
+	m.mu.Unlock()
+
+	if e, ok := readCacheEntry(key); ok {
+		content := e.Content
+		if time.Since(e.Created) > cacheTTL {
+			content = staleNote(e.Created) + content
+		}
+		m.setSectionContent(ctx, index, content)
+		return
+	}
+	if history != nil {
+		content, updated, ok, err := history.SavedSection(ctx, info.artist, info.album, info.track, title)
+		if err == nil && ok && content != "" {
+			m.setSectionContent(ctx, index, staleNote(updated)+content)
+			return
+		}
+	}
+
+	m.whileCurrent(ctx, func() {
+		m.sections[index].Error = "offline: not in the cache nor the history"
+		m.buildContent()
+		m.changed = true
+	})
+}
+
+// staleNote heads the content of a section saved at t.
+func staleNote(t time.Time) string {
+	return "*" + trf("Offline, saved on %s", t.Local().Format("2006-01-02")) + "*\n\n"
+}
+
+// lyricsCacheKey is where the lyrics of a track are kept for offline use.
+func lyricsCacheKey(info MusicInfo) string {
+	return cacheKey(info, trackScope, "lrclib", "lyrics")
+}
+
+// cachedLyrics returns the lyrics of the track saved by an earlier fetch.
+func cachedLyrics(info MusicInfo) (*Lyrics, error) {
+	e, ok := readCacheEntry(lyricsCacheKey(info))
+	if !ok {
+		return nil, errNoLyrics
+	}
+	var l Lyrics
+	if err := json.Unmarshal([]byte(e.Content), &l); err != nil {
+		return nil, errNoLyrics
+	}
+	return &l, nil
+}
+
+// freshLyrics returns the lyrics of the track saved less than cacheTTL ago.
+func freshLyrics(info MusicInfo) (*Lyrics, bool) {
+	content, ok := readCache(lyricsCacheKey(info))
+	if !ok {
+		return nil, false
+	}
+	var l Lyrics
+	if err := json.Unmarshal([]byte(content), &l); err != nil {
+		return nil, false
+	}
+	return &l, true
+}
+
+// cacheLyrics keeps the lyrics of the track for the next plays and for
+// offline use.
+func cacheLyrics(info MusicInfo, l *Lyrics) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return
+	}
+	writeCache(lyricsCacheKey(info), string(data))
+}
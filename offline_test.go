@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+type offlineCompleter struct{ t *testing.T }
+
+func (c offlineCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	c.t.Errorf("the provider was asked %q offline", prompt)
+	return "", errors.New("asked offline")
+}
+
+func TestOfflineSections(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	defer func(o bool) { offline = o }(offline)
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+
+	// Online the answers and the lyrics are cached and the sections saved.
+	m.getInfo(context.Background())
+
+	// Age the cache past its TTL.
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		var e cacheEntry
+		json.Unmarshal(data, &e)
+		e.Created = time.Now().Add(-2 * cacheTTL)
+		data, _ = json.Marshal(e)
+		os.WriteFile(f, data, 0o600)
+	}
+
+	offline = true
+	completer = offlineCompleter{t}
+	getLyrics = func(ctx context.Context, info MusicInfo) (*Lyrics, error) {
+		t.Error("lrclib was asked offline")
+		return nil, errOffline
+	}
+	m.reset(testTrack)
+	m.getInfo(context.Background())
+	stale := "*Offline, saved on " + time.Now().Add(-2*cacheTTL).Format("2006-01-02") + "*\n\nstub answer for: Give me album info"
+	if got := m.sections[0].Content; !strings.HasPrefix(got, stale) {
+		t.Errorf("got album info %q", got)
+	}
+	if m.lyrics == nil || !strings.Contains(m.lyrics.Plain, "jackknifed juggernaut") {
+		t.Errorf("got lyrics %+v", m.lyrics)
+	}
+
+	// Without the cache the sections of the history are shown.
+	cacheDir = t.TempDir()
+	m.reset(testTrack)
+	m.getInfo(context.Background())
+	saved := "*Offline, saved on " + time.Now().Format("2006-01-02") + "*\n\nstub answer for: Give me album info"
+	if got := m.sections[0].Content; !strings.HasPrefix(got, saved) {
+		t.Errorf("got album info %q", got)
+	}
+
+	// A track never seen has nothing to show.
+	m.reset(MusicInfo{artist: "Portishead", album: "Dummy", track: "Roads"})
+	m.getInfo(context.Background())
+	if got := m.sections[0]; got.Content != "" || !strings.Contains(got.Error, "offline") {
+		t.Errorf("got section %+v", got)
+	}
+}
+
+func TestOfflineTransport(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer local.Close()
+	client := &http.Client{Transport: offlineTransport{next: http.DefaultTransport}}
+
+	resp, err := client.Get(local.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, err := client.Get("https://lrclib.net/api/get"); !errors.Is(err, errOffline) || retryable(err) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "github.com/ernesto27/stui/pkg/ai"
+
+// ollamaURL is the base URL of the Ollama server.
+var ollamaURL = ai.OllamaDefaultURL
+
+func init() {
+	registerProvider("ollama", providerFactory{
+		defaultModel:   ai.OllamaDefaultModel,
+		embeddingModel: ai.OllamaDefaultEmbeddingModel,
+		new: func() (ai.Provider, error) {
+			return ai.NewOllama(ollamaURL), nil
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+func init() {
+	registerProvider("openai", providerFactory{
+		defaultModel:   openai.GPT3Dot5Turbo,
+		embeddingModel: openai.AdaEmbeddingV2.String(),
+		keyEnv:         "OPENAI_TOKEN",
+		// The servers with the same API usually take no token.
+		hasKey: func() bool {
+			token, _ := openaiToken()
+			return token != "" || openaiBaseURL != ""
+		},
+		new: func() (ai.Provider, error) {
+			token, err := openaiToken()
+			if err != nil {
+				return nil, err
+			}
+			return ai.NewOpenAI(token, openaiBaseURL), nil
+		},
+	})
+}
+
+// openaiBaseURL points the openai provider to another server with the same
+// API, such as LM Studio or vLLM.
+var openaiBaseURL string
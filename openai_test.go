@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+func TestOpenAIEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("got request %s without the key", r.URL)
+		}
+		if body.Model != "nomic-embed-text" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":{"message":"model %q not found"}}`, body.Model)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"embedding":[1],"index":1},{"embedding":[0.5],"index":0}],"usage":{"prompt_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	defer func(u string) { openaiBaseURL = u }(openaiBaseURL)
+	openaiBaseURL = server.URL
+	t.Setenv("OPENAI_TOKEN", "key")
+	p, _, err := newProvider("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := p.(ai.EmbedProvider).Embed(context.Background(), "nomic-embed-text", []string{"a", "b"})
+	if err != nil || len(vectors) != 2 || vectors[0][0] != 0.5 {
+		t.Errorf("got %v, %v", vectors, err)
+	}
+	if _, err := p.(ai.EmbedProvider).Embed(context.Background(), "unknown", []string{"a"}); statusCode(err) != http.StatusNotFound {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+var openrouterURL = ai.OpenRouterURL
+
+// openrouterAPIKey is the key of OpenRouter, which serves the models of many
+// vendors with the OpenAI API. Its models are named after the vendor, such as
+// openai/gpt-4o or anthropic/claude-3.5-sonnet.
+var openrouterAPIKey = os.Getenv("OPENROUTER_API_KEY")
+
+func init() {
+	registerProvider("openrouter", providerFactory{
+		defaultModel: "openai/gpt-4o-mini",
+		keyEnv:       "OPENROUTER_API_KEY",
+		hasKey:       func() bool { return openrouterAPIKey != "" },
+		new: func() (ai.Provider, error) {
+			return ai.NewOpenRouter(openrouterAPIKey, openrouterURL), nil
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenRouterComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("got request %s without the key", r.URL)
+		}
+		if r.Header.Get("X-Title") != "stui" {
+			t.Errorf("missing app headers")
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	defer func(u, key string) { openrouterURL, openrouterAPIKey = u, key }(openrouterURL, openrouterAPIKey)
+	openrouterURL, openrouterAPIKey = server.URL, "key"
+
+	p, _, err := newProvider("openrouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := p.Complete(context.Background(), "anthropic/claude-3.5-sonnet", "hi")
+	if err != nil || content != "Hello" {
+		t.Errorf("got %q, %v", content, err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paletteActions are the key bindings listed in the command palette, in
+// order. Scrolling and the search navigation are left out.
+var paletteActions = []string{
+	"refresh", "refresh_uncached", "retry_section", "cancel_section", "edit_prompt", "lyrics", "translate", "open_link", "copy", "copy_all",
+	"export", "share", "vault", "search", "raw", "markdown_style", "read", "chat", "ask", "similar_artists", "deep_dive", "switch_artist", "pin_album", "quiz",
+	"bookmark", "bookmarks", "history", "stats", "search_history", "queue", "library", "catalog", "tracks", "listen_next", "play_pause", "next", "previous",
+	"volume_up", "volume_down", "shuffle", "repeat", "switch_player", "help", "quit",
+}
+
+// paletteCommand is an action of the command palette, keys is the binding
+// that does the same.
+type paletteCommand struct {
+	title string
+	keys  string
+	run   func(m *model) (tea.Model, tea.Cmd)
+}
+
+// commandPalette is the state of the command palette screen.
+type commandPalette struct {
+	commands []paletteCommand
+	filter   string
+	cursor   int
+}
+
+// matches returns the commands matching the filter, the ones containing it
+// as typed first.
+func (p commandPalette) matches() []paletteCommand {
+	var commands []paletteCommand
+	for _, c := range p.commands {
+		if fuzzyMatch(p.filter, c.title) {
+			commands = append(commands, c)
+		}
+	}
+	filter := strings.ToLower(p.filter)
+	sort.SliceStable(commands, func(i, j int) bool {
+		return strings.Contains(strings.ToLower(commands[i].title), filter) && !strings.Contains(strings.ToLower(commands[j].title), filter)
+	})
+	return commands
+}
+
+// paletteCommands lists the sections, the actions of the key bindings and
+// the themes.
+func (m *model) paletteCommands() []paletteCommand {
+	var commands []paletteCommand
+
+	m.mu.Lock()
+	tabs := m.tabs()
+	m.mu.Unlock()
+	for i, t := range tabs {
+		i := i
+		commands = append(commands, paletteCommand{
+			title: trf("Go to %s", t.label),
+			keys:  fmt.Sprint(i + 1),
+			run:   func(m *model) (tea.Model, tea.Cmd) { return m, m.switchTab(i) },
+		})
+	}
+
+	named := keys.named()
+	for _, name := range paletteActions {
+		b := named[name]
+		if len(b.Keys()) == 0 {
+			continue
+		}
+		msg := keyMsg(b.Keys()[0])
+		commands = append(commands, paletteCommand{
+			title: tr(b.Help().Desc),
+			keys:  b.Help().Key,
+			run:   func(m *model) (tea.Model, tea.Cmd) { return m.update(msg) },
+		})
+	}
+
+	for _, name := range themeNames() {
+		name := name
+		commands = append(commands, paletteCommand{
+			title: trf("Theme: %s", name),
+			run:   func(m *model) (tea.Model, tea.Cmd) { return m, m.switchTheme(name) },
+		})
+	}
+	return commands
+}
+
+// keyMsg is the key message that key.Matches matches with the key name s,
+// such as "ctrl+r", "tab" or "R".
+func keyMsg(s string) tea.KeyMsg {
+	// The named keys are the negative key types and the control characters.
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if t != tea.KeyRunes && t.String() == s {
+			return tea.KeyMsg{Type: t}
+		}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// openPalette shows the command palette.
+func (m *model) openPalette() {
+	m.palette = &commandPalette{commands: m.paletteCommands()}
+}
+
+func (m *model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.palette
+	matches := p.matches()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.palette = nil
+	case tea.KeyUp, tea.KeyCtrlP:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if p.cursor < len(matches)-1 {
+			p.cursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(p.filter); len(r) > 0 {
+			p.filter = string(r[:len(r)-1])
+			p.cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.filter += string(msg.Runes)
+		p.cursor = 0
+	case tea.KeyEnter:
+		if p.cursor < len(matches) {
+			m.palette = nil
+			return matches[p.cursor].run(m)
+		}
+	}
+	return m, nil
+}
+
+// switchTheme applies the named theme to the running screen.
+func (m *model) switchTheme(name string) tea.Cmd {
+	if err := applyTheme(name); err != nil {
+		m.errMsg = "  theme: " + err.Error()
+		return nil
+	}
+	themeName = name
+
+	// The colors of the cover go on top of the new theme.
+	m.mu.Lock()
+	img := m.artwork
+	m.mu.Unlock()
+	m.coverThemed = false
+	m.applyCoverTheme(img)
+	m.restyle()
+	if m.hasContent() {
+		if err := m.renderViewport(); err != nil {
+			m.errMsg = "  theme: " + err.Error()
+		}
+	}
+	m.notice = "  " + trf("Theme: %s", name)
+	return nil
+}
+
+func (m *model) paletteView() string {
+	p := m.palette
+	pad := strings.Repeat(" ", padding)
+	matches := p.matches()
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Commands")) + "\n\n")
+	b.WriteString(pad + "> " + p.filter + "█\n\n")
+
+	// Keep the cursor in view on short terminals.
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+
+	if len(matches) == 0 {
+		b.WriteString(pad + helpStyle(tr("No commands found")) + "\n")
+	}
+	for i := start; i < len(matches) && i < start+rows; i++ {
+		c := matches[i]
+		hint := ""
+		if c.keys != "" {
+			hint = helpStyle(" " + c.keys)
+		}
+		if i == p.cursor {
+			b.WriteString(pad + styleBadge("› "+c.title) + hint + "\n")
+		} else {
+			b.WriteString(pad + "  " + c.title + hint + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("type to filter • ↑/↓: Select • enter: Run • esc: Back")))
+	return b.String()
+}
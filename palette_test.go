@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyMsg(t *testing.T) {
+	for _, s := range []string{"ctrl+r", "tab", "shift+tab", " ", "R", "+", "esc", "pgup"} {
+		if got := keyMsg(s).String(); got != s {
+			t.Errorf("keyMsg(%q) is %q", s, got)
+		}
+	}
+}
+
+func TestCommandPalette(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.Update(tickMsg{})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	for _, r := range "trnslt" {
+		m.Update(keyRune(r))
+	}
+	if view := m.View(); !strings.Contains(view, "› Translate lyrics t") {
+		t.Errorf("palette does not select the command:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	for range "trnsl" {
+		m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	for _, r := range "go to lyrics" {
+		m.Update(keyRune(r))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.palette != nil || !m.onLyricsTab() {
+		t.Errorf("got tab %d, want the lyrics", m.tab)
+	}
+
+	// The themes are switched from the palette.
+	defer func(name, style string) {
+		applyTheme(name)
+		themeName, glamourStyle = name, style
+	}(themeName, glamourStyle)
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	for _, r := range "theme light" {
+		m.Update(keyRune(r))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if themeName != "light" || glamourStyle != "light" {
+		t.Errorf("got theme %s with the %s style", themeName, glamourStyle)
+	}
+}
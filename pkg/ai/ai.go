@@ -0,0 +1,84 @@
+// Package ai is the chat model clients stui asks about the music: OpenAI and
+// the servers with its API, Azure OpenAI, OpenRouter, Anthropic, Gemini and
+// Ollama. They work as the Provider of pkg/stui.
+//
+//	sections, err := stui.FetchAlbumInfo(ctx, ai.NewOllama(ai.OllamaDefaultURL), track, stui.Options{Model: ai.OllamaDefaultModel})
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Provider sends a single prompt to a chat model and returns the answer.
+type Provider interface {
+	Complete(ctx context.Context, model string, prompt string) (string, error)
+}
+
+// StreamProvider is implemented by providers that can send the answer as it
+// is generated, onToken is called for every chunk.
+type StreamProvider interface {
+	Stream(ctx context.Context, model string, prompt string, onToken func(string)) (string, error)
+}
+
+// StructuredProvider is implemented by providers that can answer with JSON
+// matching a schema, by calling fn with function calling or tool use.
+type StructuredProvider interface {
+	CompleteJSON(ctx context.Context, model string, prompt string, fn Function) (string, error)
+}
+
+// EmbedProvider is implemented by providers with an embeddings model, it
+// returns a vector for every text.
+type EmbedProvider interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
+
+// Function is the function a structured answer calls, its arguments are the
+// answer.
+type Function struct {
+	Name        string
+	Description string
+	// Schema is the JSON schema of the arguments.
+	Schema json.RawMessage
+}
+
+// Usage is the tokens of one request. Estimated is set when the API did not
+// report them and they were guessed from the text.
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool
+}
+
+type usageKey struct{}
+
+// WithUsage makes the providers report the tokens of the requests of ctx to
+// fn once they finished.
+func WithUsage(ctx context.Context, fn func(Usage)) context.Context {
+	return context.WithValue(ctx, usageKey{}, fn)
+}
+
+func reportUsage(ctx context.Context, model string, promptTokens, completionTokens int, estimated bool) {
+	if fn, ok := ctx.Value(usageKey{}).(func(Usage)); ok {
+		fn(Usage{Model: model, PromptTokens: promptTokens, CompletionTokens: completionTokens, Estimated: estimated})
+	}
+}
+
+// EstimateTokens guesses the tokens of s for the APIs that do not report
+// them, a token is about four characters of English.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// StatusError is the error of an API that answers with a message and no
+// error type of its own, such as Ollama.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("error, status code: %d, message: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,247 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AnthropicDefaultModel is the model stui asks when none is set.
+const AnthropicDefaultModel = "claude-3-5-sonnet-latest"
+
+const (
+	anthropicURL       = "https://api.anthropic.com/v1/messages"
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 2048
+)
+
+// Anthropic talks to the Anthropic Messages API.
+type Anthropic struct {
+	apiKey string
+	url    string
+	client *http.Client
+}
+
+// NewAnthropic returns a client of the Messages API with the key apiKey.
+func NewAnthropic(apiKey string) Anthropic {
+	return Anthropic{apiKey: apiKey, url: anthropicURL, client: &http.Client{}}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	Messages    []anthropicMessage `json:"messages"`
+	Stream      bool               `json:"stream,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  *anthropicChoice   `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool is a tool the model can call, structured answers force the
+// call of the only one.
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+		// Input is the arguments of a tool_use.
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicEvent is a server sent event of a streamed response, only the
+// fields used by stui are decoded.
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	// The input tokens come with message_start, the output tokens so far
+	// with every message_delta.
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage  `json:"usage"`
+	Error *AnthropicError `json:"error"`
+}
+
+// AnthropicError is the error returned by the Anthropic API.
+type AnthropicError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+}
+
+func (e *AnthropicError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("error, status code: %d, message: %s", e.StatusCode, e.Message)
+	}
+	return e.Message
+}
+
+func (c Anthropic) Complete(ctx context.Context, model string, query string) (string, error) {
+	r, err := c.send(ctx, c.request(model, query, false))
+	if err != nil {
+		return "", err
+	}
+
+	var content strings.Builder
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			content.WriteString(c.Text)
+		}
+	}
+	reportUsage(ctx, model, r.Usage.InputTokens, r.Usage.OutputTokens, false)
+	return content.String(), nil
+}
+
+func (c Anthropic) CompleteJSON(ctx context.Context, model string, query string, fn Function) (string, error) {
+	req := c.request(model, query, false)
+	req.Tools = []anthropicTool{{Name: fn.Name, Description: fn.Description, InputSchema: fn.Schema}}
+	req.ToolChoice = &anthropicChoice{Type: "tool", Name: fn.Name}
+	r, err := c.send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	reportUsage(ctx, model, r.Usage.InputTokens, r.Usage.OutputTokens, false)
+	for _, c := range r.Content {
+		if c.Type == "tool_use" {
+			return string(c.Input), nil
+		}
+	}
+	return "", fmt.Errorf("the model did not call %s", fn.Name)
+}
+
+// send posts a request that is not streamed and decodes the answer.
+func (c Anthropic) send(ctx context.Context, req anthropicRequest) (*anthropicResponse, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (c Anthropic) Stream(ctx context.Context, model string, query string, onToken func(string)) (string, error) {
+	resp, err := c.do(ctx, c.request(model, query, true))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage anthropicUsage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return content.String(), err
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				content.WriteString(event.Delta.Text)
+				onToken(event.Delta.Text)
+			}
+		case "error":
+			if event.Error != nil {
+				return content.String(), event.Error
+			}
+		case "message_stop":
+			reportUsage(ctx, model, usage.InputTokens, usage.OutputTokens, false)
+			return content.String(), nil
+		}
+	}
+
+	return content.String(), scanner.Err()
+}
+
+func (c Anthropic) request(model string, query string, stream bool) anthropicRequest {
+	return anthropicRequest{
+		Model:       model,
+		MaxTokens:   anthropicMaxTokens,
+		Temperature: 0,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: query},
+		},
+		Stream: stream,
+	}
+}
+
+func (c Anthropic) do(ctx context.Context, r anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var r struct {
+			Error AnthropicError `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil || r.Error.Message == "" {
+			r.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		r.Error.StatusCode = resp.StatusCode
+		return nil, &r.Error
+	}
+
+	return resp, nil
+}
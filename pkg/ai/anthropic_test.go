@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "key" {
+			t.Errorf("missing api key header")
+		}
+
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":12,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n")
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":2}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	c := NewAnthropic("key")
+	c.url = server.URL
+
+	var usage Usage
+	ctx := WithUsage(context.Background(), func(u Usage) { usage = u })
+
+	var tokens []string
+	content, err := c.Stream(ctx, AnthropicDefaultModel, "hi", func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "Hello world" || len(tokens) != 2 {
+		t.Errorf("got %q from %d tokens", content, len(tokens))
+	}
+	if usage.PromptTokens != 12 || usage.CompletionTokens != 2 || usage.Estimated {
+		t.Errorf("got usage %+v", usage)
+	}
+}
+
+func TestAnthropicError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 200001 tokens > 200000 maximum"}}`)
+	}))
+	defer server.Close()
+
+	c := NewAnthropic("key")
+	c.url = server.URL
+
+	_, err := c.Complete(context.Background(), AnthropicDefaultModel, "hi")
+	var apiErr *AnthropicError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Type != "invalid_request_error" || !strings.HasPrefix(apiErr.Message, "prompt is too long") {
+		t.Errorf("got %v, want the error of the API", err)
+	}
+}
+
+// tracklistFunction is a structured answer of the tests.
+var tracklistFunction = Function{
+	Name:   "show_tracklist",
+	Schema: json.RawMessage(`{"type":"object","properties":{"tracks":{"type":"array"}}}`),
+}
+
+func TestAnthropicCompleteJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Tools) != 1 || req.ToolChoice == nil || req.ToolChoice.Name != tracklistFunction.Name {
+			t.Errorf("got tools %+v and choice %+v", req.Tools, req.ToolChoice)
+		}
+		fmt.Fprint(w, `{"content":[{"type":"tool_use","name":"show_tracklist","input":{"tracks":[{"number":"1","title":"Airbag","duration":"4:44"}]}}],"usage":{"input_tokens":30,"output_tokens":20}}`)
+	}))
+	defer server.Close()
+
+	c := NewAnthropic("key")
+	c.url = server.URL
+
+	got, err := c.CompleteJSON(context.Background(), AnthropicDefaultModel, "hi", tracklistFunction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"tracks":[{"number":"1","title":"Airbag","duration":"4:44"}]}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
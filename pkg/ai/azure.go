@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// AzureDefaultAPIVersion is the API version asked when the config leaves it
+// unset.
+const AzureDefaultAPIVersion = "2024-02-01"
+
+// AzureConfig is an Azure OpenAI resource. Endpoint is its URL, such as
+// https://my-resource.openai.azure.com, and Deployment returns the
+// deployment of a model, the model name when it is nil as Azure names them
+// after the model by default.
+type AzureConfig struct {
+	Endpoint   string
+	APIKey     string
+	APIVersion string
+	Deployment func(model string) string
+}
+
+// NewAzure returns a client of the OpenAI API of an Azure resource.
+func NewAzure(c AzureConfig) OpenAI {
+	if c.APIVersion == "" {
+		c.APIVersion = AzureDefaultAPIVersion
+	}
+	deployment := c.Deployment
+	if deployment == nil {
+		deployment = func(model string) string { return model }
+	}
+
+	config := openai.DefaultAzureConfig(c.APIKey, c.Endpoint)
+	config.APIVersion = c.APIVersion
+	config.AzureModelMapperFunc = deployment
+	client := newOpenAI(config, map[string]string{"api-key": c.APIKey})
+	endpoint := strings.TrimRight(c.Endpoint, "/")
+	client.embedURL = func(model string) string {
+		return endpoint + "/openai/deployments/" + url.PathEscape(deployment(model)) + "/embeddings?api-version=" + url.QueryEscape(c.APIVersion)
+	}
+	return client
+}
@@ -0,0 +1,234 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The models stui asks when none is set.
+const (
+	GeminiDefaultModel          = "gemini-1.5-flash"
+	GeminiDefaultEmbeddingModel = "text-embedding-004"
+)
+
+// geminiURL is the base URL of the Gemini API, the model and the method are
+// added to it.
+const geminiURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini talks to the generateContent API of Google Gemini.
+type Gemini struct {
+	apiKey string
+	url    string
+	client *http.Client
+}
+
+// NewGemini returns a client of the Gemini API with the key apiKey.
+func NewGemini(apiKey string) Gemini {
+	return Gemini{apiKey: apiKey, url: geminiURL, client: &http.Client{}}
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig struct {
+		Temperature float64 `json:"temperature"`
+	} `json:"generationConfig"`
+}
+
+// geminiResponse is a full response, or a chunk of it when streaming.
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	// The token counts so far are set on every chunk.
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *GeminiError `json:"error"`
+}
+
+func (r geminiResponse) text() string {
+	var text strings.Builder
+	for _, c := range r.Candidates {
+		for _, p := range c.Content.Parts {
+			text.WriteString(p.Text)
+		}
+	}
+	return text.String()
+}
+
+// GeminiError is the error returned by the Gemini API.
+type GeminiError struct {
+	StatusCode int    `json:"code"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+}
+
+func (e *GeminiError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("error, status code: %d, message: %s", e.StatusCode, e.Message)
+	}
+	return e.Message
+}
+
+func (c Gemini) Complete(ctx context.Context, model string, query string) (string, error) {
+	resp, err := c.do(ctx, model, query, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var r geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	reportUsage(ctx, model, r.UsageMetadata.PromptTokenCount, r.UsageMetadata.CandidatesTokenCount, false)
+	return r.text(), nil
+}
+
+func (c Gemini) Stream(ctx context.Context, model string, query string, onToken func(string)) (string, error) {
+	resp, err := c.do(ctx, model, query, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var last geminiResponse
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return content.String(), err
+		}
+		if chunk.Error != nil {
+			return content.String(), chunk.Error
+		}
+		if token := chunk.text(); token != "" {
+			content.WriteString(token)
+			onToken(token)
+		}
+		last = chunk
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), err
+	}
+
+	reportUsage(ctx, model, last.UsageMetadata.PromptTokenCount, last.UsageMetadata.CandidatesTokenCount, false)
+	return content.String(), nil
+}
+
+func (c Gemini) do(ctx context.Context, model string, query string, stream bool) (*http.Response, error) {
+	r := geminiRequest{Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: query}}}}}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The streamed response is sent as server sent events with alt=sse.
+	endpoint := c.url + "/models/" + model + ":generateContent"
+	if stream {
+		endpoint = c.url + "/models/" + model + ":streamGenerateContent?alt=sse"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var r struct {
+			Error GeminiError `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil || r.Error.Message == "" {
+			r.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		r.Error.StatusCode = resp.StatusCode
+		return nil, &r.Error
+	}
+
+	return resp, nil
+}
+
+func (c Gemini) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	type embedRequest struct {
+		Model   string        `json:"model"`
+		Content geminiContent `json:"content"`
+	}
+	var r struct {
+		Requests []embedRequest `json:"requests"`
+	}
+	for _, text := range texts {
+		r.Requests = append(r.Requests, embedRequest{Model: "models/" + model, Content: geminiContent{Parts: []geminiPart{{Text: text}}}})
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/models/"+model+":batchEmbedContents", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var answer struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+		Error GeminiError `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if answer.Error.Message == "" {
+			answer.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		answer.Error.StatusCode = resp.StatusCode
+		return nil, &answer.Error
+	}
+	if len(answer.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(answer.Embeddings), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for i, e := range answer.Embeddings {
+		vectors[i] = e.Values
+	}
+	return vectors, nil
+}
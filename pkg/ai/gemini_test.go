@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeminiStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("got request %s", r.URL)
+		}
+		if r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("missing api key header")
+		}
+
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hello\"}]}}],\"usageMetadata\":{\"promptTokenCount\":12,\"candidatesTokenCount\":1}}\r\n\r\n")
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\" world\"}]}}],\"usageMetadata\":{\"promptTokenCount\":12,\"candidatesTokenCount\":2}}\r\n\r\n")
+	}))
+	defer server.Close()
+
+	c := NewGemini("key")
+	c.url = server.URL
+
+	var usage Usage
+	ctx := WithUsage(context.Background(), func(u Usage) { usage = u })
+
+	var tokens []string
+	content, err := c.Stream(ctx, GeminiDefaultModel, "hi", func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "Hello world" || len(tokens) != 2 {
+		t.Errorf("got %q from %d tokens", content, len(tokens))
+	}
+	if usage.PromptTokens != 12 || usage.CompletionTokens != 2 || usage.Estimated {
+		t.Errorf("got usage %+v", usage)
+	}
+}
+
+func TestGeminiErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") == "" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":400,"message":"The input token count (1048577) exceeds the maximum number of tokens allowed (1048576).","status":"INVALID_ARGUMENT"}}`)
+	}))
+	defer server.Close()
+
+	c := NewGemini("key")
+	c.url = server.URL
+	var apiErr *GeminiError
+	if _, err := c.Complete(context.Background(), GeminiDefaultModel, "hi"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Status != "INVALID_ARGUMENT" {
+		t.Errorf("got %v, want the error of the API", err)
+	}
+
+	c.apiKey = ""
+	if _, err := c.Complete(context.Background(), GeminiDefaultModel, "hi"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got %v, want a rate limit", err)
+	}
+}
+
+func TestGeminiEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/text-embedding-004:batchEmbedContents" || r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("got request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"embeddings":[{"values":[0.5,0.25]}]}`)
+	}))
+	defer server.Close()
+
+	c := NewGemini("key")
+	c.url = server.URL
+	vectors, err := c.Embed(context.Background(), GeminiDefaultEmbeddingModel, []string{"moody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 1 || vectors[0][1] != 0.25 {
+		t.Errorf("got %v", vectors)
+	}
+}
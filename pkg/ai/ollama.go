@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OllamaDefaultURL is where a local Ollama server listens, the models are
+// the ones stui asks when none is set.
+const (
+	OllamaDefaultURL            = "http://localhost:11434"
+	OllamaDefaultModel          = "llama3"
+	OllamaDefaultEmbeddingModel = "nomic-embed-text"
+)
+
+// Ollama talks to the chat endpoint of a local Ollama server.
+type Ollama struct {
+	url    string
+	client *http.Client
+}
+
+// NewOllama returns a client of the Ollama server at baseURL.
+func NewOllama(baseURL string) Ollama {
+	return Ollama{url: strings.TrimSuffix(baseURL, "/") + "/api/chat", client: &http.Client{}}
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  struct {
+		Temperature float64 `json:"temperature"`
+	} `json:"options"`
+}
+
+// ollamaResponse is a full response, or a chunk of it when streaming.
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+	// The token counts are set on the last chunk.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (c Ollama) Complete(ctx context.Context, model string, query string) (string, error) {
+	resp, err := c.do(ctx, model, query, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var r ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	if r.Error != "" {
+		return "", errors.New(r.Error)
+	}
+	reportUsage(ctx, model, r.PromptEvalCount, r.EvalCount, false)
+	return r.Message.Content, nil
+}
+
+func (c Ollama) Stream(ctx context.Context, model string, query string, onToken func(string)) (string, error) {
+	resp, err := c.do(ctx, model, query, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// The streamed response is one JSON object per line.
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var chunk ollamaResponse
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			return content.String(), err
+		}
+		if chunk.Error != "" {
+			return content.String(), errors.New(chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onToken(chunk.Message.Content)
+		}
+		if chunk.Done {
+			reportUsage(ctx, model, chunk.PromptEvalCount, chunk.EvalCount, false)
+			return content.String(), nil
+		}
+	}
+
+	return content.String(), scanner.Err()
+}
+
+func (c Ollama) do(ctx context.Context, model string, query string, stream bool) (*http.Response, error) {
+	r := ollamaRequest{
+		Model:    model,
+		Messages: []ollamaMessage{{Role: "user", Content: query}},
+		Stream:   stream,
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var r ollamaResponse
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil || r.Error == "" {
+			r.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: r.Error}
+	}
+
+	return resp, nil
+}
+
+func (c Ollama) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(c.url, "/chat") + "/embed"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+		Error           string      `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if r.Error == "" {
+			r.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: r.Error}
+	}
+	if len(r.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(r.Embeddings), len(texts))
+	}
+	reportUsage(ctx, model, r.PromptEvalCount, 0, false)
+	return r.Embeddings, nil
+}
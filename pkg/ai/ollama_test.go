@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOllamaStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("got path %q", r.URL.Path)
+		}
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "llama3" || !req.Stream {
+			t.Errorf("unexpected request %+v: %v", req, err)
+		}
+
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":" world"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":26,"eval_count":2}`)
+	}))
+	defer server.Close()
+
+	c := NewOllama(server.URL + "/")
+
+	var usage Usage
+	ctx := WithUsage(context.Background(), func(u Usage) { usage = u })
+
+	var tokens []string
+	content, err := c.Stream(ctx, "llama3", "hi", func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "Hello world" || len(tokens) != 2 {
+		t.Errorf("got %q from %d tokens", content, len(tokens))
+	}
+	if usage.PromptTokens != 26 || usage.CompletionTokens != 2 {
+		t.Errorf("got usage %+v", usage)
+	}
+}
+
+func TestOllamaModelNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"llama3\" not found, try pulling it first"}`)
+	}))
+	defer server.Close()
+
+	_, err := NewOllama(server.URL).Complete(context.Background(), "llama3", "hi")
+	if err == nil || err.Error() != `error, status code: 404, message: model "llama3" not found, try pulling it first` {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestOllamaEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/api/embed" || req.Model != "nomic-embed-text" || len(req.Input) != 2 {
+			t.Errorf("unexpected request %s %+v: %v", r.URL.Path, req, err)
+		}
+		fmt.Fprint(w, `{"embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":7}`)
+	}))
+	defer server.Close()
+
+	vectors, err := NewOllama(server.URL).Embed(context.Background(), OllamaDefaultEmbeddingModel, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[1][0] != 0.3 {
+		t.Errorf("got %v", vectors)
+	}
+}
@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// headerTransport adds headers to the requests of a client.
+type headerTransport map[string]string
+
+func (h headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h {
+		req.Header.Set(k, v)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// OpenAI talks to the OpenAI API and the servers with the same API.
+type OpenAI struct {
+	client *openai.Client
+	// embedURL and embedHeader send the embeddings of the models the client
+	// does not name itself, it only names the ada ones.
+	embedURL    func(model string) string
+	embedHeader map[string]string
+	http        *http.Client
+}
+
+// NewOpenAI returns a client of the OpenAI API with token, baseURL points it
+// to another server with the same API, such as LM Studio or vLLM, which
+// usually take no token.
+func NewOpenAI(token, baseURL string) OpenAI {
+	config := openai.DefaultConfig(token)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	return newOpenAI(config, bearer(token))
+}
+
+// newOpenAI makes a client of the servers with the OpenAI API, header
+// authorizes the embeddings it sends itself.
+func newOpenAI(config openai.ClientConfig, header map[string]string) OpenAI {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	return OpenAI{
+		client:      openai.NewClientWithConfig(config),
+		embedURL:    func(string) string { return baseURL + "/embeddings" },
+		embedHeader: header,
+		http:        httpClient,
+	}
+}
+
+// bearer is the header of a token, none without it.
+func bearer(token string) map[string]string {
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func (c OpenAI) Stream(ctx context.Context, model string, query string, onToken func(string)) (string, error) {
+	stream, err := c.client.CreateChatCompletionStream(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:       model,
+			Temperature: 0,
+			Stream:      true,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: query,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// Streamed responses do not tell the usage.
+			reportUsage(ctx, model, EstimateTokens(query), EstimateTokens(content.String()), true)
+			return content.String(), nil
+		}
+		if err != nil {
+			return content.String(), err
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		token := resp.Choices[0].Delta.Content
+		content.WriteString(token)
+		onToken(token)
+	}
+}
+
+func (c OpenAI) Complete(ctx context.Context, model string, query string) (string, error) {
+	resp, err := c.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:       model,
+			Temperature: 0,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: query,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	reportUsage(ctx, model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, false)
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (c OpenAI) CompleteJSON(ctx context.Context, model string, query string, fn Function) (string, error) {
+	resp, err := c.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:       model,
+			Temperature: 0,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: query,
+				},
+			},
+			Functions:    []openai.FunctionDefinition{{Name: fn.Name, Description: fn.Description, Parameters: fn.Schema}},
+			FunctionCall: openai.FunctionCall{Name: fn.Name},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	reportUsage(ctx, model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, false)
+	call := resp.Choices[0].Message.FunctionCall
+	if call == nil {
+		return "", fmt.Errorf("the model did not call %s", fn.Name)
+	}
+	return call.Arguments, nil
+}
+
+func (c OpenAI) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	var resp openai.EmbeddingResponse
+	var m openai.EmbeddingModel
+	var err error
+	if m.UnmarshalText([]byte(model)); m != openai.Unknown {
+		resp, err = c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{Input: texts, Model: m})
+	} else {
+		resp, err = c.embed(ctx, model, texts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reportUsage(ctx, model, resp.Usage.PromptTokens, 0, false)
+	vectors := make([][]float32, len(texts))
+	for _, e := range resp.Data {
+		if e.Index >= 0 && e.Index < len(vectors) {
+			vectors[e.Index] = e.Embedding
+		}
+	}
+	return vectors, nil
+}
+
+// embed sends the embeddings request of a model the client does not name,
+// such as text-embedding-3-small.
+func (c OpenAI) embed(ctx context.Context, model string, texts []string) (openai.EmbeddingResponse, error) {
+	var resp openai.EmbeddingResponse
+	if c.embedURL == nil {
+		return resp, fmt.Errorf("unsupported embedding model %q, use %s", model, openai.AdaEmbeddingV2)
+	}
+	body, err := json.Marshal(map[string]any{"model": model, "input": texts})
+	if err != nil {
+		return resp, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.embedURL(model), bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.embedHeader {
+		req.Header.Set(k, v)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var answer openai.ErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&answer); err != nil || answer.Error == nil {
+			answer.Error = &openai.APIError{Message: http.StatusText(res.StatusCode)}
+		}
+		answer.Error.HTTPStatusCode = res.StatusCode
+		return resp, answer.Error
+	}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	return resp, err
+}
@@ -0,0 +1,24 @@
+package ai
+
+import (
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenRouterURL is the base URL of OpenRouter, which serves the models of many
+// vendors with the OpenAI API. Its models are named after the vendor, such as
+// openai/gpt-4o or anthropic/claude-3.5-sonnet.
+const OpenRouterURL = "https://openrouter.ai/api/v1"
+
+// NewOpenRouter returns a client of OpenRouter at baseURL with the key apiKey.
+func NewOpenRouter(apiKey, baseURL string) OpenAI {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+	// OpenRouter lists the apps by these headers.
+	config.HTTPClient = &http.Client{Transport: headerTransport{
+		"HTTP-Referer": "https://github.com/ernesto27/stui",
+		"X-Title":      "stui",
+	}}
+	return newOpenAI(config, bearer(apiKey))
+}
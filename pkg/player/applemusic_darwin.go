@@ -0,0 +1,146 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppleMusic reads the track from Music.app through AppleScript.
+type AppleMusic struct{}
+
+// appleScriptTrackScript reads the track of Music or Spotify, which share
+// these words. It checks that the app is running first, telling it anything
+// would launch it.
+const appleScriptTrackScript = `
+if application "%[1]s" is not running then return "unavailable"
+tell application "%[1]s"
+	if player state is not playing then return "idle"
+	return "playing" & linefeed & artist of current track & linefeed & album of current track & linefeed & name of current track
+end tell`
+
+func runOsascript(script string) (string, error) {
+	out, err := exec.Command("osascript", "-e", script).Output()
+	return strings.TrimRight(string(out), "\n"), err
+}
+
+func (AppleMusic) Track() (Track, Status) {
+	out, err := runOsascript(fmt.Sprintf(appleScriptTrackScript, "Music"))
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	return parseAppleMusicTrack(out)
+}
+
+func parseAppleMusicTrack(out string) (Track, Status) {
+	lines := strings.Split(out, "\n")
+	switch {
+	case lines[0] == "idle":
+		return Track{}, Idle
+	case lines[0] != "playing" || len(lines) < 4:
+		return Track{}, Unavailable
+	}
+
+	track := Track{
+		Artists: []string{strings.TrimSpace(lines[1])},
+		Album:   strings.TrimSpace(lines[2]),
+		Title:   strings.TrimSpace(lines[3]),
+	}
+	if track.empty() {
+		return Track{}, Idle
+	}
+	return track, Playing
+}
+
+// appleScriptControlScript runs a command, which can be a block, without
+// launching the app.
+const appleScriptControlScript = `
+if application "%[1]s" is not running then return
+tell application "%[1]s"
+	%[2]s
+end tell`
+
+func (AppleMusic) Control(action Action) {
+	command := map[Action]string{
+		PlayPause:  "playpause",
+		Next:       "next track",
+		Previous:   "previous track",
+		VolumeUp:   "set sound volume to sound volume + " + strconv.Itoa(VolumeStep),
+		VolumeDown: "set sound volume to sound volume - " + strconv.Itoa(VolumeStep),
+		Shuffle:    "set shuffle enabled to not shuffle enabled",
+		Repeat:     appleMusicRepeatCommand,
+	}[action]
+	runOsascript(fmt.Sprintf(appleScriptControlScript, "Music", command))
+}
+
+func (AppleMusic) Position() (time.Duration, error) {
+	return appleMusicSeconds(`tell application "Music" to get player position`)
+}
+
+func (AppleMusic) Duration() (time.Duration, error) {
+	return appleMusicSeconds(`tell application "Music" to get duration of current track`)
+}
+
+// appleMusicSeconds runs a script that answers with a number of seconds.
+func appleMusicSeconds(script string) (time.Duration, error) {
+	out, err := runOsascript(script)
+	if err != nil {
+		return 0, err
+	}
+
+	// The answer is a real number of seconds, formatted with the decimal
+	// separator of the user locale.
+	seconds, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(out), ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// appleMusicRepeatCommand cycles the repeat mode in the order of
+// Settings.Adjusted.
+const appleMusicRepeatCommand = `if song repeat is off then
+		set song repeat to all
+	else if song repeat is all then
+		set song repeat to one
+	else
+		set song repeat to off
+	end if`
+
+const appleMusicSettingsScript = `
+if application "Music" is not running then return "unavailable"
+tell application "Music" to return (sound volume as text) & linefeed & shuffle enabled & linefeed & song repeat`
+
+func (AppleMusic) Settings() (Settings, error) {
+	out, err := runOsascript(appleMusicSettingsScript)
+	if err != nil {
+		return Settings{}, err
+	}
+	return parseAppleScriptSettings(out)
+}
+
+// parseAppleScriptSettings reads the volume, shuffle and repeat lines of the
+// Spotify and Music.app scripts. Spotify repeats with a boolean, Music.app
+// with off, all or one.
+func parseAppleScriptSettings(out string) (Settings, error) {
+	lines := strings.Split(out, "\n")
+	if len(lines) < 3 {
+		return Settings{}, errors.New("the player is not running")
+	}
+
+	volume, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return Settings{}, err
+	}
+	settings := Settings{Volume: volume, Shuffle: strings.TrimSpace(lines[1]) == "true"}
+	switch strings.TrimSpace(lines[2]) {
+	case "true", "all":
+		settings.Repeat = RepeatAll
+	case "one":
+		settings.Repeat = RepeatTrack
+	}
+	return settings, nil
+}
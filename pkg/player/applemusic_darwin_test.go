@@ -0,0 +1,48 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAppleMusicTrack(t *testing.T) {
+	tests := []struct {
+		out    string
+		track  Track
+		status Status
+	}{
+		{"playing\nRadiohead\nOK Computer (Remastered)\nAirbag", Track{Artists: []string{"Radiohead"}, Album: "OK Computer (Remastered)", Title: "Airbag"}, Playing},
+		{"idle", Track{}, Idle},
+		{"unavailable", Track{}, Unavailable},
+		{"playing\n\n\n", Track{}, Idle},
+	}
+
+	for _, tt := range tests {
+		track, status := parseAppleMusicTrack(tt.out)
+		if !reflect.DeepEqual(track, tt.track) || status != tt.status {
+			t.Errorf("parseAppleMusicTrack(%q) = %+v, %d, want %+v, %d", tt.out, track, status, tt.track, tt.status)
+		}
+	}
+}
+
+func TestParseAppleScriptSettings(t *testing.T) {
+	tests := []struct {
+		out      string
+		settings Settings
+	}{
+		{"60\ntrue\nfalse", Settings{Volume: 60, Shuffle: true, Repeat: RepeatOff}},
+		{"35\nfalse\ntrue", Settings{Volume: 35, Repeat: RepeatAll}},
+		{"100\nfalse\none", Settings{Volume: 100, Repeat: RepeatTrack}},
+		{"0\nfalse\nall", Settings{Volume: 0, Repeat: RepeatAll}},
+	}
+
+	for _, tt := range tests {
+		settings, err := parseAppleScriptSettings(tt.out)
+		if err != nil || settings != tt.settings {
+			t.Errorf("parseAppleScriptSettings(%q) = %+v, %v, want %+v", tt.out, settings, err, tt.settings)
+		}
+	}
+	if _, err := parseAppleScriptSettings("unavailable"); err == nil {
+		t.Error("no error when the player is not running")
+	}
+}
@@ -0,0 +1,109 @@
+package player
+
+import (
+	"sync"
+	"time"
+)
+
+// Source is a player read by Auto, Name is shown as the player the track is
+// read from.
+type Source struct {
+	Name   string
+	Player Player
+}
+
+// Auto reads several players and follows the one playing, controls go to
+// it. A source picked with Cycle is kept while it is open, even when another
+// one plays.
+type Auto struct {
+	sources []Source
+
+	mu     sync.Mutex
+	active int
+	pinned bool
+}
+
+// NewAuto reads sources, the first one is active until another plays. There
+// must be at least one.
+func NewAuto(sources []Source) *Auto {
+	return &Auto{sources: sources}
+}
+
+// Track reads the active source, and the others when it is not playing: the
+// first one playing becomes the active source. While nothing plays the
+// active source stays, unless it is not open and another one is.
+func (p *Auto) Track() (Track, Status) {
+	p.mu.Lock()
+	active, pinned := p.active, p.pinned
+	p.mu.Unlock()
+
+	track, status := p.sources[active].Player.Track()
+	if status == Playing || (pinned && status == Idle) {
+		return track, status
+	}
+
+	open := -1
+	for i, s := range p.sources {
+		if i == active {
+			continue
+		}
+		sourceTrack, sourceStatus := s.Player.Track()
+		if sourceStatus == Playing {
+			p.activate(i, false)
+			return sourceTrack, sourceStatus
+		}
+		if sourceStatus == Idle && open < 0 {
+			open = i
+		}
+	}
+	if status == Unavailable && open >= 0 {
+		p.activate(open, false)
+		return Track{}, Idle
+	}
+	return track, status
+}
+
+// activate makes the source at index i the active one, pinned when it was
+// picked by hand.
+func (p *Auto) activate(i int, pinned bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active, p.pinned = i, pinned
+}
+
+// Source is the name of the active source.
+func (p *Auto) Source() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sources[p.active].Name
+}
+
+// Cycle pins the next source and returns its name.
+func (p *Auto) Cycle() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active, p.pinned = (p.active+1)%len(p.sources), true
+	return p.sources[p.active].Name
+}
+
+func (p *Auto) current() Player {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sources[p.active].Player
+}
+
+func (p *Auto) Control(action Action) {
+	p.current().Control(action)
+}
+
+func (p *Auto) Position() (time.Duration, error) {
+	return p.current().Position()
+}
+
+func (p *Auto) Duration() (time.Duration, error) {
+	return p.current().Duration()
+}
+
+func (p *Auto) Settings() (Settings, error) {
+	return p.current().Settings()
+}
@@ -0,0 +1,60 @@
+package player
+
+import (
+	"sync"
+	"time"
+)
+
+// Fallback reads from primary and switches to fallback while primary is
+// unavailable, controls go to whichever answered last.
+type Fallback struct {
+	primary  Player
+	fallback Player
+
+	mu     sync.Mutex
+	active Player
+}
+
+// NewFallback reads fallback while primary is unavailable.
+func NewFallback(primary, fallback Player) *Fallback {
+	return &Fallback{primary: primary, fallback: fallback}
+}
+
+func (p *Fallback) Track() (Track, Status) {
+	source := p.primary
+	track, status := source.Track()
+	if status == Unavailable {
+		source = p.fallback
+		track, status = source.Track()
+	}
+
+	p.mu.Lock()
+	p.active = source
+	p.mu.Unlock()
+	return track, status
+}
+
+func (p *Fallback) current() Player {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active == nil {
+		return p.primary
+	}
+	return p.active
+}
+
+func (p *Fallback) Control(action Action) {
+	p.current().Control(action)
+}
+
+func (p *Fallback) Position() (time.Duration, error) {
+	return p.current().Position()
+}
+
+func (p *Fallback) Duration() (time.Duration, error) {
+	return p.current().Duration()
+}
+
+func (p *Fallback) Settings() (Settings, error) {
+	return p.current().Settings()
+}
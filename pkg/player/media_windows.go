@@ -0,0 +1,226 @@
+package player
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The session expressions pick the media session of the Spotify app or the
+// one Windows shows in its media controls.
+const (
+	windowsSpotifySession = `$manager.GetSessions() | Where-Object { $_.SourceAppUserModelId -like '*Spotify*' } | Select-Object -First 1`
+	windowsCurrentSession = `$manager.GetCurrentSession()`
+)
+
+// windowsMediaScript reads a media session through the
+// GlobalSystemMediaTransportControls WinRT API, PowerShell awaits its async
+// calls through AsTask. The first %s picks the session and the second runs a
+// control on it before the state is printed.
+const windowsMediaScript = `
+$ErrorActionPreference = 'Stop'
+[Console]::OutputEncoding = [Text.Encoding]::UTF8
+Add-Type -AssemblyName System.Runtime.WindowsRuntime
+$asTask = [System.WindowsRuntimeSystemExtensions].GetMethods() | Where-Object {
+	$_.Name -eq 'AsTask' -and $_.GetParameters().Count -eq 1 -and $_.GetParameters()[0].ParameterType.Name -eq 'IAsyncOperation` + "`" + `1'
+} | Select-Object -First 1
+function Await($operation, [Type]$type) {
+	$task = $asTask.MakeGenericMethod($type).Invoke($null, @($operation))
+	$task.Wait(-1) | Out-Null
+	$task.Result
+}
+[Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager, Windows.Media.Control, ContentType = WindowsRuntime] | Out-Null
+$manager = Await ([Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager]::RequestAsync()) ([Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager])
+$session = %s
+if (-not $session) { 'unavailable'; exit }
+$info = $session.GetPlaybackInfo()
+%s
+$props = Await ($session.TryGetMediaPropertiesAsync()) ([Windows.Media.Control.GlobalSystemMediaTransportControlsSessionMediaProperties])
+$timeline = $session.GetTimelineProperties()
+$position = $timeline.Position
+if ($info.PlaybackStatus -eq 'Playing') { $position += [DateTimeOffset]::Now - $timeline.LastUpdatedTime }
+$info.PlaybackStatus.ToString()
+$props.Artist
+$props.AlbumTitle
+$props.Title
+[int64]$position.TotalMilliseconds
+[int64]($timeline.EndTime - $timeline.StartTime).TotalMilliseconds
+[string]$info.IsShuffleActive
+[string]$info.AutoRepeatMode`
+
+// windowsMediaControls are the PowerShell calls of the controls, the
+// settings ones toggle the state read in $info. Media sessions have no
+// volume.
+var windowsMediaControls = map[Action]string{
+	PlayPause: `Await ($session.TryTogglePlayPauseAsync()) ([bool]) | Out-Null`,
+	Next:      `Await ($session.TrySkipNextAsync()) ([bool]) | Out-Null`,
+	Previous:  `Await ($session.TrySkipPreviousAsync()) ([bool]) | Out-Null`,
+	Shuffle:   `Await ($session.TryChangeShuffleActiveAsync(-not $info.IsShuffleActive)) ([bool]) | Out-Null`,
+	Repeat: `$next = @{ 'None' = 'List'; 'List' = 'Track'; 'Track' = 'None' }[[string]$info.AutoRepeatMode]
+Await ($session.TryChangeAutoRepeatModeAsync($next)) ([bool]) | Out-Null`,
+}
+
+// windowsMediaState is what windowsMediaScript prints.
+type windowsMediaState struct {
+	status   string
+	track    Track
+	position time.Duration
+	duration time.Duration
+	settings Settings
+}
+
+var errNoMediaSession = errors.New("no media session found")
+
+// WindowsMedia is a media session of Windows, the apps that show up in the
+// media controls of the taskbar, such as Spotify, browsers or the Media
+// Player app.
+type WindowsMedia struct {
+	// session is the PowerShell expression picking the session.
+	session string
+}
+
+// NewWindowsMedia reads the session Windows shows in its media controls.
+func NewWindowsMedia() WindowsMedia {
+	return WindowsMedia{session: windowsCurrentSession}
+}
+
+func (p WindowsMedia) run(control string) (windowsMediaState, error) {
+	script := fmt.Sprintf(windowsMediaScript, p.session, control)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return windowsMediaState{}, err
+	}
+	return parseWindowsMediaState(string(out))
+}
+
+func parseWindowsMediaState(out string) (windowsMediaState, error) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimRight(out, "\r\n"), "\r\n", "\n"), "\n")
+	if lines[0] == "unavailable" {
+		return windowsMediaState{}, errNoMediaSession
+	}
+	if len(lines) < 8 {
+		return windowsMediaState{}, fmt.Errorf("unexpected media session state %q", out)
+	}
+
+	state := windowsMediaState{
+		status: lines[0],
+		track: Track{
+			Artists: []string{strings.TrimSpace(lines[1])},
+			Album:   strings.TrimSpace(lines[2]),
+			Title:   strings.TrimSpace(lines[3]),
+		},
+		settings: Settings{Volume: -1, Shuffle: lines[6] == "True"},
+	}
+	if ms, err := strconv.ParseInt(lines[4], 10, 64); err == nil {
+		state.position = time.Duration(ms) * time.Millisecond
+	}
+	if ms, err := strconv.ParseInt(lines[5], 10, 64); err == nil {
+		state.duration = time.Duration(ms) * time.Millisecond
+	}
+	switch lines[7] {
+	case "List":
+		state.settings.Repeat = RepeatAll
+	case "Track":
+		state.settings.Repeat = RepeatTrack
+	}
+	return state, nil
+}
+
+func (p WindowsMedia) Track() (Track, Status) {
+	state, err := p.run("")
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	if state.status != "Playing" || state.track.empty() {
+		return Track{}, Idle
+	}
+	return state.track, Playing
+}
+
+func (p WindowsMedia) Control(action Action) {
+	if control, ok := windowsMediaControls[action]; ok {
+		p.run(control)
+	}
+}
+
+func (p WindowsMedia) Position() (time.Duration, error) {
+	state, err := p.run("")
+	return state.position, err
+}
+
+func (p WindowsMedia) Duration() (time.Duration, error) {
+	state, err := p.run("")
+	return state.duration, err
+}
+
+func (p WindowsMedia) Settings() (Settings, error) {
+	state, err := p.run("")
+	return state.settings, err
+}
+
+// windowsSpotify is the media session of the Spotify app.
+var windowsSpotify = WindowsMedia{session: windowsSpotifySession}
+
+// Spotify is the Spotify desktop app, read from its media session.
+type Spotify struct{}
+
+// Track falls back to the window title of Spotify when the media session can
+// not be read, it has no album.
+func (Spotify) Track() (Track, Status) {
+	track, status := windowsSpotify.Track()
+	if status != Unavailable {
+		return track, status
+	}
+
+	out, err := exec.Command("tasklist", "/v", "/fo", "csv", "/nh", "/fi", "imagename eq Spotify.exe").Output()
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	return parseSpotifyWindowTitle(string(out))
+}
+
+// parseSpotifyWindowTitle reads the track from the tasklist rows of
+// Spotify.exe, the window is titled "Artist - Track" while playing and
+// Spotify otherwise.
+func parseSpotifyWindowTitle(out string) (Track, Status) {
+	r := csv.NewReader(strings.NewReader(out))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return Track{}, Unavailable
+	}
+
+	status := Unavailable
+	for _, row := range rows {
+		// The window title is the last column, Spotify runs a few processes
+		// without a window.
+		if len(row) < 9 || !strings.EqualFold(row[0], "Spotify.exe") {
+			continue
+		}
+		status = Idle
+		if artist, title, ok := strings.Cut(row[len(row)-1], " - "); ok {
+			return Track{Artists: []string{strings.TrimSpace(artist)}, Title: strings.TrimSpace(title)}, Playing
+		}
+	}
+	return Track{}, status
+}
+
+func (Spotify) Control(action Action) {
+	windowsSpotify.Control(action)
+}
+
+func (Spotify) Position() (time.Duration, error) {
+	return windowsSpotify.Position()
+}
+
+func (Spotify) Duration() (time.Duration, error) {
+	return windowsSpotify.Duration()
+}
+
+func (Spotify) Settings() (Settings, error) {
+	return windowsSpotify.Settings()
+}
@@ -0,0 +1,49 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWindowsMediaState(t *testing.T) {
+	out := "Playing\r\nRadiohead\r\nOK Computer (Remastered)\r\nAirbag\r\n42500\r\n284000\r\nTrue\r\nList\r\n"
+	state, err := parseWindowsMediaState(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Track{Artists: []string{"Radiohead"}, Album: "OK Computer (Remastered)", Title: "Airbag"}); state.status != "Playing" || !reflect.DeepEqual(state.track, want) {
+		t.Errorf("got %+v", state)
+	}
+	if state.position != 42500*time.Millisecond || state.duration != 284*time.Second {
+		t.Errorf("got position %v and duration %v", state.position, state.duration)
+	}
+	if state.settings != (Settings{Volume: -1, Shuffle: true, Repeat: RepeatAll}) {
+		t.Errorf("got settings %+v", state.settings)
+	}
+
+	if _, err := parseWindowsMediaState("unavailable\r\n"); err != errNoMediaSession {
+		t.Errorf("got %v without a session", err)
+	}
+}
+
+func TestParseSpotifyWindowTitle(t *testing.T) {
+	tests := []struct {
+		out    string
+		track  Track
+		status Status
+	}{
+		{`"Spotify.exe","1234","Console","1","120,000 K","Running","PC\me","0:01:02","N/A"` + "\r\n" +
+			`"Spotify.exe","5678","Console","1","250,000 K","Running","PC\me","0:02:10","Radiohead - Airbag"`,
+			Track{Artists: []string{"Radiohead"}, Title: "Airbag"}, Playing},
+		{`"Spotify.exe","5678","Console","1","250,000 K","Running","PC\me","0:02:10","Spotify Premium"`, Track{}, Idle},
+		{"INFO: No tasks are running which match the specified criteria.", Track{}, Unavailable},
+	}
+
+	for _, tt := range tests {
+		track, status := parseSpotifyWindowTitle(tt.out)
+		if !reflect.DeepEqual(track, tt.track) || status != tt.status {
+			t.Errorf("parseSpotifyWindowTitle(%q) = %+v, %d, want %+v, %d", tt.out, track, status, tt.track, tt.status)
+		}
+	}
+}
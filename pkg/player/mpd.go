@@ -0,0 +1,253 @@
+package player
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MPD talks to an MPD server, a connection is opened for every call as MPD
+// closes idle ones.
+type MPD struct {
+	addr     string
+	password string
+}
+
+// NewMPD talks to the server at addr, host:port, with password when it is
+// not empty.
+func NewMPD(addr, password string) MPD {
+	return MPD{addr: addr, password: password}
+}
+
+const mpdTimeout = 3 * time.Second
+
+// mpdConn is a connection speaking the line based MPD protocol.
+type mpdConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (p MPD) dial() (*mpdConn, error) {
+	conn, err := net.DialTimeout("tcp", p.addr, mpdTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(mpdTimeout))
+
+	c := &mpdConn{conn: conn, reader: bufio.NewReader(conn)}
+	greeting, err := c.reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "OK MPD ") {
+		conn.Close()
+		return nil, fmt.Errorf("mpd: unexpected greeting %q", strings.TrimSpace(greeting))
+	}
+
+	if p.password != "" {
+		if _, err := c.command("password " + quoteMPD(p.password)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// command sends cmd and returns the key/value pairs of the response.
+func (c *mpdConn) command(cmd string) (map[string]string, error) {
+	if _, err := fmt.Fprintf(c.conn, "%s\n", cmd); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if line == "OK" {
+			return values, nil
+		}
+		if strings.HasPrefix(line, "ACK ") {
+			return nil, fmt.Errorf("mpd: %s", strings.TrimPrefix(line, "ACK "))
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			// Keep the first value of tags that are repeated, such as Artist.
+			if _, seen := values[key]; !seen {
+				values[key] = value
+			}
+		}
+	}
+}
+
+func (c *mpdConn) Close() error {
+	return c.conn.Close()
+}
+
+func quoteMPD(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (p MPD) Track() (Track, Status) {
+	c, err := p.dial()
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	defer c.Close()
+
+	status, err := c.command("status")
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	if status["state"] != "play" {
+		return Track{}, Idle
+	}
+
+	song, err := c.command("currentsong")
+	if err != nil {
+		return Track{}, Unavailable
+	}
+
+	artist := song["Artist"]
+	if strings.TrimSpace(artist) == "" {
+		artist = song["AlbumArtist"]
+	}
+	track := Track{Artists: []string{artist}, Album: song["Album"], Title: song["Title"]}
+	if track.empty() {
+		return Track{}, Idle
+	}
+	return track, Playing
+}
+
+func (p MPD) Control(action Action) {
+	c, err := p.dial()
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	switch action {
+	case PlayPause:
+		status, err := c.command("status")
+		if err != nil {
+			return
+		}
+		switch status["state"] {
+		case "play":
+			c.command("pause 1")
+		case "pause":
+			c.command("pause 0")
+		default:
+			c.command("play")
+		}
+	case Next:
+		c.command("next")
+	case Previous:
+		c.command("previous")
+	default:
+		status, err := c.command("status")
+		if err != nil {
+			return
+		}
+		settings := parseMPDSettings(status)
+		adjusted := settings.Adjusted(action)
+		switch action {
+		case VolumeUp, VolumeDown:
+			// Outputs without a mixer have no volume.
+			if settings.Volume >= 0 {
+				c.command(fmt.Sprintf("setvol %d", adjusted.Volume))
+			}
+		case Shuffle:
+			c.command("random " + mpdBool(adjusted.Shuffle))
+		case Repeat:
+			c.command("repeat " + mpdBool(adjusted.Repeat != RepeatOff))
+			c.command("single " + mpdBool(adjusted.Repeat == RepeatTrack))
+		}
+	}
+}
+
+func mpdBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// parseMPDSettings reads the settings from the status, MPD repeats a single
+// track with both repeat and single on.
+func parseMPDSettings(status map[string]string) Settings {
+	settings := Settings{Volume: -1, Shuffle: status["random"] == "1"}
+	if volume, err := strconv.Atoi(status["volume"]); err == nil {
+		settings.Volume = volume
+	}
+	switch {
+	case status["repeat"] != "1":
+		settings.Repeat = RepeatOff
+	case status["single"] == "1":
+		settings.Repeat = RepeatTrack
+	default:
+		settings.Repeat = RepeatAll
+	}
+	return settings
+}
+
+func (p MPD) Position() (time.Duration, error) {
+	c, err := p.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	status, err := c.command("status")
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(status["elapsed"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("mpd: elapsed: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (p MPD) Duration() (time.Duration, error) {
+	c, err := p.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	status, err := c.command("status")
+	if err != nil {
+		return 0, err
+	}
+	// Streams have no duration.
+	if status["duration"] == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseFloat(status["duration"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("mpd: duration: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (p MPD) Settings() (Settings, error) {
+	c, err := p.dial()
+	if err != nil {
+		return Settings{}, err
+	}
+	defer c.Close()
+
+	status, err := c.command("status")
+	if err != nil {
+		return Settings{}, err
+	}
+	return parseMPDSettings(status), nil
+}
@@ -0,0 +1,115 @@
+package player
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMPD answers the MPD commands from responses and records the commands it
+// received.
+func fakeMPD(t *testing.T, responses map[string]string) (string, chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	commands := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				fmt.Fprint(conn, "OK MPD 0.23.5\n")
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					cmd := scanner.Text()
+					commands <- cmd
+					response, ok := responses[cmd]
+					if !ok {
+						fmt.Fprintf(conn, "ACK [5@0] {%s} unknown command\n", cmd)
+						continue
+					}
+					fmt.Fprint(conn, response+"OK\n")
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), commands
+}
+
+func TestMPDTrack(t *testing.T) {
+	addr, commands := fakeMPD(t, map[string]string{
+		`password "se\"cret"`: "",
+		"status":              "volume: 50\nstate: play\nelapsed: 42.500\n",
+		"currentsong":         "file: radiohead/airbag.flac\nArtist: Radiohead\nArtist: Someone Else\nAlbum: OK Computer\nTitle: Airbag\n",
+		"pause 1":             "",
+	})
+	p := NewMPD(addr, `se"cret`)
+
+	track, status := p.Track()
+	if want := (Track{Artists: []string{"Radiohead"}, Album: "OK Computer", Title: "Airbag"}); status != Playing || !reflect.DeepEqual(track, want) {
+		t.Errorf("got %+v, %d", track, status)
+	}
+
+	pos, err := p.Position()
+	if err != nil || pos != 42500*time.Millisecond {
+		t.Errorf("got position %v, %v", pos, err)
+	}
+
+	p.Control(PlayPause)
+	var sent []string
+	for len(commands) > 0 {
+		sent = append(sent, <-commands)
+	}
+	if !strings.Contains(strings.Join(sent, "\n"), "pause 1") {
+		t.Errorf("play/pause did not pause: %q", sent)
+	}
+}
+
+func TestMPDSettings(t *testing.T) {
+	addr, commands := fakeMPD(t, map[string]string{
+		"status":     "volume: 95\nrepeat: 1\nrandom: 0\nsingle: 0\nstate: play\n",
+		"setvol 100": "",
+		"random 1":   "",
+		"repeat 1":   "",
+		"single 1":   "",
+	})
+	p := NewMPD(addr, "")
+
+	settings, err := p.Settings()
+	if err != nil || settings != (Settings{Volume: 95, Repeat: RepeatAll}) {
+		t.Errorf("got settings %+v, %v", settings, err)
+	}
+
+	for _, action := range []Action{VolumeUp, Shuffle, Repeat} {
+		p.Control(action)
+	}
+	var sent []string
+	for len(commands) > 0 {
+		if cmd := <-commands; cmd != "status" {
+			sent = append(sent, cmd)
+		}
+	}
+	if got := strings.Join(sent, ", "); got != "setvol 100, random 1, repeat 1, single 1" {
+		t.Errorf("sent %s", got)
+	}
+}
+
+func TestMPDUnavailable(t *testing.T) {
+	addr, _ := fakeMPD(t, map[string]string{})
+	if _, status := NewMPD(addr, "wrong").Track(); status != Unavailable {
+		t.Errorf("got status %d, want unavailable", status)
+	}
+}
@@ -0,0 +1,298 @@
+package player
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mprisPrefix    = "org.mpris.MediaPlayer2."
+	mprisPath      = "/org/mpris/MediaPlayer2"
+	mprisInterface = "org.mpris.MediaPlayer2.Player"
+	mprisSpotify   = mprisPrefix + "spotify"
+)
+
+var errNoMPRISPlayer = errors.New("no MPRIS player found")
+
+var sessionBus struct {
+	sync.Mutex
+	conn *dbus.Conn
+}
+
+// mprisBus returns the shared session bus connection.
+func mprisBus() (*dbus.Conn, error) {
+	sessionBus.Lock()
+	defer sessionBus.Unlock()
+
+	if sessionBus.conn == nil {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			return nil, err
+		}
+		sessionBus.conn = conn
+	}
+	return sessionBus.conn, nil
+}
+
+func mprisProperty(busName string, name string, v interface{}) error {
+	conn, err := mprisBus()
+	if err != nil {
+		return err
+	}
+
+	prop, err := conn.Object(busName, mprisPath).GetProperty(mprisInterface + "." + name)
+	if err != nil {
+		return err
+	}
+	return prop.Store(v)
+}
+
+// mprisPosition reads the playback position, MPRIS reports it in
+// microseconds.
+func mprisPosition(busName string) (time.Duration, error) {
+	var us int64
+	if err := mprisProperty(busName, "Position", &us); err != nil {
+		return 0, err
+	}
+	return time.Duration(us) * time.Microsecond, nil
+}
+
+// Spotify is the Spotify desktop app, read over MPRIS.
+type Spotify struct{}
+
+func (Spotify) Track() (Track, Status) {
+	var status string
+	if err := mprisProperty(mprisSpotify, "PlaybackStatus", &status); err != nil {
+		return Track{}, Unavailable
+	}
+	return mprisTrack(mprisSpotify, status)
+}
+
+func (Spotify) Control(action Action) {
+	mprisControl(mprisSpotify, action)
+}
+
+func (Spotify) Position() (time.Duration, error) {
+	return mprisPosition(mprisSpotify)
+}
+
+func (Spotify) Duration() (time.Duration, error) {
+	return mprisDuration(mprisSpotify)
+}
+
+func (Spotify) Settings() (Settings, error) {
+	return mprisSettings(mprisSpotify)
+}
+
+// mprisDuration reads the length of the track from the metadata, in
+// microseconds like the position.
+func mprisDuration(busName string) (time.Duration, error) {
+	var metadata map[string]dbus.Variant
+	if err := mprisProperty(busName, "Metadata", &metadata); err != nil {
+		return 0, err
+	}
+	// Players disagree on the integer type.
+	switch us := metadata["mpris:length"].Value().(type) {
+	case int64:
+		return time.Duration(us) * time.Microsecond, nil
+	case uint64:
+		return time.Duration(us) * time.Microsecond, nil
+	case int32:
+		return time.Duration(us) * time.Microsecond, nil
+	}
+	return 0, nil
+}
+
+// mprisLoopStatus are the MPRIS names of the repeat modes.
+var mprisLoopStatus = map[RepeatMode]string{RepeatOff: "None", RepeatAll: "Playlist", RepeatTrack: "Track"}
+
+// mprisSettings reads the volume, shuffle and loop status, the last two are
+// optional in MPRIS and left out by some players.
+func mprisSettings(busName string) (Settings, error) {
+	var volume float64
+	if err := mprisProperty(busName, "Volume", &volume); err != nil {
+		return Settings{}, err
+	}
+	settings := Settings{Volume: int(math.Round(volume * 100))}
+
+	mprisProperty(busName, "Shuffle", &settings.Shuffle)
+	var loop string
+	if mprisProperty(busName, "LoopStatus", &loop) == nil {
+		for mode, name := range mprisLoopStatus {
+			if name == loop {
+				settings.Repeat = mode
+			}
+		}
+	}
+	return settings, nil
+}
+
+// mprisAdjust sets the property changed by action, MPRIS has no toggles.
+func mprisAdjust(busName string, action Action) {
+	conn, err := mprisBus()
+	if err != nil {
+		return
+	}
+	settings, err := mprisSettings(busName)
+	if err != nil {
+		return
+	}
+
+	settings = settings.Adjusted(action)
+	property, value := "Volume", interface{}(float64(settings.Volume)/100)
+	switch action {
+	case Shuffle:
+		property, value = "Shuffle", settings.Shuffle
+	case Repeat:
+		property, value = "LoopStatus", mprisLoopStatus[settings.Repeat]
+	}
+	conn.Object(busName, mprisPath).SetProperty(mprisInterface+"."+property, dbus.MakeVariant(value))
+}
+
+// MPRIS follows whichever MPRIS player is active, it is looked up again on
+// every call as players come and go.
+type MPRIS struct{}
+
+// NewMPRIS connects to the session bus the MPRIS players are on.
+func NewMPRIS() (MPRIS, error) {
+	_, err := mprisBus()
+	return MPRIS{}, err
+}
+
+// active returns the bus name of the active player and its playback status.
+func (MPRIS) active() (string, string, error) {
+	conn, err := mprisBus()
+	if err != nil {
+		return "", "", err
+	}
+
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return "", "", err
+	}
+
+	statuses := map[string]string{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, mprisPrefix) {
+			continue
+		}
+		var status string
+		if err := mprisProperty(name, "PlaybackStatus", &status); err == nil {
+			statuses[name] = status
+		}
+	}
+
+	name := pickMPRISPlayer(statuses)
+	if name == "" {
+		return "", "", errNoMPRISPlayer
+	}
+	return name, statuses[name], nil
+}
+
+// pickMPRISPlayer prefers a playing player, then a paused one. Ties are
+// broken by name so the choice is stable between polls.
+func pickMPRISPlayer(statuses map[string]string) string {
+	best, bestRank := "", 0
+	for name, status := range statuses {
+		rank := map[string]int{"Playing": 3, "Paused": 2}[status]
+		if rank == 0 {
+			rank = 1
+		}
+		if rank > bestRank || (rank == bestRank && name < best) {
+			best, bestRank = name, rank
+		}
+	}
+	return best
+}
+
+func (p MPRIS) Track() (Track, Status) {
+	name, status, err := p.active()
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	return mprisTrack(name, status)
+}
+
+// mprisTrack reads the track of the player on busName, status is its
+// playback status.
+func mprisTrack(busName, status string) (Track, Status) {
+	if status != "Playing" {
+		return Track{}, Idle
+	}
+
+	var metadata map[string]dbus.Variant
+	if err := mprisProperty(busName, "Metadata", &metadata); err != nil {
+		return Track{}, Unavailable
+	}
+
+	track := Track{}
+	if album, ok := metadata["xesam:album"].Value().(string); ok {
+		track.Album = album
+	}
+	if title, ok := metadata["xesam:title"].Value().(string); ok {
+		track.Title = title
+	}
+	if artists, ok := metadata["xesam:artist"].Value().([]string); ok {
+		track.Artists = artists
+	}
+
+	if track.empty() {
+		return Track{}, Idle
+	}
+	return track, Playing
+}
+
+func (p MPRIS) Control(action Action) {
+	name, _, err := p.active()
+	if err != nil {
+		return
+	}
+	mprisControl(name, action)
+}
+
+func mprisControl(busName string, action Action) {
+	conn, err := mprisBus()
+	if err != nil {
+		return
+	}
+
+	method, ok := map[Action]string{
+		PlayPause: "PlayPause",
+		Next:      "Next",
+		Previous:  "Previous",
+	}[action]
+	if !ok {
+		mprisAdjust(busName, action)
+		return
+	}
+	conn.Object(busName, mprisPath).Call(mprisInterface+"."+method, 0)
+}
+
+func (p MPRIS) Position() (time.Duration, error) {
+	name, _, err := p.active()
+	if err != nil {
+		return 0, err
+	}
+	return mprisPosition(name)
+}
+
+func (p MPRIS) Duration() (time.Duration, error) {
+	name, _, err := p.active()
+	if err != nil {
+		return 0, err
+	}
+	return mprisDuration(name)
+}
+
+func (p MPRIS) Settings() (Settings, error) {
+	name, _, err := p.active()
+	if err != nil {
+		return Settings{}, err
+	}
+	return mprisSettings(name)
+}
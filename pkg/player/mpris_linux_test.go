@@ -0,0 +1,28 @@
+package player
+
+import "testing"
+
+func TestPickMPRISPlayer(t *testing.T) {
+	tests := []struct {
+		statuses map[string]string
+		want     string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"org.mpris.MediaPlayer2.vlc": "Stopped"}, "org.mpris.MediaPlayer2.vlc"},
+		{map[string]string{
+			"org.mpris.MediaPlayer2.vlc":       "Paused",
+			"org.mpris.MediaPlayer2.spotifyd":  "Playing",
+			"org.mpris.MediaPlayer2.rhythmbox": "Stopped",
+		}, "org.mpris.MediaPlayer2.spotifyd"},
+		{map[string]string{
+			"org.mpris.MediaPlayer2.vlc":     "Paused",
+			"org.mpris.MediaPlayer2.firefox": "Paused",
+		}, "org.mpris.MediaPlayer2.firefox"},
+	}
+
+	for _, tt := range tests {
+		if got := pickMPRISPlayer(tt.statuses); got != tt.want {
+			t.Errorf("pickMPRISPlayer(%v) = %q, want %q", tt.statuses, got, tt.want)
+		}
+	}
+}
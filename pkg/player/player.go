@@ -0,0 +1,70 @@
+// Package player reads the track playing in a music player and sends it the
+// playback controls: the Spotify app, Music.app on macOS, the MPRIS players
+// of Linux, the media sessions of Windows and MPD.
+//
+//	track, status := player.Spotify{}.Track()
+package player
+
+import (
+	"strings"
+	"time"
+)
+
+// Player is a source of the now playing track that can also be controlled.
+type Player interface {
+	Track() (Track, Status)
+	Control(action Action)
+	Position() (time.Duration, error)
+	// Duration is the length of the track playing.
+	Duration() (time.Duration, error)
+	// Settings are the volume, shuffle and repeat of the player.
+	Settings() (Settings, error)
+}
+
+// Track is the track as the player reports it, the album keeps the edition
+// tags such as "(Remastered)".
+type Track struct {
+	// Artists are the names credited by the player, the main artist first.
+	// Players that report a single name may credit several in it, like
+	// "A feat. B".
+	Artists []string
+	Album   string
+	Title   string
+}
+
+// empty reports whether the player sent neither an artist nor a title, some
+// do between tracks.
+func (t Track) empty() bool {
+	if strings.TrimSpace(t.Title) != "" {
+		return false
+	}
+	for _, artist := range t.Artists {
+		if strings.TrimSpace(artist) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Status describes what the player is doing.
+type Status int
+
+const (
+	Playing Status = iota
+	Idle
+	// Unavailable is a player that is not installed or not open.
+	Unavailable
+)
+
+// Action is a playback control sent to the player.
+type Action int
+
+const (
+	PlayPause Action = iota
+	Next
+	Previous
+	VolumeUp
+	VolumeDown
+	Shuffle
+	Repeat
+)
@@ -0,0 +1,99 @@
+package player
+
+import (
+	"testing"
+	"time"
+)
+
+type fakePlayer struct {
+	status  Status
+	actions []Action
+}
+
+func (p *fakePlayer) Track() (Track, Status) {
+	return Track{Artists: []string{"Radiohead"}, Album: "OK Computer", Title: "Airbag"}, p.status
+}
+func (p *fakePlayer) Control(action Action)            { p.actions = append(p.actions, action) }
+func (p *fakePlayer) Position() (time.Duration, error) { return 0, nil }
+func (p *fakePlayer) Duration() (time.Duration, error) { return 0, nil }
+func (p *fakePlayer) Settings() (Settings, error)      { return Settings{}, nil }
+
+func TestAuto(t *testing.T) {
+	spotify := &fakePlayer{status: Idle}
+	mpd := &fakePlayer{status: Playing}
+	p := NewAuto([]Source{{Name: "Spotify", Player: spotify}, {Name: "MPD", Player: mpd}})
+
+	if _, status := p.Track(); status != Playing || p.Source() != "MPD" {
+		t.Errorf("got status %d from %s, want playing from MPD", status, p.Source())
+	}
+	p.Control(Next)
+
+	// MPD stays active while paused and nothing else plays.
+	mpd.status = Idle
+	if _, status := p.Track(); status != Idle || p.Source() != "MPD" {
+		t.Errorf("got status %d from %s, want idle from MPD", status, p.Source())
+	}
+	spotify.status = Playing
+	if _, status := p.Track(); status != Playing || p.Source() != "Spotify" {
+		t.Errorf("got status %d from %s, want playing from Spotify", status, p.Source())
+	}
+	p.Control(PlayPause)
+	if len(mpd.actions) != 1 || len(spotify.actions) != 1 {
+		t.Errorf("controls went to Spotify %v and MPD %v", spotify.actions, mpd.actions)
+	}
+
+	// A source picked by hand is kept while it is open.
+	if name := p.Cycle(); name != "MPD" {
+		t.Errorf("cycled to %s", name)
+	}
+	if _, status := p.Track(); status != Idle || p.Source() != "MPD" {
+		t.Errorf("got status %d from %s, want the picked MPD", status, p.Source())
+	}
+	mpd.status = Unavailable
+	if _, status := p.Track(); status != Playing || p.Source() != "Spotify" {
+		t.Errorf("got status %d from %s once MPD closed", status, p.Source())
+	}
+}
+
+func TestFallback(t *testing.T) {
+	primary := &fakePlayer{status: Unavailable}
+	fallback := &fakePlayer{status: Playing}
+	p := NewFallback(primary, fallback)
+
+	if _, status := p.Track(); status != Playing {
+		t.Errorf("got status %d, want playing from the fallback", status)
+	}
+	p.Control(Next)
+
+	primary.status = Idle
+	if _, status := p.Track(); status != Idle {
+		t.Errorf("got status %d, want idle from the primary", status)
+	}
+	p.Control(PlayPause)
+
+	if len(fallback.actions) != 1 || len(primary.actions) != 1 {
+		t.Errorf("controls went to primary %v and fallback %v", primary.actions, fallback.actions)
+	}
+}
+
+func TestSettingsAdjusted(t *testing.T) {
+	s := Settings{Volume: 95}
+	if got := s.Adjusted(VolumeUp).Volume; got != 100 {
+		t.Errorf("volume up from 95 = %d", got)
+	}
+	if got := (Settings{Volume: 5}).Adjusted(VolumeDown).Volume; got != 0 {
+		t.Errorf("volume down from 5 = %d", got)
+	}
+	if !s.Adjusted(Shuffle).Shuffle || s.Adjusted(Shuffle).Adjusted(Shuffle).Shuffle {
+		t.Error("shuffle does not toggle")
+	}
+
+	var repeats []RepeatMode
+	for i := 0; i < 3; i++ {
+		s = s.Adjusted(Repeat)
+		repeats = append(repeats, s.Repeat)
+	}
+	if repeats[0] != RepeatAll || repeats[1] != RepeatTrack || repeats[2] != RepeatOff {
+		t.Errorf("repeat cycles through %v", repeats)
+	}
+}
@@ -0,0 +1,49 @@
+package player
+
+// VolumeStep is how much VolumeUp and VolumeDown change the volume, in
+// percent.
+const VolumeStep = 10
+
+// RepeatMode is what the player repeats once the track ends.
+type RepeatMode int
+
+const (
+	RepeatOff RepeatMode = iota
+	// RepeatAll repeats the playlist or album.
+	RepeatAll
+	RepeatTrack
+)
+
+// Settings are the volume, shuffle and repeat of the player.
+type Settings struct {
+	// Volume is a percentage, -1 when the player does not report it.
+	Volume  int
+	Shuffle bool
+	Repeat  RepeatMode
+}
+
+// Adjusted returns the settings once action is done, players without a
+// toggle of their own set the result.
+func (s Settings) Adjusted(action Action) Settings {
+	switch action {
+	case VolumeUp:
+		s.Volume = clampVolume(s.Volume + VolumeStep)
+	case VolumeDown:
+		s.Volume = clampVolume(s.Volume - VolumeStep)
+	case Shuffle:
+		s.Shuffle = !s.Shuffle
+	case Repeat:
+		s.Repeat = (s.Repeat + 1) % (RepeatTrack + 1)
+	}
+	return s
+}
+
+func clampVolume(volume int) int {
+	switch {
+	case volume < 0:
+		return 0
+	case volume > 100:
+		return 100
+	}
+	return volume
+}
@@ -0,0 +1,56 @@
+package player
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Spotify is the Spotify desktop app, read through AppleScript.
+type Spotify struct{}
+
+func (Spotify) Track() (Track, Status) {
+	out, err := runOsascript(fmt.Sprintf(appleScriptTrackScript, "Spotify"))
+	if err != nil {
+		return Track{}, Unavailable
+	}
+	return parseAppleMusicTrack(out)
+}
+
+func (Spotify) Control(action Action) {
+	command := map[Action]string{
+		PlayPause:  "playpause",
+		Next:       "next track",
+		Previous:   "previous track",
+		VolumeUp:   "set sound volume to sound volume + " + strconv.Itoa(VolumeStep),
+		VolumeDown: "set sound volume to sound volume - " + strconv.Itoa(VolumeStep),
+		Shuffle:    "set shuffling to not shuffling",
+		Repeat:     "set repeating to not repeating",
+	}[action]
+	runOsascript(fmt.Sprintf(appleScriptControlScript, "Spotify", command))
+}
+
+func (Spotify) Position() (time.Duration, error) {
+	return appleMusicSeconds(`tell application "Spotify" to get player position`)
+}
+
+// Duration reads the length of the track playing, AppleScript reports it in
+// milliseconds.
+func (Spotify) Duration() (time.Duration, error) {
+	ms, err := appleMusicSeconds(`tell application "Spotify" to get duration of current track`)
+	return ms / 1000, err
+}
+
+// spotifySettingsScript answers with the volume, shuffling and repeating,
+// the Spotify app can only repeat the whole context through AppleScript.
+const spotifySettingsScript = `
+if application "Spotify" is not running then return "unavailable"
+tell application "Spotify" to return (sound volume as text) & linefeed & shuffling & linefeed & repeating`
+
+func (Spotify) Settings() (Settings, error) {
+	out, err := runOsascript(spotifySettingsScript)
+	if err != nil {
+		return Settings{}, err
+	}
+	return parseAppleScriptSettings(out)
+}
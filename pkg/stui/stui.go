@@ -0,0 +1,164 @@
+// Package stui asks a chat model about a track the way stui does, without
+// the TUI: the prompts of the built-in sections and the pipeline that fetches
+// them, for bots and other Go programs.
+//
+//	sections, err := stui.FetchAlbumInfo(ctx, provider, stui.Track{Artist: "Radiohead", Album: "OK Computer"}, stui.Options{})
+package stui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is how many sections are asked at once when the options
+// leave it unset.
+const DefaultConcurrency = 3
+
+// Track is what the sections are about. Without a Track only the album
+// sections are asked, Featured are the other artists of a collaboration.
+type Track struct {
+	Artist   string
+	Featured []string
+	Album    string
+	Track    string
+}
+
+// ArtistLine is the artists as stui shows them, "A feat. B, C".
+func (t Track) ArtistLine() string {
+	if len(t.Featured) == 0 {
+		return t.Artist
+	}
+	return t.Artist + " feat. " + strings.Join(t.Featured, ", ")
+}
+
+// Provider sends a single prompt to a chat model and returns the answer. The
+// clients of pkg/ai and any other with this method work.
+type Provider interface {
+	Complete(ctx context.Context, model string, prompt string) (string, error)
+}
+
+// Section is one write-up about the track. Err is why it could not be
+// fetched, the other sections are still filled then.
+type Section struct {
+	Key     string
+	Title   string
+	Prompt  string
+	Content string
+	Err     error
+}
+
+// Options tune FetchAlbumInfo. The zero value asks the default model of the
+// provider in English.
+type Options struct {
+	// Model is passed to the provider, empty for its default.
+	Model string
+	// Language the answers are written in, such as "Spanish".
+	Language string
+	// Compare adds a comparison of the album with this one.
+	Compare Track
+	// Concurrency is how many sections are asked at once.
+	Concurrency int
+}
+
+// Prompts returns the built-in AI sections of t in the order stui shows them,
+// with their prompts and without content. The comparison is added when
+// compare has an album other than the one of t.
+func Prompts(t Track, compare Track) []Section {
+	sections := []Section{
+		{
+			Key:    "album",
+			Title:  "Album info",
+			Prompt: fmt.Sprintf("Give me album info of %s %s, leave out the tracklist and credits", t.Artist, t.Album),
+		},
+		{
+			Key:    "review",
+			Title:  "Album review",
+			Prompt: fmt.Sprintf("Give me album review of %s %s", t.Artist, t.Album),
+		},
+	}
+	if compare.Album != "" && !(strings.EqualFold(compare.Artist, t.Artist) && strings.EqualFold(compare.Album, t.Album)) {
+		sections = append(sections, Section{
+			Key:    "comparison",
+			Title:  "Comparison",
+			Prompt: ComparisonPrompt(t, compare),
+		})
+	}
+	if t.Track != "" {
+		sections = append(sections,
+			Section{
+				Key:    "song",
+				Title:  "Song info",
+				Prompt: fmt.Sprintf("Give me song info of %s %s", t.ArtistLine(), t.Track),
+			},
+			Section{
+				Key:    "bio",
+				Title:  "Artist bio",
+				Prompt: fmt.Sprintf("Give me a biography of %s", t.Artist),
+			},
+		)
+	}
+	return sections
+}
+
+// ComparisonPrompt asks for the albums side by side, a table with a column
+// per album and a recommendation.
+func ComparisonPrompt(t Track, other Track) string {
+	return fmt.Sprintf("Compare the album %s by %s with the album %s by %s side by side. "+
+		"Answer with a markdown table with the columns Aspect, %s and %s, and rows for style, production, reception and highlights, "+
+		"then a short paragraph on which one to listen to first",
+		t.Album, t.Artist, other.Album, other.Artist, t.Album, other.Album)
+}
+
+// InLanguage asks for the answer to prompt in language, English when it is
+// empty.
+func InLanguage(prompt, language string) string {
+	if language == "" {
+		return prompt
+	}
+	return prompt + "\n\nWrite the answer in " + language + "."
+}
+
+// FetchAlbumInfo asks p for the built-in sections of t at the same time. The
+// sections come back in order, the error joins the ones of the sections that
+// failed.
+func FetchAlbumInfo(ctx context.Context, p Provider, t Track, o Options) ([]Section, error) {
+	sections := Prompts(t, o.Compare)
+	concurrency := o.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range sections {
+		s := &sections[i]
+		s.Prompt = InLanguage(s.Prompt, o.Language)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				s.Err = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+			if s.Err = ctx.Err(); s.Err != nil {
+				return
+			}
+			s.Content, s.Err = p.Complete(ctx, o.Model, s.Prompt)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, s := range sections {
+		if s.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.ToLower(s.Title), s.Err))
+		}
+	}
+	return sections, errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package stui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProvider answers with the prompt and fails the reviews, it records
+// how many prompts were asked at once.
+type fakeProvider struct {
+	mu      sync.Mutex
+	running int
+	most    int
+	block   chan struct{}
+}
+
+func (p *fakeProvider) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	p.mu.Lock()
+	p.running++
+	if p.running > p.most {
+		p.most = p.running
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.running--
+		p.mu.Unlock()
+	}()
+	if p.block != nil {
+		select {
+		case <-p.block:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	if strings.Contains(prompt, "album review") {
+		return "", errors.New("rate limited")
+	}
+	return model + ": " + prompt, nil
+}
+
+func TestPrompts(t *testing.T) {
+	track := Track{Artist: "Daft Punk", Featured: []string{"Pharrell Williams"}, Album: "Random Access Memories", Track: "Get Lucky"}
+
+	var keys []string
+	for _, s := range Prompts(track, Track{Artist: "Air", Album: "Moon Safari"}) {
+		keys = append(keys, s.Key)
+	}
+	if got := strings.Join(keys, ","); got != "album,review,comparison,song,bio" {
+		t.Errorf("got sections %s", got)
+	}
+
+	sections := Prompts(Track{Artist: "Radiohead", Album: "OK Computer"}, Track{Artist: "radiohead", Album: "ok computer"})
+	if len(sections) != 2 {
+		t.Errorf("an album without a track compared with itself got %d sections", len(sections))
+	}
+	if got := Prompts(track, Track{})[2].Prompt; got != "Give me song info of Daft Punk feat. Pharrell Williams Get Lucky" {
+		t.Errorf("got song prompt %q", got)
+	}
+}
+
+func TestFetchAlbumInfo(t *testing.T) {
+	p := &fakeProvider{block: make(chan struct{})}
+	track := Track{Artist: "Radiohead", Album: "OK Computer", Track: "Airbag"}
+
+	done := make(chan struct{})
+	var sections []Section
+	var err error
+	go func() {
+		defer close(done)
+		sections, err = FetchAlbumInfo(context.Background(), p, track, Options{Model: "llama3", Language: "Spanish", Concurrency: 2})
+	}()
+	close(p.block)
+	<-done
+
+	if p.most > 2 {
+		t.Errorf("%d prompts were asked at once", p.most)
+	}
+	if len(sections) != 4 {
+		t.Fatalf("got %d sections", len(sections))
+	}
+	if got := sections[0].Content; got != "llama3: Give me album info of Radiohead OK Computer, leave out the tracklist and credits\n\nWrite the answer in Spanish." {
+		t.Errorf("got album info %q", got)
+	}
+	if sections[1].Err == nil || sections[1].Content != "" || sections[3].Err != nil {
+		t.Errorf("got review %+v and bio %+v", sections[1], sections[3])
+	}
+	if err == nil || err.Error() != "album review: rate limited" {
+		t.Errorf("got error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	blocked := &fakeProvider{block: make(chan struct{})}
+	defer close(blocked.block)
+	if _, err := FetchAlbumInfo(ctx, blocked, track, Options{Concurrency: 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("a canceled fetch got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// getTrackDuration is replaced in tests.
+var getTrackDuration = spotifyDuration
+
+// playbackInterval is how often the playback position is read once loading
+// is done.
+const playbackInterval = time.Second
+
+type playbackMsg struct {
+	seq      int
+	position time.Duration
+	duration time.Duration
+	err      error
+}
+
+// playbackCmd reads the playback position after playbackInterval. The
+// duration does not change during a track, it is only read while unknown.
+func playbackCmd(seq int, duration time.Duration) tea.Cmd {
+	readDuration, readPosition := getTrackDuration, getPlaybackPosition
+	return tea.Tick(playbackInterval, func(time.Time) tea.Msg {
+		msg := playbackMsg{seq: seq, duration: duration}
+		if duration == 0 {
+			if msg.duration, msg.err = readDuration(); msg.err != nil {
+				return msg
+			}
+		}
+		msg.position, msg.err = readPosition()
+		return msg
+	})
+}
+
+// startPlayback follows the playback of the track once loading is done, the
+// seq stops the polls of the previous track.
+func (m *model) startPlayback() tea.Cmd {
+	if m.deepDive || m.query {
+		return nil
+	}
+	m.playbackSeq++
+	return playbackCmd(m.playbackSeq, 0)
+}
+
+func (m *model) playbackUpdated(msg playbackMsg) tea.Cmd {
+	if msg.seq != m.playbackSeq {
+		return nil
+	}
+	// The bar is hidden while the player does not answer.
+	if msg.err != nil {
+		m.playbackPosition, m.playbackDuration = 0, 0
+		return playbackCmd(msg.seq, 0)
+	}
+	m.playbackPosition, m.playbackDuration = msg.position, msg.duration
+	return tea.Batch(playbackCmd(msg.seq, msg.duration), m.prefetchNextCmd())
+}
+
+// playbackView is the progress bar of the track playing, empty when the
+// duration is unknown.
+func (m *model) playbackView() string {
+	if m.playbackDuration <= 0 {
+		return ""
+	}
+	percent := float64(m.playbackPosition) / float64(m.playbackDuration)
+	if percent > 1 {
+		percent = 1
+	}
+	return m.progress.ViewAs(percent) + helpStyle(" "+formatPlaybackTime(m.playbackDuration))
+}
+
+// playbackTimeView is the elapsed and remaining time of the track in the
+// header.
+func (m *model) playbackTimeView() string {
+	if m.playbackDuration <= 0 {
+		return ""
+	}
+	remaining := m.playbackDuration - m.playbackPosition
+	if remaining < 0 {
+		remaining = 0
+	}
+	return helpStyle(fmt.Sprintf("  %s / -%s", formatPlaybackTime(m.playbackPosition), formatPlaybackTime(remaining)))
+}
+
+// formatPlaybackTime formats d as minutes and seconds, e.g. 4:05.
+func formatPlaybackTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFormatPlaybackTime(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                             "0:00",
+		4*time.Minute + 5*time.Second: "4:05",
+		83500 * time.Millisecond:      "1:23",
+		time.Hour + 2*time.Minute:     "1:02:00",
+	} {
+		if got := formatPlaybackTime(d); got != want {
+			t.Errorf("formatPlaybackTime(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestPlaybackBar(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	getTrackDuration = func() (time.Duration, error) {
+		return 4*time.Minute + 5*time.Second, nil
+	}
+	getPlaybackPosition = func() (time.Duration, error) {
+		return 83 * time.Second, nil
+	}
+
+	m.getInfo(context.Background())
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	_, cmd := m.Update(tickMsg{})
+	if m.loading || cmd == nil {
+		t.Fatal("loading did not end")
+	}
+	if strings.Contains(m.View(), "-2:42") {
+		t.Fatal("the playback is shown before the player answered")
+	}
+
+	_, cmd = m.Update(playbackCmd(m.playbackSeq, 0)())
+	view := m.View()
+	for _, want := range []string{"1:23 / -2:42", " 4:05"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not include %q:\n%s", want, view)
+		}
+	}
+	if rows := strings.Count(view, "\n") + 1; rows != 40 {
+		t.Errorf("view with the playback bar has %d rows", rows)
+	}
+
+	// The next poll keeps the duration already read.
+	getTrackDuration = func() (time.Duration, error) { return 0, errors.New("no track") }
+	m.Update(playbackCmd(m.playbackSeq, m.playbackDuration)())
+	if m.playbackDuration != 4*time.Minute+5*time.Second {
+		t.Errorf("got duration %v", m.playbackDuration)
+	}
+
+	// Polls of the previous track are dropped.
+	seq := m.playbackSeq
+	m.reset(MusicInfo{artist: "Portishead", album: "Dummy", track: "Roads"})
+	if _, cmd := m.Update(playbackMsg{seq: seq, position: time.Second, duration: time.Minute}); cmd != nil || m.playbackDuration != 0 {
+		t.Error("an old poll updated the playback")
+	}
+}
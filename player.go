@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+// playerFactory describes a player that can be selected with -player.
+type playerFactory struct {
+	// name is shown in the status messages.
+	name string
+	new  func() (player.Player, error)
+}
+
+var players = map[string]playerFactory{}
+
+// registerPlayer makes a player available to -player, players that only
+// work on some systems register from a file with a build constraint.
+func registerPlayer(key string, f playerFactory) {
+	if _, ok := players[key]; ok {
+		panic("player registered twice: " + key)
+	}
+	players[key] = f
+}
+
+func playerKeys() []string {
+	keys := make([]string, 0, len(players))
+	for key := range players {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// playerKey is the player selected with -player, playerName is its name.
+var (
+	playerKey  = "spotify"
+	playerName = "Spotify"
+)
+
+// sourcePlayer is a player reading several sources, like the auto player.
+type sourcePlayer interface {
+	player.Player
+	// Source is the name of the source the track is read from.
+	Source() string
+	// Cycle switches to the next source and returns its name.
+	Cycle() string
+}
+
+// currentSource names the player the track is read from in the status bar,
+// cycleSource switches to the next source and is nil for a single player.
+var (
+	currentSource = func() string { return playerName }
+	cycleSource   func() string
+)
+
+// usePlayer reads tracks from the player registered as key and sends the
+// playback controls to it.
+func usePlayer(key string) error {
+	f, ok := players[key]
+	if !ok {
+		return fmt.Errorf("unknown player %q, use %s", key, strings.Join(playerKeys(), ", "))
+	}
+
+	p, err := f.new()
+	if err != nil {
+		return err
+	}
+
+	playerName = f.name
+	currentSource = func() string { return playerName }
+	cycleSource = nil
+	if s, ok := p.(sourcePlayer); ok {
+		currentSource = s.Source
+		cycleSource = s.Cycle
+	}
+	getTrackInfo = func() (MusicInfo, PlayerStatus) { return readTrack(p.Track()) }
+	controlPlayer = p.Control
+	getPlaybackPosition = p.Position
+	getTrackDuration = p.Duration
+	getPlayerSettings = p.Settings
+	return nil
+}
+
+func init() {
+	registerPlayer("spotify", playerFactory{
+		name: "Spotify",
+		new: func() (player.Player, error) {
+			// The Web API covers playback on other devices when the desktop
+			// app is not running.
+			if spotifyClientID != "" {
+				return player.NewFallback(player.Spotify{}, newSpotifyWebPlayer(spotifyClientID)), nil
+			}
+			return player.Spotify{}, nil
+		},
+	})
+}
+
+// readTrack is the MusicInfo of the track read from a player, the album is
+// cleaned of the albumNoise tags.
+func readTrack(track player.Track, status PlayerStatus) (MusicInfo, PlayerStatus) {
+	if status != PlayerPlaying {
+		return MusicInfo{}, status
+	}
+	info := MusicInfo{album: cleanAlbumName(track.Album, albumNoise), track: strings.TrimSpace(track.Title)}
+	info.setArtists(track.Artists...)
+	return info, status
+}
+
+// The Spotify desktop app is read until -player picks another player.
+func getSpotifyTrackInfo() (MusicInfo, PlayerStatus) { return readTrack(player.Spotify{}.Track()) }
+func controlSpotify(action player.Action)            { player.Spotify{}.Control(action) }
+func spotifyPosition() (time.Duration, error)        { return player.Spotify{}.Position() }
+func spotifyDuration() (time.Duration, error)        { return player.Spotify{}.Duration() }
+func spotifySettings() (player.Settings, error)      { return player.Spotify{}.Settings() }
+
+// controlPlayer is replaced in tests.
+var controlPlayer = controlSpotify
+
+// playerDelay gives the player time to change track before it is read.
+var playerDelay = time.Second
+
+// playerMsg is the track playing after a playback control.
+type playerMsg struct {
+	info   MusicInfo
+	status PlayerStatus
+}
+
+// playerCmd sends action to the player and reads the track afterwards, so the
+// info follows a skip, or playback starting, without waiting for watch mode.
+func playerCmd(action player.Action) tea.Cmd {
+	return func() tea.Msg {
+		controlPlayer(action)
+
+		time.Sleep(playerDelay)
+		info, status := getTrackInfo()
+		return playerMsg{info: info, status: status}
+	}
+}
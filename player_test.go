@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+func TestReadTrack(t *testing.T) {
+	tests := []struct {
+		track  player.Track
+		status PlayerStatus
+		want   MusicInfo
+	}{
+		{player.Track{Artists: []string{"Radiohead"}, Album: "OK Computer (Remastered)", Title: "Airbag"}, PlayerPlaying, testTrack},
+		{player.Track{Artists: []string{"Daft Punk feat. Pharrell Williams"}, Album: "Random Access Memories", Title: "Get Lucky"}, PlayerPlaying,
+			MusicInfo{artist: "Daft Punk", featured: "Pharrell Williams", album: "Random Access Memories", track: "Get Lucky"}},
+		{player.Track{Artists: []string{"Radiohead"}, Title: "Airbag"}, PlayerIdle, MusicInfo{}},
+	}
+
+	for _, tt := range tests {
+		info, status := readTrack(tt.track, tt.status)
+		if info != tt.want || status != tt.status {
+			t.Errorf("readTrack(%+v, %d) = %+v, %d, want %+v", tt.track, tt.status, info, status, tt.want)
+		}
+	}
+}
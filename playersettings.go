@@ -0,0 +1,79 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+// getPlayerSettings is replaced in tests.
+var getPlayerSettings = spotifySettings
+
+type settingsMsg struct {
+	settings player.Settings
+	err      error
+}
+
+// settingsCmd reads the settings of the player.
+func settingsCmd() tea.Cmd {
+	read := getPlayerSettings
+	return func() tea.Msg {
+		settings, err := read()
+		return settingsMsg{settings: settings, err: err}
+	}
+}
+
+// adjustCmd sends a volume, shuffle or repeat control to the player and
+// reads the settings back, the player may take a moment to apply it.
+func adjustCmd(action player.Action) tea.Cmd {
+	control, read := controlPlayer, getPlayerSettings
+	return func() tea.Msg {
+		control(action)
+
+		time.Sleep(playerDelay)
+		settings, err := read()
+		return settingsMsg{settings: settings, err: err}
+	}
+}
+
+// adjustPlayer shows the expected settings right away, the ones read back
+// from the player replace them.
+func (m *model) adjustPlayer(action player.Action) tea.Cmd {
+	if m.settings != nil {
+		settings := m.settings.Adjusted(action)
+		if m.settings.Volume < 0 {
+			settings.Volume = -1
+		}
+		m.settings = &settings
+	}
+	return adjustCmd(action)
+}
+
+func (m *model) settingsUpdated(msg settingsMsg) {
+	if msg.err != nil {
+		m.settings = nil
+		return
+	}
+	m.settings = &msg.settings
+}
+
+// settingsView lists the settings in the help, nothing is shown until the
+// player answered.
+func (m *model) settingsView() []string {
+	s := m.settings
+	if s == nil {
+		return nil
+	}
+
+	var items []string
+	if s.Volume >= 0 {
+		items = append(items, trf("volume %d%%", s.Volume))
+	}
+	shuffle := tr("shuffle off")
+	if s.Shuffle {
+		shuffle = tr("shuffle on")
+	}
+	repeat := map[player.RepeatMode]string{player.RepeatOff: "repeat off", player.RepeatAll: "repeat all", player.RepeatTrack: "repeat track"}[s.Repeat]
+	return append(items, shuffle, tr(repeat))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+func TestVolumeKeys(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(d time.Duration) { playerDelay = d }(playerDelay)
+	playerDelay = 0
+
+	settings := player.Settings{Volume: 50}
+	var actions []player.Action
+	controlPlayer = func(action player.Action) {
+		actions = append(actions, action)
+		settings = settings.Adjusted(action)
+	}
+	getPlayerSettings = func() (player.Settings, error) { return settings, nil }
+
+	m.loading = false
+	m.Update(settingsCmd()())
+	if bar := m.statusBarView(); !strings.Contains(bar, "volume 50%") || !strings.Contains(bar, "shuffle off") {
+		t.Fatalf("the status bar does not show the settings:\n%s", bar)
+	}
+
+	// The status bar changes before the player answers.
+	_, cmd := m.Update(keyRune('+'))
+	if m.settings.Volume != 60 || len(actions) != 0 {
+		t.Errorf("got volume %d before the player answered", m.settings.Volume)
+	}
+	m.Update(cmd())
+	for _, r := range "zxx-" {
+		_, cmd := m.Update(keyRune(r))
+		m.Update(cmd())
+	}
+
+	want := []player.Action{player.VolumeUp, player.Shuffle, player.Repeat, player.Repeat, player.VolumeDown}
+	if len(actions) != len(want) {
+		t.Fatalf("got actions %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("got actions %v, want %v", actions, want)
+		}
+	}
+	bar := m.statusBarView()
+	for _, s := range []string{"volume 50%", "shuffle on", "repeat track"} {
+		if !strings.Contains(bar, s) {
+			t.Errorf("the status bar does not include %q:\n%s", s, bar)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// playlistTracksPerArtist is how many top tracks of every similar artist go
+// in the playlist.
+const playlistTracksPerArtist = 3
+
+var errNoPlaylistTracks = errors.New("none of the artists is on Spotify")
+
+// savePlaylist is replaced in tests.
+var savePlaylist = saveSpotifyPlaylist
+
+type playlistMsg struct {
+	name   string
+	tracks int
+	err    error
+}
+
+// send encodes body as JSON and decodes the answer into v unless it is nil.
+func (p *spotifyWebPlayer) send(ctx context.Context, method string, path string, body any, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := p.request(ctx, method, path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// playlistName is the playlist of the artists similar to artist, saving it
+// again replaces its tracks.
+func playlistName(artist string) string {
+	return "stui: like " + artist
+}
+
+// saveSpotifyPlaylist resolves the top tracks of the artists on Spotify and
+// saves them to the playlist name of the account, it is created the first
+// time. It returns how many tracks the playlist has.
+func saveSpotifyPlaylist(ctx context.Context, name string, artists []string) (int, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+
+	var uris []string
+	for _, artist := range artists {
+		tracks, err := spotifyTopTracks(ctx, api, artist)
+		if err != nil {
+			return 0, err
+		}
+		uris = append(uris, tracks...)
+	}
+	if len(uris) == 0 {
+		return 0, errNoPlaylistTracks
+	}
+
+	id, err := findSpotifyPlaylist(ctx, api, name)
+	if err != nil {
+		return 0, err
+	}
+	if id == "" {
+		var me struct {
+			ID string `json:"id"`
+		}
+		if err := api.get(ctx, "/me", &me); err != nil {
+			return 0, err
+		}
+		var created struct {
+			ID string `json:"id"`
+		}
+		body := map[string]any{"name": name, "public": false, "description": "Artists you might like, made by stui"}
+		if err := api.send(ctx, http.MethodPost, "/users/"+url.PathEscape(me.ID)+"/playlists", body, &created); err != nil {
+			return 0, err
+		}
+		id = created.ID
+	}
+
+	// PUT replaces the tracks of the playlist, up to 100 of them.
+	if len(uris) > 100 {
+		uris = uris[:100]
+	}
+	if err := api.send(ctx, http.MethodPut, "/playlists/"+id+"/tracks", map[string]any{"uris": uris}, nil); err != nil {
+		return 0, err
+	}
+	return len(uris), nil
+}
+
+// spotifyTopTracks returns the uris of the top tracks of the artist, none
+// when it is not on Spotify.
+func spotifyTopTracks(ctx context.Context, api *spotifyWebPlayer, artist string) ([]string, error) {
+	var search struct {
+		Artists struct {
+			Items []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"items"`
+		} `json:"artists"`
+	}
+	if err := api.get(ctx, "/search?type=artist&limit=1&q="+url.QueryEscape("artist:"+artist), &search); err != nil {
+		return nil, err
+	}
+	// The search returns something for any name, keep only the artist.
+	if len(search.Artists.Items) == 0 || !strings.EqualFold(search.Artists.Items[0].Name, artist) {
+		return nil, nil
+	}
+
+	var top struct {
+		Tracks []struct {
+			URI string `json:"uri"`
+		} `json:"tracks"`
+	}
+	if err := api.get(ctx, "/artists/"+search.Artists.Items[0].ID+"/top-tracks?market=from_token", &top); err != nil {
+		return nil, err
+	}
+	var uris []string
+	for i := 0; i < len(top.Tracks) && i < playlistTracksPerArtist; i++ {
+		uris = append(uris, top.Tracks[i].URI)
+	}
+	return uris, nil
+}
+
+// findSpotifyPlaylist returns the id of the playlist of the account called
+// name, empty when there is none.
+func findSpotifyPlaylist(ctx context.Context, api *spotifyWebPlayer, name string) (string, error) {
+	path := "/me/playlists?limit=50"
+	for path != "" {
+		var page struct {
+			Items []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"items"`
+			Next string `json:"next"`
+		}
+		if err := api.get(ctx, path, &page); err != nil {
+			return "", err
+		}
+		for _, p := range page.Items {
+			if p.Name == name {
+				return p.ID, nil
+			}
+		}
+		// next is an absolute URL.
+		path = strings.TrimPrefix(page.Next, spotifyAPIURL)
+	}
+	return "", nil
+}
+
+// saveArtistsPlaylist saves the artists of the picker to a Spotify playlist,
+// the result comes back as a playlistMsg.
+func (m *model) saveArtistsPlaylist(artists []string) tea.Cmd {
+	m.artistPicker = nil
+	if spotifyClientID == "" || !spotifyLoggedIn() {
+		m.errMsg = "  spotify: the playlist needs spotify_client_id in the config file and stui -spotify-login"
+		return nil
+	}
+
+	name := playlistName(m.artist)
+	m.notice = "  " + trf("Saving the playlist %s", name)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		tracks, err := savePlaylist(ctx, name, artists)
+		return playlistMsg{name: name, tracks: tracks, err: err}
+	}
+}
+
+func (m *model) playlistSaved(msg playlistMsg) {
+	if msg.err != nil {
+		m.notice = ""
+		m.errMsg = "  spotify: " + msg.err.Error()
+		// Logins from before the playlists lack their scopes.
+		if statusCode(msg.err) == http.StatusForbidden {
+			m.errMsg += ", log in again with stui -spotify-login"
+		}
+		return
+	}
+	m.notice = "  " + trf("Saved %d tracks to the playlist %s", msg.tracks, msg.name)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveSpotifyPlaylist(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var playlists []string
+	var saved []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			name := strings.TrimPrefix(r.URL.Query().Get("q"), "artist:")
+			if name == "Nobody" {
+				// The search answers with the closest artist.
+				name = "Nobody Else"
+			}
+			fmt.Fprintf(w, `{"artists":{"items":[{"id":%q,"name":%q}]}}`, strings.ToLower(name), name)
+		case strings.HasSuffix(r.URL.Path, "/top-tracks"):
+			id := strings.Split(r.URL.Path, "/")[2]
+			fmt.Fprintf(w, `{"tracks":[{"uri":"spotify:track:%[1]s1"},{"uri":"spotify:track:%[1]s2"},{"uri":"spotify:track:%[1]s3"},{"uri":"spotify:track:%[1]s4"}]}`, id)
+		case r.URL.Path == "/me/playlists":
+			fmt.Fprint(w, `{"items":[{"id":"other","name":"Road trip"}`)
+			for _, p := range playlists {
+				fmt.Fprintf(w, `,{"id":"new","name":%q}`, p)
+			}
+			fmt.Fprint(w, `],"next":null}`)
+		case r.URL.Path == "/me":
+			fmt.Fprint(w, `{"id":"user"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/users/user/playlists":
+			var body struct {
+				Name   string `json:"name"`
+				Public bool   `json:"public"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			playlists = append(playlists, body.Name)
+			fmt.Fprint(w, `{"id":"new"}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/playlists/new/tracks":
+			var body struct {
+				URIs []string `json:"uris"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			saved = body.URIs
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"snapshot_id":"1"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api string) { spotifyAPIURL = api }(spotifyAPIURL)
+	spotifyAPIURL = server.URL
+
+	for range [2]struct{}{} {
+		n, err := saveSpotifyPlaylist(context.Background(), "stui: like Radiohead", []string{"Muse", "Nobody", "Portishead"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 6 || len(saved) != 6 || saved[0] != "spotify:track:muse1" || saved[5] != "spotify:track:portishead3" {
+			t.Errorf("got %d tracks %v", n, saved)
+		}
+	}
+	// Saving again updates the playlist.
+	if len(playlists) != 1 || playlists[0] != "stui: like Radiohead" {
+		t.Errorf("created playlists %v", playlists)
+	}
+
+	if _, err := saveSpotifyPlaylist(context.Background(), "stui: like Radiohead", []string{"Nobody"}); err != errNoPlaylistTracks {
+		t.Errorf("got %v, want errNoPlaylistTracks", err)
+	}
+}
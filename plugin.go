@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultPluginTimeout is how long a plugin may run when its config does not
+// set timeout.
+const defaultPluginTimeout = 30 * time.Second
+
+// Plugin adds a section printed by an external command. The command gets
+// the track as JSON on stdin and in the STUI_ARTIST, STUI_ALBUM and
+// STUI_TRACK variables, and prints the markdown of the section.
+type Plugin struct {
+	Key     string   `toml:"key"`
+	Title   string   `toml:"title"`
+	Command []string `toml:"command"`
+	Timeout duration `toml:"timeout"`
+	// Cache keeps the output for cache_ttl like the other sections, the
+	// command runs for every track otherwise.
+	Cache bool `toml:"cache"`
+}
+
+// plugins are the sections of the external commands, in the order of the
+// config file.
+var plugins []Plugin
+
+// errNoPluginOutput is a plugin that printed nothing, it knows nothing about
+// the track.
+var errNoPluginOutput = errors.New("the plugin printed nothing")
+
+// pluginInput is what a plugin reads on stdin.
+type pluginInput struct {
+	Artist string `json:"artist"`
+	Album  string `json:"album"`
+	Track  string `json:"track"`
+}
+
+// source is the section source running the plugin. The commands run on the
+// machine, so they also run offline.
+func (p Plugin) source() apiSource {
+	return apiSource{
+		name:     "plugin-" + p.Key,
+		fetch:    p.run,
+		notFound: errNoPluginOutput,
+		missing:  "The plugin has nothing about this track.",
+		live:     !p.Cache,
+		local:    true,
+	}
+}
+
+func (p Plugin) run(ctx context.Context, info MusicInfo) (string, error) {
+	timeout := p.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(pluginInput{Artist: info.artist, Album: info.album, Track: info.track})
+	if err != nil {
+		return "", err
+	}
+	out, err := runCommand(ctx, p.Command, trackEnv(info), input)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("plugin %s: timed out after %s", p.Key, timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: %w", p.Key, err)
+	}
+	if out = strings.TrimSpace(out); out == "" {
+		return "", errNoPluginOutput
+	}
+	return out, nil
+}
+
+// trackEnv are the variables of the track for the commands of the plugins
+// and hooks.
+func trackEnv(info MusicInfo) []string {
+	return []string{"STUI_ARTIST=" + info.artist, "STUI_ALBUM=" + info.album, "STUI_TRACK=" + info.track}
+}
+
+// runCommand runs command with the extra variables of env and input on
+// stdin, and returns what it printed. A failure has the first line of
+// stderr, which usually says what went wrong.
+func runCommand(ctx context.Context, command []string, env []string, input []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, expandHome(command[0]), command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPluginSection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugins are shell scripts")
+	}
+	m := setupTest(t, PlayerPlaying)
+	defer func(o bool) { offline = o }(offline)
+	defer func() {
+		for _, p := range plugins {
+			delete(apiSources, p.Key)
+		}
+		plugins = nil
+	}()
+
+	plugins = []Plugin{
+		{Key: "vinyl", Title: "My vinyl", Command: []string{"sh", "-c", `read input; echo "Shelf of $STUI_ARTIST: $input"`}},
+		{Key: "empty", Title: "Nothing", Command: []string{"true"}},
+		{Key: "broken", Title: "Broken", Command: []string{"sh", "-c", "echo 'no database' >&2; exit 3"}},
+		{Key: "slow", Title: "Slow", Command: []string{"sleep", "5"}, Timeout: duration{50 * time.Millisecond}},
+	}
+	for _, p := range plugins {
+		apiSources[p.Key] = p.source()
+	}
+
+	// Plugins run offline too.
+	offline = true
+	m.getInfo(context.Background())
+	got := map[string]Section{}
+	for _, s := range m.sections {
+		got[s.Title] = s
+	}
+	if want := `Shelf of Radiohead: {"artist":"Radiohead","album":"OK Computer","track":"Airbag"}`; got["My vinyl"].Content != want {
+		t.Errorf("got %+v, want %q", got["My vinyl"], want)
+	}
+	if s := got["Nothing"]; s.Content != "*The plugin has nothing about this track.*" {
+		t.Errorf("got %+v", s)
+	}
+	if s := got["Broken"]; s.Error != "plugin broken: exit status 3: no database" {
+		t.Errorf("got %+v", s)
+	}
+	if s := got["Slow"]; !strings.Contains(s.Error, "timed out") {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestLoadConfigPlugins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"sections = [\"vinyl\"]\n[[plugins]]\nkey = \"vinyl\"\ntitle = \"My vinyl\"\ncommand = [\"vinyl\"]\n": "",
+		"[[plugins]]\nkey = \"vinyl\"\ntitle = \"My vinyl\"\n":                                                "need a key, a title and a command",
+		"[[plugins]]\nkey = \"review\"\ntitle = \"Review\"\ncommand = [\"review\"]\n":                         "the key of another section",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// isPodcast reports whether info is a podcast episode. Players report
+// episodes without an artist, with the show as the album.
+func (i MusicInfo) isPodcast() bool {
+	return i.artist == "" && i.track != ""
+}
+
+// getPodcastInfo is getInfo for podcast episodes: the music sections,
+// databases and lyrics do not apply, so the sections setting and the custom
+// prompts are left out too.
+func (m *model) getPodcastInfo(ctx context.Context, info MusicInfo) {
+	show, episode := info.album, info.track
+
+	var sections []Section
+	if show != "" {
+		sections = append(sections, Section{
+			key:    "show",
+			Title:  "Show description",
+			prompt: fmt.Sprintf("Describe the podcast %q: its topic, format and what makes it worth listening to", show),
+		})
+		sections = append(sections, Section{
+			key:    "episode",
+			Title:  "Episode summary",
+			prompt: fmt.Sprintf("Summarize the episode %q of the podcast %q", episode, show),
+		})
+		sections = append(sections, Section{
+			key:    "hosts",
+			Title:  "Hosts",
+			prompt: fmt.Sprintf("Who hosts the podcast %q? Give me a short biography of the hosts", show),
+		})
+	} else {
+		sections = append(sections, Section{
+			key:    "episode",
+			Title:  "Episode summary",
+			prompt: fmt.Sprintf("Summarize the podcast episode %q", episode),
+		})
+	}
+	for i := range sections {
+		sections[i].prompt = inLanguage(withLength(sections[i].key, sections[i].prompt))
+	}
+
+	// The links and the history are the last step.
+	m.whileCurrent(ctx, func() {
+		m.sections = sections
+		m.steps = len(sections) + 1
+		m.stepsDone = 0
+	})
+
+	var wg sync.WaitGroup
+	for i, s := range sections {
+		wg.Add(1)
+		index, title, prompt := i, s.Title, s.prompt
+		goSafe(func() { m.fetchSection(ctx, info, index, title, prompt, &wg) })
+	}
+	wg.Wait()
+
+	// The show stands in for the artist in the searches.
+	links := buildLinks(MusicInfo{artist: show, track: episode})
+
+	m.whileCurrent(ctx, func() {
+		m.links = links
+		m.buildContent()
+	})
+
+	if ctx.Err() == nil {
+		m.saveHistory(info)
+	}
+	m.stepDone(ctx)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPodcastInfo(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.MusicInfo = MusicInfo{album: "Song Exploder", track: "Radiohead - Airbag"}
+	if !m.isPodcast() {
+		t.Fatal("an episode without an artist should be a podcast")
+	}
+
+	m.getInfo(context.Background())
+
+	var titles []string
+	for _, s := range m.sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Show description, Episode summary, Hosts" {
+		t.Fatalf("got sections %s", got)
+	}
+	if got := m.sections[1].Content; got != `stub answer for: Summarize the episode "Radiohead - Airbag" of the podcast "Song Exploder"` {
+		t.Errorf("got episode summary %q", got)
+	}
+	if m.stepsDone != m.steps || m.steps != 4 {
+		t.Errorf("got %d/%d steps", m.stepsDone, m.steps)
+	}
+
+	m.mu.Lock()
+	tabs := m.tabs()
+	m.mu.Unlock()
+	for _, tab := range tabs {
+		if tab.lyrics {
+			t.Error("podcasts have no lyrics tab")
+		}
+	}
+	if title := m.titleView(); !strings.Contains(title, "Song Exploder - Radiohead - Airbag") {
+		t.Errorf("got title %q", title)
+	}
+}
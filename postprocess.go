@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Postprocess is the postprocess table of the config file, the clean up of
+// the AI answers before they are shown. The steps are on unless set to
+// false, the replacements run last in order.
+type Postprocess struct {
+	Boilerplate *bool         `toml:"boilerplate"`
+	Headings    *bool         `toml:"headings"`
+	Links       *bool         `toml:"links"`
+	Replace     []Replacement `toml:"replace"`
+}
+
+// Replacement rewrites the matches of the regular expression Pattern with
+// With, which refers to the groups as ${1}.
+type Replacement struct {
+	Pattern string `toml:"pattern"`
+	With    string `toml:"with"`
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	with    string
+}
+
+var (
+	// postprocess is the postprocess table of the config file.
+	postprocess Postprocess
+	// replacements are its compiled replacements.
+	replacements []replacement
+)
+
+func compileReplacements(rs []Replacement) ([]replacement, error) {
+	var compiled []replacement
+	for _, r := range rs {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("replace pattern %q: %w", r.Pattern, err)
+		}
+		compiled = append(compiled, replacement{pattern: pattern, with: r.With})
+	}
+	return compiled, nil
+}
+
+// stepEnabled reports whether a step of the clean up is on.
+func stepEnabled(step *bool) bool {
+	return step == nil || *step
+}
+
+// postProcessAnswer cleans up an AI answer shown under a heading of level
+// top-1: the section title is ## and the summary of an API section ###.
+func postProcessAnswer(answer string, top int) string {
+	if stepEnabled(postprocess.Boilerplate) {
+		answer = dropBoilerplate(answer)
+	}
+	if stepEnabled(postprocess.Headings) {
+		answer = shiftHeadings(answer, top)
+	}
+	if stepEnabled(postprocess.Links) {
+		answer = linkURLs(answer)
+	}
+	for _, r := range replacements {
+		answer = r.pattern.ReplaceAllString(answer, r.with)
+	}
+	return answer
+}
+
+var (
+	// sentenceRe splits a line into sentences, the text between its
+	// sentences included so they join back into the line.
+	sentenceRe = regexp.MustCompile(`[^.!?]+(?:[.!?]+\s*)?|[.!?]+\s*`)
+	// boilerplateRe is a sentence about the model itself, an apology or the
+	// filler closing an answer.
+	boilerplateRe = regexp.MustCompile(`(?i)^\s*(?:as an ai\b|as a language model\b|i'?m sorry\b|i am sorry\b|i apologi[sz]e\b|` +
+		`i hope (?:this|that) helps|let me know if\b|feel free to\b|i (?:don'?t|do not) have (?:personal|real-time|access|the ability)|` +
+		`(?:please note that )?my (?:knowledge|training data)\b)`)
+	// openerRe is the filler opening a sentence, "Sure!" or "Here are" before
+	// a colon. The rest of the sentence is kept.
+	openerRe = regexp.MustCompile(`(?i)^\s*(?:(?:sure|certainly|of course|absolutely)[!,.]+\s*|here(?: is|'s| are)\s+([^.!?]*:\s*)$)`)
+	// fenceRe opens or closes a code block.
+	fenceRe = regexp.MustCompile("^\\s*(?:```|~~~)")
+	// headingRe is an ATX heading, "## Title".
+	headingRe = regexp.MustCompile(`^(#{1,6})(\s+.*)$`)
+)
+
+// dropBoilerplate removes the sentences matching boilerplateRe and the
+// openers matching openerRe, and the lines left empty by them.
+func dropBoilerplate(answer string) string {
+	lines := strings.Split(answer, "\n")
+	kept := lines[:0]
+	inCode := false
+	for _, line := range lines {
+		if fenceRe.MatchString(line) {
+			inCode = !inCode
+		}
+		if inCode || strings.TrimSpace(line) == "" {
+			kept = append(kept, line)
+			continue
+		}
+
+		var sentences []string
+		dropped := false
+		for _, sentence := range sentenceRe.FindAllString(line, -1) {
+			if boilerplateRe.MatchString(sentence) {
+				dropped = true
+				continue
+			}
+			if rest := openerRe.ReplaceAllString(sentence, "$1"); rest != sentence {
+				dropped = true
+				if sentence = upperFirst(rest); sentence == "" {
+					continue
+				}
+			}
+			sentences = append(sentences, sentence)
+		}
+		if !dropped {
+			kept = append(kept, line)
+		} else if rest := strings.TrimSpace(strings.Join(sentences, "")); rest != "" {
+			kept = append(kept, rest)
+		}
+	}
+	answer = strings.Join(kept, "\n")
+	for strings.Contains(answer, "\n\n\n") {
+		answer = strings.ReplaceAll(answer, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(answer)
+}
+
+// upperFirst capitalizes the first letter of s, the sentence left by an
+// opener.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// shiftHeadings moves the headings so the highest one has level top, the
+// levels under it keep their distance. Code blocks are left as they are.
+func shiftHeadings(answer string, top int) string {
+	lines := strings.Split(answer, "\n")
+	highest := 0
+	eachHeading(lines, func(i int, level int) {
+		if highest == 0 || level < highest {
+			highest = level
+		}
+	})
+	if highest == 0 || highest == top {
+		return answer
+	}
+	eachHeading(lines, func(i int, level int) {
+		level += top - highest
+		if level > 6 {
+			level = 6
+		}
+		m := headingRe.FindStringSubmatch(lines[i])
+		lines[i] = strings.Repeat("#", level) + m[2]
+	})
+	return strings.Join(lines, "\n")
+}
+
+// eachHeading calls fn with the index and the level of every heading out of
+// the code blocks.
+func eachHeading(lines []string, fn func(i int, level int)) {
+	inCode := false
+	for i, line := range lines {
+		if fenceRe.MatchString(line) {
+			inCode = !inCode
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil && !inCode {
+			fn(i, len(m[1]))
+		}
+	}
+}
+
+// linkURLs turns the bare URLs into links named after them without the
+// scheme, so the link picker finds them. The URLs of links, autolinks and
+// code are left alone, as the punctuation ending a sentence.
+func linkURLs(answer string) string {
+	lines := strings.Split(answer, "\n")
+	inCode := false
+	for i, line := range lines {
+		if fenceRe.MatchString(line) {
+			inCode = !inCode
+		}
+		if inCode {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range urlPattern.FindAllStringIndex(line, -1) {
+			start, end := loc[0], loc[1]
+			if start > 0 && strings.ContainsRune("([<`'\"", rune(line[start-1])) || strings.Count(line[:start], "`")%2 == 1 {
+				continue
+			}
+			u := strings.TrimRight(line[start:end], ".,;:!?'*_")
+			end = start + len(u)
+			fmt.Fprintf(&b, "%s[%s](%s)", line[last:start], strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://"), u)
+			last = end
+		}
+		if last > 0 {
+			lines[i] = b.String() + line[last:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostProcessAnswer(t *testing.T) {
+	for answer, want := range map[string]string{
+		"Sure! Here is the album info:\n\nOK Computer is the third album of Radiohead. As an AI, I can't listen to music.\n\nI hope this helps!": "The album info:\n\nOK Computer is the third album of Radiohead.",
+		"Of course, the band split in 1970. Certainly!\nHere are the members:\n- John Lennon":                                                    "The band split in 1970.\nThe members:\n- John Lennon",
+		"# Background\nRecorded in 1996.\n## Reception\nAcclaimed.":                                                                              "### Background\nRecorded in 1996.\n#### Reception\nAcclaimed.",
+		"#### Background\nRecorded in 1996.":                                                                     "### Background\nRecorded in 1996.",
+		"More at https://en.wikipedia.org/wiki/OK_Computer.":                                                     "More at [en.wikipedia.org/wiki/OK_Computer](https://en.wikipedia.org/wiki/OK_Computer).",
+		"See [the review](https://pitchfork.com/ok) and <https://radiohead.com>, or `curl https://example.com`.": "See [the review](https://pitchfork.com/ok) and <https://radiohead.com>, or `curl https://example.com`.",
+		"```\n# not a heading\nI'm sorry, https://example.com\n```":                                              "```\n# not a heading\nI'm sorry, https://example.com\n```",
+	} {
+		if got := postProcessAnswer(answer, 3); got != want {
+			t.Errorf("postProcessAnswer(%q) = %q, want %q", answer, got, want)
+		}
+	}
+
+	defer func() { postprocess, replacements = Postprocess{}, nil }()
+	off := false
+	postprocess = Postprocess{Boilerplate: &off, Headings: &off, Links: &off}
+	var err error
+	if replacements, err = compileReplacements([]Replacement{{Pattern: `(?i)\bcolou?r\b`, With: "colour"}, {Pattern: `(\d{4})s`, With: "the ${1}s"}}); err != nil {
+		t.Fatal(err)
+	}
+	answer := "# As an AI, I like the color of 1990s https://example.com"
+	if got, want := postProcessAnswer(answer, 3), "# As an AI, I like the colour of the 1990s https://example.com"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfigPostprocess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"[postprocess]\nlinks = false\n[[postprocess.replace]]\npattern = \"colou?r\"\nwith = \"colour\"\n": "",
+		"[[postprocess.replace]]\npattern = \"(unclosed\"\n":                                                "replace pattern \"(unclosed\"",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
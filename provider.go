@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// providerFactory describes a backend of pkg/ai that can be selected with
+// -provider.
+type providerFactory struct {
+	// defaultModel is used when -model is not set, embeddingModel when
+	// -embedding-model is not set.
+	defaultModel   string
+	embeddingModel string
+	// keyEnv is the variable of the API key, hasKey reports whether one is
+	// set. Providers without a key leave them empty.
+	keyEnv string
+	hasKey func() bool
+	// new creates the provider once the flags and the config are read.
+	new func() (ai.Provider, error)
+}
+
+var providers = map[string]providerFactory{}
+
+// registerProvider makes a provider available to -provider, it is called from
+// the init function of every backend.
+func registerProvider(name string, f providerFactory) {
+	if _, ok := providers[name]; ok {
+		panic("provider registered twice: " + name)
+	}
+	providers[name] = f
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newProvider creates the named provider and returns it along with its
+// default model.
+func newProvider(name string) (ai.Provider, string, error) {
+	f, ok := providers[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown provider %q, use %s", name, strings.Join(providerNames(), ", "))
+	}
+
+	p, err := f.new()
+	if err != nil {
+		return nil, "", err
+	}
+	return p, f.defaultModel, nil
+}
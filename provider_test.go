@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+func TestNewProvider(t *testing.T) {
+	for _, name := range []string{"openai", "anthropic", "gemini", "ollama"} {
+		p, defaultModel, err := newProvider(name)
+		if err != nil || p == nil || defaultModel == "" {
+			t.Errorf("newProvider(%q) = %v, %q, %v", name, p, defaultModel, err)
+		}
+		if _, ok := p.(ai.StreamProvider); !ok {
+			t.Errorf("%s does not stream", name)
+		}
+	}
+
+	_, _, err := newProvider("nope")
+	if err == nil || !strings.Contains(err.Error(), "anthropic, azure, gemini, ollama, openai, openrouter") {
+		t.Errorf("got %v, want the list of providers", err)
+	}
+}
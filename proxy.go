@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// proxyURL and caFile are the proxy and the extra CA certificates of the
+// outbound requests, see the proxy and ca_file settings. Without proxyURL
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables are used.
+var (
+	proxyURL string
+	caFile   string
+)
+
+// baseTransport is the http.DefaultTransport of the standard library,
+// configureHTTP builds on it.
+var baseTransport = http.DefaultTransport.(*http.Transport)
+
+// configureHTTP applies proxyURL, caFile, offline and the Retry-After of the
+// servers to http.DefaultTransport, the AI providers and the metadata APIs
+// all use it.
+func configureHTTP() error {
+	transport := baseTransport.Clone()
+
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("proxy %q is not a URL such as http://proxy.example.com:8080", proxyURL)
+		}
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			// Local servers, such as Ollama, are not reached through the proxy.
+			if isLocalHost(req.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(expandHome(caFile))
+		if err != nil {
+			return fmt.Errorf("ca_file: %w", err)
+		}
+		// The bundle is added to the system certificates, not used instead
+		// of them.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("ca_file %s: no PEM certificates found", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	http.DefaultTransport = rateLimitTransport{next: transport}
+	if offline {
+		http.DefaultTransport = offlineTransport{next: http.DefaultTransport}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func restoreHTTP(t *testing.T) {
+	transport, proxy, ca := http.DefaultTransport, proxyURL, caFile
+	t.Cleanup(func() { http.DefaultTransport, proxyURL, caFile = transport, proxy, ca })
+}
+
+func TestConfigureHTTPCAFile(t *testing.T) {
+	restoreHTTP(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// The first request rejects the certificate, which the server logs.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	if _, err := http.Get(server.URL); err == nil {
+		t.Fatal("the test certificate is trusted without ca_file")
+	}
+
+	caFile = filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configureHTTP(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configureHTTP(); err == nil {
+		t.Error("a file without certificates is accepted")
+	}
+}
+
+func TestConfigureHTTPProxy(t *testing.T) {
+	restoreHTTP(t)
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer local.Close()
+
+	proxyURL = proxy.URL
+	if err := configureHTTP(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://musicbrainz.example.com/ws/2/release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// Local servers such as Ollama are reached directly.
+	resp, err = http.Get(local.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(proxied) != 1 || proxied[0] != "http://musicbrainz.example.com/ws/2/release" {
+		t.Errorf("got proxied requests %v", proxied)
+	}
+
+	proxyURL = "proxy.example.com"
+	if err := configureHTTP(); err == nil {
+		t.Error("a proxy without a scheme is accepted")
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var errNoQueue = errors.New("nothing is queued after this track")
+
+// prefetchNext fetches the info of the next track in watch mode while the
+// current one has less than prefetchLead left, so it shows at once when the
+// track changes.
+var (
+	prefetchNext bool
+	prefetchLead = time.Minute
+)
+
+// getQueue is replaced in tests.
+var getQueue = fetchQueue
+
+// upNext is what plays after the current track and where it comes from.
+type upNext struct {
+	source string
+	tracks []MusicInfo
+}
+
+type queueMsg struct {
+	info MusicInfo
+	next upNext
+	err  error
+}
+
+// queueView is the up next screen.
+type queueView struct {
+	info    MusicInfo
+	loading bool
+	err     string
+	next    upNext
+	cursor  int
+}
+
+// fetchQueue returns the Spotify queue when the Web API is logged in, or
+// else the tracks of the album after the current one.
+func fetchQueue(ctx context.Context, info MusicInfo) (upNext, error) {
+	if spotifyClientID != "" && spotifyLoggedIn() {
+		return spotifyQueue(ctx)
+	}
+	if info.album == "" {
+		return upNext{}, errNoQueue
+	}
+
+	release, err := getRelease(ctx, info)
+	if errors.Is(err, errNoRelease) {
+		return upNext{}, errNoQueue
+	}
+	if err != nil {
+		return upNext{}, err
+	}
+	next := upNext{source: tr("Rest of the album")}
+	found := false
+	for _, medium := range release.Media {
+		for _, t := range medium.Tracks {
+			if found {
+				next.tracks = append(next.tracks, MusicInfo{artist: info.artist, album: info.album, track: t.Title})
+			}
+			found = found || strings.EqualFold(t.Title, info.track)
+		}
+	}
+	if len(next.tracks) == 0 {
+		return upNext{}, errNoQueue
+	}
+	return next, nil
+}
+
+func spotifyQueue(ctx context.Context) (upNext, error) {
+	var q struct {
+		Queue []struct {
+			Type    string `json:"type"`
+			Name    string `json:"name"`
+			Artists []struct {
+				Name string `json:"name"`
+			} `json:"artists"`
+			Album struct {
+				Name string `json:"name"`
+			} `json:"album"`
+		} `json:"queue"`
+	}
+	if err := newSpotifyWebPlayer(spotifyClientID).get(ctx, "/me/player/queue", &q); err != nil {
+		return upNext{}, err
+	}
+
+	next := upNext{source: tr("Spotify queue")}
+	for _, item := range q.Queue {
+		// Like the player, stui follows the tracks and not the episodes.
+		if item.Type != "track" || len(item.Artists) == 0 {
+			continue
+		}
+		next.tracks = append(next.tracks, MusicInfo{artist: item.Artists[0].Name, album: cleanAlbumName(item.Album.Name, albumNoise), track: item.Name})
+	}
+	if len(next.tracks) == 0 {
+		return upNext{}, errNoQueue
+	}
+	return next, nil
+}
+
+// openQueue shows the up next screen, the queue comes back as a queueMsg.
+func (m *model) openQueue() tea.Cmd {
+	info := m.MusicInfo
+	m.queue = &queueView{info: info, loading: true}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		next, err := getQueue(ctx, info)
+		return queueMsg{info: info, next: next, err: err}
+	}
+}
+
+// queueLoaded shows the queue and prefetches the next track.
+func (m *model) queueLoaded(msg queueMsg) {
+	q := m.queue
+	if q == nil || q.info != msg.info {
+		return
+	}
+	q.loading = false
+	if msg.err != nil {
+		q.err = msg.err.Error()
+		return
+	}
+	q.next = msg.next
+	m.prefetchTrack(q.next.tracks[0])
+}
+
+// nextTrackMsg is the queue looked up to prefetch the next track.
+type nextTrackMsg struct {
+	info MusicInfo
+	next upNext
+	err  error
+}
+
+// prefetchNextCmd looks up what plays after the track once it has less than
+// prefetchLead left, in watch mode. It is looked up once per track.
+func (m *model) prefetchNextCmd() tea.Cmd {
+	remaining := m.playbackDuration - m.playbackPosition
+	if !prefetchNext || !m.watch || m.loading || m.isPodcast() || !cacheEnabled() || m.nextLookedUp == m.MusicInfo ||
+		m.playbackDuration <= 0 || remaining > prefetchLead {
+		return nil
+	}
+	info := m.MusicInfo
+	m.nextLookedUp = info
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		next, err := getQueue(ctx, info)
+		return nextTrackMsg{info: info, next: next, err: err}
+	}
+}
+
+// nextTrackLoaded prefetches the first track of the queue, unless the track
+// changed meanwhile.
+func (m *model) nextTrackLoaded(msg nextTrackMsg) {
+	if msg.info != m.MusicInfo || errors.Is(msg.err, errNoQueue) {
+		return
+	}
+	if msg.err != nil {
+		m.errMsg = "  prefetch: " + msg.err.Error()
+		return
+	}
+	m.prefetchTrack(msg.next.tracks[0])
+}
+
+// prefetchTrack fetches the sections of a track in the background, so they
+// come from the cache once it plays. The fetch does not save the track to
+// the history.
+func (m *model) prefetchTrack(info MusicInfo) {
+	if !cacheEnabled() || info == m.MusicInfo || info == m.prefetched {
+		return
+	}
+	m.prefetched = info
+
+	pre, err := newModel(info.artist, info.track, info.album)
+	if err != nil {
+		m.errMsg = "  prefetch: " + err.Error()
+		return
+	}
+	pre.mu = &sync.Mutex{}
+	pre.prefetch = true
+	m.notice = "  " + trf("Prefetching %s", info.track)
+	m.goFetch(func() { pre.getInfo(context.Background()) })
+}
+
+func (m *model) updateQueue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	q := m.queue
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.queue = nil
+	case tea.KeyUp:
+		if q.cursor > 0 {
+			q.cursor--
+		}
+	case tea.KeyDown:
+		if q.cursor < len(q.next.tracks)-1 {
+			q.cursor++
+		}
+	case tea.KeyEnter:
+		if q.cursor < len(q.next.tracks) {
+			m.prefetchTrack(q.next.tracks[q.cursor])
+		}
+	}
+	return m, nil
+}
+
+func (m *model) queueScreenView() string {
+	q := m.queue
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Up next")) + "\n\n")
+	switch {
+	case q.loading:
+		b.WriteString(pad + helpStyle(tr("Loading the queue...")) + "\n")
+	case q.err != "":
+		b.WriteString(pad + styleWarning(q.err) + "\n")
+	default:
+		b.WriteString(pad + helpStyle(q.next.source) + "\n\n")
+	}
+
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if q.cursor >= rows {
+		start = q.cursor - rows + 1
+	}
+	for i := start; i < len(q.next.tracks) && i < start+rows; i++ {
+		t := q.next.tracks[i]
+		var album string
+		if t.album != "" {
+			album = helpStyle(" · " + t.album)
+		}
+		if i == q.cursor {
+			b.WriteString(pad + styleBadge("› "+t.artist+" - "+t.track) + album + "\n")
+		} else {
+			b.WriteString(pad + "  " + t.artist + " - " + t.track + album + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("↑/↓: Select • enter: Prefetch • esc: Back")))
+	return b.String()
+}
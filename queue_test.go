@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFetchQueueRestOfAlbum(t *testing.T) {
+	setupTest(t, PlayerPlaying)
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		var r Release
+		err := json.Unmarshal([]byte(`{"media":[{"tracks":[{"title":"Airbag"},{"title":"Paranoid Android"}]},{"tracks":[{"title":"Subterranean Homesick Alien"}]}]}`), &r)
+		return &r, err
+	}
+
+	next, err := fetchQueue(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.tracks) != 2 || next.tracks[0].track != "Paranoid Android" || next.tracks[1].track != "Subterranean Homesick Alien" {
+		t.Fatalf("got %+v", next.tracks)
+	}
+	if next.tracks[0].artist != testTrack.artist || next.tracks[0].album != testTrack.album {
+		t.Errorf("got %+v, want the artist and album of the track", next.tracks[0])
+	}
+
+	last := testTrack
+	last.track = "Subterranean Homesick Alien"
+	if _, err := fetchQueue(context.Background(), last); !errors.Is(err, errNoQueue) {
+		t.Errorf("got %v after the last track, want errNoQueue", err)
+	}
+}
+
+func TestQueuePrefetch(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	enabledSections = map[string]bool{"wikipedia": true}
+	// Another album, the album sections of the current one are cached already.
+	next := MusicInfo{artist: "Radiohead", album: "Kid A", track: "Idioteque"}
+	getQueue = func(ctx context.Context, info MusicInfo) (upNext, error) {
+		return upNext{source: "Spotify queue", tracks: []MusicInfo{next}}, nil
+	}
+	defer func() {
+		enabledSections = nil
+		getQueue = fetchQueue
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, cmd := m.Update(keyRune('U'))
+	if m.queue == nil || cmd == nil {
+		t.Fatal("U did not open the queue")
+	}
+	m.Update(cmd())
+	if m.queue.loading || len(m.queue.next.tracks) != 1 {
+		t.Fatalf("got queue %+v", m.queue)
+	}
+	if m.prefetched != next {
+		t.Fatalf("prefetched %+v, want the next track", m.prefetched)
+	}
+
+	key := cacheKey(next, albumScope, "wikipedia", "section")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := readCache(key); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the next track was not cached")
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.queue != nil {
+		t.Error("esc did not close the queue")
+	}
+}
+
+func TestPrefetchNext(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+	enabledSections = map[string]bool{"wikipedia": true, "lyrics": true}
+	next := MusicInfo{artist: "Radiohead", album: "Kid A", track: "Idioteque"}
+	getQueue = func(ctx context.Context, info MusicInfo) (upNext, error) {
+		return upNext{source: "Spotify queue", tracks: []MusicInfo{next}}, nil
+	}
+	defer func(p bool) {
+		prefetchNext = p
+		enabledSections = nil
+		getQueue = fetchQueue
+	}(prefetchNext)
+	prefetchNext, m.watch = true, true
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.playbackSeq = 1
+	m.Update(playbackMsg{seq: 1, position: time.Minute, duration: 4 * time.Minute})
+	if m.nextLookedUp == m.MusicInfo {
+		t.Fatal("the queue was read with three minutes left")
+	}
+	m.Update(playbackMsg{seq: 1, position: 3*time.Minute + 30*time.Second, duration: 4 * time.Minute})
+	if m.nextLookedUp != m.MusicInfo {
+		t.Fatal("the queue was not read near the end of the track")
+	}
+	// The command of the update is batched with the next poll of the
+	// playback, the lookup is asked for again to run it alone.
+	m.nextLookedUp = MusicInfo{}
+	m.nextTrackLoaded(m.prefetchNextCmd()().(nextTrackMsg))
+	if m.prefetched != next {
+		t.Fatalf("prefetched %+v, want the next track", m.prefetched)
+	}
+	if cmd := m.prefetchNextCmd(); cmd != nil {
+		t.Error("the queue was read twice for the same track")
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		_, sections := readCache(cacheKey(next, albumScope, "wikipedia", "section"))
+		if _, lyrics := freshLyrics(next); sections && lyrics {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the next track and its lyrics were not cached")
+		}
+	}
+
+	getLyrics = func(ctx context.Context, info MusicInfo) (*Lyrics, error) {
+		t.Error("the prefetched lyrics were fetched again")
+		return nil, errNoLyrics
+	}
+	m.MusicInfo = next
+	m.fetchTrackLyrics(context.Background(), next)
+	if m.lyrics == nil || m.lyrics.Plain == "" {
+		t.Errorf("got lyrics %+v", m.lyrics)
+	}
+}
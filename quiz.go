@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// quizQuestions is how many questions a quiz asks.
+const quizQuestions = 5
+
+var errNoQuiz = errors.New("the answer is not a quiz, try again with r")
+
+// quizQuestion is a multiple-choice question, answer is the index of the
+// right choice.
+type quizQuestion struct {
+	question    string
+	choices     []string
+	answer      int
+	explanation string
+}
+
+// quizView is the open quiz about the artist and album.
+type quizView struct {
+	info      MusicInfo
+	loading   bool
+	err       string
+	questions []quizQuestion
+	// current is the question shown, chosen is the choice picked for it or
+	// -1 before answering.
+	current int
+	chosen  int
+	score   int
+	// correct and total are the answers of all the quizzes in the history.
+	correct int
+	total   int
+}
+
+func (q *quizView) finished() bool {
+	return len(q.questions) > 0 && q.current >= len(q.questions)
+}
+
+type quizMsg struct {
+	info      MusicInfo
+	questions []quizQuestion
+	err       error
+}
+
+func quizPrompt(info MusicInfo) string {
+	about := info.artist
+	if info.album != "" {
+		about = fmt.Sprintf("%s and their album %s", info.artist, info.album)
+	}
+	return inLanguage(fmt.Sprintf("Write a multiple-choice quiz of %d questions for a fan about %s, from easy to hard. "+
+		"Write every question in this format, with a blank line between them and the labels in English:\n"+
+		"Q: the question\nA) a choice\nB) a choice\nC) a choice\nD) a choice\nAnswer: the letter of the right choice\nWhy: one sentence on the answer",
+		quizQuestions, about))
+}
+
+var quizChoicePattern = regexp.MustCompile(`^([A-D])[).:]\s*(.+)$`)
+
+// parseQuiz reads the questions of a quiz answer, the ones missing choices
+// or a valid answer are dropped.
+func parseQuiz(s string) []quizQuestion {
+	var questions []quizQuestion
+	var q *quizQuestion
+	done := func() {
+		if q != nil && len(q.choices) >= 2 && q.answer >= 0 && q.answer < len(q.choices) {
+			questions = append(questions, *q)
+		}
+		q = nil
+	}
+
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "*"))
+		switch {
+		case strings.HasPrefix(line, "Q:"):
+			done()
+			q = &quizQuestion{question: strings.TrimSpace(strings.TrimPrefix(line, "Q:")), answer: -1}
+		case q == nil:
+		case quizChoicePattern.MatchString(line):
+			q.choices = append(q.choices, quizChoicePattern.FindStringSubmatch(line)[2])
+		case strings.HasPrefix(line, "Answer:"):
+			letter := strings.TrimSpace(strings.TrimPrefix(line, "Answer:"))
+			if letter != "" && letter[0] >= 'A' && letter[0] <= 'D' {
+				q.answer = int(letter[0] - 'A')
+			}
+		case strings.HasPrefix(line, "Why:"):
+			q.explanation = strings.TrimSpace(strings.TrimPrefix(line, "Why:"))
+		}
+	}
+	done()
+	return questions
+}
+
+// openQuiz opens the quiz screen and asks for a quiz about the track.
+func (m *model) openQuiz() tea.Cmd {
+	q := &quizView{info: m.MusicInfo}
+	m.quiz = q
+	if history != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		q.correct, q.total, _ = history.QuizScore(ctx)
+	}
+	return m.newQuiz(false)
+}
+
+// newQuiz asks for the questions, a new quiz skips the cached one.
+func (m *model) newQuiz(skipCache bool) tea.Cmd {
+	q := m.quiz
+	q.loading, q.err, q.questions = true, "", nil
+	q.current, q.chosen, q.score = 0, -1, 0
+
+	info := q.info
+	return func() tea.Msg {
+		ctx := context.Background()
+		if skipCache {
+			ctx = withoutCache(ctx)
+		}
+
+		prompt := quizPrompt(info)
+		key := cacheKey(info, albumScope, chatModel, prompt)
+		content, cached := cachedAnswer(ctx, key)
+		if !cached {
+			var err error
+			content, err = m.complete(ctx, chatModel, prompt)
+			if err != nil {
+				return quizMsg{info: info, err: fmt.Errorf("%s api: %w", provider, err)}
+			}
+		}
+
+		questions := parseQuiz(content)
+		if len(questions) == 0 {
+			return quizMsg{info: info, err: errNoQuiz}
+		}
+		if !cached {
+			writeCache(key, content)
+		}
+		return quizMsg{info: info, questions: questions}
+	}
+}
+
+func (m *model) startQuiz(msg quizMsg) {
+	q := m.quiz
+	if q == nil || q.info != msg.info {
+		return
+	}
+	q.loading = false
+	if msg.err != nil {
+		q.err = msg.err.Error()
+		return
+	}
+	q.questions = msg.questions
+}
+
+// answerQuiz picks a choice of the current question and records it.
+func (m *model) answerQuiz(choice int) {
+	q := m.quiz
+	question := q.questions[q.current]
+	if choice >= len(question.choices) {
+		return
+	}
+	q.chosen = choice
+	correct := choice == question.answer
+	q.total++
+	if correct {
+		q.score++
+		q.correct++
+	}
+
+	if history == nil {
+		return
+	}
+	info := q.info
+	goSafe(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := history.RecordQuizAnswer(ctx, info.artist, info.album, question.question, correct, time.Now()); err != nil {
+			m.mu.Lock()
+			m.errMsg = "  history: " + err.Error()
+			m.mu.Unlock()
+		}
+	})
+}
+
+func (m *model) updateQuiz(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	q := m.quiz
+
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.quiz = nil
+	case "r":
+		if !q.loading {
+			return m, m.newQuiz(true)
+		}
+	case "1", "2", "3", "4", "a", "b", "c", "d":
+		if q.loading || q.finished() || len(q.questions) == 0 || q.chosen >= 0 {
+			return m, nil
+		}
+		choice := int(key[0] - '1')
+		if key[0] >= 'a' {
+			choice = int(key[0] - 'a')
+		}
+		m.answerQuiz(choice)
+	case "enter", " ", "n":
+		if q.chosen >= 0 {
+			q.current++
+			q.chosen = -1
+		}
+	}
+	return m, nil
+}
+
+func (m *model) quizScreenView() string {
+	q := m.quiz
+	pad := strings.Repeat(" ", padding)
+	wrap := lipgloss.NewStyle().Width(m.viewportWidth() - viewportFrame).Render
+	indent := func(s string) string {
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	}
+
+	name := q.info.artist
+	if q.info.album != "" {
+		name += " - " + q.info.album
+	}
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+trf("Quiz: %s", name)) + "\n\n")
+
+	allTime := ""
+	if q.total > 0 {
+		allTime = " • " + trf("all time %d/%d", q.correct, q.total)
+	}
+
+	switch {
+	case q.loading:
+		b.WriteString(pad + helpStyle(tr("Writing the quiz...")) + "\n\n")
+		b.WriteString(pad + helpStyle(tr("esc: Back")))
+		return b.String()
+	case q.err != "":
+		b.WriteString(indent(styleWarning(wrap("⚠ "+q.err))) + "\n\n")
+		b.WriteString(pad + helpStyle(tr("r: New quiz • esc: Back")))
+		return b.String()
+	case q.finished():
+		b.WriteString(pad + styleBadge(trf("You got %d of %d", q.score, len(q.questions))) + helpStyle(allTime) + "\n\n")
+		b.WriteString(pad + helpStyle(tr("r: New quiz • esc: Back")))
+		return b.String()
+	}
+
+	question := q.questions[q.current]
+	b.WriteString(pad + helpStyle(trf("Question %d of %d • score %d", q.current+1, len(q.questions), q.score)+allTime) + "\n\n")
+	b.WriteString(indent(wrap(question.question)) + "\n\n")
+	for i, choice := range question.choices {
+		line := fmt.Sprintf("%d) %s", i+1, choice)
+		switch {
+		case q.chosen >= 0 && i == question.answer:
+			line = styleBadge("✓ " + line)
+		case i == q.chosen:
+			line = styleWarning("✗ " + line)
+		default:
+			line = "  " + line
+		}
+		b.WriteString(pad + line + "\n")
+	}
+
+	if q.chosen >= 0 {
+		if question.explanation != "" {
+			b.WriteString("\n" + indent(helpStyle(wrap(question.explanation))) + "\n")
+		}
+		b.WriteString("\n" + pad + helpStyle(tr("enter: Next question • esc: Back")))
+	} else {
+		b.WriteString("\n" + pad + helpStyle(tr("1-4: Answer • esc: Back")))
+	}
+	return b.String()
+}
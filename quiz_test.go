@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+const testQuiz = `Here is your quiz:
+
+**Q: Which city is Radiohead from?**
+A) Manchester
+B) Abingdon
+C) Liverpool
+D) Bristol
+Answer: B
+Why: The band formed at Abingdon School in Oxfordshire.
+
+Q: What year was OK Computer released?
+A. 1995
+B. 1997
+Answer: B
+
+Q: A question without choices
+Answer: A
+
+Q: A question with an answer missing
+A) Yes
+B) No
+`
+
+type quizCompleter struct{}
+
+func (quizCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	return testQuiz, nil
+}
+
+func TestParseQuiz(t *testing.T) {
+	questions := parseQuiz(testQuiz)
+	if len(questions) != 2 {
+		t.Fatalf("got %d questions: %+v", len(questions), questions)
+	}
+
+	q := questions[0]
+	if q.question != "Which city is Radiohead from?" || len(q.choices) != 4 || q.choices[1] != "Abingdon" || q.answer != 1 ||
+		q.explanation != "The band formed at Abingdon School in Oxfordshire." {
+		t.Errorf("got first question %+v", q)
+	}
+	if q := questions[1]; len(q.choices) != 2 || q.choices[1] != "1997" || q.answer != 1 || q.explanation != "" {
+		t.Errorf("got second question %+v", q)
+	}
+}
+
+func TestQuiz(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	completer = quizCompleter{}
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	press := func(key string) {
+		switch key {
+		case "enter":
+			m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		default:
+			m.Update(keyRune(rune(key[0])))
+		}
+	}
+
+	_, cmd := m.Update(keyRune('Q'))
+	if m.quiz == nil || cmd == nil {
+		t.Fatal("Q did not open the quiz")
+	}
+	m.Update(cmd())
+	if m.quiz.loading || len(m.quiz.questions) != 2 {
+		t.Fatalf("got quiz %+v", m.quiz)
+	}
+
+	// Moving on needs an answer.
+	press("enter")
+	if m.quiz.current != 0 {
+		t.Fatal("skipped a question without an answer")
+	}
+
+	press("2")
+	press("3")
+	if m.quiz.chosen != 1 || m.quiz.score != 1 {
+		t.Errorf("got chosen %d and score %d", m.quiz.chosen, m.quiz.score)
+	}
+	press("enter")
+	press("a")
+	press("enter")
+	if !m.quiz.finished() || m.quiz.score != 1 || m.quiz.total != 2 {
+		t.Errorf("got score %d of %d, finished %v", m.quiz.score, m.quiz.total, m.quiz.finished())
+	}
+	if !strings.Contains(m.View(), "You got 1 of 2") {
+		t.Errorf("no score in view:\n%s", m.View())
+	}
+
+	// The answers are recorded in the background.
+	var correct, total int
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if correct, total, err = db.QuizScore(context.Background()); err != nil || total == 2 {
+			break
+		}
+	}
+	if err != nil || correct != 1 || total != 2 {
+		t.Errorf("got history score %d/%d, %v", correct, total, err)
+	}
+
+	press("esc")
+	if m.quiz != nil {
+		t.Error("esc did not close the quiz")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter caps the wait a server asks for with Retry-After.
+const maxRetryAfter = 2 * time.Minute
+
+// aiSlots holds a slot for every request in flight to the AI provider, up to
+// concurrency across the sections, refreshes and prefetches.
+var (
+	aiSlots     chan struct{}
+	aiSlotsOnce sync.Once
+)
+
+// acquireAISlot waits for a free slot, release it with releaseAISlot.
+func acquireAISlot(ctx context.Context) error {
+	aiSlotsOnce.Do(func() {
+		n := concurrency
+		if n < 1 {
+			n = 1
+		}
+		aiSlots = make(chan struct{}, n)
+	})
+	select {
+	case aiSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseAISlot() {
+	<-aiSlots
+}
+
+// rateLimits is when each host allows requests again after it answered with
+// Retry-After.
+var rateLimits = struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}{until: map[string]time.Time{}}
+
+// rateLimitTransport holds the requests to a host that rate limited stui
+// until the time it asked for, so the sections and refreshes do not hit the
+// limit again while they retry.
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	rateLimits.mu.Lock()
+	wait := time.Until(rateLimits.until[host])
+	rateLimits.mu.Unlock()
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if delay := retryAfter(resp.Header, time.Now()); delay > 0 {
+			debugLog("rate_limit", "host", host, "status", resp.StatusCode, "retry_after", delay)
+			rateLimits.mu.Lock()
+			if until := time.Now().Add(delay); until.After(rateLimits.until[host]) {
+				rateLimits.until[host] = until
+			}
+			rateLimits.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+// retryAfter is the wait asked for by the Retry-After header, in seconds or
+// as a date, or by the retry-after-ms header of OpenAI. Zero without one.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	var delay time.Duration
+	if ms, err := strconv.Atoi(h.Get("Retry-After-Ms")); err == nil {
+		delay = time.Duration(ms) * time.Millisecond
+	} else if v := h.Get("Retry-After"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil {
+			delay = time.Duration(s) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			delay = t.Sub(now)
+		}
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{}, 0},
+		{http.Header{"Retry-After": {"7"}}, 7 * time.Second},
+		{http.Header{"Retry-After": {now.Add(20 * time.Second).Format(http.TimeFormat)}}, 20 * time.Second},
+		{http.Header{"Retry-After": {"7"}, "Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{http.Header{"Retry-After": {"86400"}}, maxRetryAfter},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+	} {
+		if got := retryAfter(tt.header, now); got != tt.want {
+			t.Errorf("retryAfter(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	var requests []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, time.Now())
+		if len(requests) == 1 {
+			w.Header().Set("Retry-After-Ms", "300")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: rateLimitTransport{next: http.DefaultTransport}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if len(requests) != 2 || requests[1].Sub(requests[0]) < 300*time.Millisecond {
+		t.Errorf("the second request was sent %v after the rate limit", requests[1].Sub(requests[0]))
+	}
+
+	// A canceled request does not wait.
+	rateLimits.mu.Lock()
+	rateLimits.until[server.Listener.Addr().String()] = time.Now().Add(time.Minute)
+	rateLimits.mu.Unlock()
+	defer func() {
+		rateLimits.mu.Lock()
+		delete(rateLimits.until, server.Listener.Addr().String())
+		rateLimits.mu.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil || len(requests) != 2 {
+		t.Errorf("got %v after %d requests", err, len(requests))
+	}
+}
+
+func TestAISlots(t *testing.T) {
+	// Take every slot, the next request waits for one.
+	acquireAISlot(context.Background())
+	n := cap(aiSlots) - len(aiSlots)
+	for i := 0; i < n; i++ {
+		acquireAISlot(context.Background())
+	}
+	defer func() {
+		for len(aiSlots) > 0 {
+			releaseAISlot()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := acquireAISlot(ctx); err == nil {
+		t.Fatal("a request got a slot beyond concurrency")
+	}
+	releaseAISlot()
+	if err := acquireAISlot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
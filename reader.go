@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pager is the command the document is read in with keys.Read, $PAGER by
+// default. Empty or "internal" reads it in stui, full screen without the
+// borders and the title.
+var pager = os.Getenv("PAGER")
+
+// readerView is the internal reading mode.
+type readerView struct {
+	viewport viewport.Model
+}
+
+// pagerDoneMsg tells that the pager exited.
+type pagerDoneMsg struct{ err error }
+
+// readingWidth is the width the document is wrapped at, the whole terminal
+// up to max_width.
+func (m *model) readingWidth() int {
+	if m.width == 0 {
+		return clampWidth(defaultWidth)
+	}
+	return clampWidth(m.width)
+}
+
+// readingText is the document as it is read, rendered unless the raw
+// markdown is shown.
+func (m *model) readingText() (string, error) {
+	if m.showRaw {
+		return m.markdown(), nil
+	}
+	return renderContent(m.markdown(), m.readingWidth())
+}
+
+// read hands the document to the pager, which takes over the terminal until
+// it exits, or opens the internal reading mode.
+func (m *model) read() tea.Cmd {
+	if !m.hasContent() {
+		return nil
+	}
+	text, err := m.readingText()
+	if err != nil {
+		m.errMsg = "  pager: " + err.Error()
+		return nil
+	}
+
+	if pager == "" || pager == "internal" {
+		m.reader = &readerView{}
+		m.resizeReader(text)
+		return nil
+	}
+
+	args := strings.Fields(pager)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	// less shows the colors of the rendered markdown with -R.
+	if filepath.Base(args[0]) == "less" && os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=-R")
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return pagerDoneMsg{err: err} })
+}
+
+// resizeReader fits the reading mode to the terminal, the last line is the
+// help. The position is kept.
+func (m *model) resizeReader(text string) {
+	offset := m.reader.viewport.YOffset
+	height := m.height - 1
+	if height < 1 {
+		height = 1
+	}
+	vp := viewport.New(m.readingWidth(), height)
+	vp.KeyMap = keys.viewport()
+	vp.SetContent(text)
+	vp.SetYOffset(offset)
+	m.reader.viewport = vp
+}
+
+func (m *model) updateReader(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case msg.Type == tea.KeyEsc || key.Matches(msg, keys.Read, keys.Quit):
+		m.reader = nil
+		return m, nil
+	case key.Matches(msg, keys.Top):
+		m.reader.viewport.GotoTop()
+		return m, nil
+	case key.Matches(msg, keys.Bottom):
+		m.reader.viewport.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.reader.viewport, cmd = m.reader.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *model) readerScreenView() string {
+	vp := m.reader.viewport
+	return vp.View() + "\n" + helpStyle(tr("esc: Back")+fmt.Sprintf(" • %3.f%%", vp.ScrollPercent()*100))
+}
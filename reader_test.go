@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestReadingMode(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(p string) { pager = p }(pager)
+	pager = "internal"
+	m.getInfo(context.Background())
+	m.loading = false
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, cmd := m.Update(keyRune('O')); m.reader == nil || cmd != nil {
+		t.Fatal("O did not open the reading mode")
+	}
+	view := ansiPattern.ReplaceAllString(m.View(), "")
+	if strings.Contains(view, "╭") || !strings.Contains(view, "Radiohead - OK Computer - Airbag") || !strings.Contains(view, "esc: Back") {
+		t.Errorf("got view:\n%s", view)
+	}
+	if got := strings.Count(view, "\n") + 1; got != 30 {
+		t.Errorf("the view has %d lines, want the 30 of the terminal", got)
+	}
+
+	m.Update(keyRune('G'))
+	if !m.reader.viewport.AtBottom() {
+		t.Error("G did not scroll to the end")
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	if m.reader.viewport.Width != 80 || m.reader.viewport.Height != 19 {
+		t.Errorf("got %dx%d after the resize", m.reader.viewport.Width, m.reader.viewport.Height)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.reader != nil {
+		t.Error("esc did not close the reading mode")
+	}
+}
+
+func TestPager(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(p string) { pager = p }(pager)
+	pager = "less -R"
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 30
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, cmd := m.Update(keyRune('O')); cmd == nil || m.reader != nil {
+		t.Error("O did not hand the document to the pager")
+	}
+	m.Update(pagerDoneMsg{err: errors.New("exit status 2")})
+	if m.errMsg != "  pager: exit status 2" {
+		t.Errorf("got error %q", m.errMsg)
+	}
+}
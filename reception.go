@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var errNoReception = errors.New("no scores found for the album")
+
+// getReception is replaced in tests.
+var getReception = fetchReception
+
+// Reception is the scores of an album by the critics and the users.
+type Reception struct {
+	Scores []ReviewScore
+	// WikipediaURL is the article the critic scores come from.
+	WikipediaURL string
+	// Rating is the average rating of the MusicBrainz users out of 5, Votes
+	// how many rated it.
+	Rating         float64
+	Votes          int
+	ReleaseGroupID string
+}
+
+// ReviewScore is the score of a publication or an aggregator, as written in
+// the review, e.g. 4.5/5, 8.2/10 or A−.
+type ReviewScore struct {
+	Source string
+	Score  string
+}
+
+// aggregators are the scores of the Album ratings template that are not a
+// review, they are listed first.
+var aggregators = []struct{ param, name string }{
+	{"MC", "Metacritic"},
+	{"ADM", "AnyDecentMusic?"},
+	{"AOTY", "Album of the Year"},
+}
+
+// fetchReception reads the critic scores of the Album ratings box of the
+// Wikipedia article and the rating of the MusicBrainz users.
+func fetchReception(ctx context.Context, info MusicInfo) (*Reception, error) {
+	r := &Reception{}
+
+	key, err := findArticle(ctx, info.album+" "+info.artist+" album", albumWords)
+	if err != nil && !errors.Is(err, errNoWikipedia) {
+		return nil, err
+	}
+	if err == nil {
+		var page struct {
+			Source string `json:"source"`
+		}
+		if err := wikipediaGet(ctx, "/w/rest.php/v1/page/"+url.PathEscape(key), &page); err != nil && !errors.Is(err, errNoWikipedia) {
+			return nil, err
+		}
+		if r.Scores = albumRatings(page.Source); len(r.Scores) > 0 {
+			r.WikipediaURL = wikipediaURL + "/wiki/" + url.PathEscape(key)
+		}
+	}
+
+	query := fmt.Sprintf(`artist:"%s" AND releasegroup:"%s"`, info.artist, info.album)
+	var search struct {
+		ReleaseGroups []struct {
+			ID string `json:"id"`
+		} `json:"release-groups"`
+	}
+	if err := musicBrainzGet(ctx, "/release-group/?fmt=json&limit=1&query="+url.QueryEscape(query), &search); err != nil {
+		return nil, err
+	}
+	if len(search.ReleaseGroups) > 0 {
+		var group struct {
+			Rating struct {
+				Value *float64 `json:"value"`
+				Votes int      `json:"votes-count"`
+			} `json:"rating"`
+		}
+		r.ReleaseGroupID = search.ReleaseGroups[0].ID
+		if err := musicBrainzGet(ctx, "/release-group/"+r.ReleaseGroupID+"?fmt=json&inc=ratings", &group); err != nil {
+			return nil, err
+		}
+		if group.Rating.Value != nil && group.Rating.Votes > 0 {
+			r.Rating, r.Votes = *group.Rating.Value, group.Rating.Votes
+		}
+	}
+
+	if len(r.Scores) == 0 && r.Votes == 0 {
+		return nil, errNoReception
+	}
+	return r, nil
+}
+
+var (
+	refPattern      = regexp.MustCompile(`(?s)<ref[^>]*/>|<ref[^>]*>.*?</ref>|<!--.*?-->`)
+	ratingPattern   = regexp.MustCompile(`\{\{\s*[Rr]ating\s*\|\s*([\d.]+)\s*\|\s*([\d.]+)\s*\}\}`)
+	linkPattern     = regexp.MustCompile(`\[\[(?:[^|\]]*\|)?([^\]]*)\]\]`)
+	templatePattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+)
+
+// albumRatings returns the scores of the {{Album ratings}} template of the
+// wikitext of an article.
+func albumRatings(wikitext string) []ReviewScore {
+	start := strings.Index(strings.ToLower(wikitext), "{{album ratings")
+	if start < 0 {
+		return nil
+	}
+	params := templateParams(wikitext[start:])
+
+	var scores []ReviewScore
+	for _, a := range aggregators {
+		if score := wikiText(params[a.param]); score != "" {
+			scores = append(scores, ReviewScore{Source: a.name, Score: score})
+		}
+	}
+	// The reviews are rev1 and rev1score, rev2 and rev2score...
+	for i := 1; ; i++ {
+		source, ok := params[fmt.Sprintf("rev%d", i)]
+		if !ok {
+			break
+		}
+		score := wikiText(params[fmt.Sprintf("rev%dscore", i)])
+		if source = wikiText(source); source != "" && score != "" {
+			scores = append(scores, ReviewScore{Source: source, Score: score})
+		}
+	}
+	return scores
+}
+
+// templateParams splits the named parameters of the template the wikitext
+// starts with, the templates and links nested in it are kept whole.
+func templateParams(wikitext string) map[string]string {
+	params := map[string]string{}
+	depth := 0
+	var part strings.Builder
+	flush := func() {
+		if name, value, ok := strings.Cut(part.String(), "="); ok {
+			params[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		part.Reset()
+	}
+
+	for i := 0; i < len(wikitext); i++ {
+		two := wikitext[i:]
+		if len(two) > 2 {
+			two = two[:2]
+		}
+		switch {
+		case two == "{{" || two == "[[":
+			depth++
+			i++
+			if depth > 1 {
+				part.WriteString(two)
+			}
+			continue
+		case two == "}}" || two == "]]":
+			depth--
+			i++
+			if depth == 0 {
+				flush()
+				return params
+			}
+			part.WriteString(two)
+			continue
+		case wikitext[i] == '|' && depth == 1:
+			flush()
+			continue
+		}
+		part.WriteByte(wikitext[i])
+	}
+	return params
+}
+
+// wikiText turns the wikitext of a parameter into plain text: ratings such
+// as {{Rating|4|5}} become 4/5, links their label, and references and other
+// templates are dropped.
+func wikiText(s string) string {
+	s = refPattern.ReplaceAllString(s, "")
+	s = ratingPattern.ReplaceAllString(s, "$1/$2")
+	s = linkPattern.ReplaceAllString(s, "$1")
+	for templatePattern.MatchString(s) {
+		s = templatePattern.ReplaceAllString(s, "")
+	}
+	s = strings.NewReplacer("'''", "", "''", "", "<br>", " ", "<br />", " ", "&nbsp;", " ").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// receptionSection renders the scores of the album as a table.
+func receptionSection(ctx context.Context, info MusicInfo) (string, error) {
+	r, err := getReception(ctx, info)
+	if err != nil {
+		return "", err
+	}
+	return r.markdown(), nil
+}
+
+func (r *Reception) markdown() string {
+	var b strings.Builder
+	b.WriteString("| Source | Score |\n| --- | --- |\n")
+	for _, s := range r.Scores {
+		fmt.Fprintf(&b, "| %s | %s |\n", tableCell(s.Source), tableCell(s.Score))
+	}
+	if r.Votes > 0 {
+		fmt.Fprintf(&b, "| MusicBrainz users | %.1f/5 (%s votes) |\n", r.Rating, formatCount(fmt.Sprint(r.Votes)))
+	}
+
+	var sources []string
+	if r.WikipediaURL != "" {
+		sources = append(sources, "[Wikipedia]("+r.WikipediaURL+")")
+	}
+	if r.Votes > 0 {
+		sources = append(sources, "[MusicBrainz](https://musicbrainz.org/release-group/"+r.ReleaseGroupID+")")
+	}
+	if len(sources) > 0 {
+		fmt.Fprintf(&b, "\n*Sources: %s*", strings.Join(sources, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const okComputerWikitext = `'''''OK Computer''''' is the third studio album by [[Radiohead]].
+
+==Critical reception==
+{{Album ratings
+| MC = 85/100<ref>{{cite web |url=https://www.metacritic.com |title=OK Computer}}</ref>
+| rev1 = [[AllMusic]]
+| rev1score = {{Rating|5|5}}<ref name="allmusic"/>
+| rev2 = ''[[Pitchfork (website)|Pitchfork]]''
+| rev2score = 10/10<!-- the 2017 reissue -->
+| rev3 = ''[[Entertainment Weekly]]''
+| rev3score = B+
+| rev4 = ''Q''
+| rev4score =
+}}
+The album received acclaim.`
+
+func TestAlbumRatings(t *testing.T) {
+	got := albumRatings(okComputerWikitext)
+	want := []ReviewScore{
+		{"Metacritic", "85/100"},
+		{"AllMusic", "5/5"},
+		{"Pitchfork", "10/10"},
+		{"Entertainment Weekly", "B+"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("score %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if scores := albumRatings("No ratings here."); scores != nil {
+		t.Errorf("got %+v without a ratings box", scores)
+	}
+}
+
+func TestFetchReception(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/w/rest.php/v1/search/page":
+			w.Write([]byte(`{"pages":[{"key":"OK_Computer","description":"1997 studio album by Radiohead"}]}`))
+		case "/w/rest.php/v1/page/OK_Computer":
+			json.NewEncoder(w).Encode(map[string]string{"source": okComputerWikitext})
+		case "/release-group/":
+			w.Write([]byte(`{"release-groups":[{"id":"b1392450"}]}`))
+		case "/release-group/b1392450":
+			if r.URL.Query().Get("inc") != "ratings" {
+				t.Errorf("got inc %q", r.URL.Query().Get("inc"))
+			}
+			w.Write([]byte(`{"rating":{"value":4.65,"votes-count":1234}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(wp, mb string) { wikipediaURL, musicBrainzURL = wp, mb }(wikipediaURL, musicBrainzURL)
+	wikipediaURL, musicBrainzURL = server.URL, server.URL
+
+	got, err := receptionSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Metacritic | 85/100 |",
+		"| AllMusic | 5/5 |",
+		"| MusicBrainz users | 4.7/5 (1,234 votes) |",
+		"[Wikipedia](" + server.URL + "/wiki/OK_Computer)",
+		"[MusicBrainz](https://musicbrainz.org/release-group/b1392450)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("section does not include %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var redditURL = "https://www.reddit.com"
+
+// redditEnabled adds the Reddit section, redditSummary has the AI provider
+// sum up the top comments of the threads.
+var (
+	redditEnabled bool
+	redditSummary bool
+)
+
+const (
+	// redditThreads is how many threads the section lists, the comments of
+	// the first summaryThreads of them are summarized.
+	redditThreads  = 5
+	summaryThreads = 3
+	// redditComments is how many top comments of a thread are summarized.
+	redditComments = 10
+)
+
+var errNoReddit = errors.New("no Reddit threads found")
+
+// getRedditThreads and getRedditComments are replaced in tests.
+var (
+	getRedditThreads  = fetchRedditThreads
+	getRedditComments = fetchRedditComments
+)
+
+// RedditThread is a post about the album or the song.
+type RedditThread struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Subreddit   string `json:"subreddit"`
+	Score       int    `json:"score"`
+	NumComments int    `json:"num_comments"`
+	Permalink   string `json:"permalink"`
+}
+
+func redditGet(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, redditURL+path, nil)
+	if err != nil {
+		return err
+	}
+	// Reddit throttles the default user agents of HTTP libraries.
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "reddit: status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// redditQuery searches the artist along with the album or the song.
+func redditQuery(info MusicInfo) string {
+	var titles []string
+	for _, s := range []string{info.album, info.track} {
+		if s != "" {
+			titles = append(titles, fmt.Sprintf("%q", s))
+		}
+	}
+	return fmt.Sprintf("%q (%s)", info.artist, strings.Join(titles, " OR "))
+}
+
+func fetchRedditThreads(ctx context.Context, info MusicInfo) ([]RedditThread, error) {
+	var r struct {
+		Data struct {
+			Children []struct {
+				Data RedditThread `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	params := url.Values{"q": {redditQuery(info)}, "sort": {"relevance"}, "t": {"all"}, "type": {"link"}, "limit": {fmt.Sprint(redditThreads)}, "raw_json": {"1"}}
+	if err := redditGet(ctx, "/search.json?"+params.Encode(), &r); err != nil {
+		return nil, err
+	}
+
+	var threads []RedditThread
+	for _, c := range r.Data.Children {
+		threads = append(threads, c.Data)
+	}
+	if len(threads) == 0 {
+		return nil, errNoReddit
+	}
+	return threads, nil
+}
+
+// fetchRedditComments returns the top level comments of a thread, the best
+// first.
+func fetchRedditComments(ctx context.Context, thread RedditThread) ([]string, error) {
+	// The answer is the post and then its comments.
+	var listings []struct {
+		Data struct {
+			Children []struct {
+				Kind string `json:"kind"`
+				Data struct {
+					Body string `json:"body"`
+				} `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	params := url.Values{"sort": {"top"}, "depth": {"1"}, "limit": {fmt.Sprint(redditComments)}, "raw_json": {"1"}}
+	if err := redditGet(ctx, "/comments/"+url.PathEscape(thread.ID)+".json?"+params.Encode(), &listings); err != nil {
+		return nil, err
+	}
+
+	var comments []string
+	if len(listings) < 2 {
+		return comments, nil
+	}
+	for _, c := range listings[1].Data.Children {
+		if c.Kind == "t1" && c.Data.Body != "" && c.Data.Body != "[deleted]" && c.Data.Body != "[removed]" {
+			comments = append(comments, c.Data.Body)
+		}
+	}
+	return comments, nil
+}
+
+// redditSection lists the threads with their scores, the summary sums up
+// the comments of the same threads.
+func redditSection(ctx context.Context, info MusicInfo) (string, summaryPrompt, error) {
+	threads, err := getRedditThreads(ctx, info)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	for i, t := range threads {
+		fmt.Fprintf(&b, "%d. [%s](%s%s) · r/%s · ▲ %s · %s comments\n", i+1, strings.NewReplacer("[", "(", "]", ")").Replace(t.Title),
+			redditURL, t.Permalink, t.Subreddit, formatCount(fmt.Sprint(t.Score)), formatCount(fmt.Sprint(t.NumComments)))
+	}
+	b.WriteString("\n*Source: Reddit*")
+	if !redditSummary {
+		return b.String(), nil, nil
+	}
+	return b.String(), func(ctx context.Context) (string, error) { return redditSummaryPrompt(ctx, info, threads) }, nil
+}
+
+// redditSummaryPrompt asks to sum up the top comments of the first threads,
+// it is empty when there is nothing to sum up.
+func redditSummaryPrompt(ctx context.Context, info MusicInfo, threads []RedditThread) (string, error) {
+	var b strings.Builder
+	for i, t := range threads {
+		if i == summaryThreads {
+			break
+		}
+		comments, err := getRedditComments(ctx, t)
+		if err != nil {
+			return "", err
+		}
+		if len(comments) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\nThread %q in r/%s:\n", t.Title, t.Subreddit)
+		for _, c := range comments {
+			fmt.Fprintf(&b, "- %s\n", strings.Join(strings.Fields(c), " "))
+		}
+	}
+	if b.Len() == 0 {
+		return "", nil
+	}
+
+	about := info.album
+	if about == "" {
+		about = info.track
+	}
+	return inLanguage(fmt.Sprintf("Sum up in a short paragraph what Reddit thinks of %s by %s: the consensus, the hot takes and the disagreements. "+
+		"These are the top comments of the threads about it:\n%s", about, info.artist, b.String())), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchRedditThreads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.UserAgent(), "stui/") {
+			t.Errorf("got user agent %q", r.UserAgent())
+		}
+		switch r.URL.Path {
+		case "/search.json":
+			if q := r.URL.Query().Get("q"); q != `"Radiohead" ("OK Computer" OR "Airbag")` {
+				t.Errorf("got query %q", q)
+			}
+			w.Write([]byte(`{"data":{"children":[{"kind":"t3","data":{"id":"abc","title":"OK Computer [25 years]","subreddit":"radiohead","score":1234,"num_comments":456,"permalink":"/r/radiohead/comments/abc/ok_computer/"}}]}}`))
+		case "/comments/abc.json":
+			w.Write([]byte(`[{"data":{"children":[{"kind":"t3","data":{}}]}},{"data":{"children":[` +
+				`{"kind":"t1","data":{"body":"Paranoid Android is\nthe peak."}},{"kind":"t1","data":{"body":"[deleted]"}},{"kind":"more","data":{}}]}}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u string) { redditURL = u }(redditURL)
+	redditURL = server.URL
+
+	got, _, err := redditSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. [OK Computer (25 years)](" + server.URL + "/r/radiohead/comments/abc/ok_computer/) · r/radiohead · ▲ 1,234 · 456 comments"
+	if !strings.Contains(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	comments, err := fetchRedditComments(context.Background(), RedditThread{ID: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0] != "Paranoid Android is\nthe peak." {
+		t.Errorf("got comments %q", comments)
+	}
+}
+
+func TestRedditSummary(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	redditEnabled, redditSummary = true, true
+	searches := 0
+	getRedditThreads = func(ctx context.Context, info MusicInfo) ([]RedditThread, error) {
+		searches++
+		return []RedditThread{{ID: "abc", Title: "OK Computer", Subreddit: "radiohead", Score: 12, NumComments: 3, Permalink: "/r/radiohead/comments/abc/"}}, nil
+	}
+	getRedditComments = func(ctx context.Context, thread RedditThread) ([]string, error) {
+		return []string{"Paranoid Android is the peak."}, nil
+	}
+	defer func() {
+		redditEnabled, redditSummary = false, false
+		getRedditThreads = fetchRedditThreads
+		getRedditComments = fetchRedditComments
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+
+	var reddit Section
+	for _, s := range m.sections {
+		if s.key == "reddit" {
+			reddit = s
+		}
+	}
+	for _, want := range []string{"r/radiohead · ▲ 12 · 3 comments", "### What Reddit thinks", "stub answer for: Sum up", "- Paranoid Android is the peak."} {
+		if !strings.Contains(reddit.Content, want) {
+			t.Errorf("section does not include %q:\n%s", want, reddit.Content)
+		}
+	}
+	if searches != 1 {
+		t.Errorf("searched the threads %d times, the summary uses the ones of the section", searches)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxRetries is how many times a failed request is tried again, retryDelay
+// is the wait before the first retry, it doubles with every attempt.
+var (
+	maxRetries = 3
+	retryDelay = time.Second
+)
+
+// maxRetryDelay caps the exponential backoff.
+const maxRetryDelay = 30 * time.Second
+
+// statusError is an HTTP error of an API without an error type of its own.
+type statusError struct {
+	code int
+	err  error
+}
+
+func newStatusError(code int, format string, args ...any) *statusError {
+	return &statusError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+// statusCode returns the HTTP status of an API error, or 0.
+func statusCode(err error) int {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var anthropicErr *ai.AnthropicError
+	var geminiErr *ai.GeminiError
+	var aiStatusErr *ai.StatusError
+	var statusErr *statusError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		return reqErr.HTTPStatusCode
+	case errors.As(err, &anthropicErr):
+		return anthropicErr.StatusCode
+	case errors.As(err, &geminiErr):
+		return geminiErr.StatusCode
+	case errors.As(err, &aiStatusErr):
+		return aiStatusErr.StatusCode
+	case errors.As(err, &statusErr):
+		return statusErr.code
+	}
+	return 0
+}
+
+// retryable reports whether err is likely to go away, rate limits, server
+// errors, timeouts and dropped connections. A refused connection, an
+// unknown host or a certificate that does not verify are reported right
+// away, trying again gets the same.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, errOffline) {
+		return false
+	}
+
+	if code := statusCode(err); code != 0 {
+		return code == http.StatusTooManyRequests || code >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// backoff is the wait before the retry after attempt, with up to 50% of
+// jitter so parallel requests do not retry in lockstep.
+func backoff(attempt int) time.Duration {
+	delay := retryDelay << attempt
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// withRetry calls fn until it succeeds, fails with an error that is not
+// retryable or runs out of retries. Retries are counted in the progress.
+func (m *model) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxRetries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		m.whileCurrent(ctx, func() {
+			m.retries++
+			m.changed = true
+		})
+
+		delay := backoff(attempt)
+		debugLog("retry", "attempt", attempt+1, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&openai.APIError{HTTPStatusCode: 429}, true},
+		{&openai.RequestError{HTTPStatusCode: 503}, true},
+		{&ai.AnthropicError{StatusCode: 529}, true},
+		{&ai.AnthropicError{StatusCode: 400}, false},
+		{&ai.GeminiError{StatusCode: 429}, true},
+		{&ai.StatusError{StatusCode: 404}, false},
+		{newStatusError(502, "status code: %d", 502), true},
+		{newStatusError(404, "status code: %d", 404), false},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, false},
+		{&url.Error{Op: "Get", URL: "https://api.example.com", Err: &net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true}}, false},
+		{&url.Error{Op: "Post", URL: "https://api.example.com", Err: x509.UnknownAuthorityError{}}, false},
+		{&url.Error{Op: "Post", URL: "https://api.example.com", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{context.Canceled, false},
+		{errors.New("no lyrics found"), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	for err, want := range map[error]bool{
+		&openai.APIError{Code: "context_length_exceeded"}:                                                                            true,
+		&ai.AnthropicError{Type: "invalid_request_error", Message: "prompt is too long: 200001 tokens > 200000 maximum"}:             true,
+		&ai.GeminiError{Status: "INVALID_ARGUMENT", Message: "The input token count (1048577) exceeds the maximum number of tokens"}: true,
+		&ai.AnthropicError{Type: "invalid_request_error", Message: "max_tokens: must be positive"}:                                   false,
+		&ai.GeminiError{Status: "RESOURCE_EXHAUSTED", Message: "Resource has been exhausted"}:                                        false,
+	} {
+		if got := isContextLengthError(err); got != want {
+			t.Errorf("isContextLengthError(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	delay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = delay }()
+
+	calls := 0
+	err := m.withRetry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return &openai.APIError{HTTPStatusCode: 429, Message: "rate limited"}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 || m.retries != 2 {
+		t.Errorf("got error %v after %d calls and %d retries", err, calls, m.retries)
+	}
+
+	calls = 0
+	err = m.withRetry(context.Background(), func() error {
+		calls++
+		return &openai.APIError{HTTPStatusCode: 500, Message: "server error"}
+	})
+	if err == nil || calls != maxRetries+1 {
+		t.Errorf("got error %v after %d calls, want %d calls", err, calls, maxRetries+1)
+	}
+
+	if d := backoff(2); d < 2*time.Millisecond || d > 4*time.Millisecond {
+		t.Errorf("backoff(2) = %v, want 2ms to 4ms", d)
+	}
+}
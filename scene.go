@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// sceneEnabled adds the genre and era section: the tags of the album on
+// Last.fm and the year it came out on MusicBrainz, followed by the scene
+// around them written by the AI provider.
+var sceneEnabled bool
+
+// sceneTags is how many Last.fm tags name the genres.
+const sceneTags = 5
+
+var errNoScene = errors.New("no genre or era known")
+
+// sceneFacts ground the scene context, either may be missing.
+type sceneFacts struct {
+	tags []string
+	year string
+}
+
+// fetchSceneFacts reads the tags of the album, or of the track or the artist
+// when the album has none, and the year of its first release.
+func fetchSceneFacts(ctx context.Context, info MusicInfo) (sceneFacts, error) {
+	var f sceneFacts
+	release, err := getRelease(ctx, info)
+	if err != nil && !errors.Is(err, errNoRelease) {
+		return f, err
+	}
+	if err == nil {
+		f.year = release.year()
+	}
+
+	if lastfmAPIKey != "" {
+		stats, err := getLastfmStats(ctx, info)
+		if err != nil && !errors.Is(err, errNoLastfm) {
+			return f, err
+		}
+		if err == nil {
+			f.tags = stats.Tags
+			if len(f.tags) > sceneTags {
+				f.tags = f.tags[:sceneTags]
+			}
+		}
+	}
+	return f, nil
+}
+
+// decade is the decade of the year, "the 1990s".
+func (f sceneFacts) decade() string {
+	if len(f.year) != 4 {
+		return ""
+	}
+	return "the " + f.year[:3] + "0s"
+}
+
+// sceneSection lists the genres with links to their Last.fm pages and the
+// era, the scene context is grounded by the same facts. Without either the
+// section only has the scene context, it is not found when the AI provider
+// is off too.
+func sceneSection(ctx context.Context, info MusicInfo) (string, summaryPrompt, error) {
+	f, err := fetchSceneFacts(ctx, info)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(f.tags) == 0 && f.year == "" && metadataOnly() != nil {
+		return "", nil, errNoScene
+	}
+
+	var b strings.Builder
+	if len(f.tags) > 0 {
+		links := make([]string, len(f.tags))
+		for i, tag := range f.tags {
+			links[i] = fmt.Sprintf("[%s](https://www.last.fm/tag/%s)", tag, url.QueryEscape(strings.ToLower(tag)))
+		}
+		fmt.Fprintf(&b, "**Genres:** %s\n\n", strings.Join(links, ", "))
+	}
+	if f.year != "" {
+		fmt.Fprintf(&b, "**Era:** %s, %s\n\n", f.year, f.decade())
+	}
+	if metadataOnly() != nil {
+		return strings.TrimSpace(b.String()), nil, nil
+	}
+	return strings.TrimSpace(b.String()), func(context.Context) (string, error) { return sceneSummaryPrompt(info, f), nil }, nil
+}
+
+// sceneSummaryPrompt asks for the contemporaries, the influences and the
+// landmark albums of the genres and era of the album, a list of albums to
+// explore at the end.
+func sceneSummaryPrompt(info MusicInfo, f sceneFacts) string {
+	prompt := fmt.Sprintf("Give me the scene context of the album %s by %s", info.album, info.artist)
+	if f.year != "" {
+		prompt += fmt.Sprintf(", released in %s", f.year)
+	}
+	prompt += ": the genres and the era it belongs to, its contemporaries, its influences and the landmark albums of that style. Leave out a review of the album itself."
+	if len(f.tags) > 0 {
+		prompt += fmt.Sprintf(" Base the genres on these tags of its listeners: %s.", strings.Join(f.tags, ", "))
+	}
+	prompt += ` End with five albums to explore, one per line as "- Artist – Album (Year): why".`
+	return inLanguage(prompt)
+}
+
+// exploreAlbumRe is a line of the albums to explore, "- Artist – Album (1997)".
+var exploreAlbumRe = regexp.MustCompile(`(?m)^([-*] +)(?:\*\*)?([^–—\n]+?) +[–—-] +([^\n]+?)(?:\*\*)? +\((\d{4})\)`)
+
+// linkScene turns the albums to explore into YouTube searches, found by the
+// link picker with the other links of the sections.
+func linkScene(summary string) string {
+	return exploreAlbumRe.ReplaceAllStringFunc(summary, func(line string) string {
+		m := exploreAlbumRe.FindStringSubmatch(line)
+		artist, album := strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+		link := searchLink("https://www.youtube.com/results", "search_query", []string{artist, album})
+		title := strings.NewReplacer("[", "(", "]", ")").Replace(artist + " – " + album)
+		return fmt.Sprintf("%s[%s](%s) (%s)", m[1], title, link, m[4])
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSceneSection(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	sceneEnabled, lastfmAPIKey = true, "key"
+	enabledSections = map[string]bool{"scene": true}
+	reads := 0
+	getLastfmStats = func(ctx context.Context, info MusicInfo) (*LastfmStats, error) {
+		reads++
+		return &LastfmStats{Tags: []string{"alternative", "Art Rock", "90s", "british", "rock", "radiohead"}}, nil
+	}
+	defer func() {
+		sceneEnabled, lastfmAPIKey = false, ""
+		enabledSections = nil
+		getLastfmStats = fetchLastfmStats
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+
+	if len(m.sections) != 1 || m.sections[0].key != "scene" {
+		t.Fatalf("got sections %+v", m.sections)
+	}
+	content := m.sections[0].Content
+	for _, want := range []string{
+		"**Genres:** [alternative](https://www.last.fm/tag/alternative), [Art Rock](https://www.last.fm/tag/art+rock), ",
+		"**Era:** 1997, the 1990s",
+		"### Scene context",
+		"stub answer for: Give me the scene context of the album OK Computer by Radiohead, released in 1997",
+		"tags of its listeners: alternative, Art Rock, 90s, british, rock.",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("section does not include %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "radiohead)") {
+		t.Errorf("more than %d tags:\n%s", sceneTags, content)
+	}
+	if reads != 1 {
+		t.Errorf("read the tags %d times, the scene context uses the ones of the section", reads)
+	}
+
+	// Without tags nor AI provider there is nothing to show.
+	lastfmAPIKey = ""
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) { return nil, errNoRelease }
+	setMetadataOnly(&aiOff{keyEnv: "OPENAI_TOKEN"})
+	defer setMetadataOnly(nil)
+	m.getInfo(m.fetchCtx)
+	if got := m.sections[0].Content; got != "*No genre or era known for this album.*" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLinkScene(t *testing.T) {
+	for summary, want := range map[string]string{
+		"- Blur – The Great Escape (1995): Britpop at its peak": "- [Blur – The Great Escape](https://www.youtube.com/results?search_query=Blur+The+Great+Escape) (1995): Britpop at its peak",
+		"* **Portishead - Dummy** (1994): trip hop":             "* [Portishead – Dummy](https://www.youtube.com/results?search_query=Portishead+Dummy) (1994): trip hop",
+		"- Sigur Rós – Ágætis byrjun [Remaster] (1999)":         "- [Sigur Rós – Ágætis byrjun (Remaster)](https://www.youtube.com/results?search_query=Sigur+R%C3%B3s+%C3%81g%C3%A6tis+byrjun+%5BRemaster%5D) (1999)",
+		"OK Computer came out in 1997 (UK).":                    "OK Computer came out in 1997 (UK).",
+	} {
+		if got := linkScene(summary); got != want {
+			t.Errorf("linkScene(%q) = %q, want %q", summary, got, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lastfmSecret is the shared secret of the Last.fm API account, it signs the
+// scrobbles. lastfmScrobble submits the tracks shown to the account logged
+// in with -lastfm-login.
+var (
+	lastfmSecret   = os.Getenv("LASTFM_API_SECRET")
+	lastfmScrobble bool
+)
+
+// lastfmSessionKey is the session of the logged in account, it does not
+// expire.
+var lastfmSessionKey string
+
+var lastfmAuthURL = "https://www.last.fm/api/auth/"
+
+const (
+	lastfmLoginTimeout = 5 * time.Minute
+	lastfmLoginPoll    = 3 * time.Second
+)
+
+var (
+	errLastfmLogin        = errors.New("not logged in to Last.fm, run stui -lastfm-login")
+	errLastfmUnauthorized = errors.New("last.fm: the token is not authorized yet")
+)
+
+// scrobble is replaced in tests.
+var scrobble = postScrobble
+
+// lastfmSession is the session cached between runs.
+type lastfmSession struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+func lastfmSessionPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stui", "lastfm_session.json"), nil
+}
+
+func loadLastfmSession() (*lastfmSession, error) {
+	path, err := lastfmSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errLastfmLogin
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s lastfmSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *lastfmSession) save() error {
+	path, err := lastfmSessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// scrobbling reports whether the tracks are submitted to Last.fm.
+func scrobbling() bool {
+	return lastfmScrobble && lastfmAPIKey != "" && lastfmSecret != "" && lastfmSessionKey != ""
+}
+
+// lastfmSignature is the api_sig of the signed methods: the md5 of the
+// parameters sorted by name and the secret.
+func lastfmSignature(params url.Values) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if name != "format" && name != "callback" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + params.Get(name))
+	}
+	sum := md5.Sum([]byte(b.String() + lastfmSecret))
+	return hex.EncodeToString(sum[:])
+}
+
+// lastfmPost calls a signed API method and decodes its answer into v.
+func lastfmPost(ctx context.Context, method string, params url.Values, v any) error {
+	params.Set("method", method)
+	params.Set("api_key", lastfmAPIKey)
+	params.Set("api_sig", lastfmSignature(params))
+	params.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lastfmURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return lastfmDo(req, v)
+}
+
+// lastfmLogin runs the desktop authentication in the browser and caches the
+// session, it is needed once.
+func lastfmLogin() error {
+	ctx := context.Background()
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err := lastfmPost(ctx, "auth.getToken", url.Values{}, &token); err != nil {
+		return err
+	}
+
+	authURL := lastfmAuthURL + "?" + url.Values{"api_key": {lastfmAPIKey}, "token": {token.Token}}.Encode()
+	fmt.Println("Opening the browser to log in to Last.fm, allow stui and come back, if it does not open visit:")
+	fmt.Println(authURL)
+	_ = openBrowser(authURL)
+
+	// The session is there once the user allowed the token.
+	for deadline := time.Now().Add(lastfmLoginTimeout); time.Now().Before(deadline); time.Sleep(lastfmLoginPoll) {
+		var r struct {
+			Session lastfmSession `json:"session"`
+		}
+		err := lastfmPost(ctx, "auth.getSession", url.Values{"token": {token.Token}}, &r)
+		if errors.Is(err, errLastfmUnauthorized) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.Session.save(); err != nil {
+			return err
+		}
+		fmt.Printf("Logged in to Last.fm as %s\n", r.Session.Name)
+		return nil
+	}
+	return errors.New("last.fm login: timed out")
+}
+
+// postScrobble submits a scrobble, or the now playing status of the track.
+// Like the ListenBrainz listens they are best effort.
+func postScrobble(ctx context.Context, l *listen, playingNow bool) error {
+	params := url.Values{"artist": {l.info.artist}, "track": {l.info.track}, "sk": {lastfmSessionKey}}
+	if l.info.album != "" {
+		params.Set("album", l.info.album)
+	}
+	method := "track.updateNowPlaying"
+	if !playingNow {
+		method = "track.scrobble"
+		params.Set("timestamp", fmt.Sprint(l.since.Unix()))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var r json.RawMessage
+	return lastfmPost(ctx, method, params, &r)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLastfmSignature(t *testing.T) {
+	defer func(secret string) { lastfmSecret = secret }(lastfmSecret)
+	lastfmSecret = "secret"
+
+	params := url.Values{"token": {"tok"}, "method": {"auth.getSession"}, "api_key": {"key"}, "format": {"json"}}
+	if sig := lastfmSignature(params); sig != "04e870be4bb79756721b7bc1937fe83d" {
+		t.Errorf("got signature %s", sig)
+	}
+}
+
+func TestPostScrobble(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got method %s", r.Method)
+		}
+		r.ParseForm()
+		sig := r.PostForm.Get("api_sig")
+		r.PostForm.Del("api_sig")
+		if want := lastfmSignature(r.PostForm); sig != want {
+			t.Errorf("got signature %s, want %s", sig, want)
+		}
+		requests = append(requests, r.PostForm)
+		fmt.Fprint(w, `{"scrobbles":{}}`)
+	}))
+	defer server.Close()
+
+	defer func(u, key, secret, session string) {
+		lastfmURL, lastfmAPIKey, lastfmSecret, lastfmSessionKey = u, key, secret, session
+	}(lastfmURL, lastfmAPIKey, lastfmSecret, lastfmSessionKey)
+	lastfmURL, lastfmAPIKey, lastfmSecret, lastfmSessionKey = server.URL, "key", "secret", "session"
+
+	l := &listen{info: testTrack, since: time.Unix(1700000000, 0)}
+	if err := postScrobble(context.Background(), l, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := postScrobble(context.Background(), l, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests", len(requests))
+	}
+	if r := requests[0]; r.Get("method") != "track.updateNowPlaying" || r.Get("track") != "Airbag" || r.Get("album") != "OK Computer" ||
+		r.Get("sk") != "session" || r.Has("timestamp") {
+		t.Errorf("got now playing %v", r)
+	}
+	if r := requests[1]; r.Get("method") != "track.scrobble" || r.Get("artist") != "Radiohead" || r.Get("timestamp") != "1700000000" {
+		t.Errorf("got scrobble %v", r)
+	}
+}
+
+func TestListenToScrobbles(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	defer func(scrobbleOn bool, key, secret, session string) {
+		lastfmScrobble, lastfmAPIKey, lastfmSecret, lastfmSessionKey = scrobbleOn, key, secret, session
+		scrobble = postScrobble
+	}(lastfmScrobble, lastfmAPIKey, lastfmSecret, lastfmSessionKey)
+	lastfmScrobble, lastfmAPIKey, lastfmSecret, lastfmSessionKey = true, "key", "secret", "session"
+
+	var mu sync.Mutex
+	var scrobbled []string
+	var wg sync.WaitGroup
+	scrobble = func(ctx context.Context, l *listen, playingNow bool) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		kind := "scrobble"
+		if playingNow {
+			kind = "now playing"
+		}
+		scrobbled = append(scrobbled, kind+" "+l.info.track)
+		return nil
+	}
+	// ListenBrainz is not set up, only Last.fm gets the tracks.
+	submitListen = func(ctx context.Context, l *listen, playingNow bool) error {
+		t.Error("submitted to ListenBrainz without a token")
+		return nil
+	}
+	defer func() { submitListen = postListen }()
+
+	wg.Add(1)
+	m.listenTo(testTrack)
+	m.listen.since = time.Now().Add(-time.Minute)
+	wg.Add(2)
+	m.listenTo(MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"})
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(scrobbled) != 3 || !containsString(scrobbled, "scrobble Airbag") || !containsString(scrobbled, "now playing Paranoid Android") {
+		t.Errorf("got %v", scrobbled)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	styleMatch        = lipgloss.NewStyle().Reverse(true).Render
+	styleCurrentMatch = lipgloss.NewStyle().Background(lipgloss.Color("#FDFF8C")).Foreground(lipgloss.Color("0")).Render
+)
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// searchState is the / search of the viewport. line is the content line of
+// the current match, -1 before the first jump.
+type searchState struct {
+	typing bool
+	query  string
+	line   int
+}
+
+// searchLines returns the lines of content that contain query, ignoring case
+// and colors.
+func searchLines(content string, query string) []int {
+	if query == "" {
+		return nil
+	}
+
+	query = strings.ToLower(query)
+	var lines []int
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(ansiPattern.ReplaceAllString(line, "")), query) {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
+
+// highlightMatches marks the matches of query in content. Matching lines lose
+// their colors, finding the match positions in styled text is not worth it.
+func highlightMatches(content string, query string, current int) string {
+	if query == "" {
+		return content
+	}
+
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		plain := ansiPattern.ReplaceAllString(line, "")
+		if !pattern.MatchString(plain) {
+			continue
+		}
+
+		style := styleMatch
+		if i == current {
+			style = styleCurrentMatch
+		}
+		lines[i] = pattern.ReplaceAllStringFunc(plain, func(s string) string { return style(s) })
+	}
+	return strings.Join(lines, "\n")
+}
+
+// startSearch opens the search prompt.
+func (m *model) startSearch() {
+	m.search = searchState{typing: true, line: -1}
+}
+
+// clearSearch removes the query and its highlights.
+func (m *model) clearSearch() {
+	m.search = searchState{line: -1}
+	m.viewport.SetContent(m.viewportContent())
+}
+
+func (m *model) updateSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.clearSearch()
+	case tea.KeyBackspace:
+		if r := []rune(m.search.query); len(r) > 0 {
+			m.search.query = string(r[:len(r)-1])
+		}
+		m.viewport.SetContent(m.viewportContent())
+	case tea.KeyRunes, tea.KeySpace:
+		m.search.query += string(msg.Runes)
+		m.viewport.SetContent(m.viewportContent())
+	case tea.KeyEnter:
+		m.search.typing = false
+		if m.search.query == "" {
+			m.clearSearch()
+			return m, nil
+		}
+		// Start from the top of the view, like less.
+		m.search.line = m.viewport.YOffset - 1
+		m.nextMatch(1)
+	}
+	return m, nil
+}
+
+// nextMatch scrolls to the next match after the current one, or the previous
+// one when dir is negative, wrapping around the content.
+func (m *model) nextMatch(dir int) {
+	lines := searchLines(m.unhighlightedContent(), m.search.query)
+	if len(lines) == 0 {
+		m.viewport.SetContent(m.viewportContent())
+		return
+	}
+
+	next := -1
+	if dir > 0 {
+		next = lines[0]
+		for _, l := range lines {
+			if l > m.search.line {
+				next = l
+				break
+			}
+		}
+	} else {
+		next = lines[len(lines)-1]
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i] < m.search.line {
+				next = lines[i]
+				break
+			}
+		}
+	}
+
+	m.search.line = next
+	m.viewport.SetContent(m.viewportContent())
+	// Keep some context above the match.
+	offset := next - m.viewport.Height/3
+	if offset < 0 {
+		offset = 0
+	}
+	m.viewport.SetYOffset(offset)
+}
+
+// searchView is the prompt while typing and the match count afterwards.
+func (m *model) searchView() string {
+	if m.search.typing {
+		return "\n  /" + m.search.query + "█\n"
+	}
+
+	lines := searchLines(m.unhighlightedContent(), m.search.query)
+	if len(lines) == 0 {
+		return helpStyle(fmt.Sprintf("\n  %s • %s\n", trf("Pattern not found: %s", m.search.query), helpItem("Clear search", keys.ClearSearch)))
+	}
+	current := 0
+	for i, l := range lines {
+		if l == m.search.line {
+			current = i + 1
+		}
+	}
+	return helpStyle(fmt.Sprintf("\n  /%s: %d/%d • %s • %s\n", m.search.query, current, len(lines),
+		helpItem("Next/Previous match", keys.NextMatch, keys.PrevMatch), helpItem("Clear search", keys.ClearSearch)))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+func TestSearchLines(t *testing.T) {
+	content := "\x1b[1mAirbag\x1b[0m\nParanoid Android\nLucky\nthe tourist airbag"
+	if got := searchLines(content, "AIRBAG"); len(got) != 2 || got[0] != 0 || got[1] != 3 {
+		t.Errorf("got %v", got)
+	}
+
+	highlighted := highlightMatches(content, "lucky", 2)
+	if !strings.HasPrefix(highlighted, "\x1b[1mAirbag") {
+		t.Errorf("lines without matches changed: %q", highlighted)
+	}
+	if strings.Split(highlighted, "\n")[2] != styleCurrentMatch("Lucky") {
+		t.Errorf("current match is not highlighted: %q", highlighted)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.width, m.height = 100, 12
+	m.getInfo(context.Background())
+	m.loading = false
+	m.sections[0].Content = strings.Repeat("OK Computer was recorded in 1996.\n\nIt was produced by Nigel Godrich.\n\n", 5)
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []player.Action
+	controlPlayer = func(a player.Action) { actions = append(actions, a) }
+
+	m.Update(keyRune('/'))
+	for _, r := range "computer" {
+		m.Update(keyRune(r))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	lines := searchLines(m.unhighlightedContent(), "computer")
+	if len(lines) < 2 {
+		t.Fatalf("got %d matching lines, the test needs more", len(lines))
+	}
+	if m.search.line != lines[0] {
+		t.Errorf("at line %d, want %d", m.search.line, lines[0])
+	}
+
+	m.Update(keyRune('n'))
+	if m.search.line != lines[1] {
+		t.Errorf("at line %d after n, want %d", m.search.line, lines[1])
+	}
+	m.Update(keyRune('N'))
+	m.Update(keyRune('N'))
+	if m.search.line != lines[len(lines)-1] {
+		t.Errorf("at line %d after wrapping back, want %d", m.search.line, lines[len(lines)-1])
+	}
+	if !strings.Contains(m.View(), "n/N") {
+		t.Error("match count is not shown")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.search.query != "" {
+		t.Error("search was not cleared")
+	}
+	if _, cmd := m.Update(keyRune('n')); cmd == nil {
+		t.Error("n does not skip the track once the search is cleared")
+	}
+	if len(actions) != 0 {
+		t.Errorf("n moved the player while searching: %v", actions)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// serveAddr is where stui serve listens, keep it on localhost unless the
+// network is trusted: the API has no authentication.
+var serveAddr = "localhost:8765"
+
+// apiHistoryLimit is how many tracks /history returns when the request does
+// not set limit.
+const apiHistoryLimit = 50
+
+var statusNames = map[PlayerStatus]string{
+	PlayerPlaying:     "playing",
+	PlayerIdle:        "idle",
+	PlayerUnavailable: "unavailable",
+}
+
+// server is stui serve: it follows the player like watch mode and publishes
+// the info of the current track over HTTP instead of drawing it.
+type server struct {
+	m *model
+
+	mu sync.Mutex
+	// status is the player status of the last poll, the info of the last
+	// track is kept while nothing plays.
+	status PlayerStatus
+	// pending is the new track waiting for the debounce since pendingSince.
+	pending      MusicInfo
+	pendingSince time.Time
+}
+
+func newServer(m *model) *server {
+	return &server{m: m, status: m.status}
+}
+
+// serve fetches the current track, then polls the player and answers the
+// API requests until the listener fails.
+func serve(m *model, addr string) error {
+	s := newServer(m)
+	if m.status == PlayerPlaying {
+		s.fetch(m.MusicInfo)
+	}
+
+	goSafe(func() {
+		for {
+			time.Sleep(pollInterval)
+			info, status := getTrackInfo()
+			s.poll(info, status, time.Now())
+		}
+	})
+
+	fmt.Printf("Serving the info of the playing track on http://%s, the API is at /now, /sections and /history\n", addr)
+	return http.ListenAndServe(addr, s.handler())
+}
+
+// poll fetches info once it kept playing for the debounce period.
+func (s *server) poll(info MusicInfo, status PlayerStatus, now time.Time) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+
+	s.m.mu.Lock()
+	current, shown := s.m.MusicInfo, s.m.status == PlayerPlaying
+	s.m.mu.Unlock()
+
+	if status != PlayerPlaying || (info.artist == "" && !info.isPodcast()) || (shown && info == current) {
+		s.mu.Lock()
+		s.pending = MusicInfo{}
+		s.mu.Unlock()
+		return
+	}
+
+	s.mu.Lock()
+	if info != s.pending {
+		s.pending, s.pendingSince = info, now
+	}
+	settled := now.Sub(s.pendingSince) >= debounce
+	s.mu.Unlock()
+
+	if settled {
+		s.fetch(info)
+		if s.m.notify || len(webhooks) > 0 {
+			s.m.notifyTrackChange(s.m.fetchCtx, info)
+		}
+	}
+}
+
+// fetch replaces the track and fetches its info in the background.
+func (s *server) fetch(info MusicInfo) {
+	s.mu.Lock()
+	s.pending = MusicInfo{}
+	s.mu.Unlock()
+
+	m := s.m
+	m.listenTo(info)
+	m.reset(info)
+	ctx := m.newFetch()
+	goSafe(func() {
+		m.getInfoWithHooks(ctx)
+		m.whileCurrent(ctx, func() { m.loading = false })
+	})
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/now", s.handleNow)
+	mux.HandleFunc("/sections", s.handleSections)
+	mux.HandleFunc("/history", s.handleHistory)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/", s.handlePage)
+	return mux
+}
+
+// nowResponse is the track being shown and how far its fetch got.
+type nowResponse struct {
+	Status    string `json:"status"`
+	Loading   bool   `json:"loading"`
+	Steps     int    `json:"steps"`
+	StepsDone int    `json:"steps_done"`
+	Result
+}
+
+func (s *server) now() nowResponse {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+
+	m := s.m
+	m.mu.Lock()
+	now := nowResponse{Status: statusNames[status], Loading: m.loading, Steps: m.steps, StepsDone: m.stepsDone}
+	m.mu.Unlock()
+	now.Result = m.result()
+	return now
+}
+
+func (s *server) handleNow(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	writeJSON(w, s.now())
+}
+
+func (s *server) handleSections(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	writeJSON(w, s.m.result().Sections)
+}
+
+// historyTrack is a track of the history in the API.
+type historyTrack struct {
+	ID        int64     `json:"id"`
+	Artist    string    `json:"artist"`
+	Album     string    `json:"album"`
+	Track     string    `json:"track"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Plays     int       `json:"plays"`
+}
+
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	if history == nil {
+		http.Error(w, "the history is disabled, enable it with -history", http.StatusNotFound)
+		return
+	}
+
+	limit := apiHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit "+strconv.Quote(v), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	recent, err := history.Recent(ctx, limit)
+	if err != nil {
+		http.Error(w, "history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tracks := []historyTrack{}
+	for _, t := range recent {
+		tracks = append(tracks, historyTrack(t))
+	}
+	writeJSON(w, tracks)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestServePoll(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(d time.Duration) { debounce = d }(debounce)
+	debounce = 5 * time.Second
+
+	s := newServer(m)
+	s.fetch(testTrack)
+
+	next := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	start := time.Now()
+	s.poll(next, PlayerPlaying, start)
+	if m.MusicInfo != testTrack {
+		t.Fatal("the new track replaced the current one before the debounce")
+	}
+
+	// Pausing keeps the last track.
+	s.poll(MusicInfo{}, PlayerIdle, start.Add(time.Second))
+	if m.MusicInfo != testTrack || s.status != PlayerIdle {
+		t.Fatalf("got %+v, status %d", m.MusicInfo, s.status)
+	}
+
+	s.poll(next, PlayerPlaying, start.Add(2*time.Second))
+	s.poll(next, PlayerPlaying, start.Add(8*time.Second))
+	if m.MusicInfo != next {
+		t.Errorf("got %+v, want %+v", m.MusicInfo, next)
+	}
+
+	// Let the fetch finish before the stubs are restored.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		loading := m.loading
+		m.mu.Unlock()
+		if !loading || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeAPI(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+
+	srv := httptest.NewServer(newServer(m).handler())
+	defer srv.Close()
+
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if v != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	var now struct {
+		Status   string    `json:"status"`
+		Loading  bool      `json:"loading"`
+		Track    string    `json:"track"`
+		Sections []Section `json:"sections"`
+	}
+	get("/now", &now)
+	if now.Status != "playing" || now.Loading || now.Track != "Airbag" || len(now.Sections) != 7 {
+		t.Errorf("got %+v", now)
+	}
+
+	var sections []Section
+	get("/sections", &sections)
+	if len(sections) != 7 || sections[0].Title != "Album info" {
+		t.Errorf("got sections %+v", sections)
+	}
+
+	if code := get("/history", nil); code != http.StatusNotFound {
+		t.Errorf("got status %d without a history", code)
+	}
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+	m.saveHistory(testTrack)
+
+	var tracks []historyTrack
+	get("/history?limit=5", &tracks)
+	if len(tracks) != 1 || tracks[0].Track != "Airbag" || tracks[0].Plays != 1 {
+		t.Errorf("got history %+v", tracks)
+	}
+	if code := get("/history?limit=x", nil); code != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid limit", code)
+	}
+
+	resp, err := http.Post(srv.URL+"/now", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d for POST", resp.StatusCode)
+	}
+}
+
+func TestServeWebPage(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+
+	srv := httptest.NewServer(newServer(m).handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(page), `new EventSource("/events")`) {
+		t.Errorf("got page %s", page)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state webState
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Track != "Airbag" || len(state.Sections) != 7 {
+		t.Fatalf("got %+v", state)
+	}
+	if got := state.Sections[0].HTML; !strings.HasPrefix(got, "<p>stub answer for: Give me album info") {
+		t.Errorf("got html %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resumeEnabled saves the session on exit and shows it again at start while
+// its track still plays, instead of asking for the sections again.
+var resumeEnabled = true
+
+// sessionPath returns the file of the last session, empty when there is no
+// cache directory.
+var sessionPath = func() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stui", "session.json")
+}
+
+// session is what was on screen when stui quit: the track, its sections and
+// where they were read.
+type session struct {
+	Track    sessionTrack `json:"track"`
+	Compare  sessionTrack `json:"compare"`
+	DeepDive bool         `json:"deepDive,omitempty"`
+	// Model wrote the sections, another one asks again.
+	Model    string           `json:"model"`
+	Sections []sessionSection `json:"sections"`
+	Summary  *Summary         `json:"summary,omitempty"`
+	// Tab is the selected tab, Offsets the scroll position of each tab.
+	Tab       int         `json:"tab"`
+	TabChosen bool        `json:"tabChosen,omitempty"`
+	Offsets   map[int]int `json:"offsets,omitempty"`
+	Saved     time.Time   `json:"saved"`
+}
+
+type sessionTrack struct {
+	Artist   string   `json:"artist"`
+	Featured []string `json:"featured,omitempty"`
+	Album    string   `json:"album"`
+	Track    string   `json:"track"`
+}
+
+type sessionSection struct {
+	Key     string `json:"key"`
+	Title   string `json:"title"`
+	Prompt  string `json:"prompt,omitempty"`
+	Content string `json:"content"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newSessionTrack(info MusicInfo) sessionTrack {
+	return sessionTrack{Artist: info.artist, Featured: info.artists()[1:], Album: info.album, Track: info.track}
+}
+
+func (t sessionTrack) info() MusicInfo {
+	return MusicInfo{artist: t.Artist, album: t.Album, track: t.Track, featured: strings.Join(t.Featured, artistSep)}
+}
+
+// saveSession writes the sections of the track shown to sessionPath. Nothing
+// is written while they load, the last session is kept then.
+func (m *model) saveSession() error {
+	path := sessionPath()
+	if path == "" || m.status != PlayerPlaying || m.loading || m.query {
+		return nil
+	}
+
+	m.mu.Lock()
+	s := session{
+		Track:     newSessionTrack(m.MusicInfo),
+		Compare:   newSessionTrack(m.compare),
+		DeepDive:  m.deepDive,
+		Model:     chatModel,
+		Summary:   m.summary,
+		Tab:       m.tab,
+		TabChosen: m.tabChosen,
+		Offsets:   map[int]int{},
+		Saved:     time.Now(),
+	}
+	for _, section := range m.sections {
+		s.Sections = append(s.Sections, sessionSection{
+			Key:     section.key,
+			Title:   section.Title,
+			Prompt:  section.prompt,
+			Content: section.Content,
+			Error:   section.Error,
+		})
+	}
+	m.mu.Unlock()
+	if len(s.Sections) == 0 {
+		return nil
+	}
+	for tab, offset := range m.tabOffsets {
+		s.Offsets[tab] = offset
+	}
+	s.Offsets[m.tab] = m.viewport.YOffset
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// loadSession reads the last session, nil when there is none. A session
+// older than cacheTTL is not used, its answers would be asked again too.
+func loadSession() (*session, error) {
+	path := sessionPath()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if time.Since(s.Saved) > cacheTTL && !offline {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// resumes reports whether s is the session of what m is about to show, the
+// same track looked up the same way with the same model.
+func (s *session) resumes(m *model) bool {
+	return s != nil && s.Track.info() == m.MusicInfo && s.Compare.info() == m.compare &&
+		s.DeepDive == m.deepDive && s.Model == chatModel
+}
+
+// resume shows the sections of s without asking for them. The artwork and
+// the lyrics are not kept, they are fetched again and are the steps left of
+// the progress.
+func (m *model) resume(s *session) {
+	debugLog("fetch", "artist", m.artist, "album", m.album, "track", m.track, "resumed", s.Saved)
+	info := m.MusicInfo
+	ctx := m.newFetch()
+	fetchArtwork := (artworkMode != "off" || artworkTheme) && info.album != "" && !offline
+	fetchLyrics := info.track != "" && !info.isPodcast() && sectionEnabled("lyrics")
+
+	m.mu.Lock()
+	m.steps, m.stepsDone = 1, 1
+	for _, extra := range []bool{fetchArtwork, fetchLyrics} {
+		if extra {
+			m.steps++
+		}
+	}
+	m.sections = make([]Section, len(s.Sections))
+	for i, section := range s.Sections {
+		m.sections[i] = Section{
+			Title:   section.Title,
+			Content: section.Content,
+			Error:   section.Error,
+			key:     section.Key,
+			prompt:  section.Prompt,
+		}
+	}
+	m.summary = s.Summary
+	m.links = buildLinks(info)
+	m.buildContent()
+	m.mu.Unlock()
+
+	m.tab, m.tabChosen = s.Tab, s.TabChosen
+	// The file may be edited or from a version with other tabs.
+	if m.tab < 0 || m.tab >= m.tabCount() {
+		m.tab, m.tabChosen = 0, false
+	}
+	m.tabOffsets = map[int]int{}
+	for tab, offset := range s.Offsets {
+		m.tabOffsets[tab] = offset
+	}
+	if err := m.renderViewport(); err != nil {
+		m.errMsg = "  " + err.Error()
+	}
+	// The viewport has no size before the first resize, which would clamp the
+	// offset, renderViewport scrolls to it then.
+	m.viewport.YOffset = m.tabOffsets[m.tab]
+
+	if fetchArtwork {
+		m.goFetch(func() {
+			defer m.stepDone(ctx)
+			m.fetchTrackArtwork(ctx, info)
+		})
+	}
+	if fetchLyrics {
+		m.goFetch(func() {
+			defer m.stepDone(ctx)
+			m.fetchTrackLyrics(ctx, info)
+		})
+	}
+}
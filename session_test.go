@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumeSession(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	path := filepath.Join(t.TempDir(), "session.json")
+	defer func(p func() string) { sessionPath = p }(sessionPath)
+	sessionPath = func() string { return path }
+
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 20
+	if err := m.selectTab(1); err != nil {
+		t.Fatal(err)
+	}
+	m.viewport.YOffset = 2
+	m.tabOffsets[0] = 1
+	if err := m.saveSession(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := loadSession()
+	if err != nil || s == nil {
+		t.Fatalf("got %v, %v", s, err)
+	}
+	m = setupTest(t, PlayerPlaying)
+	completer = offlineCompleter{t}
+	if !s.resumes(m) {
+		t.Fatal("the session of the track playing is not resumed")
+	}
+	m.resume(s)
+	if got := m.sections[1]; got.Content != "stub answer for: Give me album review of Radiohead OK Computer" || got.key != "review" {
+		t.Errorf("got section %+v", got)
+	}
+	if m.tab != 1 || m.tabOffsets[0] != 1 || m.viewport.YOffset != 2 {
+		t.Errorf("got tab %d, offsets %v and %d", m.tab, m.tabOffsets, m.viewport.YOffset)
+	}
+	if m.links == nil {
+		t.Error("the links are missing")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		done := m.stepsDone >= m.steps && m.lyricsDone
+		m.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the lyrics were not fetched")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	waitFetch(m)
+	s.Tab = 42
+	m.resume(s)
+	if m.tab != 0 || m.tabChosen {
+		t.Errorf("got tab %d of %d", m.tab, m.tabCount())
+	}
+	waitFetch(m)
+
+	other := setupTest(t, PlayerPlaying)
+	other.MusicInfo = MusicInfo{artist: "Radiohead", album: "Kid A", track: "Idioteque"}
+	if s.resumes(other) {
+		t.Error("the session of another track is resumed")
+	}
+	defer func(model string) { chatModel = model }(chatModel)
+	chatModel = "another-model"
+	if s.resumes(m) {
+		t.Error("the session of another model is resumed")
+	}
+}
+
+func TestSaveSessionWhileLoading(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	path := filepath.Join(t.TempDir(), "session.json")
+	defer func(p func() string) { sessionPath = p }(sessionPath)
+	sessionPath = func() string { return path }
+
+	m.getInfo(context.Background())
+	if err := m.saveSession(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("a session still loading was saved: %v", err)
+	}
+}
+
+func TestLoadSessionExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	defer func(p func() string) { sessionPath = p }(sessionPath)
+	sessionPath = func() string { return path }
+
+	if s, err := loadSession(); s != nil || err != nil {
+		t.Errorf("got %v, %v without a session", s, err)
+	}
+	old := `{"track":{"artist":"Radiohead","album":"OK Computer","track":"Airbag"},"saved":"2001-01-01T00:00:00Z"}`
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := loadSession(); s != nil || err != nil {
+		t.Errorf("got %v, %v for a session older than the cache", s, err)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/ai"
+	"golang.org/x/term"
+)
+
+// setupTimeout is how long the test call of the setup waits for the AI
+// provider.
+const setupTimeout = 30 * time.Second
+
+// setup asks the questions of stui config init on a terminal, a blank answer
+// keeps the default shown in brackets.
+type setup struct {
+	in  *bufio.Reader
+	out io.Writer
+	// secret reads a key without echoing it, a plain line when the input is
+	// not a terminal.
+	secret func() (string, error)
+}
+
+func newSetup(in io.Reader, out io.Writer) *setup {
+	s := &setup{in: bufio.NewReader(in), out: out}
+	s.secret = s.line
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		s.secret = func() (string, error) {
+			b, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(out)
+			return strings.TrimSpace(string(b)), err
+		}
+	}
+	return s
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (s *setup) line() (string, error) {
+	line, err := s.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+func (s *setup) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(s.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(s.out, "%s: ", question)
+	}
+	answer, err := s.line()
+	if answer == "" {
+		answer = def
+	}
+	return answer, err
+}
+
+// askSecret asks for a key, a blank answer keeps the one already set.
+func (s *setup) askSecret(question, current string) (string, error) {
+	if current != "" {
+		question += " (blank keeps the one set)"
+	}
+	fmt.Fprintf(s.out, "%s: ", question)
+	answer, err := s.secret()
+	if answer == "" {
+		answer = current
+	}
+	return answer, err
+}
+
+// choose asks until the answer is one of options.
+func (s *setup) choose(question string, options []string, def string) (string, error) {
+	for {
+		answer, err := s.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		if containsString(options, answer) {
+			return answer, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(s.out, "  %q is not one of them\n", answer)
+	}
+}
+
+func (s *setup) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Fprintf(s.out, "%s [%s]: ", question, hint)
+	answer, err := s.line()
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, err
+	case "n", "no":
+		return false, err
+	}
+	return def, err
+}
+
+// setting is a line of the config file written by the setup.
+type setting struct {
+	key   string
+	value string
+}
+
+// runSetup asks for the AI provider and its key, the language, the theme and
+// the Spotify Web API client, tests the provider with a call and writes the
+// config file to path. It does not replace an existing file.
+func runSetup(path string, in io.Reader, out io.Writer) error {
+	if path == "" {
+		return errors.New("config init: no config path, pass -config")
+	}
+	path = expandHome(path)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("config init: %s already exists", path)
+	}
+	s := newSetup(in, out)
+	fmt.Fprintf(out, "Setting up stui, it writes %s.\n\n", path)
+
+	var settings []setting
+	for {
+		var err error
+		settings, err = s.askProvider(filepath.Dir(path))
+		if err != nil {
+			return fmt.Errorf("config init: %w", err)
+		}
+		fmt.Fprintf(out, "Testing %s…\n", provider)
+		err = testProvider()
+		if err == nil {
+			fmt.Fprintln(out, "  it answered")
+			break
+		}
+		fmt.Fprintf(out, "  %s api: %v\n", provider, err)
+		again, rerr := s.confirm("Enter the settings again?", true)
+		if rerr != nil {
+			return fmt.Errorf("config init: %w", rerr)
+		}
+		if !again {
+			break
+		}
+	}
+
+	more, err := s.askPreferences()
+	if err != nil {
+		return fmt.Errorf("config init: %w", err)
+	}
+	settings = append(settings, more...)
+	if err := writeSetup(path, settings); err != nil {
+		return fmt.Errorf("config init: %w", err)
+	}
+	fmt.Fprintln(out, "\nWrote", path)
+
+	if spotifyClientID != "" {
+		login, err := s.confirm("Log in to Spotify now?", true)
+		if err != nil || !login {
+			fmt.Fprintln(out, "Run stui -spotify-login to log in later")
+			return nil
+		}
+		if err := spotifyLogin(spotifyClientID); err != nil {
+			fmt.Fprintln(out, "spotify:", err)
+			fmt.Fprintln(out, "Run stui -spotify-login to try again")
+		}
+	}
+	return nil
+}
+
+// askProvider asks for the provider and what it needs to connect, and sets
+// them for the test call. The OpenAI token goes to a file of dir.
+func (s *setup) askProvider(dir string) ([]setting, error) {
+	name, err := s.choose("AI provider", providerNames(), provider)
+	if err != nil {
+		return nil, err
+	}
+	provider = name
+	settings := []setting{{"provider", name}}
+
+	switch name {
+	case "openai":
+		current, err := openaiToken()
+		if err != nil {
+			current = ""
+		}
+		token, err := s.askSecret("OpenAI token", current)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" && token != os.Getenv("OPENAI_TOKEN") {
+			tokenFile = filepath.Join(dir, "openai_token")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(tokenFile, []byte(token+"\n"), 0o600); err != nil {
+				return nil, err
+			}
+			settings = append(settings, setting{"token_file", tokenFile})
+		}
+	case "anthropic":
+		if anthropicAPIKey, err = s.askSecret("Anthropic API key", anthropicAPIKey); err != nil {
+			return nil, err
+		}
+		settings = appendKey(settings, "anthropic_api_key", anthropicAPIKey, "ANTHROPIC_API_KEY")
+	case "gemini":
+		if geminiAPIKey, err = s.askSecret("Google API key", geminiAPIKey); err != nil {
+			return nil, err
+		}
+		settings = appendKey(settings, "google_api_key", geminiAPIKey, "GOOGLE_API_KEY")
+	case "openrouter":
+		if openrouterAPIKey, err = s.askSecret("OpenRouter API key", openrouterAPIKey); err != nil {
+			return nil, err
+		}
+		settings = appendKey(settings, "openrouter_api_key", openrouterAPIKey, "OPENROUTER_API_KEY")
+	case "azure":
+		if azureEndpoint, err = s.ask("Azure OpenAI endpoint, such as https://my-resource.openai.azure.com", azureEndpoint); err != nil {
+			return nil, err
+		}
+		if azureDeployment, err = s.ask("Deployment of the model (blank uses the model name)", azureDeployment); err != nil {
+			return nil, err
+		}
+		if azureAPIKey, err = s.askSecret("Azure OpenAI API key", azureAPIKey); err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting{"azure_endpoint", azureEndpoint})
+		if azureDeployment != "" {
+			settings = append(settings, setting{"azure_deployment", azureDeployment})
+		}
+		settings = appendKey(settings, "azure_api_key", azureAPIKey, "AZURE_OPENAI_API_KEY")
+	case "ollama":
+		if ollamaURL, err = s.ask("URL of the Ollama server", ollamaURL); err != nil {
+			return nil, err
+		}
+		if ollamaURL != ai.OllamaDefaultURL {
+			settings = append(settings, setting{"ollama_url", ollamaURL})
+		}
+	}
+	return settings, nil
+}
+
+// appendKey adds the key to the settings unless it comes from env, the
+// environment keeps providing it.
+func appendKey(settings []setting, name, key, env string) []setting {
+	if key == "" || key == os.Getenv(env) {
+		return settings
+	}
+	return append(settings, setting{name, key})
+}
+
+// testProvider sends a short prompt to the provider set up.
+func testProvider() error {
+	p, defaultModel, err := newProvider(provider)
+	if err != nil {
+		return err
+	}
+	name := chatModel
+	if name == "" {
+		name = defaultModel
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	_, err = p.Complete(ctx, name, "Answer with the word OK.")
+	return err
+}
+
+// askPreferences asks for the language of the interface and of the answers,
+// the theme and the Spotify Web API client.
+func (s *setup) askPreferences() ([]setting, error) {
+	var settings []setting
+	loc, err := s.choose("Language of the interface", localeNames(), locale)
+	if err != nil {
+		return nil, err
+	}
+	if locale = loc; loc != "auto" {
+		settings = append(settings, setting{"locale", loc})
+	}
+	if language, err = s.ask("Language of the AI sections (blank for English)", language); err != nil {
+		return nil, err
+	}
+	if language != "" {
+		settings = append(settings, setting{"language", language})
+	}
+	theme, err := s.choose("Theme", themeNames(), themeName)
+	if err != nil {
+		return nil, err
+	}
+	if themeName = theme; theme != "auto" {
+		settings = append(settings, setting{"theme", theme})
+	}
+
+	fmt.Fprintln(s.out, "\nA Spotify Web API client adds the playback controls, the queue and the playlists.")
+	fmt.Fprintln(s.out, "Create one in https://developer.spotify.com/dashboard with the redirect URI "+spotifyRedirectURL+".")
+	if spotifyClientID, err = s.ask("Spotify client ID (blank to skip)", spotifyClientID); err != nil {
+		return nil, err
+	}
+	if spotifyClientID != "" {
+		settings = append(settings, setting{"spotify_client_id", spotifyClientID})
+	}
+	return settings, nil
+}
+
+// writeSetup writes the settings to a new config file, only readable by the
+// user as it may hold the API keys.
+func writeSetup(path string, settings []setting) error {
+	var b strings.Builder
+	b.WriteString("# stui settings, the command line flags override them.\n")
+	b.WriteString("# Every setting is described in https://github.com/ernesto27/stui#config\n\n")
+	for _, s := range settings {
+		fmt.Fprintf(&b, "%s = %q\n", s.key, s.value)
+	}
+	return writeSecret(path, b.String())
+}
+
+func writeSecret(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// needsSetup reports whether stui runs for the first time on a terminal: no
+// config file and no key for the provider.
+func needsSetup(path string, explicit bool) bool {
+	if explicit || path == "" || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return false
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	f := providers[provider]
+	return f.hasKey != nil && !f.hasKey()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+func TestRunSetup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"OK"},"done":true}`)
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"error":"model \"llama3\" not found"}`)
+	}))
+	defer failing.Close()
+
+	defer func(p, u, l, lang, theme, id, m string) {
+		provider, ollamaURL, locale, language, themeName, spotifyClientID, chatModel = p, u, l, lang, theme, id, m
+	}(provider, ollamaURL, locale, language, themeName, spotifyClientID, chatModel)
+	provider, ollamaURL, locale, language, themeName, spotifyClientID, chatModel = "openai", ai.OllamaDefaultURL, "auto", "", "auto", "", ""
+
+	path := filepath.Join(t.TempDir(), "stui", "config.toml")
+	answers := []string{
+		"llama", "ollama", failing.URL, // an unknown provider is asked again
+		"", "ollama", server.URL, // the failed test call offers to enter them again
+		"es", "Spanish", "",
+		"",
+	}
+	var out strings.Builder
+	if err := runSetup(path, strings.NewReader(strings.Join(answers, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"llama" is not one of them`, `ollama api: model "llama3" not found`, "it answered", "Wrote " + path} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the output has no %q:\n%s", want, out.String())
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("provider = \"ollama\"\nollama_url = %q\nlocale = \"es\"\nlanguage = \"Spanish\"\n", server.URL)
+	if !strings.HasSuffix(string(data), want) {
+		t.Errorf("got config:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("got %v: %v", info.Mode(), err)
+	}
+	if _, err := loadConfig(path, true); err != nil {
+		t.Errorf("the config written does not load: %v", err)
+	}
+
+	if err := runSetup(path, strings.NewReader(""), &out); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got %v for an existing config", err)
+	}
+}
+
+func TestLoadConfigAPIKeys(t *testing.T) {
+	defer func(a, g string) { anthropicAPIKey, geminiAPIKey = a, g }(anthropicAPIKey, geminiAPIKey)
+	anthropicAPIKey, geminiAPIKey = "", "from the environment"
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("anthropic_api_key = \"sk-ant\"\ngoogle_api_key = \"AIza\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.apply()
+	if anthropicAPIKey != "sk-ant" || geminiAPIKey != "from the environment" {
+		t.Errorf("got keys %q and %q", anthropicAPIKey, geminiAPIKey)
+	}
+}
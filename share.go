@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// shareFormat is the file of the now playing card, svg for an image or ansi
+// for the card as the terminal draws it.
+var shareFormat = "svg"
+
+// shareBlurbWidth is how many characters a line of the blurb has.
+const shareBlurbWidth = 46
+
+type shareMsg struct {
+	path string
+	err  error
+}
+
+// shareCard is what the now playing card shows.
+type shareCard struct {
+	info  MusicInfo
+	blurb string
+	cover image.Image
+}
+
+// shareFileName is the card file in the export directory, named after the
+// track like the export.
+func shareFileName(info MusicInfo, format string) string {
+	name := strings.TrimSuffix(exportFileName(info, "markdown"), ".md")
+	if format == "ansi" {
+		return name + " - card.ans"
+	}
+	return name + " - card.svg"
+}
+
+// share writes the now playing card of the track, the result comes back as a
+// shareMsg. The blurb is the one line summary of the journal, so with the
+// journal it comes from the cache.
+func (m *model) share() tea.Cmd {
+	m.mu.Lock()
+	card := shareCard{info: m.MusicInfo, cover: m.artwork}
+	m.mu.Unlock()
+
+	m.notice = "  " + tr("Writing the card")
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		prompt := journalSummaryPrompt(card.info)
+		key := cacheKey(card.info, trackScope, chatModel, prompt)
+		answer, ok := cachedAnswer(ctx, key)
+		if !ok && !offline {
+			// Without the provider the card has no blurb.
+			var err error
+			if answer, err = m.complete(ctx, chatModel, prompt); err == nil {
+				writeCache(key, answer)
+			}
+		}
+		card.blurb, _, _ = strings.Cut(strings.TrimSpace(answer), "\n")
+
+		path, err := card.write(shareFormat)
+		return shareMsg{path: path, err: err}
+	}
+}
+
+func (m *model) shared(msg shareMsg) {
+	if msg.err != nil {
+		m.notice = ""
+		m.errMsg = "  share: " + msg.err.Error()
+		return
+	}
+	m.notice = "  " + trf("Saved to %s", msg.path)
+}
+
+// write saves the card to the export directory and returns its path.
+func (c shareCard) write(format string) (string, error) {
+	var data []byte
+	if format == "ansi" {
+		data = []byte(c.ansi() + "\n")
+	} else {
+		var err error
+		if data, err = c.svg(); err != nil {
+			return "", err
+		}
+	}
+
+	dir := expandHome(exportDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, shareFileName(c.info, format))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// wrapWords breaks s into lines of up to width characters, a longer word
+// gets a line of its own.
+func wrapWords(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && len([]rune(line+" "+word)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// svg is the card as an image of 640x240 with the cover embedded as a PNG.
+func (c shareCard) svg() ([]byte, error) {
+	escape := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="640" height="240" viewBox="0 0 640 240">` + "\n")
+	b.WriteString(`<rect width="640" height="240" rx="16" fill="#16161e"/>` + "\n")
+	if c.cover != nil {
+		data, err := encodePNG(resizeImage(c.cover, 400, 400))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, `<image x="20" y="20" width="200" height="200" href="data:image/png;base64,%s"/>`+"\n", base64.StdEncoding.EncodeToString(data))
+	} else {
+		fmt.Fprintf(&b, `<rect x="20" y="20" width="200" height="200" rx="8" fill="%s"/>`+"\n", progressColors[0])
+	}
+
+	text := func(y int, size int, color string, weight string, s string) {
+		fmt.Fprintf(&b, `<text x="244" y="%d" font-family="sans-serif" font-size="%d" font-weight="%s" fill="%s">%s</text>`+"\n", y, size, weight, color, escape(s))
+	}
+	text(44, 13, progressColors[0], "bold", strings.ToUpper(tr("Now playing")))
+	text(76, 24, "#ffffff", "bold", c.info.track)
+	subtitle := c.info.artist
+	if c.info.album != "" {
+		subtitle += " · " + c.info.album
+	}
+	text(102, 16, "#c0c0c0", "normal", subtitle)
+	y := 136
+	for i, line := range wrapWords(c.blurb, shareBlurbWidth) {
+		if i == 4 {
+			break
+		}
+		text(y, 14, "#a0a0a0", "normal", line)
+		y += 20
+	}
+	text(220, 11, "#606060", "normal", "stui")
+	b.WriteString("</svg>\n")
+	return b.Bytes(), nil
+}
+
+// ansi is the card as the terminal draws it, with the cover in half blocks.
+// The colors are written as true color whatever the terminal of stui is.
+func (c shareCard) ansi() string {
+	r := lipgloss.NewRenderer(&bytes.Buffer{})
+	r.SetColorProfile(termenv.TrueColor)
+
+	lines := []string{
+		r.NewStyle().Foreground(lipgloss.Color(progressColors[0])).Bold(true).Render(strings.ToUpper(tr("Now playing"))),
+		"",
+		r.NewStyle().Bold(true).Render(c.info.track),
+		c.info.artist,
+	}
+	if c.info.album != "" {
+		lines = append(lines, r.NewStyle().Italic(true).Render(c.info.album))
+	}
+	lines = append(lines, "")
+	for _, line := range wrapWords(c.blurb, shareBlurbWidth) {
+		lines = append(lines, r.NewStyle().Faint(true).Render(line))
+	}
+	text := strings.Join(lines, "\n")
+
+	if c.cover != nil {
+		rows := len(lines)
+		if rows < 8 {
+			rows = 8
+		}
+		text = lipgloss.JoinHorizontal(lipgloss.Top, halfBlock(r, c.cover, rows*2, rows), "   ", text)
+	}
+	return r.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(borderColor).Padding(1, 2).Render(text)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShare(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	cover := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	cover.Set(0, 0, color.RGBA{R: 200, A: 255})
+	m.artwork = cover
+	m.mu.Unlock()
+
+	dir, format := exportDir, shareFormat
+	defer func() { exportDir, shareFormat = dir, format }()
+	exportDir = filepath.Join(t.TempDir(), "exports")
+
+	for _, tt := range []struct {
+		format string
+		file   string
+		want   []string
+	}{
+		{"svg", "Radiohead - OK Computer - Airbag - card.svg", []string{"<svg ", ">Airbag</text>", ">Radiohead · OK Computer</text>", "data:image/png;base64,", ">stub answer for: In one sentence, describe the</text>"}},
+		{"ansi", "Radiohead - OK Computer - Airbag - card.ans", []string{"Airbag", "OK Computer", "▀", "\x1b[", "stub answer for:"}},
+	} {
+		shareFormat = tt.format
+		_, cmd := m.Update(keyRune('E'))
+		if cmd == nil {
+			t.Fatal("E did not write the card")
+		}
+		m.Update(cmd())
+		path := filepath.Join(exportDir, tt.file)
+		if m.notice != "  Saved to "+path {
+			t.Fatalf("got notice %q, error %q", m.notice, m.errMsg)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("the %s card does not include %q:\n%s", tt.format, want, data)
+			}
+		}
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	got := wrapWords("a landmark of art rock   from 1997", 10)
+	want := []string{"a landmark", "of art", "rock from", "1997"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// layout is "tabs", the sections in tabs above the content, or "split", a
+// sidebar with the track details and the sections left of the content.
+var layout = "tabs"
+
+// sidebarWidth is the columns taken by the sidebar of the split layout,
+// splitMinContent the narrowest content kept next to it.
+const (
+	sidebarWidth    = 30
+	splitMinContent = 40
+)
+
+// split reports whether the sidebar is shown, terminals too narrow for it
+// and the content fall back to the tabs.
+func (m *model) split() bool {
+	return layout == "split" && (m.width == 0 || m.width >= sidebarWidth+splitMinContent+padding*2+viewportFrame)
+}
+
+// sidebarCols is the width taken left of the viewport.
+func (m *model) sidebarCols() int {
+	if m.split() {
+		return sidebarWidth
+	}
+	return 0
+}
+
+// releaseMsg brings the MusicBrainz release of the album for the year and
+// the label of the sidebar.
+type releaseMsg struct {
+	info    MusicInfo
+	release *Release
+}
+
+// releaseCmd looks up the release once loading ended, so it does not compete
+// with the tracklist under the MusicBrainz rate limit. Without a release the
+// sidebar leaves the year and the label out.
+func (m *model) releaseCmd() tea.Cmd {
+	if !m.split() || m.album == "" || m.release != nil {
+		return nil
+	}
+	ctx, info := m.fetchCtx, m.MusicInfo
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func() tea.Msg {
+		release, err := getRelease(ctx, info)
+		if err != nil {
+			return nil
+		}
+		return releaseMsg{info: info, release: release}
+	}
+}
+
+// year is the year the album first came out.
+func (r *Release) year() string {
+	date := r.ReleaseGroup.FirstReleaseDate
+	if date == "" {
+		date = r.Date
+	}
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+// label is the first record label of the release.
+func (r *Release) label() string {
+	for _, l := range r.LabelInfo {
+		if l.Label != nil {
+			return l.Label.Name
+		}
+	}
+	return ""
+}
+
+// sidebarView renders the cover, the track details and the sections, cut to
+// height rows. The selected section is marked, with an arrow while the
+// sidebar has the focus.
+func (m *model) sidebarView(height int) string {
+	width := sidebarWidth - padding - 1
+	var lines []string
+	if cover := m.artworkView(); cover != "" {
+		lines = append(strings.Split(cover, "\n"), "")
+	}
+
+	m.mu.Lock()
+	info, release, tabs := m.MusicInfo, m.release, m.tabs()
+	m.mu.Unlock()
+
+	if info.isPodcast() {
+		lines = append(lines, styleActiveTab(info.track), info.album)
+	} else {
+		lines = append(lines, styleActiveTab(info.track), info.artist, helpStyle(info.album))
+	}
+
+	var details []string
+	if m.playbackDuration > 0 {
+		details = append(details, helpStyle(tr("Length")+" ")+formatPlaybackTime(m.playbackDuration))
+	}
+	if release != nil {
+		if year := release.year(); year != "" {
+			details = append(details, helpStyle(tr("Year")+" ")+year)
+		}
+		if label := release.label(); label != "" {
+			details = append(details, helpStyle(tr("Label")+" ")+label)
+		}
+	}
+	if len(details) > 0 {
+		lines = append(append(lines, ""), details...)
+	}
+
+	lines = append(lines, "")
+	_, current := m.currentTab()
+	for i, t := range tabs {
+		label := fmt.Sprintf("%d %s", i+1, t.label)
+		switch {
+		case i == current && m.sidebarFocus:
+			lines = append(lines, "▸ "+styleActiveTab(label))
+		case i == current:
+			lines = append(lines, "  "+styleActiveTab(label))
+		default:
+			lines = append(lines, "  "+helpStyle(label))
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = lipgloss.NewStyle().MaxWidth(width).Render(line)
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lipgloss.NewStyle().PaddingLeft(padding).Width(sidebarWidth).Render(strings.Join(lines, "\n"))
+}
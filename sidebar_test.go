@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSplitLayout(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(l string) { layout = l }(layout)
+	layout = "split"
+
+	m.getInfo(context.Background())
+	m.Update(tickMsg{})
+	m.playbackDuration = 284 * time.Second
+	m.release = &Release{}
+	if err := json.Unmarshal([]byte(`{"date":"1997-05-21","label-info":[{"label":{"name":"Parlophone"}}]}`), m.release); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	view := m.View()
+	if rows := strings.Count(view, "\n") + 1; rows != 40 {
+		t.Errorf("view has %d rows", rows)
+	}
+	for _, want := range []string{"Length 4:44", "Year 1997", "Label Parlophone", "1 Album", "stub answer for: Give me album info"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not include %q:\n%s", want, view)
+		}
+	}
+
+	// Tab focuses the sidebar, where down selects the next section.
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if !m.sidebarFocus || m.tab != 1 || !strings.Contains(m.View(), "▸ ") {
+		t.Errorf("got tab %d with the sidebar focus %v", m.tab, m.sidebarFocus)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if m.sidebarFocus || m.tab != 1 {
+		t.Errorf("got tab %d with the sidebar focus %v", m.tab, m.sidebarFocus)
+	}
+
+	// Narrow terminals fall back to the tabs.
+	m.Update(tea.WindowSizeMsg{Width: 50, Height: 20})
+	if m.split() || strings.Contains(m.View(), "Year 1997") {
+		t.Error("the sidebar is shown on a narrow terminal")
+	}
+}
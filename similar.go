@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSimilarArtists is how many recommendations the section lists.
+const maxSimilarArtists = 10
+
+var errNoSimilarArtists = errors.New("no similar artists found")
+
+type SimilarArtist struct {
+	Name  string `json:"name"`
+	Match string `json:"match"`
+	URL   string `json:"url"`
+}
+
+// getSimilarArtists is replaced in tests.
+var getSimilarArtists = fetchSimilarArtists
+
+func fetchSimilarArtists(ctx context.Context, info MusicInfo) ([]SimilarArtist, error) {
+	var r struct {
+		SimilarArtists struct {
+			Artist []SimilarArtist `json:"artist"`
+		} `json:"similarartists"`
+	}
+	params := url.Values{"artist": {info.artist}, "limit": {strconv.Itoa(maxSimilarArtists)}}
+	if err := lastfmGet(ctx, "artist.getSimilar", params, &r); err != nil {
+		if errors.Is(err, errNoLastfm) {
+			return nil, errNoSimilarArtists
+		}
+		return nil, err
+	}
+	if len(r.SimilarArtists.Artist) == 0 {
+		return nil, errNoSimilarArtists
+	}
+	return r.SimilarArtists.Artist, nil
+}
+
+// similarSection lists the artists, the artist picker reads their names back
+// from the list.
+func similarSection(ctx context.Context, info MusicInfo) (string, error) {
+	artists, err := getSimilarArtists(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, a := range artists {
+		fmt.Fprintf(&b, "%d. [%s](%s)", i+1, strings.NewReplacer("[", "(", "]", ")").Replace(a.Name), a.URL)
+		if match, err := strconv.ParseFloat(a.Match, 64); err == nil {
+			fmt.Fprintf(&b, " · %.0f%% match", match*100)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n*Source: Last.fm*")
+	return b.String(), nil
+}
+
+var similarNamePattern = regexp.MustCompile(`(?m)^\d+\. \[([^\]]+)\]\(`)
+
+// similarArtistNames returns the artists listed in the section content.
+func similarArtistNames(content string) []string {
+	var names []string
+	for _, match := range similarNamePattern.FindAllStringSubmatch(content, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// artistPicker selects a similar artist to ask the AI about.
+type artistPicker struct {
+	index   int
+	artists []string
+	cursor  int
+}
+
+func (m *model) openArtistPicker() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.sections {
+		if s.key != "similar" {
+			continue
+		}
+		if names := similarArtistNames(s.Content); len(names) > 0 {
+			m.artistPicker = &artistPicker{index: i, artists: names}
+		}
+	}
+}
+
+func (m *model) updateArtistPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.artistPicker
+
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.artistPicker = nil
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.artists)-1 {
+			p.cursor++
+		}
+	case "enter":
+		return m, m.askAboutArtist(p.index, p.artists[p.cursor])
+	case "p":
+		return m, m.saveArtistsPlaylist(p.artists)
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if i := int(key[0] - '1'); i < len(p.artists) {
+			return m, m.askAboutArtist(p.index, p.artists[i])
+		}
+	}
+	return m, nil
+}
+
+// askAboutArtist asks the AI provider for a blurb on a similar artist and
+// adds it to the section at index.
+func (m *model) askAboutArtist(index int, name string) tea.Cmd {
+	m.artistPicker = nil
+	if m.fetchCtx == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	heading := "### " + name
+	if strings.Contains(m.sections[index].Content, "\n"+heading+"\n") {
+		return nil
+	}
+
+	m.steps++
+	wasLoading := m.loading
+	m.loading = true
+	m.notice = "  " + trf("Asking about %s", name)
+
+	ctx, info := m.fetchCtx, m.MusicInfo
+	prompt := inLanguage(fmt.Sprintf("In a short paragraph, tell a fan of %s about the artist %s and which album to start with", info.artist, name))
+	m.goFetch(func() {
+		defer m.stepDone(ctx)
+
+		key := cacheKey(info, albumScope, chatModel, prompt)
+		blurb, ok := cachedAnswer(ctx, key)
+		if !ok {
+			var err error
+			blurb, err = m.complete(ctx, chatModel, prompt)
+			if err != nil {
+				m.whileCurrent(ctx, func() { m.errMsg = "  " + provider + " api: " + err.Error() })
+				return
+			}
+			writeCache(key, blurb)
+		}
+
+		m.whileCurrent(ctx, func() {
+			m.sections[index].Content += "\n\n" + heading + "\n\n" + blurb
+			m.buildContent()
+			m.changed = true
+		})
+	})
+
+	// The running tick loop ends the loading already.
+	if wasLoading {
+		return nil
+	}
+	return tickCmd()
+}
+
+func (m *model) artistPickerView() string {
+	p := m.artistPicker
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+tr("Similar artists")) + "\n\n")
+	for i, name := range p.artists {
+		line := fmt.Sprintf("%d %s", i+1, name)
+		if i == p.cursor {
+			b.WriteString(pad + styleBadge("› "+line) + "\n")
+		} else {
+			b.WriteString(pad + "  " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("↑/↓: Select • enter/1-9: Ask about the artist • p: Spotify playlist • esc: Back")))
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimilarArtists(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	lastfmAPIKey = "key"
+	getLastfmStats = func(ctx context.Context, info MusicInfo) (*LastfmStats, error) {
+		return nil, errNoLastfm
+	}
+	getSimilarArtists = func(ctx context.Context, info MusicInfo) ([]SimilarArtist, error) {
+		return []SimilarArtist{
+			{Name: "Thom Yorke", Match: "1", URL: "https://www.last.fm/music/Thom+Yorke"},
+			{Name: "Muse", Match: "0.42", URL: "https://www.last.fm/music/Muse"},
+		}, nil
+	}
+	defer func() {
+		getLastfmStats = fetchLastfmStats
+		getSimilarArtists = fetchSimilarArtists
+	}()
+
+	m.fetchCtx = context.Background()
+	m.getInfo(m.fetchCtx)
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	var similar Section
+	for _, s := range m.sections {
+		if s.key == "similar" {
+			similar = s
+		}
+	}
+	if !strings.Contains(similar.Content, "2. [Muse](https://www.last.fm/music/Muse) · 42% match") {
+		t.Fatalf("got %q", similar.Content)
+	}
+
+	m.Update(keyRune('a'))
+	if m.artistPicker == nil {
+		t.Fatal("artist picker is not open")
+	}
+	if got := m.artistPicker.artists; len(got) != 2 || got[0] != "Thom Yorke" || got[1] != "Muse" {
+		t.Fatalf("got artists %v", got)
+	}
+
+	m.Update(keyRune('2'))
+	if m.artistPicker != nil {
+		t.Error("artist picker is still open")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		done := m.stepsDone >= m.steps
+		m.mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	similar = m.sections[len(m.sections)-1]
+	if !strings.Contains(similar.Content, "### Muse\n\nstub answer for: In a short paragraph, tell a fan of Radiohead about the artist Muse") {
+		t.Errorf("no blurb in %q", similar.Content)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// apiSource fills a section from a music database instead of the AI
+// provider.
+type apiSource struct {
+	// name identifies the source in the cache key.
+	name  string
+	fetch func(ctx context.Context, info MusicInfo) (string, error)
+	// fetchSummarized fills the section like fetch and also returns the
+	// prompt of its summary by the AI provider, built from the same data and
+	// added under summaryTitle. No prompt means no summary.
+	fetchSummarized func(ctx context.Context, info MusicInfo) (string, summaryPrompt, error)
+	// notFound is the error of an album the source does not know, the
+	// section then shows missing instead of an error.
+	notFound error
+	missing  string
+	// live sources are not cached, their data changes with every play.
+	live bool
+	// local sources do not need the network and also run offline.
+	local bool
+	// track sources are about the track, the others are shared by the
+	// tracks of the album in the cache.
+	track bool
+	// fallback fills the section when the source does not know the item,
+	// from the AI provider.
+	fallback     func(m *model, ctx context.Context, info MusicInfo) (string, error)
+	summaryTitle string
+	// formatSummary rewrites the summary before it is added, e.g. to link
+	// what it names.
+	formatSummary func(summary string) string
+}
+
+// summaryPrompt returns the prompt of the summary of a section, empty when
+// there is nothing to sum up.
+type summaryPrompt func(ctx context.Context) (string, error)
+
+// apiSources are the sections by key that do not come from the AI provider.
+var apiSources = map[string]apiSource{
+	"tracklist": {
+		name:     "musicbrainz",
+		fetch:    tracklistSection,
+		notFound: errNoRelease,
+		missing:  "MusicBrainz does not know this album.",
+		fallback: (*model).aiTracklistSection,
+	},
+	"features": {
+		name:     "spotify-features",
+		fetch:    audioFeaturesSection,
+		notFound: errNoSpotifyTrack,
+		missing:  "Spotify does not know this track.",
+		track:    true,
+	},
+	"stories": {
+		name:     "genius",
+		fetch:    storiesSection,
+		notFound: errNoGenius,
+		missing:  "Genius does not know this song.",
+		track:    true,
+	},
+	"wikipedia": {
+		name:     "wikipedia",
+		fetch:    wikipediaSection,
+		notFound: errNoWikipedia,
+		missing:  "Wikipedia has no article about this album or artist.",
+	},
+	"reception": {
+		name:     "reception",
+		fetch:    receptionSection,
+		notFound: errNoReception,
+		missing:  "No critic or user scores found for this album.",
+	},
+	"discogs": {
+		name:     "discogs",
+		fetch:    discogsSection,
+		notFound: errNoDiscogsRelease,
+		missing:  "Discogs does not know this album.",
+	},
+	"lastfm": {
+		name:     "lastfm",
+		fetch:    lastfmSection,
+		notFound: errNoLastfm,
+		missing:  "Last.fm does not know this artist.",
+		live:     true,
+		track:    true,
+	},
+	"concerts": {
+		name:     "bandsintown",
+		fetch:    concertsSection,
+		notFound: errNoConcerts,
+		missing:  "No upcoming concerts.",
+		live:     true,
+	},
+	"similar": {
+		name:     "lastfm-similar",
+		fetch:    similarSection,
+		notFound: errNoSimilarArtists,
+		missing:  "Last.fm knows no similar artists.",
+	},
+	"listenbrainz": {
+		name:     "listenbrainz",
+		fetch:    listenBrainzSection,
+		notFound: errNoListenBrainz,
+		missing:  "ListenBrainz does not know this artist.",
+		live:     true,
+		track:    true,
+	},
+	"reddit": {
+		name:            "reddit",
+		fetchSummarized: redditSection,
+		notFound:        errNoReddit,
+		missing:         "Reddit has no threads about this album or song.",
+		summaryTitle:    "What Reddit thinks",
+		track:           true,
+	},
+	"news": {
+		name:            "news",
+		fetchSummarized: newsSection,
+		notFound:        errNoNews,
+		missing:         "No recent news about this artist.",
+		summaryTitle:    "The news in brief",
+	},
+	"scene": {
+		name:            "scene",
+		fetchSummarized: sceneSection,
+		notFound:        errNoScene,
+		missing:         "No genre or era known for this album.",
+		summaryTitle:    "Scene context",
+		formatSummary:   linkScene,
+	},
+}
+
+// fetchAPISection fills the section at index from source. Unless the source
+// is live its answers are cached like the ones of the AI provider.
+func (m *model) fetchAPISection(ctx context.Context, info MusicInfo, index int, source apiSource, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer m.stepDone(ctx)
+
+	scope := albumScope
+	if source.track {
+		scope = trackScope
+	}
+	key := cacheKey(info, scope, source.name, "section")
+	if offline && !source.local {
+		m.offlineSection(ctx, info, index, key)
+		return
+	}
+	if !source.live {
+		if content, ok := cachedAnswer(ctx, key); ok {
+			m.setSectionContent(ctx, index, content)
+			return
+		}
+	}
+
+	var content string
+	var summarize summaryPrompt
+	err := m.withRetry(ctx, func() (err error) {
+		if source.fetchSummarized != nil {
+			content, summarize, err = source.fetchSummarized(ctx, info)
+			return err
+		}
+		content, err = source.fetch(ctx, info)
+		return err
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if errors.Is(err, source.notFound) && source.fallback != nil && metadataOnly() == nil {
+		reqCtx, done := m.sectionRequest(m.countUsage(ctx, index), index)
+		content, err = source.fallback(m, reqCtx, info)
+		err = done(err)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	if errors.Is(err, source.notFound) {
+		m.setSectionContent(ctx, index, "*"+tr(source.missing)+"*")
+		return
+	}
+	if err != nil {
+		m.whileCurrent(ctx, func() {
+			m.sections[index].Error = err.Error()
+			m.buildContent()
+			m.changed = true
+		})
+		return
+	}
+
+	m.setSectionContent(ctx, index, content)
+	if summarize != nil {
+		summary, err := m.summarizeSection(ctx, index, summarize)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// The section is still useful without its summary, which is
+			// tried again next time.
+			m.whileCurrent(ctx, func() { m.errMsg = "  summary: " + err.Error() })
+			return
+		}
+		if summary != "" {
+			summary = postProcessAnswer(summary, 4)
+			if source.formatSummary != nil {
+				summary = source.formatSummary(summary)
+			}
+			if content != "" {
+				content += "\n\n"
+			}
+			content += "### " + tr(source.summaryTitle) + "\n\n" + summary
+			m.setSectionContent(ctx, index, content)
+		}
+	}
+	if !source.live {
+		writeCache(key, content)
+	}
+}
+
+// summarizeSection asks the AI provider for the summary of the section at
+// index, empty when the source has nothing to summarize.
+func (m *model) summarizeSection(ctx context.Context, index int, summarize summaryPrompt) (string, error) {
+	var prompt string
+	err := m.withRetry(ctx, func() (err error) {
+		prompt, err = summarize(ctx)
+		return err
+	})
+	if err != nil || prompt == "" {
+		return "", err
+	}
+	reqCtx, done := m.sectionRequest(m.countUsage(ctx, index), index)
+	summary, err := m.complete(reqCtx, chatModel, prompt)
+	return summary, done(err)
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+// spotifyClientID is the client id of the Spotify app used for the Web API,
+// the Web API is only used when it is set.
+var spotifyClientID string
+
+var (
+	spotifyAPIURL      = "https://api.spotify.com/v1"
+	spotifyAccountsURL = "https://accounts.spotify.com"
+)
+
+const (
+	spotifyRedirectAddr = "127.0.0.1:8974"
+	spotifyRedirectURL  = "http://" + spotifyRedirectAddr + "/callback"
+	spotifyScopes       = "user-read-currently-playing user-read-playback-state user-modify-playback-state playlist-read-private playlist-modify-private"
+	spotifyLoginTimeout = 5 * time.Minute
+)
+
+var errSpotifyLogin = errors.New("not logged in to the Spotify Web API, run stui -spotify-login")
+
+func init() {
+	registerPlayer("spotify-web", playerFactory{
+		name: "the Spotify Web API",
+		new: func() (player.Player, error) {
+			if spotifyClientID == "" {
+				return nil, errors.New("the spotify-web player needs spotify_client_id in the config file")
+			}
+			return newSpotifyWebPlayer(spotifyClientID), nil
+		},
+	})
+}
+
+// spotifyToken is the OAuth token cached between runs.
+type spotifyToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+func spotifyTokenPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stui", "spotify_token.json"), nil
+}
+
+func loadSpotifyToken() (*spotifyToken, error) {
+	path, err := spotifyTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errSpotifyLogin
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var t spotifyToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func spotifyLoggedIn() bool {
+	path, err := spotifyTokenPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func (t *spotifyToken) save() error {
+	path, err := spotifyTokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// requestSpotifyToken posts form to the token endpoint, for both the
+// authorization code and the refresh token grants.
+func requestSpotifyToken(ctx context.Context, form url.Values) (*spotifyToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, spotifyAccountsURL+"/api/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("spotify token: status code %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spotify token: %s %s", r.Error, r.ErrorDescription)
+	}
+
+	return &spotifyToken{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(r.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// spotifyLogin runs the authorization code flow with PKCE in the browser and
+// caches the token, it is needed once.
+func spotifyLogin(clientID string) error {
+	verifier, err := randomString(64)
+	if err != nil {
+		return err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	ln, err := net.Listen("tcp", spotifyRedirectAddr)
+	if err != nil {
+		return err
+	}
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path != "/callback":
+			http.NotFound(w, r)
+			return
+		case q.Get("state") != state:
+			errs <- errors.New("spotify login: state mismatch")
+		case q.Get("error") != "":
+			errs <- fmt.Errorf("spotify login: %s", q.Get("error"))
+		default:
+			codes <- q.Get("code")
+		}
+		fmt.Fprintln(w, "You can close this window and go back to stui.")
+	})}
+	go server.Serve(ln)
+	defer server.Close()
+
+	authURL := spotifyAccountsURL + "/authorize?" + url.Values{
+		"client_id":             {clientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {spotifyRedirectURL},
+		"code_challenge_method": {"S256"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"scope":                 {spotifyScopes},
+		"state":                 {state},
+	}.Encode()
+
+	fmt.Println("Opening the browser to log in to Spotify, if it does not open visit:")
+	fmt.Println(authURL)
+	_ = openBrowser(authURL)
+
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return err
+	case <-time.After(spotifyLoginTimeout):
+		return errors.New("spotify login: timed out")
+	}
+
+	token, err := requestSpotifyToken(context.Background(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {spotifyRedirectURL},
+		"client_id":     {clientID},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return err
+	}
+	return token.save()
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+// spotifyWebPlayer reads the playback state of the account, whatever device
+// it is playing on.
+type spotifyWebPlayer struct {
+	clientID string
+	mu       sync.Mutex
+	token    *spotifyToken
+}
+
+func newSpotifyWebPlayer(clientID string) *spotifyWebPlayer {
+	return &spotifyWebPlayer{clientID: clientID}
+}
+
+// accessToken returns a valid access token, refreshing it when it is about
+// to expire.
+func (p *spotifyWebPlayer) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == nil {
+		token, err := loadSpotifyToken()
+		if err != nil {
+			return "", err
+		}
+		p.token = token
+	}
+
+	if time.Until(p.token.Expiry) < time.Minute {
+		token, err := requestSpotifyToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {p.token.RefreshToken},
+			"client_id":     {p.clientID},
+		})
+		if err != nil {
+			return "", err
+		}
+		// The refresh token is only sent again when it was rotated.
+		if token.RefreshToken == "" {
+			token.RefreshToken = p.token.RefreshToken
+		}
+		p.token = token
+		if err := token.save(); err != nil {
+			return "", err
+		}
+	}
+
+	return p.token.AccessToken, nil
+}
+
+func (p *spotifyWebPlayer) do(method string, path string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.doContext(ctx, method, path)
+}
+
+func (p *spotifyWebPlayer) doContext(ctx context.Context, method string, path string) (*http.Response, error) {
+	return p.request(ctx, method, path, nil)
+}
+
+// request sends a Web API request, body is sent as JSON when it is not nil.
+func (p *spotifyWebPlayer) request(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, spotifyAPIURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNoContent {
+		resp.Body.Close()
+		return nil, newStatusError(resp.StatusCode, "spotify api: %s %s: status code %d", method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+type spotifyPlayback struct {
+	IsPlaying   bool   `json:"is_playing"`
+	ProgressMs  int64  `json:"progress_ms"`
+	PlayingType string `json:"currently_playing_type"`
+	Item        *struct {
+		Name       string `json:"name"`
+		DurationMs int64  `json:"duration_ms"`
+		Album      struct {
+			Name string `json:"name"`
+		} `json:"album"`
+		Artists []struct {
+			Name string `json:"name"`
+		} `json:"artists"`
+	} `json:"item"`
+}
+
+// playback returns nil when nothing is playing on any device.
+func (p *spotifyWebPlayer) playback() (*spotifyPlayback, error) {
+	resp, err := p.do(http.MethodGet, "/me/player/currently-playing")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var playback spotifyPlayback
+	if err := json.NewDecoder(resp.Body).Decode(&playback); err != nil {
+		return nil, err
+	}
+	return &playback, nil
+}
+
+func (p *spotifyWebPlayer) Track() (player.Track, PlayerStatus) {
+	playback, err := p.playback()
+	if err != nil {
+		return player.Track{}, PlayerUnavailable
+	}
+	if playback == nil || !playback.IsPlaying || playback.PlayingType != "track" || playback.Item == nil {
+		return player.Track{}, PlayerIdle
+	}
+
+	track := player.Track{Album: playback.Item.Album.Name, Title: playback.Item.Name}
+	for _, a := range playback.Item.Artists {
+		track.Artists = append(track.Artists, a.Name)
+	}
+	return track, PlayerPlaying
+}
+
+// spotifyRepeatStates are the Web API names of the repeat modes.
+var spotifyRepeatStates = map[player.RepeatMode]string{player.RepeatOff: "off", player.RepeatAll: "context", player.RepeatTrack: "track"}
+
+func (p *spotifyWebPlayer) Control(action player.Action) {
+	method, path := http.MethodPost, "/me/player/next"
+	switch action {
+	case player.Previous:
+		path = "/me/player/previous"
+	case player.VolumeUp, player.VolumeDown, player.Shuffle, player.Repeat:
+		settings, err := p.Settings()
+		if err != nil {
+			return
+		}
+		settings = settings.Adjusted(action)
+		method = http.MethodPut
+		switch action {
+		case player.Shuffle:
+			path = fmt.Sprintf("/me/player/shuffle?state=%t", settings.Shuffle)
+		case player.Repeat:
+			path = "/me/player/repeat?state=" + spotifyRepeatStates[settings.Repeat]
+		default:
+			path = fmt.Sprintf("/me/player/volume?volume_percent=%d", settings.Volume)
+		}
+	case player.PlayPause:
+		playback, err := p.playback()
+		if err != nil {
+			return
+		}
+		method, path = http.MethodPut, "/me/player/play"
+		if playback != nil && playback.IsPlaying {
+			path = "/me/player/pause"
+		}
+	}
+
+	// Controls need a premium account, there is nothing to do about failures.
+	if resp, err := p.do(method, path); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (p *spotifyWebPlayer) Position() (time.Duration, error) {
+	playback, err := p.playback()
+	if err != nil {
+		return 0, err
+	}
+	if playback == nil {
+		return 0, nil
+	}
+	return time.Duration(playback.ProgressMs) * time.Millisecond, nil
+}
+
+func (p *spotifyWebPlayer) Duration() (time.Duration, error) {
+	playback, err := p.playback()
+	if err != nil {
+		return 0, err
+	}
+	if playback == nil || playback.Item == nil {
+		return 0, nil
+	}
+	return time.Duration(playback.Item.DurationMs) * time.Millisecond, nil
+}
+
+// Settings reads the device volume with shuffle and repeat, the volume of
+// some devices can not be read or changed.
+func (p *spotifyWebPlayer) Settings() (player.Settings, error) {
+	resp, err := p.do(http.MethodGet, "/me/player")
+	if err != nil {
+		return player.Settings{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return player.Settings{}, errors.New("spotify: no active device")
+	}
+
+	var state struct {
+		Device struct {
+			VolumePercent *int `json:"volume_percent"`
+		} `json:"device"`
+		ShuffleState bool   `json:"shuffle_state"`
+		RepeatState  string `json:"repeat_state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return player.Settings{}, err
+	}
+
+	settings := player.Settings{Volume: -1, Shuffle: state.ShuffleState}
+	if state.Device.VolumePercent != nil {
+		settings.Volume = *state.Device.VolumePercent
+	}
+	for mode, name := range spotifyRepeatStates {
+		if name == state.RepeatState {
+			settings.Repeat = mode
+		}
+	}
+	return settings, nil
+}
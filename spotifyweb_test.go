@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/player"
+)
+
+func TestSpotifyWebTrack(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	playing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/token":
+			if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh" {
+				t.Errorf("unexpected token request %v", r.Form)
+			}
+			fmt.Fprint(w, `{"access_token":"new","expires_in":3600}`)
+		case "/me/player/currently-playing":
+			if r.Header.Get("Authorization") != "Bearer new" {
+				t.Errorf("got authorization %q", r.Header.Get("Authorization"))
+			}
+			if !playing {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			fmt.Fprint(w, `{"is_playing":true,"progress_ms":42000,"currently_playing_type":"track",
+				"item":{"name":"Airbag","album":{"name":"OK Computer"},"artists":[{"name":"Radiohead"}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api, accounts string) { spotifyAPIURL, spotifyAccountsURL = api, accounts }(spotifyAPIURL, spotifyAccountsURL)
+	spotifyAPIURL, spotifyAccountsURL = server.URL, server.URL
+
+	p := newSpotifyWebPlayer("client")
+	if _, status := p.Track(); status != PlayerUnavailable {
+		t.Errorf("got status %d without a token, want unavailable", status)
+	}
+
+	// An expired token is refreshed and the refresh token kept.
+	if err := (&spotifyToken{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now()}).save(); err != nil {
+		t.Fatal(err)
+	}
+	info, status := readTrack(p.Track())
+	if status != PlayerPlaying || info != testTrack {
+		t.Errorf("got %+v, %d", info, status)
+	}
+	if pos, err := p.Position(); err != nil || pos != 42*time.Second {
+		t.Errorf("got position %v, %v", pos, err)
+	}
+	if token, err := loadSpotifyToken(); err != nil || token.AccessToken != "new" || token.RefreshToken != "refresh" {
+		t.Errorf("cached token %+v, %v", token, err)
+	}
+
+	playing = false
+	if _, status := p.Track(); status != PlayerIdle {
+		t.Errorf("got status %d, want idle", status)
+	}
+}
+
+func TestSpotifyWebSettings(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/me/player" {
+			fmt.Fprint(w, `{"device":{"volume_percent":40},"shuffle_state":true,"repeat_state":"track"}`)
+			return
+		}
+		sent = append(sent, r.Method+" "+r.URL.RequestURI())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	defer func(api string) { spotifyAPIURL = api }(spotifyAPIURL)
+	spotifyAPIURL = server.URL
+
+	p := newSpotifyWebPlayer("client")
+	settings, err := p.Settings()
+	if err != nil || settings != (player.Settings{Volume: 40, Shuffle: true, Repeat: player.RepeatTrack}) {
+		t.Errorf("got settings %+v, %v", settings, err)
+	}
+
+	for _, action := range []player.Action{player.VolumeDown, player.Shuffle, player.Repeat} {
+		p.Control(action)
+	}
+	want := "PUT /me/player/volume?volume_percent=30, PUT /me/player/shuffle?state=false, PUT /me/player/repeat?state=off"
+	if got := strings.Join(sent, ", "); got != want {
+		t.Errorf("sent %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+// statsLimit is how many artists, albums, tracks and reads each chart has.
+const statsLimit = 5
+
+// statsPeriod is a period of the stats screen, since is when it started.
+type statsPeriod struct {
+	title string
+	since time.Time
+	stats storage.Stats
+}
+
+// statsView is the state of the stats screen, tab switches the period.
+type statsView struct {
+	periods []statsPeriod
+	period  int
+}
+
+// statsPeriods are this week from Monday, this month from the 1st and all
+// the history.
+func statsPeriods(now time.Time) []statsPeriod {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekday := (int(now.Weekday()) + 6) % 7
+	return []statsPeriod{
+		{title: "This week", since: midnight.AddDate(0, 0, -weekday)},
+		{title: "This month", since: midnight.AddDate(0, 0, 1-now.Day())},
+		{title: "All time"},
+	}
+}
+
+// openStats reads the stats of the periods from the history.
+func (m *model) openStats() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	periods := statsPeriods(time.Now())
+	for i := range periods {
+		stats, err := history.StatsSince(ctx, periods[i].since, statsLimit)
+		if err != nil {
+			return err
+		}
+		periods[i].stats = stats
+	}
+	m.stats = &statsView{periods: periods}
+	return nil
+}
+
+func (m *model) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.stats
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.stats = nil
+	case tea.KeyTab, tea.KeyRight:
+		v.period = (v.period + 1) % len(v.periods)
+	case tea.KeyShiftTab, tea.KeyLeft:
+		v.period = (v.period + len(v.periods) - 1) % len(v.periods)
+	}
+	return m, nil
+}
+
+// statsBar is a bar of width cells for n out of top, in eighths of a cell.
+func statsBar(n, top, width int) string {
+	if top <= 0 {
+		return ""
+	}
+	eighths := n * width * 8 / top
+	if eighths < 1 && n > 0 {
+		eighths = 1
+	}
+	bar := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		bar += string([]rune("▏▎▍▌▋▊▉")[rest-1])
+	}
+	return bar
+}
+
+// statsChart renders counts as a bar chart under title, the labels are cut
+// to fit width.
+func statsChart(title string, counts []storage.Count, label func(storage.Count) string, width int) string {
+	pad := strings.Repeat(" ", padding)
+	var b strings.Builder
+	b.WriteString(pad + styleBadge(tr(title)) + "\n")
+	if len(counts) == 0 {
+		b.WriteString(pad + helpStyle(tr("Nothing yet")) + "\n")
+		return b.String()
+	}
+
+	labelWidth := 0
+	for _, c := range counts {
+		if w := lipgloss.Width(label(c)); w > labelWidth {
+			labelWidth = w
+		}
+	}
+	barWidth := 20
+	if limit := width - padding*2 - barWidth - 8; width > 0 && labelWidth > limit {
+		labelWidth = limit
+	}
+	if labelWidth < 1 {
+		labelWidth = 1
+	}
+
+	bar := lipgloss.NewStyle().Foreground(lipgloss.Color(progressColors[0]))
+	for _, c := range counts {
+		name := lipgloss.NewStyle().MaxWidth(labelWidth).Render(label(c))
+		name += strings.Repeat(" ", labelWidth-lipgloss.Width(name))
+		fmt.Fprintf(&b, "%s%s  %s %d\n", pad, name, bar.Render(statsBar(c.Count, counts[0].Count, barWidth)), c.Count)
+	}
+	return b.String()
+}
+
+func (m *model) statsScreenView() string {
+	v := m.stats
+	p := v.periods[v.period]
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	var periods []string
+	for i, period := range v.periods {
+		if i == v.period {
+			periods = append(periods, styleActiveTab(tr(period.title)))
+		} else {
+			periods = append(periods, helpStyle(tr(period.title)))
+		}
+	}
+	b.WriteString(styleTitle(pad+tr("Listening stats")) + "  " + strings.Join(periods, "  ") + "\n\n")
+	b.WriteString(pad + trf("%d plays in %d listening sessions", p.stats.Plays, p.stats.Sessions) + "\n\n")
+
+	artist := func(c storage.Count) string { return c.Artist }
+	album := func(c storage.Count) string { return c.Album + " · " + c.Artist }
+	track := func(c storage.Count) string { return c.Track + " · " + c.Artist }
+	b.WriteString(statsChart("Top artists", p.stats.Artists, artist, m.width) + "\n")
+	b.WriteString(statsChart("Top albums", p.stats.Albums, album, m.width) + "\n")
+	b.WriteString(statsChart("Top tracks", p.stats.Tracks, track, m.width) + "\n")
+	b.WriteString(statsChart("Most reread", p.stats.Reads, track, m.width) + "\n")
+
+	b.WriteString(pad + helpStyle(tr("tab: Period • esc: Back")))
+	return b.String()
+}
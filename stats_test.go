@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ernesto27/stui/internal/storage"
+)
+
+func TestStatsPeriods(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2023, 9, 13, 21, 30, 0, 0, time.UTC)
+	periods := statsPeriods(now)
+	if want := time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC); !periods[0].since.Equal(want) {
+		t.Errorf("the week starts at %s, want %s", periods[0].since, want)
+	}
+	if want := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC); !periods[1].since.Equal(want) {
+		t.Errorf("the month starts at %s, want %s", periods[1].since, want)
+	}
+	if !periods[2].since.IsZero() {
+		t.Errorf("all time starts at %s", periods[2].since)
+	}
+}
+
+func TestStatsBar(t *testing.T) {
+	for _, tt := range []struct {
+		n, top int
+		want   string
+	}{
+		{4, 4, "████"},
+		{2, 4, "██"},
+		{3, 8, "█▌"},
+		{1, 100, "▏"},
+		{0, 4, ""},
+	} {
+		if got := statsBar(tt.n, tt.top, 4); got != tt.want {
+			t.Errorf("statsBar(%d, %d) = %q, want %q", tt.n, tt.top, got, tt.want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.loading = false
+	m.width, m.height = 100, 40
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = db
+	defer func() {
+		history = nil
+		db.Close()
+	}()
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, track := range []string{"Airbag", "Paranoid Android", "Airbag"} {
+		if _, err := db.RecordPlay(ctx, "Radiohead", "OK Computer", track, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.Update(keyRune('H'))
+	if m.stats == nil {
+		t.Fatalf("H did not open the stats, error %q", m.errMsg)
+	}
+	view := m.View()
+	for _, want := range []string{"3 plays in 1 listening sessions", "Radiohead", "OK Computer · Radiohead", "Airbag · Radiohead"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("the stats do not include %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.stats.period != 1 {
+		t.Errorf("tab went to period %d", m.stats.period)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.stats != nil {
+		t.Error("esc did not close the stats")
+	}
+}
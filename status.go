@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// statusFormat is the template of the line of stui status.
+var statusFormat = "{{.Artist}} – {{.Track}}"
+
+// statusCacheTTL is how long stui status reuses the track it read last, zero
+// reads the player every time.
+var statusCacheTTL time.Duration
+
+// statusLine is what the template of stui status gets.
+type statusLine struct {
+	Artist string
+	Album  string
+	Track  string
+	Player string
+}
+
+// statusCache is the track read by the last stui status.
+type statusCache struct {
+	Player  string    `json:"player"`
+	Artist  string    `json:"artist"`
+	Album   string    `json:"album"`
+	Track   string    `json:"track"`
+	Playing bool      `json:"playing"`
+	Read    time.Time `json:"read"`
+}
+
+// statusCachePath returns the file of the cached track, empty when there is
+// no cache directory.
+var statusCachePath = func() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stui", "status.json")
+}
+
+// parseStatusFormat parses the template of stui status.
+func parseStatusFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("status").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("status format: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printStatus writes the track playing as one line formatted with tmpl, for
+// a tmux status bar or a shell prompt. Nothing is written when nothing
+// plays.
+func printStatus(w io.Writer, tmpl *template.Template) error {
+	c := statusTrack()
+	if !c.Playing {
+		return nil
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, statusLine{Artist: c.Artist, Album: c.Album, Track: c.Track, Player: playerName}); err != nil {
+		return fmt.Errorf("status format: %w", err)
+	}
+	// The status bars show one line.
+	line := strings.Join(strings.Fields(b.String()), " ")
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// statusTrack reads the player, or the cache when it was read less than
+// statusCacheTTL ago.
+func statusTrack() statusCache {
+	path := ""
+	if statusCacheTTL > 0 {
+		path = statusCachePath()
+	}
+	if path != "" {
+		var c statusCache
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &c) == nil &&
+			c.Player == playerKey && time.Since(c.Read) < statusCacheTTL {
+			return c
+		}
+	}
+
+	info, status := getTrackInfo()
+	c := statusCache{
+		Player:  playerKey,
+		Artist:  info.artistLine(),
+		Album:   info.album,
+		Track:   info.track,
+		Playing: status == PlayerPlaying && (info.artist != "" || info.isPodcast()),
+		Read:    time.Now(),
+	}
+	if path != "" {
+		// A cache that can not be written only makes the next run slower.
+		if data, err := json.Marshal(c); err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			_ = os.WriteFile(path, data, 0o600)
+		}
+	}
+	return c
+}
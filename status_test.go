@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintStatus(t *testing.T) {
+	setupTest(t, PlayerPlaying)
+	path := filepath.Join(t.TempDir(), "status.json")
+	defer func(p func() string) { statusCachePath = p }(statusCachePath)
+	statusCachePath = func() string { return path }
+
+	tmpl, err := parseStatusFormat("♪ {{.Artist}} – {{.Track}} ({{.Album}})\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := printStatus(&b, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "♪ Radiohead – Airbag (OK Computer)\n" {
+		t.Errorf("got %q", got)
+	}
+
+	// Without a cache the player is read every time.
+	getTrackInfo = func() (MusicInfo, PlayerStatus) { return MusicInfo{}, PlayerIdle }
+	b.Reset()
+	if err := printStatus(&b, tmpl); err != nil || b.Len() != 0 {
+		t.Errorf("got %q, %v with nothing playing", b.String(), err)
+	}
+
+	defer func(ttl time.Duration) { statusCacheTTL = ttl }(statusCacheTTL)
+	statusCacheTTL = time.Minute
+	getTrackInfo = func() (MusicInfo, PlayerStatus) { return testTrack, PlayerPlaying }
+	printStatus(&b, tmpl)
+	getTrackInfo = func() (MusicInfo, PlayerStatus) {
+		t.Error("the player was read with the track cached")
+		return MusicInfo{}, PlayerIdle
+	}
+	b.Reset()
+	if err := printStatus(&b, tmpl); err != nil || b.String() != "♪ Radiohead – Airbag (OK Computer)\n" {
+		t.Errorf("got %q, %v from the cache", b.String(), err)
+	}
+
+	if _, err := parseStatusFormat("{{.Artist"); err == nil {
+		t.Error("a broken template is accepted")
+	}
+	tmpl, _ = parseStatusFormat("{{.Genre}}")
+	if err := printStatus(&b, tmpl); err == nil {
+		t.Error("an unknown field is accepted")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusBarView is the last line of the main view: what the player is doing
+// and where the track comes from, the model, how many sections came from
+// the cache and when loading ended, then the usage and the player settings.
+func (m *model) statusBarView() string {
+	state := "▶ " + tr("playing")
+	switch m.playerState {
+	case PlayerIdle:
+		state = "⏸ " + tr("paused")
+	case PlayerUnavailable:
+		state = "■ " + tr("unavailable")
+	}
+
+	items := []string{currentSource(), trf("model: %s", chatModel)}
+	if offline {
+		items = append(items, tr("offline"))
+	}
+	if cache := m.cacheView(); cache != "" {
+		items = append(items, cache)
+	}
+	if !m.refreshed.IsZero() {
+		items = append(items, trf("updated %s", m.refreshed.Format("15:04")))
+	}
+	if usage := m.usageView(); usage != "" {
+		items = append(items, usage)
+	}
+	if m.compare.album != "" {
+		items = append(items, trf("pinned: %s - %s", m.compare.artist, m.compare.album))
+	}
+	items = append(items, m.settingsView()...)
+	if m.watch {
+		items = append(items, tr("watching"))
+	}
+
+	bar := strings.Repeat(" ", padding) + styleBadge(state) + helpStyle(" • "+strings.Join(items, " • "))
+	// The bar is a single line, what does not fit is cut.
+	if m.width > 0 {
+		bar = lipgloss.NewStyle().MaxWidth(m.width).Render(bar)
+	}
+	return bar
+}
+
+// cacheView tells how many sections were read from the cache, empty until a
+// section was fetched.
+func (m *model) cacheView() string {
+	if !cacheEnabled() {
+		return tr("cache off")
+	}
+	m.mu.Lock()
+	hits, misses := m.cacheHits, m.cacheMisses
+	m.mu.Unlock()
+	if hits+misses == 0 {
+		return ""
+	}
+	return trf("cache %d/%d", hits, hits+misses)
+}
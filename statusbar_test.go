@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStatusBar(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m.Update(tickMsg{})
+
+	lines := strings.Split(m.View(), "\n")
+	bar := lines[len(lines)-1]
+	for _, want := range []string{"▶ playing", playerName, "model: " + chatModel, "cache off", "updated "} {
+		if !strings.Contains(bar, want) {
+			t.Errorf("the status bar does not include %q: %q", want, bar)
+		}
+	}
+
+	// Play/pause keeps the track on screen.
+	m.Update(playerMsg{status: PlayerIdle})
+	if bar := m.statusBarView(); !strings.Contains(bar, "⏸ paused") {
+		t.Errorf("got status bar %q after pausing", bar)
+	}
+}
+
+func TestStatusBarCache(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	cacheDir = t.TempDir()
+
+	m.getInfo(context.Background())
+	sections := m.cacheMisses
+	if m.cacheHits != 0 || sections == 0 {
+		t.Fatalf("got %d hits and %d misses without a cache", m.cacheHits, m.cacheMisses)
+	}
+
+	m.reset(m.MusicInfo)
+	m.getInfo(context.Background())
+	if m.cacheHits != sections || m.cacheMisses != 0 {
+		t.Errorf("got %d hits and %d misses from the cache", m.cacheHits, m.cacheMisses)
+	}
+	if view := m.cacheView(); !strings.HasPrefix(view, "cache ") || strings.Contains(view, "off") {
+		t.Errorf("got %q", view)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// completeJSON asks the provider for an answer matching the schema of fn and
+// decodes it into v. The providers without structured output get the schema
+// in the prompt instead.
+func (m *model) completeJSON(ctx context.Context, model string, prompt string, fn ai.Function, v any) error {
+	answer, err := m.completeWith(ctx, model, prompt, func(ctx context.Context) (string, error) {
+		if sp, ok := completer.(ai.StructuredProvider); ok {
+			return sp.CompleteJSON(ctx, model, prompt, fn)
+		}
+		return completer.Complete(ctx, model, prompt+"\n\nAnswer only with a JSON object matching this JSON schema, without any other text:\n"+string(fn.Schema))
+	})
+	if err != nil {
+		return err
+	}
+	// Without structured output the JSON may come in a code block.
+	answer = strings.Trim(strings.TrimPrefix(strings.TrimSpace(answer), "```json"), "`\n ")
+	if err := json.Unmarshal([]byte(answer), v); err != nil {
+		return fmt.Errorf("the answer of %s is not the JSON asked for: %w", model, err)
+	}
+	return nil
+}
+
+// tracklistFunction is the structured answer of the tracklist of an album
+// MusicBrainz does not know.
+var tracklistFunction = ai.Function{
+	Name:        "show_tracklist",
+	Description: "Show the tracklist and the credits of an album",
+	Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"released": {"type": "string", "description": "Release date of the album, as YYYY-MM-DD or the year"},
+		"label": {"type": "string", "description": "Record label of the first release"},
+		"tracks": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"number": {"type": "string"},
+					"title": {"type": "string"},
+					"duration": {"type": "string", "description": "Length of the track as m:ss, empty when unknown"}
+				},
+				"required": ["number", "title", "duration"]
+			}
+		},
+		"personnel": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"roles": {"type": "array", "items": {"type": "string"}, "description": "Such as producer, engineer, guitar or vocals"}
+				},
+				"required": ["name", "roles"]
+			}
+		}
+	},
+	"required": ["tracks", "personnel"]
+}`),
+}
+
+// aiTracklist is the answer of tracklistFunction.
+type aiTracklist struct {
+	Released string `json:"released"`
+	Label    string `json:"label"`
+	Tracks   []struct {
+		Number   string `json:"number"`
+		Title    string `json:"title"`
+		Duration string `json:"duration"`
+	} `json:"tracks"`
+	Personnel []struct {
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	} `json:"personnel"`
+}
+
+// aiTracklistSection asks the AI provider for the tracklist and credits of an
+// album MusicBrainz does not know. They come back as JSON, not as the
+// markdown the model would pick, so the tables are always aligned.
+func (m *model) aiTracklistSection(ctx context.Context, info MusicInfo) (string, error) {
+	prompt := fmt.Sprintf("List the tracks of the album %s by %s with their lengths, and the personnel who played on it or produced it with their roles.", info.album, info.artist)
+	var t aiTracklist
+	if err := m.completeJSON(ctx, chatModel, prompt, tracklistFunction, &t); err != nil {
+		return "", err
+	}
+	if len(t.Tracks) == 0 {
+		return "", errNoRelease
+	}
+	return t.markdown(), nil
+}
+
+// markdown renders the release and the tables of the tracks and the
+// personnel.
+func (t aiTracklist) markdown() string {
+	var b strings.Builder
+
+	var released []string
+	for _, s := range []string{t.Released, t.Label} {
+		if s != "" {
+			released = append(released, s)
+		}
+	}
+	if len(released) > 0 {
+		fmt.Fprintf(&b, "**Released:** %s\n\n", strings.Join(released, " · "))
+	}
+
+	rows := make([][]string, len(t.Tracks))
+	for i, track := range t.Tracks {
+		rows[i] = []string{track.Number, track.Title, track.Duration}
+	}
+	b.WriteString("```\n" + alignedTable([]string{"#", "Title", "Length"}, rows, 0, 2) + "\n```\n")
+
+	if len(t.Personnel) > 0 {
+		rows = make([][]string, len(t.Personnel))
+		for i, p := range t.Personnel {
+			rows[i] = []string{p.Name, strings.Join(p.Roles, ", ")}
+		}
+		b.WriteString("\n### Personnel\n\n```\n" + alignedTable([]string{"Name", "Role"}, rows) + "\n```\n")
+	}
+
+	fmt.Fprintf(&b, "\n*Source: %s, MusicBrainz does not know this album*", chatModel)
+	return b.String()
+}
+
+// alignedTable lays out rows under header in columns padded with lipgloss,
+// which measures the wide and combined characters. The columns in right are
+// aligned to the right.
+func alignedTable(header []string, rows [][]string, right ...int) string {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i < len(widths) && lipgloss.Width(cell) > widths[i] {
+				widths[i] = lipgloss.Width(cell)
+			}
+		}
+	}
+	rule := make([]string, len(header))
+	for i, w := range widths {
+		rule[i] = strings.Repeat("─", w)
+	}
+
+	var lines []string
+	for _, row := range append([][]string{header, rule}, rows...) {
+		cells := make([]string, len(widths))
+		for i, w := range widths {
+			style := lipgloss.NewStyle().Width(w)
+			if containsInt(right, i) {
+				style = style.Align(lipgloss.Right)
+			}
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			cells[i] = style.Render(cell)
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type tracklistCompleter struct{}
+
+func (tracklistCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	if !strings.Contains(prompt, "JSON schema") {
+		return "an album answer", nil
+	}
+	return "```json\n" + `{"released":"1997","label":"Parlophone","tracks":[{"number":"1","title":"Airbag","duration":"4:44"},{"number":"10","title":"No Surprises","duration":"3:49"}],"personnel":[{"name":"Nigel Godrich","roles":["producer","engineer"]}]}` + "\n```", nil
+}
+
+func TestAITracklist(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	completer = tracklistCompleter{}
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		return nil, errNoRelease
+	}
+
+	m.getInfo(m.newFetch())
+	var got string
+	for _, s := range m.sections {
+		if s.key == "tracklist" {
+			got = s.Content
+		}
+	}
+	for _, want := range []string{
+		"**Released:** 1997 · Parlophone",
+		" #  Title         Length\n",
+		" 1  Airbag          4:44\n",
+		"10  No Surprises    3:49\n",
+		"Nigel Godrich  producer, engineer\n",
+		"MusicBrainz does not know this album",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("the tracklist does not include %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestCompleteJSONInvalid(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	var v aiTracklist
+	if err := m.completeJSON(context.Background(), chatModel, "tracks", tracklistFunction, &v); err == nil || !strings.Contains(err.Error(), "not the JSON asked for") {
+		t.Errorf("got %v, want an invalid JSON error", err)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tabLabels are the short names of the built-in sections in the tab bar,
+// sections added in the config file use their title.
+var tabLabels = map[string]string{
+	"album":        "Album",
+	"tracklist":    "Tracks",
+	"features":     "Features",
+	"stories":      "Stories",
+	"wikipedia":    "Wikipedia",
+	"reception":    "Scores",
+	"discogs":      "Discogs",
+	"lastfm":       "Last.fm",
+	"similar":      "Similar",
+	"listenbrainz": "ListenBrainz",
+	"concerts":     "Concerts",
+	"reddit":       "Reddit",
+	"news":         "News",
+	"scene":        "Scene",
+	"review":       "Review",
+	"comparison":   "Compare",
+	"song":         "Song",
+	"bio":          "Bio",
+	"members":      "Members",
+	"discography":  "Discography",
+	"trivia":       "Trivia",
+	"show":         "Show",
+	"episode":      "Episode",
+	"hosts":        "Hosts",
+	"ask":          "Ask",
+}
+
+// sectionLabel is the short name of a section.
+func sectionLabel(s Section) string {
+	if label := tabLabels[s.key]; label != "" {
+		return label
+	}
+	return s.Title
+}
+
+var styleActiveTab = lipgloss.NewStyle().Bold(true).Underline(true).Render
+
+// tab is a page of the viewport. Lyrics tabs are rendered from the lyrics
+// instead of markdown.
+type tab struct {
+	label   string
+	content string
+	lyrics  bool
+	// usage is the tokens of the requests of a section tab.
+	usage tokenUsage
+}
+
+// tabs returns one tab per section, then the lyrics and the links. Sections
+// get a tab before their answer arrives so the numbers do not shift while
+// loading. The caller must hold m.mu.
+func (m *model) tabs() []tab {
+	var tabs []tab
+	for _, s := range m.sections {
+		label := sectionLabel(s)
+		content := s.Content
+		if s.Error != "" {
+			label = "⚠ " + label
+			content = "> ⚠ " + s.Error + "\n\n*" + trf("Press %s to retry this section.", keys.RetrySection.Help().Key) + "*"
+		} else if content == "" {
+			content = "*Nothing to show.*"
+			if m.loading {
+				content = "*" + tr("Loading...") + "*"
+			}
+		} else if s.streaming {
+			content += "\n\n*" + tr("Writing...") + "*"
+		}
+		tabs = append(tabs, tab{label: label, content: "## " + s.Title + "\n" + content, usage: s.usage})
+	}
+
+	if m.track != "" && !m.isPodcast() && sectionEnabled("lyrics") {
+		tabs = append(tabs, tab{label: "Lyrics", lyrics: true})
+	}
+
+	if m.links != nil {
+		tabs = append(tabs, tab{label: "Links", content: linksTabContent(m.namedLinks())})
+	}
+
+	return tabs
+}
+
+// currentTab returns the selected tab, falling back to the first one when
+// the selection does not exist yet.
+func (m *model) currentTab() (tab, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tabs := m.tabs()
+
+	if len(tabs) == 0 {
+		return tab{}, 0
+	}
+	if m.tab >= len(tabs) {
+		return tabs[0], 0
+	}
+	return tabs[m.tab], m.tab
+}
+
+func (m *model) tabCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.tabs())
+}
+
+// onLyricsTab reports whether the lyrics are shown.
+func (m *model) onLyricsTab() bool {
+	t, _ := m.currentTab()
+	return t.lyrics
+}
+
+// lyricsTabIndex returns the index of the lyrics tab, or -1.
+func (m *model) lyricsTabIndex() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.tabs() {
+		if t.lyrics {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectTab switches to the tab at index, each tab keeps its own scroll
+// position.
+func (m *model) selectTab(index int) error {
+	if index < 0 || index >= m.tabCount() || index == m.tab {
+		return nil
+	}
+
+	m.tabOffsets[m.tab] = m.viewport.YOffset
+	m.tab = index
+	if err := m.renderViewport(); err != nil {
+		return err
+	}
+	m.viewport.SetYOffset(m.tabOffsets[index])
+	return nil
+}
+
+// switchTab selects the tab at index for the user, the lyrics start
+// following the playback when they are shown.
+func (m *model) switchTab(index int) tea.Cmd {
+	wasLyrics := m.onLyricsTab()
+	m.tabChosen = true
+	if err := m.selectTab(index); err != nil {
+		return errorCmd(err)
+	}
+	if m.onLyricsTab() && !wasLyrics {
+		m.lyricsSeq++
+		return positionCmd(m.lyricsSeq)
+	}
+	return nil
+}
+
+// followFinishedSection shows the first section with an answer while the
+// selected one is still loading, so there is something to read. It stops
+// once a tab was chosen.
+func (m *model) followFinishedSection() {
+	if m.tabChosen {
+		return
+	}
+
+	m.mu.Lock()
+	follow := -1
+	if m.tab < len(m.sections) && m.sections[m.tab].Content == "" && m.sections[m.tab].Error == "" {
+		for i, s := range m.sections {
+			if s.Content != "" && !s.streaming {
+				follow = i
+				break
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if follow >= 0 {
+		m.tabOffsets[m.tab] = m.viewport.YOffset
+		m.tab = follow
+	}
+}
+
+// tabBarView renders the numbered tab titles, clipped to the viewport width.
+func (m *model) tabBarView() string {
+	m.mu.Lock()
+	tabs := m.tabs()
+	m.mu.Unlock()
+
+	_, current := m.currentTab()
+	labels := make([]string, len(tabs))
+	for i, t := range tabs {
+		label := fmt.Sprintf("%d %s", i+1, t.label)
+		if i == current {
+			labels[i] = styleActiveTab(label)
+		} else {
+			labels[i] = helpStyle(label)
+		}
+	}
+
+	bar := strings.Join(labels, helpStyle(" │ "))
+	return "  " + lipgloss.NewStyle().MaxWidth(m.viewportWidth()).Render(bar) + "\n"
+}
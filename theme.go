@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// themeName picks one of the themes, auto uses the dark or light one
+// depending on the terminal background.
+var themeName = "auto"
+
+// glamourStyle is the glamour style of the markdown: auto, a built-in style
+// name (dark, light, dracula, notty...) or the path of a JSON style.
+var glamourStyle = "auto"
+
+// glamourStyleNames are the built-in glamour styles, in the order M cycles
+// through them.
+var glamourStyleNames = []string{"auto", "dark", "light", "dracula", "notty", "pink", "ascii"}
+
+// glamourStylePath is the JSON style of the config, M cycles through it
+// after the built-in ones.
+var glamourStylePath string
+
+// checkGlamourStyle tells whether style is a built-in glamour style or the
+// path of a valid JSON style.
+func checkGlamourStyle(style string) error {
+	if containsString(glamourStyleNames, style) {
+		return nil
+	}
+	path := expandHome(style)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unknown glamour style %q, use %s or the path of a glamour JSON style", style, strings.Join(glamourStyleNames, ", "))
+	}
+	var config ansi.StyleConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("glamour style %s: %w", path, err)
+	}
+	return nil
+}
+
+// nextGlamourStyle is the style after the one in use, the JSON style of the
+// config follows the built-in ones.
+func nextGlamourStyle() string {
+	styles := glamourStyleNames
+	if glamourStylePath != "" {
+		styles = append(styles[:len(styles):len(styles)], glamourStylePath)
+	}
+	for i, s := range styles {
+		if s == glamourStyle {
+			return styles[(i+1)%len(styles)]
+		}
+	}
+	return styles[0]
+}
+
+// customColors are the colors set in the config file, they are applied on
+// top of the theme.
+var customColors Colors
+
+var themes = map[string]Colors{
+	"dark": {
+		Title:         "#b8ffcb",
+		Warning:       "#ff7cc8",
+		Badge:         "#FDFF8C",
+		Help:          "241",
+		Border:        "62",
+		ProgressStart: "#FF7CCB",
+		ProgressEnd:   "#FDFF8C",
+		Glamour:       "dark",
+	},
+	"light": {
+		Title:         "#1a7f37",
+		Warning:       "#cf222e",
+		Badge:         "#9a6700",
+		Help:          "245",
+		Border:        "25",
+		ProgressStart: "#8250df",
+		ProgressEnd:   "#0969da",
+		Glamour:       "light",
+	},
+	"dracula": {
+		Title:         "#50fa7b",
+		Warning:       "#ff5555",
+		Badge:         "#f1fa8c",
+		Help:          "#6272a4",
+		Border:        "#bd93f9",
+		ProgressStart: "#ff79c6",
+		ProgressEnd:   "#bd93f9",
+		Glamour:       "dracula",
+	},
+	"nord": {
+		Title:         "#a3be8c",
+		Warning:       "#bf616a",
+		Badge:         "#ebcb8b",
+		Help:          "#4c566a",
+		Border:        "#5e81ac",
+		ProgressStart: "#88c0d0",
+		ProgressEnd:   "#b48ead",
+		Glamour:       "dark",
+	},
+}
+
+func themeNames() []string {
+	names := []string{"auto"}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// applyTheme sets the colors of the named theme and then the custom colors.
+func applyTheme(name string) error {
+	t, ok := themes[name]
+	switch {
+	case name == "auto":
+		t = themes["light"]
+		if lipgloss.HasDarkBackground() {
+			t = themes["dark"]
+		}
+		// glamour detects the background on its own.
+		t.Glamour = "auto"
+	case !ok:
+		return fmt.Errorf("unknown theme %q, use %s", name, strings.Join(themeNames(), ", "))
+	}
+
+	applyColors(t)
+	applyColors(customColors)
+	return nil
+}
+
+// cycleGlamourStyle renders the markdown with the next glamour style. A JSON
+// style is read again, so edits to it show up.
+func (m *model) cycleGlamourStyle() {
+	previous := glamourStyle
+	glamourStyle = nextGlamourStyle()
+	if err := m.renderViewport(); err != nil {
+		m.errMsg = "  " + err.Error()
+		glamourStyle = previous
+		return
+	}
+	m.notice = "  " + trf("Markdown style: %s", glamourStyle)
+}
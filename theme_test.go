@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestApplyTheme(t *testing.T) {
+	defer func() {
+		customColors = Colors{}
+		applyTheme("dark")
+		glamourStyle = "auto"
+	}()
+
+	customColors = Colors{Border: "99"}
+	if err := applyTheme("light"); err != nil {
+		t.Fatal(err)
+	}
+	if glamourStyle != "light" || progressColors[0] != "#8250df" {
+		t.Errorf("got glamour style %q and progress colors %v", glamourStyle, progressColors)
+	}
+	if borderColor != lipgloss.Color("99") {
+		t.Errorf("custom border color was not applied, got %v", borderColor)
+	}
+
+	if _, err := renderContent("## Airbag", 40); err != nil {
+		t.Errorf("rendering with the light style: %v", err)
+	}
+
+	err := applyTheme("vaporwave")
+	if err == nil || !strings.Contains(err.Error(), "auto, dark, dracula, light, nord") {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestGlamourStyle(t *testing.T) {
+	defer func(style, path string) { glamourStyle, glamourStylePath = style, path }(glamourStyle, glamourStylePath)
+
+	dir := t.TempDir()
+	style := filepath.Join(dir, "style.json")
+	if err := os.WriteFile(style, []byte(`{"document":{"margin":1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"document":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for s, want := range map[string]string{"dracula": "", "auto": "", style: "", "bogus": "unknown glamour style", broken: "glamour style " + broken} {
+		if err := checkGlamourStyle(s); (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("checkGlamourStyle(%q) = %v, want %q", s, err, want)
+		}
+	}
+
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	glamourStyle, glamourStylePath = "ascii", style
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(keyRune('M'))
+	if glamourStyle != style || m.renderedFor.style != style || m.notice != "  Markdown style: "+style {
+		t.Errorf("M switched to %q, rendered with %q, notice %q", glamourStyle, m.renderedFor.style, m.notice)
+	}
+	m.Update(keyRune('M'))
+	if glamourStyle != "auto" {
+		t.Errorf("M switched to %q after the style of the config", glamourStyle)
+	}
+
+	// A style that no longer parses is not kept.
+	glamourStyle = "ascii"
+	if err := os.WriteFile(style, []byte(`{"document":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(keyRune('M'))
+	if glamourStyle != "ascii" || m.errMsg == "" {
+		t.Errorf("got style %q and error %q with a broken style", glamourStyle, m.errMsg)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// aiTimeout is how long a request to the AI provider may take, a streamed
+// answer until its last token. Zero waits for as long as the provider takes.
+var aiTimeout = time.Minute
+
+// slowAfter is how long a section waits for its answer before the progress
+// tells for how long.
+const slowAfter = 10 * time.Second
+
+var errCanceled = errors.New("canceled")
+
+// withAITimeout runs call with a context ending after aiTimeout. A timed out
+// request is not retried, the provider is unlikely to answer sooner.
+func withAITimeout(ctx context.Context, call func(ctx context.Context) error) error {
+	if aiTimeout <= 0 {
+		return call(ctx)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, aiTimeout)
+	defer cancel()
+	err := call(callCtx)
+	if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("timed out after %s", aiTimeout)
+	}
+	return err
+}
+
+// sectionRequest returns the context of the AI requests of the section at
+// index, canceled with keys.CancelSection. done reports the cancel as
+// errCanceled.
+func (m *model) sectionRequest(ctx context.Context, index int) (reqCtx context.Context, done func(error) error) {
+	reqCtx, cancel := context.WithCancel(ctx)
+	m.whileCurrent(ctx, func() {
+		m.sections[index].started = time.Now()
+		m.sections[index].cancelRequest = cancel
+	})
+	return reqCtx, func(err error) error {
+		m.whileCurrent(ctx, func() {
+			m.sections[index].started = time.Time{}
+			m.sections[index].cancelRequest = nil
+		})
+		canceled := reqCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if canceled {
+			return errCanceled
+		}
+		return err
+	}
+}
+
+// waitingView tells how long the section has been waiting for its answer,
+// empty until slowAfter. The caller must hold m.mu.
+func waitingView(s Section) string {
+	if s.started.IsZero() || time.Since(s.started) < slowAfter {
+		return ""
+	}
+	return trf("still working… (%ds)", int(time.Since(s.started).Seconds()))
+}
+
+// cancelSections cancels the sections waiting for the AI provider, r asks
+// again. The sections done are kept.
+func (m *model) cancelSections() {
+	m.mu.Lock()
+	var labels []string
+	for i := range m.sections {
+		if s := &m.sections[i]; s.cancelRequest != nil {
+			s.cancelRequest()
+			labels = append(labels, sectionLabel(*s))
+		}
+	}
+	m.mu.Unlock()
+
+	if len(labels) > 0 {
+		m.notice = "  " + trf("Canceled: %s", strings.Join(labels, ", "))
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"image"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAITimeout(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(d time.Duration) { aiTimeout = d }(aiTimeout)
+	aiTimeout = 50 * time.Millisecond
+	completer = blockingCompleter{started: make(chan struct{}, 20), canceled: make(chan struct{}, 20)}
+
+	m.getInfo(context.Background())
+	if got, want := m.sections[0].Error, provider+" api: timed out after 50ms"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+	if m.sections[2].Error != "" || m.retries != 0 {
+		t.Errorf("got error %q and %d retries", m.sections[2].Error, m.retries)
+	}
+}
+
+func TestCancelSections(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(d time.Duration) { aiTimeout = d }(aiTimeout)
+	aiTimeout = 2 * time.Second
+	c := blockingCompleter{started: make(chan struct{}, 20), canceled: make(chan struct{}, 20)}
+	completer = c
+	getArtwork = func(ctx context.Context, info MusicInfo) (image.Image, error) { return nil, errNoArtwork }
+
+	m.startFetch()
+	<-c.started
+	<-c.started
+	// The Wikipedia section does not wait for the provider, it is kept when
+	// the others are canceled.
+	wikipedia := -1
+	waitFor(t, m, func() bool {
+		for i, s := range m.sections {
+			if s.key == "wikipedia" && s.Content != "" {
+				wikipedia = i
+			}
+		}
+		return wikipedia >= 0
+	})
+
+	m.mu.Lock()
+	m.sections[0].started = time.Now().Add(-35 * time.Second)
+	m.mu.Unlock()
+	if view := m.progressView(); !strings.Contains(view, "still working… (35s) • X: Cancel") {
+		t.Errorf("got progress %q", view)
+	}
+
+	m.Update(keyRune('X'))
+	if !strings.Contains(m.notice, "Canceled: ") {
+		t.Errorf("got notice %q", m.notice)
+	}
+	waitFor(t, m, func() bool { return m.steps > 0 && m.stepsDone >= m.steps })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if got := m.sections[0].Error; got != provider+" api: canceled" {
+		t.Errorf("got error %q for the canceled section", got)
+	}
+	if s := m.sections[wikipedia]; s.Error != "" || !strings.Contains(s.Content, "An English rock band.") {
+		t.Errorf("the section done was not kept: %+v", s)
+	}
+}
+
+// summaryCompleter waits for a cancel on the summaries of the sources.
+type summaryCompleter struct{ blockingCompleter }
+
+func (c summaryCompleter) Complete(ctx context.Context, model string, prompt string) (string, error) {
+	if !strings.HasPrefix(prompt, "Sum up") {
+		return "answer for: " + prompt, nil
+	}
+	return c.blockingCompleter.Complete(ctx, model, "OK Computer")
+}
+
+func TestCancelSummary(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	c := summaryCompleter{blockingCompleter{started: make(chan struct{}, 20), canceled: make(chan struct{}, 20)}}
+	completer = c
+	newsEnabled, newsSummary = true, true
+	getNews = func(ctx context.Context, info MusicInfo) ([]NewsItem, error) {
+		return []NewsItem{{Title: "OK Computer reissue", Link: "https://example.com/reissue", Source: "Pitchfork"}}, nil
+	}
+	defer func() {
+		newsEnabled, newsSummary = false, false
+		getNews = fetchNews
+	}()
+	// The fetch reads them until it is done.
+	defer waitFetch(m)
+
+	m.startFetch()
+	<-c.started
+	m.mu.Lock()
+	var labels []string
+	for _, s := range m.sections {
+		if s.cancelRequest != nil {
+			labels = append(labels, s.key)
+		}
+	}
+	m.mu.Unlock()
+	if len(labels) != 1 || labels[0] != "news" {
+		t.Fatalf("got the sections %v waiting", labels)
+	}
+
+	m.Update(keyRune('X'))
+	waitFor(t, m, func() bool { return m.steps > 0 && m.stepsDone >= m.steps })
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errMsg != "  summary: canceled" {
+		t.Errorf("got error %q", m.errMsg)
+	}
+	for _, s := range m.sections {
+		if s.key == "news" && !strings.Contains(s.Content, "OK Computer reissue") {
+			t.Errorf("the news were not kept: %+v", s)
+		}
+	}
+}
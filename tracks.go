@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errNoAlbumTracks = errors.New("no tracklist found for this album")
+
+// getAlbumTracks and playAlbumTrack are replaced in tests.
+var (
+	getAlbumTracks = fetchAlbumTracks
+	playAlbumTrack = startAlbumTrack
+)
+
+// albumTrack is a track of the album screen. The MusicBrainz tracks have no
+// uri, they can not be played.
+type albumTrack struct {
+	number string
+	title  string
+	length time.Duration
+	uri    string
+}
+
+// albumTracks is the tracklist of an album and where it comes from, uri is
+// the Spotify album the tracks play in.
+type albumTracks struct {
+	source string
+	uri    string
+	tracks []albumTrack
+}
+
+type albumTracksMsg struct {
+	info  MusicInfo
+	album albumTracks
+	err   error
+}
+
+// tracksView is the tracklist of the album playing, enter plays the track
+// under the cursor.
+type tracksView struct {
+	info    MusicInfo
+	loading bool
+	err     string
+	album   albumTracks
+	cursor  int
+}
+
+// fetchAlbumTracks returns the tracks of the album on Spotify when the Web
+// API is logged in, so they can be played, or else the ones of MusicBrainz.
+func fetchAlbumTracks(ctx context.Context, info MusicInfo) (albumTracks, error) {
+	if spotifyClientID != "" && spotifyLoggedIn() {
+		return spotifyAlbumTracks(ctx, info)
+	}
+
+	release, err := getRelease(ctx, info)
+	if errors.Is(err, errNoRelease) {
+		return albumTracks{}, errNoAlbumTracks
+	}
+	if err != nil {
+		return albumTracks{}, err
+	}
+	album := albumTracks{source: "MusicBrainz"}
+	for i, medium := range release.Media {
+		for _, t := range medium.Tracks {
+			number := t.Number
+			if len(release.Media) > 1 {
+				number = strconv.Itoa(i+1) + "-" + number
+			}
+			album.tracks = append(album.tracks, albumTrack{number: number, title: t.Title, length: time.Duration(t.Length) * time.Millisecond})
+		}
+	}
+	if len(album.tracks) == 0 {
+		return albumTracks{}, errNoAlbumTracks
+	}
+	return album, nil
+}
+
+func spotifyAlbumTracks(ctx context.Context, info MusicInfo) (albumTracks, error) {
+	api := newSpotifyWebPlayer(spotifyClientID)
+	var search struct {
+		Albums struct {
+			Items []struct {
+				ID  string `json:"id"`
+				URI string `json:"uri"`
+			} `json:"items"`
+		} `json:"albums"`
+	}
+	query := "album:" + info.album + " artist:" + info.artist
+	if err := api.get(ctx, "/search?type=album&limit=1&q="+url.QueryEscape(query), &search); err != nil {
+		return albumTracks{}, err
+	}
+	if len(search.Albums.Items) == 0 {
+		return albumTracks{}, errNoAlbumTracks
+	}
+
+	var page struct {
+		Items []struct {
+			Name        string `json:"name"`
+			URI         string `json:"uri"`
+			DurationMs  int    `json:"duration_ms"`
+			TrackNumber int    `json:"track_number"`
+			DiscNumber  int    `json:"disc_number"`
+		} `json:"items"`
+	}
+	found := search.Albums.Items[0]
+	if err := api.get(ctx, "/albums/"+found.ID+"/tracks?limit=50", &page); err != nil {
+		return albumTracks{}, err
+	}
+
+	album := albumTracks{source: "Spotify", uri: found.URI}
+	discs := 1
+	for _, t := range page.Items {
+		if t.DiscNumber > discs {
+			discs = t.DiscNumber
+		}
+	}
+	for _, t := range page.Items {
+		number := strconv.Itoa(t.TrackNumber)
+		if discs > 1 {
+			number = strconv.Itoa(t.DiscNumber) + "-" + number
+		}
+		album.tracks = append(album.tracks, albumTrack{number: number, title: t.Name, length: time.Duration(t.DurationMs) * time.Millisecond, uri: t.URI})
+	}
+	if len(album.tracks) == 0 {
+		return albumTracks{}, errNoAlbumTracks
+	}
+	return album, nil
+}
+
+// startAlbumTrack plays the album from the track on the active device, so
+// the rest of the album follows it.
+func startAlbumTrack(ctx context.Context, album albumTracks, t albumTrack) error {
+	body := map[string]any{"context_uri": album.uri, "offset": map[string]string{"uri": t.uri}}
+	return newSpotifyWebPlayer(spotifyClientID).send(ctx, http.MethodPut, "/me/player/play", body, nil)
+}
+
+// playing reports whether t is the track of info.
+func (t albumTrack) playing(info MusicInfo) bool {
+	return strings.EqualFold(t.title, info.track)
+}
+
+// openTracks shows the tracklist of the album, it comes back as an
+// albumTracksMsg.
+func (m *model) openTracks() tea.Cmd {
+	info := m.MusicInfo
+	m.tracks = &tracksView{info: info, loading: true}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		album, err := getAlbumTracks(ctx, info)
+		return albumTracksMsg{info: info, album: album, err: err}
+	}
+}
+
+// tracksLoaded shows the tracklist with the cursor on the track playing.
+func (m *model) tracksLoaded(msg albumTracksMsg) {
+	v := m.tracks
+	if v == nil || v.info != msg.info {
+		return
+	}
+	v.loading = false
+	if msg.err != nil {
+		v.err = msg.err.Error()
+		return
+	}
+	v.album = msg.album
+	for i, t := range v.album.tracks {
+		if t.playing(m.MusicInfo) {
+			v.cursor = i
+			break
+		}
+	}
+}
+
+func (m *model) updateTracks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.tracks
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.tracks = nil
+	case tea.KeyUp:
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case tea.KeyDown:
+		if v.cursor < len(v.album.tracks)-1 {
+			v.cursor++
+		}
+	case tea.KeyEnter:
+		if v.loading || v.cursor >= len(v.album.tracks) {
+			return m, nil
+		}
+		album, t := v.album, v.album.tracks[v.cursor]
+		if t.uri == "" {
+			m.errMsg = "  spotify: playing a track needs spotify_client_id in the config file and stui -spotify-login"
+			return m, nil
+		}
+		info := MusicInfo{artist: v.info.artist, album: v.info.album, track: t.title}
+		m.notice = "  " + trf("Playing %s", t.title)
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return libraryPlayMsg{info: info, err: playAlbumTrack(ctx, album, t)}
+		}
+	}
+	return m, nil
+}
+
+func (m *model) tracksScreenView() string {
+	v := m.tracks
+	pad := strings.Repeat(" ", padding)
+
+	var b strings.Builder
+	b.WriteString(styleTitle(pad+v.info.album) + "\n\n")
+	switch {
+	case v.loading:
+		b.WriteString(pad + helpStyle(tr("Loading...")) + "\n")
+	case v.err != "":
+		b.WriteString(pad + styleWarning(v.err) + "\n")
+	default:
+		b.WriteString(pad + helpStyle(v.info.artist+" · "+v.album.source) + "\n\n")
+	}
+
+	// The lengths line up right of the longest title that fits.
+	numberWidth, titleWidth := 0, 0
+	for _, t := range v.album.tracks {
+		if w := lipgloss.Width(t.number); w > numberWidth {
+			numberWidth = w
+		}
+		if w := lipgloss.Width(t.title); w > titleWidth {
+			titleWidth = w
+		}
+	}
+	if limit := m.width - padding*2 - numberWidth - 16; m.width > 0 && titleWidth > limit {
+		titleWidth = limit
+	}
+	if titleWidth < 1 {
+		titleWidth = 1
+	}
+
+	rows := m.height - 8
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if v.cursor >= rows {
+		start = v.cursor - rows + 1
+	}
+	for i := start; i < len(v.album.tracks) && i < start+rows; i++ {
+		t := v.album.tracks[i]
+		title := lipgloss.NewStyle().MaxWidth(titleWidth).Render(t.title)
+		title += strings.Repeat(" ", titleWidth-lipgloss.Width(title))
+		number := fmt.Sprintf("%*s", numberWidth, t.number)
+		var length string
+		if t.length > 0 {
+			length = formatPlaybackTime(t.length)
+		}
+		line := number + "  " + title + "  " + fmt.Sprintf("%5s", length)
+
+		marker := "  "
+		if t.playing(m.MusicInfo) {
+			marker = "♪ "
+		}
+		switch {
+		case i == v.cursor:
+			b.WriteString(pad + styleBadge("› "+marker+line) + "\n")
+		case t.playing(m.MusicInfo):
+			b.WriteString(pad + "  " + styleActiveTab(marker+line) + "\n")
+		default:
+			b.WriteString(pad + "  " + marker + line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + helpStyle(tr("↑/↓: Select • enter: Play • esc: Back")))
+	return b.String()
+}
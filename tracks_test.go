@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSpotifyAlbumTracks(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := (&spotifyToken{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}).save(); err != nil {
+		t.Fatal(err)
+	}
+	defer func(id string) { spotifyClientID = id }(spotifyClientID)
+	spotifyClientID = "client"
+
+	var played map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			if q := r.URL.Query(); q.Get("q") != "album:OK Computer artist:Radiohead" || q.Get("type") != "album" {
+				t.Errorf("got query %v", q)
+			}
+			w.Write([]byte(`{"albums":{"items":[{"id":"okc","uri":"spotify:album:okc"}]}}`))
+		case r.URL.Path == "/albums/okc/tracks":
+			w.Write([]byte(`{"items":[{"name":"Airbag","uri":"spotify:track:1","duration_ms":284000,"track_number":1,"disc_number":1},
+				{"name":"Paranoid Android","uri":"spotify:track:2","duration_ms":383000,"track_number":2,"disc_number":1}]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/me/player/play":
+			json.NewDecoder(r.Body).Decode(&played)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(api string) { spotifyAPIURL = api }(spotifyAPIURL)
+	spotifyAPIURL = server.URL
+
+	album, err := fetchAlbumTracks(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if album.uri != "spotify:album:okc" || len(album.tracks) != 2 || album.tracks[1] != (albumTrack{number: "2", title: "Paranoid Android", length: 383 * time.Second, uri: "spotify:track:2"}) {
+		t.Fatalf("got %+v", album)
+	}
+
+	if err := startAlbumTrack(context.Background(), album, album.tracks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if offset, _ := played["offset"].(map[string]any); played["context_uri"] != "spotify:album:okc" || offset["uri"] != "spotify:track:2" {
+		t.Errorf("played %v", played)
+	}
+}
+
+func TestMusicBrainzAlbumTracks(t *testing.T) {
+	setupTest(t, PlayerPlaying)
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		var r Release
+		err := json.Unmarshal([]byte(`{"media":[{"tracks":[{"number":"1","title":"Airbag","length":284000}]},{"tracks":[{"number":"1","title":"Lucky"}]}]}`), &r)
+		return &r, err
+	}
+
+	album, err := fetchAlbumTracks(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []albumTrack{{number: "1-1", title: "Airbag", length: 284 * time.Second}, {number: "2-1", title: "Lucky"}}
+	if album.source != "MusicBrainz" || len(album.tracks) != 2 || album.tracks[0] != want[0] || album.tracks[1] != want[1] {
+		t.Errorf("got %+v", album)
+	}
+
+	getRelease = func(ctx context.Context, info MusicInfo) (*Release, error) {
+		return nil, errNoRelease
+	}
+	if _, err := fetchAlbumTracks(context.Background(), testTrack); err != errNoAlbumTracks {
+		t.Errorf("got %v, want errNoAlbumTracks", err)
+	}
+}
+
+func TestTracks(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	m.getInfo(context.Background())
+	m.loading = false
+	m.width, m.height = 100, 40
+	if err := m.renderViewport(); err != nil {
+		t.Fatal(err)
+	}
+
+	var played albumTrack
+	getAlbumTracks = func(ctx context.Context, info MusicInfo) (albumTracks, error) {
+		return albumTracks{source: "Spotify", uri: "spotify:album:okc", tracks: []albumTrack{
+			{number: "1", title: "Airbag", length: 284 * time.Second, uri: "spotify:track:1"},
+			{number: "2", title: "Paranoid Android", length: 383 * time.Second, uri: "spotify:track:2"},
+		}}, nil
+	}
+	playAlbumTrack = func(ctx context.Context, album albumTracks, t albumTrack) error {
+		played = t
+		return nil
+	}
+	defer func() {
+		getAlbumTracks = fetchAlbumTracks
+		playAlbumTrack = startAlbumTrack
+	}()
+
+	_, cmd := m.Update(keyRune('T'))
+	if m.tracks == nil || cmd == nil {
+		t.Fatal("T did not open the tracklist")
+	}
+	m.Update(cmd())
+	view := m.View()
+	for _, want := range []string{"♪ 1  Airbag             4:44", "2  Paranoid Android   6:23"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("the tracklist does not include %q:\n%s", want, view)
+		}
+	}
+	if m.tracks.cursor != 0 {
+		t.Errorf("the cursor is on %d, not on the track playing", m.tracks.cursor)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+	paranoid := MusicInfo{artist: "Radiohead", album: "OK Computer", track: "Paranoid Android"}
+	if played.uri != "spotify:track:2" || m.tracks != nil || m.MusicInfo != paranoid {
+		t.Errorf("played %+v, got track %+v", played, m.MusicInfo)
+	}
+	waitFetched(t, m)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// translateTo is the language the lyrics are translated to, empty uses the
+// language of the AI answers or English.
+var translateTo string
+
+type translationMsg struct {
+	info  MusicInfo
+	lines []string
+	err   error
+}
+
+func translationLanguage() string {
+	switch {
+	case translateTo != "":
+		return translateTo
+	case language != "":
+		return language
+	}
+	return "English"
+}
+
+// lyricsLines are the lines of the lyrics as shown, the synced ones when
+// there are any.
+func lyricsLines(lyrics *Lyrics, synced []lyricLine) []string {
+	if len(synced) == 0 {
+		return strings.Split(lyrics.Plain, "\n")
+	}
+	lines := make([]string, len(synced))
+	for i, l := range synced {
+		lines[i] = l.text
+	}
+	return lines
+}
+
+func translationPrompt(info MusicInfo, lines []string, lang string) string {
+	return fmt.Sprintf("Translate the lyrics of %q by %s into %s. Answer only with the translation, one line for every line of the lyrics "+
+		"in the same order, keep the empty lines empty and do not add notes. The lyrics are:\n\n%s", info.track, info.artist, lang, strings.Join(lines, "\n"))
+}
+
+// parseTranslation splits the answer into n lines to show next to the
+// original ones, even when the model merged or split some.
+func parseTranslation(answer string, n int) []string {
+	answer = strings.Trim(answer, "\n")
+	answer = strings.TrimPrefix(strings.TrimSuffix(answer, "```"), "```")
+	lines := strings.Split(strings.Trim(answer, "\n"), "\n")
+	for len(lines) < n {
+		lines = append(lines, "")
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines[:n]
+}
+
+// toggleTranslation shows the lyrics translated side by side with the
+// original, or hides them. The first time the AI provider translates them,
+// the result comes back as a translationMsg.
+func (m *model) toggleTranslation() tea.Cmd {
+	m.mu.Lock()
+	lyrics, synced := m.lyrics, m.syncedLyrics
+	m.mu.Unlock()
+	if !m.hasContent() || !m.onLyricsTab() || lyrics == nil || lyrics.Instrumental || m.translating {
+		return nil
+	}
+	if m.translation != nil {
+		m.showTranslation = !m.showTranslation
+		if err := m.renderViewport(); err != nil {
+			return errorCmd(err)
+		}
+		return nil
+	}
+
+	lang := translationLanguage()
+	m.translating = true
+	m.notice = "  " + trf("Translating the lyrics to %s", lang)
+	info, lines := m.MusicInfo, lyricsLines(lyrics, synced)
+	return func() tea.Msg {
+		ctx := context.Background()
+		prompt := translationPrompt(info, lines, lang)
+		key := cacheKey(info, trackScope, chatModel, prompt)
+		content, cached := cachedAnswer(ctx, key)
+		if !cached {
+			var err error
+			content, err = m.complete(ctx, chatModel, prompt)
+			if err != nil {
+				return translationMsg{info: info, err: fmt.Errorf("%s api: %w", provider, err)}
+			}
+			writeCache(key, content)
+		}
+		return translationMsg{info: info, lines: parseTranslation(content, len(lines))}
+	}
+}
+
+func (m *model) lyricsTranslated(msg translationMsg) {
+	// The track changed while translating.
+	if msg.info != m.MusicInfo {
+		return
+	}
+	m.translating = false
+	m.notice = ""
+	if msg.err != nil {
+		m.errMsg = "  translation: " + msg.err.Error()
+		return
+	}
+	m.translation = msg.lines
+	m.showTranslation = true
+	if m.hasContent() && m.onLyricsTab() {
+		if err := m.renderViewport(); err != nil {
+			m.fail(err)
+		}
+	}
+}
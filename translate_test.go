@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+func TestParseTranslation(t *testing.T) {
+	got := parseTranslation("```\nEn la próxima guerra mundial\n  En un camión volcado \n```", 3)
+	want := []string{"En la próxima guerra mundial", "En un camión volcado", ""}
+	if len(got) != len(want) {
+		t.Fatalf("got %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d is %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if got := parseTranslation("one\ntwo\nthree", 2); len(got) != 2 || got[1] != "two" {
+		t.Errorf("got %q, want the extra lines dropped", got)
+	}
+}
+
+func TestTranslateLyrics(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(l string) { translateTo = l }(translateTo)
+	translateTo = "Spanish"
+	m.startFetch()
+
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+	waitReady(t, tm, m)
+
+	tm.Send(keyRune('l'))
+	teatest.WaitFor(t, tm.Output(), contains("jackknifed juggernaut"))
+	tm.Send(keyRune('t'))
+	teatest.WaitFor(t, tm.Output(), contains("stub answer for"))
+
+	tm.Send(keyRune('t'))
+	tm.Send(keyRune('q'))
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(*model)
+
+	if len(fm.translation) != 2 {
+		t.Fatalf("got translation %q, want a line for every line of the lyrics", fm.translation)
+	}
+	if fm.showTranslation {
+		t.Error("t again should hide the translation")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ernesto27/stui/pkg/ai"
+)
+
+// tokenUsage counts the tokens of provider requests. Cost is the estimate in
+// USD of the requests to models with a known price.
+type tokenUsage struct {
+	requests         int
+	promptTokens     int
+	completionTokens int
+	cost             float64
+	// estimated is set when a provider did not report the tokens and they
+	// were guessed from the text.
+	estimated bool
+}
+
+func (u *tokenUsage) add(o tokenUsage) {
+	u.requests += o.requests
+	u.promptTokens += o.promptTokens
+	u.completionTokens += o.completionTokens
+	u.cost += o.cost
+	u.estimated = u.estimated || o.estimated
+}
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	Input  float64 `toml:"input"`
+	Output float64 `toml:"output"`
+}
+
+// modelPrices are matched by the longest prefix of the model name, the
+// prices table of the config file adds to them.
+var modelPrices = map[string]Price{
+	"gpt-3.5-turbo":     {0.5, 1.5},
+	"gpt-4":             {30, 60},
+	"gpt-4-turbo":       {10, 30},
+	"gpt-4o":            {2.5, 10},
+	"gpt-4o-mini":       {0.15, 0.6},
+	"gpt-4.1":           {2, 8},
+	"gpt-4.1-mini":      {0.4, 1.6},
+	"claude-3-haiku":    {0.25, 1.25},
+	"claude-3-opus":     {15, 75},
+	"claude-3-5-haiku":  {0.8, 4},
+	"claude-3-5-sonnet": {3, 15},
+	"gemini-1.5-flash":  {0.075, 0.3},
+	"gemini-1.5-pro":    {1.25, 5},
+}
+
+// modelPrice returns the price of model, models run by Ollama are free. The
+// vendor of the OpenRouter names, such as openai/gpt-4o, is not needed in
+// the prices.
+func modelPrice(model string) (Price, bool) {
+	if provider == "ollama" {
+		return Price{}, true
+	}
+
+	var best string
+	_, name, _ := strings.Cut(model, "/")
+	for prefix := range modelPrices {
+		if (strings.HasPrefix(model, prefix) || name != "" && strings.HasPrefix(name, prefix)) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return modelPrices[best], true
+}
+
+// requestUsage is the usage of one request, without a cost when the price
+// of the model is not known.
+func requestUsage(model string, promptTokens, completionTokens int, estimated bool) tokenUsage {
+	u := tokenUsage{requests: 1, promptTokens: promptTokens, completionTokens: completionTokens, estimated: estimated}
+	if price, ok := modelPrice(model); ok {
+		u.cost = (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6
+	}
+	return u
+}
+
+type usageKey struct{}
+
+// withUsage makes the providers report the tokens of the requests of ctx to
+// fn, usageKey marks the requests already counted.
+func withUsage(ctx context.Context, fn func(model string, u tokenUsage)) context.Context {
+	ctx = context.WithValue(ctx, usageKey{}, true)
+	return ai.WithUsage(ctx, func(u ai.Usage) {
+		debugLog("usage", "model", u.Model, "prompt_tokens", u.PromptTokens, "completion_tokens", u.CompletionTokens, "estimated", u.Estimated)
+		fn(u.Model, requestUsage(u.Model, u.PromptTokens, u.CompletionTokens, u.Estimated))
+	})
+}
+
+// countUsage adds the requests of ctx to the session total, to the section
+// at index unless it is -1, and to the history.
+func (m *model) countUsage(ctx context.Context, index int) context.Context {
+	return withUsage(ctx, func(model string, u tokenUsage) {
+		m.mu.Lock()
+		m.usage.add(u)
+		m.todayUsage()
+		m.today.add(u)
+		if index >= 0 && ctx.Err() == nil && index < len(m.sections) {
+			m.sections[index].usage.add(u)
+		}
+		info := m.MusicInfo
+		m.mu.Unlock()
+
+		if history == nil {
+			return
+		}
+		goSafe(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			history.RecordUsage(ctx, info.artist, info.album, info.track, model, u.promptTokens, u.completionTokens, u.cost, time.Now())
+		})
+	})
+}
+
+// formatTokens shortens large token counts, 1234 is 1.2k and 12345 is 12k.
+func formatTokens(n int) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e4:
+		return fmt.Sprintf("%.0fk", float64(n)/1e3)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprint(n)
+}
+
+// formatCost shows cents with more digits, the requests of a track cost a
+// fraction of one.
+func formatCost(cost float64) string {
+	if cost < 1 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// view is the token counts and the estimated cost, "~" marks estimates.
+func (u tokenUsage) view() string {
+	approx := ""
+	if u.estimated {
+		approx = "~"
+	}
+	s := trf("tokens: %s in, %s out", approx+formatTokens(u.promptTokens), approx+formatTokens(u.completionTokens))
+	if u.cost > 0 || provider == "ollama" {
+		s += " • ~" + formatCost(u.cost)
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestRequestUsage(t *testing.T) {
+	tests := []struct {
+		model string
+		cost  float64
+	}{
+		{"gpt-4o-2024-08-06", 0.0075},
+		{"gpt-4o-mini", 0.00045},
+		{"claude-3-5-sonnet-latest", 0.0105},
+		{"openai/gpt-4o-mini", 0.00045},
+		{"mistral-large", 0},
+	}
+	for _, tt := range tests {
+		u := requestUsage(tt.model, 1000, 500, false)
+		if u.requests != 1 || u.promptTokens != 1000 || u.completionTokens != 500 {
+			t.Errorf("%s: got %+v", tt.model, u)
+		}
+		if diff := u.cost - tt.cost; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: got cost %v, want %v", tt.model, u.cost, tt.cost)
+		}
+	}
+}
+
+func TestUsageView(t *testing.T) {
+	u := tokenUsage{requests: 3, promptTokens: 12345, completionTokens: 980, cost: 0.0421}
+	if got := u.view(); got != "tokens: 12k in, 980 out • ~$0.0421" {
+		t.Errorf("got %q", got)
+	}
+
+	u = tokenUsage{requests: 1, promptTokens: 1500, completionTokens: 20, estimated: true}
+	if got := u.view(); got != "tokens: ~1.5k in, ~20 out" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// vaultDir is the folder of a notes vault, such as Obsidian, that gets a note
+// per album and per track. vaultAuto writes the notes of every track in
+// watch mode, otherwise they are written with the V key.
+var (
+	vaultDir         string
+	vaultAuto        bool
+	vaultFrontmatter = defaultVaultFrontmatter
+)
+
+// defaultVaultFrontmatter is the template of the YAML frontmatter of the
+// notes, Track is empty for the album notes.
+const defaultVaultFrontmatter = `artist: {{yaml .Artist}}
+album: {{yaml .Album}}
+{{- if .Track}}
+track: {{yaml .Track}}
+{{- end}}
+tags: [{{join .Tags ", "}}]
+date: {{.Date}}`
+
+// vaultSongSections are the sections about the track, the others go to the
+// album note.
+var vaultSongSections = map[string]bool{"song": true, "features": true, "stories": true}
+
+// vaultNote is the data of the frontmatter template.
+type vaultNote struct {
+	Artist string
+	Album  string
+	Track  string
+	Tags   []string
+	Date   string
+	Rating int
+	Genre  string
+	Mood   string
+}
+
+var vaultFuncs = template.FuncMap{
+	"join": strings.Join,
+	// yaml quotes a value, a JSON string is a valid YAML one.
+	"yaml": strconv.Quote,
+}
+
+// vaultNoteName is the file name of a note without the extension, wiki
+// links use it.
+func vaultNoteName(info MusicInfo) string {
+	return strings.TrimSuffix(exportFileName(info, "markdown"), ".md")
+}
+
+// vaultTag turns a genre into an Obsidian tag, which can not have spaces.
+func vaultTag(s string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '&' || r == ',' {
+			return '-'
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s))), "-")
+}
+
+func renderFrontmatter(note vaultNote) (string, error) {
+	tmpl, err := template.New("frontmatter").Funcs(vaultFuncs).Parse(vaultFrontmatter)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, note); err != nil {
+		return "", err
+	}
+	return "---\n" + strings.TrimSpace(b.String()) + "\n---\n\n", nil
+}
+
+// writeVault writes the note of the track, linked to the note of its album.
+// The album note is only written once, so edits made in the vault are kept,
+// later tracks are added to its list of tracks. It returns the path of the
+// track note.
+func (m *model) writeVault() (string, error) {
+	m.mu.Lock()
+	info := m.MusicInfo
+	var albumContent, songContent strings.Builder
+	for _, s := range m.sections {
+		if s.Content == "" {
+			continue
+		}
+		content := &albumContent
+		if vaultSongSections[s.key] {
+			content = &songContent
+		}
+		fmt.Fprintf(content, "## %s\n\n%s\n\n", s.Title, strings.TrimSpace(s.Content))
+	}
+	note := vaultNote{Artist: info.artist, Album: info.album, Tags: []string{"music"}, Date: time.Now().Format("2006-01-02")}
+	if m.summary != nil {
+		note.Rating, note.Genre, note.Mood = m.summary.Rating, m.summary.Genre, m.summary.Mood
+		if tag := vaultTag(m.summary.Genre); tag != "" {
+			note.Tags = append(note.Tags, tag)
+		}
+	}
+	if m.lyrics != nil && m.lyrics.Plain != "" {
+		songContent.WriteString("## Lyrics\n\n" + strings.ReplaceAll(m.lyrics.Plain, "\n", "  \n") + "\n")
+	}
+	m.mu.Unlock()
+
+	if info.artist == "" || info.album == "" || info.track == "" {
+		return "", fmt.Errorf("the notes need the artist, album and track")
+	}
+
+	dir := expandHome(vaultDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	album := vaultNoteName(MusicInfo{artist: info.artist, album: info.album})
+	track := vaultNoteName(info)
+	if err := writeAlbumNote(filepath.Join(dir, album+".md"), note, albumContent.String(), track); err != nil {
+		return "", err
+	}
+
+	note.Track = info.track
+	frontmatter, err := renderFrontmatter(note)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, track+".md")
+	doc := frontmatter + fmt.Sprintf("# %s\n\n%s · [[%s]]\n\n", info.track, info.artist, album) + songContent.String()
+	if err := os.WriteFile(path, []byte(strings.TrimRight(doc, "\n")+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeAlbumNote creates the album note with its content, or adds the track
+// to the tracks of the note already in the vault.
+func writeAlbumNote(path string, note vaultNote, content string, track string) error {
+	link := "- [[" + track + "]]\n"
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if bytes.Contains(data, []byte("[["+track+"]]")) {
+			return nil
+		}
+		if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+			link = "\n" + link
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(link); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	frontmatter, err := renderFrontmatter(note)
+	if err != nil {
+		return err
+	}
+	doc := frontmatter + fmt.Sprintf("# %s\n\n%s\n\n", note.Album, note.Artist) + content + "## Tracks\n\n" + link
+	return os.WriteFile(path, []byte(doc), 0o644)
+}
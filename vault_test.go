@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteVault(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+	defer func(dir string) { vaultDir = dir }(vaultDir)
+	vaultDir = t.TempDir()
+
+	m.getInfo(context.Background())
+	m.summary = &Summary{Rating: 9, Genre: "Alternative rock", Mood: "Anxious"}
+	path, err := m.writeVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(vaultDir, "Radiohead - OK Computer - Airbag.md") {
+		t.Errorf("got path %s", path)
+	}
+
+	track, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"---\nartist: \"Radiohead\"\nalbum: \"OK Computer\"\ntrack: \"Airbag\"\ntags: [music, alternative-rock]\n", "[[Radiohead - OK Computer]]", "## Song", "## Lyrics"} {
+		if !strings.Contains(string(track), want) {
+			t.Errorf("track note does not include %q:\n%s", want, track)
+		}
+	}
+	if strings.Contains(string(track), "## Album info") {
+		t.Errorf("track note has the album sections:\n%s", track)
+	}
+
+	// The album note is kept, the next track is only added to its tracks.
+	albumPath := filepath.Join(vaultDir, "Radiohead - OK Computer.md")
+	album, err := os.ReadFile(albumPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(album), "## Album info") || !strings.HasSuffix(string(album), "## Tracks\n\n- [[Radiohead - OK Computer - Airbag]]\n") {
+		t.Errorf("got album note:\n%s", album)
+	}
+	if err := os.WriteFile(albumPath, append(album, "My notes"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.MusicInfo.track = "Lucky"
+	if _, err := m.writeVault(); err != nil {
+		t.Fatal(err)
+	}
+	m.writeVault()
+	album, _ = os.ReadFile(albumPath)
+	if !strings.HasSuffix(string(album), "My notes\n- [[Radiohead - OK Computer - Lucky]]\n") {
+		t.Errorf("got album note:\n%s", album)
+	}
+}
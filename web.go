@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webInterval is how often the events stream checks the info for changes.
+const webInterval = time.Second
+
+// webPage is the companion page of stui serve, it follows /events.
+const webPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>stui</title>
+<style>
+body { margin: 0 auto; max-width: 52rem; padding: 1rem 1.5rem 3rem; font: 17px/1.6 system-ui, sans-serif; background: #16161e; color: #d8d8e0; }
+a { color: #8ab4f8; }
+h1 { font-size: 1.5rem; margin-bottom: 0; color: #b8ffcb; }
+#status { color: #888; font-size: .9rem; }
+nav a { margin-right: .8rem; font-size: .9rem; }
+section { border-top: 1px solid #333; margin-top: 1.5rem; }
+section h2 { color: #fdff8c; }
+.error { color: #ff7cc8; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #333; padding: .2rem .5rem; }
+pre { white-space: pre-wrap; font: inherit; }
+</style>
+</head>
+<body>
+<h1 id="title">stui</h1>
+<div id="status">Connecting…</div>
+<nav id="nav"></nav>
+<div id="sections"></div>
+<script>
+const el = (tag, props, children) => {
+  const e = Object.assign(document.createElement(tag), props || {});
+  (children || []).forEach(c => e.append(c));
+  return e;
+};
+
+function render(now) {
+  const name = now.artist ? [now.artist, now.album, now.track] : [now.album, now.track];
+  document.getElementById("title").textContent = name.filter(Boolean).join(" - ") || "stui";
+  document.title = (now.track || "stui") + " · stui";
+
+  let status = now.status;
+  if (now.loading) status += " · loading " + now.steps_done + "/" + now.steps;
+  document.getElementById("status").textContent = status;
+
+  const nav = document.getElementById("nav");
+  const sections = document.getElementById("sections");
+  nav.replaceChildren();
+  sections.replaceChildren();
+  const add = (id, title, body) => {
+    nav.append(el("a", {href: "#" + id, textContent: title}));
+    sections.append(el("section", {id: id}, [el("h2", {textContent: title}), body]));
+  };
+
+  now.sections.forEach((s, i) => {
+    const body = el("div");
+    if (s.error) body.append(el("p", {className: "error", textContent: "⚠ " + s.error}));
+    else body.innerHTML = s.html;
+    add("s" + i, s.title, body);
+  });
+  if (now.lyrics) add("lyrics", "Lyrics", el("pre", {textContent: now.lyrics}));
+}
+
+const events = new EventSource("/events");
+events.onmessage = e => render(JSON.parse(e.data));
+events.onerror = () => { document.getElementById("status").textContent = "Disconnected, retrying…"; };
+</script>
+</body>
+</html>
+`
+
+// webSection is a section with its markdown converted for the page.
+type webSection struct {
+	Title string `json:"title"`
+	HTML  string `json:"html"`
+	Error string `json:"error,omitempty"`
+}
+
+// webState is what the page shows.
+type webState struct {
+	Status    string       `json:"status"`
+	Loading   bool         `json:"loading"`
+	Steps     int          `json:"steps"`
+	StepsDone int          `json:"steps_done"`
+	Artist    string       `json:"artist"`
+	Album     string       `json:"album"`
+	Track     string       `json:"track"`
+	Sections  []webSection `json:"sections"`
+	Lyrics    string       `json:"lyrics,omitempty"`
+}
+
+func (s *server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if !allowGet(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, webPage)
+}
+
+// webState converts the current info for the page.
+func (s *server) webState() (webState, error) {
+	now := s.now()
+	state := webState{
+		Status:    now.Status,
+		Loading:   now.Loading,
+		Steps:     now.Steps,
+		StepsDone: now.StepsDone,
+		Artist:    now.Artist,
+		Album:     now.Album,
+		Track:     now.Track,
+		Sections:  []webSection{},
+		Lyrics:    now.Lyrics,
+	}
+	for _, section := range now.Sections {
+		html, err := markdownBody([]byte(section.Content))
+		if err != nil {
+			return webState{}, err
+		}
+		state.Sections = append(state.Sections, webSection{Title: section.Title, HTML: html, Error: section.Error})
+	}
+	return state, nil
+}
+
+// handleEvents streams the page state as server-sent events, a new event
+// is sent when it changes.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(webInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		state, err := s.webState()
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(data, last) {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+			last = data
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookTimeout is how long a webhook may take to answer.
+const webhookTimeout = 10 * time.Second
+
+// webhookFormats are the payloads a webhook can post.
+var webhookFormats = []string{"discord", "slack", "json"}
+
+// Webhook posts the track to a chat channel when it changes in watch mode or
+// stui serve. Format is discord, slack or json, it is guessed from the URL
+// when empty.
+type Webhook struct {
+	URL    string `toml:"url"`
+	Format string `toml:"format"`
+}
+
+// webhooks are the webhooks of the config file.
+var webhooks []Webhook
+
+// format is the payload of the webhook, the Discord and Slack ones are
+// recognized by their URL.
+func (w Webhook) format() string {
+	switch {
+	case w.Format != "":
+		return w.Format
+	case strings.Contains(w.URL, "discord.com/api/webhooks/") || strings.Contains(w.URL, "discordapp.com/api/webhooks/"):
+		return "discord"
+	case strings.Contains(w.URL, "hooks.slack.com/"):
+		return "slack"
+	}
+	return "json"
+}
+
+// trackAnnouncement is what the notifications and webhooks say of a track.
+type trackAnnouncement struct {
+	info    MusicInfo
+	title   string
+	summary string
+}
+
+// payload is the body of the webhook for a.
+func (w Webhook) payload(a trackAnnouncement) any {
+	switch w.format() {
+	case "discord":
+		embed := map[string]any{"title": a.title, "description": a.summary}
+		if a.info.album != "" && !a.info.isPodcast() {
+			embed["footer"] = map[string]string{"text": a.info.album}
+		}
+		return map[string]any{"username": "stui", "embeds": []any{embed}}
+	case "slack":
+		text := "*" + a.title + "*"
+		if a.info.album != "" && !a.info.isPodcast() {
+			text += "\n_" + a.info.album + "_"
+		}
+		if a.summary != "" {
+			text += "\n" + a.summary
+		}
+		return map[string]string{"text": text}
+	}
+	return map[string]string{"artist": a.info.artist, "album": a.info.album, "track": a.info.track, "summary": a.summary}
+}
+
+func (w Webhook) post(a trackAnnouncement) error {
+	data, err := json.Marshal(w.payload(a))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postWebhooks posts a to the webhooks in the background, their failures
+// are only logged for -debug.
+func postWebhooks(a trackAnnouncement) {
+	for _, w := range webhooks {
+		w := w
+		goSafe(func() {
+			if err := w.post(a); err != nil {
+				debugLog("webhook", "format", w.format(), "error", err)
+			}
+		})
+	}
+}
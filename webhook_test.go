@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookFormat(t *testing.T) {
+	for _, tt := range []struct {
+		webhook Webhook
+		want    string
+	}{
+		{Webhook{URL: "https://discord.com/api/webhooks/1/abc"}, "discord"},
+		{Webhook{URL: "https://discordapp.com/api/webhooks/1/abc"}, "discord"},
+		{Webhook{URL: "https://hooks.slack.com/services/T/B/x"}, "slack"},
+		{Webhook{URL: "https://example.com/now-playing"}, "json"},
+		{Webhook{URL: "https://example.com/now-playing", Format: "slack"}, "slack"},
+	} {
+		if got := tt.webhook.format(); got != tt.want {
+			t.Errorf("%+v.format() = %q, want %q", tt.webhook, got, tt.want)
+		}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	m := setupTest(t, PlayerPlaying)
+
+	bodies := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- r.URL.Path + " " + string(body)
+	}))
+	defer server.Close()
+	webhooks = []Webhook{
+		{URL: server.URL + "/discord", Format: "discord"},
+		{URL: server.URL + "/slack", Format: "slack"},
+		{URL: server.URL + "/json"},
+	}
+	defer func() { webhooks = nil }()
+
+	m.notifyTrackChange(context.Background(), testTrack)
+	summary := `stub answer for: In one short sentence, tease the song \"Airbag\" by Radiohead for someone who just started playing it. Answer only with the sentence.`
+	want := map[string]string{
+		"/discord": `{"embeds":[{"description":"` + summary + `","footer":{"text":"OK Computer"},"title":"♪ Radiohead – Airbag"}],"username":"stui"}`,
+		"/slack":   `{"text":"*♪ Radiohead – Airbag*\n_OK Computer_\n` + summary + `"}`,
+		"/json":    `{"album":"OK Computer","artist":"Radiohead","summary":"` + summary + `","track":"Airbag"}`,
+	}
+	for range want {
+		select {
+		case got := <-bodies:
+			path, body, _ := strings.Cut(got, " ")
+			var payload any
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				t.Fatalf("%s got %q: %v", path, body, err)
+			}
+			// Marshaled again for the sorted keys.
+			again, _ := json.Marshal(payload)
+			if string(again) != strings.ReplaceAll(want[path], "\n", `\n`) {
+				t.Errorf("%s got %s", path, again)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the webhooks were not posted")
+		}
+	}
+}
+
+func TestLoadConfigWebhooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	for config, want := range map[string]string{
+		"[[webhooks]]\nurl = \"https://hooks.slack.com/services/T/B/x\"\n":  "",
+		"[[webhooks]]\nurl = \"https://example.com\"\nformat = \"json\"\n":  "",
+		"[[webhooks]]\nformat = \"discord\"\n":                              "need a url",
+		"[[webhooks]]\nurl = \"https://example.com\"\nformat = \"teams\"\n": "unknown webhook format",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, true)
+		if (want == "" && err != nil) || (want != "" && (err == nil || !strings.Contains(err.Error(), want))) {
+			t.Errorf("loadConfig(%q) = %v, want %q", config, err, want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var wikipediaURL = "https://en.wikipedia.org"
+
+var errNoWikipedia = errors.New("no Wikipedia article found")
+
+// getWikipedia is replaced in tests.
+var getWikipedia = fetchWikipedia
+
+// WikipediaPage is the summary of an article.
+type WikipediaPage struct {
+	Label   string `json:"-"`
+	Title   string `json:"title"`
+	Type    string `json:"type"`
+	Extract string `json:"extract"`
+	URLs    struct {
+		Desktop struct {
+			Page string `json:"page"`
+		} `json:"desktop"`
+	} `json:"content_urls"`
+}
+
+// Words in the short description of an article that tell it is about music,
+// the search matches other articles with the same name first.
+var (
+	albumWords  = []string{"album", "extended play", "soundtrack", "mixtape"}
+	artistWords = []string{"band", "musician", "singer", "rapper", "group", "duo", "composer", "DJ", "producer", "songwriter", "artist", "orchestra"}
+)
+
+func wikipediaGet(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wikipediaURL+path, nil)
+	if err != nil {
+		return err
+	}
+	// Wikimedia asks clients to identify themselves.
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errNoWikipedia
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "wikipedia: status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// findArticle searches query and returns the key of the first article whose
+// description has one of words.
+func findArticle(ctx context.Context, query string, words []string) (string, error) {
+	var r struct {
+		Pages []struct {
+			Key         string `json:"key"`
+			Description string `json:"description"`
+		} `json:"pages"`
+	}
+	if err := wikipediaGet(ctx, "/w/rest.php/v1/search/page?limit=5&q="+url.QueryEscape(query), &r); err != nil {
+		return "", err
+	}
+
+	for _, p := range r.Pages {
+		for _, w := range words {
+			if strings.Contains(strings.ToLower(p.Description), strings.ToLower(w)) {
+				return p.Key, nil
+			}
+		}
+	}
+	return "", errNoWikipedia
+}
+
+// articleSummary returns the intro of the article with key.
+func articleSummary(ctx context.Context, key string) (*WikipediaPage, error) {
+	var p WikipediaPage
+	if err := wikipediaGet(ctx, "/api/rest_v1/page/summary/"+url.PathEscape(key), &p); err != nil {
+		return nil, err
+	}
+	if p.Type == "disambiguation" || p.Extract == "" {
+		return nil, errNoWikipedia
+	}
+	return &p, nil
+}
+
+// fetchWikipedia returns the articles of the album and the artist, those
+// Wikipedia does not have are left out.
+func fetchWikipedia(ctx context.Context, info MusicInfo) ([]WikipediaPage, error) {
+	type lookup struct {
+		label string
+		query string
+		words []string
+	}
+	lookups := []lookup{{label: "Artist", query: info.artist, words: artistWords}}
+	if info.album != "" {
+		lookups = append([]lookup{{label: "Album", query: info.album + " " + info.artist + " album", words: albumWords}}, lookups...)
+	}
+
+	var pages []WikipediaPage
+	for _, l := range lookups {
+		key, err := findArticle(ctx, l.query, l.words)
+		if errors.Is(err, errNoWikipedia) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := articleSummary(ctx, key)
+		if errors.Is(err, errNoWikipedia) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		page.Label = l.label
+		pages = append(pages, *page)
+	}
+
+	if len(pages) == 0 {
+		return nil, errNoWikipedia
+	}
+	return pages, nil
+}
+
+// wikipediaSection renders the intros of the album and artist articles.
+func wikipediaSection(ctx context.Context, info MusicInfo) (string, error) {
+	pages, err := getWikipedia(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, p := range pages {
+		part := fmt.Sprintf("### %s: %s\n\n%s", p.Label, p.Title, p.Extract)
+		if p.URLs.Desktop.Page != "" {
+			part += "\n\n[Read more on Wikipedia](" + p.URLs.Desktop.Page + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchWikipedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/w/rest.php/v1/search/page":
+			switch q := r.URL.Query().Get("q"); q {
+			case "OK Computer Radiohead album":
+				w.Write([]byte(`{"pages":[{"key":"OK_Computer","description":"1997 studio album by Radiohead"}]}`))
+			case "Radiohead":
+				// The first match is not about the band.
+				w.Write([]byte(`{"pages":[{"key":"Radiohead_(disambiguation)","description":"Topics referred to by the same term"},
+					{"key":"Radiohead","description":"English rock band"}]}`))
+			default:
+				t.Errorf("got query %q", q)
+			}
+		case "/api/rest_v1/page/summary/OK_Computer":
+			w.Write([]byte(`{"title":"OK Computer","type":"standard","extract":"OK Computer is the third studio album.",
+				"content_urls":{"desktop":{"page":"https://en.wikipedia.org/wiki/OK_Computer"}}}`))
+		case "/api/rest_v1/page/summary/Radiohead":
+			w.Write([]byte(`{"title":"Radiohead","type":"standard","extract":"Radiohead are an English rock band.",
+				"content_urls":{"desktop":{"page":"https://en.wikipedia.org/wiki/Radiohead"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(u string) { wikipediaURL = u }(wikipediaURL)
+	wikipediaURL = server.URL
+
+	got, err := wikipediaSection(context.Background(), testTrack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"### Album: OK Computer\n\nOK Computer is the third studio album.",
+		"[Read more on Wikipedia](https://en.wikipedia.org/wiki/OK_Computer)",
+		"### Artist: Radiohead\n\nRadiohead are an English rock band.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("section does not include %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "### Album") > strings.Index(got, "### Artist") {
+		t.Errorf("the album does not come first:\n%s", got)
+	}
+}